@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/tanydotai/tanyai/backend/internal/ai"
+	"github.com/tanydotai/tanyai/backend/internal/eval"
+)
+
+func main() {
+	var (
+		suitePath      = flag.String("suite", "eval/suite.yaml", "suite file (.yaml, .yml or .json)")
+		kbPath         = flag.String("kb", "", "knowledge base JSON fixture; overrides the suite's knowledgeBase")
+		providerName   = flag.String("provider", "replay", "provider answering the questions: replay, mock or gemini")
+		model          = flag.String("model", os.Getenv("GEMINI_MODEL"), "model for the gemini provider")
+		cassetteDir    = flag.String("cassettes", "eval/cassettes", "cassette directory replayed by -provider replay, or written by -record")
+		record         = flag.Bool("record", false, "record the live provider's responses to -cassettes")
+		allowStale     = flag.Bool("allow-stale", false, "skip cases whose prompt has no recording instead of failing them")
+		judgeName      = flag.String("judge", "", "provider grading answers as an LLM judge: mock or gemini (default off)")
+		judgeModel     = flag.String("judge-model", "", "model for the judge provider")
+		judgeThreshold = flag.Int("judge-threshold", eval.DefaultJudgeThreshold, "lowest passing judge score (1-5)")
+		maxTokens      = flag.Int("max-tokens", eval.DefaultMaxTokens, "maximum output tokens per answer")
+		temperature    = flag.Float64("temperature", eval.DefaultTemperature, "sampling temperature")
+		tokenBudget    = flag.Int("token-budget", 0, "prompt token budget; 0 uses PROMPT_TOKEN_BUDGET or the default")
+		junitPath      = flag.String("junit", "", "write a JUnit XML report to this file")
+		jsonPath       = flag.String("json", "", "write a JSON report to this file; without -junit or -json the JSON report goes to stdout")
+	)
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	suite, err := eval.LoadSuite(*suitePath)
+	if err != nil {
+		log.Fatalf("load suite: %v", err)
+	}
+	fixture := *kbPath
+	if fixture == "" {
+		fixture = suite.KnowledgeBasePath()
+	}
+	if fixture == "" {
+		log.Fatalf("no knowledge base fixture: set knowledgeBase in the suite or pass -kb")
+	}
+	base, err := eval.LoadKnowledgeBase(fixture)
+	if err != nil {
+		log.Fatalf("load knowledge base: %v", err)
+	}
+
+	runner := &eval.Runner{
+		ProviderName: strings.ToLower(*providerName),
+		Model:        *model,
+		MaxTokens:    *maxTokens,
+		Temperature:  float32(*temperature),
+		AllowStale:   *allowStale,
+	}
+	runner.Prompt.TokenBudget = *tokenBudget
+
+	switch runner.ProviderName {
+	case "replay":
+		if *record {
+			log.Fatalf("-record needs a live -provider")
+		}
+		cassettes, err := ai.NewReplay(*cassetteDir)
+		if err != nil {
+			log.Fatalf("load cassettes: %v", err)
+		}
+		runner.Provider = cassettes
+		runner.Model = ""
+	default:
+		provider, err := newProvider(runner.ProviderName, *model)
+		if err != nil {
+			log.Fatalf("provider: %v", err)
+		}
+		runner.Provider = provider
+		if *record {
+			runner.Provider = ai.NewRecorder(provider, *cassetteDir)
+		}
+	}
+
+	if *judgeName != "" {
+		judge, err := newProvider(strings.ToLower(*judgeName), *judgeModel)
+		if err != nil {
+			log.Fatalf("judge: %v", err)
+		}
+		runner.Judge = &eval.Judge{Provider: judge, Threshold: *judgeThreshold}
+	}
+
+	report := runner.Run(ctx, suite, base)
+	if *record {
+		recorded := 0
+		for _, c := range report.Cases {
+			if c.Error == "" {
+				recorded++
+			}
+		}
+		log.Printf("recorded %d responses to %s", recorded, *cassetteDir)
+	}
+
+	if *junitPath != "" {
+		if err := writeReport(*junitPath, report.WriteJUnit); err != nil {
+			log.Fatalf("write junit report: %v", err)
+		}
+	}
+	if *jsonPath != "" {
+		if err := writeReport(*jsonPath, report.WriteJSON); err != nil {
+			log.Fatalf("write json report: %v", err)
+		}
+	}
+	if *junitPath == "" && *jsonPath == "" {
+		if err := report.WriteJSON(os.Stdout); err != nil {
+			log.Fatalf("write json report: %v", err)
+		}
+	}
+
+	log.Printf("suite %s: %d passed, %d failed, %d skipped", report.Suite, report.Passed, report.Failed, report.Skipped)
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// newProvider builds a live provider from the same environment variables as the API.
+func newProvider(name, model string) (ai.Provider, error) {
+	switch name {
+	case "mock":
+		return ai.NewMock(), nil
+	case "gemini":
+		key := strings.TrimSpace(os.Getenv("GOOGLE_GENAI_API_KEY"))
+		if key == "" {
+			return nil, errors.New("GOOGLE_GENAI_API_KEY is empty")
+		}
+		return ai.NewGemini(key, model), nil
+	default:
+		return nil, fmt.Errorf("unsupported provider %q", name)
+	}
+}
+
+func writeReport(path string, write func(io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
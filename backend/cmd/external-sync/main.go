@@ -19,6 +19,7 @@ import (
 	"github.com/tanydotai/tanyai/backend/internal/models"
 	"github.com/tanydotai/tanyai/backend/internal/repos"
 	"github.com/tanydotai/tanyai/backend/internal/services/ingest"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
 )
 
 func main() {
@@ -46,13 +47,48 @@ func main() {
 	itemRepo := repos.NewExternalItemRepository(database)
 	ingestService := ingest.NewService(cfg.External.HTTPTimeout, cfg.External.RateLimitRPM, cfg.External.DomainAllowlist)
 
-	if err := ensureDefaultSources(ctx, sourceRepo, cfg.External.SourcesDefault); err != nil {
-		log.Fatalf("ensure defaults: %v", err)
+	tenants, err := repos.NewTenantRepository(database).List(ctx)
+	if err != nil {
+		log.Fatalf("list tenants: %v", err)
+	}
+
+	synced, err := syncTenants(ctx, tenants, cfg.External.SourcesDefault, sourceRepo, itemRepo, ingestService)
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	if err := runSync(ctx, sourceRepo, itemRepo, ingestService); err != nil {
-		log.Fatalf("sync failed: %v", err)
+	payload, err := json.MarshalIndent(struct {
+		CompletedAt time.Time    `json:"completedAt"`
+		Results     []syncResult `json:"results"`
+	}{CompletedAt: time.Now(), Results: synced}, "", "  ")
+	if err != nil {
+		log.Fatalf("encode results: %v", err)
 	}
+	fmt.Println(string(payload))
+}
+
+// syncTenants syncs the sources of every tenant. EXTERNAL_SOURCES_DEFAULT belongs to the
+// deployment owner, so it is seeded into the default tenant only, as the API does at
+// startup; other tenants sync just the sources they added themselves.
+func syncTenants(ctx context.Context, tenants []models.Tenant, seeds []config.ExternalSourceSeed, sourceRepo repos.ExternalSourceRepository, itemRepo repos.ExternalItemRepository, svc *ingest.Service) ([]syncResult, error) {
+	if err := ensureDefaultSources(tenant.WithID(ctx, tenant.DefaultID), sourceRepo, seeds); err != nil {
+		return nil, fmt.Errorf("ensure defaults: %w", err)
+	}
+
+	// Source repositories are scoped to the tenant in the context, so each tenant is
+	// synced separately.
+	synced := make([]syncResult, 0)
+	for _, t := range tenants {
+		results, err := runSync(tenant.WithID(ctx, t.ID), sourceRepo, itemRepo, svc)
+		if err != nil {
+			return nil, fmt.Errorf("sync failed for tenant %s: %w", t.Slug, err)
+		}
+		for i := range results {
+			results[i].Tenant = t.Slug
+		}
+		synced = append(synced, results...)
+	}
+	return synced, nil
 }
 
 func ensureDefaultSources(ctx context.Context, repo repos.ExternalSourceRepository, seeds []config.ExternalSourceSeed) error {
@@ -80,7 +116,8 @@ func ensureDefaultSources(ctx context.Context, repo repos.ExternalSourceReposito
 	return repo.EnsureDefaults(ctx, defaults)
 }
 
-func runSync(ctx context.Context, sourceRepo repos.ExternalSourceRepository, itemRepo repos.ExternalItemRepository, svc *ingest.Service) error {
+// runSync syncs every enabled external source of the tenant in ctx.
+func runSync(ctx context.Context, sourceRepo repos.ExternalSourceRepository, itemRepo repos.ExternalItemRepository, svc *ingest.Service) ([]syncResult, error) {
 	page := 1
 	limit := 100
 	synced := make([]syncResult, 0)
@@ -89,10 +126,11 @@ func runSync(ctx context.Context, sourceRepo repos.ExternalSourceRepository, ite
 		params := repos.ListParams{Page: page, Limit: limit, SortField: "name", SortDir: "asc"}
 		sources, total, err := sourceRepo.List(ctx, params)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		for _, source := range sources {
-			if !source.Enabled {
+			// Document sources are filled by uploads and have nothing to fetch.
+			if !source.Enabled || source.SourceType == repos.DocumentSourceType {
 				continue
 			}
 			parsed, err := normalizeBaseURL(source.BaseURL)
@@ -141,19 +179,11 @@ func runSync(ctx context.Context, sourceRepo repos.ExternalSourceRepository, ite
 		}
 		page++
 	}
-
-	payload, err := json.MarshalIndent(struct {
-		CompletedAt time.Time    `json:"completedAt"`
-		Results     []syncResult `json:"results"`
-	}{CompletedAt: time.Now(), Results: synced}, "", "  ")
-	if err != nil {
-		return err
-	}
-	fmt.Println(string(payload))
-	return nil
+	return synced, nil
 }
 
 type syncResult struct {
+	Tenant string `json:"tenant"`
 	ID     string `json:"id"`
 	Name   string `json:"name"`
 	Status string `json:"status"`
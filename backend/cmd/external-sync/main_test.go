@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/tanydotai/tanyai/backend/internal/config"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/repos"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
+)
+
+// sourceRepoStub keeps external sources per tenant.
+type sourceRepoStub struct {
+	repos.ExternalSourceRepository
+	sources map[uuid.UUID][]models.ExternalSource
+}
+
+func (s *sourceRepoStub) List(ctx context.Context, _ repos.ListParams) ([]models.ExternalSource, int64, error) {
+	sources := s.sources[tenant.ID(ctx)]
+	return sources, int64(len(sources)), nil
+}
+
+func (s *sourceRepoStub) EnsureDefaults(ctx context.Context, defaults []models.ExternalSource) error {
+	id := tenant.ID(ctx)
+	s.sources[id] = append(s.sources[id], defaults...)
+	return nil
+}
+
+func TestSyncTenantsSeedsDefaultsIntoDefaultTenantOnly(t *testing.T) {
+	other := models.Tenant{ID: uuid.New(), Slug: "other"}
+	repo := &sourceRepoStub{sources: map[uuid.UUID][]models.ExternalSource{}}
+	seeds := []config.ExternalSourceSeed{{Name: "owner.example", BaseURL: "https://owner.example"}}
+
+	results, err := syncTenants(context.Background(), []models.Tenant{{ID: tenant.DefaultID, Slug: tenant.DefaultSlug}, other}, seeds, repo, nil, nil)
+	if err != nil {
+		t.Fatalf("syncTenants returned error: %v", err)
+	}
+	if got := repo.sources[tenant.DefaultID]; len(got) != 1 || got[0].BaseURL != "https://owner.example" {
+		t.Fatalf("expected the default tenant to be seeded, got %+v", got)
+	}
+	if got := repo.sources[other.ID]; len(got) != 0 {
+		t.Fatalf("other tenants must not receive the owner's default sources, got %+v", got)
+	}
+	// Seeded sources are disabled unless configured otherwise, so nothing is synced.
+	if len(results) != 0 {
+		t.Fatalf("unexpected results %+v", results)
+	}
+}
+
+func TestRunSyncSkipsDocumentSources(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), uuid.New())
+	repo := &sourceRepoStub{sources: map[uuid.UUID][]models.ExternalSource{
+		tenant.ID(ctx): {{ID: uuid.New(), Name: "Dokumen", BaseURL: "documents://uploads", SourceType: repos.DocumentSourceType, Enabled: true}},
+	}}
+
+	// A nil ingest service panics if the document source is fetched.
+	results, err := runSync(ctx, repo, nil, nil)
+	if err != nil {
+		t.Fatalf("runSync returned error: %v", err)
+	}
+	if len(results) != 0 {
+		t.Fatalf("document sources must not be synced, got %+v", results)
+	}
+}
@@ -0,0 +1,142 @@
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrCassetteMiss is returned by Replay for prompts that were never recorded.
+var ErrCassetteMiss = errors.New("no recorded response for prompt")
+
+// Interaction is one recorded request/response pair. A cassette directory holds one
+// interaction per file, named after the prompt hash, so re-recording a single prompt
+// produces a one-file diff.
+type Interaction struct {
+	PromptHash  string              `json:"promptHash"`
+	Prompt      string              `json:"prompt"`
+	MaxTokens   int                 `json:"maxTokens"`
+	Temperature float32             `json:"temperature"`
+	Response    InteractionResponse `json:"response"`
+}
+
+// InteractionResponse is the recorded provider output.
+type InteractionResponse struct {
+	Text  string `json:"text"`
+	Usage Usage  `json:"usage"`
+}
+
+// PromptHash returns the key interactions are stored under.
+func PromptHash(prompt string) string {
+	sum := sha256.Sum256([]byte(prompt))
+	return hex.EncodeToString(sum[:])
+}
+
+// Recorder wraps a live provider and writes every successful generation to a cassette
+// directory. It is meant for development: point tests at the directory with Replay.
+type Recorder struct {
+	Provider Provider
+	Dir      string
+}
+
+// NewRecorder wraps provider, recording into dir.
+func NewRecorder(provider Provider, dir string) *Recorder {
+	return &Recorder{Provider: provider, Dir: dir}
+}
+
+// Generate forwards the request and records the response. Provider errors are not
+// recorded.
+func (r *Recorder) Generate(ctx context.Context, req Request) (Response, error) {
+	resp, err := r.Provider.Generate(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+	interaction := Interaction{
+		PromptHash:  PromptHash(req.Prompt),
+		Prompt:      req.Prompt,
+		MaxTokens:   req.MaxTokens,
+		Temperature: req.Temperature,
+		Response:    InteractionResponse{Text: resp.Text, Usage: resp.Usage},
+	}
+	if err := writeInteraction(r.Dir, interaction); err != nil {
+		return resp, fmt.Errorf("record interaction: %w", err)
+	}
+	return resp, nil
+}
+
+func writeInteraction(dir string, interaction Interaction) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(interaction, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Write to a temporary file first so concurrent requests never leave a torn cassette.
+	tmp, err := os.CreateTemp(dir, ".interaction-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, interaction.PromptHash+".json"))
+}
+
+// Replay serves recorded interactions and fails on prompts it has not seen, so a
+// changed prompt shows up as a test failure instead of a silently reused answer.
+type Replay struct {
+	interactions map[string]Interaction
+}
+
+// NewReplay loads every interaction in dir.
+func NewReplay(dir string) (*Replay, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	replay := &Replay{interactions: make(map[string]Interaction, len(entries))}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var interaction Interaction
+		if err := json.Unmarshal(data, &interaction); err != nil {
+			return nil, fmt.Errorf("parse cassette %s: %w", entry.Name(), err)
+		}
+		// Key on the stored prompt so hand-edited prompts stay consistent with the hash.
+		replay.interactions[PromptHash(interaction.Prompt)] = interaction
+	}
+	return replay, nil
+}
+
+// Len reports how many interactions were loaded.
+func (r *Replay) Len() int {
+	return len(r.interactions)
+}
+
+// Generate returns the recorded response for the request prompt.
+func (r *Replay) Generate(_ context.Context, req Request) (Response, error) {
+	hash := PromptHash(req.Prompt)
+	interaction, ok := r.interactions[hash]
+	if !ok {
+		return Response{}, fmt.Errorf("%w (hash %s)", ErrCassetteMiss, hash)
+	}
+	return Response{Text: interaction.Response.Text, Usage: interaction.Response.Usage}, nil
+}
@@ -0,0 +1,79 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type fixedProvider struct {
+	text  string
+	err   error
+	calls int
+}
+
+func (p *fixedProvider) Generate(context.Context, Request) (Response, error) {
+	p.calls++
+	if p.err != nil {
+		return Response{}, p.err
+	}
+	return Response{Text: p.text, Usage: Usage{PromptTokens: 4, OutputTokens: 2, TotalTokens: 6}}, nil
+}
+
+func TestRecorderAndReplayRoundTrip(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cassettes")
+	recorder := NewRecorder(&fixedProvider{text: "Halo"}, dir)
+
+	if _, err := recorder.Generate(context.Background(), Request{Prompt: "Sapa saya", MaxTokens: 64, Temperature: 0.5}); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, PromptHash("Sapa saya")+".json")); err != nil {
+		t.Fatalf("expected cassette keyed by prompt hash: %v", err)
+	}
+
+	replay, err := NewReplay(dir)
+	if err != nil {
+		t.Fatalf("load replay: %v", err)
+	}
+	if replay.Len() != 1 {
+		t.Fatalf("expected one interaction, got %d", replay.Len())
+	}
+	resp, err := replay.Generate(context.Background(), Request{Prompt: "Sapa saya"})
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if resp.Text != "Halo" || resp.Usage.TotalTokens != 6 {
+		t.Fatalf("unexpected replayed response: %+v", resp)
+	}
+
+	if _, err := replay.Generate(context.Background(), Request{Prompt: "Sapa saya!"}); !errors.Is(err, ErrCassetteMiss) {
+		t.Fatalf("expected a cassette miss for an unknown prompt, got %v", err)
+	}
+}
+
+func TestRecorderSkipsFailedGenerations(t *testing.T) {
+	dir := t.TempDir()
+	recorder := NewRecorder(&fixedProvider{err: errors.New("quota")}, dir)
+	if _, err := recorder.Generate(context.Background(), Request{Prompt: "x"}); err == nil {
+		t.Fatalf("expected the provider error")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Fatalf("failed generations must not be recorded, found %d files", len(entries))
+	}
+}
+
+func TestNewReplayRejectsBrokenCassettes(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := NewReplay(dir); err == nil {
+		t.Fatalf("expected a parse error")
+	}
+	if _, err := NewReplay(filepath.Join(dir, "missing")); err == nil {
+		t.Fatalf("expected an error for a missing directory")
+	}
+}
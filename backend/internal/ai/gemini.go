@@ -13,16 +13,18 @@ import (
 )
 
 const (
-	defaultGeminiEndpoint = "https://generativelanguage.googleapis.com"
-	defaultGeminiModel    = "gemini-2.5-pro"
+	defaultGeminiEndpoint       = "https://generativelanguage.googleapis.com"
+	defaultGeminiModel          = "gemini-2.5-pro"
+	defaultGeminiEmbeddingModel = "text-embedding-004"
 )
 
 // Gemini implements the Provider interface using Google Gemini's REST API.
 type Gemini struct {
-	Key      string
-	Model    string
-	Client   *http.Client
-	Endpoint string
+	Key            string
+	Model          string
+	EmbeddingModel string
+	Client         *http.Client
+	Endpoint       string
 }
 
 // NewGemini constructs a Gemini provider with the supplied API key and model.
@@ -33,10 +35,11 @@ func NewGemini(key, model string) *Gemini {
 	}
 
 	return &Gemini{
-		Key:      strings.TrimSpace(key),
-		Model:    model,
-		Client:   &http.Client{Timeout: 15 * time.Second},
-		Endpoint: defaultGeminiEndpoint,
+		Key:            strings.TrimSpace(key),
+		Model:          model,
+		EmbeddingModel: defaultGeminiEmbeddingModel,
+		Client:         &http.Client{Timeout: 15 * time.Second},
+		Endpoint:       defaultGeminiEndpoint,
 	}
 }
 
@@ -152,6 +155,11 @@ func (g *Gemini) Generate(ctx context.Context, r Request) (Response, error) {
 			} `json:"content"`
 			FinishReason string `json:"finishReason"`
 		} `json:"candidates"`
+		UsageMetadata struct {
+			PromptTokenCount     int `json:"promptTokenCount"`
+			CandidatesTokenCount int `json:"candidatesTokenCount"`
+			TotalTokenCount      int `json:"totalTokenCount"`
+		} `json:"usageMetadata"`
 	}
 
 	if err := json.NewDecoder(bytes.NewReader(bodyBytes)).Decode(&decoded); err != nil {
@@ -175,5 +183,80 @@ func (g *Gemini) Generate(ctx context.Context, r Request) (Response, error) {
 		return Response{}, errors.New("empty response from gemini")
 	}
 
-	return Response{Text: text}, nil
+	return Response{Text: text, Usage: Usage{
+		PromptTokens: decoded.UsageMetadata.PromptTokenCount,
+		OutputTokens: decoded.UsageMetadata.CandidatesTokenCount,
+		TotalTokens:  decoded.UsageMetadata.TotalTokenCount,
+	}}, nil
+}
+
+// Embed returns the embedding vector for text using the Gemini embedContent API.
+func (g *Gemini) Embed(ctx context.Context, text string) ([]float32, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, errors.New("text is required")
+	}
+	if g == nil {
+		return nil, errors.New("gemini provider is not configured")
+	}
+	if strings.TrimSpace(g.Key) == "" {
+		return nil, errors.New("missing GOOGLE_GENAI_API_KEY")
+	}
+
+	model := g.EmbeddingModel
+	if model == "" {
+		model = defaultGeminiEmbeddingModel
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"content": map[string]any{
+			"parts": []any{map[string]any{"text": text}},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	endpoint := g.Endpoint
+	if endpoint == "" {
+		endpoint = defaultGeminiEndpoint
+	}
+	endpoint = strings.TrimSuffix(endpoint, "/")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/v1beta/models/%s:embedContent", endpoint, model), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	query := req.URL.Query()
+	query.Set("key", g.Key)
+	req.URL.RawQuery = query.Encode()
+	req.Header.Set("Content-Type", "application/json")
+
+	client := g.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 8<<10))
+		return nil, fmt.Errorf("gemini embed failed: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	var decoded struct {
+		Embedding struct {
+			Values []float32 `json:"values"`
+		} `json:"embedding"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode embedding: %w", err)
+	}
+	if len(decoded.Embedding.Values) == 0 {
+		return nil, errors.New("empty embedding from gemini")
+	}
+	return decoded.Embedding.Values, nil
 }
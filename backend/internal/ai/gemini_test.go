@@ -24,6 +24,7 @@ func TestGeminiGenerateSuccess(t *testing.T) {
 					},
 				},
 			},
+			"usageMetadata": map[string]any{"promptTokenCount": 12, "candidatesTokenCount": 3, "totalTokenCount": 15},
 		}
 		_ = json.NewEncoder(w).Encode(response)
 	})
@@ -41,6 +42,9 @@ func TestGeminiGenerateSuccess(t *testing.T) {
 	if resp.Text != "Hello" {
 		t.Fatalf("expected response text, got %q", resp.Text)
 	}
+	if resp.Usage.PromptTokens != 12 || resp.Usage.OutputTokens != 3 || resp.Usage.TotalTokens != 15 {
+		t.Fatalf("expected usage metadata, got %+v", resp.Usage)
+	}
 }
 
 func TestGeminiGenerateHandlesEmptyResponse(t *testing.T) {
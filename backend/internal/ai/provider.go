@@ -11,10 +11,24 @@ type Request struct {
 
 // Response represents a normalized AI generation result.
 type Response struct {
-	Text string
+	Text  string
+	Usage Usage
+}
+
+// Usage reports the tokens a provider billed for a generation. Providers that do not
+// report usage leave it zero.
+type Usage struct {
+	PromptTokens int
+	OutputTokens int
+	TotalTokens  int
 }
 
 // Provider describes the capabilities required from any AI text generator.
 type Provider interface {
 	Generate(ctx context.Context, r Request) (Response, error)
 }
+
+// Embedder describes providers able to turn text into embedding vectors.
+type Embedder interface {
+	Embed(ctx context.Context, text string) ([]float32, error)
+}
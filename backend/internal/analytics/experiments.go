@@ -0,0 +1,169 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+)
+
+// SignificanceLevel is the p-value below which a difference is reported as significant.
+const SignificanceLevel = 0.05
+
+// ErrUnknownVariant is returned when the requested baseline variant has no events.
+var ErrUnknownVariant = errors.New("unknown variant")
+
+// ExperimentReport compares the variants of one experiment.
+type ExperimentReport struct {
+	Experiment string          `json:"experiment"`
+	Baseline   string          `json:"baseline"`
+	RangeStart time.Time       `json:"rangeStart"`
+	RangeEnd   time.Time       `json:"rangeEnd"`
+	Variants   []VariantReport `json:"variants"`
+}
+
+// VariantReport summarises one variant. Success rate is per answered message;
+// conversion rate is the share of chats that produced at least one lead.
+type VariantReport struct {
+	Variant        string  `json:"variant"`
+	Chats          int     `json:"chats"`
+	Messages       int     `json:"messages"`
+	AvgLatencyMS   float64 `json:"avgLatencyMs"`
+	SuccessRate    float64 `json:"successRate"`
+	FeedbackCount  int     `json:"feedbackCount"`
+	AvgFeedback    float64 `json:"avgFeedback"`
+	Conversions    int     `json:"conversions"`
+	ConversionRate float64 `json:"conversionRate"`
+	// VsBaseline compares the variant with the baseline; it is nil for the baseline.
+	VsBaseline *VariantComparison `json:"vsBaseline,omitempty"`
+}
+
+// VariantComparison holds the difference to the baseline for each metric.
+type VariantComparison struct {
+	Latency    MetricComparison `json:"latency"`
+	Success    MetricComparison `json:"success"`
+	Feedback   MetricComparison `json:"feedback"`
+	Conversion MetricComparison `json:"conversion"`
+}
+
+// MetricComparison is a variant minus baseline difference with a two-sided p-value.
+// Rates use a two-proportion z-test and means a Welch z-test; both rely on the normal
+// approximation and need a few dozen samples per variant to be meaningful.
+type MetricComparison struct {
+	Delta       float64 `json:"delta"`
+	PValue      float64 `json:"pValue"`
+	Significant bool    `json:"significant"`
+}
+
+// Experiment reports per-variant metrics of an experiment and compares every variant
+// with baseline. An empty baseline picks the variant named "control", or else the first
+// variant by name.
+func (s *Service) Experiment(ctx context.Context, filter ExperimentFilter, baseline string) (ExperimentReport, error) {
+	if filter.Start.IsZero() {
+		filter.Start = time.Now().AddDate(0, 0, -30)
+	}
+	if filter.End.IsZero() {
+		filter.End = time.Now()
+	}
+
+	rows, err := s.repo.AggregateVariants(ctx, filter)
+	if err != nil {
+		return ExperimentReport{}, err
+	}
+
+	report := ExperimentReport{
+		Experiment: filter.Experiment,
+		RangeStart: filter.Start,
+		RangeEnd:   filter.End,
+		Variants:   make([]VariantReport, 0, len(rows)),
+	}
+	if len(rows) == 0 {
+		if baseline != "" {
+			return ExperimentReport{}, ErrUnknownVariant
+		}
+		return report, nil
+	}
+
+	base := -1
+	for i, row := range rows {
+		if row.Variant == baseline || (baseline == "" && row.Variant == "control") {
+			base = i
+		}
+	}
+	if base < 0 {
+		if baseline != "" {
+			return ExperimentReport{}, ErrUnknownVariant
+		}
+		base = 0
+	}
+	report.Baseline = rows[base].Variant
+
+	for i, row := range rows {
+		variant := VariantReport{
+			Variant:        row.Variant,
+			Chats:          row.Chats,
+			Messages:       row.Messages,
+			AvgLatencyMS:   row.AvgLatencyMS,
+			SuccessRate:    ratio(row.Successes, row.Messages),
+			FeedbackCount:  row.FeedbackCount,
+			AvgFeedback:    row.AvgFeedback,
+			Conversions:    row.ConvertedChats,
+			ConversionRate: ratio(row.ConvertedChats, row.Chats),
+		}
+		if i != base {
+			variant.VsBaseline = compareVariants(rows[base], row)
+		}
+		report.Variants = append(report.Variants, variant)
+	}
+	return report, nil
+}
+
+func compareVariants(base, variant VariantAggregate) *VariantComparison {
+	return &VariantComparison{
+		Latency: welchTest(base.AvgLatencyMS, base.StddevLatencyMS, base.Messages,
+			variant.AvgLatencyMS, variant.StddevLatencyMS, variant.Messages),
+		Success: proportionTest(base.Successes, base.Messages, variant.Successes, variant.Messages),
+		Feedback: welchTest(base.AvgFeedback, base.StddevFeedback, base.FeedbackCount,
+			variant.AvgFeedback, variant.StddevFeedback, variant.FeedbackCount),
+		Conversion: proportionTest(base.ConvertedChats, base.Chats, variant.ConvertedChats, variant.Chats),
+	}
+}
+
+// proportionTest compares x1/n1 with x2/n2 using a pooled two-proportion z-test.
+func proportionTest(x1, n1, x2, n2 int) MetricComparison {
+	p1, p2 := ratio(x1, n1), ratio(x2, n2)
+	result := MetricComparison{Delta: p2 - p1, PValue: 1}
+	if n1 == 0 || n2 == 0 {
+		return result
+	}
+	pooled := float64(x1+x2) / float64(n1+n2)
+	se := math.Sqrt(pooled * (1 - pooled) * (1/float64(n1) + 1/float64(n2)))
+	return withPValue(result, se)
+}
+
+// welchTest compares two means from their sample standard deviations and sizes.
+func welchTest(mean1, sd1 float64, n1 int, mean2, sd2 float64, n2 int) MetricComparison {
+	result := MetricComparison{Delta: mean2 - mean1, PValue: 1}
+	if n1 < 2 || n2 < 2 {
+		return result
+	}
+	se := math.Sqrt(sd1*sd1/float64(n1) + sd2*sd2/float64(n2))
+	return withPValue(result, se)
+}
+
+func withPValue(result MetricComparison, se float64) MetricComparison {
+	if se == 0 || math.IsNaN(se) {
+		return result
+	}
+	z := result.Delta / se
+	result.PValue = math.Erfc(math.Abs(z) / math.Sqrt2)
+	result.Significant = result.PValue < SignificanceLevel
+	return result
+}
+
+func ratio(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total)
+}
@@ -0,0 +1,71 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestExperimentComparesVariantsWithControl(t *testing.T) {
+	repo := &stubRepository{variants: []VariantAggregate{
+		{Variant: "cheap", Chats: 400, Messages: 1000, AvgLatencyMS: 800, StddevLatencyMS: 300, Successes: 990,
+			FeedbackCount: 200, AvgFeedback: 3.9, StddevFeedback: 1, ConvertedChats: 20},
+		{Variant: "control", Chats: 400, Messages: 1000, AvgLatencyMS: 1500, StddevLatencyMS: 400, Successes: 985,
+			FeedbackCount: 200, AvgFeedback: 4.0, StddevFeedback: 1, ConvertedChats: 40},
+	}}
+	service := NewService(repo, 30, true)
+
+	report, err := service.Experiment(context.Background(), ExperimentFilter{Experiment: "pro-vs-flash"}, "")
+	if err != nil {
+		t.Fatalf("experiment: %v", err)
+	}
+	if repo.lastExperiment.Experiment != "pro-vs-flash" || repo.lastExperiment.Start.IsZero() {
+		t.Fatalf("expected the experiment filter with a default range, got %+v", repo.lastExperiment)
+	}
+	if report.Baseline != "control" {
+		t.Fatalf("expected control as the default baseline, got %q", report.Baseline)
+	}
+	control, cheap := report.Variants[1], report.Variants[0]
+	if control.VsBaseline != nil {
+		t.Fatalf("the baseline should not be compared with itself")
+	}
+	if control.ConversionRate != 0.1 || cheap.SuccessRate != 0.99 {
+		t.Fatalf("unexpected rates: %+v %+v", control, cheap)
+	}
+
+	cmp := cheap.VsBaseline
+	if cmp == nil {
+		t.Fatalf("expected a comparison for the challenger")
+	}
+	if cmp.Latency.Delta != -700 || !cmp.Latency.Significant {
+		t.Fatalf("expected a significant latency drop, got %+v", cmp.Latency)
+	}
+	if math.Abs(cmp.Conversion.Delta+0.05) > 1e-9 || !cmp.Conversion.Significant {
+		t.Fatalf("expected a significant conversion drop, got %+v", cmp.Conversion)
+	}
+	if cmp.Success.Significant || cmp.Feedback.Significant {
+		t.Fatalf("small success and feedback differences should not be significant: %+v", cmp)
+	}
+}
+
+func TestExperimentBaselineSelection(t *testing.T) {
+	repo := &stubRepository{variants: []VariantAggregate{{Variant: "a", Chats: 1, Messages: 1}, {Variant: "b"}}}
+	service := NewService(repo, 30, true)
+
+	report, err := service.Experiment(context.Background(), ExperimentFilter{Experiment: "x"}, "")
+	if err != nil || report.Baseline != "a" {
+		t.Fatalf("expected the first variant as baseline, got %q (%v)", report.Baseline, err)
+	}
+	if cmp := report.Variants[1].VsBaseline; cmp == nil || cmp.Success.PValue != 1 || cmp.Latency.Significant {
+		t.Fatalf("comparisons without data should not be significant, got %+v", cmp)
+	}
+
+	report, err = service.Experiment(context.Background(), ExperimentFilter{Experiment: "x"}, "b")
+	if err != nil || report.Baseline != "b" {
+		t.Fatalf("expected the requested baseline, got %q (%v)", report.Baseline, err)
+	}
+	if _, err := service.Experiment(context.Background(), ExperimentFilter{Experiment: "x"}, "c"); !errors.Is(err, ErrUnknownVariant) {
+		t.Fatalf("expected ErrUnknownVariant, got %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package analytics
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -133,3 +134,35 @@ func (h *Handler) Leads(c *gin.Context) {
 	}
 	httpapi.RespondList(c, http.StatusOK, result.Items, page, limit, result.Total)
 }
+
+// Experiment compares the variants of an experiment by name.
+func (h *Handler) Experiment(c *gin.Context) {
+	filter := ExperimentFilter{Experiment: c.Param("name")}
+	if from := c.Query("from"); from != "" {
+		parsed, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "invalid from timestamp", nil)
+			return
+		}
+		filter.Start = parsed
+	}
+	if to := c.Query("to"); to != "" {
+		parsed, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "invalid to timestamp", nil)
+			return
+		}
+		filter.End = parsed
+	}
+
+	report, err := h.service.Experiment(c.Request.Context(), filter, c.Query("baseline"))
+	if err != nil {
+		if errors.Is(err, ErrUnknownVariant) {
+			httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "baseline variant has no events", nil)
+			return
+		}
+		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "failed to load experiment analytics", nil)
+		return
+	}
+	httpapi.RespondData(c, http.StatusOK, report)
+}
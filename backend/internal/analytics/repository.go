@@ -55,6 +55,28 @@ type SummaryAggregate struct {
 	Conversions       int     `db:"conversions"`
 }
 
+// ExperimentFilter selects the events of one experiment.
+type ExperimentFilter struct {
+	Experiment string
+	Start      time.Time
+	End        time.Time
+}
+
+// VariantAggregate summarises chat, feedback and lead events of one experiment variant.
+// Feedback fields count only the latest rating of each chat.
+type VariantAggregate struct {
+	Variant         string  `db:"variant"`
+	Chats           int     `db:"chats"`
+	Messages        int     `db:"messages"`
+	AvgLatencyMS    float64 `db:"avg_latency"`
+	StddevLatencyMS float64 `db:"stddev_latency"`
+	Successes       int     `db:"successes"`
+	FeedbackCount   int     `db:"feedback_count"`
+	AvgFeedback     float64 `db:"avg_feedback"`
+	StddevFeedback  float64 `db:"stddev_feedback"`
+	ConvertedChats  int     `db:"converted_chats"`
+}
+
 // Repository persists analytics related data.
 type Repository interface {
 	InsertEvent(ctx context.Context, event models.AnalyticsEvent) (models.AnalyticsEvent, error)
@@ -62,6 +84,7 @@ type Repository interface {
 	AggregateRange(ctx context.Context, filter RangeFilter) (SummaryAggregate, error)
 	AggregateProviders(ctx context.Context, filter RangeFilter) ([]ProviderAggregate, error)
 	AggregateDaily(ctx context.Context, filter RangeFilter) ([]DailyAggregate, error)
+	AggregateVariants(ctx context.Context, filter ExperimentFilter) ([]VariantAggregate, error)
 	UpsertSummary(ctx context.Context, date time.Time) error
 }
 
@@ -271,6 +294,61 @@ WHERE 1=1`
 	return rows, nil
 }
 
+func (r *repository) AggregateVariants(ctx context.Context, filter ExperimentFilter) ([]VariantAggregate, error) {
+	// Feedback can be sent more than once per chat; only the latest rating counts so a
+	// single visitor cannot weigh a variant's score.
+	const base = `WITH scoped AS (
+    SELECT event_type, metadata, duration_ms, success, timestamp
+    FROM analytics_events
+    WHERE event_type IN ('chat', 'feedback', 'lead') AND metadata->>'experiment' = $1`
+	const rest = `
+), latest_feedback AS (
+    SELECT DISTINCT ON (metadata->>'chat_id') event_type, metadata, duration_ms, success
+    FROM scoped
+    WHERE event_type = 'feedback'
+    ORDER BY metadata->>'chat_id', timestamp DESC
+), events AS (
+    SELECT event_type, metadata, duration_ms, success FROM scoped WHERE event_type <> 'feedback'
+    UNION ALL
+    SELECT event_type, metadata, duration_ms, success FROM latest_feedback
+)
+SELECT
+    metadata->>'variant' AS variant,
+    COUNT(DISTINCT metadata->>'chat_id') FILTER (WHERE event_type = 'chat') AS chats,
+    COUNT(*) FILTER (WHERE event_type = 'chat') AS messages,
+    COALESCE(AVG(duration_ms) FILTER (WHERE event_type = 'chat'), 0) AS avg_latency,
+    COALESCE(STDDEV_SAMP(duration_ms) FILTER (WHERE event_type = 'chat'), 0) AS stddev_latency,
+    COUNT(*) FILTER (WHERE event_type = 'chat' AND success) AS successes,
+    COUNT(*) FILTER (WHERE event_type = 'feedback') AS feedback_count,
+    COALESCE(AVG((metadata->>'score')::float) FILTER (WHERE event_type = 'feedback'), 0) AS avg_feedback,
+    COALESCE(STDDEV_SAMP((metadata->>'score')::float) FILTER (WHERE event_type = 'feedback'), 0) AS stddev_feedback,
+    COUNT(DISTINCT metadata->>'chat_id') FILTER (WHERE event_type = 'lead') AS converted_chats
+FROM events
+GROUP BY variant ORDER BY variant`
+
+	query := strings.Builder{}
+	query.WriteString(base)
+	args := []interface{}{filter.Experiment}
+	add := func(clause string, value interface{}) {
+		args = append(args, value)
+		query.WriteString(" AND ")
+		query.WriteString(fmt.Sprintf(clause, len(args)))
+	}
+	if !filter.Start.IsZero() {
+		add("timestamp >= $%d", filter.Start)
+	}
+	if !filter.End.IsZero() {
+		add("timestamp <= $%d", filter.End)
+	}
+	query.WriteString(rest)
+
+	rows := []VariantAggregate{}
+	if err := r.db.SelectContext(ctx, &rows, query.String(), args...); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
 func (r *repository) UpsertSummary(ctx context.Context, date time.Time) error {
 	const query = `WITH provider_stats AS (
     SELECT
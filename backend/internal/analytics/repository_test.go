@@ -0,0 +1,42 @@
+package analytics
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+func TestAggregateVariantsCountsLatestFeedbackPerChat(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewRepository(sqlx.NewDb(db, "sqlmock"))
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	columns := []string{"variant", "chats", "messages", "avg_latency", "stddev_latency", "successes", "feedback_count", "avg_feedback", "stddev_feedback", "converted_chats"}
+
+	// A chat rated 1 and then 5 must count once, with its latest score, so the range
+	// filter has to apply before the per-chat dedupe.
+	mock.ExpectQuery(`metadata->>'experiment' = \$1 AND timestamp >= \$2\s+\), latest_feedback AS \(\s+`+
+		regexp.QuoteMeta(`SELECT DISTINCT ON (metadata->>'chat_id')`)+`[\s\S]+`+
+		regexp.QuoteMeta(`ORDER BY metadata->>'chat_id', timestamp DESC`)).
+		WithArgs("prompt-v2", start).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("control", 1, 2, 120.0, 0.0, 2, 1, 5.0, 0.0, 0))
+
+	rows, err := repo.AggregateVariants(context.Background(), ExperimentFilter{Experiment: "prompt-v2", Start: start})
+	if err != nil {
+		t.Fatalf("AggregateVariants returned error: %v", err)
+	}
+	if len(rows) != 1 || rows[0].FeedbackCount != 1 || rows[0].AvgFeedback != 5 {
+		t.Fatalf("unexpected aggregates %+v", rows)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
@@ -15,6 +15,8 @@ type stubRepository struct {
 	summary         SummaryAggregate
 	providers       []ProviderAggregate
 	daily           []DailyAggregate
+	variants        []VariantAggregate
+	lastExperiment  ExperimentFilter
 	events          []models.AnalyticsEvent
 	total           int64
 	lastEventFilter EventFilter
@@ -45,6 +47,11 @@ func (s *stubRepository) AggregateDaily(ctx context.Context, filter RangeFilter)
 	return s.daily, nil
 }
 
+func (s *stubRepository) AggregateVariants(ctx context.Context, filter ExperimentFilter) ([]VariantAggregate, error) {
+	s.lastExperiment = filter
+	return s.variants, nil
+}
+
 func (s *stubRepository) UpsertSummary(ctx context.Context, date time.Time) error {
 	s.summaries = append(s.summaries, date)
 	return nil
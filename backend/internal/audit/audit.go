@@ -0,0 +1,178 @@
+// Package audit carries the acting principal through request contexts and describes the
+// changes recorded in the audit_log table.
+package audit
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+)
+
+// Actions recorded in the audit log.
+const (
+	ActionCreate     = "create"
+	ActionUpdate     = "update"
+	ActionDelete     = "delete"
+	ActionReorder    = "reorder"
+	ActionToggle     = "toggle"
+	ActionFeature    = "feature"
+	ActionVisibility = "visibility"
+	ActionUpload     = "upload"
+	ActionRevoke     = "revoke"
+	ActionRoles      = "roles"
+	ActionActivate   = "activate"
+	ActionDeactivate = "deactivate"
+	ActionPublish    = "publish"
+	ActionSchedule   = "schedule"
+	ActionRestore    = "restore"
+	ActionImport     = "import"
+	ActionPurge      = "purge"
+)
+
+// Entity types recorded in the audit log.
+const (
+	EntityProfile      = "profile"
+	EntitySkill        = "skill"
+	EntityService      = "service"
+	EntityProject      = "project"
+	EntityExternalItem = "external_item"
+	EntityUpload       = "upload"
+	EntityUser         = "user"
+	EntityAPIKey       = "api_key"
+	EntityPortfolio    = "portfolio"
+	EntityDocument     = "document"
+)
+
+// Actor identifies who performed a change. A zero Actor stands for the system itself,
+// such as background jobs.
+type Actor struct {
+	UserID   uuid.UUID
+	APIKeyID uuid.UUID
+	Email    string
+	IP       string
+}
+
+type contextKey struct{}
+
+// WithActor returns a copy of ctx carrying the actor.
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, contextKey{}, actor)
+}
+
+// ActorFrom returns the actor stored in ctx.
+func ActorFrom(ctx context.Context) (Actor, bool) {
+	actor, ok := ctx.Value(contextKey{}).(Actor)
+	return actor, ok
+}
+
+// Change describes a single audited mutation. Before is nil for creations and After is
+// nil for deletions.
+type Change struct {
+	Action     string
+	EntityType string
+	EntityID   string
+	Before     any
+	After      any
+}
+
+// Snapshot flattens a model into its column values, keyed by db tag. Fields tagged
+// audit:"-" (password hashes, secrets) are omitted and sql.Null* values are unwrapped.
+// Maps are returned unchanged and nil yields nil.
+func Snapshot(v any) models.JSONB {
+	if v == nil {
+		return nil
+	}
+	switch typed := v.(type) {
+	case models.JSONB:
+		return typed
+	case map[string]any:
+		return models.JSONB(typed)
+	}
+
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	snapshot := models.JSONB{}
+	flatten(value, snapshot)
+	return normalize(snapshot)
+}
+
+func flatten(value reflect.Value, into models.JSONB) {
+	typ := value.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() || field.Tag.Get("audit") == "-" {
+			continue
+		}
+		fieldValue := value.Field(i)
+		if field.Anonymous && fieldValue.Kind() == reflect.Struct {
+			flatten(fieldValue, into)
+			continue
+		}
+		name := strings.Split(field.Tag.Get("db"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		into[name] = columnValue(fieldValue)
+	}
+}
+
+func columnValue(value reflect.Value) any {
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Pointer {
+		if valuer, ok := value.Interface().(driver.Valuer); ok {
+			if v, err := valuer.Value(); err == nil {
+				if raw, isBytes := v.([]byte); isBytes {
+					return string(raw)
+				}
+				return v
+			}
+		}
+	}
+	if value.Kind() == reflect.Pointer && value.IsNil() {
+		return nil
+	}
+	return value.Interface()
+}
+
+// normalize round-trips through JSON so snapshots compare the way they are stored.
+func normalize(snapshot models.JSONB) models.JSONB {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return snapshot
+	}
+	var out models.JSONB
+	if err := json.Unmarshal(data, &out); err != nil {
+		return snapshot
+	}
+	return out
+}
+
+// Diff lists the fields whose values differ between two snapshots as
+// {"field": {"before": ..., "after": ...}}.
+func Diff(before, after models.JSONB) models.JSONB {
+	changes := models.JSONB{}
+	for key, old := range before {
+		if updated, ok := after[key]; !ok || !reflect.DeepEqual(old, updated) {
+			changes[key] = map[string]any{"before": old, "after": after[key]}
+		}
+	}
+	for key, updated := range after {
+		if _, ok := before[key]; !ok {
+			changes[key] = map[string]any{"before": nil, "after": updated}
+		}
+	}
+	return changes
+}
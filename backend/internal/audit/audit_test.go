@@ -0,0 +1,61 @@
+package audit
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+type Account struct {
+	ID       uuid.UUID      `db:"id"`
+	Email    string         `db:"email"`
+	Bio      sql.NullString `db:"bio"`
+	Password string         `db:"password_hash" audit:"-"`
+	internal string
+}
+
+type accountWithRoles struct {
+	Account
+	Roles []string `db:"roles"`
+}
+
+func TestSnapshotOmitsSecretsAndUnwrapsNulls(t *testing.T) {
+	id := uuid.New()
+	snapshot := Snapshot(accountWithRoles{
+		Account: Account{ID: id, Email: "a@example.com", Bio: sql.NullString{String: "hi", Valid: true}, Password: "hash"},
+		Roles:   []string{"admin"},
+	})
+
+	if _, ok := snapshot["password_hash"]; ok {
+		t.Fatalf("expected password hash to be omitted, got %v", snapshot)
+	}
+	if snapshot["id"] != id.String() || snapshot["email"] != "a@example.com" || snapshot["bio"] != "hi" {
+		t.Fatalf("unexpected snapshot %v", snapshot)
+	}
+	if roles, ok := snapshot["roles"].([]any); !ok || len(roles) != 1 || roles[0] != "admin" {
+		t.Fatalf("unexpected roles %v", snapshot["roles"])
+	}
+	if Snapshot(nil) != nil || Snapshot((*Account)(nil)) != nil {
+		t.Fatalf("expected nil snapshots for nil values")
+	}
+}
+
+func TestDiffListsChangedFields(t *testing.T) {
+	before := Snapshot(Account{Email: "a@example.com", Bio: sql.NullString{String: "old", Valid: true}})
+	after := Snapshot(Account{Email: "a@example.com"})
+
+	changes := Diff(before, after)
+	if len(changes) != 1 {
+		t.Fatalf("expected only bio to change, got %v", changes)
+	}
+	bio, ok := changes["bio"].(map[string]any)
+	if !ok || bio["before"] != "old" || bio["after"] != nil {
+		t.Fatalf("unexpected bio change %v", changes["bio"])
+	}
+
+	created := Diff(nil, after)
+	if len(created) != len(after) {
+		t.Fatalf("expected every field in a creation diff, got %v", created)
+	}
+}
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/base64"
+	"strings"
+)
+
+const (
+	// APIKeyPrefix starts every API key so leaked keys are easy to recognise and scan for.
+	APIKeyPrefix   = "tany_"
+	apiKeyIDBytes  = 5
+	apiKeySecretLn = 32
+)
+
+// ScopeChat allows an API key to call the public chat endpoint with its own rate limit and
+// analytics attribution. It is never granted through roles.
+const ScopeChat Permission = "chat"
+
+// APIKeyScopes lists the scopes an API key may carry. users:manage is deliberately absent
+// so a key can never mint other credentials.
+var APIKeyScopes = []Permission{
+	ScopeChat,
+	PermissionAnalyticsRead,
+	PermissionContentWrite,
+	PermissionSourcesSync,
+}
+
+// IsAPIKeyScope reports whether scope may be granted to an API key.
+func IsAPIKeyScope(scope string) bool {
+	for _, allowed := range APIKeyScopes {
+		if string(allowed) == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateAPIKey creates a key of the form tany_<id>_<secret>. The returned prefix
+// (tany_<id>) is safe to display; only the hash of the full key is persisted.
+func GenerateAPIKey() (key, prefix, hash string, err error) {
+	id := make([]byte, apiKeyIDBytes)
+	if _, err = rand.Read(id); err != nil {
+		return "", "", "", err
+	}
+	secret := make([]byte, apiKeySecretLn)
+	if _, err = rand.Read(secret); err != nil {
+		return "", "", "", err
+	}
+	prefix = APIKeyPrefix + strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(id))
+	key = prefix + "_" + base64.RawURLEncoding.EncodeToString(secret)
+	return key, prefix, HashAPIKey(key), nil
+}
+
+// HashAPIKey hashes a raw API key for storage and lookup.
+func HashAPIKey(key string) string {
+	return HashRefreshToken(key)
+}
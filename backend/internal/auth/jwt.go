@@ -6,6 +6,7 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -26,11 +27,15 @@ var ErrInvalidToken = errors.New("invalid token")
 // ErrTokenExpired indicates the token is no longer valid due to expiration.
 var ErrTokenExpired = errors.New("token expired")
 
+// ErrRateLimited indicates the credential exceeded its request allowance.
+var ErrRateLimited = errors.New("rate limit exceeded")
+
 // Subject represents identity data embedded within tokens.
 type Subject struct {
-	ID    uuid.UUID
-	Email string
-	Roles []string
+	ID       uuid.UUID
+	Email    string
+	Roles    []string
+	TenantID uuid.UUID
 }
 
 // Claims represents validated access token data.
@@ -38,41 +43,117 @@ type Claims struct {
 	UserID    uuid.UUID
 	Email     string
 	Roles     []string
+	TenantID  uuid.UUID
 	IssuedAt  time.Time
 	ExpiresAt time.Time
+	// APIKeyID is set when the request authenticated with an API key rather than a user
+	// token. Such principals are authorised by Scopes instead of Roles.
+	APIKeyID     uuid.UUID
+	APIKeyPrefix string
+	Scopes       []Permission
+}
+
+// IsAPIKey reports whether the claims belong to an API key.
+func (c *Claims) IsAPIKey() bool {
+	return c.APIKeyID != uuid.Nil
+}
+
+// HasRole reports whether a user principal carries role. API keys hold no roles.
+func (c *Claims) HasRole(role string) bool {
+	if c.IsAPIKey() {
+		return false
+	}
+	for _, candidate := range c.Roles {
+		if strings.EqualFold(candidate, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission reports whether the principal holds perm through its roles or key scopes.
+func (c *Claims) HasPermission(perm Permission) bool {
+	if !c.IsAPIKey() {
+		return HasPermission(c.Roles, perm)
+	}
+	for _, scope := range c.Scopes {
+		if scope == perm {
+			return true
+		}
+	}
+	return false
 }
 
-// TokenService issues and validates access/refresh tokens.
+// Permissions returns the admin permissions held by the principal.
+func (c *Claims) Permissions() []Permission {
+	if !c.IsAPIKey() {
+		return PermissionsForRoles(c.Roles)
+	}
+	var perms []Permission
+	for _, perm := range AllPermissions {
+		if c.HasPermission(perm) {
+			perms = append(perms, perm)
+		}
+	}
+	return perms
+}
+
+// TokenService issues and validates access/refresh tokens. Tokens are signed with the
+// active key of an asymmetric KeySet when one is configured, otherwise with the HS256 secret.
 type TokenService struct {
 	secret     []byte
+	keys       *KeySet
 	accessTTL  time.Duration
 	refreshTTL time.Duration
 	clock      func() time.Time
 }
 
 type accessTokenClaims struct {
-	Email string   `json:"email"`
-	Roles []string `json:"roles"`
+	Email  string   `json:"email"`
+	Roles  []string `json:"roles"`
+	Tenant string   `json:"tid,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// NewTokenService constructs a TokenService.
+// NewTokenService constructs a TokenService signing with an HS256 secret.
 func NewTokenService(secret string, accessTTL, refreshTTL time.Duration) (*TokenService, error) {
 	if len(secret) == 0 {
 		return nil, fmt.Errorf("secret must not be empty")
 	}
+	return NewTokenServiceWithKeys(nil, secret, accessTTL, refreshTTL)
+}
+
+// NewTokenServiceWithKeys constructs a TokenService signing with the active key of keys.
+// A non-empty legacySecret keeps HS256 tokens issued before the switch verifiable until
+// they expire; it is also used for signing when keys is nil.
+func NewTokenServiceWithKeys(keys *KeySet, legacySecret string, accessTTL, refreshTTL time.Duration) (*TokenService, error) {
+	if keys == nil && len(legacySecret) == 0 {
+		return nil, fmt.Errorf("either signing keys or a secret is required")
+	}
 	if accessTTL <= 0 {
 		return nil, fmt.Errorf("access token TTL must be positive")
 	}
 	if refreshTTL <= 0 {
 		return nil, fmt.Errorf("refresh token TTL must be positive")
 	}
-	return &TokenService{
-		secret:     []byte(secret),
+	svc := &TokenService{
+		keys:       keys,
 		accessTTL:  accessTTL,
 		refreshTTL: refreshTTL,
 		clock:      time.Now,
-	}, nil
+	}
+	if legacySecret != "" {
+		svc.secret = []byte(legacySecret)
+	}
+	return svc, nil
+}
+
+// JWKS returns the public verification keys. It is empty when only HS256 is configured.
+func (s *TokenService) JWKS() JWKS {
+	if s.keys == nil {
+		return JWKS{Keys: []JWK{}}
+	}
+	return s.keys.JWKS()
 }
 
 // SetClock overrides the default clock. Intended for testing.
@@ -99,27 +180,29 @@ func (s *TokenService) GenerateAccessToken(sub Subject) (string, error) {
 			ExpiresAt: jwt.NewNumericDate(now.Add(s.accessTTL)),
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signed, err := token.SignedString(s.secret)
-	if err != nil {
-		return "", err
+	if sub.TenantID != uuid.Nil {
+		claims.Tenant = sub.TenantID.String()
 	}
-	return signed, nil
+	return s.sign(claims)
 }
 
 // ValidateAccessToken verifies the supplied token and returns claims on success.
 func (s *TokenService) ValidateAccessToken(token string) (*Claims, error) {
 	parsed := &accessTokenClaims{}
-	_, err := jwt.ParseWithClaims(token, parsed, func(_ *jwt.Token) (interface{}, error) {
-		return s.secret, nil
-	}, jwt.WithTimeFunc(s.now), jwt.WithLeeway(clockSkew))
-	if err != nil {
+	if err := s.parse(token, parsed); err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
 			return nil, ErrTokenExpired
 		}
 		return nil, ErrInvalidToken
 	}
 
+	// MFA challenge tokens share the signing key but must never authenticate requests.
+	for _, aud := range parsed.Audience {
+		if aud == mfaAudience {
+			return nil, ErrInvalidToken
+		}
+	}
+
 	subjectStr := parsed.Subject
 	if subjectStr == "" {
 		return nil, ErrInvalidToken
@@ -131,6 +214,13 @@ func (s *TokenService) ValidateAccessToken(token string) (*Claims, error) {
 
 	roles := append([]string(nil), parsed.Roles...)
 
+	var tenantID uuid.UUID
+	if parsed.Tenant != "" {
+		if tenantID, err = uuid.Parse(parsed.Tenant); err != nil {
+			return nil, ErrInvalidToken
+		}
+	}
+
 	issuedAt := time.Time{}
 	if parsed.IssuedAt != nil {
 		issuedAt = parsed.IssuedAt.Time
@@ -144,6 +234,7 @@ func (s *TokenService) ValidateAccessToken(token string) (*Claims, error) {
 		UserID:    subjectID,
 		Email:     parsed.Email,
 		Roles:     roles,
+		TenantID:  tenantID,
 		IssuedAt:  issuedAt,
 		ExpiresAt: expiresAt,
 	}, nil
@@ -161,12 +252,66 @@ func (s *TokenService) GenerateRefreshToken() (token string, hash string, expire
 	return
 }
 
+// GenerateOpaqueToken creates a random single-use token, such as an invite or password
+// reset token, together with the hash to persist.
+func GenerateOpaqueToken() (token string, hash string, err error) {
+	raw := make([]byte, refreshTokenBytes)
+	if _, err = rand.Read(raw); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(raw)
+	return token, HashRefreshToken(token), nil
+}
+
 // HashRefreshToken hashes the raw refresh token value for storage.
 func HashRefreshToken(token string) string {
 	sum := sha256.Sum256([]byte(token))
 	return base64.RawURLEncoding.EncodeToString(sum[:])
 }
 
+// sign serialises claims using the active asymmetric key, falling back to HS256.
+func (s *TokenService) sign(claims jwt.Claims) (string, error) {
+	if s.keys != nil {
+		active := s.keys.active
+		token := jwt.NewWithClaims(signingMethodFor(active.Algorithm), claims)
+		token.Header["kid"] = active.ID
+		return token.SignedString(active.Private)
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+}
+
+// parse verifies token against the key named by its kid header, or the HS256 secret for
+// kid-less legacy tokens. The algorithm must match the key to prevent algorithm confusion.
+func (s *TokenService) parse(token string, claims jwt.Claims, opts ...jwt.ParserOption) error {
+	var methods []string
+	if s.secret != nil {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if s.keys != nil {
+		methods = append(methods, s.keys.algorithms()...)
+	}
+
+	opts = append(opts, jwt.WithTimeFunc(s.now), jwt.WithLeeway(clockSkew), jwt.WithValidMethods(methods))
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		if t.Method.Alg() == jwt.SigningMethodHS256.Alg() {
+			if s.secret == nil {
+				return nil, ErrInvalidToken
+			}
+			return s.secret, nil
+		}
+		if s.keys == nil {
+			return nil, ErrInvalidToken
+		}
+		kid, _ := t.Header["kid"].(string)
+		key, ok := s.keys.lookup(kid)
+		if !ok || key.Algorithm != t.Method.Alg() {
+			return nil, ErrInvalidToken
+		}
+		return key.Public, nil
+	}, opts...)
+	return err
+}
+
 func (s *TokenService) now() time.Time {
 	if s.clock != nil {
 		return s.clock()
@@ -13,7 +13,7 @@ func TestTokenServiceAccessTokenLifecycle(t *testing.T) {
 	if err != nil {
 		t.Fatalf("new token service: %v", err)
 	}
-	subject := Subject{ID: uuid.New(), Email: "admin@example.com", Roles: []string{"admin"}}
+	subject := Subject{ID: uuid.New(), Email: "admin@example.com", Roles: []string{"admin"}, TenantID: uuid.New()}
 	token, err := svc.GenerateAccessToken(subject)
 	if err != nil {
 		t.Fatalf("generate access token: %v", err)
@@ -31,6 +31,9 @@ func TestTokenServiceAccessTokenLifecycle(t *testing.T) {
 	if len(claims.Roles) != 1 || claims.Roles[0] != "admin" {
 		t.Fatalf("expected roles [admin], got %v", claims.Roles)
 	}
+	if claims.TenantID != subject.TenantID {
+		t.Fatalf("expected tenant %s, got %s", subject.TenantID, claims.TenantID)
+	}
 }
 
 func TestTokenServiceExpiredAccessToken(t *testing.T) {
@@ -0,0 +1,210 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const minRSAKeyBits = 2048
+
+// Supported asymmetric signing algorithms.
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// SigningKey is an asymmetric key identified by a key ID. Keys without a private half can
+// only verify tokens, which is how retired keys are kept around after a rotation.
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	Private   crypto.Signer
+	Public    crypto.PublicKey
+}
+
+// KeySet holds the active signing key and every key still accepted for verification.
+type KeySet struct {
+	active *SigningKey
+	keys   map[string]*SigningKey
+}
+
+// NewKeySet builds a KeySet in which activeID signs new tokens.
+func NewKeySet(activeID string, keys ...SigningKey) (*KeySet, error) {
+	set := &KeySet{keys: make(map[string]*SigningKey, len(keys))}
+	for i := range keys {
+		key := keys[i]
+		if strings.TrimSpace(key.ID) == "" {
+			return nil, errors.New("signing key id must not be empty")
+		}
+		if _, exists := set.keys[key.ID]; exists {
+			return nil, fmt.Errorf("duplicate signing key id %q", key.ID)
+		}
+		if key.Public == nil && key.Private != nil {
+			key.Public = key.Private.Public()
+		}
+		alg, err := algorithmFor(key.Public)
+		if err != nil {
+			return nil, fmt.Errorf("signing key %q: %w", key.ID, err)
+		}
+		if key.Algorithm != "" && key.Algorithm != alg {
+			return nil, fmt.Errorf("signing key %q: algorithm %s does not match key type", key.ID, key.Algorithm)
+		}
+		key.Algorithm = alg
+		set.keys[key.ID] = &key
+	}
+
+	active, ok := set.keys[activeID]
+	if !ok {
+		return nil, fmt.Errorf("active signing key %q not found", activeID)
+	}
+	if active.Private == nil {
+		return nil, fmt.Errorf("active signing key %q has no private key", activeID)
+	}
+	set.active = active
+	return set, nil
+}
+
+// ActiveID returns the key ID used for signing.
+func (s *KeySet) ActiveID() string {
+	return s.active.ID
+}
+
+func (s *KeySet) lookup(kid string) (*SigningKey, bool) {
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+func (s *KeySet) algorithms() []string {
+	seen := map[string]bool{}
+	var algs []string
+	for _, key := range s.keys {
+		if !seen[key.Algorithm] {
+			seen[key.Algorithm] = true
+			algs = append(algs, key.Algorithm)
+		}
+	}
+	return algs
+}
+
+// ParseKeyPEM decodes a PKCS#8/PKCS#1 private key or a PKIX public key. Private keys
+// may sign; public keys are verify-only.
+func ParseKeyPEM(id string, data []byte) (SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return SigningKey{}, fmt.Errorf("signing key %q: no PEM block found", id)
+	}
+
+	key := SigningKey{ID: id}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return SigningKey{}, fmt.Errorf("signing key %q: %w", id, err)
+		}
+		signer, ok := parsed.(crypto.Signer)
+		if !ok {
+			return SigningKey{}, fmt.Errorf("signing key %q: unsupported private key type", id)
+		}
+		key.Private = signer
+	case "RSA PRIVATE KEY":
+		parsed, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return SigningKey{}, fmt.Errorf("signing key %q: %w", id, err)
+		}
+		key.Private = parsed
+	case "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return SigningKey{}, fmt.Errorf("signing key %q: %w", id, err)
+		}
+		key.Public = parsed
+	default:
+		return SigningKey{}, fmt.Errorf("signing key %q: unsupported PEM block %q", id, block.Type)
+	}
+
+	if key.Public == nil {
+		key.Public = key.Private.Public()
+	}
+	alg, err := algorithmFor(key.Public)
+	if err != nil {
+		return SigningKey{}, fmt.Errorf("signing key %q: %w", id, err)
+	}
+	key.Algorithm = alg
+	return key, nil
+}
+
+func algorithmFor(public crypto.PublicKey) (string, error) {
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSAKeyBits {
+			return "", fmt.Errorf("rsa key must be at least %d bits", minRSAKeyBits)
+		}
+		return AlgorithmRS256, nil
+	case ed25519.PublicKey:
+		return AlgorithmEdDSA, nil
+	default:
+		return "", errors.New("unsupported key type, expected RSA or Ed25519")
+	}
+}
+
+func signingMethodFor(alg string) jwt.SigningMethod {
+	switch alg {
+	case AlgorithmRS256:
+		return jwt.SigningMethodRS256
+	case AlgorithmEdDSA:
+		return jwt.SigningMethodEdDSA
+	default:
+		return nil
+	}
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+// JWKS is the document served at /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public halves of every verification key, sorted by key ID.
+func (s *KeySet) JWKS() JWKS {
+	doc := JWKS{Keys: make([]JWK, 0, len(s.keys))}
+	for _, key := range s.keys {
+		jwk := JWK{KeyID: key.ID, Use: "sig", Algorithm: key.Algorithm}
+		switch pub := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		doc.Keys = append(doc.Keys, jwk)
+	}
+	sort.Slice(doc.Keys, func(i, j int) bool { return doc.Keys[i].KeyID < doc.Keys[j].KeyID })
+	return doc
+}
@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func pemEncode(t *testing.T, blockType string, der []byte) []byte {
+	t.Helper()
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+}
+
+func newEd25519Key(t *testing.T, id string) (SigningKey, []byte) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ed25519: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatalf("marshal ed25519: %v", err)
+	}
+	key, err := ParseKeyPEM(id, pemEncode(t, "PRIVATE KEY", der))
+	if err != nil {
+		t.Fatalf("parse ed25519: %v", err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatalf("marshal public key: %v", err)
+	}
+	return key, pemEncode(t, "PUBLIC KEY", pubDER)
+}
+
+func TestParseKeyPEMDetectsAlgorithms(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa: %v", err)
+	}
+	key, err := ParseKeyPEM("rsa", pemEncode(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey)))
+	if err != nil {
+		t.Fatalf("parse rsa: %v", err)
+	}
+	if key.Algorithm != AlgorithmRS256 || key.Private == nil {
+		t.Fatalf("expected signing RS256 key, got %+v", key)
+	}
+
+	edKey, publicPEM := newEd25519Key(t, "ed")
+	if edKey.Algorithm != AlgorithmEdDSA {
+		t.Fatalf("expected EdDSA, got %s", edKey.Algorithm)
+	}
+	verifyOnly, err := ParseKeyPEM("ed-public", publicPEM)
+	if err != nil {
+		t.Fatalf("parse public key: %v", err)
+	}
+	if verifyOnly.Private != nil || verifyOnly.Algorithm != AlgorithmEdDSA {
+		t.Fatalf("expected verify-only EdDSA key, got %+v", verifyOnly)
+	}
+
+	weak, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("generate weak rsa: %v", err)
+	}
+	if _, err := ParseKeyPEM("weak", pemEncode(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(weak))); err == nil {
+		t.Fatalf("expected weak rsa key to be rejected")
+	}
+	if _, err := ParseKeyPEM("junk", []byte("not pem")); err == nil {
+		t.Fatalf("expected error for invalid pem")
+	}
+}
+
+func TestKeyRotationKeepsPreviousTokensValid(t *testing.T) {
+	oldKey, oldPublic := newEd25519Key(t, "2025-01")
+	newKey, _ := newEd25519Key(t, "2025-02")
+	subject := Subject{ID: uuid.New(), Email: "admin@example.com", Roles: []string{"admin"}}
+
+	before, err := NewKeySet("2025-01", oldKey)
+	if err != nil {
+		t.Fatalf("new key set: %v", err)
+	}
+	svc, err := NewTokenServiceWithKeys(before, "", time.Minute, time.Hour)
+	if err != nil {
+		t.Fatalf("new token service: %v", err)
+	}
+	oldToken, err := svc.GenerateAccessToken(subject)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+
+	// After rotation the previous key is only kept for verification.
+	retired, err := ParseKeyPEM("2025-01", oldPublic)
+	if err != nil {
+		t.Fatalf("parse retired key: %v", err)
+	}
+	after, err := NewKeySet("2025-02", newKey, retired)
+	if err != nil {
+		t.Fatalf("new key set: %v", err)
+	}
+	rotated, err := NewTokenServiceWithKeys(after, "", time.Minute, time.Hour)
+	if err != nil {
+		t.Fatalf("new token service: %v", err)
+	}
+
+	if claims, err := rotated.ValidateAccessToken(oldToken); err != nil || claims.UserID != subject.ID {
+		t.Fatalf("expected token from previous key to verify, got %v", err)
+	}
+	newToken, err := rotated.GenerateAccessToken(subject)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, jwt.MapClaims{})
+	if err != nil {
+		t.Fatalf("parse header: %v", err)
+	}
+	if parsed.Header["kid"] != "2025-02" || parsed.Method.Alg() != AlgorithmEdDSA {
+		t.Fatalf("expected token signed by 2025-02, got kid=%v alg=%s", parsed.Header["kid"], parsed.Method.Alg())
+	}
+	if _, err := svc.ValidateAccessToken(newToken); err != ErrInvalidToken {
+		t.Fatalf("expected unknown kid to be rejected, got %v", err)
+	}
+
+	doc := rotated.JWKS()
+	if len(doc.Keys) != 2 || doc.Keys[0].KeyID != "2025-01" || doc.Keys[1].KeyID != "2025-02" {
+		t.Fatalf("unexpected jwks %+v", doc)
+	}
+	if doc.Keys[1].KeyType != "OKP" || doc.Keys[1].Curve != "Ed25519" || doc.Keys[1].X == "" {
+		t.Fatalf("unexpected jwk %+v", doc.Keys[1])
+	}
+}
+
+func TestKeyedTokenServiceAcceptsLegacyHS256Tokens(t *testing.T) {
+	secret := strings.Repeat("k", 64)
+	legacy, err := NewTokenService(secret, time.Minute, time.Hour)
+	if err != nil {
+		t.Fatalf("new token service: %v", err)
+	}
+	subject := Subject{ID: uuid.New()}
+	hsToken, err := legacy.GenerateAccessToken(subject)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+
+	key, _ := newEd25519Key(t, "k1")
+	keys, err := NewKeySet("k1", key)
+	if err != nil {
+		t.Fatalf("new key set: %v", err)
+	}
+
+	withSecret, err := NewTokenServiceWithKeys(keys, secret, time.Minute, time.Hour)
+	if err != nil {
+		t.Fatalf("new token service: %v", err)
+	}
+	if _, err := withSecret.ValidateAccessToken(hsToken); err != nil {
+		t.Fatalf("expected legacy token to verify, got %v", err)
+	}
+
+	withoutSecret, err := NewTokenServiceWithKeys(keys, "", time.Minute, time.Hour)
+	if err != nil {
+		t.Fatalf("new token service: %v", err)
+	}
+	if _, err := withoutSecret.ValidateAccessToken(hsToken); err != ErrInvalidToken {
+		t.Fatalf("expected HS256 token to be rejected without secret, got %v", err)
+	}
+	if doc := legacy.JWKS(); len(doc.Keys) != 0 {
+		t.Fatalf("expected empty jwks for HS256 service")
+	}
+}
@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	mfaAudience = "mfa"
+	// MFAChallengeTTL bounds how long a password-verified login may wait for its second factor.
+	MFAChallengeTTL = 5 * time.Minute
+)
+
+// GenerateMFAToken issues a short-lived challenge token proving the password step succeeded.
+// It cannot be used as an access token.
+func (s *TokenService) GenerateMFAToken(userID uuid.UUID) (string, time.Time, error) {
+	if userID == uuid.Nil {
+		return "", time.Time{}, errors.New("subject ID is required")
+	}
+	now := s.now()
+	expiresAt := now.Add(MFAChallengeTTL)
+	claims := jwt.RegisteredClaims{
+		Subject:   userID.String(),
+		Audience:  jwt.ClaimStrings{mfaAudience},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+	}
+	signed, err := s.sign(claims)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
+// ValidateMFAToken verifies a challenge token and returns the user it was issued for.
+func (s *TokenService) ValidateMFAToken(token string) (uuid.UUID, error) {
+	parsed := &jwt.RegisteredClaims{}
+	if err := s.parse(token, parsed, jwt.WithAudience(mfaAudience)); err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return uuid.Nil, ErrTokenExpired
+		}
+		return uuid.Nil, ErrInvalidToken
+	}
+	userID, err := uuid.Parse(parsed.Subject)
+	if err != nil {
+		return uuid.Nil, ErrInvalidToken
+	}
+	return userID, nil
+}
@@ -21,7 +21,11 @@ func HashPassword(password string) (string, error) {
 }
 
 // ComparePassword compares a bcrypt hash against the supplied plaintext password.
+// An empty hash, as stored for invited users who have not set a password yet, never matches.
 func ComparePassword(hash, password string) error {
+	if hash == "" {
+		return ErrInvalidPassword
+	}
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
 		return ErrInvalidPassword
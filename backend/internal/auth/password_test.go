@@ -17,3 +17,9 @@ func TestHashAndComparePassword(t *testing.T) {
 		t.Fatalf("expected ErrInvalidPassword, got %v", err)
 	}
 }
+
+func TestComparePasswordRejectsEmptyHash(t *testing.T) {
+	if err := ComparePassword("", ""); err != ErrInvalidPassword {
+		t.Fatalf("expected ErrInvalidPassword, got %v", err)
+	}
+}
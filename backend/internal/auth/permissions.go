@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"sort"
+	"strings"
+)
+
+// Permission names a capability granted to roles.
+type Permission string
+
+const (
+	// PermissionContentWrite allows managing profile, skills, services, projects, uploads and external items.
+	PermissionContentWrite Permission = "content:write"
+	// PermissionAnalyticsRead allows reading analytics and leads.
+	PermissionAnalyticsRead Permission = "analytics:read"
+	// PermissionSourcesSync allows listing and syncing external sources.
+	PermissionSourcesSync Permission = "sources:sync"
+	// PermissionUsersManage allows administering users and their roles.
+	PermissionUsersManage Permission = "users:manage"
+)
+
+// Built-in roles.
+const (
+	RoleAdmin   = "admin"
+	RoleEditor  = "editor"
+	RoleAnalyst = "analyst"
+)
+
+// AllPermissions lists every known permission.
+var AllPermissions = []Permission{
+	PermissionContentWrite,
+	PermissionAnalyticsRead,
+	PermissionSourcesSync,
+	PermissionUsersManage,
+}
+
+// rolePermissions maps built-in roles to the permissions they grant.
+var rolePermissions = map[string][]Permission{
+	RoleAdmin:   AllPermissions,
+	RoleEditor:  {PermissionContentWrite},
+	RoleAnalyst: {PermissionAnalyticsRead},
+}
+
+// IsKnownRole reports whether role is one of the built-in roles.
+func IsKnownRole(role string) bool {
+	_, ok := rolePermissions[strings.ToLower(strings.TrimSpace(role))]
+	return ok
+}
+
+// PermissionsForRoles returns the sorted union of permissions granted by roles.
+// Unknown roles grant nothing.
+func PermissionsForRoles(roles []string) []Permission {
+	seen := make(map[Permission]bool)
+	for _, role := range roles {
+		for _, perm := range rolePermissions[strings.ToLower(strings.TrimSpace(role))] {
+			seen[perm] = true
+		}
+	}
+	perms := make([]Permission, 0, len(seen))
+	for perm := range seen {
+		perms = append(perms, perm)
+	}
+	sort.Slice(perms, func(i, j int) bool { return perms[i] < perms[j] })
+	return perms
+}
+
+// HasPermission reports whether any of roles grants perm.
+func HasPermission(roles []string, perm Permission) bool {
+	for _, role := range roles {
+		for _, granted := range rolePermissions[strings.ToLower(strings.TrimSpace(role))] {
+			if granted == perm {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package auth
+
+import "testing"
+
+func TestPermissionsForRoles(t *testing.T) {
+	perms := PermissionsForRoles([]string{"Editor", "analyst", "unknown"})
+	if len(perms) != 2 || perms[0] != PermissionAnalyticsRead || perms[1] != PermissionContentWrite {
+		t.Fatalf("unexpected permissions: %v", perms)
+	}
+	if len(PermissionsForRoles([]string{RoleAdmin})) != len(AllPermissions) {
+		t.Fatalf("expected admin to hold every permission")
+	}
+	if len(PermissionsForRoles(nil)) != 0 {
+		t.Fatalf("expected no permissions without roles")
+	}
+}
+
+func TestHasPermission(t *testing.T) {
+	if !HasPermission([]string{RoleEditor}, PermissionContentWrite) {
+		t.Fatalf("editor should write content")
+	}
+	if HasPermission([]string{RoleEditor}, PermissionAnalyticsRead) {
+		t.Fatalf("editor should not read analytics")
+	}
+	if !HasPermission([]string{RoleAdmin}, PermissionUsersManage) {
+		t.Fatalf("admin should manage users")
+	}
+}
@@ -45,6 +45,19 @@ func NewRateLimiter(perMinute, burst int, ttl time.Duration) *RateLimiter {
 
 // Allow reports whether a request associated with the given key may proceed.
 func (r *RateLimiter) Allow(key string) bool {
+	return r.allow(key, r.limit, r.burst)
+}
+
+// AllowLimit is like Allow but applies a per-key limit instead of the limiter defaults.
+// A changed limit takes effect on the key's next request.
+func (r *RateLimiter) AllowLimit(key string, perMinute, burst int) bool {
+	if perMinute <= 0 || burst <= 0 {
+		return r.Allow(key)
+	}
+	return r.allow(key, rate.Limit(float64(perMinute)/60.0), burst)
+}
+
+func (r *RateLimiter) allow(key string, limit rate.Limit, burst int) bool {
 	now := time.Now()
 
 	r.mu.Lock()
@@ -53,10 +66,13 @@ func (r *RateLimiter) Allow(key string) bool {
 	entry, ok := r.clients[key]
 	if !ok {
 		entry = &clientLimiter{
-			limiter:  rate.NewLimiter(r.limit, r.burst),
+			limiter:  rate.NewLimiter(limit, burst),
 			lastSeen: now,
 		}
 		r.clients[key] = entry
+	} else if entry.limiter.Limit() != limit || entry.limiter.Burst() != burst {
+		entry.limiter.SetLimitAt(now, limit)
+		entry.limiter.SetBurstAt(now, burst)
 	}
 
 	entry.lastSeen = now
@@ -14,3 +14,15 @@ func TestRateLimiterAllow(t *testing.T) {
 		t.Fatal("expected different key to pass")
 	}
 }
+
+func TestRateLimiterAllowLimitUsesPerKeyBurst(t *testing.T) {
+	limiter := NewRateLimiter(1, 1, defaultLimiterTTL)
+	for i := 0; i < 3; i++ {
+		if !limiter.AllowLimit("key:1", 3, 3) {
+			t.Fatalf("expected request %d to pass", i+1)
+		}
+	}
+	if limiter.AllowLimit("key:1", 3, 3) {
+		t.Fatal("expected fourth request to be rate limited")
+	}
+}
@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// TOTPPeriod is the RFC 6238 time step.
+	TOTPPeriod = 30 * time.Second
+	// TOTPDigits is the number of digits in a generated code.
+	TOTPDigits = 6
+	// totpSkewSteps allows codes from adjacent time steps to absorb clock drift.
+	totpSkewSteps = 1
+
+	totpSecretBytes    = 20
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10
+	recoveryAlphabet   = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret returns a random base32 encoded shared secret.
+func GenerateTOTPSecret() (string, error) {
+	raw := make([]byte, totpSecretBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(raw), nil
+}
+
+// TOTPProvisioningURI builds the otpauth:// URI that authenticator apps import, usually via a QR code.
+func TOTPProvisioningURI(issuer, account, secret string) string {
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	values := url.Values{}
+	values.Set("secret", secret)
+	values.Set("issuer", issuer)
+	values.Set("algorithm", "SHA1")
+	values.Set("digits", fmt.Sprint(TOTPDigits))
+	values.Set("period", fmt.Sprint(int(TOTPPeriod.Seconds())))
+	return "otpauth://totp/" + label + "?" + values.Encode()
+}
+
+// TOTPCode computes the code for the time step containing at.
+func TOTPCode(secret string, at time.Time) (string, error) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, totpCounter(at)), nil
+}
+
+// VerifyTOTP checks code against the time steps around at and returns the matching
+// counter so callers can reject replays of an already accepted code.
+func VerifyTOTP(secret, code string, at time.Time) (int64, bool) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return 0, false
+	}
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != TOTPDigits {
+		return 0, false
+	}
+	current := totpCounter(at)
+	for offset := int64(-totpSkewSteps); offset <= totpSkewSteps; offset++ {
+		counter := current + offset
+		if subtle.ConstantTimeCompare([]byte(hotp(key, counter)), []byte(code)) == 1 {
+			return counter, true
+		}
+	}
+	return 0, false
+}
+
+// GenerateRecoveryCodes returns single-use recovery codes along with the hashes to persist.
+func GenerateRecoveryCodes() (codes []string, hashes []string, err error) {
+	codes = make([]string, recoveryCodeCount)
+	hashes = make([]string, recoveryCodeCount)
+	raw := make([]byte, recoveryCodeLength)
+	for i := range codes {
+		if _, err = rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+		var b strings.Builder
+		for j, v := range raw {
+			if j == recoveryCodeLength/2 {
+				b.WriteByte('-')
+			}
+			b.WriteByte(recoveryAlphabet[int(v)%len(recoveryAlphabet)])
+		}
+		codes[i] = b.String()
+		hashes[i] = HashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// HashRecoveryCode normalises and hashes a recovery code for storage and lookup.
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(code)))
+	sum := sha256.Sum256([]byte(normalized))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	normalized := strings.ToUpper(strings.TrimRight(strings.ReplaceAll(secret, " ", ""), "="))
+	key, err := totpEncoding.DecodeString(normalized)
+	if err != nil || len(key) == 0 {
+		return nil, fmt.Errorf("invalid totp secret")
+	}
+	return key, nil
+}
+
+func totpCounter(at time.Time) int64 {
+	return at.Unix() / int64(TOTPPeriod.Seconds())
+}
+
+// hotp implements RFC 4226 with HMAC-SHA1 and dynamic truncation.
+func hotp(key []byte, counter int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < TOTPDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", TOTPDigits, value%mod)
+}
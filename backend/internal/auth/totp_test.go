@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// rfc6238Secret is the ASCII key "12345678901234567890" from RFC 6238 Appendix B.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCodeMatchesRFCVectors(t *testing.T) {
+	vectors := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for unix, want := range vectors {
+		got, err := TOTPCode(rfc6238Secret, time.Unix(unix, 0))
+		if err != nil {
+			t.Fatalf("totp code: %v", err)
+		}
+		if got != want {
+			t.Fatalf("at %d expected %s, got %s", unix, want, got)
+		}
+	}
+}
+
+func TestVerifyTOTPAllowsOneStepOfDrift(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("generate secret: %v", err)
+	}
+	now := time.Unix(1_700_000_000, 0)
+	previous, _ := TOTPCode(secret, now.Add(-TOTPPeriod))
+	if counter, ok := VerifyTOTP(secret, previous, now); !ok || counter != totpCounter(now)-1 {
+		t.Fatalf("expected previous step to verify, got %d %v", counter, ok)
+	}
+	stale, _ := TOTPCode(secret, now.Add(-3*TOTPPeriod))
+	if _, ok := VerifyTOTP(secret, stale, now); ok {
+		t.Fatalf("expected stale code to be rejected")
+	}
+	if _, ok := VerifyTOTP(secret, "12345", now); ok {
+		t.Fatalf("expected short code to be rejected")
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri := TOTPProvisioningURI("tany.ai", "admin@example.com", "ABC")
+	if !strings.HasPrefix(uri, "otpauth://totp/tany.ai:admin@example.com?") || !strings.Contains(uri, "secret=ABC") || !strings.Contains(uri, "issuer=tany.ai") {
+		t.Fatalf("unexpected uri %s", uri)
+	}
+}
+
+func TestRecoveryCodesHashNormalised(t *testing.T) {
+	codes, hashes, err := GenerateRecoveryCodes()
+	if err != nil {
+		t.Fatalf("generate recovery codes: %v", err)
+	}
+	if len(codes) != recoveryCodeCount || len(hashes) != recoveryCodeCount {
+		t.Fatalf("expected %d codes", recoveryCodeCount)
+	}
+	if HashRecoveryCode(strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))) != hashes[0] {
+		t.Fatalf("expected hash to ignore case and separators")
+	}
+}
+
+func TestMFATokenCannotAuthenticate(t *testing.T) {
+	svc, err := NewTokenService(strings.Repeat("m", 64), time.Minute, time.Hour)
+	if err != nil {
+		t.Fatalf("new token service: %v", err)
+	}
+	userID := uuid.New()
+	token, _, err := svc.GenerateMFAToken(userID)
+	if err != nil {
+		t.Fatalf("generate mfa token: %v", err)
+	}
+	if got, err := svc.ValidateMFAToken(token); err != nil || got != userID {
+		t.Fatalf("expected mfa token for %s, got %s %v", userID, got, err)
+	}
+	if _, err := svc.ValidateAccessToken(token); err != ErrInvalidToken {
+		t.Fatalf("expected mfa token to be rejected as access token, got %v", err)
+	}
+
+	access, err := svc.GenerateAccessToken(Subject{ID: userID})
+	if err != nil {
+		t.Fatalf("generate access token: %v", err)
+	}
+	if _, err := svc.ValidateMFAToken(access); err != ErrInvalidToken {
+		t.Fatalf("expected access token to be rejected as mfa token, got %v", err)
+	}
+}
@@ -21,19 +21,42 @@ const (
 	defaultLoginPerMin           = 5
 	defaultLoginBurst            = 10
 	defaultStorageDriver         = string(StorageDriverSupabase)
+	defaultLocalStorageDir       = "data/uploads"
+	defaultLocalStorageURL       = "http://localhost:8080/api/v1/files"
+	defaultLocalURLTTLSeconds    = 7 * 24 * 60 * 60
+	defaultLocalCacheMaxAgeSec   = 365 * 24 * 60 * 60
 	defaultUploadMaxMB           = 5
 	defaultUploadRatePerMin      = 10
 	defaultUploadRateBurst       = 10
+	defaultUploadJPEGQuality     = 82
+	defaultUploadMaxPixels       = 40_000_000
+	defaultDirectUploadMaxMB     = 200
+	defaultPresignTTLSeconds     = 15 * 60
+	maxPresignTTLSeconds         = 7 * 24 * 60 * 60
+	defaultClamdTimeoutSeconds   = 10
+	defaultClamdStreamMaxMB      = 25
+	defaultDocumentMaxMB         = 10
+	defaultDocumentChunkRunes    = 1200
+	minDocumentChunkRunes        = 200
+	defaultDocumentMaxChunks     = 200
 	defaultKBCacheTTLSeconds     = 60
 	defaultKnowledgeRatePer5Min  = 30
 	defaultKnowledgeRateBurst    = 30
 	defaultChatRatePer5Min       = 30
 	defaultChatRateBurst         = 30
 	defaultAIModel               = "gemini-1.5-pro"
+	defaultAICassetteDir         = "testdata/cassettes"
 	defaultAnalyticsRetention    = 90
 	minJWTSecretLength           = 32
+	defaultMFAIssuer             = "tany.ai"
+	defaultTokenCleanupMin       = 60
+	defaultScheduledPublishSec   = 60
+	defaultTrashRetentionDays    = 30
+	defaultMediaOrphanGraceHours = 7 * 24
 	defaultExternalHTTPTimeoutMS = 8000
 	defaultExternalRateLimitRPM  = 30
+	defaultAnswerCacheTTLSeconds = 6 * 60 * 60
+	defaultAnswerCacheMaxEntries = 1000
 )
 
 var defaultAllowedMIMEs = []string{
@@ -43,6 +66,22 @@ var defaultAllowedMIMEs = []string{
 	"image/svg+xml",
 }
 
+// defaultDirectMIMEs omits SVG: direct uploads never pass through the sanitizer.
+var defaultDirectMIMEs = []string{
+	"image/jpeg",
+	"image/png",
+	"image/webp",
+	"video/mp4",
+	"video/webm",
+	"application/pdf",
+}
+
+var defaultImageVariants = []ImageVariantConfig{
+	{Name: "thumb", Width: 320},
+	{Name: "medium", Width: 960},
+	{Name: "large", Width: 1920},
+}
+
 var defaultExternalAllowlist = []string{"noahis.me", "www.noahis.me", "noahisme.vercel.app"}
 
 // Config contains runtime configuration loaded from environment variables.
@@ -53,9 +92,19 @@ type Config struct {
 	DBMaxIdleConns           int
 	DBConnMaxLifetime        time.Duration
 	JWTSecret                string
+	JWTKeys                  JWTKeysConfig
 	AccessTokenTTL           time.Duration
 	RefreshTokenTTL          time.Duration
 	RefreshCookieName        string
+	TokenCleanupInterval     time.Duration
+	ScheduledPublishInterval time.Duration
+	// TrashRetentionDays is how long soft-deleted content is kept before it is purged;
+	// zero disables automatic purging.
+	TrashRetentionDays int
+	// MediaOrphanGraceHours is how long an unreferenced upload is kept before the media
+	// cleanup job deletes it; zero disables the job.
+	MediaOrphanGraceHours    int
+	MFAIssuer                string
 	LoginRateLimitPerMin     int
 	LoginRateLimitBurst      int
 	Storage                  StorageConfig
@@ -68,7 +117,11 @@ type Config struct {
 	ChatRateLimitPerMin      int
 	ChatRateLimitBurst       int
 	ChatModel                string
+	ChatExposePrompt         bool
+	ChatExperiment           string
 	AIProvider               string
+	AICassetteDir            string
+	AIRecord                 bool
 	GoogleGenAIKey           string
 	LeapcellAPIKey           string
 	LeapcellProjectID        string
@@ -76,6 +129,30 @@ type Config struct {
 	External                 ExternalConfig
 	EnableAnalytics          bool
 	AnalyticsRetentionDays   int
+	AnswerCache              AnswerCacheConfig
+}
+
+// AnswerCacheConfig controls caching of provider answers for repeated questions.
+type AnswerCacheConfig struct {
+	Enabled    bool
+	TTL        time.Duration
+	MaxEntries int
+	// Similarity enables embedding based near-duplicate matching when greater than zero.
+	Similarity float64
+}
+
+// JWTKeysConfig lists the asymmetric keys used for access tokens. When ActiveKeyID is empty
+// tokens are signed with JWTSecret (HS256).
+type JWTKeysConfig struct {
+	ActiveKeyID string
+	Keys        []JWTKeyConfig
+}
+
+// JWTKeyConfig is a PEM encoded private key, or a public key kept to verify tokens signed
+// before a rotation.
+type JWTKeyConfig struct {
+	ID  string
+	PEM []byte
 }
 
 // StorageDriver enumerates supported object storage providers.
@@ -84,6 +161,7 @@ type StorageDriver string
 const (
 	StorageDriverSupabase StorageDriver = "supabase"
 	StorageDriverS3       StorageDriver = "s3"
+	StorageDriverLocal    StorageDriver = "local"
 )
 
 // StorageConfig captures configuration for object storage integrations.
@@ -91,6 +169,7 @@ type StorageConfig struct {
 	Driver   StorageDriver
 	Supabase SupabaseConfig
 	S3       S3Config
+	Local    LocalStorageConfig
 }
 
 // SupabaseConfig stores Supabase storage settings.
@@ -112,26 +191,93 @@ type S3Config struct {
 	ForcePathStyle  bool
 }
 
+// LocalStorageConfig stores settings for the filesystem driver. When SigningKey is set the
+// serving route only accepts HMAC-signed URLs that expire after URLTTL.
+type LocalStorageConfig struct {
+	Dir         string
+	PublicURL   string
+	SigningKey  string
+	URLTTL      time.Duration
+	CacheMaxAge time.Duration
+}
+
 // UploadConfig defines upload validation rules.
 type UploadConfig struct {
 	MaxBytes    int64
 	AllowedMIME []string
 	AllowSVG    bool
+	Image       ImageConfig
+	Direct      DirectUploadConfig
+	Scan        ScanConfig
+	Document    DocumentConfig
+}
+
+// DocumentConfig limits PDF, Markdown and text documents uploaded as knowledge sources.
+type DocumentConfig struct {
+	MaxBytes int64
+	// ChunkRunes is the largest chunk of extracted text stored as one knowledge item.
+	ChunkRunes int
+	// MaxChunks rejects documents that would produce more chunks than this.
+	MaxChunks int
+}
+
+// ScanConfig selects the scanners run on uploads before they are stored.
+type ScanConfig struct {
+	// Builtin enables the dependency-free polyglot and embedded script checker.
+	Builtin bool
+	// ClamdAddr is "host:port" or "unix:/path" of a ClamAV daemon; empty disables it.
+	ClamdAddr    string
+	ClamdTimeout time.Duration
+	// ClamdStreamMax caps the leading bytes sent to clamd; keep it at or below clamd's
+	// StreamMaxLength so larger files are scanned instead of rejected.
+	ClamdStreamMax int64
+	// FailOpen stores uploads when a scanner errors instead of rejecting them.
+	FailOpen bool
+}
+
+// DirectUploadConfig limits uploads sent straight to the bucket through presigned URLs.
+// SigningKey signs the completion tokens issued at presign; without it every process
+// uses a random key, so tokens only work on the instance that issued them.
+type DirectUploadConfig struct {
+	MaxBytes    int64
+	AllowedMIME []string
+	URLTTL      time.Duration
+	SigningKey  string
+}
+
+// ImageConfig controls post-processing of raster uploads.
+type ImageConfig struct {
+	Enabled     bool
+	Variants    []ImageVariantConfig
+	JPEGQuality int
+	WebP        bool
+	MaxPixels   int
+}
+
+// ImageVariantConfig names a resized rendition and its maximum width in pixels.
+type ImageVariantConfig struct {
+	Name  string
+	Width int
 }
 
 // Load reads configuration values from the process environment.
 func Load() (Config, error) {
 	cfg := Config{
-		AppEnv:               getEnv("APP_ENV", defaultAppEnv),
-		PostgresURL:          os.Getenv("POSTGRES_URL"),
-		DBMaxOpenConns:       defaultMaxOpenConns,
-		DBMaxIdleConns:       defaultMaxIdleConns,
-		DBConnMaxLifetime:    defaultConnMaxLifetime,
-		AccessTokenTTL:       time.Duration(defaultAccessTTLMin) * time.Minute,
-		RefreshTokenTTL:      time.Duration(defaultRefreshTTLDays) * 24 * time.Hour,
-		RefreshCookieName:    defaultRefreshCookie,
-		LoginRateLimitPerMin: defaultLoginPerMin,
-		LoginRateLimitBurst:  defaultLoginBurst,
+		AppEnv:                   getEnv("APP_ENV", defaultAppEnv),
+		PostgresURL:              os.Getenv("POSTGRES_URL"),
+		DBMaxOpenConns:           defaultMaxOpenConns,
+		DBMaxIdleConns:           defaultMaxIdleConns,
+		DBConnMaxLifetime:        defaultConnMaxLifetime,
+		AccessTokenTTL:           time.Duration(defaultAccessTTLMin) * time.Minute,
+		RefreshTokenTTL:          time.Duration(defaultRefreshTTLDays) * 24 * time.Hour,
+		RefreshCookieName:        defaultRefreshCookie,
+		MFAIssuer:                getEnv("MFA_ISSUER", defaultMFAIssuer),
+		TokenCleanupInterval:     time.Duration(defaultTokenCleanupMin) * time.Minute,
+		ScheduledPublishInterval: time.Duration(defaultScheduledPublishSec) * time.Second,
+		TrashRetentionDays:       defaultTrashRetentionDays,
+		MediaOrphanGraceHours:    defaultMediaOrphanGraceHours,
+		LoginRateLimitPerMin:     defaultLoginPerMin,
+		LoginRateLimitBurst:      defaultLoginBurst,
 		Storage: StorageConfig{
 			Driver: StorageDriver(strings.ToLower(getEnv("STORAGE_DRIVER", defaultStorageDriver))),
 		},
@@ -139,6 +285,29 @@ func Load() (Config, error) {
 			MaxBytes:    int64(defaultUploadMaxMB) * 1024 * 1024,
 			AllowedMIME: append([]string{}, defaultAllowedMIMEs...),
 			AllowSVG:    false,
+			Image: ImageConfig{
+				Enabled:     true,
+				Variants:    append([]ImageVariantConfig{}, defaultImageVariants...),
+				JPEGQuality: defaultUploadJPEGQuality,
+				WebP:        true,
+				MaxPixels:   defaultUploadMaxPixels,
+			},
+			Direct: DirectUploadConfig{
+				MaxBytes:    int64(defaultDirectUploadMaxMB) * 1024 * 1024,
+				AllowedMIME: append([]string{}, defaultDirectMIMEs...),
+				URLTTL:      time.Duration(defaultPresignTTLSeconds) * time.Second,
+			},
+			Scan: ScanConfig{
+				Builtin:        true,
+				ClamdAddr:      strings.TrimSpace(os.Getenv("CLAMD_ADDR")),
+				ClamdTimeout:   time.Duration(defaultClamdTimeoutSeconds) * time.Second,
+				ClamdStreamMax: int64(defaultClamdStreamMaxMB) * 1024 * 1024,
+			},
+			Document: DocumentConfig{
+				MaxBytes:   int64(defaultDocumentMaxMB) * 1024 * 1024,
+				ChunkRunes: defaultDocumentChunkRunes,
+				MaxChunks:  defaultDocumentMaxChunks,
+			},
 		},
 		UploadRateLimitPerMin:    defaultUploadRatePerMin,
 		UploadRateLimitBurst:     defaultUploadRateBurst,
@@ -149,9 +318,15 @@ func Load() (Config, error) {
 		ChatRateLimitBurst:       defaultChatRateBurst,
 		ChatModel:                getEnv("GEMINI_MODEL", defaultAIModel),
 		AIProvider:               strings.ToLower(getEnv("AI_PROVIDER", "mock")),
+		AICassetteDir:            getEnv("AI_CASSETTE_DIR", defaultAICassetteDir),
 		GoogleGenAIKey:           strings.TrimSpace(os.Getenv("GOOGLE_GENAI_API_KEY")),
 		EnableAnalytics:          false,
 		AnalyticsRetentionDays:   defaultAnalyticsRetention,
+		AnswerCache: AnswerCacheConfig{
+			Enabled:    true,
+			TTL:        time.Duration(defaultAnswerCacheTTLSeconds) * time.Second,
+			MaxEntries: defaultAnswerCacheMaxEntries,
+		},
 		External: ExternalConfig{
 			HTTPTimeout:     time.Duration(defaultExternalHTTPTimeoutMS) * time.Millisecond,
 			DomainAllowlist: append([]string{}, defaultExternalAllowlist...),
@@ -163,9 +338,8 @@ func Load() (Config, error) {
 		return Config{}, fmt.Errorf("POSTGRES_URL is required")
 	}
 
-	cfg.JWTSecret = os.Getenv("JWT_SECRET")
-	if len(cfg.JWTSecret) < minJWTSecretLength {
-		return Config{}, errors.New("JWT_SECRET must be at least 32 characters")
+	if err := populateJWTConfig(&cfg); err != nil {
+		return Config{}, err
 	}
 
 	if v := os.Getenv("DB_MAX_OPEN_CONNS"); v != "" {
@@ -236,6 +410,50 @@ func Load() (Config, error) {
 		cfg.RefreshCookieName = v
 	}
 
+	if v := os.Getenv("TOKEN_CLEANUP_INTERVAL_MIN"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid TOKEN_CLEANUP_INTERVAL_MIN: %w", err)
+		}
+		if parsed <= 0 {
+			return Config{}, errors.New("TOKEN_CLEANUP_INTERVAL_MIN must be greater than zero")
+		}
+		cfg.TokenCleanupInterval = time.Duration(parsed) * time.Minute
+	}
+
+	if v := os.Getenv("SCHEDULED_PUBLISH_INTERVAL_SEC"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid SCHEDULED_PUBLISH_INTERVAL_SEC: %w", err)
+		}
+		if parsed <= 0 {
+			return Config{}, errors.New("SCHEDULED_PUBLISH_INTERVAL_SEC must be greater than zero")
+		}
+		cfg.ScheduledPublishInterval = time.Duration(parsed) * time.Second
+	}
+
+	if v := os.Getenv("TRASH_RETENTION_DAYS"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid TRASH_RETENTION_DAYS: %w", err)
+		}
+		if parsed < 0 {
+			return Config{}, errors.New("TRASH_RETENTION_DAYS must not be negative")
+		}
+		cfg.TrashRetentionDays = parsed
+	}
+
+	if v := os.Getenv("MEDIA_ORPHAN_GRACE_HOURS"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid MEDIA_ORPHAN_GRACE_HOURS: %w", err)
+		}
+		if parsed < 0 {
+			return Config{}, errors.New("MEDIA_ORPHAN_GRACE_HOURS must not be negative")
+		}
+		cfg.MediaOrphanGraceHours = parsed
+	}
+
 	if v := os.Getenv("LOGIN_RATE_LIMIT_PER_MIN"); v != "" {
 		parsed, err := strconv.Atoi(v)
 		if err != nil {
@@ -270,20 +488,55 @@ func Load() (Config, error) {
 	}
 
 	if v := os.Getenv("UPLOAD_ALLOWED_MIME"); v != "" {
-		tokens := strings.Split(v, ",")
-		allowed := make([]string, 0, len(tokens))
-		for _, token := range tokens {
-			trimmed := strings.TrimSpace(token)
-			if trimmed != "" {
-				allowed = append(allowed, strings.ToLower(trimmed))
-			}
-		}
+		allowed := parseMIMEList(v)
 		if len(allowed) == 0 {
 			return Config{}, errors.New("UPLOAD_ALLOWED_MIME must contain at least one mime type")
 		}
 		cfg.Upload.AllowedMIME = allowed
 	}
 
+	if v := os.Getenv("UPLOAD_DIRECT_MAX_MB"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid UPLOAD_DIRECT_MAX_MB: %w", err)
+		}
+		if parsed <= 0 {
+			return Config{}, errors.New("UPLOAD_DIRECT_MAX_MB must be greater than zero")
+		}
+		cfg.Upload.Direct.MaxBytes = int64(parsed) * 1024 * 1024
+	}
+
+	if v := os.Getenv("UPLOAD_DIRECT_ALLOWED_MIME"); v != "" {
+		allowed := parseMIMEList(v)
+		if len(allowed) == 0 {
+			return Config{}, errors.New("UPLOAD_DIRECT_ALLOWED_MIME must contain at least one mime type")
+		}
+		for _, mime := range allowed {
+			if mime == "image/svg+xml" {
+				return Config{}, errors.New("UPLOAD_DIRECT_ALLOWED_MIME must not include image/svg+xml")
+			}
+		}
+		cfg.Upload.Direct.AllowedMIME = allowed
+	}
+
+	if v := os.Getenv("UPLOAD_PRESIGN_TTL_SEC"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid UPLOAD_PRESIGN_TTL_SEC: %w", err)
+		}
+		if parsed <= 0 || parsed > maxPresignTTLSeconds {
+			return Config{}, fmt.Errorf("UPLOAD_PRESIGN_TTL_SEC must be between 1 and %d", maxPresignTTLSeconds)
+		}
+		cfg.Upload.Direct.URLTTL = time.Duration(parsed) * time.Second
+	}
+
+	if v := strings.TrimSpace(os.Getenv("UPLOAD_DIRECT_SIGNING_KEY")); v != "" {
+		if len(v) < minJWTSecretLength {
+			return Config{}, fmt.Errorf("UPLOAD_DIRECT_SIGNING_KEY must be at least %d characters", minJWTSecretLength)
+		}
+		cfg.Upload.Direct.SigningKey = v
+	}
+
 	if v := os.Getenv("ALLOW_SVG"); v != "" {
 		enabled, err := strconv.ParseBool(v)
 		if err != nil {
@@ -292,6 +545,123 @@ func Load() (Config, error) {
 		cfg.Upload.AllowSVG = enabled
 	}
 
+	if v := os.Getenv("UPLOAD_IMAGE_PROCESSING"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid UPLOAD_IMAGE_PROCESSING: %w", err)
+		}
+		cfg.Upload.Image.Enabled = enabled
+	}
+
+	if v := os.Getenv("UPLOAD_IMAGE_VARIANTS"); v != "" {
+		variants, err := parseImageVariants(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid UPLOAD_IMAGE_VARIANTS: %w", err)
+		}
+		cfg.Upload.Image.Variants = variants
+	}
+
+	if v := os.Getenv("UPLOAD_JPEG_QUALITY"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid UPLOAD_JPEG_QUALITY: %w", err)
+		}
+		if parsed < 1 || parsed > 100 {
+			return Config{}, errors.New("UPLOAD_JPEG_QUALITY must be between 1 and 100")
+		}
+		cfg.Upload.Image.JPEGQuality = parsed
+	}
+
+	if v := os.Getenv("UPLOAD_WEBP"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid UPLOAD_WEBP: %w", err)
+		}
+		cfg.Upload.Image.WebP = enabled
+	}
+
+	if v := os.Getenv("UPLOAD_MAX_PIXELS"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid UPLOAD_MAX_PIXELS: %w", err)
+		}
+		if parsed < 0 {
+			return Config{}, errors.New("UPLOAD_MAX_PIXELS must not be negative")
+		}
+		cfg.Upload.Image.MaxPixels = parsed
+	}
+
+	if v := os.Getenv("UPLOAD_SCAN_BUILTIN"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid UPLOAD_SCAN_BUILTIN: %w", err)
+		}
+		cfg.Upload.Scan.Builtin = enabled
+	}
+
+	if v := os.Getenv("CLAMD_TIMEOUT_SEC"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid CLAMD_TIMEOUT_SEC: %w", err)
+		}
+		if parsed <= 0 {
+			return Config{}, errors.New("CLAMD_TIMEOUT_SEC must be greater than zero")
+		}
+		cfg.Upload.Scan.ClamdTimeout = time.Duration(parsed) * time.Second
+	}
+
+	if v := os.Getenv("CLAMD_STREAM_MAX_MB"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid CLAMD_STREAM_MAX_MB: %w", err)
+		}
+		if parsed <= 0 {
+			return Config{}, errors.New("CLAMD_STREAM_MAX_MB must be greater than zero")
+		}
+		cfg.Upload.Scan.ClamdStreamMax = int64(parsed) * 1024 * 1024
+	}
+
+	if v := os.Getenv("UPLOAD_SCAN_FAIL_OPEN"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid UPLOAD_SCAN_FAIL_OPEN: %w", err)
+		}
+		cfg.Upload.Scan.FailOpen = enabled
+	}
+
+	if v := os.Getenv("UPLOAD_DOCUMENT_MAX_MB"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid UPLOAD_DOCUMENT_MAX_MB: %w", err)
+		}
+		if parsed <= 0 {
+			return Config{}, errors.New("UPLOAD_DOCUMENT_MAX_MB must be greater than zero")
+		}
+		cfg.Upload.Document.MaxBytes = int64(parsed) * 1024 * 1024
+	}
+
+	if v := os.Getenv("DOCUMENT_CHUNK_RUNES"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid DOCUMENT_CHUNK_RUNES: %w", err)
+		}
+		if parsed < minDocumentChunkRunes {
+			return Config{}, fmt.Errorf("DOCUMENT_CHUNK_RUNES must be at least %d", minDocumentChunkRunes)
+		}
+		cfg.Upload.Document.ChunkRunes = parsed
+	}
+
+	if v := os.Getenv("DOCUMENT_MAX_CHUNKS"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid DOCUMENT_MAX_CHUNKS: %w", err)
+		}
+		if parsed <= 0 {
+			return Config{}, errors.New("DOCUMENT_MAX_CHUNKS must be greater than zero")
+		}
+		cfg.Upload.Document.MaxChunks = parsed
+	}
+
 	if v := os.Getenv("UPLOAD_RATE_LIMIT_PER_MIN"); v != "" {
 		parsed, err := strconv.Atoi(v)
 		if err != nil {
@@ -375,6 +745,65 @@ func Load() (Config, error) {
 		cfg.ChatModel = v
 	}
 
+	if v := os.Getenv("CHAT_EXPOSE_PROMPT"); v != "" {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid CHAT_EXPOSE_PROMPT: %w", err)
+		}
+		cfg.ChatExposePrompt = parsed
+	}
+
+	cfg.ChatExperiment = strings.TrimSpace(os.Getenv("CHAT_EXPERIMENT"))
+
+	if v := os.Getenv("AI_RECORD"); v != "" {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid AI_RECORD: %w", err)
+		}
+		cfg.AIRecord = parsed
+	}
+
+	if v := os.Getenv("ANSWER_CACHE_ENABLED"); v != "" {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid ANSWER_CACHE_ENABLED: %w", err)
+		}
+		cfg.AnswerCache.Enabled = parsed
+	}
+
+	if v := os.Getenv("ANSWER_CACHE_TTL_SECONDS"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid ANSWER_CACHE_TTL_SECONDS: %w", err)
+		}
+		if parsed <= 0 {
+			return Config{}, errors.New("ANSWER_CACHE_TTL_SECONDS must be greater than zero")
+		}
+		cfg.AnswerCache.TTL = time.Duration(parsed) * time.Second
+	}
+
+	if v := os.Getenv("ANSWER_CACHE_MAX_ENTRIES"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid ANSWER_CACHE_MAX_ENTRIES: %w", err)
+		}
+		if parsed <= 0 {
+			return Config{}, errors.New("ANSWER_CACHE_MAX_ENTRIES must be greater than zero")
+		}
+		cfg.AnswerCache.MaxEntries = parsed
+	}
+
+	if v := os.Getenv("ANSWER_CACHE_SIMILARITY"); v != "" {
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid ANSWER_CACHE_SIMILARITY: %w", err)
+		}
+		if parsed < 0 || parsed > 1 {
+			return Config{}, errors.New("ANSWER_CACHE_SIMILARITY must be between 0 and 1")
+		}
+		cfg.AnswerCache.Similarity = parsed
+	}
+
 	timeoutMS := defaultExternalHTTPTimeoutMS
 	if v := os.Getenv("HTTP_TIMEOUT_MS"); v != "" {
 		parsed, err := strconv.Atoi(v)
@@ -436,6 +865,52 @@ func perMinuteFromWindow(per5min int) int {
 	return perMinute
 }
 
+func populateJWTConfig(cfg *Config) error {
+	activeID := strings.TrimSpace(os.Getenv("JWT_ACTIVE_KID"))
+	if activeID != "" {
+		var active []byte
+		if inline := os.Getenv("JWT_PRIVATE_KEY"); strings.TrimSpace(inline) != "" {
+			// Allow single-line env values with escaped newlines.
+			active = []byte(strings.ReplaceAll(inline, `\n`, "\n"))
+		} else if path := strings.TrimSpace(os.Getenv("JWT_PRIVATE_KEY_FILE")); path != "" {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("read JWT_PRIVATE_KEY_FILE: %w", err)
+			}
+			active = data
+		} else {
+			return errors.New("JWT_PRIVATE_KEY or JWT_PRIVATE_KEY_FILE is required when JWT_ACTIVE_KID is set")
+		}
+		cfg.JWTKeys.ActiveKeyID = activeID
+		cfg.JWTKeys.Keys = append(cfg.JWTKeys.Keys, JWTKeyConfig{ID: activeID, PEM: active})
+
+		for _, entry := range splitAndTrim(os.Getenv("JWT_VERIFY_KEY_FILES")) {
+			kid, path, ok := strings.Cut(entry, "=")
+			kid, path = strings.TrimSpace(kid), strings.TrimSpace(path)
+			if !ok || kid == "" || path == "" {
+				return fmt.Errorf("invalid JWT_VERIFY_KEY_FILES entry %q, expected kid=path", entry)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("read JWT_VERIFY_KEY_FILES key %q: %w", kid, err)
+			}
+			cfg.JWTKeys.Keys = append(cfg.JWTKeys.Keys, JWTKeyConfig{ID: kid, PEM: data})
+		}
+	} else if os.Getenv("JWT_VERIFY_KEY_FILES") != "" {
+		return errors.New("JWT_ACTIVE_KID is required when JWT_VERIFY_KEY_FILES is set")
+	}
+
+	// With asymmetric keys the secret is optional and only verifies legacy HS256 tokens.
+	cfg.JWTSecret = os.Getenv("JWT_SECRET")
+	if cfg.JWTSecret == "" && activeID != "" {
+		return nil
+	}
+	if len(cfg.JWTSecret) < minJWTSecretLength {
+		return errors.New("JWT_SECRET must be at least 32 characters")
+	}
+	return nil
+}
+
 func populateStorageConfig(cfg *Config) error {
 	switch cfg.Storage.Driver {
 	case StorageDriverSupabase:
@@ -501,6 +976,41 @@ func populateStorageConfig(cfg *Config) error {
 			SecretAccessKey: secretKey,
 			ForcePathStyle:  forcePathStyle,
 		}
+	case StorageDriverLocal:
+		local := LocalStorageConfig{
+			Dir:         strings.TrimSpace(getEnv("LOCAL_STORAGE_DIR", defaultLocalStorageDir)),
+			PublicURL:   strings.TrimSuffix(strings.TrimSpace(getEnv("LOCAL_STORAGE_PUBLIC_URL", defaultLocalStorageURL)), "/"),
+			SigningKey:  strings.TrimSpace(os.Getenv("LOCAL_STORAGE_SIGNING_KEY")),
+			URLTTL:      time.Duration(defaultLocalURLTTLSeconds) * time.Second,
+			CacheMaxAge: time.Duration(defaultLocalCacheMaxAgeSec) * time.Second,
+		}
+		if local.Dir == "" {
+			return errors.New("LOCAL_STORAGE_DIR must not be empty")
+		}
+		if local.SigningKey != "" && len(local.SigningKey) < minJWTSecretLength {
+			return fmt.Errorf("LOCAL_STORAGE_SIGNING_KEY must be at least %d characters", minJWTSecretLength)
+		}
+		if v := os.Getenv("LOCAL_STORAGE_URL_TTL_SEC"); v != "" {
+			parsed, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("invalid LOCAL_STORAGE_URL_TTL_SEC: %w", err)
+			}
+			if parsed <= 0 {
+				return errors.New("LOCAL_STORAGE_URL_TTL_SEC must be greater than zero")
+			}
+			local.URLTTL = time.Duration(parsed) * time.Second
+		}
+		if v := os.Getenv("LOCAL_STORAGE_CACHE_MAX_AGE_SEC"); v != "" {
+			parsed, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("invalid LOCAL_STORAGE_CACHE_MAX_AGE_SEC: %w", err)
+			}
+			if parsed < 0 {
+				return errors.New("LOCAL_STORAGE_CACHE_MAX_AGE_SEC must not be negative")
+			}
+			local.CacheMaxAge = time.Duration(parsed) * time.Second
+		}
+		cfg.Storage.Local = local
 	default:
 		return fmt.Errorf("unsupported STORAGE_DRIVER: %s", cfg.Storage.Driver)
 	}
@@ -553,3 +1063,40 @@ func splitAndTrim(value string) []string {
 	}
 	return result
 }
+
+// parseMIMEList reads a comma separated list of MIME types, lowercased.
+func parseMIMEList(value string) []string {
+	tokens := strings.Split(value, ",")
+	allowed := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		trimmed := strings.TrimSpace(token)
+		if trimmed != "" {
+			allowed = append(allowed, strings.ToLower(trimmed))
+		}
+	}
+	return allowed
+}
+
+// parseImageVariants reads "name:width" pairs such as "thumb:320,medium:960". Names
+// become part of storage keys, so only lowercase letters, digits and dashes are accepted.
+func parseImageVariants(value string) ([]ImageVariantConfig, error) {
+	variants := []ImageVariantConfig{}
+	seen := map[string]bool{}
+	for _, part := range splitAndTrim(value) {
+		name, widthText, ok := strings.Cut(part, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.Trim(name, "abcdefghijklmnopqrstuvwxyz0123456789-") != "" {
+			return nil, fmt.Errorf("variant %q must look like name:width", part)
+		}
+		width, err := strconv.Atoi(strings.TrimSpace(widthText))
+		if err != nil || width <= 0 {
+			return nil, fmt.Errorf("variant %q must have a positive width", part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate variant %q", name)
+		}
+		seen[name] = true
+		variants = append(variants, ImageVariantConfig{Name: name, Width: width})
+	}
+	return variants, nil
+}
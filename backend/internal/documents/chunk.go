@@ -0,0 +1,112 @@
+package documents
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultChunkRunes is used when Chunk is called with a non-positive size.
+const DefaultChunkRunes = 1200
+
+// Chunk splits text into pieces of at most maxRunes runes. Whole paragraphs are packed
+// together while they fit; longer paragraphs are split on sentence boundaries, then on
+// words, and only a single oversized word is ever cut mid-way.
+func Chunk(text string, maxRunes int) []string {
+	if maxRunes <= 0 {
+		maxRunes = DefaultChunkRunes
+	}
+
+	var (
+		chunks  []string
+		current strings.Builder
+		size    int
+	)
+	flush := func() {
+		if size > 0 {
+			chunks = append(chunks, current.String())
+		}
+		current.Reset()
+		size = 0
+	}
+	add := func(piece, sep string) {
+		n := utf8.RuneCountInString(piece)
+		if size > 0 && size+utf8.RuneCountInString(sep)+n > maxRunes {
+			flush()
+		}
+		if size > 0 {
+			current.WriteString(sep)
+			size += utf8.RuneCountInString(sep)
+		}
+		current.WriteString(piece)
+		size += n
+	}
+
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		if utf8.RuneCountInString(paragraph) <= maxRunes {
+			add(paragraph, "\n\n")
+			continue
+		}
+		for i, piece := range splitLong(paragraph, maxRunes) {
+			sep := " "
+			if i == 0 {
+				sep = "\n\n"
+			}
+			add(piece, sep)
+		}
+	}
+	flush()
+	return chunks
+}
+
+// splitLong breaks a paragraph into sentences, falling back to words and finally to
+// fixed rune windows so that every returned piece fits in maxRunes.
+func splitLong(paragraph string, maxRunes int) []string {
+	var pieces []string
+	for _, sentence := range splitSentences(paragraph) {
+		if utf8.RuneCountInString(sentence) <= maxRunes {
+			pieces = append(pieces, sentence)
+			continue
+		}
+		for _, word := range strings.Fields(sentence) {
+			runes := []rune(word)
+			for len(runes) > maxRunes {
+				pieces = append(pieces, string(runes[:maxRunes]))
+				runes = runes[maxRunes:]
+			}
+			pieces = append(pieces, string(runes))
+		}
+	}
+	return pieces
+}
+
+// splitSentences cuts after '.', '!' or '?' followed by whitespace and at line breaks.
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	runes := []rune(text)
+	for i, r := range runes {
+		end := false
+		switch {
+		case r == '\n':
+			end = true
+		case r == '.' || r == '!' || r == '?':
+			end = i+1 < len(runes) && unicode.IsSpace(runes[i+1])
+		}
+		if !end {
+			continue
+		}
+		if sentence := strings.TrimSpace(string(runes[start : i+1])); sentence != "" {
+			sentences = append(sentences, sentence)
+		}
+		start = i + 1
+	}
+	if sentence := strings.TrimSpace(string(runes[start:])); sentence != "" {
+		sentences = append(sentences, sentence)
+	}
+	return sentences
+}
@@ -0,0 +1,126 @@
+package documents
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// minimalPDF renders a single page PDF that shows each line with the Helvetica base font.
+func minimalPDF(lines ...string) []byte {
+	var stream strings.Builder
+	for i, line := range lines {
+		fmt.Fprintf(&stream, "BT /F1 12 Tf 72 %d Td (%s) Tj ET\n", 720-i*16, line)
+	}
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 4 0 R >> >> /Contents 5 0 R >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", stream.Len(), stream.String()),
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+func TestExtractPDF(t *testing.T) {
+	text, err := Extract(minimalPDF("Harga paket landing page mulai 5 juta.", "Pengerjaan dua minggu."), TypePDF)
+	if err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	if !strings.Contains(text, "Harga paket landing page mulai 5 juta.") || !strings.Contains(text, "Pengerjaan dua minggu.") {
+		t.Fatalf("unexpected text %q", text)
+	}
+}
+
+func TestExtractPDFRejectsBrokenFiles(t *testing.T) {
+	_, err := Extract([]byte("%PDF-1.4\nnot really a pdf"), TypePDF)
+	if !errors.Is(err, ErrInvalidDocument) {
+		t.Fatalf("expected ErrInvalidDocument, got %v", err)
+	}
+}
+
+func TestExtractPDFWithoutText(t *testing.T) {
+	_, err := Extract(minimalPDF(), TypePDF)
+	if !errors.Is(err, ErrNoText) {
+		t.Fatalf("expected ErrNoText, got %v", err)
+	}
+}
+
+func TestExtractMarkdownStripsSyntax(t *testing.T) {
+	src := "---\ntitle: Harga\n---\n# Paket **Website**\n\n> Catatan penting\n\n* Desain [Figma](https://figma.com/x)\n* Kode `Go`\n\n```go\nfmt.Println(1)\n```\n\n![logo](logo.png)\n"
+	text, err := Extract([]byte(src), TypeMarkdown)
+	if err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	want := "Paket Website\n\nCatatan penting\n\n- Desain Figma (https://figma.com/x)\n- Kode Go\n\nfmt.Println(1)\n\nlogo"
+	if text != want {
+		t.Fatalf("unexpected markdown text:\n%q\nwant\n%q", text, want)
+	}
+}
+
+func TestExtractTextNormalizesWhitespace(t *testing.T) {
+	text, err := Extract([]byte("  satu\tdua \r\n\r\n\r\n\x00tiga\xff  "), TypeText)
+	if err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	if text != "satu dua\n\ntiga" {
+		t.Fatalf("unexpected text %q", text)
+	}
+
+	if _, err := Extract([]byte(" \n\t "), TypeText); !errors.Is(err, ErrNoText) {
+		t.Fatalf("expected ErrNoText, got %v", err)
+	}
+	if _, err := Extract([]byte("x"), "application/msword"); !errors.Is(err, ErrUnsupportedType) {
+		t.Fatalf("expected ErrUnsupportedType, got %v", err)
+	}
+}
+
+func TestChunkPacksParagraphs(t *testing.T) {
+	chunks := Chunk("Satu.\n\nDua.\n\nTiga.", 12)
+	want := []string{"Satu.\n\nDua.", "Tiga."}
+	if len(chunks) != len(want) {
+		t.Fatalf("expected %d chunks, got %q", len(want), chunks)
+	}
+	for i := range want {
+		if chunks[i] != want[i] {
+			t.Fatalf("chunk %d = %q, want %q", i, chunks[i], want[i])
+		}
+	}
+}
+
+func TestChunkSplitsLongParagraphs(t *testing.T) {
+	paragraph := strings.Repeat("Kalimat pendek di sini. ", 20) + strings.Repeat("x", 70)
+	chunks := Chunk(paragraph, 50)
+	if len(chunks) < 2 {
+		t.Fatalf("expected several chunks, got %q", chunks)
+	}
+	for _, chunk := range chunks {
+		if n := utf8.RuneCountInString(chunk); n > 50 {
+			t.Fatalf("chunk exceeds limit (%d runes): %q", n, chunk)
+		}
+	}
+	if !strings.HasPrefix(chunks[0], "Kalimat pendek di sini.") {
+		t.Fatalf("expected sentence boundary, got %q", chunks[0])
+	}
+	joined := strings.Join(chunks, "")
+	if strings.Count(joined, "x") != 70 {
+		t.Fatalf("oversized word lost runes: %q", chunks)
+	}
+}
@@ -0,0 +1,159 @@
+// Package documents extracts plain text from uploaded PDF, Markdown and text files and
+// splits it into chunks small enough to be quoted in a prompt.
+package documents
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// Content types accepted by Extract.
+const (
+	TypePDF      = "application/pdf"
+	TypeMarkdown = "text/markdown"
+	TypeText     = "text/plain"
+)
+
+var (
+	// ErrUnsupportedType is returned for content types other than PDF, Markdown or text.
+	ErrUnsupportedType = errors.New("documents: unsupported content type")
+	// ErrInvalidDocument is returned when a file cannot be parsed, e.g. a broken or encrypted PDF.
+	ErrInvalidDocument = errors.New("documents: invalid document")
+	// ErrNoText is returned when a document parses but contains no readable text,
+	// which is typical for scanned PDFs without a text layer.
+	ErrNoText = errors.New("documents: no extractable text")
+)
+
+// Supported reports whether Extract understands contentType.
+func Supported(contentType string) bool {
+	switch contentType {
+	case TypePDF, TypeMarkdown, TypeText:
+		return true
+	}
+	return false
+}
+
+// Extract returns the normalized plain text of data. Paragraphs are separated by a
+// blank line so Chunk can split on them.
+func Extract(data []byte, contentType string) (string, error) {
+	var (
+		text string
+		err  error
+	)
+	switch contentType {
+	case TypePDF:
+		text, err = extractPDF(data)
+	case TypeMarkdown:
+		text = stripMarkdown(string(data))
+	case TypeText:
+		text = string(data)
+	default:
+		return "", ErrUnsupportedType
+	}
+	if err != nil {
+		return "", err
+	}
+	text = normalize(text)
+	if text == "" {
+		return "", ErrNoText
+	}
+	return text, nil
+}
+
+// extractPDF reads the text layer of every page. The parser panics on some malformed
+// input, so panics are turned into ErrInvalidDocument.
+func extractPDF(data []byte) (text string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			text, err = "", fmt.Errorf("%w: %v", ErrInvalidDocument, r)
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+	}
+
+	var builder strings.Builder
+	for i := 1; i <= reader.NumPage(); i++ {
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		content, err := page.GetPlainText(nil)
+		if err != nil {
+			return "", fmt.Errorf("%w: page %d: %v", ErrInvalidDocument, i, err)
+		}
+		builder.WriteString(content)
+		// Page breaks always end a paragraph.
+		builder.WriteString("\n\n")
+	}
+	return builder.String(), nil
+}
+
+var (
+	mdFrontMatter = regexp.MustCompile(`(?s)\A---\n.*?\n---\n`)
+	mdFence       = regexp.MustCompile("(?m)^[ \t]*(```|~~~).*$")
+	mdHeading     = regexp.MustCompile(`(?m)^[ \t]{0,3}#{1,6}[ \t]+`)
+	mdQuote       = regexp.MustCompile(`(?m)^[ \t]{0,3}>[ \t]?`)
+	mdListMarker  = regexp.MustCompile(`(?m)^([ \t]*)(?:[-*+]|\d+[.)])[ \t]+`)
+	mdRule        = regexp.MustCompile(`(?m)^[ \t]{0,3}(?:[-*_][ \t]*){3,}$`)
+	mdImage       = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdLink        = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)[^)]*\)`)
+	mdEmphasis    = regexp.MustCompile("(\\*\\*|__|\\*|`|~~)([^*`~\\n]+)(\\*\\*|__|\\*|`|~~)")
+	mdHTMLTag     = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+)
+
+// stripMarkdown removes formatting syntax while keeping the readable text. Links keep
+// their target in parentheses so answers can point visitors at it.
+func stripMarkdown(src string) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = mdFrontMatter.ReplaceAllString(src, "")
+	src = mdFence.ReplaceAllString(src, "")
+	src = mdRule.ReplaceAllString(src, "")
+	src = mdHeading.ReplaceAllString(src, "")
+	src = mdQuote.ReplaceAllString(src, "")
+	src = mdListMarker.ReplaceAllString(src, "$1- ")
+	src = mdImage.ReplaceAllString(src, "$1")
+	src = mdLink.ReplaceAllString(src, "$1 ($2)")
+	src = mdEmphasis.ReplaceAllString(src, "$2")
+	src = mdHTMLTag.ReplaceAllString(src, "")
+	return src
+}
+
+// normalize drops control characters and invalid UTF-8, collapses runs of spaces and
+// keeps at most one blank line between paragraphs.
+func normalize(text string) string {
+	text = strings.ToValidUTF8(text, "")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+
+	lines := strings.Split(text, "\n")
+	var builder strings.Builder
+	blank := 0
+	for _, line := range lines {
+		line = strings.Join(strings.FieldsFunc(line, func(r rune) bool {
+			return unicode.IsSpace(r) || unicode.IsControl(r)
+		}), " ")
+		if line == "" {
+			blank++
+			continue
+		}
+		if builder.Len() > 0 {
+			if blank > 0 {
+				builder.WriteString("\n\n")
+			} else {
+				builder.WriteString("\n")
+			}
+		}
+		blank = 0
+		builder.WriteString(line)
+	}
+	return builder.String()
+}
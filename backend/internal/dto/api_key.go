@@ -0,0 +1,53 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/tanydotai/tanyai/backend/internal/models"
+)
+
+// CreateAPIKeyRequest defines payload for issuing an API key.
+type CreateAPIKeyRequest struct {
+	Name            string     `json:"name" binding:"required,max=120"`
+	Scopes          []string   `json:"scopes" binding:"required,min=1,dive,required"`
+	RateLimitPerMin int        `json:"rateLimitPerMin" binding:"omitempty,min=1,max=10000"`
+	ExpiresAt       *time.Time `json:"expiresAt"`
+}
+
+// APIKeyResponse describes an API key without its secret.
+type APIKeyResponse struct {
+	ID              string     `json:"id"`
+	Name            string     `json:"name"`
+	Prefix          string     `json:"prefix"`
+	Scopes          []string   `json:"scopes"`
+	RateLimitPerMin int        `json:"rateLimitPerMin"`
+	ExpiresAt       *time.Time `json:"expiresAt"`
+	LastUsedAt      *time.Time `json:"lastUsedAt"`
+	RevokedAt       *time.Time `json:"revokedAt"`
+	CreatedAt       time.Time  `json:"createdAt"`
+}
+
+// CreatedAPIKeyResponse returns a freshly issued key. Key is only ever shown once.
+type CreatedAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+// NewAPIKeyResponse converts an API key to its response representation.
+func NewAPIKeyResponse(key models.APIKey) APIKeyResponse {
+	scopes := []string(key.Scopes)
+	if scopes == nil {
+		scopes = []string{}
+	}
+	return APIKeyResponse{
+		ID:              key.ID.String(),
+		Name:            key.Name,
+		Prefix:          key.Prefix,
+		Scopes:          scopes,
+		RateLimitPerMin: key.RateLimitPerMin,
+		ExpiresAt:       key.ExpiresAt,
+		LastUsedAt:      key.LastUsedAt,
+		RevokedAt:       key.RevokedAt,
+		CreatedAt:       key.CreatedAt,
+	}
+}
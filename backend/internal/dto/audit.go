@@ -0,0 +1,56 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/tanydotai/tanyai/backend/internal/models"
+)
+
+// AuditActorResponse identifies who made an audited change.
+type AuditActorResponse struct {
+	UserID   *string `json:"userId"`
+	APIKeyID *string `json:"apiKeyId"`
+	Email    string  `json:"email"`
+	IP       string  `json:"ip"`
+}
+
+// AuditLogResponse describes a single audit log entry.
+type AuditLogResponse struct {
+	ID         string             `json:"id"`
+	Actor      AuditActorResponse `json:"actor"`
+	Action     string             `json:"action"`
+	EntityType string             `json:"entityType"`
+	EntityID   string             `json:"entityId"`
+	Before     map[string]any     `json:"before"`
+	After      map[string]any     `json:"after"`
+	Changes    map[string]any     `json:"changes"`
+	CreatedAt  time.Time          `json:"createdAt"`
+}
+
+// NewAuditLogResponse converts an audit entry to its response representation.
+func NewAuditLogResponse(entry models.AuditLog) AuditLogResponse {
+	actor := AuditActorResponse{Email: entry.ActorEmail, IP: entry.IPAddress}
+	if entry.ActorUserID != nil {
+		id := entry.ActorUserID.String()
+		actor.UserID = &id
+	}
+	if entry.ActorAPIKeyID != nil {
+		id := entry.ActorAPIKeyID.String()
+		actor.APIKeyID = &id
+	}
+	changes := map[string]any(entry.Changes)
+	if changes == nil {
+		changes = map[string]any{}
+	}
+	return AuditLogResponse{
+		ID:         entry.ID.String(),
+		Actor:      actor,
+		Action:     entry.Action,
+		EntityType: entry.EntityType,
+		EntityID:   entry.EntityID,
+		Before:     entry.Before,
+		After:      entry.After,
+		Changes:    changes,
+		CreatedAt:  entry.CreatedAt,
+	}
+}
@@ -0,0 +1,269 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/repos"
+	"github.com/tanydotai/tanyai/backend/internal/services/portfolio"
+)
+
+// BundleVersion is the format version written by exports and accepted by imports.
+const BundleVersion = 1
+
+// Bundle is the versioned export format of a tenant's content. Entries reuse the
+// validation rules of the regular admin request payloads.
+type Bundle struct {
+	Version         int                    `json:"version" binding:"required"`
+	ExportedAt      time.Time              `json:"exported_at"`
+	Profile         *ProfileRequest        `json:"profile"`
+	Skills          []BundleSkill          `json:"skills" binding:"dive"`
+	Services        []BundleService        `json:"services" binding:"dive"`
+	Projects        []BundleProject        `json:"projects" binding:"dive"`
+	ExternalSources []BundleExternalSource `json:"external_sources" binding:"dive"`
+}
+
+// BundleSkill is a skill entry of a bundle.
+type BundleSkill struct {
+	ID uuid.UUID `json:"id" binding:"required"`
+	SkillRequest
+}
+
+// BundleService is a service entry of a bundle.
+type BundleService struct {
+	ID uuid.UUID `json:"id" binding:"required"`
+	ServiceRequest
+}
+
+// BundleProject is a project entry of a bundle.
+type BundleProject struct {
+	ID uuid.UUID `json:"id" binding:"required"`
+	ProjectRequest
+}
+
+// BundleExternalSource is an external source entry of a bundle.
+type BundleExternalSource struct {
+	ID uuid.UUID `json:"id" binding:"required"`
+	ExternalSourceRequest
+}
+
+// ExternalSourceRequest describes an external knowledge source.
+type ExternalSourceRequest struct {
+	Name       string `json:"name" binding:"required,min=2,max=120"`
+	BaseURL    string `json:"base_url" binding:"required,url"`
+	SourceType string `json:"source_type" binding:"required,max=40"`
+	Enabled    bool   `json:"enabled"`
+}
+
+// UploadManifestEntry references an uploaded file used by exported content.
+type UploadManifestEntry struct {
+	Entity   string `json:"entity"`
+	EntityID string `json:"entity_id"`
+	Field    string `json:"field"`
+	URL      string `json:"url"`
+}
+
+// NewBundle converts exported content into its bundle representation.
+func NewBundle(content repos.PortfolioContent, exportedAt time.Time) Bundle {
+	bundle := Bundle{
+		Version:         BundleVersion,
+		ExportedAt:      exportedAt.UTC(),
+		Skills:          make([]BundleSkill, 0, len(content.Skills)),
+		Services:        make([]BundleService, 0, len(content.Services)),
+		Projects:        make([]BundleProject, 0, len(content.Projects)),
+		ExternalSources: make([]BundleExternalSource, 0, len(content.ExternalSources)),
+	}
+	if profile := content.Profile; profile != nil {
+		id := profile.ID
+		bundle.Profile = &ProfileRequest{
+			ID:             &id,
+			Name:           profile.Name,
+			Title:          profile.Title,
+			Bio:            profile.Bio,
+			Email:          profile.Email,
+			Phone:          profile.Phone,
+			Location:       profile.Location,
+			AvatarURL:      profile.AvatarURL,
+			AvatarVariants: NewImageSetRequest(profile.AvatarVariants),
+		}
+	}
+	for _, skill := range content.Skills {
+		order := skill.Order
+		bundle.Skills = append(bundle.Skills, BundleSkill{ID: skill.ID, SkillRequest: SkillRequest{Name: skill.Name, Order: &order}})
+	}
+	for _, service := range content.Services {
+		isActive, order := service.IsActive, service.Order
+		bundle.Services = append(bundle.Services, BundleService{ID: service.ID, ServiceRequest: ServiceRequest{
+			Name:          service.Name,
+			Description:   service.Description.String,
+			PriceMin:      nullFloat64Pointer(service.PriceMin),
+			PriceMax:      nullFloat64Pointer(service.PriceMax),
+			Currency:      service.Currency.String,
+			DurationLabel: service.DurationLabel.String,
+			IsActive:      &isActive,
+			Order:         &order,
+		}})
+	}
+	for _, project := range content.Projects {
+		isFeatured, order := project.IsFeatured, project.Order
+		bundle.Projects = append(bundle.Projects, BundleProject{ID: project.ID, ProjectRequest: ProjectRequest{
+			Title:         project.Title,
+			Description:   project.Description.String,
+			TechStack:     []string(project.TechStack),
+			ImageURL:      project.ImageURL.String,
+			ImageVariants: NewImageSetRequest(project.ImageVariants),
+			ProjectURL:    project.ProjectURL.String,
+			Category:      project.Category.String,
+			DurationLabel: project.DurationLabel.String,
+			PriceLabel:    project.PriceLabel.String,
+			BudgetLabel:   project.BudgetLabel.String,
+			Order:         &order,
+			IsFeatured:    &isFeatured,
+		}})
+	}
+	for _, source := range content.ExternalSources {
+		bundle.ExternalSources = append(bundle.ExternalSources, BundleExternalSource{ID: source.ID, ExternalSourceRequest: ExternalSourceRequest{
+			Name:       source.Name,
+			BaseURL:    source.BaseURL,
+			SourceType: source.SourceType,
+			Enabled:    source.Enabled,
+		}})
+	}
+	return bundle
+}
+
+// Content converts the bundle into the models written by an import.
+func (b Bundle) Content() repos.PortfolioContent {
+	content := repos.PortfolioContent{
+		Skills:          make([]models.Skill, 0, len(b.Skills)),
+		Services:        make([]models.Service, 0, len(b.Services)),
+		Projects:        make([]models.Project, 0, len(b.Projects)),
+		ExternalSources: make([]models.ExternalSource, 0, len(b.ExternalSources)),
+	}
+	if b.Profile != nil {
+		profile := b.Profile.ToModel(uuid.Nil)
+		content.Profile = &profile
+	}
+	for _, skill := range b.Skills {
+		model := models.Skill{ID: skill.ID, Name: skill.Name}
+		if skill.Order != nil {
+			model.Order = *skill.Order
+		}
+		content.Skills = append(content.Skills, model)
+	}
+	for _, service := range b.Services {
+		content.Services = append(content.Services, service.ToModel(service.ID, models.Service{IsActive: true}))
+	}
+	for _, project := range b.Projects {
+		content.Projects = append(content.Projects, project.ToModel(project.ID, models.Project{TechStack: pq.StringArray{}}))
+	}
+	for _, source := range b.ExternalSources {
+		content.ExternalSources = append(content.ExternalSources, models.ExternalSource{
+			ID:         source.ID,
+			Name:       source.Name,
+			BaseURL:    source.BaseURL,
+			SourceType: source.SourceType,
+			Enabled:    source.Enabled,
+		})
+	}
+	return content
+}
+
+// UploadManifest lists the file URLs referenced by the bundle so they can be copied
+// alongside it.
+func (b Bundle) UploadManifest() []UploadManifestEntry {
+	manifest := []UploadManifestEntry{}
+	if b.Profile != nil {
+		entityID := ""
+		if b.Profile.ID != nil {
+			entityID = b.Profile.ID.String()
+		}
+		if b.Profile.AvatarURL != "" {
+			manifest = append(manifest, UploadManifestEntry{Entity: "profile", EntityID: entityID, Field: "avatar_url", URL: b.Profile.AvatarURL})
+		}
+		manifest = appendVariantManifest(manifest, "profile", entityID, "avatar_variants", b.Profile.AvatarVariants)
+	}
+	for _, project := range b.Projects {
+		if project.ImageURL != "" {
+			manifest = append(manifest, UploadManifestEntry{Entity: "projects", EntityID: project.ID.String(), Field: "image_url", URL: project.ImageURL})
+		}
+		manifest = appendVariantManifest(manifest, "projects", project.ID.String(), "image_variants", project.ImageVariants)
+	}
+	return manifest
+}
+
+func appendVariantManifest(manifest []UploadManifestEntry, entity, entityID, field string, set *ImageSetRequest) []UploadManifestEntry {
+	if set == nil {
+		return manifest
+	}
+	for _, variant := range set.Variants {
+		manifest = append(manifest, UploadManifestEntry{Entity: entity, EntityID: entityID, Field: field, URL: variant.URL})
+		if variant.WebPURL != "" {
+			manifest = append(manifest, UploadManifestEntry{Entity: entity, EntityID: entityID, Field: field, URL: variant.WebPURL})
+		}
+	}
+	return manifest
+}
+
+// ImportEntityReport summarises what an import does to one kind of content.
+type ImportEntityReport struct {
+	Created   []string             `json:"created"`
+	Updated   []ImportUpdateReport `json:"updated"`
+	Deleted   []string             `json:"deleted"`
+	Unchanged int                  `json:"unchanged"`
+}
+
+// ImportUpdateReport lists the changed fields of an updated entry.
+type ImportUpdateReport struct {
+	ID      string         `json:"id"`
+	Changes map[string]any `json:"changes"`
+}
+
+// ImportResponse is returned by the import endpoint.
+type ImportResponse struct {
+	DryRun          bool               `json:"dry_run"`
+	Mode            string             `json:"mode"`
+	Published       int                `json:"published"`
+	Profile         ImportEntityReport `json:"profile"`
+	Skills          ImportEntityReport `json:"skills"`
+	Services        ImportEntityReport `json:"services"`
+	Projects        ImportEntityReport `json:"projects"`
+	ExternalSources ImportEntityReport `json:"external_sources"`
+}
+
+// NewImportResponse converts an import report.
+func NewImportResponse(report portfolio.Report, mode string, dryRun bool) ImportResponse {
+	return ImportResponse{
+		DryRun:          dryRun,
+		Mode:            mode,
+		Published:       report.Published,
+		Profile:         newImportEntityReport(report.Profile),
+		Skills:          newImportEntityReport(report.Skills),
+		Services:        newImportEntityReport(report.Services),
+		Projects:        newImportEntityReport(report.Projects),
+		ExternalSources: newImportEntityReport(report.ExternalSources),
+	}
+}
+
+func newImportEntityReport(report portfolio.EntityReport) ImportEntityReport {
+	response := ImportEntityReport{
+		Created:   uuidStrings(report.Created),
+		Updated:   make([]ImportUpdateReport, 0, len(report.Updated)),
+		Deleted:   uuidStrings(report.Deleted),
+		Unchanged: report.Unchanged,
+	}
+	for _, update := range report.Updated {
+		response.Updated = append(response.Updated, ImportUpdateReport{ID: update.ID.String(), Changes: update.Changes})
+	}
+	return response
+}
+
+func uuidStrings(ids []uuid.UUID) []string {
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, id.String())
+	}
+	return out
+}
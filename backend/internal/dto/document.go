@@ -0,0 +1,33 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/tanydotai/tanyai/backend/internal/models"
+)
+
+// DocumentResponse describes an uploaded knowledge document.
+type DocumentResponse struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"contentType"`
+	Size        int64     `json:"size"`
+	URL         string    `json:"url,omitempty"`
+	Chunks      int       `json:"chunks"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// NewDocumentResponse converts a document to its response representation.
+func NewDocumentResponse(doc models.Document) DocumentResponse {
+	return DocumentResponse{
+		ID:          doc.ID.String(),
+		Title:       doc.Title,
+		Filename:    doc.Filename,
+		ContentType: doc.ContentType,
+		Size:        doc.SizeBytes,
+		URL:         doc.URL,
+		Chunks:      doc.Chunks,
+		CreatedAt:   doc.CreatedAt,
+	}
+}
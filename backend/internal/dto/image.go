@@ -0,0 +1,58 @@
+package dto
+
+import "github.com/tanydotai/tanyai/backend/internal/models"
+
+// ImageSetRequest carries the renditions returned by the upload endpoint so clients can
+// attach them to a project image or profile avatar.
+type ImageSetRequest struct {
+	Width         int                   `json:"width" binding:"min=0"`
+	Height        int                   `json:"height" binding:"min=0"`
+	Blurhash      string                `json:"blurhash" binding:"omitempty,max=128"`
+	DominantColor string                `json:"dominant_color" binding:"omitempty,hexcolor"`
+	Variants      []ImageVariantRequest `json:"variants" binding:"max=16,dive"`
+}
+
+// ImageVariantRequest describes one stored rendition.
+type ImageVariantRequest struct {
+	Name    string `json:"name" binding:"required,max=32"`
+	Width   int    `json:"width" binding:"min=0"`
+	Height  int    `json:"height" binding:"min=0"`
+	URL     string `json:"url" binding:"required,url"`
+	WebPURL string `json:"webp_url" binding:"omitempty,url"`
+}
+
+// ToModel converts the request into a models.ImageSet; nil requests stay nil.
+func (r *ImageSetRequest) ToModel() *models.ImageSet {
+	if r == nil {
+		return nil
+	}
+	set := &models.ImageSet{
+		Width:         r.Width,
+		Height:        r.Height,
+		Blurhash:      r.Blurhash,
+		DominantColor: r.DominantColor,
+		Variants:      make([]models.ImageVariant, 0, len(r.Variants)),
+	}
+	for _, variant := range r.Variants {
+		set.Variants = append(set.Variants, models.ImageVariant(variant))
+	}
+	return set
+}
+
+// NewImageSetRequest converts a stored image set back into its request form.
+func NewImageSetRequest(set *models.ImageSet) *ImageSetRequest {
+	if set == nil {
+		return nil
+	}
+	req := &ImageSetRequest{
+		Width:         set.Width,
+		Height:        set.Height,
+		Blurhash:      set.Blurhash,
+		DominantColor: set.DominantColor,
+		Variants:      make([]ImageVariantRequest, 0, len(set.Variants)),
+	}
+	for _, variant := range set.Variants {
+		req.Variants = append(req.Variants, ImageVariantRequest(variant))
+	}
+	return req
+}
@@ -0,0 +1,64 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/tanydotai/tanyai/backend/internal/models"
+)
+
+// MediaResponse describes an uploaded original in the media library.
+type MediaResponse struct {
+	ID            string                   `json:"id"`
+	Key           string                   `json:"key"`
+	URL           string                   `json:"url"`
+	ContentType   string                   `json:"contentType"`
+	Size          int64                    `json:"size"`
+	UploadedBy    *string                  `json:"uploadedBy"`
+	UploaderEmail string                   `json:"uploaderEmail,omitempty"`
+	CreatedAt     time.Time                `json:"createdAt"`
+	Variants      []MediaVariantResponse   `json:"variants"`
+	References    []MediaReferenceResponse `json:"references"`
+	Orphaned      bool                     `json:"orphaned"`
+}
+
+// MediaVariantResponse describes a rendition stored next to an original.
+type MediaVariantResponse struct {
+	Key         string `json:"key"`
+	URL         string `json:"url"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+}
+
+// MediaReferenceResponse names content using an upload.
+type MediaReferenceResponse struct {
+	Entity    string `json:"entity"`
+	ID        string `json:"id"`
+	Published bool   `json:"published"`
+}
+
+// NewMediaResponse converts a media library item to its response representation.
+func NewMediaResponse(item models.MediaItem) MediaResponse {
+	response := MediaResponse{
+		ID:            item.ID.String(),
+		Key:           item.Key,
+		URL:           item.URL,
+		ContentType:   item.ContentType,
+		Size:          item.SizeBytes,
+		UploaderEmail: item.UploaderEmail,
+		CreatedAt:     item.CreatedAt,
+		Variants:      make([]MediaVariantResponse, len(item.Variants)),
+		References:    make([]MediaReferenceResponse, len(item.References)),
+		Orphaned:      len(item.References) == 0,
+	}
+	if item.UploadedBy != nil {
+		uploadedBy := item.UploadedBy.String()
+		response.UploadedBy = &uploadedBy
+	}
+	for i, variant := range item.Variants {
+		response.Variants[i] = MediaVariantResponse{Key: variant.Key, URL: variant.URL, ContentType: variant.ContentType, Size: variant.SizeBytes}
+	}
+	for i, ref := range item.References {
+		response.References[i] = MediaReferenceResponse{Entity: ref.Entity, ID: ref.ID.String(), Published: ref.Published}
+	}
+	return response
+}
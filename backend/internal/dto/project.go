@@ -10,33 +10,37 @@ import (
 
 // ProjectRequest captures payload for creating or updating a project entry.
 type ProjectRequest struct {
-	Title         string   `json:"title" binding:"required,min=2,max=160"`
-	Description   string   `json:"description" binding:"omitempty,max=4000"`
-	TechStack     []string `json:"tech_stack" binding:"omitempty,dive,max=32"`
-	ImageURL      string   `json:"image_url" binding:"omitempty,url"`
-	ProjectURL    string   `json:"project_url" binding:"omitempty,url"`
-	Category      string   `json:"category" binding:"omitempty,max=80"`
-	DurationLabel string   `json:"duration_label" binding:"omitempty,max=80"`
-	PriceLabel    string   `json:"price_label" binding:"omitempty,max=120"`
-	BudgetLabel   string   `json:"budget_label" binding:"omitempty,max=120"`
-	Order         *int     `json:"order" binding:"omitempty,min=0"`
-	IsFeatured    *bool    `json:"is_featured"`
+	Title         string           `json:"title" binding:"required,min=2,max=160"`
+	Description   string           `json:"description" binding:"omitempty,max=4000"`
+	TechStack     []string         `json:"tech_stack" binding:"omitempty,dive,max=32"`
+	ImageURL      string           `json:"image_url" binding:"omitempty,url"`
+	ImageVariants *ImageSetRequest `json:"image_variants"`
+	ProjectURL    string           `json:"project_url" binding:"omitempty,url"`
+	Category      string           `json:"category" binding:"omitempty,max=80"`
+	DurationLabel string           `json:"duration_label" binding:"omitempty,max=80"`
+	PriceLabel    string           `json:"price_label" binding:"omitempty,max=120"`
+	BudgetLabel   string           `json:"budget_label" binding:"omitempty,max=120"`
+	Order         *int             `json:"order" binding:"omitempty,min=0"`
+	IsFeatured    *bool            `json:"is_featured"`
 }
 
 // ProjectResponse is returned by project endpoints.
 type ProjectResponse struct {
-	ID            string   `json:"id"`
-	Title         string   `json:"title"`
-	Description   string   `json:"description"`
-	TechStack     []string `json:"tech_stack"`
-	ImageURL      string   `json:"image_url"`
-	ProjectURL    string   `json:"project_url"`
-	Category      string   `json:"category"`
-	DurationLabel string   `json:"duration_label"`
-	PriceLabel    string   `json:"price_label"`
-	BudgetLabel   string   `json:"budget_label"`
-	Order         int      `json:"order"`
-	IsFeatured    bool     `json:"is_featured"`
+	ID            string           `json:"id"`
+	Title         string           `json:"title"`
+	Description   string           `json:"description"`
+	TechStack     []string         `json:"tech_stack"`
+	ImageURL      string           `json:"image_url"`
+	ImageVariants *models.ImageSet `json:"image_variants"`
+	ProjectURL    string           `json:"project_url"`
+	Category      string           `json:"category"`
+	DurationLabel string           `json:"duration_label"`
+	PriceLabel    string           `json:"price_label"`
+	BudgetLabel   string           `json:"budget_label"`
+	Order         int              `json:"order"`
+	IsFeatured    bool             `json:"is_featured"`
+
+	PublicationResponse
 }
 
 // ProjectReorderItem describes reorder payload.
@@ -60,6 +64,7 @@ func (r ProjectRequest) ToModel(id uuid.UUID, existing models.Project) models.Pr
 		result.TechStack = pq.StringArray(r.TechStack)
 	}
 	result.ImageURL = sql.NullString{String: r.ImageURL, Valid: r.ImageURL != ""}
+	result.ImageVariants = r.ImageVariants.ToModel()
 	result.ProjectURL = sql.NullString{String: r.ProjectURL, Valid: r.ProjectURL != ""}
 	result.Category = sql.NullString{String: r.Category, Valid: r.Category != ""}
 	result.DurationLabel = sql.NullString{String: r.DurationLabel, Valid: r.DurationLabel != ""}
@@ -77,17 +82,19 @@ func (r ProjectRequest) ToModel(id uuid.UUID, existing models.Project) models.Pr
 // NewProjectResponse converts model to response struct.
 func NewProjectResponse(project models.Project) ProjectResponse {
 	return ProjectResponse{
-		ID:            project.ID.String(),
-		Title:         project.Title,
-		Description:   project.Description.String,
-		TechStack:     []string(project.TechStack),
-		ImageURL:      project.ImageURL.String,
-		ProjectURL:    project.ProjectURL.String,
-		Category:      project.Category.String,
-		DurationLabel: project.DurationLabel.String,
-		PriceLabel:    project.PriceLabel.String,
-		BudgetLabel:   project.BudgetLabel.String,
-		Order:         project.Order,
-		IsFeatured:    project.IsFeatured,
+		ID:                  project.ID.String(),
+		Title:               project.Title,
+		Description:         project.Description.String,
+		TechStack:           []string(project.TechStack),
+		ImageURL:            project.ImageURL.String,
+		ImageVariants:       project.ImageVariants,
+		ProjectURL:          project.ProjectURL.String,
+		Category:            project.Category.String,
+		DurationLabel:       project.DurationLabel.String,
+		PriceLabel:          project.PriceLabel.String,
+		BudgetLabel:         project.BudgetLabel.String,
+		Order:               project.Order,
+		IsFeatured:          project.IsFeatured,
+		PublicationResponse: NewPublicationResponse(project.Publication),
 	}
 }
@@ -0,0 +1,73 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+)
+
+// PublicationResponse reports the publish state of draft-enabled content.
+type PublicationResponse struct {
+	Status    string     `json:"status"`
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+// NewPublicationResponse builds the publish state from a model.
+func NewPublicationResponse(publication models.Publication) PublicationResponse {
+	return PublicationResponse{Status: publication.Status(), PublishAt: publication.PublishAt}
+}
+
+// PublishItem references a draft to publish.
+type PublishItem struct {
+	Entity string    `json:"entity" binding:"required,oneof=profile services projects"`
+	ID     uuid.UUID `json:"id" binding:"required"`
+}
+
+// PublishRequest publishes drafts now or, when PublishAt is set, schedules them.
+type PublishRequest struct {
+	Items     []PublishItem `json:"items" binding:"required,min=1,max=100,dive"`
+	PublishAt *time.Time    `json:"publish_at"`
+}
+
+// SchedulePublishRequest is the optional body of a single publish.
+type SchedulePublishRequest struct {
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+// RevisionResponse describes a published revision.
+type RevisionResponse struct {
+	ID          string         `json:"id"`
+	EntityType  string         `json:"entity_type"`
+	EntityID    string         `json:"entity_id"`
+	Version     int            `json:"version"`
+	PublishedBy *string        `json:"published_by"`
+	CreatedAt   time.Time      `json:"created_at"`
+	Data        map[string]any `json:"data,omitempty"`
+}
+
+// RevisionDiffResponse lists the fields that differ between two versions of content.
+type RevisionDiffResponse struct {
+	From    string         `json:"from"`
+	To      string         `json:"to"`
+	Changes map[string]any `json:"changes"`
+}
+
+// NewRevisionResponse converts a revision; data is included only when withData is set.
+func NewRevisionResponse(revision models.ContentRevision, withData bool) RevisionResponse {
+	response := RevisionResponse{
+		ID:         revision.ID.String(),
+		EntityType: revision.EntityType,
+		EntityID:   revision.EntityID.String(),
+		Version:    revision.Version,
+		CreatedAt:  revision.CreatedAt,
+	}
+	if revision.PublishedBy != nil {
+		publishedBy := revision.PublishedBy.String()
+		response.PublishedBy = &publishedBy
+	}
+	if withData {
+		response.Data = revision.Data
+	}
+	return response
+}
@@ -31,6 +31,8 @@ type ServiceResponse struct {
 	DurationLabel string   `json:"duration_label"`
 	IsActive      bool     `json:"is_active"`
 	Order         int      `json:"order"`
+
+	PublicationResponse
 }
 
 // ServiceToggleRequest captures payload for toggle endpoint.
@@ -72,15 +74,16 @@ func (r ServiceRequest) ToModel(id uuid.UUID, existing models.Service) models.Se
 // NewServiceResponse builds response from model.
 func NewServiceResponse(service models.Service) ServiceResponse {
 	return ServiceResponse{
-		ID:            service.ID.String(),
-		Name:          service.Name,
-		Description:   service.Description.String,
-		PriceMin:      nullFloat64Pointer(service.PriceMin),
-		PriceMax:      nullFloat64Pointer(service.PriceMax),
-		Currency:      service.Currency.String,
-		DurationLabel: service.DurationLabel.String,
-		IsActive:      service.IsActive,
-		Order:         service.Order,
+		ID:                  service.ID.String(),
+		Name:                service.Name,
+		Description:         service.Description.String,
+		PriceMin:            nullFloat64Pointer(service.PriceMin),
+		PriceMax:            nullFloat64Pointer(service.PriceMax),
+		Currency:            service.Currency.String,
+		DurationLabel:       service.DurationLabel.String,
+		IsActive:            service.IsActive,
+		Order:               service.Order,
+		PublicationResponse: NewPublicationResponse(service.Publication),
 	}
 }
 
@@ -0,0 +1,31 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/tanydotai/tanyai/backend/internal/models"
+)
+
+// TrashItemResponse describes a soft-deleted entry.
+type TrashItemResponse struct {
+	EntityType string     `json:"entity_type"`
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	DeletedAt  time.Time  `json:"deleted_at"`
+	PurgeAt    *time.Time `json:"purge_at"`
+}
+
+// NewTrashItemResponse converts a trash item; purge_at is omitted when retention is zero.
+func NewTrashItemResponse(item models.TrashItem, retention time.Duration) TrashItemResponse {
+	response := TrashItemResponse{
+		EntityType: item.EntityType,
+		ID:         item.ID.String(),
+		Name:       item.Name,
+		DeletedAt:  item.DeletedAt,
+	}
+	if retention > 0 {
+		purgeAt := item.DeletedAt.Add(retention)
+		response.PurgeAt = &purgeAt
+	}
+	return response
+}
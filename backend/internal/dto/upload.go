@@ -0,0 +1,30 @@
+package dto
+
+import "time"
+
+// PresignUploadRequest declares a file the client wants to upload straight to storage.
+type PresignUploadRequest struct {
+	Filename    string `json:"filename" binding:"max=255"`
+	ContentType string `json:"contentType" binding:"required,max=100"`
+	Size        int64  `json:"size" binding:"required,min=1"`
+}
+
+// PresignUploadResponse tells the client where and how to send the file.
+type PresignUploadResponse struct {
+	Key       string            `json:"key"`
+	UploadURL string            `json:"uploadUrl"`
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers"`
+	ExpiresAt time.Time         `json:"expiresAt"`
+	// Token must be sent back to complete; it binds the key to the tenant and declaration.
+	Token string `json:"token"`
+}
+
+// CompleteUploadRequest registers an object uploaded through a presigned URL. ContentType
+// and Size must match the values sent to presign, and Token is the one it returned.
+type CompleteUploadRequest struct {
+	Key         string `json:"key" binding:"required,max=255"`
+	ContentType string `json:"contentType" binding:"required,max=100"`
+	Size        int64  `json:"size" binding:"required,min=1"`
+	Token       string `json:"token" binding:"required,max=200"`
+}
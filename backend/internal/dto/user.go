@@ -1,21 +1,75 @@
 package dto
 
-import "github.com/tanydotai/tanyai/backend/internal/models"
+import (
+	"time"
+
+	"github.com/tanydotai/tanyai/backend/internal/auth"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+)
 
 // UserResponse represents the authenticated user payload.
 type UserResponse struct {
-	ID    string   `json:"id"`
-	Email string   `json:"email"`
-	Name  *string  `json:"name,omitempty"`
-	Roles []string `json:"roles"`
+	ID          string   `json:"id"`
+	Email       string   `json:"email"`
+	Name        *string  `json:"name,omitempty"`
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+	MFAEnabled  bool     `json:"mfaEnabled"`
 }
 
 // NewUserResponse builds a UserResponse from a user model and roles.
 func NewUserResponse(user models.User, roles []string) UserResponse {
+	perms := auth.PermissionsForRoles(roles)
+	permissions := make([]string, 0, len(perms))
+	for _, perm := range perms {
+		permissions = append(permissions, string(perm))
+	}
 	return UserResponse{
-		ID:    user.ID.String(),
-		Email: user.Email,
-		Name:  user.Name,
-		Roles: append([]string(nil), roles...),
+		ID:          user.ID.String(),
+		Email:       user.Email,
+		Name:        user.Name,
+		Roles:       append([]string(nil), roles...),
+		Permissions: permissions,
+		MFAEnabled:  user.TOTPEnabled,
+	}
+}
+
+// InviteUserRequest defines payload for inviting a new admin user.
+type InviteUserRequest struct {
+	Email string   `json:"email" binding:"required,email,max=254"`
+	Name  *string  `json:"name" binding:"omitempty,max=120"`
+	Roles []string `json:"roles" binding:"required,min=1,dive,required"`
+}
+
+// UpdateUserRolesRequest replaces the roles assigned to a user.
+type UpdateUserRolesRequest struct {
+	Roles []string `json:"roles" binding:"required,min=1,dive,required"`
+}
+
+// AdminUserResponse describes a user in admin listings.
+type AdminUserResponse struct {
+	UserResponse
+	Active      bool      `json:"active"`
+	PasswordSet bool      `json:"passwordSet"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// PasswordTokenResponse returns a freshly issued one-time password token.
+type PasswordTokenResponse struct {
+	User      AdminUserResponse `json:"user"`
+	Token     string            `json:"token"`
+	Purpose   string            `json:"purpose"`
+	ExpiresAt time.Time         `json:"expiresAt"`
+}
+
+// NewAdminUserResponse converts a user and its roles to an admin response.
+func NewAdminUserResponse(user models.User, roles []string) AdminUserResponse {
+	return AdminUserResponse{
+		UserResponse: NewUserResponse(user, roles),
+		Active:       user.IsActive,
+		PasswordSet:  user.PasswordHash != "",
+		CreatedAt:    user.CreatedAt,
+		UpdatedAt:    user.UpdatedAt,
 	}
 }
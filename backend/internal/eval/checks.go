@@ -0,0 +1,237 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/tanydotai/tanyai/backend/internal/services/kb"
+)
+
+// Supported answer languages.
+const (
+	LanguageIndonesian = "id"
+	LanguageEnglish    = "en"
+)
+
+// CheckResult is the outcome of one check on an answer.
+type CheckResult struct {
+	Name    string `json:"name"`
+	Passed  bool   `json:"passed"`
+	Message string `json:"message,omitempty"`
+}
+
+var urlPattern = regexp.MustCompile(`https?://[^\s<>"'()\[\]]+`)
+
+// knowledgeURLs collects every URL that appears anywhere in the knowledge base.
+func knowledgeURLs(base kb.KnowledgeBase) map[string]bool {
+	known := make(map[string]bool)
+	data, err := json.Marshal(base)
+	if err != nil {
+		return known
+	}
+	for _, url := range urlPattern.FindAllString(string(data), -1) {
+		known[normalizeURL(url)] = true
+	}
+	return known
+}
+
+func normalizeURL(url string) string {
+	url = strings.TrimRight(url, ".,;:!?")
+	return strings.TrimSuffix(strings.ToLower(url), "/")
+}
+
+// runChecks applies the deterministic checks to answer.
+func runChecks(answer string, checks Checks, base kb.KnowledgeBase, knownURLs map[string]bool) []CheckResult {
+	results := make([]CheckResult, 0)
+	lower := strings.ToLower(answer)
+
+	for _, phrase := range checks.MustMention {
+		result := CheckResult{Name: "mentions " + phrase, Passed: strings.Contains(lower, strings.ToLower(phrase))}
+		if !result.Passed {
+			result.Message = fmt.Sprintf("answer does not mention %q", phrase)
+		}
+		results = append(results, result)
+	}
+	for _, phrase := range checks.MustNotMention {
+		result := CheckResult{Name: "does not mention " + phrase, Passed: !strings.Contains(lower, strings.ToLower(phrase))}
+		if !result.Passed {
+			result.Message = fmt.Sprintf("answer mentions %q", phrase)
+		}
+		results = append(results, result)
+	}
+	for _, name := range checks.Services {
+		results = append(results, checkService(lower, name, base))
+	}
+	if !checks.AllowUnknownURLs {
+		results = append(results, checkURLs(answer, knownURLs))
+	}
+	if checks.Language != "" {
+		results = append(results, checkLanguage(answer, checks.Language))
+	}
+	if checks.MinWords > 0 || checks.MaxWords > 0 {
+		results = append(results, checkLength(answer, checks.MinWords, checks.MaxWords))
+	}
+	return results
+}
+
+// checkService requires the service name and one of its prices from the knowledge base.
+func checkService(lower, name string, base kb.KnowledgeBase) CheckResult {
+	result := CheckResult{Name: "service " + name}
+	var service *kb.Service
+	for i := range base.Services {
+		if strings.EqualFold(base.Services[i].Name, name) {
+			service = &base.Services[i]
+			break
+		}
+	}
+	if service == nil {
+		result.Message = fmt.Sprintf("service %q is not in the knowledge base", name)
+		return result
+	}
+	if !strings.Contains(lower, strings.ToLower(service.Name)) {
+		result.Message = fmt.Sprintf("answer does not mention service %q", service.Name)
+		return result
+	}
+	if len(service.PriceRange) == 0 {
+		result.Passed = true
+		return result
+	}
+	amounts := answerAmounts(lower)
+	for _, price := range service.PriceRange {
+		if amount, ok := priceAmount(price); ok && amounts[amount] {
+			result.Passed = true
+			return result
+		}
+	}
+	result.Message = fmt.Sprintf("answer does not mention a price of %q (%s)", service.Name, strings.Join(service.PriceRange, ", "))
+	return result
+}
+
+var amountPattern = regexp.MustCompile(`(\d+(?:[.,]\d+)*)(?:\s*(juta|jt|ribu|rb|k)\b)?`)
+
+// answerAmounts extracts the money amounts written in an answer, understanding
+// thousands separators ("5.000.000") and Indonesian shorthands ("5 juta", "2,5jt",
+// "750rb").
+func answerAmounts(lower string) map[int64]bool {
+	amounts := make(map[int64]bool)
+	for _, match := range amountPattern.FindAllStringSubmatch(lower, -1) {
+		number, unit := match[1], match[2]
+		var multiplier float64 = 1
+		switch unit {
+		case "juta", "jt":
+			multiplier = 1_000_000
+		case "ribu", "rb", "k":
+			multiplier = 1_000
+		}
+		var value float64
+		var err error
+		if unit != "" {
+			// Shorthands use a decimal comma: "2,5 juta".
+			value, err = strconv.ParseFloat(strings.ReplaceAll(strings.ReplaceAll(number, ".", ""), ",", "."), 64)
+		} else {
+			value, err = strconv.ParseFloat(strings.NewReplacer(".", "", ",", "").Replace(number), 64)
+		}
+		if err == nil {
+			amounts[int64(value*multiplier+0.5)] = true
+		}
+	}
+	return amounts
+}
+
+// priceAmount parses a knowledge base price such as "IDR 5000000".
+func priceAmount(price string) (int64, bool) {
+	digits := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) || r == '.' {
+			return r
+		}
+		return -1
+	}, price)
+	value, err := strconv.ParseFloat(digits, 64)
+	if err != nil {
+		return 0, false
+	}
+	return int64(value + 0.5), true
+}
+
+// checkURLs fails when the answer links to a URL that is not in the knowledge base.
+func checkURLs(answer string, known map[string]bool) CheckResult {
+	result := CheckResult{Name: "no invented URLs", Passed: true}
+	invented := make([]string, 0)
+	for _, url := range urlPattern.FindAllString(answer, -1) {
+		if !known[normalizeURL(url)] {
+			invented = append(invented, strings.TrimRight(url, ".,;:!?"))
+		}
+	}
+	if len(invented) > 0 {
+		result.Passed = false
+		result.Message = "answer links to URLs not in the knowledge base: " + strings.Join(invented, ", ")
+	}
+	return result
+}
+
+var stopwords = map[string]map[string]bool{
+	LanguageIndonesian: wordSet("yang dan di ke dari untuk dengan ini itu saya anda kami adalah akan bisa tidak juga atau pada dalam sebagai ada karena jika sudah kita mereka oleh"),
+	LanguageEnglish:    wordSet("the and to of in for with this that is are you we can will not also or on as be by it your our have from if they"),
+}
+
+func wordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
+
+// detectLanguage guesses between Indonesian and English by counting stopwords. It
+// returns an empty string when neither language has any hits.
+func detectLanguage(text string) string {
+	counts := make(map[string]int, len(stopwords))
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		for lang, set := range stopwords {
+			if set[word] {
+				counts[lang]++
+			}
+		}
+	}
+	switch {
+	case counts[LanguageIndonesian] == 0 && counts[LanguageEnglish] == 0:
+		return ""
+	case counts[LanguageIndonesian] >= counts[LanguageEnglish]:
+		return LanguageIndonesian
+	default:
+		return LanguageEnglish
+	}
+}
+
+func checkLanguage(answer, want string) CheckResult {
+	result := CheckResult{Name: "language " + want}
+	got := detectLanguage(answer)
+	result.Passed = got == want
+	if !result.Passed {
+		if got == "" {
+			got = "unknown"
+		}
+		result.Message = fmt.Sprintf("answer language looks like %s, want %s", got, want)
+	}
+	return result
+}
+
+func checkLength(answer string, minWords, maxWords int) CheckResult {
+	words := len(strings.Fields(answer))
+	result := CheckResult{Name: "length", Passed: true}
+	switch {
+	case minWords > 0 && words < minWords:
+		result.Passed = false
+		result.Message = fmt.Sprintf("answer has %d words, want at least %d", words, minWords)
+	case maxWords > 0 && words > maxWords:
+		result.Passed = false
+		result.Message = fmt.Sprintf("answer has %d words, want at most %d", words, maxWords)
+	}
+	return result
+}
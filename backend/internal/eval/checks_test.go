@@ -0,0 +1,124 @@
+package eval
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tanydotai/tanyai/backend/internal/services/kb"
+)
+
+func checksBase() kb.KnowledgeBase {
+	return kb.KnowledgeBase{
+		Services: []kb.Service{
+			{ID: "svc-1", Name: "Landing Page", PriceRange: []string{"IDR 3500000", "IDR 6000000"}},
+			{ID: "svc-2", Name: "Konsultasi"},
+		},
+		Projects: []kb.Project{{ID: "prj-1", Title: "Kopi", ProjectURL: "https://kopi.example.com/"}},
+	}
+}
+
+func failedChecks(results []CheckResult) []string {
+	failed := make([]string, 0)
+	for _, result := range results {
+		if !result.Passed {
+			failed = append(failed, result.Name)
+		}
+	}
+	return failed
+}
+
+func TestCheckServiceAcceptsWrittenPrices(t *testing.T) {
+	base := checksBase()
+	answers := []string{
+		"Landing Page mulai dari IDR 3500000.",
+		"Landing Page sekitar Rp 3.500.000 sampai Rp 6.000.000.",
+		"Landing Page kira-kira 6 juta rupiah.",
+		"Landing Page mulai 3,5jt saja.",
+		"Landing Page mulai 3500rb.",
+	}
+	for _, answer := range answers {
+		if result := checkService(strings.ToLower(answer), "Landing Page", base); !result.Passed {
+			t.Fatalf("expected %q to pass, got %q", answer, result.Message)
+		}
+	}
+}
+
+func TestCheckServiceRejectsMissingNameOrPrice(t *testing.T) {
+	base := checksBase()
+	cases := map[string]string{
+		"Landing Page sekitar 4 juta.":   "price",
+		"Halaman promosi sekitar 6 juta": "does not mention service",
+	}
+	for answer, want := range cases {
+		result := checkService(strings.ToLower(answer), "Landing Page", base)
+		if result.Passed || !strings.Contains(result.Message, want) {
+			t.Fatalf("expected %q to fail with %q, got %+v", answer, want, result)
+		}
+	}
+	if result := checkService("konsultasi gratis", "Konsultasi", base); !result.Passed {
+		t.Fatalf("services without prices only need their name, got %q", result.Message)
+	}
+	if result := checkService("apa saja", "Desain Logo", base); result.Passed {
+		t.Fatalf("expected unknown services to fail")
+	}
+}
+
+func TestRunChecksFlagsInventedURLs(t *testing.T) {
+	base := checksBase()
+	known := knowledgeURLs(base)
+
+	ok := runChecks("Lihat https://KOPI.example.com.", Checks{}, base, known)
+	if failed := failedChecks(ok); len(failed) != 0 {
+		t.Fatalf("expected known URL to pass, failed %v", failed)
+	}
+
+	invented := runChecks("Lihat https://kopi.example.com/toko dan https://contoh.id", Checks{}, base, known)
+	if len(invented) != 1 || invented[0].Passed {
+		t.Fatalf("expected invented URLs to fail, got %+v", invented)
+	}
+	if !strings.Contains(invented[0].Message, "https://contoh.id") {
+		t.Fatalf("expected the invented URL in the message, got %q", invented[0].Message)
+	}
+
+	allowed := runChecks("Lihat https://contoh.id", Checks{AllowUnknownURLs: true}, base, known)
+	if len(allowed) != 0 {
+		t.Fatalf("expected no URL check when unknown URLs are allowed, got %+v", allowed)
+	}
+}
+
+func TestRunChecksLanguageLengthAndPhrases(t *testing.T) {
+	base := checksBase()
+	answer := "Tentu, kami bisa membantu Anda dengan Landing Page yang cepat dan rapi."
+	checks := Checks{
+		MustMention:    []string{"landing page"},
+		MustNotMention: []string{"gratis"},
+		Language:       LanguageIndonesian,
+		MinWords:       5,
+		MaxWords:       20,
+	}
+	if failed := failedChecks(runChecks(answer, checks, base, nil)); len(failed) != 0 {
+		t.Fatalf("expected all checks to pass, failed %v", failed)
+	}
+
+	checks.Language = LanguageEnglish
+	checks.MaxWords = 5
+	checks.MustNotMention = []string{"Landing"}
+	failed := failedChecks(runChecks(answer, checks, base, nil))
+	want := []string{"does not mention Landing", "language en", "length"}
+	if strings.Join(failed, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected failures %v, got %v", want, failed)
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	cases := map[string]string{
+		"Saya bisa membantu untuk proyek ini.":    LanguageIndonesian,
+		"We can help you with the project.":       LanguageEnglish,
+		"Landing Page: IDR 3500000 — 2 minggu!!!": "",
+	}
+	for text, want := range cases {
+		if got := detectLanguage(text); got != want {
+			t.Fatalf("detectLanguage(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
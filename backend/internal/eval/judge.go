@@ -0,0 +1,72 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/tanydotai/tanyai/backend/internal/ai"
+)
+
+// DefaultJudgeThreshold is the lowest judge score, on a 1–5 scale, that passes.
+const DefaultJudgeThreshold = 4
+
+const defaultRubric = "Jawaban akurat sesuai konteks, tidak mengarang informasi, relevan dengan pertanyaan, dan ramah."
+
+// Judge asks a provider to grade answers against a rubric.
+type Judge struct {
+	Provider ai.Provider
+	// Threshold is the lowest passing score; zero uses DefaultJudgeThreshold.
+	Threshold int
+}
+
+// Verdict is the judge's grade for one answer.
+type Verdict struct {
+	Score  int    `json:"score"`
+	Reason string `json:"reason"`
+}
+
+var verdictPattern = regexp.MustCompile(`(?s)\{.*\}`)
+
+// Grade scores answer for question. The prompt given to the assistant is included so
+// the judge can tell grounded facts from invented ones.
+func (j *Judge) Grade(ctx context.Context, question, promptText, answer, rubric string) (Verdict, CheckResult) {
+	if rubric == "" {
+		rubric = defaultRubric
+	}
+	threshold := j.Threshold
+	if threshold <= 0 {
+		threshold = DefaultJudgeThreshold
+	}
+	result := CheckResult{Name: "judge"}
+
+	var builder strings.Builder
+	builder.WriteString("Anda menilai jawaban asisten virtual.\n\n")
+	builder.WriteString("Konteks yang diberikan kepada asisten:\n")
+	builder.WriteString(promptText)
+	builder.WriteString("\n\nPertanyaan: ")
+	builder.WriteString(question)
+	builder.WriteString("\n\nJawaban asisten:\n")
+	builder.WriteString(answer)
+	builder.WriteString("\n\nKriteria: ")
+	builder.WriteString(rubric)
+	builder.WriteString("\n\nBeri skor 1 (buruk) sampai 5 (sangat baik). Balas hanya dengan JSON: {\"score\": <1-5>, \"reason\": \"<alasan singkat>\"}")
+
+	resp, err := j.Provider.Generate(ctx, ai.Request{Prompt: builder.String(), MaxTokens: 256, Temperature: 0})
+	if err != nil {
+		result.Message = fmt.Sprintf("judge failed: %v", err)
+		return Verdict{}, result
+	}
+
+	var verdict Verdict
+	raw := verdictPattern.FindString(resp.Text)
+	if raw == "" || json.Unmarshal([]byte(raw), &verdict) != nil || verdict.Score < 1 || verdict.Score > 5 {
+		result.Message = fmt.Sprintf("judge returned an unreadable verdict: %q", strings.TrimSpace(resp.Text))
+		return Verdict{}, result
+	}
+	result.Passed = verdict.Score >= threshold
+	result.Message = fmt.Sprintf("score %d/5: %s", verdict.Score, verdict.Reason)
+	return verdict, result
+}
@@ -0,0 +1,147 @@
+package eval
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Report is the outcome of one suite run.
+type Report struct {
+	Suite     string        `json:"suite"`
+	Provider  string        `json:"provider"`
+	Model     string        `json:"model,omitempty"`
+	StartedAt time.Time     `json:"startedAt"`
+	Duration  time.Duration `json:"durationNs"`
+	Passed    int           `json:"passed"`
+	Failed    int           `json:"failed"`
+	Skipped   int           `json:"skipped,omitempty"`
+	Cases     []CaseResult  `json:"cases"`
+}
+
+// CaseResult is the outcome of one case.
+type CaseResult struct {
+	ID                string        `json:"id"`
+	Question          string        `json:"question"`
+	Answer            string        `json:"answer"`
+	Passed            bool          `json:"passed"`
+	Error             string        `json:"error,omitempty"`
+	Checks            []CheckResult `json:"checks"`
+	JudgeScore        int           `json:"judgeScore,omitempty"`
+	Citations         int           `json:"citations"`
+	RejectedCitations []string      `json:"rejectedCitations,omitempty"`
+	PromptTokens      int           `json:"promptTokens"`
+	// Stale marks cases without a recorded response for their prompt, usually because the
+	// prompt changed since recording. Skipped is set instead of failing them when stale
+	// cases are allowed.
+	Stale    bool          `json:"stale,omitempty"`
+	Skipped  bool          `json:"skipped,omitempty"`
+	Duration time.Duration `json:"durationNs"`
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Props     []junitProperty `xml:"properties>property,omitempty"`
+	Cases     []junitCase     `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the report in the JUnit XML format understood by CI systems. Each
+// case is a test case; failed checks and stale recordings become its failure message,
+// provider errors are reported as errors, and allowed stale cases are skipped.
+func (r Report) WriteJUnit(w io.Writer) error {
+	suite := junitSuite{
+		Name:      r.Suite,
+		Tests:     len(r.Cases),
+		Time:      seconds(r.Duration),
+		Timestamp: r.StartedAt.Format(time.RFC3339),
+		Props:     []junitProperty{{Name: "provider", Value: r.Provider}},
+	}
+	if r.Model != "" {
+		suite.Props = append(suite.Props, junitProperty{Name: "model", Value: r.Model})
+	}
+	for _, c := range r.Cases {
+		tc := junitCase{Name: c.ID, ClassName: r.Suite, Time: seconds(c.Duration), SystemOut: c.Answer}
+		switch {
+		case c.Stale:
+			stale := &junitMessage{Message: staleMessage, Body: c.Error}
+			if c.Skipped {
+				suite.Skipped++
+				tc.Skipped = stale
+			} else {
+				suite.Failures++
+				tc.Failure = stale
+			}
+		case c.Error != "":
+			suite.Errors++
+			tc.Error = &junitMessage{Message: c.Error, Body: c.Question}
+		case !c.Passed:
+			suite.Failures++
+			failed := make([]string, 0)
+			for _, check := range c.Checks {
+				if !check.Passed {
+					failed = append(failed, fmt.Sprintf("%s: %s", check.Name, check.Message))
+				}
+			}
+			tc.Failure = &junitMessage{Message: fmt.Sprintf("%d check(s) failed", len(failed)), Body: strings.Join(failed, "\n")}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(junitSuites{Suites: []junitSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+const staleMessage = "stale recording: the prompt changed since it was recorded; re-record with -record"
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,127 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/tanydotai/tanyai/backend/internal/ai"
+	"github.com/tanydotai/tanyai/backend/internal/services/kb"
+	"github.com/tanydotai/tanyai/backend/internal/services/prompt"
+)
+
+// Generation defaults, matching the chat endpoint.
+const (
+	DefaultMaxTokens   = 2048
+	DefaultTemperature = 0.7
+)
+
+// Runner evaluates suites with one provider.
+type Runner struct {
+	Provider     ai.Provider
+	ProviderName string
+	Model        string
+	// Judge optionally grades every answer with an LLM.
+	Judge       *Judge
+	MaxTokens   int
+	Temperature float32
+	// Prompt tunes prompt assembly; History is taken from each case.
+	Prompt prompt.Options
+	// AllowStale skips cases whose prompt has no recorded response instead of failing
+	// them.
+	AllowStale bool
+	// Now is used for timings; nil uses time.Now.
+	Now func() time.Time
+}
+
+// Run evaluates every case of suite against base. Provider errors fail the case
+// rather than aborting the run. A replayed provider without a recording for a case's
+// prompt marks the case stale, which fails it unless AllowStale is set.
+func (r *Runner) Run(ctx context.Context, suite Suite, base kb.KnowledgeBase) Report {
+	now := r.now
+	started := now()
+	report := Report{
+		Suite:     suite.Name,
+		Provider:  r.ProviderName,
+		Model:     r.Model,
+		StartedAt: started.UTC(),
+		Cases:     make([]CaseResult, 0, len(suite.Cases)),
+	}
+	knownURLs := knowledgeURLs(base)
+
+	for _, c := range suite.Cases {
+		result := r.runCase(ctx, c, c.Checks.merged(suite.Defaults), base, knownURLs)
+		switch {
+		case result.Skipped:
+			report.Skipped++
+		case result.Passed:
+			report.Passed++
+		default:
+			report.Failed++
+		}
+		report.Cases = append(report.Cases, result)
+	}
+	report.Duration = now().Sub(started)
+	return report
+}
+
+func (r *Runner) runCase(ctx context.Context, c Case, checks Checks, base kb.KnowledgeBase, knownURLs map[string]bool) CaseResult {
+	started := r.now()
+	result := CaseResult{ID: c.ID, Question: c.Question, Checks: make([]CheckResult, 0)}
+
+	opts := r.Prompt
+	opts.History = make([]prompt.Turn, 0, len(c.History))
+	for _, turn := range c.History {
+		opts.History = append(opts.History, prompt.Turn{Question: turn.Question, Answer: turn.Answer})
+	}
+	assembled := prompt.Assemble(base, c.Question, opts)
+	result.PromptTokens = assembled.EstimatedTokens
+
+	maxTokens := r.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = DefaultMaxTokens
+	}
+	resp, err := r.Provider.Generate(ctx, ai.Request{
+		Prompt:      assembled.Text,
+		MaxTokens:   maxTokens,
+		Temperature: r.Temperature,
+	})
+	if errors.Is(err, ai.ErrCassetteMiss) {
+		result.Stale = true
+		result.Skipped = r.AllowStale
+	}
+	if err != nil {
+		result.Error = err.Error()
+		result.Duration = r.now().Sub(started)
+		return result
+	}
+
+	answer, citations, rejected := prompt.Cite(strings.TrimSpace(resp.Text), assembled.References)
+	result.Answer = answer
+	result.Citations = len(citations)
+	result.RejectedCitations = rejected
+	result.Checks = runChecks(answer, checks, base, knownURLs)
+	if r.Judge != nil {
+		verdict, check := r.Judge.Grade(ctx, c.Question, assembled.Text, answer, checks.Rubric)
+		result.JudgeScore = verdict.Score
+		result.Checks = append(result.Checks, check)
+	}
+
+	result.Passed = true
+	for _, check := range result.Checks {
+		if !check.Passed {
+			result.Passed = false
+			break
+		}
+	}
+	result.Duration = r.now().Sub(started)
+	return result
+}
+
+func (r *Runner) now() time.Time {
+	if r.Now != nil {
+		return r.Now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,210 @@
+package eval
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tanydotai/tanyai/backend/internal/ai"
+)
+
+type stubProvider struct {
+	text    string
+	err     error
+	prompts []string
+}
+
+func (p *stubProvider) Generate(_ context.Context, req ai.Request) (ai.Response, error) {
+	p.prompts = append(p.prompts, req.Prompt)
+	return ai.Response{Text: p.text}, p.err
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestShippedSuitePassesOffline(t *testing.T) {
+	suite, err := LoadSuite("../../eval/suite.yaml")
+	if err != nil {
+		t.Fatalf("load suite: %v", err)
+	}
+	base, err := LoadKnowledgeBase(suite.KnowledgeBasePath())
+	if err != nil {
+		t.Fatalf("load knowledge base: %v", err)
+	}
+	cassettes, err := ai.NewReplay("../../eval/cassettes")
+	if err != nil {
+		t.Fatalf("load cassettes: %v", err)
+	}
+
+	runner := &Runner{Provider: cassettes, ProviderName: "replay"}
+	report := runner.Run(context.Background(), suite, base)
+	for _, c := range report.Cases {
+		if !c.Passed {
+			t.Fatalf("case %s failed: %s %+v", c.ID, c.Error, c.Checks)
+		}
+	}
+	if report.Passed != len(suite.Cases) || report.Failed != 0 {
+		t.Fatalf("expected every case to pass, got %d passed and %d failed", report.Passed, report.Failed)
+	}
+}
+
+func TestLoadSuiteValidates(t *testing.T) {
+	dir := t.TempDir()
+	cases := map[string]string{
+		"empty.yaml":     "name: x\ncases: []\n",
+		"noid.yaml":      "cases:\n  - question: Halo\n",
+		"dupe.yaml":      "cases:\n  - {id: a, question: Halo}\n  - {id: a, question: Hai}\n",
+		"noquestion.yml": "cases:\n  - id: a\n",
+		"language.json":  `{"defaults": {"language": "fr"}, "cases": [{"id": "a", "question": "Halo"}]}`,
+		"suite.txt":      "cases: []",
+	}
+	for name, content := range cases {
+		if _, err := LoadSuite(writeFile(t, dir, name, content)); err == nil {
+			t.Fatalf("expected %s to be rejected", name)
+		}
+	}
+
+	path := writeFile(t, dir, "ok.json", `{"knowledgeBase": "kb.json", "cases": [{"id": "a", "question": "Halo"}]}`)
+	suite, err := LoadSuite(path)
+	if err != nil {
+		t.Fatalf("load suite: %v", err)
+	}
+	if suite.Name != "ok" {
+		t.Fatalf("expected the file name as default suite name, got %q", suite.Name)
+	}
+	if suite.KnowledgeBasePath() != filepath.Join(dir, "kb.json") {
+		t.Fatalf("expected fixture path relative to the suite, got %q", suite.KnowledgeBasePath())
+	}
+}
+
+func TestChecksMergedKeepsCaseSettings(t *testing.T) {
+	defaults := Checks{MustMention: []string{"a"}, Language: LanguageIndonesian, MaxWords: 100}
+	merged := Checks{MustMention: []string{"b"}, MaxWords: 50}.merged(defaults)
+	if strings.Join(merged.MustMention, ",") != "a,b" || merged.Language != LanguageIndonesian || merged.MaxWords != 50 {
+		t.Fatalf("unexpected merge result: %+v", merged)
+	}
+}
+
+func TestRunnerRecordsAndReplays(t *testing.T) {
+	suite := Suite{Name: "s", Cases: []Case{
+		{ID: "a", Question: "Berapa harga landing page?", Checks: Checks{Services: []string{"Landing Page"}}},
+		{ID: "b", Question: "Ada konsultasi?"},
+	}}
+	base := checksBase()
+	dir := filepath.Join(t.TempDir(), "cassettes")
+
+	live := &stubProvider{text: "Landing Page mulai Rp 3.500.000."}
+	report := (&Runner{Provider: ai.NewRecorder(live, dir), ProviderName: "stub"}).Run(context.Background(), suite, base)
+	if report.Passed != 2 {
+		t.Fatalf("expected live run to pass, got %+v", report.Cases)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ai.PromptHash(live.prompts[0])+".json")); err != nil {
+		t.Fatalf("expected one cassette per case keyed by prompt hash: %v", err)
+	}
+
+	cassettes, err := ai.NewReplay(dir)
+	if err != nil {
+		t.Fatalf("load cassettes: %v", err)
+	}
+	replay := &Runner{Provider: cassettes, ProviderName: "replay"}
+	report = replay.Run(context.Background(), suite, base)
+	if report.Passed != 2 || report.Cases[0].Stale {
+		t.Fatalf("expected the replayed cases to pass, got %+v", report.Cases)
+	}
+
+	suite.Cases[0].Question = "Berapa biaya landing page?"
+	report = replay.Run(context.Background(), suite, base)
+	if !report.Cases[0].Stale || report.Cases[0].Passed || report.Failed != 1 || report.Skipped != 0 {
+		t.Fatalf("expected a changed prompt to fail as stale, got %+v", report)
+	}
+
+	replay.AllowStale = true
+	report = replay.Run(context.Background(), suite, base)
+	if !report.Cases[0].Skipped || report.Passed != 1 || report.Failed != 0 || report.Skipped != 1 {
+		t.Fatalf("expected -allow-stale to skip the stale case, got %+v", report)
+	}
+}
+
+func TestJudgeGrade(t *testing.T) {
+	cases := []struct {
+		text   string
+		err    error
+		score  int
+		passed bool
+	}{
+		{text: "```json\n{\"score\": 5, \"reason\": \"akurat\"}\n```", score: 5, passed: true},
+		{text: `{"score": 3, "reason": "kurang lengkap"}`, score: 3},
+		{text: "bagus sekali"},
+		{text: `{"score": 9}`},
+		{err: errors.New("quota")},
+	}
+	for _, tc := range cases {
+		judge := &Judge{Provider: &stubProvider{text: tc.text, err: tc.err}}
+		verdict, check := judge.Grade(context.Background(), "q", "prompt", "answer", "")
+		if verdict.Score != tc.score || check.Passed != tc.passed {
+			t.Fatalf("judge(%q, %v) = %d/%v, want %d/%v (%s)", tc.text, tc.err, verdict.Score, check.Passed, tc.score, tc.passed, check.Message)
+		}
+	}
+
+	provider := &stubProvider{text: `{"score": 3, "reason": "cukup"}`}
+	judge := &Judge{Provider: provider, Threshold: 3}
+	if _, check := judge.Grade(context.Background(), "q", "prompt", "answer", "Sebut harga."); !check.Passed {
+		t.Fatalf("expected the custom threshold to apply")
+	}
+	if !strings.Contains(provider.prompts[0], "Sebut harga.") {
+		t.Fatalf("expected the rubric in the judge prompt")
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	report := Report{
+		Suite:    "s",
+		Provider: "recorded",
+		Passed:   1,
+		Failed:   3,
+		Skipped:  1,
+		Cases: []CaseResult{
+			{ID: "ok", Passed: true, Answer: "Halo"},
+			{ID: "bad", Checks: []CheckResult{{Name: "length", Message: "too long"}, {Name: "language id", Passed: true}}},
+			{ID: "broken", Question: "q", Error: "quota exceeded"},
+			{ID: "stale", Question: "q", Stale: true, Error: "no recorded response for prompt (hash ab12)"},
+			{ID: "allowed", Question: "q", Stale: true, Skipped: true, Error: "no recorded response for prompt (hash cd34)"},
+		},
+	}
+	var buf bytes.Buffer
+	if err := report.WriteJUnit(&buf); err != nil {
+		t.Fatalf("write junit: %v", err)
+	}
+
+	var parsed junitSuites
+	if err := xml.Unmarshal(buf.Bytes(), &parsed); err != nil {
+		t.Fatalf("parse junit: %v\n%s", err, buf.String())
+	}
+	suite := parsed.Suites[0]
+	if suite.Tests != 5 || suite.Failures != 2 || suite.Errors != 1 || suite.Skipped != 1 {
+		t.Fatalf("unexpected totals: %+v", suite)
+	}
+	if suite.Cases[1].Failure == nil || suite.Cases[1].Failure.Body != "length: too long" {
+		t.Fatalf("expected failed checks in the failure, got %+v", suite.Cases[1].Failure)
+	}
+	if suite.Cases[2].Error == nil || suite.Cases[2].Error.Message != "quota exceeded" {
+		t.Fatalf("expected provider errors as errors, got %+v", suite.Cases[2])
+	}
+	if suite.Cases[3].Failure == nil || !strings.HasPrefix(suite.Cases[3].Failure.Message, "stale recording") || !strings.Contains(suite.Cases[3].Failure.Body, "ab12") {
+		t.Fatalf("expected stale cases to fail with the reason, got %+v", suite.Cases[3])
+	}
+	if suite.Cases[4].Skipped == nil || suite.Cases[4].Failure != nil || !strings.HasPrefix(suite.Cases[4].Skipped.Message, "stale recording") {
+		t.Fatalf("expected allowed stale cases to be skipped with the reason, got %+v", suite.Cases[4])
+	}
+}
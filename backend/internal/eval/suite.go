@@ -0,0 +1,162 @@
+// Package eval scores assistant answers for a suite of questions against a knowledge base
+// fixture, running them through the same prompt pipeline as the chat endpoint.
+package eval
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tanydotai/tanyai/backend/internal/services/kb"
+	"gopkg.in/yaml.v3"
+)
+
+// Suite is a named list of cases evaluated against one knowledge base fixture.
+type Suite struct {
+	Name string `json:"name" yaml:"name"`
+	// KnowledgeBase is the path of a knowledge base JSON fixture, relative to the suite
+	// file. It has the shape returned by GET /api/v1/knowledge-base.
+	KnowledgeBase string `json:"knowledgeBase" yaml:"knowledgeBase"`
+	// Defaults are merged into every case's checks.
+	Defaults Checks `json:"defaults" yaml:"defaults"`
+	Cases    []Case `json:"cases" yaml:"cases"`
+
+	dir string
+}
+
+// Case is a single question and the checks its answer must pass.
+type Case struct {
+	ID       string `json:"id" yaml:"id"`
+	Question string `json:"question" yaml:"question"`
+	History  []Turn `json:"history" yaml:"history"`
+	Checks   Checks `json:"checks" yaml:"checks"`
+}
+
+// Turn is an earlier exchange of the conversation a case continues.
+type Turn struct {
+	Question string `json:"question" yaml:"question"`
+	Answer   string `json:"answer" yaml:"answer"`
+}
+
+// Checks lists the expectations for an answer. Matching is case-insensitive.
+type Checks struct {
+	// MustMention lists phrases the answer has to contain.
+	MustMention []string `json:"mustMention" yaml:"mustMention"`
+	// MustNotMention lists phrases the answer may not contain.
+	MustNotMention []string `json:"mustNotMention" yaml:"mustNotMention"`
+	// Services names knowledge base services the answer has to mention together with
+	// one of their listed prices.
+	Services []string `json:"services" yaml:"services"`
+	// AllowUnknownURLs turns off the check that every URL in the answer comes from the
+	// knowledge base.
+	AllowUnknownURLs bool `json:"allowUnknownUrls" yaml:"allowUnknownUrls"`
+	// Language is the expected answer language, "id" or "en".
+	Language string `json:"language" yaml:"language"`
+	MinWords int    `json:"minWords" yaml:"minWords"`
+	MaxWords int    `json:"maxWords" yaml:"maxWords"`
+	// Rubric is handed to the LLM judge, when one is configured.
+	Rubric string `json:"rubric" yaml:"rubric"`
+}
+
+// LoadSuite reads a suite from a .yaml, .yml or .json file and validates it.
+func LoadSuite(path string) (Suite, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Suite{}, err
+	}
+
+	var suite Suite
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &suite)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &suite)
+	default:
+		return Suite{}, fmt.Errorf("unsupported suite format %q", filepath.Ext(path))
+	}
+	if err != nil {
+		return Suite{}, fmt.Errorf("parse suite: %w", err)
+	}
+	suite.dir = filepath.Dir(path)
+	if suite.Name == "" {
+		suite.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if err := suite.validate(); err != nil {
+		return Suite{}, err
+	}
+	return suite, nil
+}
+
+func (s Suite) validate() error {
+	if len(s.Cases) == 0 {
+		return errors.New("suite has no cases")
+	}
+	seen := make(map[string]bool, len(s.Cases))
+	for i, c := range s.Cases {
+		if strings.TrimSpace(c.ID) == "" {
+			return fmt.Errorf("case %d: id is required", i+1)
+		}
+		if seen[c.ID] {
+			return fmt.Errorf("case %s: duplicate id", c.ID)
+		}
+		seen[c.ID] = true
+		if strings.TrimSpace(c.Question) == "" {
+			return fmt.Errorf("case %s: question is required", c.ID)
+		}
+		lang := c.Checks.Language
+		if lang == "" {
+			lang = s.Defaults.Language
+		}
+		if lang != "" && lang != LanguageIndonesian && lang != LanguageEnglish {
+			return fmt.Errorf("case %s: unsupported language %q", c.ID, lang)
+		}
+	}
+	return nil
+}
+
+// KnowledgeBasePath resolves the fixture path relative to the suite file.
+func (s Suite) KnowledgeBasePath() string {
+	if s.KnowledgeBase == "" || filepath.IsAbs(s.KnowledgeBase) {
+		return s.KnowledgeBase
+	}
+	return filepath.Join(s.dir, s.KnowledgeBase)
+}
+
+// LoadKnowledgeBase reads a knowledge base JSON fixture.
+func LoadKnowledgeBase(path string) (kb.KnowledgeBase, error) {
+	var base kb.KnowledgeBase
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return base, err
+	}
+	if err := json.Unmarshal(data, &base); err != nil {
+		return base, fmt.Errorf("parse knowledge base: %w", err)
+	}
+	return base, nil
+}
+
+// merged combines the suite defaults with the case's own checks. Lists are appended;
+// scalar settings on the case win.
+func (c Checks) merged(defaults Checks) Checks {
+	out := c
+	out.MustMention = append(append([]string{}, defaults.MustMention...), c.MustMention...)
+	out.MustNotMention = append(append([]string{}, defaults.MustNotMention...), c.MustNotMention...)
+	out.Services = append(append([]string{}, defaults.Services...), c.Services...)
+	out.AllowUnknownURLs = c.AllowUnknownURLs || defaults.AllowUnknownURLs
+	if out.Language == "" {
+		out.Language = defaults.Language
+	}
+	if out.MinWords == 0 {
+		out.MinWords = defaults.MinWords
+	}
+	if out.MaxWords == 0 {
+		out.MaxWords = defaults.MaxWords
+	}
+	if out.Rubric == "" {
+		out.Rubric = defaults.Rubric
+	}
+	return out
+}
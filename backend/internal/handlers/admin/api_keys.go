@@ -0,0 +1,125 @@
+package admin
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/tanydotai/tanyai/backend/internal/auth"
+	"github.com/tanydotai/tanyai/backend/internal/dto"
+	"github.com/tanydotai/tanyai/backend/internal/httpapi"
+	"github.com/tanydotai/tanyai/backend/internal/middleware"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/repos"
+	"github.com/tanydotai/tanyai/backend/internal/services/apikeys"
+)
+
+// APIKeysHandler manages API keys of the current tenant.
+type APIKeysHandler struct {
+	repo repos.APIKeyRepository
+	now  func() time.Time
+}
+
+// NewAPIKeysHandler creates a new APIKeysHandler.
+func NewAPIKeysHandler(repo repos.APIKeyRepository) *APIKeysHandler {
+	ensureValidators()
+	return &APIKeysHandler{repo: repo, now: time.Now}
+}
+
+// List returns paginated API keys, including revoked ones.
+func (h *APIKeysHandler) List(c *gin.Context) {
+	params := parseListParams(c)
+	keys, total, err := h.repo.List(c.Request.Context(), params)
+	if handleListError(c, err) {
+		return
+	}
+
+	responses := make([]dto.APIKeyResponse, len(keys))
+	for i, key := range keys {
+		responses[i] = dto.NewAPIKeyResponse(key)
+	}
+	httpapi.RespondList(c, http.StatusOK, responses, params.Page, params.Limit, total)
+}
+
+// Create issues a new API key. The raw key is returned only in this response.
+func (h *APIKeysHandler) Create(c *gin.Context) {
+	var req dto.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	scopes, ok := normalizeScopes(c, req.Scopes)
+	if !ok {
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(h.now()) {
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "invalid request payload", map[string]string{"expiresAt": "must be in the future"})
+		return
+	}
+	rateLimit := req.RateLimitPerMin
+	if rateLimit == 0 {
+		rateLimit = apikeys.DefaultRateLimitPerMin
+	}
+
+	raw, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "failed to generate api key", nil)
+		return
+	}
+
+	key := models.APIKey{
+		Name:            strings.TrimSpace(req.Name),
+		Prefix:          prefix,
+		KeyHash:         hash,
+		Scopes:          scopes,
+		RateLimitPerMin: rateLimit,
+		ExpiresAt:       req.ExpiresAt,
+	}
+	if claims, ok := middleware.GetClaims(c); ok && claims.UserID != uuid.Nil {
+		createdBy := claims.UserID
+		key.CreatedBy = &createdBy
+	}
+
+	created, err := h.repo.Create(c.Request.Context(), key)
+	if handleRepoError(c, err) {
+		return
+	}
+	httpapi.RespondData(c, http.StatusCreated, dto.CreatedAPIKeyResponse{
+		APIKeyResponse: dto.NewAPIKeyResponse(created),
+		Key:            raw,
+	})
+}
+
+// Revoke permanently disables an API key.
+func (h *APIKeysHandler) Revoke(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondValidationError(c, err)
+		return
+	}
+	if handleRepoError(c, h.repo.Revoke(c.Request.Context(), id, h.now().UTC())) {
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func normalizeScopes(c *gin.Context, scopes []string) (pq.StringArray, bool) {
+	seen := make(map[string]bool, len(scopes))
+	normalized := make(pq.StringArray, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if !auth.IsAPIKeyScope(scope) {
+			httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "invalid request payload", map[string]string{"scopes": "unknown scope " + scope})
+			return nil, false
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			normalized = append(normalized, scope)
+		}
+	}
+	return normalized, true
+}
@@ -0,0 +1,89 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/tanydotai/tanyai/backend/internal/auth"
+	"github.com/tanydotai/tanyai/backend/internal/dto"
+	"github.com/tanydotai/tanyai/backend/internal/middleware"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/repos"
+)
+
+type stubAPIKeyRepo struct {
+	repos.APIKeyRepository
+	keys map[uuid.UUID]models.APIKey
+}
+
+func (s *stubAPIKeyRepo) Create(ctx context.Context, key models.APIKey) (models.APIKey, error) {
+	key.ID = uuid.New()
+	key.CreatedAt = time.Now()
+	s.keys[key.ID] = key
+	return key, nil
+}
+
+func (s *stubAPIKeyRepo) Revoke(ctx context.Context, id uuid.UUID, at time.Time) error {
+	key, ok := s.keys[id]
+	if !ok || key.RevokedAt != nil {
+		return repos.ErrNotFound
+	}
+	key.RevokedAt = &at
+	s.keys[id] = key
+	return nil
+}
+
+func TestAPIKeysCreateAndRevoke(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := &stubAPIKeyRepo{keys: map[uuid.UUID]models.APIKey{}}
+	handler := NewAPIKeysHandler(repo)
+	adminID := uuid.New()
+
+	router := gin.New()
+	group := router.Group("", func(c *gin.Context) {
+		middleware.SetClaims(c, &auth.Claims{UserID: adminID, Roles: []string{auth.RoleAdmin}})
+	})
+	group.POST("/api-keys", handler.Create)
+	group.DELETE("/api-keys/:id", handler.Revoke)
+
+	create := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api-keys", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := create(`{"name":"Slack bot","scopes":["chat","analytics:read","chat"]}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	var resp struct {
+		Data dto.CreatedAPIKeyResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.True(t, strings.HasPrefix(resp.Data.Key, resp.Data.Prefix+"_"))
+	require.Equal(t, []string{"chat", "analytics:read"}, resp.Data.Scopes)
+	require.Equal(t, 60, resp.Data.RateLimitPerMin)
+
+	stored := repo.keys[uuid.MustParse(resp.Data.ID)]
+	require.Equal(t, auth.HashAPIKey(resp.Data.Key), stored.KeyHash)
+	require.Equal(t, adminID, *stored.CreatedBy)
+
+	require.Equal(t, http.StatusBadRequest, create(`{"name":"escalate","scopes":["users:manage"]}`).Code)
+	require.Equal(t, http.StatusBadRequest, create(`{"name":"old","scopes":["chat"],"expiresAt":"2020-01-01T00:00:00Z"}`).Code)
+
+	for _, want := range []int{http.StatusNoContent, http.StatusNotFound} {
+		req := httptest.NewRequest(http.MethodDelete, "/api-keys/"+resp.Data.ID, nil)
+		rec = httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, want, rec.Code)
+	}
+}
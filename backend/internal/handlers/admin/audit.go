@@ -0,0 +1,65 @@
+package admin
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tanydotai/tanyai/backend/internal/dto"
+	"github.com/tanydotai/tanyai/backend/internal/httpapi"
+	"github.com/tanydotai/tanyai/backend/internal/repos"
+)
+
+// AuditHandler exposes the audit log of admin mutations.
+type AuditHandler struct {
+	repo repos.AuditRepository
+}
+
+// NewAuditHandler constructs AuditHandler.
+func NewAuditHandler(repo repos.AuditRepository) *AuditHandler {
+	return &AuditHandler{repo: repo}
+}
+
+// List returns audit entries, newest first, filtered by entity, action, actor and time range.
+func (h *AuditHandler) List(c *gin.Context) {
+	params := parseListParams(c)
+	filter := repos.AuditListParams{
+		ListParams: params,
+		EntityType: strings.TrimSpace(c.Query("entity")),
+		EntityID:   strings.TrimSpace(c.Query("entityId")),
+		Action:     strings.TrimSpace(c.Query("action")),
+	}
+	if actor := strings.TrimSpace(c.Query("actor")); actor != "" {
+		id, err := uuid.Parse(actor)
+		if err != nil {
+			respondValidationError(c, err)
+			return
+		}
+		filter.ActorID = &id
+	}
+	for name, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		value := strings.TrimSpace(c.Query(name))
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "invalid request payload", map[string]string{name: "must be an RFC3339 timestamp"})
+			return
+		}
+		*target = &parsed
+	}
+
+	entries, total, err := h.repo.List(c.Request.Context(), filter)
+	if handleListError(c, err) {
+		return
+	}
+
+	responses := make([]dto.AuditLogResponse, 0, len(entries))
+	for _, entry := range entries {
+		responses = append(responses, dto.NewAuditLogResponse(entry))
+	}
+	httpapi.RespondList(c, http.StatusOK, responses, params.Page, params.Limit, total)
+}
@@ -0,0 +1,75 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/tanydotai/tanyai/backend/internal/dto"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/repos"
+)
+
+type stubAuditRepo struct {
+	repos.AuditRepository
+	params  repos.AuditListParams
+	entries []models.AuditLog
+}
+
+func (s *stubAuditRepo) List(ctx context.Context, params repos.AuditListParams) ([]models.AuditLog, int64, error) {
+	s.params = params
+	return s.entries, int64(len(s.entries)), nil
+}
+
+func TestAuditListParsesFilters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	actorID := uuid.New()
+	repo := &stubAuditRepo{entries: []models.AuditLog{{
+		ID:          uuid.New(),
+		ActorUserID: &actorID,
+		ActorEmail:  "admin@example.com",
+		Action:      "update",
+		EntityType:  "service",
+		EntityID:    "svc-1",
+		Before:      models.NullJSONB{"price_min": 100.0},
+		After:       models.NullJSONB{"price_min": 150.0},
+		Changes:     models.JSONB{"price_min": map[string]any{"before": 100.0, "after": 150.0}},
+		CreatedAt:   time.Now(),
+	}}}
+	router := gin.New()
+	router.GET("/audit", NewAuditHandler(repo).List)
+
+	rec := httptest.NewRecorder()
+	url := "/audit?entity=service&entityId=svc-1&action=update&actor=" + actorID.String() + "&from=2025-02-01T00:00:00Z&to=2025-03-01T00:00:00Z"
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	require.Equal(t, "service", repo.params.EntityType)
+	require.Equal(t, "svc-1", repo.params.EntityID)
+	require.Equal(t, "update", repo.params.Action)
+	require.NotNil(t, repo.params.ActorID)
+	require.Equal(t, actorID, *repo.params.ActorID)
+	require.NotNil(t, repo.params.From)
+	require.NotNil(t, repo.params.To)
+	require.True(t, repo.params.From.Before(*repo.params.To))
+
+	var body struct {
+		Items []dto.AuditLogResponse `json:"items"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Len(t, body.Items, 1)
+	require.Equal(t, actorID.String(), *body.Items[0].Actor.UserID)
+	require.Contains(t, body.Items[0].Changes, "price_min")
+
+	for _, query := range []string{"actor=nope", "from=yesterday"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/audit?"+query, nil))
+		require.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
@@ -0,0 +1,261 @@
+package admin
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tanydotai/tanyai/backend/internal/config"
+	"github.com/tanydotai/tanyai/backend/internal/documents"
+	"github.com/tanydotai/tanyai/backend/internal/dto"
+	"github.com/tanydotai/tanyai/backend/internal/httpapi"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/repos"
+	"github.com/tanydotai/tanyai/backend/internal/scanner"
+	"github.com/tanydotai/tanyai/backend/internal/storage"
+)
+
+const maxDocumentTitleRunes = 200
+
+var documentExtensions = map[string]string{
+	documents.TypePDF:      ".pdf",
+	documents.TypeMarkdown: ".md",
+	documents.TypeText:     ".txt",
+}
+
+// DocumentsHandler turns uploaded PDF, Markdown and text files into knowledge items the
+// assistant can quote.
+type DocumentsHandler struct {
+	repo       repos.DocumentRepository
+	storage    storage.ObjectStorage
+	policy     config.DocumentConfig
+	scanners   *scanner.Chain
+	invalidate func()
+	logger     *log.Logger
+	urls       storage.URLSigner
+}
+
+// NewDocumentsHandler constructs a DocumentsHandler. The original file is kept in store
+// when it is configured so answers can link to it; invalidate refreshes the knowledge
+// base after documents change.
+func NewDocumentsHandler(repo repos.DocumentRepository, store storage.ObjectStorage, policy config.UploadConfig, invalidate func(), logger *log.Logger) *DocumentsHandler {
+	if logger == nil {
+		logger = log.New(os.Stdout, "", 0)
+	}
+	return &DocumentsHandler{
+		repo:       repo,
+		storage:    store,
+		policy:     policy.Document,
+		scanners:   newScannerChain(policy.Scan),
+		invalidate: invalidate,
+		logger:     logger,
+		urls:       storage.SignerFor(store),
+	}
+}
+
+// List returns uploaded documents, newest first.
+func (h *DocumentsHandler) List(c *gin.Context) {
+	params := parseListParams(c)
+	docs, total, err := h.repo.List(c.Request.Context(), params)
+	if handleListError(c, err) {
+		return
+	}
+
+	responses := make([]dto.DocumentResponse, len(docs))
+	for i, doc := range docs {
+		responses[i] = h.response(doc)
+	}
+	httpapi.RespondList(c, http.StatusOK, responses, params.Page, params.Limit, total)
+}
+
+// Create accepts a multipart "file" field holding a PDF, Markdown or plain text document
+// and an optional "title". The extracted text is chunked and stored under the tenant's
+// documents source.
+func (h *DocumentsHandler) Create(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.policy.MaxBytes)
+	if err := c.Request.ParseMultipartForm(h.policy.MaxBytes); err != nil {
+		if isMaxBytesError(err) {
+			httpapi.RespondError(c, http.StatusRequestEntityTooLarge, httpapi.ErrorCodeValidation, fmt.Sprintf("file exceeds %d bytes", h.policy.MaxBytes), nil)
+			return
+		}
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "invalid multipart payload", nil)
+		return
+	}
+	defer func() {
+		if c.Request.MultipartForm != nil {
+			_ = c.Request.MultipartForm.RemoveAll()
+		}
+	}()
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "file field is required", nil)
+		return
+	}
+	defer file.Close()
+
+	buf := &bytes.Buffer{}
+	if _, err := io.Copy(buf, file); err != nil {
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "failed to read upload", nil)
+		return
+	}
+	data := buf.Bytes()
+	if len(data) == 0 {
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "file is empty", nil)
+		return
+	}
+
+	contentType := documentType(data, header.Filename, header.Header.Get("Content-Type"))
+	if contentType == "" {
+		httpapi.RespondError(c, http.StatusUnsupportedMediaType, httpapi.ErrorCodeValidation, "unsupported document type", map[string]string{"file": "must be a PDF, Markdown or plain text file"})
+		return
+	}
+
+	if h.scanners != nil {
+		// Reading from memory cannot fail.
+		report, _ := h.scanners.Scan(c.Request.Context(), bytes.NewReader(data), contentType)
+		switch report.Status {
+		case scanner.StatusInfected:
+			h.logger.Printf("document rejected by scanner: %s (%s)", header.Filename, report.Threat)
+			httpapi.RespondError(c, http.StatusUnprocessableEntity, httpapi.ErrorCodeValidation, "file rejected by scanner", map[string]string{"threat": report.Threat})
+			return
+		case scanner.StatusFailed:
+			httpapi.RespondError(c, http.StatusServiceUnavailable, httpapi.ErrorCodeExternal, "upload scanner unavailable", nil)
+			return
+		}
+	}
+
+	text, err := documents.Extract(data, contentType)
+	switch {
+	case errors.Is(err, documents.ErrNoText):
+		httpapi.RespondError(c, http.StatusUnprocessableEntity, httpapi.ErrorCodeValidation, "document has no extractable text", nil)
+		return
+	case err != nil:
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "invalid document", nil)
+		return
+	}
+
+	chunks := documents.Chunk(text, h.policy.ChunkRunes)
+	if h.policy.MaxChunks > 0 && len(chunks) > h.policy.MaxChunks {
+		httpapi.RespondError(c, http.StatusRequestEntityTooLarge, httpapi.ErrorCodeValidation, fmt.Sprintf("document exceeds %d chunks", h.policy.MaxChunks), nil)
+		return
+	}
+
+	doc := models.Document{
+		ID:          uuid.New(),
+		Title:       documentTitle(c.PostForm("title"), header.Filename),
+		Filename:    filepath.Base(header.Filename),
+		ContentType: contentType,
+		SizeBytes:   int64(len(data)),
+	}
+	if h.storage != nil {
+		key := newDocumentKey(doc.ID, documentExtensions[contentType])
+		url, err := h.storage.Put(c.Request.Context(), key, data, contentType)
+		if err != nil {
+			h.logger.Printf("document store failed: %v", err)
+			httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "failed to store file", nil)
+			return
+		}
+		doc.Key, doc.URL = key, url
+	}
+
+	created, err := h.repo.Create(c.Request.Context(), doc, chunks)
+	if err != nil {
+		if doc.Key != "" {
+			if delErr := h.storage.Delete(c.Request.Context(), doc.Key); delErr != nil {
+				h.logger.Printf("document cleanup failed: %v", delErr)
+			}
+		}
+		handleRepoError(c, err)
+		return
+	}
+
+	if h.invalidate != nil {
+		h.invalidate()
+	}
+	httpapi.RespondData(c, http.StatusCreated, h.response(created))
+}
+
+func (h *DocumentsHandler) response(doc models.Document) dto.DocumentResponse {
+	doc.URL = h.urls.SignURL(doc.URL)
+	return dto.NewDocumentResponse(doc)
+}
+
+// Delete removes every chunk of a document and its stored original.
+func (h *DocumentsHandler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	doc, err := h.repo.Delete(c.Request.Context(), id)
+	if handleRepoError(c, err) {
+		return
+	}
+	if h.invalidate != nil {
+		h.invalidate()
+	}
+
+	if doc.Key != "" && h.storage != nil {
+		if err := h.storage.Delete(c.Request.Context(), doc.Key); err != nil {
+			h.logger.Printf("document object delete failed: %v", err)
+			httpapi.RespondError(c, http.StatusBadGateway, httpapi.ErrorCodeExternal, "failed to delete stored document", nil)
+			return
+		}
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// documentType sniffs the upload. PDFs are recognised by content; text files are told
+// apart from Markdown by extension or declared type because both sniff as text/plain.
+func documentType(data []byte, filename, declared string) string {
+	detected := normalizeMIME(http.DetectContentType(data))
+	if detected == documents.TypePDF {
+		return documents.TypePDF
+	}
+	if detected != documents.TypeText {
+		return ""
+	}
+	if declared = normalizeMIME(declared); declared == documents.TypeMarkdown || declared == "text/x-markdown" {
+		return documents.TypeMarkdown
+	}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".md", ".markdown":
+		return documents.TypeMarkdown
+	}
+	return documents.TypeText
+}
+
+// documentTitle prefers the submitted title and falls back to the file name without its
+// extension.
+func documentTitle(title, filename string) string {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		base := filepath.Base(filename)
+		title = strings.TrimSpace(strings.TrimSuffix(base, filepath.Ext(base)))
+	}
+	if title == "" || title == "." {
+		title = "Dokumen"
+	}
+	if runes := []rune(title); len(runes) > maxDocumentTitleRunes {
+		title = string(runes[:maxDocumentTitleRunes])
+	}
+	return title
+}
+
+// newDocumentKey keeps originals under their own prefix so the media orphan sweep, which
+// only walks "uploads/", never removes them.
+func newDocumentKey(id uuid.UUID, ext string) string {
+	now := time.Now().UTC()
+	return fmt.Sprintf("documents/%04d/%02d/%02d/%s%s", now.Year(), now.Month(), now.Day(), id, ext)
+}
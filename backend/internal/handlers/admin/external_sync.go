@@ -67,6 +67,10 @@ func (h *ExternalSourceHandler) Sync(c *gin.Context) {
 		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "source disabled", nil)
 		return
 	}
+	if source.SourceType == repos.DocumentSourceType {
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "document sources are updated by uploading documents", nil)
+		return
+	}
 
 	parsedURL, err := parseBaseURL(source.BaseURL)
 	if err != nil {
@@ -94,6 +94,10 @@ func handleRepoError(c *gin.Context, err error) bool {
 		httpapi.RespondError(c, http.StatusNotFound, httpapi.ErrorCodeNotFound, "resource not found", nil)
 		return true
 	}
+	if errors.Is(err, repos.ErrConflict) {
+		httpapi.RespondError(c, http.StatusConflict, httpapi.ErrorCodeConflict, "resource already exists", nil)
+		return true
+	}
 	httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "internal server error", nil)
 	return true
 }
@@ -0,0 +1,101 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tanydotai/tanyai/backend/internal/dto"
+	"github.com/tanydotai/tanyai/backend/internal/httpapi"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/repos"
+	"github.com/tanydotai/tanyai/backend/internal/services/medialibrary"
+	"github.com/tanydotai/tanyai/backend/internal/storage"
+)
+
+// MediaService lists and deletes uploaded media.
+type MediaService interface {
+	List(ctx context.Context, params repos.MediaListParams) ([]models.MediaItem, int64, error)
+	Delete(ctx context.Context, id uuid.UUID, force bool) error
+}
+
+// MediaHandler exposes the media library.
+type MediaHandler struct {
+	service MediaService
+	urls    storage.URLSigner
+}
+
+// NewMediaHandler constructs a MediaHandler.
+func NewMediaHandler(service MediaService) *MediaHandler {
+	return &MediaHandler{service: service, urls: storage.SignerFor(nil)}
+}
+
+// SetURLSigner signs the URLs of listed media.
+func (h *MediaHandler) SetURLSigner(signer storage.URLSigner) {
+	h.urls = signer
+}
+
+// List returns uploaded originals, newest first. ?contentType= filters by MIME type or,
+// with a trailing slash, by family ("image/"); ?orphaned=true|false filters by whether any
+// content references the upload.
+func (h *MediaHandler) List(c *gin.Context) {
+	params := repos.MediaListParams{
+		ListParams:  parseListParams(c),
+		ContentType: strings.ToLower(strings.TrimSpace(c.Query("contentType"))),
+	}
+	if value := c.Query("orphaned"); value != "" {
+		orphaned, err := strconv.ParseBool(value)
+		if err != nil {
+			httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "invalid request payload", map[string]string{"orphaned": "must be true or false"})
+			return
+		}
+		params.Orphaned = &orphaned
+	}
+
+	items, total, err := h.service.List(c.Request.Context(), params)
+	if handleListError(c, err) {
+		return
+	}
+
+	responses := make([]dto.MediaResponse, len(items))
+	for i, item := range items {
+		item.URL = h.urls.SignURL(item.URL)
+		variants := make(models.MediaVariants, len(item.Variants))
+		for j, variant := range item.Variants {
+			variant.URL = h.urls.SignURL(variant.URL)
+			variants[j] = variant
+		}
+		item.Variants = variants
+		responses[i] = dto.NewMediaResponse(item)
+	}
+	httpapi.RespondList(c, http.StatusOK, responses, params.Page, params.Limit, total)
+}
+
+// Delete removes an upload, its variants and their objects. Uploads still referenced by
+// a draft or published content return 409 unless ?force=true.
+func (h *MediaHandler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondValidationError(c, err)
+		return
+	}
+	force, _ := strconv.ParseBool(c.Query("force"))
+
+	err = h.service.Delete(c.Request.Context(), id, force)
+	switch {
+	case errors.Is(err, repos.ErrConflict):
+		httpapi.RespondError(c, http.StatusConflict, httpapi.ErrorCodeConflict, "media is still referenced", map[string]string{"force": "set force=true to delete anyway"})
+		return
+	case errors.Is(err, medialibrary.ErrStorage):
+		httpapi.RespondError(c, http.StatusBadGateway, httpapi.ErrorCodeExternal, "failed to delete stored objects", nil)
+		return
+	}
+	if handleRepoError(c, err) {
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
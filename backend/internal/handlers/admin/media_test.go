@@ -0,0 +1,108 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/repos"
+	"github.com/tanydotai/tanyai/backend/internal/services/medialibrary"
+)
+
+type stubMediaService struct {
+	items     []models.MediaItem
+	params    repos.MediaListParams
+	deleteErr error
+	forced    bool
+}
+
+func (s *stubMediaService) List(_ context.Context, params repos.MediaListParams) ([]models.MediaItem, int64, error) {
+	s.params = params
+	return s.items, int64(len(s.items)), nil
+}
+
+func (s *stubMediaService) Delete(_ context.Context, _ uuid.UUID, force bool) error {
+	s.forced = force
+	return s.deleteErr
+}
+
+func newMediaRouter(service *stubMediaService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	handler := NewMediaHandler(service)
+	router := gin.New()
+	router.GET("/media", handler.List)
+	router.DELETE("/media/:id", handler.Delete)
+	return router
+}
+
+func TestMediaListFiltersAndReportsReferences(t *testing.T) {
+	projectID := uuid.New()
+	service := &stubMediaService{items: []models.MediaItem{{
+		Upload:     models.Upload{ID: uuid.New(), Key: "uploads/a.jpg", ContentType: "image/jpeg", CreatedAt: time.Date(2025, 2, 11, 8, 0, 0, 0, time.UTC)},
+		Variants:   models.MediaVariants{{Key: "uploads/a_thumb.jpg", ContentType: "image/jpeg"}},
+		References: models.MediaReferences{{Entity: "project", ID: projectID, Published: true}},
+	}}}
+	router := newMediaRouter(service)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/media?contentType=image/&orphaned=false", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "image/", service.params.ContentType)
+	require.NotNil(t, service.params.Orphaned)
+	require.False(t, *service.params.Orphaned)
+
+	var body struct {
+		Items []struct {
+			Variants   []map[string]any `json:"variants"`
+			References []struct {
+				ID        string `json:"id"`
+				Published bool   `json:"published"`
+			} `json:"references"`
+			Orphaned bool `json:"orphaned"`
+		} `json:"items"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Len(t, body.Items, 1)
+	require.Len(t, body.Items[0].Variants, 1)
+	require.Equal(t, projectID.String(), body.Items[0].References[0].ID)
+	require.False(t, body.Items[0].Orphaned)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/media?orphaned=maybe", nil))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestMediaDeleteRequiresForceForReferencedMedia(t *testing.T) {
+	service := &stubMediaService{deleteErr: repos.ErrConflict}
+	router := newMediaRouter(service)
+	path := "/media/" + uuid.NewString()
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, path, nil))
+	require.Equal(t, http.StatusConflict, rec.Code)
+	require.False(t, service.forced)
+
+	service.deleteErr = nil
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, path+"?force=true", nil))
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	require.True(t, service.forced)
+
+	service.deleteErr = fmt.Errorf("%w: boom", medialibrary.ErrStorage)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, path, nil))
+	require.Equal(t, http.StatusBadGateway, rec.Code)
+
+	service.deleteErr = repos.ErrNotFound
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, path, nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,276 @@
+package admin
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/tanydotai/tanyai/backend/internal/dto"
+	"github.com/tanydotai/tanyai/backend/internal/httpapi"
+	"github.com/tanydotai/tanyai/backend/internal/services/portfolio"
+)
+
+const (
+	// maxImportBytes bounds import payloads, zipped or not.
+	maxImportBytes = 10 << 20
+
+	bundleFileName   = "bundle.json"
+	manifestFileName = "uploads.json"
+
+	importModeUpsert  = "upsert"
+	importModeReplace = "replace"
+)
+
+// PortfolioHandler exports and imports a tenant's complete content.
+type PortfolioHandler struct {
+	service    *portfolio.Service
+	invalidate func()
+	now        func() time.Time
+}
+
+// NewPortfolioHandler constructs a PortfolioHandler.
+func NewPortfolioHandler(service *portfolio.Service, invalidate func()) *PortfolioHandler {
+	ensureValidators()
+	return &PortfolioHandler{service: service, invalidate: invalidate, now: time.Now}
+}
+
+// Export downloads the content as a versioned JSON bundle or, with ?format=zip, as a zip
+// archive holding the bundle and a manifest of referenced uploads.
+func (h *PortfolioHandler) Export(c *gin.Context) {
+	format := strings.ToLower(c.DefaultQuery("format", "json"))
+	if format != "json" && format != "zip" {
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "invalid format", map[string]string{"format": "must be json or zip"})
+		return
+	}
+
+	content, err := h.service.Export(c.Request.Context())
+	if handleRepoError(c, err) {
+		return
+	}
+	now := h.now()
+	bundle := dto.NewBundle(content, now)
+	filename := "portfolio-" + now.UTC().Format("20060102T150405Z")
+
+	if format == "json" {
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, filename))
+		c.JSON(http.StatusOK, bundle)
+		return
+	}
+
+	archive, err := buildExportArchive(bundle)
+	if err != nil {
+		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "internal server error", nil)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.zip"`, filename))
+	c.Data(http.StatusOK, "application/zip", archive)
+}
+
+func buildExportArchive(bundle dto.Bundle) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	files := []struct {
+		name  string
+		value any
+	}{
+		{bundleFileName, bundle},
+		{manifestFileName, bundle.UploadManifest()},
+	}
+	for _, file := range files {
+		entry, err := writer.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+		encoder := json.NewEncoder(entry)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.value); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Import applies a bundle, sent as JSON or as an export zip. ?mode=replace removes entries
+// missing from the bundle, ?dryRun=true only reports the changes and ?publish=true
+// publishes the imported drafts.
+func (h *PortfolioHandler) Import(c *gin.Context) {
+	mode := strings.ToLower(c.DefaultQuery("mode", importModeUpsert))
+	if mode != importModeUpsert && mode != importModeReplace {
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "invalid mode", map[string]string{"mode": "must be upsert or replace"})
+		return
+	}
+	dryRun := c.Query("dryRun") == "true"
+	publish := c.Query("publish") == "true"
+
+	bundle, err := readBundle(c)
+	if err != nil {
+		if isMaxBytesError(err) {
+			httpapi.RespondError(c, http.StatusRequestEntityTooLarge, httpapi.ErrorCodeValidation, fmt.Sprintf("payload exceeds %d bytes", maxImportBytes), nil)
+			return
+		}
+		respondValidationError(c, err)
+		return
+	}
+	if bundle.Version != dto.BundleVersion {
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "unsupported bundle version", map[string]string{"version": fmt.Sprintf("must be %d", dto.BundleVersion)})
+		return
+	}
+	if details := validateBundle(bundle); len(details) > 0 {
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "invalid request payload", details)
+		return
+	}
+
+	report, err := h.service.Import(c.Request.Context(), bundle.Content(), portfolio.Options{
+		Replace: mode == importModeReplace,
+		DryRun:  dryRun,
+		Publish: publish && !dryRun,
+	})
+	if handleRepoError(c, err) {
+		return
+	}
+	if !dryRun && h.invalidate != nil {
+		h.invalidate()
+	}
+
+	httpapi.RespondData(c, http.StatusOK, dto.NewImportResponse(report, mode, dryRun))
+}
+
+func readBundle(c *gin.Context) (dto.Bundle, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes))
+	if err != nil {
+		return dto.Bundle{}, err
+	}
+
+	if c.ContentType() == "application/zip" {
+		body, err = readArchiveBundle(body)
+		if err != nil {
+			return dto.Bundle{}, err
+		}
+	}
+
+	var bundle dto.Bundle
+	if err := json.Unmarshal(body, &bundle); err != nil {
+		return dto.Bundle{}, errors.New("invalid bundle JSON")
+	}
+	return bundle, nil
+}
+
+func readArchiveBundle(data []byte) ([]byte, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, errors.New("invalid zip archive")
+	}
+	for _, file := range archive.File {
+		if file.Name != bundleFileName {
+			continue
+		}
+		entry, err := file.Open()
+		if err != nil {
+			return nil, errors.New("invalid zip archive")
+		}
+		defer entry.Close()
+		// The limit guards against archives that expand far beyond their compressed size.
+		content, err := io.ReadAll(io.LimitReader(entry, maxImportBytes+1))
+		if err != nil {
+			return nil, errors.New("invalid zip archive")
+		}
+		if len(content) > maxImportBytes {
+			return nil, &http.MaxBytesError{Limit: maxImportBytes}
+		}
+		return content, nil
+	}
+	return nil, fmt.Errorf("zip archive has no %s", bundleFileName)
+}
+
+// validateBundle applies the request validation rules to every entry and reports errors
+// by their JSON path, e.g. services[2].price_max.
+func validateBundle(bundle dto.Bundle) map[string]string {
+	details := map[string]string{}
+	if err := binding.Validator.ValidateStruct(&bundle); err != nil {
+		var fieldErrs validator.ValidationErrors
+		if !errors.As(err, &fieldErrs) {
+			return map[string]string{"message": err.Error()}
+		}
+		for _, fieldErr := range fieldErrs {
+			details[bundleFieldPath(fieldErr.Namespace())] = fieldErr.Error()
+		}
+	}
+
+	for i, service := range bundle.Services {
+		if err := validateServicePrices(service.ServiceRequest); err != nil {
+			details[fmt.Sprintf("services[%d].price_max", i)] = err.Error()
+		}
+	}
+
+	checkDuplicates := func(name string, ids []uuid.UUID) {
+		seen := make(map[uuid.UUID]struct{}, len(ids))
+		for i, id := range ids {
+			if _, ok := seen[id]; ok {
+				details[fmt.Sprintf("%s[%d].id", name, i)] = "duplicate id"
+			}
+			seen[id] = struct{}{}
+		}
+	}
+	checkDuplicates("skills", collectIDs(bundle.Skills, func(s dto.BundleSkill) uuid.UUID { return s.ID }))
+	checkDuplicates("services", collectIDs(bundle.Services, func(s dto.BundleService) uuid.UUID { return s.ID }))
+	checkDuplicates("projects", collectIDs(bundle.Projects, func(p dto.BundleProject) uuid.UUID { return p.ID }))
+	checkDuplicates("external_sources", collectIDs(bundle.ExternalSources, func(s dto.BundleExternalSource) uuid.UUID { return s.ID }))
+	return details
+}
+
+func collectIDs[T any](entries []T, id func(T) uuid.UUID) []uuid.UUID {
+	ids := make([]uuid.UUID, len(entries))
+	for i, entry := range entries {
+		ids[i] = id(entry)
+	}
+	return ids
+}
+
+// bundleFieldPath turns a validator namespace such as
+// "Bundle.Services[2].ServiceRequest.PriceMax" into "services[2].price_max".
+func bundleFieldPath(namespace string) string {
+	segments := strings.Split(namespace, ".")
+	if len(segments) > 1 {
+		segments = segments[1:]
+	}
+	path := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		if strings.HasSuffix(segment, "Request") {
+			continue
+		}
+		path = append(path, snakeCase(segment))
+	}
+	return strings.Join(path, ".")
+}
+
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			prevLower := i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]))
+			nextLower := i > 0 && i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsLower(runes[i+1])
+			if prevLower || nextLower {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
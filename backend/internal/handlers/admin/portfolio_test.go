@@ -0,0 +1,144 @@
+package admin
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/repos"
+	"github.com/tanydotai/tanyai/backend/internal/services/portfolio"
+)
+
+type stubPortfolioRepo struct {
+	current  repos.PortfolioContent
+	imported *repos.PortfolioContent
+}
+
+func (s *stubPortfolioRepo) Export(context.Context) (repos.PortfolioContent, error) {
+	return s.current, nil
+}
+
+func (s *stubPortfolioRepo) Import(_ context.Context, content repos.PortfolioContent, _ bool) error {
+	s.imported = &content
+	return nil
+}
+
+func newPortfolioRouter(repo *stubPortfolioRepo, invalidate func()) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	handler := NewPortfolioHandler(portfolio.NewService(repo, &stubRevisionRepo{}), invalidate)
+	handler.now = func() time.Time { return time.Date(2025, 2, 9, 12, 0, 0, 0, time.UTC) }
+	router := gin.New()
+	router.GET("/export", handler.Export)
+	router.POST("/import", handler.Import)
+	return router
+}
+
+func TestPortfolioExportZipRoundTrips(t *testing.T) {
+	projectID := uuid.New()
+	repo := &stubPortfolioRepo{current: repos.PortfolioContent{
+		Profile:  &models.Profile{ID: uuid.New(), Name: "Jane", Title: "Engineer", AvatarURL: "https://cdn.example.com/avatar.png"},
+		Projects: []models.Project{{ID: projectID, Title: "Atlas"}},
+	}}
+	router := newPortfolioRouter(repo, nil)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/export?format=zip", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/zip", rec.Header().Get("Content-Type"))
+	require.Contains(t, rec.Header().Get("Content-Disposition"), "portfolio-20250209T120000Z.zip")
+
+	archive, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	require.NoError(t, err)
+	files := map[string][]byte{}
+	for _, file := range archive.File {
+		entry, err := file.Open()
+		require.NoError(t, err)
+		files[file.Name], err = io.ReadAll(entry)
+		require.NoError(t, err)
+		entry.Close()
+	}
+	var manifest []map[string]string
+	require.NoError(t, json.Unmarshal(files["uploads.json"], &manifest))
+	require.Len(t, manifest, 1)
+	require.Equal(t, "avatar_url", manifest[0]["field"])
+
+	invalidated := 0
+	router = newPortfolioRouter(repo, func() { invalidated++ })
+	req := httptest.NewRequest(http.MethodPost, "/import?dryRun=true", bytes.NewReader(rec.Body.Bytes()))
+	req.Header.Set("Content-Type", "application/zip")
+	importRec := httptest.NewRecorder()
+	router.ServeHTTP(importRec, req)
+	require.Equal(t, http.StatusOK, importRec.Code, importRec.Body.String())
+
+	var response struct {
+		Data struct {
+			DryRun   bool `json:"dry_run"`
+			Projects struct {
+				Unchanged int `json:"unchanged"`
+			} `json:"projects"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(importRec.Body.Bytes(), &response))
+	require.True(t, response.Data.DryRun)
+	require.Equal(t, 1, response.Data.Projects.Unchanged)
+	require.Nil(t, repo.imported)
+	require.Zero(t, invalidated)
+}
+
+func TestPortfolioImportValidatesEntriesByPath(t *testing.T) {
+	repo := &stubPortfolioRepo{}
+	router := newPortfolioRouter(repo, nil)
+	body := `{"version":1,"services":[{"id":"` + uuid.NewString() + `","name":"Audit","price_min":200,"price_max":100}],` +
+		`"projects":[{"id":"` + uuid.NewString() + `","title":"A","image_url":"not-a-url",` +
+		`"image_variants":{"variants":[{"name":"thumb","url":"not-a-url"}]}}]}`
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/import", bytes.NewBufferString(body)))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var response struct {
+		Error struct {
+			Details map[string]string `json:"details"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	require.Contains(t, response.Error.Details, "services[0].price_max")
+	require.Contains(t, response.Error.Details, "projects[0].title")
+	require.Contains(t, response.Error.Details, "projects[0].image_url")
+	require.Contains(t, response.Error.Details, "projects[0].image_variants.variants[0].url")
+	require.Nil(t, repo.imported)
+}
+
+func TestPortfolioImportRejectsUnsupportedVersion(t *testing.T) {
+	router := newPortfolioRouter(&stubPortfolioRepo{}, nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/import", bytes.NewBufferString(`{"version":2}`)))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Contains(t, rec.Body.String(), "unsupported bundle version")
+}
+
+func TestPortfolioImportWritesAndInvalidates(t *testing.T) {
+	repo := &stubPortfolioRepo{}
+	invalidated := 0
+	router := newPortfolioRouter(repo, func() { invalidated++ })
+	skillID := uuid.New()
+	body := `{"version":1,"skills":[{"id":"` + skillID.String() + `","name":"Go","order":3}]}`
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/import?mode=replace&publish=true", bytes.NewBufferString(body)))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NotNil(t, repo.imported)
+	require.Equal(t, []models.Skill{{ID: skillID, Name: "Go", Order: 3}}, repo.imported.Skills)
+	require.Equal(t, 1, invalidated)
+	require.Contains(t, rec.Body.String(), `"mode":"replace"`)
+}
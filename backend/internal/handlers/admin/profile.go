@@ -7,19 +7,27 @@ import (
 	"github.com/google/uuid"
 	"github.com/tanydotai/tanyai/backend/internal/dto"
 	"github.com/tanydotai/tanyai/backend/internal/httpapi"
+	"github.com/tanydotai/tanyai/backend/internal/models"
 	"github.com/tanydotai/tanyai/backend/internal/repos"
+	"github.com/tanydotai/tanyai/backend/internal/storage"
 )
 
-// ProfileHandler handles admin profile endpoints.
+// ProfileHandler handles admin profile endpoints. Writes only change the draft; the
+// knowledge base picks them up once published through RevisionHandler.
 type ProfileHandler struct {
-	repo       repos.ProfileRepository
-	invalidate func()
+	repo repos.ProfileRepository
+	urls storage.URLSigner
 }
 
 // NewProfileHandler constructs a ProfileHandler.
-func NewProfileHandler(repo repos.ProfileRepository, invalidate func()) *ProfileHandler {
+func NewProfileHandler(repo repos.ProfileRepository) *ProfileHandler {
 	ensureValidators()
-	return &ProfileHandler{repo: repo, invalidate: invalidate}
+	return &ProfileHandler{repo: repo, urls: storage.SignerFor(nil)}
+}
+
+// SetURLSigner signs avatar URLs in responses and stores them unsigned.
+func (h *ProfileHandler) SetURLSigner(signer storage.URLSigner) {
+	h.urls = signer
 }
 
 // Get returns the current profile or 404 if missing.
@@ -28,7 +36,7 @@ func (h *ProfileHandler) Get(c *gin.Context) {
 	if handleRepoError(c, err) {
 		return
 	}
-	httpapi.RespondData(c, http.StatusOK, dto.NewProfileResponse(profile))
+	httpapi.RespondData(c, http.StatusOK, h.response(profile))
 }
 
 // Put creates or updates the profile.
@@ -40,15 +48,19 @@ func (h *ProfileHandler) Put(c *gin.Context) {
 	}
 
 	model := req.ToModel(uuid.Nil)
+	model.AvatarURL = h.urls.StableURL(model.AvatarURL)
+	model.AvatarVariants = model.AvatarVariants.WithURLs(h.urls.StableURL)
 	profile, err := h.repo.Upsert(c.Request.Context(), model)
 	if err != nil {
 		handleRepoError(c, err)
 		return
 	}
 
-	if h.invalidate != nil {
-		h.invalidate()
-	}
+	httpapi.RespondData(c, http.StatusOK, h.response(profile))
+}
 
-	httpapi.RespondData(c, http.StatusOK, dto.NewProfileResponse(profile))
+func (h *ProfileHandler) response(profile models.Profile) dto.ProfileResponse {
+	profile.AvatarURL = h.urls.SignURL(profile.AvatarURL)
+	profile.AvatarVariants = profile.AvatarVariants.WithURLs(h.urls.SignURL)
+	return dto.NewProfileResponse(profile)
 }
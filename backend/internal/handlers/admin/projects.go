@@ -9,18 +9,26 @@ import (
 	"github.com/tanydotai/tanyai/backend/internal/httpapi"
 	"github.com/tanydotai/tanyai/backend/internal/models"
 	"github.com/tanydotai/tanyai/backend/internal/repos"
+	"github.com/tanydotai/tanyai/backend/internal/storage"
 )
 
-// ProjectHandler manages admin project endpoints.
+// ProjectHandler manages admin project endpoints. Writes only change drafts, while
+// deletions take effect immediately and therefore invalidate the knowledge base.
 type ProjectHandler struct {
 	repo       repos.ProjectRepository
 	invalidate func()
+	urls       storage.URLSigner
 }
 
 // NewProjectHandler constructs a ProjectHandler.
 func NewProjectHandler(repo repos.ProjectRepository, invalidate func()) *ProjectHandler {
 	ensureValidators()
-	return &ProjectHandler{repo: repo, invalidate: invalidate}
+	return &ProjectHandler{repo: repo, invalidate: invalidate, urls: storage.SignerFor(nil)}
+}
+
+// SetURLSigner signs image URLs in responses and stores them unsigned.
+func (h *ProjectHandler) SetURLSigner(signer storage.URLSigner) {
+	h.urls = signer
 }
 
 // List returns paginated projects.
@@ -33,7 +41,7 @@ func (h *ProjectHandler) List(c *gin.Context) {
 
 	responses := make([]dto.ProjectResponse, len(projects))
 	for i, project := range projects {
-		responses[i] = dto.NewProjectResponse(project)
+		responses[i] = h.response(project)
 	}
 
 	httpapi.RespondList(c, http.StatusOK, responses, params.Page, params.Limit, total)
@@ -47,18 +55,14 @@ func (h *ProjectHandler) Create(c *gin.Context) {
 		return
 	}
 
-	model := req.ToModel(uuid.Nil, models.Project{})
+	model := h.stable(req.ToModel(uuid.Nil, models.Project{}))
 	created, err := h.repo.Create(c.Request.Context(), model)
 	if err != nil {
 		handleRepoError(c, err)
 		return
 	}
 
-	if h.invalidate != nil {
-		h.invalidate()
-	}
-
-	httpapi.RespondData(c, http.StatusCreated, dto.NewProjectResponse(created))
+	httpapi.RespondData(c, http.StatusCreated, h.response(created))
 }
 
 // Update modifies a project entry.
@@ -75,18 +79,14 @@ func (h *ProjectHandler) Update(c *gin.Context) {
 		return
 	}
 
-	model := req.ToModel(id, models.Project{ID: id})
+	model := h.stable(req.ToModel(id, models.Project{ID: id}))
 	updated, err := h.repo.Update(c.Request.Context(), model)
 	if err != nil {
 		handleRepoError(c, err)
 		return
 	}
 
-	if h.invalidate != nil {
-		h.invalidate()
-	}
-
-	httpapi.RespondData(c, http.StatusOK, dto.NewProjectResponse(updated))
+	httpapi.RespondData(c, http.StatusOK, h.response(updated))
 }
 
 // Delete removes a project entry.
@@ -127,10 +127,6 @@ func (h *ProjectHandler) Reorder(c *gin.Context) {
 		return
 	}
 
-	if h.invalidate != nil {
-		h.invalidate()
-	}
-
 	c.Status(http.StatusNoContent)
 }
 
@@ -154,9 +150,21 @@ func (h *ProjectHandler) Feature(c *gin.Context) {
 		return
 	}
 
-	if h.invalidate != nil {
-		h.invalidate()
+	httpapi.RespondData(c, http.StatusOK, h.response(project))
+}
+
+func (h *ProjectHandler) response(project models.Project) dto.ProjectResponse {
+	if project.ImageURL.Valid {
+		project.ImageURL.String = h.urls.SignURL(project.ImageURL.String)
 	}
+	project.ImageVariants = project.ImageVariants.WithURLs(h.urls.SignURL)
+	return dto.NewProjectResponse(project)
+}
 
-	httpapi.RespondData(c, http.StatusOK, dto.NewProjectResponse(project))
+func (h *ProjectHandler) stable(project models.Project) models.Project {
+	if project.ImageURL.Valid {
+		project.ImageURL.String = h.urls.StableURL(project.ImageURL.String)
+	}
+	project.ImageVariants = project.ImageVariants.WithURLs(h.urls.StableURL)
+	return project
 }
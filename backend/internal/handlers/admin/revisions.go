@@ -0,0 +1,222 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tanydotai/tanyai/backend/internal/audit"
+	"github.com/tanydotai/tanyai/backend/internal/dto"
+	"github.com/tanydotai/tanyai/backend/internal/httpapi"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/repos"
+)
+
+// revisionEntities maps the :entity route segment to the audited entity type.
+var revisionEntities = map[string]string{
+	"profile":  audit.EntityProfile,
+	"services": audit.EntityService,
+	"projects": audit.EntityProject,
+}
+
+// RevisionHandler publishes drafts and exposes their revision history. Publishing is the
+// only write that invalidates the knowledge base.
+type RevisionHandler struct {
+	repo       repos.RevisionRepository
+	invalidate func()
+	now        func() time.Time
+}
+
+// NewRevisionHandler constructs a RevisionHandler.
+func NewRevisionHandler(repo repos.RevisionRepository, invalidate func()) *RevisionHandler {
+	ensureValidators()
+	return &RevisionHandler{repo: repo, invalidate: invalidate, now: time.Now}
+}
+
+// PublishBatch publishes several drafts atomically, or schedules them when publish_at is
+// in the future.
+func (h *RevisionHandler) PublishBatch(c *gin.Context) {
+	var req dto.PublishRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+	refs := make([]repos.RevisionRef, len(req.Items))
+	for i, item := range req.Items {
+		refs[i] = repos.RevisionRef{EntityType: revisionEntities[item.Entity], EntityID: item.ID}
+	}
+	h.publish(c, refs, req.PublishAt)
+}
+
+// Publish publishes a single draft. An optional publish_at body schedules it instead.
+func (h *RevisionHandler) Publish(c *gin.Context) {
+	ref, ok := h.parseRef(c)
+	if !ok {
+		return
+	}
+	var req dto.SchedulePublishRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondValidationError(c, err)
+			return
+		}
+	}
+	h.publish(c, []repos.RevisionRef{ref}, req.PublishAt)
+}
+
+func (h *RevisionHandler) publish(c *gin.Context, refs []repos.RevisionRef, publishAt *time.Time) {
+	if publishAt != nil && publishAt.After(h.now()) {
+		at := publishAt.UTC()
+		if handleRepoError(c, h.repo.Schedule(c.Request.Context(), refs, &at)) {
+			return
+		}
+		httpapi.RespondData(c, http.StatusAccepted, gin.H{"publish_at": at, "scheduled": len(refs)})
+		return
+	}
+
+	revisions, err := h.repo.Publish(c.Request.Context(), refs)
+	if handleRepoError(c, err) {
+		return
+	}
+	if h.invalidate != nil {
+		h.invalidate()
+	}
+
+	responses := make([]dto.RevisionResponse, len(revisions))
+	for i, revision := range revisions {
+		responses[i] = dto.NewRevisionResponse(revision, false)
+	}
+	httpapi.RespondData(c, http.StatusOK, responses)
+}
+
+// CancelSchedule clears a pending scheduled publish.
+func (h *RevisionHandler) CancelSchedule(c *gin.Context) {
+	ref, ok := h.parseRef(c)
+	if !ok {
+		return
+	}
+	if handleRepoError(c, h.repo.Schedule(c.Request.Context(), []repos.RevisionRef{ref}, nil)) {
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// List returns the revision history, newest first.
+func (h *RevisionHandler) List(c *gin.Context) {
+	ref, ok := h.parseRef(c)
+	if !ok {
+		return
+	}
+	params := parseListParams(c)
+	revisions, total, err := h.repo.List(c.Request.Context(), ref, params)
+	if handleListError(c, err) {
+		return
+	}
+
+	responses := make([]dto.RevisionResponse, len(revisions))
+	for i, revision := range revisions {
+		responses[i] = dto.NewRevisionResponse(revision, false)
+	}
+	httpapi.RespondList(c, http.StatusOK, responses, params.Page, params.Limit, total)
+}
+
+// Get returns a single revision including its content.
+func (h *RevisionHandler) Get(c *gin.Context) {
+	ref, version, ok := h.parseVersion(c)
+	if !ok {
+		return
+	}
+	revision, err := h.repo.Get(c.Request.Context(), ref, version)
+	if handleRepoError(c, err) {
+		return
+	}
+	httpapi.RespondData(c, http.StatusOK, dto.NewRevisionResponse(revision, true))
+}
+
+// Diff compares a revision with an older one (?against=<version>, default the previous
+// version) or with the current draft (?against=draft).
+func (h *RevisionHandler) Diff(c *gin.Context) {
+	ref, version, ok := h.parseVersion(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	revision, err := h.repo.Get(ctx, ref, version)
+	if handleRepoError(c, err) {
+		return
+	}
+
+	against := c.DefaultQuery("against", strconv.Itoa(version-1))
+	if against == "draft" {
+		draft, err := h.repo.Draft(ctx, ref)
+		if handleRepoError(c, err) {
+			return
+		}
+		httpapi.RespondData(c, http.StatusOK, dto.RevisionDiffResponse{
+			From:    "v" + strconv.Itoa(version),
+			To:      "draft",
+			Changes: audit.Diff(revision.Data, draft),
+		})
+		return
+	}
+
+	base, err := strconv.Atoi(against)
+	if err != nil || base < 0 {
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "invalid request payload", map[string]string{"against": "must be a version number or draft"})
+		return
+	}
+	var baseData models.JSONB
+	if base > 0 {
+		baseRevision, err := h.repo.Get(ctx, ref, base)
+		if handleRepoError(c, err) {
+			return
+		}
+		baseData = baseRevision.Data
+	}
+	httpapi.RespondData(c, http.StatusOK, dto.RevisionDiffResponse{
+		From:    "v" + strconv.Itoa(base),
+		To:      "v" + strconv.Itoa(version),
+		Changes: audit.Diff(baseData, revision.Data),
+	})
+}
+
+// Restore copies a revision into the draft. It has to be published to go live.
+func (h *RevisionHandler) Restore(c *gin.Context) {
+	ref, version, ok := h.parseVersion(c)
+	if !ok {
+		return
+	}
+	if handleRepoError(c, h.repo.Restore(c.Request.Context(), ref, version)) {
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *RevisionHandler) parseRef(c *gin.Context) (repos.RevisionRef, bool) {
+	entityType, ok := revisionEntities[c.Param("entity")]
+	if !ok {
+		httpapi.RespondError(c, http.StatusNotFound, httpapi.ErrorCodeNotFound, "resource not found", nil)
+		return repos.RevisionRef{}, false
+	}
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondValidationError(c, err)
+		return repos.RevisionRef{}, false
+	}
+	return repos.RevisionRef{EntityType: entityType, EntityID: id}, true
+}
+
+func (h *RevisionHandler) parseVersion(c *gin.Context) (repos.RevisionRef, int, bool) {
+	ref, ok := h.parseRef(c)
+	if !ok {
+		return repos.RevisionRef{}, 0, false
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "invalid request payload", map[string]string{"version": "must be a positive integer"})
+		return repos.RevisionRef{}, 0, false
+	}
+	return ref, version, true
+}
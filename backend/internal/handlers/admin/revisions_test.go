@@ -0,0 +1,147 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/tanydotai/tanyai/backend/internal/audit"
+	"github.com/tanydotai/tanyai/backend/internal/dto"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/repos"
+)
+
+type stubRevisionRepo struct {
+	repos.RevisionRepository
+	published []repos.RevisionRef
+	scheduled []repos.RevisionRef
+	at        *time.Time
+	revisions map[int]models.JSONB
+	draft     models.JSONB
+}
+
+func (s *stubRevisionRepo) Publish(ctx context.Context, refs []repos.RevisionRef) ([]models.ContentRevision, error) {
+	s.published = append(s.published, refs...)
+	out := make([]models.ContentRevision, len(refs))
+	for i, ref := range refs {
+		out[i] = models.ContentRevision{ID: uuid.New(), EntityType: ref.EntityType, EntityID: ref.EntityID, Version: 1}
+	}
+	return out, nil
+}
+
+func (s *stubRevisionRepo) Schedule(ctx context.Context, refs []repos.RevisionRef, at *time.Time) error {
+	s.scheduled = append(s.scheduled, refs...)
+	s.at = at
+	return nil
+}
+
+func (s *stubRevisionRepo) Get(ctx context.Context, ref repos.RevisionRef, version int) (models.ContentRevision, error) {
+	data, ok := s.revisions[version]
+	if !ok {
+		return models.ContentRevision{}, repos.ErrNotFound
+	}
+	return models.ContentRevision{EntityType: ref.EntityType, EntityID: ref.EntityID, Version: version, Data: data}, nil
+}
+
+func (s *stubRevisionRepo) Draft(ctx context.Context, ref repos.RevisionRef) (models.JSONB, error) {
+	return s.draft, nil
+}
+
+func newRevisionRouter(repo *stubRevisionRepo, invalidate func()) (*gin.Engine, *RevisionHandler) {
+	gin.SetMode(gin.TestMode)
+	handler := NewRevisionHandler(repo, invalidate)
+	handler.now = func() time.Time { return time.Date(2025, 2, 8, 12, 0, 0, 0, time.UTC) }
+	router := gin.New()
+	router.POST("/publish", handler.PublishBatch)
+	router.POST("/content/:entity/:id/publish", handler.Publish)
+	router.GET("/content/:entity/:id/revisions/:version/diff", handler.Diff)
+	return router, handler
+}
+
+func TestRevisionPublishBatchInvalidatesOnlyWhenPublishing(t *testing.T) {
+	repo := &stubRevisionRepo{}
+	invalidated := 0
+	router, _ := newRevisionRouter(repo, func() { invalidated++ })
+	serviceID, projectID := uuid.New(), uuid.New()
+
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/publish", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post(`{"items":[{"entity":"services","id":"` + serviceID.String() + `"},{"entity":"projects","id":"` + projectID.String() + `"}]}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Equal(t, []repos.RevisionRef{{EntityType: audit.EntityService, EntityID: serviceID}, {EntityType: audit.EntityProject, EntityID: projectID}}, repo.published)
+	require.Equal(t, 1, invalidated)
+
+	rec = post(`{"items":[{"entity":"services","id":"` + serviceID.String() + `"}],"publish_at":"2025-02-09T08:00:00+07:00"}`)
+	require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+	require.Len(t, repo.scheduled, 1)
+	require.Equal(t, time.Date(2025, 2, 9, 1, 0, 0, 0, time.UTC), *repo.at)
+	require.Equal(t, 1, invalidated)
+
+	rec = post(`{"items":[{"entity":"skills","id":"` + serviceID.String() + `"}]}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestRevisionPublishSingleWithPastSchedulePublishesNow(t *testing.T) {
+	repo := &stubRevisionRepo{}
+	router, _ := newRevisionRouter(repo, nil)
+	profileID := uuid.New()
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/content/profile/"+profileID.String()+"/publish", bytes.NewBufferString(`{"publish_at":"2025-01-01T00:00:00Z"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Equal(t, []repos.RevisionRef{{EntityType: audit.EntityProfile, EntityID: profileID}}, repo.published)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/content/skills/"+profileID.String()+"/publish", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestRevisionDiff(t *testing.T) {
+	repo := &stubRevisionRepo{
+		revisions: map[int]models.JSONB{
+			1: {"name": "Audit", "price_min": 100.0},
+			2: {"name": "Audit", "price_min": 150.0},
+		},
+		draft: models.JSONB{"name": "Audit+", "price_min": 150.0},
+	}
+	router, _ := newRevisionRouter(repo, nil)
+	base := "/content/services/" + uuid.NewString() + "/revisions/2/diff"
+
+	diff := func(query string) dto.RevisionDiffResponse {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, base+query, nil))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var body struct {
+			Data dto.RevisionDiffResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return body.Data
+	}
+
+	previous := diff("")
+	require.Equal(t, "v1", previous.From)
+	require.Equal(t, map[string]any{"price_min": map[string]any{"before": 100.0, "after": 150.0}}, previous.Changes)
+
+	draft := diff("?against=draft")
+	require.Equal(t, "draft", draft.To)
+	require.Equal(t, map[string]any{"name": map[string]any{"before": "Audit", "after": "Audit+"}}, draft.Changes)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, base+"?against=latest", nil))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
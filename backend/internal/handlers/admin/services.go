@@ -11,7 +11,8 @@ import (
 	"github.com/tanydotai/tanyai/backend/internal/repos"
 )
 
-// ServiceHandler manages admin service endpoints.
+// ServiceHandler manages admin service endpoints. Writes only change drafts, while
+// deletions take effect immediately and therefore invalidate the knowledge base.
 type ServiceHandler struct {
 	repo       repos.ServiceRepository
 	invalidate func()
@@ -67,10 +68,6 @@ func (h *ServiceHandler) Create(c *gin.Context) {
 		return
 	}
 
-	if h.invalidate != nil {
-		h.invalidate()
-	}
-
 	httpapi.RespondData(c, http.StatusCreated, dto.NewServiceResponse(created))
 }
 
@@ -101,10 +98,6 @@ func (h *ServiceHandler) Update(c *gin.Context) {
 		return
 	}
 
-	if h.invalidate != nil {
-		h.invalidate()
-	}
-
 	httpapi.RespondData(c, http.StatusOK, dto.NewServiceResponse(updated))
 }
 
@@ -146,10 +139,6 @@ func (h *ServiceHandler) Reorder(c *gin.Context) {
 		return
 	}
 
-	if h.invalidate != nil {
-		h.invalidate()
-	}
-
 	c.Status(http.StatusNoContent)
 }
 
@@ -177,10 +166,6 @@ func (h *ServiceHandler) Toggle(c *gin.Context) {
 		return
 	}
 
-	if h.invalidate != nil {
-		h.invalidate()
-	}
-
 	httpapi.RespondData(c, http.StatusOK, dto.NewServiceResponse(service))
 }
 
@@ -0,0 +1,99 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tanydotai/tanyai/backend/internal/audit"
+	"github.com/tanydotai/tanyai/backend/internal/dto"
+	"github.com/tanydotai/tanyai/backend/internal/httpapi"
+	"github.com/tanydotai/tanyai/backend/internal/repos"
+)
+
+// trashEntities maps the :entity route segment and ?entity filter to the entity type.
+var trashEntities = map[string]string{
+	"skills":   audit.EntitySkill,
+	"services": audit.EntityService,
+	"projects": audit.EntityProject,
+}
+
+// TrashHandler lists, restores and purges soft-deleted content.
+type TrashHandler struct {
+	repo       repos.TrashRepository
+	retention  time.Duration
+	invalidate func()
+}
+
+// NewTrashHandler constructs a TrashHandler. retention is only used to report when items
+// are purged automatically; zero means never.
+func NewTrashHandler(repo repos.TrashRepository, retention time.Duration, invalidate func()) *TrashHandler {
+	return &TrashHandler{repo: repo, retention: retention, invalidate: invalidate}
+}
+
+// List returns trashed items, most recently deleted first. ?entity=skills|services|projects
+// narrows the list.
+func (h *TrashHandler) List(c *gin.Context) {
+	entityType := ""
+	if entity := c.Query("entity"); entity != "" {
+		var ok bool
+		if entityType, ok = trashEntities[entity]; !ok {
+			httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "invalid request payload", map[string]string{"entity": "must be skills, services or projects"})
+			return
+		}
+	}
+
+	params := parseListParams(c)
+	items, total, err := h.repo.List(c.Request.Context(), entityType, params)
+	if handleListError(c, err) {
+		return
+	}
+
+	responses := make([]dto.TrashItemResponse, len(items))
+	for i, item := range items {
+		responses[i] = dto.NewTrashItemResponse(item, h.retention)
+	}
+	httpapi.RespondList(c, http.StatusOK, responses, params.Page, params.Limit, total)
+}
+
+// Restore takes an item out of the trash.
+func (h *TrashHandler) Restore(c *gin.Context) {
+	entityType, id, ok := parseTrashRef(c)
+	if !ok {
+		return
+	}
+	if handleRepoError(c, h.repo.Restore(c.Request.Context(), entityType, id)) {
+		return
+	}
+	if h.invalidate != nil {
+		h.invalidate()
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Purge permanently deletes a trashed item.
+func (h *TrashHandler) Purge(c *gin.Context) {
+	entityType, id, ok := parseTrashRef(c)
+	if !ok {
+		return
+	}
+	if handleRepoError(c, h.repo.Purge(c.Request.Context(), entityType, id)) {
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func parseTrashRef(c *gin.Context) (string, uuid.UUID, bool) {
+	entityType, ok := trashEntities[c.Param("entity")]
+	if !ok {
+		httpapi.RespondError(c, http.StatusNotFound, httpapi.ErrorCodeNotFound, "resource not found", nil)
+		return "", uuid.Nil, false
+	}
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondValidationError(c, err)
+		return "", uuid.Nil, false
+	}
+	return entityType, id, true
+}
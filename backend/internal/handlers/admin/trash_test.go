@@ -0,0 +1,106 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/tanydotai/tanyai/backend/internal/audit"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/repos"
+)
+
+type stubTrashRepo struct {
+	repos.TrashRepository
+	items      []models.TrashItem
+	listEntity string
+	restored   []uuid.UUID
+	purged     []uuid.UUID
+}
+
+func (s *stubTrashRepo) List(_ context.Context, entityType string, _ repos.ListParams) ([]models.TrashItem, int64, error) {
+	s.listEntity = entityType
+	return s.items, int64(len(s.items)), nil
+}
+
+func (s *stubTrashRepo) Restore(_ context.Context, entityType string, id uuid.UUID) error {
+	if entityType != audit.EntityService {
+		return repos.ErrNotFound
+	}
+	s.restored = append(s.restored, id)
+	return nil
+}
+
+func (s *stubTrashRepo) Purge(_ context.Context, _ string, id uuid.UUID) error {
+	s.purged = append(s.purged, id)
+	return nil
+}
+
+func newTrashRouter(repo *stubTrashRepo, invalidate func()) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	handler := NewTrashHandler(repo, 30*24*time.Hour, invalidate)
+	router := gin.New()
+	router.GET("/trash", handler.List)
+	router.POST("/trash/:entity/:id/restore", handler.Restore)
+	router.DELETE("/trash/:entity/:id", handler.Purge)
+	return router
+}
+
+func TestTrashListReportsPurgeTime(t *testing.T) {
+	deletedAt := time.Date(2025, 2, 9, 8, 0, 0, 0, time.UTC)
+	repo := &stubTrashRepo{items: []models.TrashItem{{EntityType: audit.EntityProject, ID: uuid.New(), Name: "Atlas", DeletedAt: deletedAt}}}
+	router := newTrashRouter(repo, nil)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/trash?entity=projects", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, audit.EntityProject, repo.listEntity)
+
+	var body struct {
+		Items []struct {
+			EntityType string    `json:"entity_type"`
+			PurgeAt    time.Time `json:"purge_at"`
+		} `json:"items"`
+		Total int `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Equal(t, 1, body.Total)
+	require.Equal(t, deletedAt.Add(30*24*time.Hour), body.Items[0].PurgeAt)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/trash?entity=profile", nil))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestTrashRestoreInvalidatesAndPurgeDoesNot(t *testing.T) {
+	repo := &stubTrashRepo{}
+	invalidated := 0
+	router := newTrashRouter(repo, func() { invalidated++ })
+	id := uuid.New()
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/trash/services/"+id.String()+"/restore", nil))
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	require.Equal(t, []uuid.UUID{id}, repo.restored)
+	require.Equal(t, 1, invalidated)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/trash/projects/"+id.String()+"/restore", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/trash/skills/"+id.String(), nil))
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	require.Equal(t, []uuid.UUID{id}, repo.purged)
+	require.Equal(t, 1, invalidated)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/trash/profile/"+id.String(), nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -2,6 +2,8 @@ package admin
 
 import (
 	"bytes"
+	"context"
+	"crypto/rand"
 	"encoding/json"
 	"encoding/xml"
 	"errors"
@@ -16,18 +18,40 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/tanydotai/tanyai/backend/internal/audit"
 	"github.com/tanydotai/tanyai/backend/internal/auth"
 	"github.com/tanydotai/tanyai/backend/internal/config"
 	"github.com/tanydotai/tanyai/backend/internal/httpapi"
+	"github.com/tanydotai/tanyai/backend/internal/media"
 	"github.com/tanydotai/tanyai/backend/internal/middleware"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/scanner"
 	"github.com/tanydotai/tanyai/backend/internal/storage"
 )
 
 // UploadsHandler processes secure media uploads for admin users.
 type UploadsHandler struct {
-	storage storage.ObjectStorage
-	policy  config.UploadConfig
-	logger  *log.Logger
+	storage   storage.ObjectStorage
+	policy    config.UploadConfig
+	logger    *log.Logger
+	audit     AuditRecorder
+	library   MediaRecorder
+	processor *media.Processor
+	scanners  *scanner.Chain
+	urls      storage.URLSigner
+	directKey []byte
+}
+
+// AuditRecorder records changes that are not made through a repository transaction.
+type AuditRecorder interface {
+	Record(ctx context.Context, change audit.Change) error
+}
+
+// MediaRecorder tracks stored objects so the media library can list and clean them up.
+type MediaRecorder interface {
+	Create(ctx context.Context, upload models.Upload, variants []models.Upload) (models.Upload, error)
+	// Registered reports whether an upload with key exists in any tenant.
+	Registered(ctx context.Context, key string) (bool, error)
 }
 
 // NewUploadsHandler constructs UploadsHandler.
@@ -35,14 +59,63 @@ func NewUploadsHandler(store storage.ObjectStorage, policy config.UploadConfig,
 	if logger == nil {
 		logger = log.New(os.Stdout, "", 0)
 	}
-	return &UploadsHandler{storage: store, policy: policy, logger: logger}
+	handler := &UploadsHandler{storage: store, policy: policy, logger: logger, urls: storage.SignerFor(store)}
+	handler.directKey = []byte(policy.Direct.SigningKey)
+	if len(handler.directKey) == 0 {
+		handler.directKey = make([]byte, 32)
+		if _, err := rand.Read(handler.directKey); err != nil {
+			panic(fmt.Sprintf("uploads: generate direct upload key: %v", err))
+		}
+	}
+	if policy.Image.Enabled {
+		variants := make([]media.VariantSpec, 0, len(policy.Image.Variants))
+		for _, variant := range policy.Image.Variants {
+			variants = append(variants, media.VariantSpec{Name: variant.Name, Width: variant.Width})
+		}
+		handler.processor = media.NewProcessor(media.Options{
+			Variants:    variants,
+			JPEGQuality: policy.Image.JPEGQuality,
+			WebP:        policy.Image.WebP,
+			MaxPixels:   policy.Image.MaxPixels,
+		})
+	}
+	handler.scanners = newScannerChain(policy.Scan)
+	return handler
+}
+
+// newScannerChain builds the configured upload scanners, or nil when none are enabled.
+func newScannerChain(cfg config.ScanConfig) *scanner.Chain {
+	var scanners []scanner.Scanner
+	if cfg.ClamdAddr != "" {
+		scanners = append(scanners, scanner.NewClamd(cfg.ClamdAddr, cfg.ClamdTimeout, cfg.ClamdStreamMax))
+	}
+	if cfg.Builtin {
+		scanners = append(scanners, scanner.NewContent())
+	}
+	if len(scanners) == 0 {
+		return nil
+	}
+	return scanner.NewChain(cfg.FailOpen, scanners...)
 }
 
 var mimeExtensions = map[string]string{
-	"image/jpeg":    ".jpg",
-	"image/png":     ".png",
-	"image/webp":    ".webp",
-	"image/svg+xml": ".svg",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/webp":      ".webp",
+	"image/svg+xml":   ".svg",
+	"video/mp4":       ".mp4",
+	"video/webm":      ".webm",
+	"application/pdf": ".pdf",
+}
+
+// SetAuditRecorder enables audit entries for successful uploads.
+func (h *UploadsHandler) SetAuditRecorder(recorder AuditRecorder) {
+	h.audit = recorder
+}
+
+// SetMediaRecorder records successful uploads and their variants in the media library.
+func (h *UploadsHandler) SetMediaRecorder(recorder MediaRecorder) {
+	h.library = recorder
 }
 
 // Create handles secure image uploads and returns a public URL.
@@ -114,6 +187,31 @@ func (h *UploadsHandler) Create(c *gin.Context) {
 		return
 	}
 
+	var scanReport *scanner.Report
+	if h.scanners != nil {
+		// Reading from memory cannot fail.
+		report, _ := h.scanners.Scan(c.Request.Context(), bytes.NewReader(data), detected)
+		scanReport = &report
+		if report.Status == scanner.StatusInfected || report.Status == scanner.StatusFailed {
+			h.logUpload(map[string]interface{}{
+				"route":      c.FullPath(),
+				"method":     c.Request.Method,
+				"error":      "scan " + string(report.Status),
+				"mime":       detected,
+				"size":       len(data),
+				"scan":       report,
+				"user_id":    userIDFromClaims(claims),
+				"latency_ms": time.Since(started).Milliseconds(),
+			})
+			if report.Status == scanner.StatusInfected {
+				httpapi.RespondError(c, http.StatusUnprocessableEntity, httpapi.ErrorCodeValidation, "file rejected by scanner", map[string]string{"threat": report.Threat})
+				return
+			}
+			httpapi.RespondError(c, http.StatusServiceUnavailable, httpapi.ErrorCodeExternal, "upload scanner unavailable", nil)
+			return
+		}
+	}
+
 	if detected == "image/svg+xml" {
 		if !h.policy.AllowSVG {
 			httpapi.RespondError(c, http.StatusUnsupportedMediaType, httpapi.ErrorCodeValidation, "svg uploads are disabled", nil)
@@ -127,6 +225,21 @@ func (h *UploadsHandler) Create(c *gin.Context) {
 		data = sanitized
 	}
 
+	var processed *media.Result
+	if h.processor != nil && media.Supports(detected) {
+		processed, err = h.processor.Process(data, detected)
+		if err != nil {
+			if errors.Is(err, media.ErrTooManyPixels) {
+				httpapi.RespondError(c, http.StatusRequestEntityTooLarge, httpapi.ErrorCodeValidation, "image dimensions exceed limit", nil)
+				return
+			}
+			httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "invalid image", nil)
+			return
+		}
+		data = processed.Original.Data
+		detected = processed.Original.ContentType
+	}
+
 	ext := mimeExtensions[detected]
 	if ext == "" {
 		ext = filepath.Ext(header.Filename)
@@ -135,10 +248,16 @@ func (h *UploadsHandler) Create(c *gin.Context) {
 		ext = ".bin"
 	}
 
-	now := time.Now().UTC()
-	key := fmt.Sprintf("uploads/%04d/%02d/%02d/%s%s", now.Year(), now.Month(), now.Day(), uuid.NewString(), ext)
+	key := newUploadKey(ext)
 
 	publicURL, err := h.storage.Put(c.Request.Context(), key, data, detected)
+	var (
+		images   *models.ImageSet
+		variants []models.Upload
+	)
+	if err == nil && processed != nil {
+		images, variants, err = h.storeVariants(c.Request.Context(), strings.TrimSuffix(key, ext), processed)
+	}
 	if err != nil {
 		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "failed to store file", nil)
 		h.logUpload(map[string]interface{}{
@@ -154,7 +273,7 @@ func (h *UploadsHandler) Create(c *gin.Context) {
 		return
 	}
 
-	h.logUpload(map[string]interface{}{
+	fields := map[string]interface{}{
 		"route":      c.FullPath(),
 		"method":     c.Request.Method,
 		"mime":       detected,
@@ -163,14 +282,101 @@ func (h *UploadsHandler) Create(c *gin.Context) {
 		"url":        publicURL,
 		"user_id":    userIDFromClaims(claims),
 		"latency_ms": time.Since(started).Milliseconds(),
-	})
+	}
+	if scanReport != nil {
+		fields["scan"] = scanReport
+	}
+	h.logUpload(fields)
+
+	after := map[string]any{"url": publicURL, "contentType": detected, "size": len(data)}
+	if images != nil {
+		after["variants"] = len(images.Variants)
+	}
+	if scanReport != nil {
+		after["scan"] = scanReport.Status
+	}
+	h.auditUpload(c.Request.Context(), key, after)
 
-	httpapi.RespondData(c, http.StatusCreated, gin.H{
-		"url":         publicURL,
+	response := gin.H{
+		"url":         h.urls.SignURL(publicURL),
 		"key":         key,
 		"contentType": detected,
 		"size":        len(data),
-	})
+	}
+	if h.library != nil {
+		upload := models.Upload{Key: key, URL: publicURL, ContentType: detected, SizeBytes: int64(len(data)), UploadedBy: uploaderFromClaims(claims)}
+		// The object is stored either way; an unrecorded upload is only cleaned up once it
+		// is unreferenced and older than the orphan grace period.
+		if created, err := h.library.Create(c.Request.Context(), upload, variants); err != nil {
+			h.logger.Printf("upload record failed: %v", err)
+		} else {
+			response["id"] = created.ID
+		}
+	}
+	if scanReport != nil {
+		response["scan"] = scanReport
+	}
+	if images != nil {
+		response["width"] = images.Width
+		response["height"] = images.Height
+		response["image"] = images.WithURLs(h.urls.SignURL)
+	}
+	httpapi.RespondData(c, http.StatusCreated, response)
+}
+
+// auditUpload records a stored upload; failures are logged because the object already exists.
+func (h *UploadsHandler) auditUpload(ctx context.Context, key string, after map[string]any) {
+	if h.audit == nil {
+		return
+	}
+	change := audit.Change{
+		Action:     audit.ActionUpload,
+		EntityType: audit.EntityUpload,
+		EntityID:   key,
+		After:      after,
+	}
+	if err := h.audit.Record(ctx, change); err != nil {
+		h.logger.Printf("upload audit failed: %v", err)
+	}
+}
+
+// newUploadKey returns a fresh object key under the date-partitioned uploads prefix.
+func newUploadKey(ext string) string {
+	now := time.Now().UTC()
+	return fmt.Sprintf("uploads/%04d/%02d/%02d/%s%s", now.Year(), now.Month(), now.Day(), uuid.NewString(), ext)
+}
+
+// storeVariants uploads the resized renditions next to the original, named
+// "<base>_<variant><ext>" plus "<base>_<variant>.webp" when a WebP copy exists, and
+// returns every stored object for the media library.
+func (h *UploadsHandler) storeVariants(ctx context.Context, base string, result *media.Result) (*models.ImageSet, []models.Upload, error) {
+	images := &models.ImageSet{
+		Width:         result.Width,
+		Height:        result.Height,
+		Blurhash:      result.Blurhash,
+		DominantColor: result.DominantColor,
+		Variants:      make([]models.ImageVariant, 0, len(result.Variants)),
+	}
+	var objects []models.Upload
+	for _, variant := range result.Variants {
+		name := base + "_" + variant.Name
+		key := name + mimeExtensions[variant.Image.ContentType]
+		url, err := h.storage.Put(ctx, key, variant.Image.Data, variant.Image.ContentType)
+		if err != nil {
+			return nil, nil, err
+		}
+		objects = append(objects, models.Upload{Key: key, URL: url, ContentType: variant.Image.ContentType, SizeBytes: int64(len(variant.Image.Data))})
+		stored := models.ImageVariant{Name: variant.Name, Width: variant.Width, Height: variant.Height, URL: url}
+		if variant.WebP != nil {
+			stored.WebPURL, err = h.storage.Put(ctx, name+".webp", variant.WebP, media.TypeWebP)
+			if err != nil {
+				return nil, nil, err
+			}
+			objects = append(objects, models.Upload{Key: name + ".webp", URL: stored.WebPURL, ContentType: media.TypeWebP, SizeBytes: int64(len(variant.WebP))})
+		}
+		images.Variants = append(images.Variants, stored)
+	}
+	return images, objects, nil
 }
 
 func (h *UploadsHandler) isAllowed(mime string) bool {
@@ -224,6 +430,14 @@ func userIDFromClaims(claims *auth.Claims) string {
 	return claims.UserID.String()
 }
 
+func uploaderFromClaims(claims *auth.Claims) *uuid.UUID {
+	if claims == nil || claims.UserID == uuid.Nil {
+		return nil
+	}
+	userID := claims.UserID
+	return &userID
+}
+
 func (h *UploadsHandler) logUpload(fields map[string]interface{}) {
 	payload, err := json.Marshal(fields)
 	if err != nil {
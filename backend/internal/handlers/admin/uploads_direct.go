@@ -0,0 +1,328 @@
+package admin
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tanydotai/tanyai/backend/internal/dto"
+	"github.com/tanydotai/tanyai/backend/internal/httpapi"
+	"github.com/tanydotai/tanyai/backend/internal/middleware"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/repos"
+	"github.com/tanydotai/tanyai/backend/internal/scanner"
+	"github.com/tanydotai/tanyai/backend/internal/storage"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
+)
+
+const (
+	// sniffLength is how much of an uploaded object is read back for content detection.
+	sniffLength = 512
+	// directCompleteGrace is how long after its upload URL expires an upload can still be
+	// completed.
+	directCompleteGrace = time.Hour
+)
+
+var (
+	errInvalidDirectToken = errors.New("invalid upload token")
+	errDirectTokenExpired = errors.New("upload token expired")
+)
+
+// directKeyPattern matches the shape of keys issued by Presign; the completion token then
+// proves the key was issued to the caller's tenant.
+var directKeyPattern = regexp.MustCompile(`^uploads/\d{4}/\d{2}/\d{2}/[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\.[a-z0-9]{1,8}$`)
+
+var fileExtensionPattern = regexp.MustCompile(`^\.[a-z0-9]{1,8}$`)
+
+// sniffableMIMEs are the types http.DetectContentType recognises; for these the detected
+// type must match exactly instead of falling back to the declared one.
+var sniffableMIMEs = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/webp":      true,
+	"image/gif":       true,
+	"video/mp4":       true,
+	"video/webm":      true,
+	"application/pdf": true,
+}
+
+// Presign validates a declared upload against the direct upload policy and returns a
+// presigned URL the client PUTs the file to.
+func (h *UploadsHandler) Presign(c *gin.Context) {
+	direct, ok := h.storage.(storage.DirectUploader)
+	if !ok {
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "direct uploads are not supported by the storage driver", nil)
+		return
+	}
+
+	var req dto.PresignUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+	contentType := normalizeMIME(req.ContentType)
+	if !h.isDirectAllowed(contentType) {
+		httpapi.RespondError(c, http.StatusUnsupportedMediaType, httpapi.ErrorCodeValidation, "unsupported media type", nil)
+		return
+	}
+	if req.Size > h.policy.Direct.MaxBytes {
+		httpapi.RespondError(c, http.StatusRequestEntityTooLarge, httpapi.ErrorCodeValidation, fmt.Sprintf("file exceeds %d bytes", h.policy.Direct.MaxBytes), nil)
+		return
+	}
+
+	ext := mimeExtensions[contentType]
+	if ext == "" {
+		ext = strings.ToLower(filepath.Ext(req.Filename))
+	}
+	if !fileExtensionPattern.MatchString(ext) {
+		ext = ".bin"
+	}
+	key := newUploadKey(ext)
+
+	presigned, err := direct.PresignPut(c.Request.Context(), key, contentType, req.Size, h.policy.Direct.URLTTL)
+	if err != nil {
+		h.logger.Printf("upload presign failed: %v", err)
+		httpapi.RespondError(c, http.StatusBadGateway, httpapi.ErrorCodeExternal, "failed to presign upload", nil)
+		return
+	}
+	httpapi.RespondData(c, http.StatusOK, dto.PresignUploadResponse{
+		Key:       key,
+		UploadURL: presigned.URL,
+		Method:    presigned.Method,
+		Headers:   presigned.Headers,
+		ExpiresAt: presigned.ExpiresAt,
+		Token:     h.directToken(c.Request.Context(), key, contentType, req.Size, presigned.ExpiresAt.Add(directCompleteGrace)),
+	})
+}
+
+// Complete verifies an object uploaded through a presigned URL and registers it. The
+// presign token and the media library are checked before the object is touched, so only
+// the tenant that was issued a key can complete it, and only once. Objects whose size or
+// sniffed content does not match the declaration, or that the upload scanners reject or
+// cannot scan, are deleted.
+func (h *UploadsHandler) Complete(c *gin.Context) {
+	started := time.Now()
+	claims, _ := middleware.GetClaims(c)
+
+	direct, ok := h.storage.(storage.DirectUploader)
+	if !ok {
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "direct uploads are not supported by the storage driver", nil)
+		return
+	}
+
+	var req dto.CompleteUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+	contentType := normalizeMIME(req.ContentType)
+	if !directKeyPattern.MatchString(req.Key) {
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "invalid request payload", map[string]string{"key": "must be a key returned by presign"})
+		return
+	}
+	if !h.isDirectAllowed(contentType) {
+		httpapi.RespondError(c, http.StatusUnsupportedMediaType, httpapi.ErrorCodeValidation, "unsupported media type", nil)
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.verifyDirectToken(ctx, req.Token, req.Key, contentType, req.Size); err != nil {
+		httpapi.RespondError(c, http.StatusForbidden, httpapi.ErrorCodeForbidden, err.Error(), nil)
+		return
+	}
+	if h.library != nil {
+		registered, err := h.library.Registered(ctx, req.Key)
+		if err != nil {
+			h.logger.Printf("upload lookup failed: %v", err)
+			httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "failed to verify upload", nil)
+			return
+		}
+		if registered {
+			httpapi.RespondError(c, http.StatusConflict, httpapi.ErrorCodeConflict, "upload already completed", nil)
+			return
+		}
+	}
+
+	info, err := h.storage.Stat(ctx, req.Key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			httpapi.RespondError(c, http.StatusNotFound, httpapi.ErrorCodeNotFound, "object has not been uploaded", nil)
+			return
+		}
+		h.logger.Printf("upload stat failed: %v", err)
+		httpapi.RespondError(c, http.StatusBadGateway, httpapi.ErrorCodeExternal, "failed to verify upload", nil)
+		return
+	}
+
+	var scanReport *scanner.Report
+	reject := func(status int, message string, details map[string]string) {
+		if err := h.storage.Delete(ctx, req.Key); err != nil {
+			h.logger.Printf("rejected upload delete failed: %v", err)
+		}
+		fields := map[string]interface{}{
+			"route":      c.FullPath(),
+			"method":     c.Request.Method,
+			"error":      message,
+			"mime":       contentType,
+			"size":       info.Size,
+			"key":        req.Key,
+			"user_id":    userIDFromClaims(claims),
+			"latency_ms": time.Since(started).Milliseconds(),
+		}
+		if scanReport != nil {
+			fields["scan"] = scanReport
+		}
+		h.logUpload(fields)
+		httpapi.RespondError(c, status, httpapi.ErrorCodeValidation, message, details)
+	}
+
+	if info.Size > h.policy.Direct.MaxBytes {
+		reject(http.StatusRequestEntityTooLarge, fmt.Sprintf("file exceeds %d bytes", h.policy.Direct.MaxBytes), nil)
+		return
+	}
+	if info.Size != req.Size {
+		reject(http.StatusBadRequest, "uploaded size does not match", nil)
+		return
+	}
+
+	head, err := direct.ReadPrefix(ctx, req.Key, sniffLength)
+	if err != nil {
+		h.logger.Printf("upload read failed: %v", err)
+		httpapi.RespondError(c, http.StatusBadGateway, httpapi.ErrorCodeExternal, "failed to verify upload", nil)
+		return
+	}
+	detected := normalizeMIME(http.DetectContentType(head))
+	if detected == "application/octet-stream" && !sniffableMIMEs[contentType] {
+		detected = contentType
+	}
+	if detected != contentType || isLikelySVG(head) {
+		reject(http.StatusUnsupportedMediaType, "content type mismatch", nil)
+		return
+	}
+
+	if h.scanners != nil {
+		// The object never passed through the API, so it is streamed back through the
+		// scanners rather than held in memory.
+		body, err := direct.Open(ctx, req.Key)
+		if err != nil {
+			h.logger.Printf("upload read failed: %v", err)
+			httpapi.RespondError(c, http.StatusBadGateway, httpapi.ErrorCodeExternal, "failed to verify upload", nil)
+			return
+		}
+		report, err := h.scanners.Scan(ctx, body, contentType)
+		body.Close()
+		if err != nil {
+			h.logger.Printf("upload read failed: %v", err)
+			httpapi.RespondError(c, http.StatusBadGateway, httpapi.ErrorCodeExternal, "failed to verify upload", nil)
+			return
+		}
+		scanReport = &report
+		switch report.Status {
+		case scanner.StatusInfected:
+			reject(http.StatusUnprocessableEntity, "file rejected by scanner", map[string]string{"threat": report.Threat})
+			return
+		case scanner.StatusFailed:
+			reject(http.StatusUnprocessableEntity, "file could not be scanned", nil)
+			return
+		}
+	}
+
+	upload := models.Upload{
+		Key:         req.Key,
+		URL:         direct.PublicURL(req.Key),
+		ContentType: contentType,
+		SizeBytes:   info.Size,
+		UploadedBy:  uploaderFromClaims(claims),
+	}
+	response := gin.H{
+		"url":         upload.URL,
+		"key":         upload.Key,
+		"contentType": upload.ContentType,
+		"size":        upload.SizeBytes,
+	}
+	if h.library != nil {
+		created, err := h.library.Create(ctx, upload, nil)
+		if err != nil {
+			if errors.Is(err, repos.ErrConflict) {
+				httpapi.RespondError(c, http.StatusConflict, httpapi.ErrorCodeConflict, "upload already completed", nil)
+				return
+			}
+			h.logger.Printf("upload record failed: %v", err)
+			httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "failed to register upload", nil)
+			return
+		}
+		response["id"] = created.ID
+	}
+
+	fields := map[string]interface{}{
+		"route":      c.FullPath(),
+		"method":     c.Request.Method,
+		"mime":       contentType,
+		"size":       info.Size,
+		"key":        req.Key,
+		"url":        upload.URL,
+		"user_id":    userIDFromClaims(claims),
+		"latency_ms": time.Since(started).Milliseconds(),
+	}
+	after := map[string]any{"url": upload.URL, "contentType": contentType, "size": info.Size, "direct": true}
+	if scanReport != nil {
+		fields["scan"] = scanReport
+		after["scan"] = scanReport.Status
+		response["scan"] = scanReport
+	}
+	h.logUpload(fields)
+	h.auditUpload(ctx, req.Key, after)
+	httpapi.RespondData(c, http.StatusCreated, response)
+}
+
+// directToken signs a presign declaration for the tenant in ctx. The token is the expiry
+// in Unix seconds and an HMAC over the tenant, key, content type, size and expiry.
+func (h *UploadsHandler) directToken(ctx context.Context, key, contentType string, size int64, expiry time.Time) string {
+	expires := strconv.FormatInt(expiry.Unix(), 10)
+	return expires + "." + h.signDirect(tenant.ID(ctx).String(), key, contentType, strconv.FormatInt(size, 10), expires)
+}
+
+// verifyDirectToken checks a token returned by directToken against a completion request.
+func (h *UploadsHandler) verifyDirectToken(ctx context.Context, token, key, contentType string, size int64) error {
+	expires, signature, ok := strings.Cut(token, ".")
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if !ok || err != nil {
+		return errInvalidDirectToken
+	}
+	expected := h.signDirect(tenant.ID(ctx).String(), key, contentType, strconv.FormatInt(size, 10), expires)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return errInvalidDirectToken
+	}
+	if !time.Now().Before(time.Unix(unix, 0)) {
+		return errDirectTokenExpired
+	}
+	return nil
+}
+
+func (h *UploadsHandler) signDirect(fields ...string) string {
+	mac := hmac.New(sha256.New, h.directKey)
+	mac.Write([]byte(strings.Join(fields, "\n")))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (h *UploadsHandler) isDirectAllowed(mime string) bool {
+	if mime == "image/svg+xml" {
+		return false
+	}
+	for _, allowed := range h.policy.Direct.AllowedMIME {
+		if normalizeMIME(allowed) == mime {
+			return true
+		}
+	}
+	return false
+}
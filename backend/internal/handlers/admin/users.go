@@ -0,0 +1,277 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tanydotai/tanyai/backend/internal/auth"
+	"github.com/tanydotai/tanyai/backend/internal/dto"
+	"github.com/tanydotai/tanyai/backend/internal/httpapi"
+	"github.com/tanydotai/tanyai/backend/internal/middleware"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/repos"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
+)
+
+const (
+	inviteTokenTTL = 72 * time.Hour
+	resetTokenTTL  = time.Hour
+)
+
+// UsersHandler manages admin user accounts of the current tenant.
+type UsersHandler struct {
+	repo repos.UserRepository
+	now  func() time.Time
+}
+
+// NewUsersHandler creates a new UsersHandler.
+func NewUsersHandler(repo repos.UserRepository) *UsersHandler {
+	ensureValidators()
+	return &UsersHandler{repo: repo, now: time.Now}
+}
+
+// List returns paginated users together with their roles.
+func (h *UsersHandler) List(c *gin.Context) {
+	params := parseListParams(c)
+	users, total, err := h.repo.List(c.Request.Context(), params)
+	if handleListError(c, err) {
+		return
+	}
+
+	responses := make([]dto.AdminUserResponse, len(users))
+	for i, user := range users {
+		responses[i] = dto.NewAdminUserResponse(user.User, user.Roles)
+	}
+
+	httpapi.RespondList(c, http.StatusOK, responses, params.Page, params.Limit, total)
+}
+
+// Invite creates a user without a password and returns a one-time token to set it.
+func (h *UsersHandler) Invite(c *gin.Context) {
+	var req dto.InviteUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+	roles, ok := normalizeRoles(c, req.Roles)
+	if !ok {
+		return
+	}
+
+	user := models.User{Email: req.Email, Name: req.Name, IsActive: true}
+	created, err := h.repo.Create(c.Request.Context(), user, roles)
+	if err != nil {
+		if errors.Is(err, repos.ErrConflict) {
+			httpapi.RespondError(c, http.StatusConflict, httpapi.ErrorCodeConflict, "email is already registered", map[string]string{"email": "already registered"})
+			return
+		}
+		handleRepoError(c, err)
+		return
+	}
+
+	h.issuePasswordToken(c, http.StatusCreated, created, roles, models.PasswordTokenInvite, inviteTokenTTL)
+}
+
+// UpdateRoles replaces the roles of a user.
+func (h *UsersHandler) UpdateRoles(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	var req dto.UpdateUserRolesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+	roles, ok := normalizeRoles(c, req.Roles)
+	if !ok {
+		return
+	}
+	if isSelf(c, id) && !auth.HasPermission(roles, auth.PermissionUsersManage) {
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "cannot remove your own user management access", nil)
+		return
+	}
+
+	if err := h.repo.SetRoles(c.Request.Context(), id, roles); err != nil {
+		handleRepoError(c, err)
+		return
+	}
+
+	user, ok := h.loadUser(c, id)
+	if !ok {
+		return
+	}
+	httpapi.RespondData(c, http.StatusOK, dto.NewAdminUserResponse(user.user, user.roles))
+}
+
+// Deactivate disables a user and revokes all of their sessions.
+func (h *UsersHandler) Deactivate(c *gin.Context) {
+	h.setActive(c, false)
+}
+
+// Activate re-enables a previously deactivated user.
+func (h *UsersHandler) Activate(c *gin.Context) {
+	h.setActive(c, true)
+}
+
+// Logout revokes every refresh token of a user, forcing a new login once access tokens expire.
+func (h *UsersHandler) Logout(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondValidationError(c, err)
+		return
+	}
+	if _, ok := h.loadUser(c, id); !ok {
+		return
+	}
+
+	revoked, err := h.repo.RevokeAllRefreshTokens(c.Request.Context(), id)
+	if err != nil {
+		handleRepoError(c, err)
+		return
+	}
+	httpapi.RespondData(c, http.StatusOK, gin.H{"revokedSessions": revoked})
+}
+
+// ResetPassword clears existing sessions and issues a one-time password reset token.
+func (h *UsersHandler) ResetPassword(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondValidationError(c, err)
+		return
+	}
+	user, ok := h.loadUser(c, id)
+	if !ok {
+		return
+	}
+
+	if _, err := h.repo.RevokeAllRefreshTokens(c.Request.Context(), id); err != nil {
+		handleRepoError(c, err)
+		return
+	}
+
+	h.issuePasswordToken(c, http.StatusOK, user.user, user.roles, models.PasswordTokenReset, resetTokenTTL)
+}
+
+// ResetMFA removes a user's TOTP enrollment and recovery codes, e.g. after a lost device,
+// and revokes their sessions.
+func (h *UsersHandler) ResetMFA(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondValidationError(c, err)
+		return
+	}
+	if _, ok := h.loadUser(c, id); !ok {
+		return
+	}
+
+	if err := h.repo.DisableTOTP(c.Request.Context(), id); err != nil {
+		handleRepoError(c, err)
+		return
+	}
+	if _, err := h.repo.RevokeAllRefreshTokens(c.Request.Context(), id); err != nil {
+		handleRepoError(c, err)
+		return
+	}
+
+	user, ok := h.loadUser(c, id)
+	if !ok {
+		return
+	}
+	httpapi.RespondData(c, http.StatusOK, dto.NewAdminUserResponse(user.user, user.roles))
+}
+
+func (h *UsersHandler) setActive(c *gin.Context, active bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondValidationError(c, err)
+		return
+	}
+	if !active && isSelf(c, id) {
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "cannot deactivate your own account", nil)
+		return
+	}
+
+	if err := h.repo.SetActive(c.Request.Context(), id, active); err != nil {
+		handleRepoError(c, err)
+		return
+	}
+
+	user, ok := h.loadUser(c, id)
+	if !ok {
+		return
+	}
+	httpapi.RespondData(c, http.StatusOK, dto.NewAdminUserResponse(user.user, user.roles))
+}
+
+func (h *UsersHandler) issuePasswordToken(c *gin.Context, status int, user models.User, roles []string, purpose string, ttl time.Duration) {
+	raw, hash, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		handleRepoError(c, err)
+		return
+	}
+
+	token := models.PasswordToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: hash,
+		Purpose:   purpose,
+		ExpiresAt: h.now().Add(ttl).UTC(),
+	}
+	if err := h.repo.CreatePasswordToken(c.Request.Context(), token); err != nil {
+		handleRepoError(c, err)
+		return
+	}
+
+	httpapi.RespondData(c, status, dto.PasswordTokenResponse{
+		User:      dto.NewAdminUserResponse(user, roles),
+		Token:     raw,
+		Purpose:   purpose,
+		ExpiresAt: token.ExpiresAt,
+	})
+}
+
+type userWithRoles struct {
+	user  models.User
+	roles []string
+}
+
+// loadUser fetches a user of the current tenant, responding 404 for users of other tenants.
+func (h *UsersHandler) loadUser(c *gin.Context, id uuid.UUID) (userWithRoles, bool) {
+	user, roles, err := h.repo.GetByID(c.Request.Context(), id)
+	if err == nil && user.TenantID != tenant.ID(c.Request.Context()) {
+		err = repos.ErrNotFound
+	}
+	if handleRepoError(c, err) {
+		return userWithRoles{}, false
+	}
+	return userWithRoles{user: user, roles: roles}, true
+}
+
+func normalizeRoles(c *gin.Context, roles []string) ([]string, bool) {
+	seen := make(map[string]bool, len(roles))
+	normalized := make([]string, 0, len(roles))
+	for _, role := range roles {
+		role = strings.ToLower(strings.TrimSpace(role))
+		if !auth.IsKnownRole(role) {
+			httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "invalid request payload", map[string]string{"roles": "unknown role " + role})
+			return nil, false
+		}
+		if !seen[role] {
+			seen[role] = true
+			normalized = append(normalized, role)
+		}
+	}
+	return normalized, true
+}
+
+func isSelf(c *gin.Context, id uuid.UUID) bool {
+	claims, ok := middleware.GetClaims(c)
+	return ok && claims.UserID == id
+}
@@ -0,0 +1,220 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/tanydotai/tanyai/backend/internal/auth"
+	"github.com/tanydotai/tanyai/backend/internal/dto"
+	"github.com/tanydotai/tanyai/backend/internal/middleware"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/repos"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
+)
+
+type stubUserRepo struct {
+	repos.UserRepository
+
+	users          map[uuid.UUID]models.User
+	roles          map[uuid.UUID][]string
+	passwordTokens []models.PasswordToken
+	revoked        map[uuid.UUID]int
+}
+
+func newStubUserRepo() *stubUserRepo {
+	return &stubUserRepo{
+		users:   make(map[uuid.UUID]models.User),
+		roles:   make(map[uuid.UUID][]string),
+		revoked: make(map[uuid.UUID]int),
+	}
+}
+
+func (s *stubUserRepo) GetByID(ctx context.Context, id uuid.UUID) (models.User, []string, error) {
+	user, ok := s.users[id]
+	if !ok {
+		return models.User{}, nil, repos.ErrNotFound
+	}
+	return user, s.roles[id], nil
+}
+
+func (s *stubUserRepo) Create(ctx context.Context, user models.User, roles []string) (models.User, error) {
+	for _, existing := range s.users {
+		if existing.Email == user.Email {
+			return models.User{}, repos.ErrConflict
+		}
+	}
+	user.ID = uuid.New()
+	user.TenantID = tenant.ID(ctx)
+	s.users[user.ID] = user
+	s.roles[user.ID] = roles
+	return user, nil
+}
+
+func (s *stubUserRepo) SetRoles(ctx context.Context, id uuid.UUID, roles []string) error {
+	if _, ok := s.users[id]; !ok {
+		return repos.ErrNotFound
+	}
+	s.roles[id] = roles
+	return nil
+}
+
+func (s *stubUserRepo) SetActive(ctx context.Context, id uuid.UUID, active bool) error {
+	user, ok := s.users[id]
+	if !ok || user.TenantID != tenant.ID(ctx) {
+		return repos.ErrNotFound
+	}
+	user.IsActive = active
+	s.users[id] = user
+	if !active {
+		s.revoked[id]++
+	}
+	return nil
+}
+
+func (s *stubUserRepo) DisableTOTP(ctx context.Context, id uuid.UUID) error {
+	user, ok := s.users[id]
+	if !ok {
+		return repos.ErrNotFound
+	}
+	user.TOTPEnabled = false
+	user.TOTPSecret = nil
+	s.users[id] = user
+	return nil
+}
+
+func (s *stubUserRepo) CreatePasswordToken(ctx context.Context, token models.PasswordToken) error {
+	s.passwordTokens = append(s.passwordTokens, token)
+	return nil
+}
+
+func (s *stubUserRepo) RevokeAllRefreshTokens(ctx context.Context, userID uuid.UUID) (int64, error) {
+	s.revoked[userID]++
+	return 2, nil
+}
+
+func newUsersRouter(repo *stubUserRepo, actor uuid.UUID) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	handler := NewUsersHandler(repo)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		middleware.SetClaims(c, &auth.Claims{UserID: actor, Roles: []string{auth.RoleAdmin}})
+	})
+	router.POST("/users/invite", handler.Invite)
+	router.PUT("/users/:id/roles", handler.UpdateRoles)
+	router.POST("/users/:id/deactivate", handler.Deactivate)
+	router.POST("/users/:id/logout", handler.Logout)
+	router.POST("/users/:id/reset-password", handler.ResetPassword)
+	router.POST("/users/:id/mfa/reset", handler.ResetMFA)
+	return router
+}
+
+func sendJSON(router *gin.Engine, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestUsersHandlerInviteIssuesOneTimeToken(t *testing.T) {
+	repo := newStubUserRepo()
+	router := newUsersRouter(repo, uuid.New())
+
+	rec := sendJSON(router, http.MethodPost, "/users/invite", `{"email":"editor@example.com","roles":["Editor"]}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var resp struct {
+		Data dto.PasswordTokenResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.NotEmpty(t, resp.Data.Token)
+	require.Equal(t, models.PasswordTokenInvite, resp.Data.Purpose)
+	require.Equal(t, []string{"editor"}, resp.Data.User.Roles)
+	require.False(t, resp.Data.User.PasswordSet)
+
+	require.Len(t, repo.passwordTokens, 1)
+	require.Equal(t, auth.HashRefreshToken(resp.Data.Token), repo.passwordTokens[0].TokenHash)
+	require.WithinDuration(t, time.Now().Add(inviteTokenTTL), repo.passwordTokens[0].ExpiresAt, time.Minute)
+
+	rec = sendJSON(router, http.MethodPost, "/users/invite", `{"email":"editor@example.com","roles":["editor"]}`)
+	require.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestUsersHandlerRejectsUnknownRoles(t *testing.T) {
+	repo := newStubUserRepo()
+	router := newUsersRouter(repo, uuid.New())
+
+	rec := sendJSON(router, http.MethodPost, "/users/invite", `{"email":"x@example.com","roles":["owner"]}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Empty(t, repo.users)
+}
+
+func TestUsersHandlerProtectsOwnAccount(t *testing.T) {
+	repo := newStubUserRepo()
+	actor := uuid.New()
+	repo.users[actor] = models.User{ID: actor, Email: "admin@example.com", TenantID: tenant.DefaultID, IsActive: true}
+	repo.roles[actor] = []string{auth.RoleAdmin}
+	router := newUsersRouter(repo, actor)
+
+	rec := sendJSON(router, http.MethodPost, "/users/"+actor.String()+"/deactivate", "")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.True(t, repo.users[actor].IsActive)
+
+	rec = sendJSON(router, http.MethodPut, "/users/"+actor.String()+"/roles", `{"roles":["editor"]}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Equal(t, []string{auth.RoleAdmin}, repo.roles[actor])
+}
+
+func TestUsersHandlerDeactivateAndLogoutRevokeSessions(t *testing.T) {
+	repo := newStubUserRepo()
+	target := uuid.New()
+	repo.users[target] = models.User{ID: target, Email: "analyst@example.com", TenantID: tenant.DefaultID, IsActive: true}
+	router := newUsersRouter(repo, uuid.New())
+
+	rec := sendJSON(router, http.MethodPost, "/users/"+target.String()+"/logout", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, 1, repo.revoked[target])
+
+	rec = sendJSON(router, http.MethodPost, "/users/"+target.String()+"/deactivate", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.False(t, repo.users[target].IsActive)
+	require.Equal(t, 2, repo.revoked[target])
+
+	rec = sendJSON(router, http.MethodPost, "/users/"+target.String()+"/reset-password", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, 3, repo.revoked[target])
+	require.Equal(t, models.PasswordTokenReset, repo.passwordTokens[0].Purpose)
+}
+
+func TestUsersHandlerResetMFA(t *testing.T) {
+	repo := newStubUserRepo()
+	target := uuid.New()
+	secret := "SECRET"
+	repo.users[target] = models.User{ID: target, Email: "editor@example.com", TenantID: tenant.DefaultID, IsActive: true, TOTPEnabled: true, TOTPSecret: &secret}
+	router := newUsersRouter(repo, uuid.New())
+
+	rec := sendJSON(router, http.MethodPost, "/users/"+target.String()+"/mfa/reset", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.False(t, repo.users[target].TOTPEnabled)
+	require.Nil(t, repo.users[target].TOTPSecret)
+	require.Equal(t, 1, repo.revoked[target])
+}
+
+func TestUsersHandlerHidesOtherTenants(t *testing.T) {
+	repo := newStubUserRepo()
+	target := uuid.New()
+	repo.users[target] = models.User{ID: target, Email: "other@example.com", TenantID: uuid.New(), IsActive: true}
+	router := newUsersRouter(repo, uuid.New())
+
+	rec := sendJSON(router, http.MethodPost, "/users/"+target.String()+"/logout", "")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	require.Zero(t, repo.revoked[target])
+}
@@ -9,11 +9,13 @@ import (
 	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 	appauth "github.com/tanydotai/tanyai/backend/internal/auth"
+	"github.com/tanydotai/tanyai/backend/internal/middleware"
 	"github.com/tanydotai/tanyai/backend/internal/models"
 	"github.com/tanydotai/tanyai/backend/internal/repos"
 )
@@ -27,7 +29,7 @@ func TestLoginSuccess(t *testing.T) {
 	passwordHash, err := appauth.HashPassword("Admin#12345")
 	require.NoError(t, err)
 	userID := uuid.New()
-	repo.addUser(models.User{ID: userID, Email: "admin@example.com", PasswordHash: passwordHash}, []string{"admin"})
+	repo.addUser(models.User{ID: userID, Email: "admin@example.com", PasswordHash: passwordHash, IsActive: true}, []string{"admin"})
 
 	tokenService, err := appauth.NewTokenService(strings.Repeat("a", 64), 15*time.Minute, 24*time.Hour)
 	require.NoError(t, err)
@@ -69,11 +71,40 @@ func TestLoginSuccess(t *testing.T) {
 	require.Equal(t, "/", refreshCookie.Path)
 }
 
+func TestLoginTruncatesUserAgentOnRuneBoundary(t *testing.T) {
+	repo := newUserRepoStub()
+	passwordHash, err := appauth.HashPassword("Admin#12345")
+	require.NoError(t, err)
+	repo.addUser(models.User{ID: uuid.New(), Email: "admin@example.com", PasswordHash: passwordHash, IsActive: true}, []string{"admin"})
+
+	tokenService, err := appauth.NewTokenService(strings.Repeat("a", 64), 15*time.Minute, 24*time.Hour)
+	require.NoError(t, err)
+	handler := NewHandler(repo, tokenService, appauth.NewRateLimiter(10, 10, time.Minute), "__Host_refresh")
+
+	router := gin.New()
+	router.POST("/login", handler.Login)
+
+	// "é" is two bytes, so a 513 byte user agent puts the byte limit inside a rune.
+	userAgent := "x" + strings.Repeat("é", 256)
+	req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewBufferString(`{"email":"admin@example.com","password":"Admin#12345"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	stored := repo.lastCreatedRefresh.UserAgent
+	require.True(t, utf8.ValidString(stored))
+	require.LessOrEqual(t, len(stored), maxUserAgentLength)
+	require.Equal(t, userAgent[:maxUserAgentLength-1], stored)
+}
+
 func TestLoginInvalidCredentials(t *testing.T) {
 	repo := newUserRepoStub()
 	passwordHash, err := appauth.HashPassword("Admin#12345")
 	require.NoError(t, err)
-	repo.addUser(models.User{ID: uuid.New(), Email: "admin@example.com", PasswordHash: passwordHash}, []string{"admin"})
+	repo.addUser(models.User{ID: uuid.New(), Email: "admin@example.com", PasswordHash: passwordHash, IsActive: true}, []string{"admin"})
 
 	tokenService, err := appauth.NewTokenService(strings.Repeat("b", 64), 15*time.Minute, 24*time.Hour)
 	require.NoError(t, err)
@@ -98,7 +129,7 @@ func TestLoginRateLimited(t *testing.T) {
 	repo := newUserRepoStub()
 	passwordHash, err := appauth.HashPassword("Admin#12345")
 	require.NoError(t, err)
-	repo.addUser(models.User{ID: uuid.New(), Email: "admin@example.com", PasswordHash: passwordHash}, []string{"admin"})
+	repo.addUser(models.User{ID: uuid.New(), Email: "admin@example.com", PasswordHash: passwordHash, IsActive: true}, []string{"admin"})
 
 	tokenService, err := appauth.NewTokenService(strings.Repeat("c", 64), 15*time.Minute, 24*time.Hour)
 	require.NoError(t, err)
@@ -127,7 +158,7 @@ func TestRefreshSuccess(t *testing.T) {
 	passwordHash, err := appauth.HashPassword("Admin#12345")
 	require.NoError(t, err)
 	userID := uuid.New()
-	repo.addUser(models.User{ID: userID, Email: "admin@example.com", PasswordHash: passwordHash}, []string{"admin"})
+	repo.addUser(models.User{ID: userID, Email: "admin@example.com", PasswordHash: passwordHash, IsActive: true}, []string{"admin"})
 
 	tokenService, err := appauth.NewTokenService(strings.Repeat("d", 64), 15*time.Minute, 24*time.Hour)
 	require.NoError(t, err)
@@ -177,7 +208,7 @@ func TestRefreshInvalidToken(t *testing.T) {
 func TestLogoutClearsCookie(t *testing.T) {
 	repo := newUserRepoStub()
 	userID := uuid.New()
-	repo.addUser(models.User{ID: userID, Email: "admin@example.com"}, []string{"admin"})
+	repo.addUser(models.User{ID: userID, Email: "admin@example.com", IsActive: true}, []string{"admin"})
 	raw := "refresh"
 	hash := appauth.HashRefreshToken(raw)
 	repo.addRefreshToken(models.RefreshToken{ID: uuid.New(), UserID: userID, TokenHash: hash, ExpiresAt: time.Now().Add(time.Hour)})
@@ -207,12 +238,102 @@ func TestLogoutClearsCookie(t *testing.T) {
 	require.True(t, found)
 }
 
+func TestLoginRejectsDeactivatedUser(t *testing.T) {
+	repo := newUserRepoStub()
+	passwordHash, err := appauth.HashPassword("Admin#12345")
+	require.NoError(t, err)
+	repo.addUser(models.User{ID: uuid.New(), Email: "admin@example.com", PasswordHash: passwordHash}, []string{"admin"})
+
+	tokenService, err := appauth.NewTokenService(strings.Repeat("f", 64), 15*time.Minute, 24*time.Hour)
+	require.NoError(t, err)
+	handler := NewHandler(repo, tokenService, appauth.NewRateLimiter(10, 10, time.Minute), "__Host_refresh")
+
+	router := gin.New()
+	router.POST("/login", handler.Login)
+
+	body := bytes.NewBufferString(`{"email":"admin@example.com","password":"Admin#12345"}`)
+	req := httptest.NewRequest(http.MethodPost, "/login", body)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.Empty(t, repo.lastCreatedRefresh.TokenHash)
+}
+
+func TestSetPasswordConsumesTokenAndRevokesSessions(t *testing.T) {
+	repo := newUserRepoStub()
+	userID := uuid.New()
+	repo.addUser(models.User{ID: userID, Email: "editor@example.com", IsActive: true}, []string{"editor"})
+	sessionHash := appauth.HashRefreshToken("session")
+	repo.addRefreshToken(models.RefreshToken{ID: uuid.New(), UserID: userID, TokenHash: sessionHash, ExpiresAt: time.Now().Add(time.Hour)})
+
+	raw, hash, err := appauth.GenerateOpaqueToken()
+	require.NoError(t, err)
+	require.NoError(t, repo.CreatePasswordToken(context.Background(), models.PasswordToken{ID: uuid.New(), UserID: userID, TokenHash: hash, Purpose: models.PasswordTokenInvite, ExpiresAt: time.Now().Add(time.Hour)}))
+
+	handler := NewHandler(repo, nil, nil, "__Host_refresh")
+	router := gin.New()
+	router.POST("/password/set", handler.SetPassword)
+
+	doSet := func() *httptest.ResponseRecorder {
+		body := bytes.NewBufferString(`{"token":"` + raw + `","password":"Editor#12345"}`)
+		req := httptest.NewRequest(http.MethodPost, "/password/set", body)
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	require.Equal(t, http.StatusNoContent, doSet().Code)
+	require.NoError(t, appauth.ComparePassword(repo.usersByID[userID].user.PasswordHash, "Editor#12345"))
+	require.True(t, repo.refreshTokens[sessionHash].Revoked)
+
+	require.Equal(t, http.StatusBadRequest, doSet().Code)
+}
+
+func TestChangePasswordRequiresCurrentPassword(t *testing.T) {
+	repo := newUserRepoStub()
+	passwordHash, err := appauth.HashPassword("Admin#12345")
+	require.NoError(t, err)
+	userID := uuid.New()
+	repo.addUser(models.User{ID: userID, Email: "admin@example.com", PasswordHash: passwordHash, IsActive: true}, []string{"admin"})
+	sessionHash := appauth.HashRefreshToken("session")
+	repo.addRefreshToken(models.RefreshToken{ID: uuid.New(), UserID: userID, TokenHash: sessionHash, ExpiresAt: time.Now().Add(time.Hour)})
+
+	handler := NewHandler(repo, nil, nil, "__Host_refresh")
+	router := gin.New()
+	router.POST("/password/change", func(c *gin.Context) {
+		middleware.SetClaims(c, &appauth.Claims{UserID: userID})
+	}, handler.ChangePassword)
+
+	doChange := func(current string) *httptest.ResponseRecorder {
+		body := bytes.NewBufferString(`{"currentPassword":"` + current + `","newPassword":"Changed#12345"}`)
+		req := httptest.NewRequest(http.MethodPost, "/password/change", body)
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	require.Equal(t, http.StatusBadRequest, doChange("wrong").Code)
+	require.False(t, repo.refreshTokens[sessionHash].Revoked)
+
+	require.Equal(t, http.StatusNoContent, doChange("Admin#12345").Code)
+	require.True(t, repo.refreshTokens[sessionHash].Revoked)
+	require.NoError(t, appauth.ComparePassword(repo.usersByID[userID].user.PasswordHash, "Changed#12345"))
+}
+
 type userRepoStub struct {
 	usersByEmail       map[string]userRecord
 	usersByID          map[uuid.UUID]userRecord
 	refreshTokens      map[string]models.RefreshToken
 	refreshByID        map[uuid.UUID]string
 	lastCreatedRefresh models.RefreshToken
+	passwordTokens     map[string]models.PasswordToken
+	totpCounters       map[uuid.UUID]int64
+	recoveryCodes      map[uuid.UUID]map[string]bool
 }
 
 type userRecord struct {
@@ -222,10 +343,13 @@ type userRecord struct {
 
 func newUserRepoStub() *userRepoStub {
 	return &userRepoStub{
-		usersByEmail:  make(map[string]userRecord),
-		usersByID:     make(map[uuid.UUID]userRecord),
-		refreshTokens: make(map[string]models.RefreshToken),
-		refreshByID:   make(map[uuid.UUID]string),
+		usersByEmail:   make(map[string]userRecord),
+		usersByID:      make(map[uuid.UUID]userRecord),
+		refreshTokens:  make(map[string]models.RefreshToken),
+		refreshByID:    make(map[uuid.UUID]string),
+		passwordTokens: make(map[string]models.PasswordToken),
+		totpCounters:   make(map[uuid.UUID]int64),
+		recoveryCodes:  make(map[uuid.UUID]map[string]bool),
 	}
 }
 
@@ -297,3 +421,155 @@ func (s *userRepoStub) RevokeRefreshTokenByHash(ctx context.Context, hash string
 func (s *userRepoStub) DeleteExpiredRefreshTokens(ctx context.Context, before time.Time) (int64, error) {
 	return 0, nil
 }
+
+func (s *userRepoStub) List(ctx context.Context, params repos.ListParams) ([]repos.UserWithRoles, int64, error) {
+	return nil, 0, nil
+}
+
+func (s *userRepoStub) Create(ctx context.Context, user models.User, roles []string) (models.User, error) {
+	user.ID = uuid.New()
+	s.addUser(user, roles)
+	return user, nil
+}
+
+func (s *userRepoStub) SetRoles(ctx context.Context, id uuid.UUID, roles []string) error {
+	rec, ok := s.usersByID[id]
+	if !ok {
+		return repos.ErrNotFound
+	}
+	s.addUser(rec.user, roles)
+	return nil
+}
+
+func (s *userRepoStub) SetActive(ctx context.Context, id uuid.UUID, active bool) error {
+	rec, ok := s.usersByID[id]
+	if !ok {
+		return repos.ErrNotFound
+	}
+	rec.user.IsActive = active
+	s.addUser(rec.user, rec.roles)
+	return nil
+}
+
+func (s *userRepoStub) SetPassword(ctx context.Context, id uuid.UUID, hash string) error {
+	rec, ok := s.usersByID[id]
+	if !ok {
+		return repos.ErrNotFound
+	}
+	rec.user.PasswordHash = hash
+	s.addUser(rec.user, rec.roles)
+	_, err := s.RevokeAllRefreshTokens(ctx, id)
+	return err
+}
+
+func (s *userRepoStub) CreatePasswordToken(ctx context.Context, token models.PasswordToken) error {
+	s.passwordTokens[token.TokenHash] = token
+	return nil
+}
+
+func (s *userRepoStub) ConsumePasswordToken(ctx context.Context, hash string, now time.Time) (models.PasswordToken, error) {
+	token, ok := s.passwordTokens[hash]
+	if !ok || token.UsedAt != nil || !token.ExpiresAt.After(now) {
+		return models.PasswordToken{}, repos.ErrNotFound
+	}
+	token.UsedAt = &now
+	s.passwordTokens[hash] = token
+	return token, nil
+}
+
+func (s *userRepoStub) RevokeAllRefreshTokens(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var revoked int64
+	for hash, token := range s.refreshTokens {
+		if token.UserID == userID && !token.Revoked {
+			token.Revoked = true
+			s.refreshTokens[hash] = token
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+func (s *userRepoStub) SetPendingTOTP(ctx context.Context, id uuid.UUID, secret string) error {
+	rec, ok := s.usersByID[id]
+	if !ok {
+		return repos.ErrNotFound
+	}
+	if rec.user.TOTPEnabled {
+		return repos.ErrConflict
+	}
+	rec.user.TOTPSecret = &secret
+	s.addUser(rec.user, rec.roles)
+	return nil
+}
+
+func (s *userRepoStub) EnableTOTP(ctx context.Context, id uuid.UUID, counter int64, recoveryHashes []string) error {
+	rec, ok := s.usersByID[id]
+	if !ok || rec.user.TOTPSecret == nil || rec.user.TOTPEnabled {
+		return repos.ErrConflict
+	}
+	rec.user.TOTPEnabled = true
+	s.addUser(rec.user, rec.roles)
+	s.totpCounters[id] = counter
+	return s.ReplaceRecoveryCodes(ctx, id, recoveryHashes)
+}
+
+func (s *userRepoStub) DisableTOTP(ctx context.Context, id uuid.UUID) error {
+	rec, ok := s.usersByID[id]
+	if !ok {
+		return repos.ErrNotFound
+	}
+	rec.user.TOTPEnabled = false
+	rec.user.TOTPSecret = nil
+	s.addUser(rec.user, rec.roles)
+	delete(s.totpCounters, id)
+	delete(s.recoveryCodes, id)
+	return nil
+}
+
+func (s *userRepoStub) AcceptTOTPCounter(ctx context.Context, id uuid.UUID, counter int64) (bool, error) {
+	if last, ok := s.totpCounters[id]; ok && last >= counter {
+		return false, nil
+	}
+	s.totpCounters[id] = counter
+	return true, nil
+}
+
+func (s *userRepoStub) ReplaceRecoveryCodes(ctx context.Context, id uuid.UUID, recoveryHashes []string) error {
+	codes := make(map[string]bool, len(recoveryHashes))
+	for _, hash := range recoveryHashes {
+		codes[hash] = false
+	}
+	s.recoveryCodes[id] = codes
+	return nil
+}
+
+func (s *userRepoStub) ConsumeRecoveryCode(ctx context.Context, id uuid.UUID, hash string, now time.Time) (bool, error) {
+	used, ok := s.recoveryCodes[id][hash]
+	if !ok || used {
+		return false, nil
+	}
+	s.recoveryCodes[id][hash] = true
+	return true, nil
+}
+
+func (s *userRepoStub) RevokeRefreshFamily(ctx context.Context, userID, familyID uuid.UUID) (int64, error) {
+	var revoked int64
+	for hash, token := range s.refreshTokens {
+		if token.UserID == userID && token.FamilyID == familyID && !token.Revoked {
+			token.Revoked = true
+			s.refreshTokens[hash] = token
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+func (s *userRepoStub) ListActiveRefreshTokens(ctx context.Context, userID uuid.UUID, now time.Time) ([]models.RefreshToken, error) {
+	var tokens []models.RefreshToken
+	for _, token := range s.refreshTokens {
+		if token.UserID == userID && !token.Revoked && token.ExpiresAt.After(now) {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, nil
+}
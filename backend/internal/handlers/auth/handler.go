@@ -1,8 +1,10 @@
 package auth
 
 import (
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/tanydotai/tanyai/backend/internal/auth"
 	"github.com/tanydotai/tanyai/backend/internal/repos"
 )
@@ -11,6 +13,9 @@ import (
 type TokenManager interface {
 	GenerateAccessToken(sub auth.Subject) (string, error)
 	GenerateRefreshToken() (token string, hash string, expiresAt time.Time, err error)
+	GenerateMFAToken(userID uuid.UUID) (string, time.Time, error)
+	ValidateMFAToken(token string) (uuid.UUID, error)
+	JWKS() auth.JWKS
 }
 
 // Handler groups authentication related HTTP handlers.
@@ -19,6 +24,7 @@ type Handler struct {
 	tokens            TokenManager
 	limiter           *auth.RateLimiter
 	refreshCookieName string
+	mfaIssuer         string
 }
 
 // NewHandler constructs an auth Handler.
@@ -28,6 +34,14 @@ func NewHandler(users repos.UserRepository, tokens TokenManager, limiter *auth.R
 		tokens:            tokens,
 		limiter:           limiter,
 		refreshCookieName: refreshCookieName,
+		mfaIssuer:         defaultMFAIssuer,
+	}
+}
+
+// SetMFAIssuer overrides the issuer shown by authenticator apps.
+func (h *Handler) SetMFAIssuer(issuer string) {
+	if issuer = strings.TrimSpace(issuer); issuer != "" {
+		h.mfaIssuer = issuer
 	}
 }
 
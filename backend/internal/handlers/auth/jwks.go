@@ -0,0 +1,14 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKS publishes the public keys used to verify access tokens. The document is empty when
+// tokens are signed with a shared secret.
+func (h *Handler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.tokens.JWKS())
+}
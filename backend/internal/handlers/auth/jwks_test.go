@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	appauth "github.com/tanydotai/tanyai/backend/internal/auth"
+)
+
+func TestJWKSPublishesVerificationKeys(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	keys, err := appauth.NewKeySet("k1", appauth.SigningKey{ID: "k1", Private: priv})
+	require.NoError(t, err)
+	tokenService, err := appauth.NewTokenServiceWithKeys(keys, "", 15*time.Minute, 24*time.Hour)
+	require.NoError(t, err)
+
+	handler := NewHandler(newUserRepoStub(), tokenService, nil, "__Host_refresh")
+	router := gin.New()
+	router.GET("/.well-known/jwks.json", handler.JWKS)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "public, max-age=300", rec.Header().Get("Cache-Control"))
+
+	var doc appauth.JWKS
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	require.Len(t, doc.Keys, 1)
+	require.Equal(t, "k1", doc.Keys[0].KeyID)
+	require.Equal(t, "EdDSA", doc.Keys[0].Algorithm)
+	require.Empty(t, doc.Keys[0].N)
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -23,6 +25,8 @@ var dummyPasswordHash = func() string {
 	return hash
 }()
 
+const maxUserAgentLength = 512
+
 type loginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
@@ -74,27 +78,51 @@ func (h *Handler) Login(c *gin.Context) {
 		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "login failed", nil)
 		return
 	}
+	if !user.IsActive {
+		respondInvalidCredentials(c)
+		return
+	}
+
+	if user.TOTPEnabled {
+		h.respondMFAChallenge(c, user)
+		return
+	}
+
+	h.startSession(c, user, roles, nil, "login failed")
+}
 
-	accessToken, err := h.tokens.GenerateAccessToken(appauth.Subject{ID: user.ID, Email: user.Email, Roles: roles})
+// startSession issues an access token and a persisted refresh token cookie for user. When
+// previous is set the new refresh token continues that token's family; otherwise a new
+// session family is started.
+func (h *Handler) startSession(c *gin.Context, user models.User, roles []string, previous *models.RefreshToken, failure string) {
+	accessToken, err := h.tokens.GenerateAccessToken(appauth.Subject{ID: user.ID, Email: user.Email, Roles: roles, TenantID: user.TenantID})
 	if err != nil {
-		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "login failed", nil)
+		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, failure, nil)
 		return
 	}
 
 	refreshToken, refreshHash, expiresAt, err := h.tokens.GenerateRefreshToken()
 	if err != nil {
-		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "login failed", nil)
+		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, failure, nil)
 		return
 	}
 
 	record := models.RefreshToken{
-		ID:        uuid.New(),
-		UserID:    user.ID,
-		TokenHash: refreshHash,
-		ExpiresAt: expiresAt,
+		ID:               uuid.New(),
+		UserID:           user.ID,
+		TokenHash:        refreshHash,
+		ExpiresAt:        expiresAt.UTC(),
+		SessionStartedAt: time.Now().UTC(),
+		UserAgent:        truncate(c.Request.UserAgent(), maxUserAgentLength),
+		IPAddress:        c.ClientIP(),
+	}
+	record.FamilyID = record.ID
+	if previous != nil {
+		record.FamilyID = previous.FamilyID
+		record.SessionStartedAt = previous.SessionStartedAt
 	}
 	if err := h.users.CreateRefreshToken(c.Request.Context(), record); err != nil {
-		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "login failed", nil)
+		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, failure, nil)
 		return
 	}
 
@@ -107,6 +135,18 @@ func (h *Handler) Login(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
+// truncate cuts value to at most limit bytes without splitting a UTF-8 sequence,
+// which Postgres would reject as an invalid TEXT value.
+func truncate(value string, limit int) string {
+	if len(value) <= limit {
+		return value
+	}
+	for limit > 0 && !utf8.RuneStart(value[limit]) {
+		limit--
+	}
+	return value[:limit]
+}
+
 func respondInvalidCredentials(c *gin.Context) {
 	httpapi.RespondError(c, http.StatusUnauthorized, httpapi.ErrorCodeUnauthorized, "invalid credentials", nil)
 }
@@ -0,0 +1,284 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	appauth "github.com/tanydotai/tanyai/backend/internal/auth"
+	"github.com/tanydotai/tanyai/backend/internal/httpapi"
+	"github.com/tanydotai/tanyai/backend/internal/middleware"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/repos"
+)
+
+const defaultMFAIssuer = "tany.ai"
+
+type mfaChallengeResponse struct {
+	MFARequired bool      `json:"mfaRequired"`
+	MFAToken    string    `json:"mfaToken"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+}
+
+type mfaVerifyRequest struct {
+	MFAToken     string `json:"mfaToken" binding:"required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recoveryCode"`
+}
+
+type mfaCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type mfaDisableRequest struct {
+	Password     string `json:"password" binding:"required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recoveryCode"`
+}
+
+type mfaEnrollResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioningUri"`
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+func (h *Handler) respondMFAChallenge(c *gin.Context, user models.User) {
+	token, expiresAt, err := h.tokens.GenerateMFAToken(user.ID)
+	if err != nil {
+		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "login failed", nil)
+		return
+	}
+	c.JSON(http.StatusOK, mfaChallengeResponse{MFARequired: true, MFAToken: token, ExpiresAt: expiresAt.UTC()})
+}
+
+// VerifyMFA completes a two-step login using the challenge token from Login and either
+// a TOTP code or an unused recovery code.
+func (h *Handler) VerifyMFA(c *gin.Context) {
+	var req mfaVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Code == "") == (req.RecoveryCode == "") {
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "provide mfaToken and either code or recoveryCode", nil)
+		return
+	}
+
+	userID, err := h.tokens.ValidateMFAToken(req.MFAToken)
+	if err != nil {
+		httpapi.RespondError(c, http.StatusUnauthorized, httpapi.ErrorCodeUnauthorized, "mfa challenge is invalid or expired", nil)
+		return
+	}
+
+	if h.limiter != nil && !h.limiter.Allow("mfa:"+userID.String()+":"+c.ClientIP()) {
+		httpapi.RespondError(c, http.StatusTooManyRequests, httpapi.ErrorCodeTooManyRequests, "too many verification attempts", nil)
+		return
+	}
+
+	user, roles, err := h.users.GetByID(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, repos.ErrNotFound) {
+			respondInvalidCredentials(c)
+			return
+		}
+		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "login failed", nil)
+		return
+	}
+	if !user.IsActive || !user.TOTPEnabled {
+		respondInvalidCredentials(c)
+		return
+	}
+
+	ok, err := h.verifySecondFactor(c, user, req.Code, req.RecoveryCode)
+	if err != nil {
+		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "login failed", nil)
+		return
+	}
+	if !ok {
+		httpapi.RespondError(c, http.StatusUnauthorized, httpapi.ErrorCodeUnauthorized, "invalid verification code", nil)
+		return
+	}
+
+	h.startSession(c, user, roles, nil, "login failed")
+}
+
+// EnrollMFA generates a new TOTP secret for the authenticated user. The secret only takes
+// effect once confirmed with ConfirmMFA.
+func (h *Handler) EnrollMFA(c *gin.Context) {
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+	if user.TOTPEnabled {
+		httpapi.RespondError(c, http.StatusConflict, httpapi.ErrorCodeConflict, "two-factor authentication is already enabled", nil)
+		return
+	}
+
+	secret, err := appauth.GenerateTOTPSecret()
+	if err != nil {
+		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "failed to start enrollment", nil)
+		return
+	}
+	if err := h.users.SetPendingTOTP(c.Request.Context(), user.ID, secret); err != nil {
+		if errors.Is(err, repos.ErrConflict) {
+			httpapi.RespondError(c, http.StatusConflict, httpapi.ErrorCodeConflict, "two-factor authentication is already enabled", nil)
+			return
+		}
+		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "failed to start enrollment", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, mfaEnrollResponse{
+		Secret:          secret,
+		ProvisioningURI: appauth.TOTPProvisioningURI(h.mfaIssuer, user.Email, secret),
+	})
+}
+
+// ConfirmMFA verifies the first code from the authenticator app, enables TOTP and returns
+// recovery codes. The codes are shown only once.
+func (h *Handler) ConfirmMFA(c *gin.Context) {
+	var req mfaCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "invalid payload", nil)
+		return
+	}
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+	if user.TOTPEnabled || user.TOTPSecret == nil {
+		httpapi.RespondError(c, http.StatusConflict, httpapi.ErrorCodeConflict, "no pending enrollment", nil)
+		return
+	}
+
+	counter, valid := appauth.VerifyTOTP(*user.TOTPSecret, req.Code, time.Now())
+	if !valid {
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "invalid verification code", nil)
+		return
+	}
+
+	codes, hashes, err := appauth.GenerateRecoveryCodes()
+	if err != nil {
+		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "failed to enable two-factor authentication", nil)
+		return
+	}
+	if err := h.users.EnableTOTP(c.Request.Context(), user.ID, counter, hashes); err != nil {
+		if errors.Is(err, repos.ErrConflict) {
+			httpapi.RespondError(c, http.StatusConflict, httpapi.ErrorCodeConflict, "no pending enrollment", nil)
+			return
+		}
+		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "failed to enable two-factor authentication", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableMFA turns off TOTP after re-checking the password and a second factor.
+func (h *Handler) DisableMFA(c *gin.Context) {
+	var req mfaDisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Code == "") == (req.RecoveryCode == "") {
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "provide password and either code or recoveryCode", nil)
+		return
+	}
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+	if !user.TOTPEnabled {
+		httpapi.RespondError(c, http.StatusConflict, httpapi.ErrorCodeConflict, "two-factor authentication is not enabled", nil)
+		return
+	}
+	if err := appauth.ComparePassword(user.PasswordHash, req.Password); err != nil {
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "current password is incorrect", nil)
+		return
+	}
+
+	valid, err := h.verifySecondFactor(c, user, req.Code, req.RecoveryCode)
+	if err != nil {
+		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "failed to disable two-factor authentication", nil)
+		return
+	}
+	if !valid {
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "invalid verification code", nil)
+		return
+	}
+
+	if err := h.users.DisableTOTP(c.Request.Context(), user.ID); err != nil {
+		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "failed to disable two-factor authentication", nil)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after verifying a current TOTP code.
+func (h *Handler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req mfaCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "invalid payload", nil)
+		return
+	}
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+	if !user.TOTPEnabled {
+		httpapi.RespondError(c, http.StatusConflict, httpapi.ErrorCodeConflict, "two-factor authentication is not enabled", nil)
+		return
+	}
+
+	valid, err := h.verifySecondFactor(c, user, req.Code, "")
+	if err != nil {
+		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "failed to regenerate recovery codes", nil)
+		return
+	}
+	if !valid {
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "invalid verification code", nil)
+		return
+	}
+
+	codes, hashes, err := appauth.GenerateRecoveryCodes()
+	if err != nil {
+		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "failed to regenerate recovery codes", nil)
+		return
+	}
+	if err := h.users.ReplaceRecoveryCodes(c.Request.Context(), user.ID, hashes); err != nil {
+		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "failed to regenerate recovery codes", nil)
+		return
+	}
+	c.JSON(http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// verifySecondFactor accepts a TOTP code at most once, or consumes a recovery code.
+func (h *Handler) verifySecondFactor(c *gin.Context, user models.User, code, recoveryCode string) (bool, error) {
+	if recoveryCode = strings.TrimSpace(recoveryCode); recoveryCode != "" {
+		return h.users.ConsumeRecoveryCode(c.Request.Context(), user.ID, appauth.HashRecoveryCode(recoveryCode), time.Now().UTC())
+	}
+	if user.TOTPSecret == nil {
+		return false, nil
+	}
+	counter, valid := appauth.VerifyTOTP(*user.TOTPSecret, code, time.Now())
+	if !valid {
+		return false, nil
+	}
+	return h.users.AcceptTOTPCounter(c.Request.Context(), user.ID, counter)
+}
+
+func (h *Handler) currentUser(c *gin.Context) (models.User, bool) {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		httpapi.RespondError(c, http.StatusUnauthorized, httpapi.ErrorCodeUnauthorized, "unauthorized", nil)
+		return models.User{}, false
+	}
+	user, _, err := h.users.GetByID(c.Request.Context(), claims.UserID)
+	if err != nil {
+		if errors.Is(err, repos.ErrNotFound) {
+			httpapi.RespondError(c, http.StatusUnauthorized, httpapi.ErrorCodeUnauthorized, "unauthorized", nil)
+			return models.User{}, false
+		}
+		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "failed to load user", nil)
+		return models.User{}, false
+	}
+	return user, true
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	appauth "github.com/tanydotai/tanyai/backend/internal/auth"
+	"github.com/tanydotai/tanyai/backend/internal/middleware"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+)
+
+func postJSON(router *gin.Engine, target string, payload any) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(payload)
+	req := httptest.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMFAEnrollmentAndTwoStepLogin(t *testing.T) {
+	repo := newUserRepoStub()
+	passwordHash, err := appauth.HashPassword("Admin#12345")
+	require.NoError(t, err)
+	userID := uuid.New()
+	repo.addUser(models.User{ID: userID, Email: "admin@example.com", PasswordHash: passwordHash, IsActive: true}, []string{"admin"})
+
+	tokenService, err := appauth.NewTokenService(strings.Repeat("g", 64), 15*time.Minute, 24*time.Hour)
+	require.NoError(t, err)
+	handler := NewHandler(repo, tokenService, appauth.NewRateLimiter(100, 100, time.Minute), "__Host_refresh")
+
+	router := gin.New()
+	router.POST("/login", handler.Login)
+	router.POST("/mfa/verify", handler.VerifyMFA)
+	authed := router.Group("", func(c *gin.Context) {
+		middleware.SetClaims(c, &appauth.Claims{UserID: userID})
+	})
+	authed.POST("/mfa/enroll", handler.EnrollMFA)
+	authed.POST("/mfa/enroll/verify", handler.ConfirmMFA)
+
+	rec := postJSON(router, "/mfa/enroll", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var enrollment mfaEnrollResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &enrollment))
+	require.Contains(t, enrollment.ProvisioningURI, "otpauth://totp/tany.ai:admin@example.com")
+	require.False(t, repo.usersByID[userID].user.TOTPEnabled)
+
+	require.Equal(t, http.StatusBadRequest, postJSON(router, "/mfa/enroll/verify", gin.H{"code": "000000"}).Code)
+
+	now := time.Now()
+	code, err := appauth.TOTPCode(enrollment.Secret, now)
+	require.NoError(t, err)
+	rec = postJSON(router, "/mfa/enroll/verify", gin.H{"code": code})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var recovery recoveryCodesResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &recovery))
+	require.Len(t, recovery.RecoveryCodes, 10)
+	require.True(t, repo.usersByID[userID].user.TOTPEnabled)
+
+	rec = postJSON(router, "/login", gin.H{"email": "admin@example.com", "password": "Admin#12345"})
+	require.Equal(t, http.StatusOK, rec.Code)
+	var challenge mfaChallengeResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &challenge))
+	require.True(t, challenge.MFARequired)
+	require.NotEmpty(t, challenge.MFAToken)
+	require.Empty(t, repo.lastCreatedRefresh.TokenHash)
+	require.Empty(t, rec.Result().Cookies())
+
+	// The code used for enrollment cannot be replayed.
+	require.Equal(t, http.StatusUnauthorized, postJSON(router, "/mfa/verify", gin.H{"mfaToken": challenge.MFAToken, "code": code}).Code)
+
+	next, err := appauth.TOTPCode(enrollment.Secret, now.Add(appauth.TOTPPeriod))
+	require.NoError(t, err)
+	rec = postJSON(router, "/mfa/verify", gin.H{"mfaToken": challenge.MFAToken, "code": next})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var session authResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &session))
+	require.NotEmpty(t, session.AccessToken)
+	require.True(t, session.User.MFAEnabled)
+	require.NotEmpty(t, repo.lastCreatedRefresh.TokenHash)
+
+	rec = postJSON(router, "/mfa/verify", gin.H{"mfaToken": challenge.MFAToken, "recoveryCode": strings.ToUpper(recovery.RecoveryCodes[0])})
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = postJSON(router, "/mfa/verify", gin.H{"mfaToken": challenge.MFAToken, "recoveryCode": recovery.RecoveryCodes[0]})
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestVerifyMFARejectsAccessTokens(t *testing.T) {
+	repo := newUserRepoStub()
+	userID := uuid.New()
+	repo.addUser(models.User{ID: userID, Email: "admin@example.com", IsActive: true, TOTPEnabled: true}, []string{"admin"})
+
+	tokenService, err := appauth.NewTokenService(strings.Repeat("h", 64), 15*time.Minute, 24*time.Hour)
+	require.NoError(t, err)
+	handler := NewHandler(repo, tokenService, nil, "__Host_refresh")
+	router := gin.New()
+	router.POST("/mfa/verify", handler.VerifyMFA)
+
+	access, err := tokenService.GenerateAccessToken(appauth.Subject{ID: userID})
+	require.NoError(t, err)
+	rec := postJSON(router, "/mfa/verify", gin.H{"mfaToken": access, "code": "123456"})
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+}
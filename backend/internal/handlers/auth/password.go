@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	appauth "github.com/tanydotai/tanyai/backend/internal/auth"
+	"github.com/tanydotai/tanyai/backend/internal/httpapi"
+	"github.com/tanydotai/tanyai/backend/internal/repos"
+)
+
+type setPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=10,max=128"`
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required,min=10,max=128"`
+}
+
+// SetPassword redeems a one-time invite or reset token and stores the new password.
+// Existing sessions of the user are revoked.
+func (h *Handler) SetPassword(c *gin.Context) {
+	var req setPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "invalid payload", nil)
+		return
+	}
+
+	hash, err := appauth.HashPassword(req.Password)
+	if err != nil {
+		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "failed to set password", nil)
+		return
+	}
+
+	token, err := h.users.ConsumePasswordToken(c.Request.Context(), appauth.HashRefreshToken(req.Token), time.Now().UTC())
+	if err != nil {
+		if errors.Is(err, repos.ErrNotFound) {
+			httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "token is invalid or expired", nil)
+			return
+		}
+		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "failed to set password", nil)
+		return
+	}
+
+	if err := h.users.SetPassword(c.Request.Context(), token.UserID, hash); err != nil {
+		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "failed to set password", nil)
+		return
+	}
+
+	appauth.ClearRefreshCookie(c, h.refreshCookieName)
+	c.Status(http.StatusNoContent)
+}
+
+// ChangePassword updates the authenticated user's password after verifying the current one.
+// All sessions, including the current one, are revoked.
+func (h *Handler) ChangePassword(c *gin.Context) {
+	var req changePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "invalid payload", nil)
+		return
+	}
+
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	if err := appauth.ComparePassword(user.PasswordHash, req.CurrentPassword); err != nil {
+		if errors.Is(err, appauth.ErrInvalidPassword) {
+			httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "current password is incorrect", nil)
+			return
+		}
+		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "failed to change password", nil)
+		return
+	}
+
+	hash, err := appauth.HashPassword(req.NewPassword)
+	if err != nil {
+		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "failed to change password", nil)
+		return
+	}
+	if err := h.users.SetPassword(c.Request.Context(), user.ID, hash); err != nil {
+		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "failed to change password", nil)
+		return
+	}
+
+	appauth.ClearRefreshCookie(c, h.refreshCookieName)
+	c.Status(http.StatusNoContent)
+}
@@ -2,15 +2,13 @@ package auth
 
 import (
 	"errors"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 	appauth "github.com/tanydotai/tanyai/backend/internal/auth"
-	"github.com/tanydotai/tanyai/backend/internal/dto"
 	"github.com/tanydotai/tanyai/backend/internal/httpapi"
-	"github.com/tanydotai/tanyai/backend/internal/models"
 	"github.com/tanydotai/tanyai/backend/internal/repos"
 )
 
@@ -34,8 +32,24 @@ func (h *Handler) Refresh(c *gin.Context) {
 		return
 	}
 
+	if stored.Revoked {
+		// A rotated token was presented again: either it was stolen or the legitimate client
+		// is replaying an old copy. Either way the session can no longer be trusted.
+		revoked, err := h.users.RevokeRefreshFamily(c.Request.Context(), stored.UserID, stored.FamilyID)
+		if err != nil {
+			httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "refresh failed", nil)
+			return
+		}
+		if revoked > 0 {
+			log.Printf("[warn] refresh token reuse detected user=%s session=%s ip=%s: revoked %d token(s)", stored.UserID, stored.FamilyID, c.ClientIP(), revoked)
+		}
+		appauth.ClearRefreshCookie(c, h.refreshCookieName)
+		unauthorizedRefresh(c)
+		return
+	}
+
 	now := time.Now().UTC()
-	if stored.ExpiresAt.Before(now) || stored.Revoked {
+	if stored.ExpiresAt.Before(now) {
 		_ = h.users.RevokeRefreshTokenByHash(c.Request.Context(), tokenHash)
 		appauth.ClearRefreshCookie(c, h.refreshCookieName)
 		unauthorizedRefresh(c)
@@ -48,6 +62,7 @@ func (h *Handler) Refresh(c *gin.Context) {
 		return
 	}
 	if !revoked {
+		// Lost a race against a concurrent refresh with the same token; the winner keeps the session.
 		appauth.ClearRefreshCookie(c, h.refreshCookieName)
 		unauthorizedRefresh(c)
 		return
@@ -63,37 +78,13 @@ func (h *Handler) Refresh(c *gin.Context) {
 		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "refresh failed", nil)
 		return
 	}
-
-	accessToken, err := h.tokens.GenerateAccessToken(appauth.Subject{ID: user.ID, Email: user.Email, Roles: roles})
-	if err != nil {
-		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "refresh failed", nil)
-		return
-	}
-
-	refreshToken, refreshHash, expiresAt, err := h.tokens.GenerateRefreshToken()
-	if err != nil {
-		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "refresh failed", nil)
-		return
-	}
-
-	record := models.RefreshToken{
-		ID:        uuid.New(),
-		UserID:    user.ID,
-		TokenHash: refreshHash,
-		ExpiresAt: expiresAt.UTC(),
-	}
-	if err := h.users.CreateRefreshToken(c.Request.Context(), record); err != nil {
-		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "refresh failed", nil)
+	if !user.IsActive {
+		appauth.ClearRefreshCookie(c, h.refreshCookieName)
+		unauthorizedRefresh(c)
 		return
 	}
 
-	appauth.SetRefreshCookie(c, h.refreshCookieName, refreshToken, expiresAt)
-
-	resp := authResponse{
-		AccessToken: accessToken,
-		User:        dto.NewUserResponse(user, roles),
-	}
-	c.JSON(http.StatusOK, resp)
+	h.startSession(c, user, roles, &stored, "refresh failed")
 }
 
 func unauthorizedRefresh(c *gin.Context) {
@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	appauth "github.com/tanydotai/tanyai/backend/internal/auth"
+	"github.com/tanydotai/tanyai/backend/internal/httpapi"
+	"github.com/tanydotai/tanyai/backend/internal/middleware"
+)
+
+type sessionResponse struct {
+	ID         string    `json:"id"`
+	Device     string    `json:"device"`
+	UserAgent  string    `json:"userAgent"`
+	IPAddress  string    `json:"ipAddress"`
+	StartedAt  time.Time `json:"startedAt"`
+	LastUsedAt time.Time `json:"lastUsedAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	Current    bool      `json:"current"`
+}
+
+// ListSessions returns the authenticated user's active sessions, one per refresh token family.
+func (h *Handler) ListSessions(c *gin.Context) {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		httpapi.RespondError(c, http.StatusUnauthorized, httpapi.ErrorCodeUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	tokens, err := h.users.ListActiveRefreshTokens(c.Request.Context(), claims.UserID, time.Now().UTC())
+	if err != nil {
+		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "failed to list sessions", nil)
+		return
+	}
+
+	currentHash := ""
+	if raw, err := c.Cookie(h.refreshCookieName); err == nil && raw != "" {
+		currentHash = appauth.HashRefreshToken(raw)
+	}
+
+	sessions := make([]sessionResponse, 0, len(tokens))
+	for _, token := range tokens {
+		sessions = append(sessions, sessionResponse{
+			ID:         token.FamilyID.String(),
+			Device:     describeDevice(token.UserAgent),
+			UserAgent:  token.UserAgent,
+			IPAddress:  token.IPAddress,
+			StartedAt:  token.SessionStartedAt,
+			LastUsedAt: token.CreatedAt,
+			ExpiresAt:  token.ExpiresAt,
+			Current:    currentHash != "" && token.TokenHash == currentHash,
+		})
+	}
+	httpapi.RespondData(c, http.StatusOK, sessions)
+}
+
+// RevokeSession signs out a single session of the authenticated user.
+func (h *Handler) RevokeSession(c *gin.Context) {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		httpapi.RespondError(c, http.StatusUnauthorized, httpapi.ErrorCodeUnauthorized, "unauthorized", nil)
+		return
+	}
+	familyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "invalid session id", nil)
+		return
+	}
+
+	current := false
+	if raw, err := c.Cookie(h.refreshCookieName); err == nil && raw != "" {
+		if stored, err := h.users.FindRefreshTokenByHash(c.Request.Context(), appauth.HashRefreshToken(raw)); err == nil {
+			current = stored.FamilyID == familyID
+		}
+	}
+
+	revoked, err := h.users.RevokeRefreshFamily(c.Request.Context(), claims.UserID, familyID)
+	if err != nil {
+		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "failed to revoke session", nil)
+		return
+	}
+	if revoked == 0 {
+		httpapi.RespondError(c, http.StatusNotFound, httpapi.ErrorCodeNotFound, "session not found", nil)
+		return
+	}
+
+	if current {
+		appauth.ClearRefreshCookie(c, h.refreshCookieName)
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// describeDevice produces a short human readable label such as "Firefox on Windows".
+func describeDevice(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+	if ua == "" {
+		return "Unknown device"
+	}
+
+	browser := "Unknown browser"
+	switch {
+	case strings.Contains(ua, "edg/"):
+		browser = "Edge"
+	case strings.Contains(ua, "opr/") || strings.Contains(ua, "opera"):
+		browser = "Opera"
+	case strings.Contains(ua, "firefox/"):
+		browser = "Firefox"
+	case strings.Contains(ua, "chrome/") || strings.Contains(ua, "crios/"):
+		browser = "Chrome"
+	case strings.Contains(ua, "safari/"):
+		browser = "Safari"
+	case strings.Contains(ua, "curl/"):
+		browser = "curl"
+	}
+
+	platform := ""
+	switch {
+	case strings.Contains(ua, "iphone") || strings.Contains(ua, "ipad"):
+		platform = "iOS"
+	case strings.Contains(ua, "android"):
+		platform = "Android"
+	case strings.Contains(ua, "windows"):
+		platform = "Windows"
+	case strings.Contains(ua, "mac os") || strings.Contains(ua, "macintosh"):
+		platform = "macOS"
+	case strings.Contains(ua, "linux"):
+		platform = "Linux"
+	}
+
+	if platform == "" {
+		return browser
+	}
+	return browser + " on " + platform
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	appauth "github.com/tanydotai/tanyai/backend/internal/auth"
+	"github.com/tanydotai/tanyai/backend/internal/middleware"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+)
+
+func TestRefreshRotationKeepsFamilyAndReuseRevokesIt(t *testing.T) {
+	repo := newUserRepoStub()
+	userID := uuid.New()
+	repo.addUser(models.User{ID: userID, Email: "admin@example.com", IsActive: true}, []string{"admin"})
+
+	tokenService, err := appauth.NewTokenService(strings.Repeat("i", 64), 15*time.Minute, 24*time.Hour)
+	require.NoError(t, err)
+	handler := NewHandler(repo, tokenService, nil, "__Host_refresh")
+	router := gin.New()
+	router.POST("/refresh", handler.Refresh)
+
+	family := uuid.New()
+	started := time.Now().Add(-time.Hour).UTC()
+	stolen := "stolen-refresh"
+	repo.addRefreshToken(models.RefreshToken{ID: uuid.New(), UserID: userID, FamilyID: family, TokenHash: appauth.HashRefreshToken(stolen), ExpiresAt: time.Now().Add(time.Hour), SessionStartedAt: started})
+	other := models.RefreshToken{ID: uuid.New(), UserID: userID, FamilyID: uuid.New(), TokenHash: appauth.HashRefreshToken("other-device"), ExpiresAt: time.Now().Add(time.Hour)}
+	repo.addRefreshToken(other)
+
+	doRefresh := func(raw string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/refresh", nil)
+		req.AddCookie(&http.Cookie{Name: "__Host_refresh", Value: raw})
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	require.Equal(t, http.StatusOK, doRefresh(stolen).Code)
+	rotated := repo.lastCreatedRefresh
+	require.Equal(t, family, rotated.FamilyID)
+	require.True(t, rotated.SessionStartedAt.Equal(started))
+	require.False(t, repo.refreshTokens[rotated.TokenHash].Revoked)
+
+	// Replaying the rotated-out token revokes the whole family but no other session.
+	require.Equal(t, http.StatusUnauthorized, doRefresh(stolen).Code)
+	require.True(t, repo.refreshTokens[rotated.TokenHash].Revoked)
+	require.False(t, repo.refreshTokens[other.TokenHash].Revoked)
+}
+
+func TestSessionsListAndRevoke(t *testing.T) {
+	repo := newUserRepoStub()
+	userID := uuid.New()
+	repo.addUser(models.User{ID: userID, Email: "admin@example.com", IsActive: true}, []string{"admin"})
+
+	current := models.RefreshToken{ID: uuid.New(), UserID: userID, FamilyID: uuid.New(), TokenHash: appauth.HashRefreshToken("current"), ExpiresAt: time.Now().Add(time.Hour),
+		UserAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_0) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Safari/605.1.15", IPAddress: "203.0.113.7"}
+	laptop := models.RefreshToken{ID: uuid.New(), UserID: userID, FamilyID: uuid.New(), TokenHash: appauth.HashRefreshToken("laptop"), ExpiresAt: time.Now().Add(time.Hour),
+		UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:128.0) Gecko/20100101 Firefox/128.0"}
+	expired := models.RefreshToken{ID: uuid.New(), UserID: userID, FamilyID: uuid.New(), TokenHash: appauth.HashRefreshToken("expired"), ExpiresAt: time.Now().Add(-time.Hour)}
+	for _, token := range []models.RefreshToken{current, laptop, expired} {
+		repo.addRefreshToken(token)
+	}
+
+	handler := NewHandler(repo, nil, nil, "__Host_refresh")
+	router := gin.New()
+	authed := router.Group("", func(c *gin.Context) {
+		middleware.SetClaims(c, &appauth.Claims{UserID: userID})
+	})
+	authed.GET("/sessions", handler.ListSessions)
+	authed.DELETE("/sessions/:id", handler.RevokeSession)
+
+	req := httptest.NewRequest(http.MethodGet, "/sessions", nil)
+	req.AddCookie(&http.Cookie{Name: "__Host_refresh", Value: "current"})
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp struct {
+		Data []sessionResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 2)
+	devices := map[string]sessionResponse{}
+	for _, session := range resp.Data {
+		devices[session.ID] = session
+	}
+	require.True(t, devices[current.FamilyID.String()].Current)
+	require.Equal(t, "Safari on macOS", devices[current.FamilyID.String()].Device)
+	require.Equal(t, "Firefox on Windows", devices[laptop.FamilyID.String()].Device)
+	require.False(t, devices[laptop.FamilyID.String()].Current)
+
+	req = httptest.NewRequest(http.MethodDelete, "/sessions/"+laptop.FamilyID.String(), nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	require.True(t, repo.refreshTokens[laptop.TokenHash].Revoked)
+	require.False(t, repo.refreshTokens[current.TokenHash].Revoked)
+
+	req = httptest.NewRequest(http.MethodDelete, "/sessions/"+laptop.FamilyID.String(), nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tanydotai/tanyai/backend/internal/analytics"
+	"github.com/tanydotai/tanyai/backend/internal/httpapi"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/services/experiments"
+)
+
+const (
+	minFeedbackScore = 1
+	maxFeedbackScore = 5
+	maxLeadChannel   = 64
+)
+
+// SetExperiment splits chats between the experiment's variants. Passing nil ends the
+// experiment. Tenants with their own provider or model override are never enrolled.
+func (h *ChatHandler) SetExperiment(experiment *experiments.Experiment) {
+	h.experiment = experiment
+}
+
+// chatArm holds the generation settings for one chat, and the experiment variant they
+// come from when the chat is enrolled in an experiment.
+type chatArm struct {
+	selection    ProviderSelection
+	instructions string
+	maxTokens    int
+	temperature  float32
+	experiment   string
+	variant      string
+}
+
+// chooseArm applies the chat's experiment variant on top of the deployment defaults.
+func (h *ChatHandler) chooseArm(ctx context.Context, chatID uuid.UUID) chatArm {
+	selection, overridden := h.selectProvider(ctx)
+	arm := chatArm{selection: selection, maxTokens: chatMaxTokens, temperature: chatTemperature}
+	if h.experiment == nil || overridden {
+		return arm
+	}
+
+	variant := h.experiment.Assign(chatID)
+	if variant.Provider != "" || variant.Model != "" {
+		if h.resolver == nil {
+			return arm
+		}
+		name, model := variant.Provider, variant.Model
+		if name == "" {
+			name = selection.Name
+		}
+		if model == "" {
+			model = selection.Model
+		}
+		resolved, err := h.resolver.Resolve(name, model)
+		if err != nil {
+			slog.Warn("experiment_variant_unavailable", "experiment", h.experiment.Name, "variant", variant.Name, "error", err)
+			return arm
+		}
+		arm.selection = resolved
+	}
+	arm.instructions = variant.Template
+	if variant.MaxTokens > 0 {
+		arm.maxTokens = variant.MaxTokens
+	}
+	if variant.Temperature != nil {
+		arm.temperature = *variant.Temperature
+	}
+	arm.experiment = h.experiment.Name
+	arm.variant = variant.Name
+	return arm
+}
+
+// FeedbackRequest rates an answered chat.
+type FeedbackRequest struct {
+	ChatID string `json:"chatId" binding:"required"`
+	Score  int    `json:"score" binding:"required"`
+}
+
+// LeadRequest reports that a chat led to a contact, e.g. a WhatsApp click or a
+// submitted contact form.
+type LeadRequest struct {
+	ChatID  string `json:"chatId" binding:"required"`
+	Channel string `json:"channel"`
+}
+
+// HandleFeedback records a 1–5 rating for a chat as a "feedback" analytics event.
+func (h *ChatHandler) HandleFeedback(c *gin.Context) {
+	var payload FeedbackRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "chatId and score are required", nil)
+		return
+	}
+	if payload.Score < minFeedbackScore || payload.Score > maxFeedbackScore {
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "score must be between 1 and 5", nil)
+		return
+	}
+	h.recordChatEvent(c, payload.ChatID, "feedback", models.JSONB{"score": payload.Score})
+}
+
+// HandleLead records a "lead" analytics event for a chat, counted as a conversion.
+func (h *ChatHandler) HandleLead(c *gin.Context) {
+	var payload LeadRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "chatId is required", nil)
+		return
+	}
+	channel := strings.TrimSpace(payload.Channel)
+	if len(channel) > maxLeadChannel {
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "channel is too long", nil)
+		return
+	}
+	metadata := models.JSONB{}
+	if channel != "" {
+		metadata["channel"] = channel
+	}
+	h.recordChatEvent(c, payload.ChatID, "lead", metadata)
+}
+
+// recordChatEvent stores an analytics event for an existing chat of the request's
+// tenant, tagged with the chat's experiment variant so it can be attributed.
+func (h *ChatHandler) recordChatEvent(c *gin.Context, rawChatID, eventType string, metadata models.JSONB) {
+	chatID, err := uuid.Parse(rawChatID)
+	if err != nil {
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "chatId must be a valid UUID", nil)
+		return
+	}
+	if h.history == nil {
+		httpapi.RespondError(c, http.StatusNotFound, httpapi.ErrorCodeNotFound, "chat not found", nil)
+		return
+	}
+	rows, err := h.history.ListRecentByChat(c.Request.Context(), chatID, 1)
+	if err != nil {
+		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "failed to load chat", nil)
+		return
+	}
+	if len(rows) == 0 {
+		httpapi.RespondError(c, http.StatusNotFound, httpapi.ErrorCodeNotFound, "chat not found", nil)
+		return
+	}
+
+	metadata["chat_id"] = chatID.String()
+	if latest := rows[0]; latest.Variant != "" {
+		metadata["experiment"] = latest.Experiment
+		metadata["variant"] = latest.Variant
+	}
+	if h.analytics != nil {
+		if err := h.analytics.RecordEvent(c.Request.Context(), analytics.RecordEventInput{
+			Timestamp: time.Now(),
+			Type:      eventType,
+			Source:    c.GetHeader("X-Chat-Source"),
+			Success:   true,
+			UserAgent: c.Request.UserAgent(),
+			Metadata:  metadata,
+		}); err != nil && !errors.Is(err, analytics.ErrAnalyticsDisabled) {
+			slog.Warn("analytics_record_failed", "error", err, "chat_id", chatID.String(), "event", eventType)
+		}
+	}
+	c.Status(http.StatusNoContent)
+}
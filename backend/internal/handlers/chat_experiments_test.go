@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tanydotai/tanyai/backend/internal/analytics"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/services/experiments"
+	"github.com/tanydotai/tanyai/backend/internal/services/kb"
+)
+
+type stubAnalytics struct {
+	chats  []analytics.RecordChatInput
+	events []analytics.RecordEventInput
+}
+
+func (s *stubAnalytics) RecordChat(_ context.Context, input analytics.RecordChatInput) error {
+	s.chats = append(s.chats, input)
+	return nil
+}
+
+func (s *stubAnalytics) RecordEvent(_ context.Context, input analytics.RecordEventInput) error {
+	s.events = append(s.events, input)
+	return nil
+}
+
+// chatStore keeps chat history in memory so follow-up requests can find earlier chats.
+type chatStore struct {
+	historyRecorder
+}
+
+func (s *chatStore) ListRecentByChat(_ context.Context, chatID uuid.UUID, limit int) ([]models.ChatHistory, error) {
+	rows := make([]models.ChatHistory, 0)
+	for i := len(s.records) - 1; i >= 0 && len(rows) < limit; i-- {
+		if s.records[i].ChatID == chatID {
+			rows = append(rows, s.records[i])
+		}
+	}
+	return rows, nil
+}
+
+type fixedSelector struct {
+	selection ProviderSelection
+}
+
+func (s fixedSelector) Select(context.Context) (ProviderSelection, bool) {
+	return s.selection, true
+}
+
+func testExperiment(t *testing.T) *experiments.Experiment {
+	t.Helper()
+	experiment, err := experiments.Parse(`{"name": "pro-vs-flash", "variants": [
+		{"name": "control", "weight": 1},
+		{"name": "flash", "model": "gemini-2.5-flash", "template": "Jawab dalam satu kalimat.", "temperature": 0.2, "maxTokens": 512, "weight": 1}
+	]}`)
+	if err != nil {
+		t.Fatalf("parse experiment: %v", err)
+	}
+	return experiment
+}
+
+// chatIDFor finds a chat ID the experiment assigns to variant.
+func chatIDFor(t *testing.T, experiment *experiments.Experiment, variant string) uuid.UUID {
+	t.Helper()
+	for i := 0; i < 1000; i++ {
+		id := uuid.New()
+		if experiment.Assign(id).Name == variant {
+			return id
+		}
+	}
+	t.Fatalf("no chat assigned to %s", variant)
+	return uuid.Nil
+}
+
+func postJSON(handler gin.HandlerFunc, body string) *httptest.ResponseRecorder {
+	engine := gin.New()
+	engine.POST("/", handler)
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, req)
+	return res
+}
+
+func TestHandleChatAppliesExperimentVariant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	knowledge := &stubKnowledge{base: kb.KnowledgeBase{Profile: kb.Profile{Name: "Tanya"}}}
+	defaultProvider := &recordingProvider{text: "Jawaban pro"}
+	flashProvider := &recordingProvider{text: "Jawaban flash"}
+	history := &chatStore{}
+	recorder := &stubAnalytics{}
+	experiment := testExperiment(t)
+
+	handler := NewChatHandler(knowledge, history, "gemini-2.5-pro", defaultProvider, "gemini", recorder)
+	handler.SetProviderResolver(stubResolver{"gemini": {Provider: flashProvider, Name: "gemini"}})
+	handler.SetExperiment(experiment)
+
+	flashChat := chatIDFor(t, experiment, "flash")
+	res := postJSON(handler.HandleChat, `{"question":"Halo?","chatId":"`+flashChat.String()+`"}`)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", res.Code)
+	}
+	var payload ChatResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if payload.Answer != "Jawaban flash" || payload.Model != "gemini-2.5-flash" {
+		t.Fatalf("expected the flash variant to answer, got %+v", payload)
+	}
+	if flashProvider.last.MaxTokens != 512 || flashProvider.last.Temperature != 0.2 {
+		t.Fatalf("expected variant generation settings, got %+v", flashProvider.last)
+	}
+	if !strings.Contains(flashProvider.last.Prompt, "Jawab dalam satu kalimat.") {
+		t.Fatalf("expected the variant template in the prompt")
+	}
+	record := history.records[0]
+	if record.Experiment != "pro-vs-flash" || record.Variant != "flash" {
+		t.Fatalf("expected the variant in chat history, got %q/%q", record.Experiment, record.Variant)
+	}
+	if meta := recorder.chats[0].Metadata; meta["experiment"] != "pro-vs-flash" || meta["variant"] != "flash" {
+		t.Fatalf("expected the variant in analytics metadata, got %+v", meta)
+	}
+
+	controlChat := chatIDFor(t, experiment, "control")
+	postJSON(handler.HandleChat, `{"question":"Halo?","chatId":"`+controlChat.String()+`"}`)
+	if defaultProvider.last.MaxTokens != chatMaxTokens || history.records[1].Variant != "control" {
+		t.Fatalf("expected control to keep the defaults, got %+v / %q", defaultProvider.last, history.records[1].Variant)
+	}
+}
+
+func TestHandleChatSkipsExperimentForTenantOverrides(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	knowledge := &stubKnowledge{base: kb.KnowledgeBase{Profile: kb.Profile{Name: "Tanya"}}}
+	tenantProvider := &recordingProvider{text: "Jawaban tenant"}
+	history := &chatStore{}
+	experiment := testExperiment(t)
+
+	handler := NewChatHandler(knowledge, history, "gemini-2.5-pro", &recordingProvider{text: "x"}, "gemini", nil)
+	handler.SetProviderSelector(fixedSelector{ProviderSelection{Provider: tenantProvider, Name: "leapcell", Model: "tenant-model"}})
+	handler.SetProviderResolver(stubResolver{"gemini": {Provider: &recordingProvider{text: "flash"}, Name: "gemini"}})
+	handler.SetExperiment(experiment)
+
+	postJSON(handler.HandleChat, `{"question":"Halo?","chatId":"`+chatIDFor(t, experiment, "flash").String()+`"}`)
+	if record := history.records[0]; record.Variant != "" || record.ResponseText != "Jawaban tenant" {
+		t.Fatalf("tenants with overrides should not be enrolled, got %+v", record)
+	}
+}
+
+func TestHandleFeedbackAndLeadAttributeVariant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	chatID := uuid.New()
+	history := &chatStore{}
+	history.records = append(history.records, models.ChatHistory{ChatID: chatID, Experiment: "pro-vs-flash", Variant: "flash"})
+	recorder := &stubAnalytics{}
+	handler := NewChatHandler(&stubKnowledge{}, history, "m", nil, "mock", recorder)
+
+	if res := postJSON(handler.HandleFeedback, `{"chatId":"`+chatID.String()+`","score":6}`); res.Code != http.StatusBadRequest {
+		t.Fatalf("expected out of range scores to be rejected, got %d", res.Code)
+	}
+	if res := postJSON(handler.HandleFeedback, `{"chatId":"`+uuid.NewString()+`","score":4}`); res.Code != http.StatusNotFound {
+		t.Fatalf("expected unknown chats to be rejected, got %d", res.Code)
+	}
+	if res := postJSON(handler.HandleLead, `{"chatId":"nope"}`); res.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid chat IDs to be rejected, got %d", res.Code)
+	}
+
+	if res := postJSON(handler.HandleFeedback, `{"chatId":"`+chatID.String()+`","score":4}`); res.Code != http.StatusNoContent {
+		t.Fatalf("expected feedback to be recorded, got %d", res.Code)
+	}
+	if res := postJSON(handler.HandleLead, `{"chatId":"`+chatID.String()+`","channel":"whatsapp"}`); res.Code != http.StatusNoContent {
+		t.Fatalf("expected lead to be recorded, got %d", res.Code)
+	}
+	if len(recorder.events) != 2 {
+		t.Fatalf("expected two events, got %d", len(recorder.events))
+	}
+	feedback, lead := recorder.events[0], recorder.events[1]
+	if feedback.Type != "feedback" || feedback.Metadata["score"] != 4 || feedback.Metadata["variant"] != "flash" {
+		t.Fatalf("unexpected feedback event %+v", feedback)
+	}
+	if lead.Type != "lead" || lead.Metadata["channel"] != "whatsapp" || lead.Metadata["chat_id"] != chatID.String() || lead.Metadata["experiment"] != "pro-vs-flash" {
+		t.Fatalf("unexpected lead event %+v", lead)
+	}
+}
@@ -15,11 +15,24 @@ import (
 	"github.com/google/uuid"
 	"github.com/tanydotai/tanyai/backend/internal/ai"
 	"github.com/tanydotai/tanyai/backend/internal/analytics"
+	"github.com/tanydotai/tanyai/backend/internal/auth"
 	"github.com/tanydotai/tanyai/backend/internal/httpapi"
+	"github.com/tanydotai/tanyai/backend/internal/middleware"
 	"github.com/tanydotai/tanyai/backend/internal/models"
 	"github.com/tanydotai/tanyai/backend/internal/repos"
+	"github.com/tanydotai/tanyai/backend/internal/services/answercache"
+	"github.com/tanydotai/tanyai/backend/internal/services/experiments"
 	"github.com/tanydotai/tanyai/backend/internal/services/kb"
 	"github.com/tanydotai/tanyai/backend/internal/services/prompt"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
+)
+
+const (
+	maxHistoryTurns = 3
+	// Default generation settings for chat answers. Experiment variants and the admin
+	// playground may override both per request.
+	chatMaxTokens   = 2048 // upper bound on answer length, in provider tokens
+	chatTemperature = 0.7  // moderate sampling: varied wording, answers stay on the prompt
 )
 
 // KnowledgeService defines the behaviour required from a knowledge base provider.
@@ -30,6 +43,26 @@ type KnowledgeService interface {
 
 type analyticsRecorder interface {
 	RecordChat(ctx context.Context, input analytics.RecordChatInput) error
+	RecordEvent(ctx context.Context, input analytics.RecordEventInput) error
+}
+
+// AnswerCache stores provider answers for repeated questions against the same knowledge base.
+type AnswerCache interface {
+	Lookup(ctx context.Context, question, etag string) (answercache.Match, bool)
+	Store(ctx context.Context, question, etag, answer, model string)
+}
+
+// ProviderSelection identifies the AI provider and model answering a request.
+type ProviderSelection struct {
+	Provider ai.Provider
+	Name     string
+	Model    string
+}
+
+// ProviderSelector picks the provider configured for the tenant carried by the context.
+// It reports false when the tenant uses the deployment defaults.
+type ProviderSelector interface {
+	Select(ctx context.Context) (ProviderSelection, bool)
 }
 
 // ChatHandler exposes HTTP handlers for chat and knowledge base endpoints.
@@ -40,6 +73,11 @@ type ChatHandler struct {
 	provider     ai.Provider
 	providerName string
 	analytics    analyticsRecorder
+	answers      AnswerCache
+	providers    ProviderSelector
+	resolver     ProviderResolver
+	experiment   *experiments.Experiment
+	exposePrompt bool
 }
 
 // ChatRequest represents the incoming chat payload.
@@ -53,7 +91,12 @@ type ChatResponse struct {
 	ChatID string `json:"chatId"`
 	Answer string `json:"answer"`
 	Model  string `json:"model"`
-	Prompt string `json:"prompt"`
+	// Prompt is only returned to tenant admins or when prompt exposure is enabled.
+	Prompt string `json:"prompt,omitempty"`
+	Cached bool   `json:"cached"`
+	// Citations lists the knowledge base entries the answer refers to, in order of
+	// first mention.
+	Citations []prompt.Reference `json:"citations"`
 }
 
 // NewChatHandler constructs a ChatHandler with the provided dependencies.
@@ -68,6 +111,22 @@ func NewChatHandler(knowledge KnowledgeService, history repos.ChatHistoryReposit
 	}
 }
 
+// SetAnswerCache enables serving repeated questions from cache. Passing nil disables it.
+func (h *ChatHandler) SetAnswerCache(cache AnswerCache) {
+	h.answers = cache
+}
+
+// SetProviderSelector enables per-tenant provider overrides. Passing nil restores the defaults.
+func (h *ChatHandler) SetProviderSelector(selector ProviderSelector) {
+	h.providers = selector
+}
+
+// SetExposePrompt returns the assembled prompt to every caller when enabled. Otherwise
+// only admins of the tenant receive it.
+func (h *ChatHandler) SetExposePrompt(expose bool) {
+	h.exposePrompt = expose
+}
+
 // HandleChat processes the chat question and stores the interaction history.
 func (h *ChatHandler) HandleChat(c *gin.Context) {
 	var payload ChatRequest
@@ -76,18 +135,12 @@ func (h *ChatHandler) HandleChat(c *gin.Context) {
 		return
 	}
 
-	base, _, cacheHit, err := h.knowledge.Get(c.Request.Context())
+	base, etag, cacheHit, err := h.knowledge.Get(c.Request.Context())
 	if err != nil {
 		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "failed to load knowledge base", nil)
 		return
 	}
 	c.Set("kb_cache_hit", cacheHit)
-	c.Set("model", h.modelName)
-
-	promptText := prompt.BuildPrompt(base, payload.Question)
-	answer := ""
-	promptHash := sha256.Sum256([]byte(promptText))
-	promptLength := len([]rune(promptText))
 
 	chatID := uuid.New()
 	if payload.ChatID != "" {
@@ -98,24 +151,72 @@ func (h *ChatHandler) HandleChat(c *gin.Context) {
 		}
 		chatID = parsed
 	}
+
+	arm := h.chooseArm(c.Request.Context(), chatID)
+	selection := arm.selection
+	c.Set("model", selection.Model)
+	if arm.variant != "" {
+		c.Set("experiment", arm.experiment)
+		c.Set("variant", arm.variant)
+	}
+
+	turns := h.recentTurns(c.Request.Context(), payload.ChatID, chatID)
+	assembled := prompt.Assemble(base, payload.Question, prompt.Options{History: turns, Instructions: arm.instructions})
+	promptText := assembled.Text
+	answer := ""
+	promptHash := sha256.Sum256([]byte(promptText))
+	promptLength := len([]rune(promptText))
+
 	c.Set("chat_id", chatID.String())
 	c.Set("prompt_length", promptLength)
+	c.Set("prompt_tokens", assembled.EstimatedTokens)
+	if len(assembled.Dropped) > 0 {
+		slog.Debug("prompt_sections_dropped", "chat_id", chatID.String(), "sections", assembled.Dropped, "budget", assembled.Budget)
+	}
+
+	// Follow-up questions depend on the conversation, so only standalone questions are cached.
+	// Experiment chats bypass the cache so every variant is measured on its own answers.
+	cacheable := h.answers != nil && len(turns) == 0 && arm.variant == ""
 
 	started := time.Now()
 	var providerErr error
-	if h.provider != nil {
-		resp, err := h.provider.Generate(c.Request.Context(), ai.Request{
+	generated := false
+	var cached answercache.Match
+	answerCached := false
+	if cacheable {
+		cached, answerCached = h.answers.Lookup(c.Request.Context(), payload.Question, etag)
+		if answerCached {
+			answer = cached.Answer
+		}
+	}
+	c.Set("answer_cache_hit", answerCached)
+
+	if !answerCached && selection.Provider != nil {
+		resp, err := selection.Provider.Generate(c.Request.Context(), ai.Request{
 			Prompt:      promptText,
-			MaxTokens:   2048, // Increased token limit for longer responses
-			Temperature: 0.7,  // Matched with Gemini provider
+			MaxTokens:   arm.maxTokens,
+			Temperature: arm.temperature,
 		})
 		if err != nil {
 			providerErr = err
 		} else {
 			answer = strings.TrimSpace(resp.Text)
+			generated = true
 		}
 	}
 
+	citations := make([]prompt.Reference, 0)
+	if answer != "" {
+		var rejected []string
+		answer, citations, rejected = prompt.Cite(answer, assembled.References)
+		if len(rejected) > 0 {
+			slog.Warn("chat_citations_rejected", "chat_id", chatID.String(), "ids", rejected)
+		}
+	}
+	if generated && cacheable && answer != "" {
+		h.answers.Store(c.Request.Context(), payload.Question, etag, answer, selection.Model)
+	}
+
 	if answer == "" {
 		answer = prompt.SummarizeForHuman(payload.Question, base)
 	}
@@ -130,12 +231,15 @@ func (h *ChatHandler) HandleChat(c *gin.Context) {
 	record := models.ChatHistory{
 		ChatID:       chatID,
 		UserInput:    payload.Question,
-		Model:        h.modelName,
+		Model:        selection.Model,
 		Prompt:       promptText,
 		PromptHash:   hex.EncodeToString(promptHash[:]),
 		PromptLength: promptLength,
 		ResponseText: answer,
 		LatencyMS:    int(latency.Milliseconds()),
+		CacheHit:     answerCached,
+		Experiment:   arm.experiment,
+		Variant:      arm.variant,
 		CreatedAt:    time.Now(),
 	}
 
@@ -151,14 +255,36 @@ func (h *ChatHandler) HandleChat(c *gin.Context) {
 			"cache_hit":       cacheHit,
 			"question_length": len([]rune(payload.Question)),
 			"ip":              c.ClientIP(),
+			"prompt_tokens":   assembled.EstimatedTokens,
+			"answer_cached":   answerCached,
+			"citations":       len(citations),
+		}
+		if answerCached {
+			metadata["answer_cache_exact"] = cached.Exact
+			metadata["answer_cache_score"] = cached.Score
+		}
+		if len(assembled.Dropped) > 0 {
+			metadata["prompt_dropped_sections"] = assembled.Dropped
+		}
+		if arm.variant != "" {
+			metadata["experiment"] = arm.experiment
+			metadata["variant"] = arm.variant
 		}
 		if payload.ChatID != "" {
 			metadata["session_chat_id"] = payload.ChatID
 		}
+		if t, ok := tenant.FromContext(c.Request.Context()); ok && t.Slug != "" {
+			metadata["tenant"] = t.Slug
+		}
+		source := c.GetHeader("X-Chat-Source")
+		if claims, ok := middleware.GetClaims(c); ok && claims.IsAPIKey() {
+			source = "api_key:" + claims.APIKeyPrefix
+			metadata["api_key_id"] = claims.APIKeyID.String()
+		}
 		if err := h.analytics.RecordChat(c.Request.Context(), analytics.RecordChatInput{
 			Timestamp: time.Now(),
-			Source:    c.GetHeader("X-Chat-Source"),
-			Provider:  h.providerName,
+			Source:    source,
+			Provider:  selection.Name,
 			Duration:  latency,
 			Success:   providerErr == nil,
 			UserAgent: c.Request.UserAgent(),
@@ -170,10 +296,14 @@ func (h *ChatHandler) HandleChat(c *gin.Context) {
 	}
 
 	response := ChatResponse{
-		ChatID: chatID.String(),
-		Answer: answer,
-		Model:  h.modelName,
-		Prompt: promptText,
+		ChatID:    chatID.String(),
+		Answer:    answer,
+		Model:     selection.Model,
+		Cached:    answerCached,
+		Citations: citations,
+	}
+	if h.canSeePrompt(c) {
+		response.Prompt = promptText
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -208,3 +338,49 @@ func (h *ChatHandler) HandleKnowledgeBase(c *gin.Context) {
 
 	c.JSON(http.StatusOK, data)
 }
+
+// canSeePrompt reports whether the caller may receive the assembled prompt: always when
+// exposure is enabled, otherwise only for admins signed in to the request's tenant.
+func (h *ChatHandler) canSeePrompt(c *gin.Context) bool {
+	if h.exposePrompt {
+		return true
+	}
+	claims, ok := middleware.GetClaims(c)
+	if !ok || !claims.HasRole(auth.RoleAdmin) {
+		return false
+	}
+	tenantID := claims.TenantID
+	if tenantID == uuid.Nil {
+		tenantID = tenant.DefaultID
+	}
+	return tenantID == tenant.ID(c.Request.Context())
+}
+
+// selectProvider returns the tenant's provider override, or the handler defaults. It
+// reports whether the tenant overrides the defaults.
+func (h *ChatHandler) selectProvider(ctx context.Context) (ProviderSelection, bool) {
+	if h.providers != nil {
+		if selection, ok := h.providers.Select(ctx); ok {
+			return selection, true
+		}
+	}
+	return ProviderSelection{Provider: h.provider, Name: h.providerName, Model: h.modelName}, false
+}
+
+// recentTurns loads earlier exchanges of an existing chat so they can compete for prompt budget.
+func (h *ChatHandler) recentTurns(ctx context.Context, rawChatID string, chatID uuid.UUID) []prompt.Turn {
+	if h.history == nil || rawChatID == "" {
+		return nil
+	}
+	rows, err := h.history.ListRecentByChat(ctx, chatID, maxHistoryTurns)
+	if err != nil {
+		slog.Warn("chat_history_lookup_failed", "error", err, "chat_id", chatID.String())
+		return nil
+	}
+	turns := make([]prompt.Turn, 0, len(rows))
+	// Rows arrive newest first; the prompt builder expects chronological order.
+	for i := len(rows) - 1; i >= 0; i-- {
+		turns = append(turns, prompt.Turn{Question: rows[i].UserInput, Answer: rows[i].ResponseText})
+	}
+	return turns
+}
@@ -7,14 +7,19 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/tanydotai/tanyai/backend/internal/ai"
+	"github.com/tanydotai/tanyai/backend/internal/auth"
+	"github.com/tanydotai/tanyai/backend/internal/middleware"
 	"github.com/tanydotai/tanyai/backend/internal/models"
 	"github.com/tanydotai/tanyai/backend/internal/repos"
+	"github.com/tanydotai/tanyai/backend/internal/services/answercache"
 	"github.com/tanydotai/tanyai/backend/internal/services/kb"
 )
 
@@ -93,14 +98,109 @@ func TestHandleChatStoresHistory(t *testing.T) {
 	if payload.Answer != "Jawaban AI" {
 		t.Fatalf("expected provider answer, got %q", payload.Answer)
 	}
-	if payload.Prompt == "" {
-		t.Fatalf("prompt should not be empty")
+	if payload.Prompt != "" {
+		t.Fatalf("prompt should not be returned to anonymous visitors")
+	}
+	if history.records[0].Prompt == "" {
+		t.Fatalf("prompt should still be stored in history")
 	}
 	if payload.Model != "mock-model" {
 		t.Fatalf("expected model to be propagated")
 	}
 }
 
+func TestHandleChatReturnsPromptToAdminsOrWhenExposed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	knowledge := &stubKnowledge{base: kb.KnowledgeBase{Profile: kb.Profile{Name: "Tanya"}}}
+	handler := NewChatHandler(knowledge, nil, "mock-model", &stubProvider{response: "Jawaban AI"}, "mock", nil)
+
+	ask := func(claims *auth.Claims) ChatResponse {
+		engine := gin.New()
+		engine.POST("/chat", func(c *gin.Context) {
+			if claims != nil {
+				middleware.SetClaims(c, claims)
+			}
+			c.Next()
+		}, handler.HandleChat)
+		req := httptest.NewRequest(http.MethodPost, "/chat", bytes.NewBufferString(`{"question":"Siapa kamu?"}`))
+		req.Header.Set("Content-Type", "application/json")
+		res := httptest.NewRecorder()
+		engine.ServeHTTP(res, req)
+		var payload ChatResponse
+		if err := json.Unmarshal(res.Body.Bytes(), &payload); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return payload
+	}
+
+	if ask(&auth.Claims{Roles: []string{auth.RoleAdmin}}).Prompt == "" {
+		t.Fatalf("admins of the tenant should receive the prompt")
+	}
+	if ask(&auth.Claims{Roles: []string{auth.RoleEditor}}).Prompt != "" {
+		t.Fatalf("non-admins should not receive the prompt")
+	}
+	if ask(&auth.Claims{Roles: []string{auth.RoleAdmin}, TenantID: uuid.New()}).Prompt != "" {
+		t.Fatalf("admins of another tenant should not receive the prompt")
+	}
+	if ask(&auth.Claims{APIKeyID: uuid.New(), Roles: []string{auth.RoleAdmin}}).Prompt != "" {
+		t.Fatalf("api keys should not receive the prompt")
+	}
+
+	handler.SetExposePrompt(true)
+	if ask(nil).Prompt == "" {
+		t.Fatalf("prompt should be returned to everyone when exposure is enabled")
+	}
+}
+
+// citingProvider answers with the first project reference found in the prompt and an
+// invented one.
+type citingProvider struct{}
+
+func (citingProvider) Generate(_ context.Context, req ai.Request) (ai.Response, error) {
+	ref := regexp.MustCompile(`\[prj:[0-9a-f]+\]`).FindString(req.Prompt)
+	return ai.Response{Text: "Lihat proyek Featured " + ref + " dan proyek lain [prj:000000]."}, nil
+}
+
+func TestHandleChatReturnsCitations(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	knowledge := &stubKnowledge{base: kb.KnowledgeBase{
+		Profile:  kb.Profile{Name: "Tanya"},
+		Projects: []kb.Project{{ID: "p1", Title: "Featured", IsFeatured: true, ProjectURL: "https://example.com/featured"}},
+	}}
+	history := &historyRecorder{}
+	handler := NewChatHandler(knowledge, history, "mock-model", citingProvider{}, "mock", nil)
+	engine := gin.New()
+	engine.POST("/chat", handler.HandleChat)
+
+	req := httptest.NewRequest(http.MethodPost, "/chat", bytes.NewBufferString(`{"question":"Proyek apa saja?"}`))
+	req.Header.Set("Content-Type", "application/json")
+	res := httptest.NewRecorder()
+
+	engine.ServeHTTP(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", res.Code)
+	}
+
+	var payload ChatResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(payload.Citations) != 1 {
+		t.Fatalf("expected one citation, got %+v", payload.Citations)
+	}
+	citation := payload.Citations[0]
+	if citation.Kind != "project" || citation.Title != "Featured" || citation.URL != "https://example.com/featured" {
+		t.Fatalf("unexpected citation %+v", citation)
+	}
+	if !strings.Contains(payload.Answer, "["+citation.ID+"]") || strings.Contains(payload.Answer, "prj:000000") {
+		t.Fatalf("answer should keep valid and drop invented references, got %q", payload.Answer)
+	}
+	if history.records[0].ResponseText != payload.Answer {
+		t.Fatalf("history should store the cleaned answer")
+	}
+}
+
 func TestHandleChatFallsBackOnProviderError(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	knowledge := &stubKnowledge{base: kb.KnowledgeBase{
@@ -155,3 +255,59 @@ func TestHandleKnowledgeBaseSetsCachingHeaders(t *testing.T) {
 		t.Fatalf("expected Cache-Control header to be set")
 	}
 }
+
+type countingProvider struct {
+	calls int
+}
+
+func (p *countingProvider) Generate(context.Context, ai.Request) (ai.Response, error) {
+	p.calls++
+	return ai.Response{Text: "Jawaban AI"}, nil
+}
+
+func TestHandleChatServesRepeatedQuestionFromAnswerCache(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	knowledge := &stubKnowledge{base: kb.KnowledgeBase{
+		Profile:  kb.Profile{Name: "Tanya"},
+		Services: []kb.Service{{Name: "Consulting"}},
+	}}
+	history := &historyRecorder{}
+	provider := &countingProvider{}
+	handler := NewChatHandler(knowledge, history, "mock-model", provider, "mock", nil)
+	handler.SetAnswerCache(answercache.New(answercache.Options{}))
+	engine := gin.New()
+	engine.POST("/chat", handler.HandleChat)
+
+	ask := func(question string) ChatResponse {
+		req := httptest.NewRequest(http.MethodPost, "/chat", bytes.NewBufferString(`{"question":"`+question+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		res := httptest.NewRecorder()
+		engine.ServeHTTP(res, req)
+		if res.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", res.Code)
+		}
+		var payload ChatResponse
+		if err := json.Unmarshal(res.Body.Bytes(), &payload); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return payload
+	}
+
+	first := ask("Layanan apa saja?")
+	if first.Cached {
+		t.Fatalf("first answer should come from the provider")
+	}
+	second := ask("layanan  apa saja")
+	if !second.Cached {
+		t.Fatalf("expected repeated question to be served from cache")
+	}
+	if second.Answer != first.Answer {
+		t.Fatalf("expected cached answer %q, got %q", first.Answer, second.Answer)
+	}
+	if provider.calls != 1 {
+		t.Fatalf("expected provider to be called once, got %d", provider.calls)
+	}
+	if len(history.records) != 2 || !history.records[1].CacheHit {
+		t.Fatalf("expected cache hit to be recorded in chat history")
+	}
+}
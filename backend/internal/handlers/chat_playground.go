@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tanydotai/tanyai/backend/internal/ai"
+	"github.com/tanydotai/tanyai/backend/internal/httpapi"
+	"github.com/tanydotai/tanyai/backend/internal/services/kb"
+	"github.com/tanydotai/tanyai/backend/internal/services/prompt"
+)
+
+const (
+	playgroundMaxTokensLimit   = 8192
+	playgroundMaxTemperature   = 2
+	playgroundMaxTemplateRunes = 2000
+)
+
+// ProviderResolver builds a provider by name and model for the chat playground. It
+// returns an error when the provider is unknown or not configured.
+type ProviderResolver interface {
+	Resolve(name, model string) (ProviderSelection, error)
+}
+
+// SetProviderResolver enables choosing a provider in the chat playground. Without a
+// resolver the playground only runs the tenant's own provider.
+func (h *ChatHandler) SetProviderResolver(resolver ProviderResolver) {
+	h.resolver = resolver
+}
+
+// PlaygroundRequest runs a question through the chat pipeline with admin chosen settings.
+type PlaygroundRequest struct {
+	Question string `json:"question" binding:"required"`
+	// Provider and Model override the tenant's provider; empty keeps it.
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	// Template replaces the default answering instructions of the prompt.
+	Template    string           `json:"template"`
+	TokenBudget int              `json:"tokenBudget"`
+	Priority    []prompt.Section `json:"priority"`
+	History     []PlaygroundTurn `json:"history"`
+	// KnowledgeBase is a snapshot, e.g. from GET /api/v1/knowledge-base, used instead of
+	// the live knowledge base.
+	KnowledgeBase *kb.KnowledgeBase `json:"knowledgeBase"`
+	MaxTokens     int               `json:"maxTokens"`
+	Temperature   *float32          `json:"temperature"`
+}
+
+// PlaygroundTurn is an earlier exchange fed into the playground prompt.
+type PlaygroundTurn struct {
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+}
+
+// PlaygroundResponse exposes every intermediate result of a playground run.
+type PlaygroundResponse struct {
+	Provider          string                 `json:"provider"`
+	Model             string                 `json:"model"`
+	KnowledgeBase     PlaygroundKnowledge    `json:"knowledgeBase"`
+	Prompt            string                 `json:"prompt"`
+	Sections          []prompt.SectionReport `json:"sections"`
+	Dropped           []prompt.Section       `json:"dropped"`
+	RawOutput         string                 `json:"rawOutput"`
+	Answer            string                 `json:"answer"`
+	Citations         []prompt.Reference     `json:"citations"`
+	RejectedCitations []string               `json:"rejectedCitations"`
+	Error             string                 `json:"error,omitempty"`
+	Timings           PlaygroundTimings      `json:"timings"`
+	Usage             PlaygroundUsage        `json:"usage"`
+}
+
+// PlaygroundKnowledge identifies the knowledge base a playground run used.
+type PlaygroundKnowledge struct {
+	// Source is "live" or "snapshot".
+	Source string `json:"source"`
+	ETag   string `json:"etag,omitempty"`
+}
+
+// PlaygroundTimings reports how long each pipeline stage took, in milliseconds.
+type PlaygroundTimings struct {
+	KnowledgeMS float64 `json:"knowledgeMs"`
+	PromptMS    float64 `json:"promptMs"`
+	ProviderMS  float64 `json:"providerMs"`
+	TotalMS     float64 `json:"totalMs"`
+}
+
+// PlaygroundUsage reports token usage. Estimated is true when the provider did not
+// report usage and the counts come from the prompt budgeter's estimate.
+type PlaygroundUsage struct {
+	PromptTokens int  `json:"promptTokens"`
+	OutputTokens int  `json:"outputTokens"`
+	TotalTokens  int  `json:"totalTokens"`
+	Budget       int  `json:"budget"`
+	Estimated    bool `json:"estimated"`
+}
+
+// HandlePlayground runs a question through prompt assembly and the chosen provider and
+// returns the prompt, raw provider output, timings and token usage. Nothing is cached,
+// stored in chat history or recorded in analytics.
+func (h *ChatHandler) HandlePlayground(c *gin.Context) {
+	var payload PlaygroundRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "question field is required", nil)
+		return
+	}
+	if fields := validatePlayground(payload); len(fields) > 0 {
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "invalid playground request", fields)
+		return
+	}
+
+	selection, _ := h.selectProvider(c.Request.Context())
+	if payload.Provider != "" || payload.Model != "" {
+		if h.resolver == nil {
+			httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "provider selection is not available", nil)
+			return
+		}
+		name := payload.Provider
+		if name == "" {
+			name = selection.Name
+		}
+		resolved, err := h.resolver.Resolve(name, payload.Model)
+		if err != nil {
+			httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "invalid provider", map[string]string{"provider": err.Error()})
+			return
+		}
+		selection = resolved
+	}
+	if selection.Provider == nil {
+		httpapi.RespondError(c, http.StatusServiceUnavailable, httpapi.ErrorCodeExternal, "no AI provider configured", nil)
+		return
+	}
+
+	started := time.Now()
+	response := PlaygroundResponse{
+		Provider:          selection.Name,
+		Model:             selection.Model,
+		Citations:         make([]prompt.Reference, 0),
+		RejectedCitations: make([]string, 0),
+	}
+
+	var base kb.KnowledgeBase
+	if payload.KnowledgeBase != nil {
+		base = *payload.KnowledgeBase
+		response.KnowledgeBase.Source = "snapshot"
+	} else {
+		live, etag, _, err := h.knowledge.Get(c.Request.Context())
+		if err != nil {
+			httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "failed to load knowledge base", nil)
+			return
+		}
+		base = live
+		response.KnowledgeBase = PlaygroundKnowledge{Source: "live", ETag: etag}
+	}
+	knowledgeDone := time.Now()
+
+	turns := make([]prompt.Turn, 0, len(payload.History))
+	for _, turn := range payload.History {
+		turns = append(turns, prompt.Turn{Question: turn.Question, Answer: turn.Answer})
+	}
+	assembled := prompt.Assemble(base, payload.Question, prompt.Options{
+		TokenBudget:  payload.TokenBudget,
+		Priority:     payload.Priority,
+		History:      turns,
+		Instructions: payload.Template,
+	})
+	promptDone := time.Now()
+	response.Prompt = assembled.Text
+	response.Sections = assembled.Sections
+	response.Dropped = assembled.Dropped
+
+	maxTokens := chatMaxTokens
+	if payload.MaxTokens > 0 {
+		maxTokens = payload.MaxTokens
+	}
+	var temperature float32 = chatTemperature
+	if payload.Temperature != nil {
+		temperature = *payload.Temperature
+	}
+	resp, err := selection.Provider.Generate(c.Request.Context(), ai.Request{
+		Prompt:      assembled.Text,
+		MaxTokens:   maxTokens,
+		Temperature: temperature,
+	})
+	providerDone := time.Now()
+	if err != nil {
+		response.Error = err.Error()
+	} else {
+		response.RawOutput = resp.Text
+		answer, citations, rejected := prompt.Cite(strings.TrimSpace(resp.Text), assembled.References)
+		response.Answer = answer
+		response.Citations = citations
+		if len(rejected) > 0 {
+			response.RejectedCitations = rejected
+		}
+	}
+
+	response.Usage = PlaygroundUsage{
+		PromptTokens: resp.Usage.PromptTokens,
+		OutputTokens: resp.Usage.OutputTokens,
+		TotalTokens:  resp.Usage.TotalTokens,
+		Budget:       assembled.Budget,
+	}
+	if response.Usage.TotalTokens == 0 {
+		response.Usage.PromptTokens = assembled.EstimatedTokens
+		response.Usage.OutputTokens = prompt.EstimateTokens(resp.Text)
+		response.Usage.TotalTokens = response.Usage.PromptTokens + response.Usage.OutputTokens
+		response.Usage.Estimated = true
+	}
+	response.Timings = PlaygroundTimings{
+		KnowledgeMS: milliseconds(knowledgeDone.Sub(started)),
+		PromptMS:    milliseconds(promptDone.Sub(knowledgeDone)),
+		ProviderMS:  milliseconds(providerDone.Sub(promptDone)),
+		TotalMS:     milliseconds(time.Since(started)),
+	}
+
+	httpapi.RespondData(c, http.StatusOK, response)
+}
+
+// validatePlayground checks the tunables that are not covered by binding tags.
+func validatePlayground(payload PlaygroundRequest) map[string]string {
+	fields := make(map[string]string)
+	if strings.TrimSpace(payload.Question) == "" {
+		fields["question"] = "must not be empty"
+	}
+	if payload.TokenBudget < 0 {
+		fields["tokenBudget"] = "must not be negative"
+	}
+	if payload.MaxTokens < 0 || payload.MaxTokens > playgroundMaxTokensLimit {
+		fields["maxTokens"] = "must be between 0 and 8192"
+	}
+	if payload.Temperature != nil && (*payload.Temperature < 0 || *payload.Temperature > playgroundMaxTemperature) {
+		fields["temperature"] = "must be between 0 and 2"
+	}
+	if len([]rune(payload.Template)) > playgroundMaxTemplateRunes {
+		fields["template"] = "must be at most 2000 characters"
+	}
+	known := make(map[prompt.Section]bool, len(prompt.DefaultPriority))
+	for _, section := range prompt.DefaultPriority {
+		known[section] = true
+	}
+	for _, section := range payload.Priority {
+		if !known[section] {
+			fields["priority"] = "unknown section " + string(section)
+			break
+		}
+	}
+	return fields
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
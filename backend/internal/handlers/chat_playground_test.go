@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tanydotai/tanyai/backend/internal/ai"
+	"github.com/tanydotai/tanyai/backend/internal/services/kb"
+)
+
+// recordingProvider remembers the last request and answers with usage figures.
+type recordingProvider struct {
+	last ai.Request
+	text string
+}
+
+func (p *recordingProvider) Generate(_ context.Context, req ai.Request) (ai.Response, error) {
+	p.last = req
+	return ai.Response{Text: p.text, Usage: ai.Usage{PromptTokens: 40, OutputTokens: 5, TotalTokens: 45}}, nil
+}
+
+type stubResolver map[string]ProviderSelection
+
+func (s stubResolver) Resolve(name, model string) (ProviderSelection, error) {
+	selection, ok := s[name]
+	if !ok {
+		return ProviderSelection{}, errors.New("unsupported provider")
+	}
+	selection.Model = model
+	return selection, nil
+}
+
+func postPlayground(t *testing.T, handler *ChatHandler, body string) (*httptest.ResponseRecorder, PlaygroundResponse) {
+	t.Helper()
+	engine := gin.New()
+	engine.POST("/playground", handler.HandlePlayground)
+	req := httptest.NewRequest(http.MethodPost, "/playground", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, req)
+
+	var envelope struct {
+		Data PlaygroundResponse `json:"data"`
+	}
+	if res.Code == http.StatusOK {
+		if err := json.Unmarshal(res.Body.Bytes(), &envelope); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+	}
+	return res, envelope.Data
+}
+
+func TestHandlePlaygroundRunsChosenProviderOnSnapshot(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	live := &stubKnowledge{base: kb.KnowledgeBase{Profile: kb.Profile{Name: "Live"}}}
+	chosen := &recordingProvider{text: "Snapshot answer [prj:000000]"}
+	history := &historyRecorder{}
+	handler := NewChatHandler(live, history, "default-model", &stubProvider{response: "default"}, "mock", nil)
+	handler.SetProviderResolver(stubResolver{"gemini": {Provider: chosen, Name: "gemini"}})
+
+	body := `{
+		"question": "Apa layananmu?",
+		"provider": "gemini",
+		"model": "gemini-2.5-flash",
+		"template": "Answer in English.",
+		"tokenBudget": 500,
+		"maxTokens": 256,
+		"temperature": 0.2,
+		"knowledgeBase": {"profile": {"name": "Snapshot"}, "services": [{"id": "s1", "name": "Audit"}]}
+	}`
+	res, payload := postPlayground(t, handler, body)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Code, res.Body.String())
+	}
+
+	if payload.Provider != "gemini" || payload.Model != "gemini-2.5-flash" {
+		t.Fatalf("expected chosen provider and model, got %s/%s", payload.Provider, payload.Model)
+	}
+	if payload.KnowledgeBase.Source != "snapshot" {
+		t.Fatalf("expected snapshot knowledge base, got %+v", payload.KnowledgeBase)
+	}
+	if !strings.Contains(payload.Prompt, "Snapshot") || strings.Contains(payload.Prompt, "Live") {
+		t.Fatalf("prompt should be built from the snapshot:\n%s", payload.Prompt)
+	}
+	if !strings.Contains(payload.Prompt, "Instruksi: Answer in English.") {
+		t.Fatalf("prompt should use the template:\n%s", payload.Prompt)
+	}
+	if chosen.last.Prompt != payload.Prompt || chosen.last.MaxTokens != 256 || chosen.last.Temperature != 0.2 {
+		t.Fatalf("unexpected provider request %+v", chosen.last)
+	}
+	if payload.RawOutput != "Snapshot answer [prj:000000]" || payload.Answer != "Snapshot answer" {
+		t.Fatalf("expected raw and cleaned output, got %q / %q", payload.RawOutput, payload.Answer)
+	}
+	if len(payload.RejectedCitations) != 1 || payload.RejectedCitations[0] != "prj:000000" {
+		t.Fatalf("expected rejected citation, got %v", payload.RejectedCitations)
+	}
+	if payload.Usage.TotalTokens != 45 || payload.Usage.Estimated || payload.Usage.Budget != 500 {
+		t.Fatalf("expected provider usage, got %+v", payload.Usage)
+	}
+	if payload.Timings.TotalMS < payload.Timings.ProviderMS {
+		t.Fatalf("unexpected timings %+v", payload.Timings)
+	}
+	if len(history.records) != 0 {
+		t.Fatalf("playground runs must not be stored in chat history")
+	}
+}
+
+func TestHandlePlaygroundDefaultsToLiveKnowledgeAndEstimatesUsage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	live := &stubKnowledge{base: kb.KnowledgeBase{Profile: kb.Profile{Name: "Live"}}}
+	handler := NewChatHandler(live, nil, "default-model", &stubProvider{response: "Halo dari live"}, "mock", nil)
+
+	res, payload := postPlayground(t, handler, `{"question":"Siapa kamu?"}`)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Code, res.Body.String())
+	}
+	if payload.KnowledgeBase.Source != "live" || payload.KnowledgeBase.ETag != "\"etag\"" {
+		t.Fatalf("expected live knowledge base, got %+v", payload.KnowledgeBase)
+	}
+	if payload.Provider != "mock" || payload.Answer != "Halo dari live" {
+		t.Fatalf("expected default provider answer, got %+v", payload)
+	}
+	if !payload.Usage.Estimated || payload.Usage.PromptTokens == 0 || payload.Usage.OutputTokens == 0 {
+		t.Fatalf("expected estimated usage, got %+v", payload.Usage)
+	}
+}
+
+func TestHandlePlaygroundValidatesRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	live := &stubKnowledge{base: kb.KnowledgeBase{}}
+	handler := NewChatHandler(live, nil, "default-model", &stubProvider{err: errors.New("boom")}, "mock", nil)
+	handler.SetProviderResolver(stubResolver{})
+
+	for _, body := range []string{
+		`{"question":"  "}`,
+		`{"question":"Hai","temperature":3}`,
+		`{"question":"Hai","priority":["secrets"]}`,
+		`{"question":"Hai","provider":"openai"}`,
+	} {
+		if res, _ := postPlayground(t, handler, body); res.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", body, res.Code)
+		}
+	}
+
+	res, payload := postPlayground(t, handler, `{"question":"Hai"}`)
+	if res.Code != http.StatusOK || payload.Error != "boom" || payload.Prompt == "" {
+		t.Fatalf("provider errors should be reported alongside the prompt, got %d %+v", res.Code, payload)
+	}
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tanydotai/tanyai/backend/internal/ai"
+	"github.com/tanydotai/tanyai/backend/internal/services/kb"
+)
+
+const chatCassetteDir = "testdata/cassettes/chat"
+
+// TestHandleChatReplaysCassette pins the prompt the chat handler sends for a realistic
+// knowledge base: any change to prompt assembly misses the cassette and fails here.
+// After an intended prompt change, re-record with
+//
+//	UPDATE_CASSETTES=1 go test ./internal/handlers -run TestHandleChatReplaysCassette
+//
+// and review the cassette diff.
+func TestHandleChatReplaysCassette(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const answer = "Landing Page mulai dari IDR 3500000 dan bisa selesai dalam 2 minggu."
+
+	var provider ai.Provider
+	if os.Getenv("UPDATE_CASSETTES") != "" {
+		if err := os.RemoveAll(chatCassetteDir); err != nil {
+			t.Fatalf("clear cassettes: %v", err)
+		}
+		provider = ai.NewRecorder(&stubProvider{response: answer}, chatCassetteDir)
+	} else {
+		replay, err := ai.NewReplay(chatCassetteDir)
+		if err != nil {
+			t.Fatalf("load cassettes: %v", err)
+		}
+		provider = replay
+	}
+
+	knowledge := &stubKnowledge{base: kb.KnowledgeBase{
+		Profile: kb.Profile{Name: "Rina Pratama", Title: "Full-stack Developer", Location: "Bandung"},
+		Skills:  []kb.Skill{{Name: "Go"}, {Name: "TypeScript"}},
+		Services: []kb.Service{
+			{ID: "svc-landing", Name: "Landing Page", Currency: "IDR", DurationLabel: "1-2 minggu", PriceRange: []string{"IDR 3500000", "IDR 6000000"}, Order: 1},
+			{ID: "svc-chatbot", Name: "Chatbot AI", Currency: "IDR", DurationLabel: "3-4 minggu", PriceRange: []string{"IDR 12000000"}, Order: 2},
+		},
+		Projects: []kb.Project{
+			{ID: "prj-kopi", Title: "Toko Online Kopi", TechStack: []string{"Go"}, ProjectURL: "https://kopi.example.com", IsFeatured: true, Order: 1},
+		},
+	}}
+	history := &historyRecorder{}
+	handler := NewChatHandler(knowledge, history, "gemini-test", provider, "replay", nil)
+	engine := gin.New()
+	engine.POST("/chat", handler.HandleChat)
+
+	req := httptest.NewRequest(http.MethodPost, "/chat", bytes.NewBufferString(`{"question":"Berapa biaya landing page?"}`))
+	req.Header.Set("Content-Type", "application/json")
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", res.Code)
+	}
+	var payload ChatResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if payload.Answer != answer {
+		hash := ai.PromptHash(history.records[0].Prompt)
+		t.Fatalf("prompt %s is not in %s; the chat prompt changed. Re-record with UPDATE_CASSETTES=1 if intended.\nprompt:\n%s",
+			hash, filepath.Join(chatCassetteDir, hash+".json"), history.records[0].Prompt)
+	}
+}
@@ -0,0 +1,339 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tanydotai/tanyai/backend/internal/httpapi"
+	"github.com/tanydotai/tanyai/backend/internal/services/kb"
+)
+
+const (
+	defaultContentLimit = 20
+	maxContentLimit     = 100
+)
+
+// ContentHandler exposes read-only portfolio resources backed by the knowledge base cache.
+type ContentHandler struct {
+	knowledge KnowledgeService
+}
+
+// NewContentHandler constructs a ContentHandler.
+func NewContentHandler(knowledge KnowledgeService) *ContentHandler {
+	return &ContentHandler{knowledge: knowledge}
+}
+
+type contentPage struct {
+	Items      []map[string]any `json:"items"`
+	Limit      int              `json:"limit"`
+	NextCursor string           `json:"nextCursor,omitempty"`
+}
+
+type pageParams struct {
+	limit  int
+	after  string
+	fields map[string]bool
+}
+
+// HandleProjects lists projects filtered by category, tech stack and featured flag.
+func (h *ContentHandler) HandleProjects(c *gin.Context) {
+	params, ok := parsePageParams(c)
+	if !ok {
+		return
+	}
+	featured, ok := parseOptionalBool(c, "featured")
+	if !ok {
+		return
+	}
+	category := strings.TrimSpace(c.Query("category"))
+	tech := splitQueryList(c.Query("tech"))
+
+	h.respondList(c, params, func(base kb.KnowledgeBase) []keyedItem {
+		items := make([]keyedItem, 0, len(base.Projects))
+		for _, project := range base.Projects {
+			if category != "" && !strings.EqualFold(project.Category, category) {
+				continue
+			}
+			if featured != nil && project.IsFeatured != *featured {
+				continue
+			}
+			if len(tech) > 0 && !containsAnyFold(project.TechStack, tech) {
+				continue
+			}
+			items = append(items, keyedItem{key: project.ID, value: project})
+		}
+		return items
+	})
+}
+
+// HandleProject returns a single project by ID.
+func (h *ContentHandler) HandleProject(c *gin.Context) {
+	fields := parseFields(c.Query("fields"))
+	id := strings.TrimSpace(c.Param("id"))
+
+	base, ok := h.load(c)
+	if !ok {
+		return
+	}
+	for _, project := range base.Projects {
+		if project.ID == id {
+			item, err := selectFields(project, fields)
+			if err != nil {
+				httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "failed to encode project", nil)
+				return
+			}
+			h.respondCached(c, gin.H{"data": item})
+			return
+		}
+	}
+	httpapi.RespondError(c, http.StatusNotFound, httpapi.ErrorCodeNotFound, "project not found", nil)
+}
+
+// HandleServices lists active services.
+func (h *ContentHandler) HandleServices(c *gin.Context) {
+	params, ok := parsePageParams(c)
+	if !ok {
+		return
+	}
+	h.respondList(c, params, func(base kb.KnowledgeBase) []keyedItem {
+		items := make([]keyedItem, 0, len(base.Services))
+		for _, service := range base.Services {
+			items = append(items, keyedItem{key: service.ID, value: service})
+		}
+		return items
+	})
+}
+
+// HandleSkills lists skills in display order.
+func (h *ContentHandler) HandleSkills(c *gin.Context) {
+	params, ok := parsePageParams(c)
+	if !ok {
+		return
+	}
+	h.respondList(c, params, func(base kb.KnowledgeBase) []keyedItem {
+		items := make([]keyedItem, 0, len(base.Skills))
+		for _, skill := range base.Skills {
+			items = append(items, keyedItem{key: skill.Name, value: skill})
+		}
+		return items
+	})
+}
+
+// HandlePosts lists external posts filtered by kind and source.
+func (h *ContentHandler) HandlePosts(c *gin.Context) {
+	params, ok := parsePageParams(c)
+	if !ok {
+		return
+	}
+	kinds := splitQueryList(c.Query("kind"))
+	source := strings.TrimSpace(c.Query("source"))
+
+	h.respondList(c, params, func(base kb.KnowledgeBase) []keyedItem {
+		items := make([]keyedItem, 0, len(base.Posts))
+		for _, post := range base.Posts {
+			if len(kinds) > 0 && !containsAnyFold([]string{post.Kind}, kinds) {
+				continue
+			}
+			if source != "" && !strings.EqualFold(post.Source, source) {
+				continue
+			}
+			items = append(items, keyedItem{key: post.ID, value: post})
+		}
+		return items
+	})
+}
+
+type keyedItem struct {
+	key   string
+	value any
+}
+
+func (h *ContentHandler) load(c *gin.Context) (kb.KnowledgeBase, bool) {
+	base, _, cacheHit, err := h.knowledge.Get(c.Request.Context())
+	if err != nil {
+		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "failed to load knowledge base", nil)
+		return kb.KnowledgeBase{}, false
+	}
+	c.Set("kb_cache_hit", cacheHit)
+	return base, true
+}
+
+func (h *ContentHandler) respondList(c *gin.Context, params pageParams, collect func(kb.KnowledgeBase) []keyedItem) {
+	base, ok := h.load(c)
+	if !ok {
+		return
+	}
+	items := collect(base)
+
+	start := 0
+	if params.after != "" {
+		start = -1
+		for i, item := range items {
+			if item.key == params.after {
+				start = i + 1
+				break
+			}
+		}
+		if start < 0 {
+			httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "cursor is no longer valid", nil)
+			return
+		}
+	}
+
+	end := start + params.limit
+	if end > len(items) {
+		end = len(items)
+	}
+
+	page := contentPage{Items: make([]map[string]any, 0, end-start), Limit: params.limit}
+	for _, item := range items[start:end] {
+		selected, err := selectFields(item.value, params.fields)
+		if err != nil {
+			httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "failed to encode items", nil)
+			return
+		}
+		page.Items = append(page.Items, selected)
+	}
+	if end < len(items) && end > start {
+		page.NextCursor = encodeCursor(items[end-1].key)
+	}
+
+	h.respondCached(c, page)
+}
+
+// respondCached writes payload with an ETag derived from its own content, so each
+// resource (and each filtered view) revalidates independently of the rest of the knowledge base.
+func (h *ContentHandler) respondCached(c *gin.Context, payload any) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "failed to encode response", nil)
+		return
+	}
+	sum := sha256.Sum256(body)
+	etag := "W/\"" + hex.EncodeToString(sum[:16]) + "\""
+
+	maxAge := int(h.knowledge.CacheTTL().Seconds())
+	if maxAge <= 0 {
+		maxAge = 60
+	}
+	c.Header("Cache-Control", "public, max-age="+strconv.Itoa(maxAge))
+	c.Header("ETag", etag)
+	if match := c.GetHeader("If-None-Match"); match != "" && match == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+func parsePageParams(c *gin.Context) (pageParams, bool) {
+	params := pageParams{limit: defaultContentLimit, fields: parseFields(c.Query("fields"))}
+
+	if raw := strings.TrimSpace(c.Query("limit")); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "limit must be a positive integer", nil)
+			return pageParams{}, false
+		}
+		if limit > maxContentLimit {
+			limit = maxContentLimit
+		}
+		params.limit = limit
+	}
+
+	if raw := strings.TrimSpace(c.Query("cursor")); raw != "" {
+		after, err := decodeCursor(raw)
+		if err != nil {
+			httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, "invalid cursor", nil)
+			return pageParams{}, false
+		}
+		params.after = after
+	}
+
+	return params, true
+}
+
+func parseOptionalBool(c *gin.Context, key string) (*bool, bool) {
+	raw := strings.TrimSpace(c.Query(key))
+	if raw == "" {
+		return nil, true
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		httpapi.RespondError(c, http.StatusBadRequest, httpapi.ErrorCodeValidation, key+" must be a boolean", nil)
+		return nil, false
+	}
+	return &value, true
+}
+
+func parseFields(raw string) map[string]bool {
+	names := splitQueryList(raw)
+	if len(names) == 0 {
+		return nil
+	}
+	fields := make(map[string]bool, len(names))
+	for _, name := range names {
+		fields[name] = true
+	}
+	return fields
+}
+
+func splitQueryList(raw string) []string {
+	var values []string
+	for _, part := range strings.Split(raw, ",") {
+		if trimmed := strings.TrimSpace(part); trimmed != "" {
+			values = append(values, trimmed)
+		}
+	}
+	return values
+}
+
+func containsAnyFold(values, wanted []string) bool {
+	for _, value := range values {
+		for _, candidate := range wanted {
+			if strings.EqualFold(value, candidate) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// selectFields converts value to its JSON object form and keeps only the requested keys.
+// A nil field set keeps everything.
+func selectFields(value any, fields map[string]bool) (map[string]any, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var object map[string]any
+	if err := json.Unmarshal(raw, &object); err != nil {
+		return nil, err
+	}
+	if fields == nil {
+		return object, nil
+	}
+	for key := range object {
+		if !fields[key] {
+			delete(object, key)
+		}
+	}
+	return object, nil
+}
+
+func encodeCursor(key string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(key))
+}
+
+func decodeCursor(cursor string) (string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
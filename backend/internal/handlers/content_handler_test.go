@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tanydotai/tanyai/backend/internal/services/kb"
+)
+
+func newContentEngine() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	knowledge := &stubKnowledge{base: kb.KnowledgeBase{
+		Skills:   []kb.Skill{{Name: "Go"}, {Name: "React"}},
+		Services: []kb.Service{{ID: "svc-1", Name: "Consulting"}},
+		Projects: []kb.Project{
+			{ID: "p1", Title: "Shop", Category: "Web", TechStack: []string{"Go", "React"}, IsFeatured: true},
+			{ID: "p2", Title: "Bot", Category: "AI", TechStack: []string{"Python"}},
+			{ID: "p3", Title: "Dashboard", Category: "web", TechStack: []string{"Go"}},
+		},
+		Posts: []kb.Post{
+			{ID: "a1", Title: "Launch", Kind: "post", Source: "blog"},
+			{ID: "a2", Title: "Talk", Kind: "video", Source: "youtube"},
+		},
+	}}
+	handler := NewContentHandler(knowledge)
+	engine := gin.New()
+	engine.GET("/projects", handler.HandleProjects)
+	engine.GET("/projects/:id", handler.HandleProject)
+	engine.GET("/posts", handler.HandlePosts)
+	return engine
+}
+
+func getContent(t *testing.T, engine *gin.Engine, target string, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, req)
+	return res
+}
+
+func TestHandleProjectsFiltersAndPaginates(t *testing.T) {
+	engine := newContentEngine()
+
+	res := getContent(t, engine, "/projects?category=web&tech=go&limit=1&fields=id,title", nil)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Code, res.Body.String())
+	}
+	var page contentPage
+	if err := json.Unmarshal(res.Body.Bytes(), &page); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(page.Items) != 1 || page.Items[0]["id"] != "p1" {
+		t.Fatalf("unexpected first page: %+v", page.Items)
+	}
+	if _, ok := page.Items[0]["techStack"]; ok {
+		t.Fatalf("expected unselected fields to be omitted")
+	}
+	if page.NextCursor == "" {
+		t.Fatalf("expected next cursor")
+	}
+
+	res = getContent(t, engine, "/projects?category=web&tech=go&limit=1&cursor="+page.NextCursor, nil)
+	page = contentPage{}
+	if err := json.Unmarshal(res.Body.Bytes(), &page); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(page.Items) != 1 || page.Items[0]["id"] != "p3" || page.NextCursor != "" {
+		t.Fatalf("unexpected second page: %+v", page)
+	}
+}
+
+func TestHandleProjectsRejectsInvalidParams(t *testing.T) {
+	engine := newContentEngine()
+	for _, target := range []string{"/projects?featured=maybe", "/projects?limit=-1", "/projects?cursor=%21%21"} {
+		if res := getContent(t, engine, target, nil); res.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected status 400, got %d", target, res.Code)
+		}
+	}
+}
+
+func TestHandleProjectSupportsConditionalRequests(t *testing.T) {
+	engine := newContentEngine()
+
+	res := getContent(t, engine, "/projects/p2", nil)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", res.Code)
+	}
+	etag := res.Header().Get("ETag")
+	if etag == "" || res.Header().Get("Cache-Control") == "" {
+		t.Fatalf("expected caching headers")
+	}
+
+	res = getContent(t, engine, "/projects/p2", map[string]string{"If-None-Match": etag})
+	if res.Code != http.StatusNotModified {
+		t.Fatalf("expected status 304, got %d", res.Code)
+	}
+
+	if other := getContent(t, engine, "/projects/p1", nil); other.Header().Get("ETag") == etag {
+		t.Fatalf("expected per-resource ETags")
+	}
+	if missing := getContent(t, engine, "/projects/unknown", nil); missing.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", missing.Code)
+	}
+}
+
+func TestHandlePostsFiltersByKind(t *testing.T) {
+	engine := newContentEngine()
+
+	res := getContent(t, engine, "/posts?kind=video", nil)
+	var page contentPage
+	if err := json.Unmarshal(res.Body.Bytes(), &page); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(page.Items) != 1 || page.Items[0]["id"] != "a2" {
+		t.Fatalf("unexpected posts: %+v", page.Items)
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tanydotai/tanyai/backend/internal/httpapi"
+	"github.com/tanydotai/tanyai/backend/internal/storage"
+)
+
+// FileStore is the subset of storage.LocalStorage used to serve files.
+type FileStore interface {
+	Open(key string) (*os.File, os.FileInfo, error)
+	SigningRequired() bool
+	Verify(key, expires, signature string) (time.Time, error)
+	CacheMaxAge() time.Duration
+}
+
+// FilesHandler serves objects written by the local storage driver.
+type FilesHandler struct {
+	store FileStore
+	now   func() time.Time
+}
+
+// NewFilesHandler constructs a FilesHandler.
+func NewFilesHandler(store FileStore) *FilesHandler {
+	return &FilesHandler{store: store, now: time.Now}
+}
+
+// Serve streams the file named by the *key route parameter. Conditional and range requests
+// are handled by http.ServeContent.
+func (h *FilesHandler) Serve(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	if err := storage.ValidateKey(key); err != nil {
+		httpapi.RespondError(c, http.StatusNotFound, httpapi.ErrorCodeNotFound, "file not found", nil)
+		return
+	}
+
+	cacheControl := fmt.Sprintf("public, max-age=%d, immutable", int(h.store.CacheMaxAge().Seconds()))
+	if h.store.SigningRequired() {
+		expiry, err := h.store.Verify(key, c.Query("expires"), c.Query("signature"))
+		if err != nil {
+			message := "invalid signature"
+			if errors.Is(err, storage.ErrURLExpired) {
+				message = "link expired"
+			}
+			httpapi.RespondError(c, http.StatusForbidden, httpapi.ErrorCodeForbidden, message, nil)
+			return
+		}
+		// Never let a cache keep the file beyond the link's lifetime.
+		maxAge := int(expiry.Sub(h.now()).Seconds())
+		cacheControl = fmt.Sprintf("private, max-age=%d", min(maxAge, int(h.store.CacheMaxAge().Seconds())))
+	}
+
+	file, info, err := h.store.Open(key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+			httpapi.RespondError(c, http.StatusNotFound, httpapi.ErrorCodeNotFound, "file not found", nil)
+			return
+		}
+		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "failed to read file", nil)
+		return
+	}
+	defer file.Close()
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	header := c.Writer.Header()
+	header.Set("Content-Type", contentType)
+	header.Set("Cache-Control", cacheControl)
+	header.Set("ETag", fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size()))
+	header.Set("X-Content-Type-Options", "nosniff")
+	if strings.HasPrefix(contentType, "image/svg") {
+		// Sanitised SVGs are still documents; keep them from running anything if opened directly.
+		header.Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; sandbox")
+	}
+	http.ServeContent(c.Writer, c.Request, "", info.ModTime(), file)
+}
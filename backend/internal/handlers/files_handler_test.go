@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tanydotai/tanyai/backend/internal/config"
+	"github.com/tanydotai/tanyai/backend/internal/storage"
+)
+
+func newFilesEngine(t *testing.T, signingKey string) (*gin.Engine, *storage.LocalStorage) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	store, err := storage.NewLocalStorage(config.LocalStorageConfig{
+		Dir:         t.TempDir(),
+		PublicURL:   "http://example.com/files",
+		SigningKey:  signingKey,
+		URLTTL:      time.Hour,
+		CacheMaxAge: 24 * time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("new local storage: %v", err)
+	}
+	handler := NewFilesHandler(store)
+	engine := gin.New()
+	engine.GET("/files/*key", handler.Serve)
+	engine.HEAD("/files/*key", handler.Serve)
+	return engine, store
+}
+
+func TestFilesHandlerServesWithCachingHeaders(t *testing.T) {
+	engine, store := newFilesEngine(t, "")
+	if _, err := store.Put(context.Background(), "uploads/a.webp", []byte("RIFFdata"), "image/webp"); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+
+	res := getContent(t, engine, "/files/uploads/a.webp", nil)
+	if res.Code != http.StatusOK || res.Body.String() != "RIFFdata" {
+		t.Fatalf("unexpected response %d: %s", res.Code, res.Body.String())
+	}
+	if got := res.Header().Get("Content-Type"); got != "image/webp" {
+		t.Fatalf("unexpected content type %s", got)
+	}
+	if got := res.Header().Get("Cache-Control"); got != "public, max-age=86400, immutable" {
+		t.Fatalf("unexpected cache control %s", got)
+	}
+	if res.Header().Get("X-Content-Type-Options") != "nosniff" || res.Header().Get("Last-Modified") == "" {
+		t.Fatalf("missing headers: %v", res.Header())
+	}
+
+	etag := res.Header().Get("ETag")
+	res = getContent(t, engine, "/files/uploads/a.webp", map[string]string{"If-None-Match": etag})
+	if res.Code != http.StatusNotModified {
+		t.Fatalf("expected 304, got %d", res.Code)
+	}
+	res = getContent(t, engine, "/files/uploads/a.webp", map[string]string{"Range": "bytes=0-3"})
+	if res.Code != http.StatusPartialContent || res.Body.String() != "RIFF" {
+		t.Fatalf("expected partial content, got %d: %s", res.Code, res.Body.String())
+	}
+
+	for _, target := range []string{"/files/uploads/missing.png", "/files/uploads/../../etc/passwd", "/files/uploads/%2e%2e/secret"} {
+		if res := getContent(t, engine, target, nil); res.Code != http.StatusNotFound {
+			t.Fatalf("expected %s to be refused, got %d", target, res.Code)
+		}
+	}
+}
+
+func TestFilesHandlerRequiresValidSignature(t *testing.T) {
+	engine, store := newFilesEngine(t, "a-signing-key-that-is-long-enough-1234")
+	stable, err := store.Put(context.Background(), "uploads/a.png", []byte("png"), "image/png")
+	if err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	parsed, _ := url.Parse(store.SignURL(stable))
+
+	res := getContent(t, engine, "/files/uploads/a.png?"+parsed.RawQuery, nil)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected signed url to be served, got %d: %s", res.Code, res.Body.String())
+	}
+	if got := res.Header().Get("Cache-Control"); !strings.HasPrefix(got, "private, max-age=") || strings.HasSuffix(got, "=86400") {
+		t.Fatalf("expected cache lifetime bounded by expiry, got %s", got)
+	}
+
+	if res := getContent(t, engine, "/files/uploads/a.png", nil); res.Code != http.StatusForbidden {
+		t.Fatalf("expected unsigned request to be forbidden, got %d", res.Code)
+	}
+	tampered := strings.Replace(parsed.RawQuery, "signature=", "signature=x", 1)
+	if res := getContent(t, engine, "/files/uploads/a.png?"+tampered, nil); res.Code != http.StatusForbidden {
+		t.Fatalf("expected tampered signature to be forbidden, got %d", res.Code)
+	}
+}
+
+func TestFilesHandlerServesSignedChunkURL(t *testing.T) {
+	engine, store := newFilesEngine(t, "a-signing-key-that-is-long-enough-1234")
+	stable, err := store.Put(context.Background(), "documents/2025/02/10/brief.pdf", []byte("%PDF-1.7"), "application/pdf")
+	if err != nil {
+		t.Fatalf("put: %v", err)
+	}
+
+	// Document citations point at a chunk anchor; the fragment must stay out of the key.
+	signed := store.SignURL(stable + "#chunk-2")
+	parsed, err := url.Parse(signed)
+	if err != nil {
+		t.Fatalf("parse url: %v", err)
+	}
+	if parsed.Fragment != "chunk-2" || parsed.Path != "/files/documents/2025/02/10/brief.pdf" {
+		t.Fatalf("expected the fragment after the signature, got %s", signed)
+	}
+	if store.StableURL(signed) != stable+"#chunk-2" {
+		t.Fatalf("expected the stable url to keep the fragment, got %s", store.StableURL(signed))
+	}
+
+	res := getContent(t, engine, parsed.Path+"?"+parsed.RawQuery, nil)
+	if res.Code != http.StatusOK || res.Body.String() != "%PDF-1.7" {
+		t.Fatalf("expected the signed chunk url to be served, got %d: %s", res.Code, res.Body.String())
+	}
+}
@@ -10,6 +10,7 @@ const (
 	ErrorCodeNotFound        ErrorCode = "NOT_FOUND"
 	ErrorCodeUnauthorized    ErrorCode = "UNAUTHORIZED"
 	ErrorCodeForbidden       ErrorCode = "FORBIDDEN"
+	ErrorCodeConflict        ErrorCode = "CONFLICT"
 	ErrorCodeTooManyRequests ErrorCode = "TOO_MANY_REQUESTS"
 	ErrorCodeInternal        ErrorCode = "INTERNAL"
 	ErrorCodeExternal        ErrorCode = "EXTERNAL_ERROR"
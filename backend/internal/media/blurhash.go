@@ -0,0 +1,129 @@
+package media
+
+import (
+	"image"
+	"math"
+	"strings"
+)
+
+const base83Chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// Blurhash encodes img as a blurhash string (https://blurha.sh) using the given number
+// of horizontal and vertical components (1-9 each). Callers should downscale large
+// images first; the cost grows with the pixel count.
+func Blurhash(img image.Image, componentsX, componentsY int) string {
+	componentsX = clampInt(componentsX, 1, 9)
+	componentsY = clampInt(componentsY, 1, 9)
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return ""
+	}
+
+	linear := make([][3]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			linear[y*width+x] = [3]float64{
+				srgbToLinear(int(r >> 8)),
+				srgbToLinear(int(g >> 8)),
+				srgbToLinear(int(b >> 8)),
+			}
+		}
+	}
+
+	factors := make([][3]float64, 0, componentsX*componentsY)
+	for j := 0; j < componentsY; j++ {
+		for i := 0; i < componentsX; i++ {
+			normalisation := 2.0
+			if i == 0 && j == 0 {
+				normalisation = 1
+			}
+			var factor [3]float64
+			for y := 0; y < height; y++ {
+				for x := 0; x < width; x++ {
+					basis := normalisation *
+						math.Cos(math.Pi*float64(i)*float64(x)/float64(width)) *
+						math.Cos(math.Pi*float64(j)*float64(y)/float64(height))
+					px := linear[y*width+x]
+					factor[0] += basis * px[0]
+					factor[1] += basis * px[1]
+					factor[2] += basis * px[2]
+				}
+			}
+			scale := 1 / float64(width*height)
+			factors = append(factors, [3]float64{factor[0] * scale, factor[1] * scale, factor[2] * scale})
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(encode83((componentsX-1)+(componentsY-1)*9, 1))
+
+	maximum := 1.0
+	if len(factors) > 1 {
+		actualMax := 0.0
+		for _, factor := range factors[1:] {
+			for _, v := range factor {
+				actualMax = math.Max(actualMax, math.Abs(v))
+			}
+		}
+		quantised := clampInt(int(math.Floor(actualMax*166-0.5)), 0, 82)
+		maximum = float64(quantised+1) / 166
+		sb.WriteString(encode83(quantised, 1))
+	} else {
+		sb.WriteString(encode83(0, 1))
+	}
+
+	dc := factors[0]
+	sb.WriteString(encode83(linearToSRGB(dc[0])<<16|linearToSRGB(dc[1])<<8|linearToSRGB(dc[2]), 4))
+	for _, factor := range factors[1:] {
+		sb.WriteString(encode83(encodeAC(factor, maximum), 2))
+	}
+	return sb.String()
+}
+
+func encodeAC(factor [3]float64, maximum float64) int {
+	quant := func(v float64) int {
+		return clampInt(int(math.Floor(signPow(v/maximum, 0.5)*9+9.5)), 0, 18)
+	}
+	return quant(factor[0])*19*19 + quant(factor[1])*19 + quant(factor[2])
+}
+
+func encode83(value, length int) string {
+	out := make([]byte, length)
+	for i := 1; i <= length; i++ {
+		digit := (value / int(math.Pow(83, float64(length-i)))) % 83
+		out[i-1] = base83Chars[digit]
+	}
+	return string(out)
+}
+
+func srgbToLinear(value int) float64 {
+	v := float64(value) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(value float64) int {
+	v := math.Max(0, math.Min(1, value))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(value, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(value), exp), value)
+}
+
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
@@ -0,0 +1,50 @@
+package media
+
+import (
+	"fmt"
+	"image"
+)
+
+// colorBits is the per-channel precision used when bucketing pixels.
+const colorBits = 4
+
+// DominantColor returns the most common colour of img as "#rrggbb". Pixels are bucketed
+// at reduced precision and the winning bucket is averaged; mostly transparent pixels are
+// ignored. Callers should downscale large images first.
+func DominantColor(img image.Image) string {
+	type bucket struct {
+		count   int
+		r, g, b int
+	}
+	buckets := map[int]*bucket{}
+	var best *bucket
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			if a < 0x8000 {
+				continue
+			}
+			// Undo alpha premultiplication before bucketing.
+			r8, g8, b8 := int(r*0xff/a), int(g*0xff/a), int(b*0xff/a)
+			shift := 8 - colorBits
+			key := (r8>>shift)<<(2*colorBits) | (g8>>shift)<<colorBits | b8>>shift
+			bk := buckets[key]
+			if bk == nil {
+				bk = &bucket{}
+				buckets[key] = bk
+			}
+			bk.count++
+			bk.r += r8
+			bk.g += g8
+			bk.b += b8
+			if best == nil || bk.count > best.count {
+				best = bk
+			}
+		}
+	}
+	if best == nil {
+		return ""
+	}
+	return fmt.Sprintf("#%02x%02x%02x", best.r/best.count, best.g/best.count, best.b/best.count)
+}
@@ -0,0 +1,256 @@
+// Package media post-processes uploaded raster images: it strips metadata, applies the
+// EXIF orientation, renders resized variants (with WebP copies when they are smaller) and
+// computes a blurhash and dominant color placeholder.
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // registers the WebP decoder
+)
+
+// Content types handled by the processor.
+const (
+	TypeJPEG = "image/jpeg"
+	TypePNG  = "image/png"
+	TypeWebP = "image/webp"
+)
+
+const (
+	defaultJPEGQuality = 82
+	blurhashWidth      = 32
+	blurhashComponentX = 4
+	blurhashComponentY = 3
+	paletteWidth       = 64
+)
+
+var (
+	// ErrUnsupportedType is returned for content the processor cannot decode.
+	ErrUnsupportedType = errors.New("media: unsupported image type")
+	// ErrTooManyPixels guards against decompression bombs.
+	ErrTooManyPixels = errors.New("media: image dimensions exceed limit")
+	// ErrInvalidImage is returned when the payload does not decode.
+	ErrInvalidImage = errors.New("media: invalid image")
+)
+
+// VariantSpec requests a variant no wider than Width pixels.
+type VariantSpec struct {
+	Name  string
+	Width int
+}
+
+// Options configures a Processor.
+type Options struct {
+	Variants    []VariantSpec
+	JPEGQuality int
+	// WebP adds a lossless WebP copy of each variant when it is smaller than the JPEG or
+	// PNG rendition.
+	WebP bool
+	// MaxPixels rejects images with more pixels than this; zero disables the check.
+	MaxPixels int
+}
+
+// Encoded is an encoded image.
+type Encoded struct {
+	Data        []byte
+	ContentType string
+}
+
+// Variant is a resized rendition of the upload. WebP is nil when no smaller WebP copy
+// could be produced.
+type Variant struct {
+	Name   string
+	Width  int
+	Height int
+	Image  Encoded
+	WebP   []byte
+}
+
+// Result is the outcome of processing one upload.
+type Result struct {
+	// Original is the upload without metadata, rotated upright when it carried an EXIF
+	// orientation.
+	Original      Encoded
+	Width         int
+	Height        int
+	Variants      []Variant
+	Blurhash      string
+	DominantColor string
+}
+
+// Processor renders uploads according to its options.
+type Processor struct {
+	opts Options
+}
+
+// NewProcessor constructs a Processor.
+func NewProcessor(opts Options) *Processor {
+	if opts.JPEGQuality <= 0 || opts.JPEGQuality > 100 {
+		opts.JPEGQuality = defaultJPEGQuality
+	}
+	return &Processor{opts: opts}
+}
+
+// Supports reports whether contentType can be processed.
+func Supports(contentType string) bool {
+	switch contentType {
+	case TypeJPEG, TypePNG, TypeWebP:
+		return true
+	}
+	return false
+}
+
+// Process strips metadata from data and renders the configured variants.
+func (p *Processor) Process(data []byte, contentType string) (*Result, error) {
+	if !Supports(contentType) {
+		return nil, ErrUnsupportedType
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if p.opts.MaxPixels > 0 && cfg.Width*cfg.Height > p.opts.MaxPixels {
+		return nil, ErrTooManyPixels
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+
+	result := &Result{}
+	orientation := 1
+	if contentType == TypeJPEG {
+		orientation = jpegOrientation(data)
+	}
+	if orientation != 1 {
+		img = orient(img, orientation)
+		encoded, err := p.encode(img)
+		if err != nil {
+			return nil, err
+		}
+		result.Original = encoded
+	} else {
+		stripped, err := stripMetadata(data, contentType)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+		}
+		result.Original = Encoded{Data: stripped, ContentType: contentType}
+	}
+
+	bounds := img.Bounds()
+	result.Width, result.Height = bounds.Dx(), bounds.Dy()
+
+	for _, spec := range p.opts.Variants {
+		variant, err := p.variant(img, spec)
+		if err != nil {
+			return nil, err
+		}
+		result.Variants = append(result.Variants, variant)
+	}
+
+	result.Blurhash = Blurhash(resize(img, blurhashWidth), blurhashComponentX, blurhashComponentY)
+	result.DominantColor = DominantColor(resize(img, paletteWidth))
+	return result, nil
+}
+
+func (p *Processor) variant(img image.Image, spec VariantSpec) (Variant, error) {
+	resized := resize(img, spec.Width)
+	bounds := resized.Bounds()
+	encoded, err := p.encode(resized)
+	if err != nil {
+		return Variant{}, err
+	}
+	variant := Variant{Name: spec.Name, Width: bounds.Dx(), Height: bounds.Dy(), Image: encoded}
+
+	if p.opts.WebP {
+		var buf bytes.Buffer
+		if err := nativewebp.Encode(&buf, resized, nil); err == nil && buf.Len() < len(encoded.Data) {
+			variant.WebP = buf.Bytes()
+		}
+	}
+	return variant, nil
+}
+
+// encode writes opaque images as JPEG and images with transparency as PNG.
+func (p *Processor) encode(img image.Image) (Encoded, error) {
+	var buf bytes.Buffer
+	if isOpaque(img) {
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: p.opts.JPEGQuality}); err != nil {
+			return Encoded{}, err
+		}
+		return Encoded{Data: buf.Bytes(), ContentType: TypeJPEG}, nil
+	}
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := encoder.Encode(&buf, img); err != nil {
+		return Encoded{}, err
+	}
+	return Encoded{Data: buf.Bytes(), ContentType: TypePNG}, nil
+}
+
+// resize scales img down to at most width pixels wide, keeping the aspect ratio. Images
+// that are already narrow enough are returned unchanged.
+func resize(img image.Image, width int) image.Image {
+	bounds := img.Bounds()
+	if width <= 0 || bounds.Dx() <= width {
+		return img
+	}
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
+}
+
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
+
+// orient applies an EXIF orientation (2-8) so the image displays upright.
+func orient(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // mirrored horizontally
+				dx, dy = w-1-x, y
+			case 3: // rotated 180°
+				dx, dy = w-1-x, h-1-y
+			case 4: // mirrored vertically
+				dx, dy = x, h-1-y
+			case 5: // transposed
+				dx, dy = y, x
+			case 6: // rotated 90° clockwise
+				dx, dy = h-1-y, x
+			case 7: // transversed
+				dx, dy = h-1-y, w-1-x
+			case 8: // rotated 90° counter-clockwise
+				dx, dy = y, w-1-x
+			}
+			dst.Set(dx, dy, img.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,205 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func gradient(width, height int, alpha uint8) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x * 255 / width), G: uint8(y * 255 / height), B: 120, A: alpha})
+		}
+	}
+	return img
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("encode jpeg: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// withEXIF inserts an APP1 segment carrying the given orientation right after SOI.
+func withEXIF(data []byte, orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = binary.BigEndian.AppendUint16(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, exifOrientationTag)
+	tiff = binary.BigEndian.AppendUint16(tiff, 3) // SHORT
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+
+	payload := append(append([]byte{}, exifHeader...), tiff...)
+	segment := []byte{0xFF, 0xE1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	out := append([]byte{}, data[:2]...)
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+func withPNGText(data []byte) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, 10)
+	chunk = append(chunk, "tEXtComment\x00hi"...)
+	chunk = append(chunk, 0, 0, 0, 0) // CRC is not verified by the stripper
+	// Insert right after IHDR (signature + 25 byte chunk).
+	cut := len(pngSignature) + 25
+	out := append([]byte{}, data[:cut]...)
+	out = append(out, chunk...)
+	return append(out, data[cut:]...)
+}
+
+func TestProcessAppliesEXIFOrientation(t *testing.T) {
+	data := withEXIF(encodeJPEG(t, gradient(40, 20, 255)), 6)
+	if got := jpegOrientation(data); got != 6 {
+		t.Fatalf("expected orientation 6, got %d", got)
+	}
+
+	result, err := NewProcessor(Options{}).Process(data, TypeJPEG)
+	if err != nil {
+		t.Fatalf("process: %v", err)
+	}
+	if result.Width != 20 || result.Height != 40 {
+		t.Fatalf("expected rotated 20x40, got %dx%d", result.Width, result.Height)
+	}
+	if result.Original.ContentType != TypeJPEG || jpegOrientation(result.Original.Data) != 1 {
+		t.Fatalf("expected upright jpeg without exif")
+	}
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(result.Original.Data))
+	if err != nil || cfg.Width != 20 || cfg.Height != 40 {
+		t.Fatalf("unexpected original config %+v (%v)", cfg, err)
+	}
+}
+
+func TestProcessStripsMetadataWithoutReencoding(t *testing.T) {
+	plain := encodeJPEG(t, gradient(16, 16, 255))
+	result, err := NewProcessor(Options{}).Process(withEXIF(plain, 1), TypeJPEG)
+	if err != nil {
+		t.Fatalf("process: %v", err)
+	}
+	if !bytes.Equal(result.Original.Data, plain) {
+		t.Fatalf("expected exif segment to be removed losslessly")
+	}
+
+	pngData := encodePNG(t, gradient(8, 8, 255))
+	stripped, err := stripMetadata(withPNGText(pngData), TypePNG)
+	if err != nil {
+		t.Fatalf("strip png: %v", err)
+	}
+	if !bytes.Equal(stripped, pngData) {
+		t.Fatalf("expected text chunk to be removed")
+	}
+
+	webp := []byte("RIFF\x00\x00\x00\x00WEBP" +
+		"VP8X\x0a\x00\x00\x00\x0c\x00\x00\x00\x00\x00\x00\x00\x00\x00" +
+		"EXIF\x03\x00\x00\x00abc\x00")
+	binary.LittleEndian.PutUint32(webp[4:], uint32(len(webp)-8))
+	stripped, err = stripMetadata(webp, TypeWebP)
+	if err != nil {
+		t.Fatalf("strip webp: %v", err)
+	}
+	if bytes.Contains(stripped, []byte("EXIF")) || stripped[20] != 0 {
+		t.Fatalf("expected exif chunk and flags removed, got %q", stripped)
+	}
+	if size := binary.LittleEndian.Uint32(stripped[4:]); int(size) != len(stripped)-8 {
+		t.Fatalf("expected riff size %d, got %d", len(stripped)-8, size)
+	}
+}
+
+func TestProcessRendersVariants(t *testing.T) {
+	processor := NewProcessor(Options{
+		Variants: []VariantSpec{{Name: "thumb", Width: 32}, {Name: "large", Width: 400}},
+		WebP:     true,
+	})
+	result, err := processor.Process(encodePNG(t, gradient(128, 64, 200)), TypePNG)
+	if err != nil {
+		t.Fatalf("process: %v", err)
+	}
+	if len(result.Variants) != 2 {
+		t.Fatalf("expected 2 variants, got %d", len(result.Variants))
+	}
+	thumb, large := result.Variants[0], result.Variants[1]
+	if thumb.Width != 32 || thumb.Height != 16 || thumb.Image.ContentType != TypePNG {
+		t.Fatalf("unexpected thumb %+v", thumb)
+	}
+	if large.Width != 128 || large.Height != 64 {
+		t.Fatalf("expected large variant to keep original size, got %dx%d", large.Width, large.Height)
+	}
+	for _, variant := range result.Variants {
+		if variant.WebP != nil && len(variant.WebP) >= len(variant.Image.Data) {
+			t.Fatalf("webp copy kept although not smaller for %s", variant.Name)
+		}
+	}
+
+	opaque, err := processor.Process(encodePNG(t, gradient(64, 64, 255)), TypePNG)
+	if err != nil {
+		t.Fatalf("process opaque: %v", err)
+	}
+	if opaque.Variants[0].Image.ContentType != TypeJPEG {
+		t.Fatalf("expected opaque variant as jpeg, got %s", opaque.Variants[0].Image.ContentType)
+	}
+}
+
+func TestProcessRejectsOversizedAndInvalidImages(t *testing.T) {
+	processor := NewProcessor(Options{MaxPixels: 100})
+	if _, err := processor.Process(encodePNG(t, gradient(20, 20, 255)), TypePNG); err != ErrTooManyPixels {
+		t.Fatalf("expected ErrTooManyPixels, got %v", err)
+	}
+	if _, err := processor.Process([]byte("not an image"), TypePNG); err == nil || !strings.Contains(err.Error(), ErrInvalidImage.Error()) {
+		t.Fatalf("expected invalid image error, got %v", err)
+	}
+	if _, err := processor.Process(nil, "image/svg+xml"); err != ErrUnsupportedType {
+		t.Fatalf("expected ErrUnsupportedType, got %v", err)
+	}
+}
+
+func TestBlurhashAndDominantColor(t *testing.T) {
+	solid := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for i := range solid.Pix {
+		solid.Pix[i] = []byte{200, 40, 40, 255}[i%4]
+	}
+	hash := Blurhash(solid, 4, 3)
+	if len(hash) != 28 {
+		t.Fatalf("expected 28 character hash, got %q", hash)
+	}
+	if hash[0] != 'L' {
+		t.Fatalf("expected 4x3 size flag, got %q", hash)
+	}
+	if dc := hash[2:6]; dc != encode83(200<<16|40<<8|40, 4) {
+		t.Fatalf("unexpected dc component %q", dc)
+	}
+
+	if got := DominantColor(solid); got != "#c82828" {
+		t.Fatalf("expected #c82828, got %s", got)
+	}
+	mixed := gradient(10, 10, 255)
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 7; x++ {
+			mixed.Set(x, y, color.NRGBA{R: 10, G: 20, B: 30, A: 255})
+		}
+	}
+	if got := DominantColor(mixed); got != "#0a141e" {
+		t.Fatalf("expected #0a141e, got %s", got)
+	}
+}
@@ -0,0 +1,198 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var (
+	errTruncated = errors.New("truncated image data")
+	pngSignature = []byte("\x89PNG\r\n\x1a\n")
+	exifHeader   = []byte("Exif\x00\x00")
+)
+
+const (
+	exifOrientationTag = 0x0112
+
+	webpFlagEXIF = 0x08
+	webpFlagXMP  = 0x04
+)
+
+// stripMetadata removes EXIF, XMP, IPTC and comment blocks without re-encoding pixels.
+// Colour profiles are kept so the image still renders the same.
+func stripMetadata(data []byte, contentType string) ([]byte, error) {
+	switch contentType {
+	case TypeJPEG:
+		return stripJPEG(data)
+	case TypePNG:
+		return stripPNG(data)
+	case TypeWebP:
+		return stripWebP(data)
+	}
+	return nil, ErrUnsupportedType
+}
+
+// jpegSegments walks the marker segments preceding the scan data and calls fn for each
+// one. It returns the offset of the SOS marker.
+func jpegSegments(data []byte, fn func(marker byte, segment []byte)) (int, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 0, errors.New("missing JPEG SOI marker")
+	}
+	pos := 2
+	for {
+		if pos+4 > len(data) {
+			return 0, errTruncated
+		}
+		if data[pos] != 0xFF {
+			return 0, errors.New("invalid JPEG marker")
+		}
+		marker := data[pos+1]
+		if marker == 0xFF { // fill byte
+			pos++
+			continue
+		}
+		if marker == 0xDA {
+			return pos, nil
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return 0, errTruncated
+		}
+		fn(marker, data[pos:end])
+		pos = end
+	}
+}
+
+func stripJPEG(data []byte) ([]byte, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, 0xFF, 0xD8)
+	sos, err := jpegSegments(data, func(marker byte, segment []byte) {
+		switch marker {
+		case 0xE1, 0xEC, 0xED, 0xFE: // EXIF/XMP, Ducky, IPTC, comment
+			return
+		}
+		out = append(out, segment...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(out, data[sos:]...), nil
+}
+
+// jpegOrientation returns the EXIF orientation of a JPEG, or 1 when absent or unreadable.
+func jpegOrientation(data []byte) int {
+	orientation := 1
+	_, _ = jpegSegments(data, func(marker byte, segment []byte) {
+		if marker != 0xE1 || orientation != 1 {
+			return
+		}
+		payload := segment[4:]
+		if !bytes.HasPrefix(payload, exifHeader) {
+			return
+		}
+		orientation = tiffOrientation(payload[len(exifHeader):])
+	})
+	return orientation
+}
+
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	offset := int(order.Uint32(tiff[4:]))
+	if offset+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < count; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) != exifOrientationTag {
+			continue
+		}
+		value := int(order.Uint16(tiff[entry+8:]))
+		if value < 1 || value > 8 {
+			return 1
+		}
+		return value
+	}
+	return 1
+}
+
+func stripPNG(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, errors.New("missing PNG signature")
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, pngSignature...)
+	pos := len(pngSignature)
+	for pos < len(data) {
+		if pos+8 > len(data) {
+			return nil, errTruncated
+		}
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		end := pos + 12 + length
+		if length < 0 || end > len(data) {
+			return nil, errTruncated
+		}
+		switch string(data[pos+4 : pos+8]) {
+		case "tEXt", "zTXt", "iTXt", "eXIf", "tIME":
+		default:
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+	}
+	return out, nil
+}
+
+func stripWebP(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, errors.New("missing WebP RIFF header")
+	}
+	out := make([]byte, 12, len(data))
+	copy(out, data[:12])
+	vp8x := -1
+	pos := 12
+	for pos < len(data) {
+		if pos+8 > len(data) {
+			return nil, errTruncated
+		}
+		fourcc := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		end := pos + 8 + size + size%2
+		if end > len(data) {
+			// Some encoders omit the final padding byte.
+			if pos+8+size != len(data) {
+				return nil, errTruncated
+			}
+			end = len(data)
+		}
+		switch fourcc {
+		case "EXIF", "XMP ":
+		default:
+			if fourcc == "VP8X" {
+				vp8x = len(out)
+			}
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+	}
+	if vp8x >= 0 && vp8x+8 < len(out) {
+		out[vp8x+8] &^= webpFlagEXIF | webpFlagXMP
+	}
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out, nil
+}
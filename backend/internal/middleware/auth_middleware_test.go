@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -10,6 +11,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	appauth "github.com/tanydotai/tanyai/backend/internal/auth"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
 )
 
 func init() {
@@ -22,7 +24,7 @@ func TestAuthnMissingToken(t *testing.T) {
 		t.Fatalf("token service: %v", err)
 	}
 	router := gin.New()
-	router.GET("/admin", Authn(tokenService), func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/admin", Authn(tokenService, nil), func(c *gin.Context) { c.Status(http.StatusOK) })
 
 	req := httptest.NewRequest(http.MethodGet, "/admin", nil)
 	rec := httptest.NewRecorder()
@@ -39,7 +41,7 @@ func TestAuthnInvalidToken(t *testing.T) {
 		t.Fatalf("token service: %v", err)
 	}
 	router := gin.New()
-	router.GET("/admin", Authn(tokenService), func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/admin", Authn(tokenService, nil), func(c *gin.Context) { c.Status(http.StatusOK) })
 
 	req := httptest.NewRequest(http.MethodGet, "/admin", nil)
 	req.Header.Set("Authorization", "Bearer invalid")
@@ -65,7 +67,7 @@ func TestAuthnExpiredToken(t *testing.T) {
 	tokenService.SetClock(func() time.Time { return base.Add(appauth.ClockSkew + 2*time.Second) })
 
 	router := gin.New()
-	router.GET("/admin", Authn(tokenService), func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/admin", Authn(tokenService, nil), func(c *gin.Context) { c.Status(http.StatusOK) })
 
 	req := httptest.NewRequest(http.MethodGet, "/admin", nil)
 	req.Header.Set("Authorization", "Bearer "+token)
@@ -88,7 +90,7 @@ func TestAuthzAdminRejectsNonAdmin(t *testing.T) {
 	}
 
 	router := gin.New()
-	router.GET("/admin", Authn(tokenService), AuthzAdmin(), func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/admin", Authn(tokenService, nil), AuthzAdmin(), func(c *gin.Context) { c.Status(http.StatusOK) })
 
 	req := httptest.NewRequest(http.MethodGet, "/admin", nil)
 	req.Header.Set("Authorization", "Bearer "+token)
@@ -111,7 +113,7 @@ func TestAuthzAdminAllowsAdmin(t *testing.T) {
 	}
 
 	router := gin.New()
-	router.GET("/admin", Authn(tokenService), AuthzAdmin(), func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/admin", Authn(tokenService, nil), AuthzAdmin(), func(c *gin.Context) { c.Status(http.StatusOK) })
 
 	req := httptest.NewRequest(http.MethodGet, "/admin", nil)
 	req.Header.Set("Authorization", "Bearer "+token)
@@ -122,3 +124,172 @@ func TestAuthzAdminAllowsAdmin(t *testing.T) {
 		t.Fatalf("expected 200, got %d", rec.Code)
 	}
 }
+
+func TestRequirePermissionSeparatesEditorsFromAnalytics(t *testing.T) {
+	tokenService, err := appauth.NewTokenService(strings.Repeat("r", 64), time.Minute, time.Hour)
+	if err != nil {
+		t.Fatalf("token service: %v", err)
+	}
+	token, err := tokenService.GenerateAccessToken(appauth.Subject{ID: uuid.New(), Email: "editor@example.com", Roles: []string{appauth.RoleEditor}})
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+
+	router := gin.New()
+	admin := router.Group("/admin", Authn(tokenService, nil), RequireAnyPermission())
+	admin.GET("/projects", RequirePermission(appauth.PermissionContentWrite), func(c *gin.Context) { c.Status(http.StatusOK) })
+	admin.GET("/analytics", RequirePermission(appauth.PermissionAnalyticsRead), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for path, want := range map[string]int{"/admin/projects": http.StatusOK, "/admin/analytics": http.StatusForbidden} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Fatalf("%s: expected %d, got %d", path, want, rec.Code)
+		}
+	}
+}
+
+func TestRequireAnyPermissionRejectsUnknownRoles(t *testing.T) {
+	tokenService, err := appauth.NewTokenService(strings.Repeat("u", 64), time.Minute, time.Hour)
+	if err != nil {
+		t.Fatalf("token service: %v", err)
+	}
+	token, err := tokenService.GenerateAccessToken(appauth.Subject{ID: uuid.New(), Email: "user@example.com", Roles: []string{"user"}})
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+
+	router := gin.New()
+	router.GET("/admin", Authn(tokenService, nil), RequireAnyPermission(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rec.Code)
+	}
+}
+
+type stubAPIKeys map[string]*appauth.Claims
+
+func (s stubAPIKeys) AuthenticateAPIKey(_ context.Context, key string) (*appauth.Claims, error) {
+	if key == "tany_limited" {
+		return nil, appauth.ErrRateLimited
+	}
+	claims, ok := s[key]
+	if !ok {
+		return nil, appauth.ErrInvalidToken
+	}
+	return claims, nil
+}
+
+func TestAuthnAcceptsAPIKeysWithinScopes(t *testing.T) {
+	tokenService, err := appauth.NewTokenService(strings.Repeat("v", 64), time.Minute, time.Hour)
+	if err != nil {
+		t.Fatalf("token service: %v", err)
+	}
+	keyTenant := uuid.New()
+	keys := stubAPIKeys{"tany_analytics": {APIKeyID: uuid.New(), TenantID: keyTenant, Scopes: []appauth.Permission{appauth.PermissionAnalyticsRead}}}
+
+	router := gin.New()
+	admin := router.Group("/admin", Authn(tokenService, keys), RequireAnyPermission())
+	admin.GET("/analytics", RequirePermission(appauth.PermissionAnalyticsRead), func(c *gin.Context) {
+		if tenant.ID(c.Request.Context()) != keyTenant {
+			t.Errorf("expected request scoped to key tenant")
+		}
+		c.Status(http.StatusOK)
+	})
+	admin.GET("/projects", RequirePermission(appauth.PermissionContentWrite), func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/sessions", Authn(tokenService, nil), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	cases := []struct {
+		path, key string
+		want      int
+	}{
+		{"/admin/analytics", "tany_analytics", http.StatusOK},
+		{"/admin/projects", "tany_analytics", http.StatusForbidden},
+		{"/admin/analytics", "tany_unknown", http.StatusUnauthorized},
+		{"/admin/analytics", "tany_limited", http.StatusTooManyRequests},
+		{"/sessions", "tany_analytics", http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		req.Header.Set(APIKeyHeader, tc.key)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Fatalf("%s with %s: expected %d, got %d", tc.path, tc.key, tc.want, rec.Code)
+		}
+	}
+}
+
+func TestOptionalAPIKeyChecksScopeAndTenant(t *testing.T) {
+	keys := stubAPIKeys{
+		"tany_chat":      {APIKeyID: uuid.New(), TenantID: tenant.DefaultID, Scopes: []appauth.Permission{appauth.ScopeChat}},
+		"tany_analytics": {APIKeyID: uuid.New(), TenantID: tenant.DefaultID, Scopes: []appauth.Permission{appauth.PermissionAnalyticsRead}},
+		"tany_other":     {APIKeyID: uuid.New(), TenantID: uuid.New(), Scopes: []appauth.Permission{appauth.ScopeChat}},
+	}
+	router := gin.New()
+	router.POST("/chat", OptionalAPIKey(keys, appauth.ScopeChat), func(c *gin.Context) {
+		_, authed := GetClaims(c)
+		c.JSON(http.StatusOK, gin.H{"apiKey": authed})
+	})
+
+	cases := map[string]int{
+		"":               http.StatusOK,
+		"tany_chat":      http.StatusOK,
+		"tany_analytics": http.StatusForbidden,
+		"tany_other":     http.StatusForbidden,
+		"tany_unknown":   http.StatusUnauthorized,
+	}
+	for key, want := range cases {
+		req := httptest.NewRequest(http.MethodPost, "/chat", nil)
+		if key != "" {
+			req.Header.Set(APIKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Fatalf("key %q: expected %d, got %d", key, want, rec.Code)
+		}
+	}
+}
+
+func TestOptionalBearerAttachesValidTokensOnly(t *testing.T) {
+	tokenService, err := appauth.NewTokenService(strings.Repeat("s", 64), time.Minute, time.Hour)
+	if err != nil {
+		t.Fatalf("token service: %v", err)
+	}
+	token, err := tokenService.GenerateAccessToken(appauth.Subject{ID: uuid.New(), Email: "admin@example.com", Roles: []string{"admin"}})
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+
+	router := gin.New()
+	router.POST("/chat", OptionalBearer(tokenService), func(c *gin.Context) {
+		claims, ok := GetClaims(c)
+		c.JSON(http.StatusOK, gin.H{"admin": ok && claims.HasRole(appauth.RoleAdmin)})
+	})
+
+	cases := map[string]string{
+		"":                `{"admin":false}`,
+		"Bearer invalid":  `{"admin":false}`,
+		"Bearer " + token: `{"admin":true}`,
+		"Basic " + token:  `{"admin":false}`,
+	}
+	for header, want := range cases {
+		req := httptest.NewRequest(http.MethodPost, "/chat", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK || rec.Body.String() != want {
+			t.Fatalf("header %q: expected 200 %s, got %d %s", header, want, rec.Code, rec.Body.String())
+		}
+	}
+}
@@ -1,12 +1,17 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tanydotai/tanyai/backend/internal/audit"
 	"github.com/tanydotai/tanyai/backend/internal/auth"
 	"github.com/tanydotai/tanyai/backend/internal/httpapi"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
 )
 
 const contextClaimsKey = "auth.claims"
@@ -16,9 +21,32 @@ type AccessTokenValidator interface {
 	ValidateAccessToken(token string) (*auth.Claims, error)
 }
 
-// Authn enforces JWT-based authentication on protected routes.
-func Authn(validator AccessTokenValidator) gin.HandlerFunc {
+// APIKeyHeader carries API keys for server-to-server requests.
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyAuthenticator resolves API keys to claims.
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*auth.Claims, error)
+}
+
+// Authn enforces authentication on protected routes using either a Bearer JWT or, when
+// apiKeys is non-nil, an X-API-Key header.
+func Authn(validator AccessTokenValidator, apiKeys APIKeyAuthenticator) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if key := c.GetHeader(APIKeyHeader); key != "" {
+			if apiKeys == nil {
+				httpapi.RespondError(c, http.StatusUnauthorized, httpapi.ErrorCodeUnauthorized, "api keys are not accepted for this endpoint", nil)
+				return
+			}
+			claims, ok := authenticateAPIKey(c, apiKeys, key)
+			if !ok {
+				return
+			}
+			setPrincipal(c, claims)
+			c.Next()
+			return
+		}
+
 		header := c.GetHeader("Authorization")
 		if header == "" {
 			httpapi.RespondError(c, http.StatusUnauthorized, httpapi.ErrorCodeUnauthorized, "authentication required", nil)
@@ -43,11 +71,96 @@ func Authn(validator AccessTokenValidator) gin.HandlerFunc {
 			return
 		}
 
-		c.Set(contextClaimsKey, claims)
+		setPrincipal(c, claims)
 		c.Next()
 	}
 }
 
+// OptionalAPIKey authenticates requests that carry an X-API-Key and lets anonymous requests
+// through unchanged. Keys must hold scope and belong to the tenant already resolved for the
+// request.
+func OptionalAPIKey(apiKeys APIKeyAuthenticator, scope auth.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(APIKeyHeader)
+		if key == "" || apiKeys == nil {
+			c.Next()
+			return
+		}
+
+		claims, ok := authenticateAPIKey(c, apiKeys, key)
+		if !ok {
+			return
+		}
+		if !claims.HasPermission(scope) {
+			httpapi.RespondError(c, http.StatusForbidden, httpapi.ErrorCodeForbidden, "missing permission", gin.H{"permission": scope})
+			return
+		}
+		if claims.TenantID != tenant.ID(c.Request.Context()) {
+			httpapi.RespondError(c, http.StatusForbidden, httpapi.ErrorCodeForbidden, "api key does not belong to this tenant", nil)
+			return
+		}
+
+		SetClaims(c, claims)
+		c.Next()
+	}
+}
+
+// OptionalBearer attaches the claims of a valid Bearer token and otherwise lets the request
+// through anonymously, so public endpoints can offer extras to signed-in users. It never
+// changes the tenant resolved for the request and keeps claims already set by an API key.
+func OptionalBearer(validator AccessTokenValidator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := GetClaims(c); ok || validator == nil {
+			c.Next()
+			return
+		}
+		parts := strings.Fields(c.GetHeader("Authorization"))
+		if len(parts) == 2 && strings.EqualFold(parts[0], "Bearer") {
+			if claims, err := validator.ValidateAccessToken(parts[1]); err == nil {
+				SetClaims(c, claims)
+			}
+		}
+		c.Next()
+	}
+}
+
+func authenticateAPIKey(c *gin.Context, apiKeys APIKeyAuthenticator, key string) (*auth.Claims, bool) {
+	claims, err := apiKeys.AuthenticateAPIKey(c.Request.Context(), key)
+	switch {
+	case err == nil:
+		return claims, true
+	case errors.Is(err, auth.ErrRateLimited):
+		httpapi.RespondError(c, http.StatusTooManyRequests, httpapi.ErrorCodeTooManyRequests, "too many requests", nil)
+	case errors.Is(err, auth.ErrInvalidToken), errors.Is(err, auth.ErrTokenExpired):
+		httpapi.RespondError(c, http.StatusUnauthorized, httpapi.ErrorCodeUnauthorized, "invalid or expired api key", nil)
+	default:
+		httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "failed to authenticate api key", nil)
+	}
+	return nil, false
+}
+
+// setPrincipal stores claims and scopes the request to the principal's tenant.
+func setPrincipal(c *gin.Context, claims *auth.Claims) {
+	SetClaims(c, claims)
+	tenantID := claims.TenantID
+	if tenantID == uuid.Nil {
+		tenantID = tenant.DefaultID
+	}
+	ctx := tenant.WithID(c.Request.Context(), tenantID)
+	ctx = audit.WithActor(ctx, audit.Actor{
+		UserID:   claims.UserID,
+		APIKeyID: claims.APIKeyID,
+		Email:    claims.Email,
+		IP:       c.ClientIP(),
+	})
+	c.Request = c.Request.WithContext(ctx)
+}
+
+// SetClaims stores access token claims on the request context.
+func SetClaims(c *gin.Context, claims *auth.Claims) {
+	c.Set(contextClaimsKey, claims)
+}
+
 // GetClaims retrieves access token claims from context.
 func GetClaims(c *gin.Context) (*auth.Claims, bool) {
 	value, ok := c.Get(contextClaimsKey)
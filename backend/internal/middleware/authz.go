@@ -5,10 +5,11 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/tanydotai/tanyai/backend/internal/auth"
 	"github.com/tanydotai/tanyai/backend/internal/httpapi"
 )
 
-const adminRole = "admin"
+const adminRole = auth.RoleAdmin
 
 // AuthzAdmin ensures the authenticated principal carries the admin role.
 func AuthzAdmin() gin.HandlerFunc {
@@ -28,6 +29,44 @@ func AuthzAdmin() gin.HandlerFunc {
 	}
 }
 
+// RequirePermission ensures the authenticated principal holds every listed permission
+// through one of its roles.
+func RequirePermission(perms ...auth.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := GetClaims(c)
+		if !ok {
+			httpapi.RespondError(c, http.StatusUnauthorized, httpapi.ErrorCodeUnauthorized, "authentication required", nil)
+			return
+		}
+
+		for _, perm := range perms {
+			if !claims.HasPermission(perm) {
+				httpapi.RespondError(c, http.StatusForbidden, httpapi.ErrorCodeForbidden, "missing permission", gin.H{"permission": perm})
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// RequireAnyPermission admits principals whose roles grant at least one permission,
+// keeping users without a recognised role out of the admin API entirely.
+func RequireAnyPermission() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := GetClaims(c)
+		if !ok {
+			httpapi.RespondError(c, http.StatusUnauthorized, httpapi.ErrorCodeUnauthorized, "authentication required", nil)
+			return
+		}
+		if len(claims.Permissions()) == 0 {
+			httpapi.RespondError(c, http.StatusForbidden, httpapi.ErrorCodeForbidden, "admin access required", nil)
+			return
+		}
+		c.Next()
+	}
+}
+
 func hasRole(roles []string, desired string) bool {
 	for _, role := range roles {
 		if strings.EqualFold(role, desired) {
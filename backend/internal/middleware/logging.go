@@ -26,8 +26,11 @@ func JSONLogger(route string) gin.HandlerFunc {
 			"ip", c.ClientIP(),
 			"latency_ms", time.Since(start).Milliseconds(),
 			"user_agent", c.Request.UserAgent(),
+			"tenant", valueOrNil(c, "tenant"),
 			"cache_hit", valueOrNil(c, "kb_cache_hit"),
 			"prompt_length", valueOrNil(c, "prompt_length"),
+			"prompt_tokens", valueOrNil(c, "prompt_tokens"),
+			"answer_cache_hit", valueOrNil(c, "answer_cache_hit"),
 			"chat_id", valueOrNil(c, "chat_id"),
 			"model", valueOrNil(c, "model"),
 		)
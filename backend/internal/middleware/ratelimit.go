@@ -17,6 +17,11 @@ func RateLimitByIP(limiter *appauth.RateLimiter) gin.HandlerFunc {
 	}
 
 	return func(c *gin.Context) {
+		// API key requests are already limited per key by OptionalAPIKey.
+		if claims, ok := GetClaims(c); ok && claims.IsAPIKey() {
+			c.Next()
+			return
+		}
 		key := c.ClientIP()
 		if key == "" {
 			key = "unknown"
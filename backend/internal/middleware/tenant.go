@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tanydotai/tanyai/backend/internal/httpapi"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
+)
+
+// TenantParam is the route parameter carrying the tenant slug on path-scoped routes.
+const TenantParam = "tenant"
+
+// TenantResolver maps a path slug or request host to a tenant.
+type TenantResolver interface {
+	Resolve(ctx context.Context, slug, host string) (models.Tenant, error)
+}
+
+// ResolveTenant attaches the tenant addressed by the :tenant path parameter, or by the
+// request host when no slug is present, to the request context.
+func ResolveTenant(resolver TenantResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		t, err := resolver.Resolve(c.Request.Context(), c.Param(TenantParam), c.Request.Host)
+		if err != nil {
+			if errors.Is(err, tenant.ErrUnknownTenant) {
+				httpapi.RespondError(c, http.StatusNotFound, httpapi.ErrorCodeNotFound, "tenant not found", nil)
+				return
+			}
+			slog.Error("failed to resolve tenant", "error", err)
+			httpapi.RespondError(c, http.StatusInternalServerError, httpapi.ErrorCodeInternal, "failed to resolve tenant", nil)
+			return
+		}
+
+		c.Set("tenant", t.Slug)
+		c.Request = c.Request.WithContext(tenant.WithTenant(c.Request.Context(), t))
+		c.Next()
+	}
+}
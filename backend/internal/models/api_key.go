@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// APIKey is a long-lived credential for server-to-server access. Only the SHA-256 hash of
+// the key is stored; Prefix is the non-secret part shown in listings and analytics.
+type APIKey struct {
+	ID              uuid.UUID      `db:"id"`
+	TenantID        uuid.UUID      `db:"tenant_id"`
+	Name            string         `db:"name"`
+	Prefix          string         `db:"prefix"`
+	KeyHash         string         `db:"key_hash" audit:"-"`
+	Scopes          pq.StringArray `db:"scopes"`
+	RateLimitPerMin int            `db:"rate_limit_per_min"`
+	ExpiresAt       *time.Time     `db:"expires_at"`
+	LastUsedAt      *time.Time     `db:"last_used_at"`
+	RevokedAt       *time.Time     `db:"revoked_at"`
+	CreatedBy       *uuid.UUID     `db:"created_by"`
+	CreatedAt       time.Time      `db:"created_at"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditLog records a single admin mutation. Actor fields are empty for changes made by
+// the system itself.
+type AuditLog struct {
+	ID            uuid.UUID  `db:"id"`
+	TenantID      uuid.UUID  `db:"tenant_id"`
+	ActorUserID   *uuid.UUID `db:"actor_user_id"`
+	ActorAPIKeyID *uuid.UUID `db:"actor_api_key_id"`
+	ActorEmail    string     `db:"actor_email"`
+	Action        string     `db:"action"`
+	EntityType    string     `db:"entity_type"`
+	EntityID      string     `db:"entity_id"`
+	Before        NullJSONB  `db:"before"`
+	After         NullJSONB  `db:"after"`
+	Changes       JSONB      `db:"changes"`
+	IPAddress     string     `db:"ip_address"`
+	CreatedAt     time.Time  `db:"created_at"`
+}
@@ -6,7 +6,8 @@ import (
 	"github.com/google/uuid"
 )
 
-// ChatHistory represents a persisted chat interaction.
+// ChatHistory represents a persisted chat interaction. Experiment and Variant name the
+// experiment arm that answered and are empty outside experiments.
 type ChatHistory struct {
 	ID           uuid.UUID `db:"id"`
 	ChatID       uuid.UUID `db:"chat_id"`
@@ -17,5 +18,8 @@ type ChatHistory struct {
 	PromptLength int       `db:"prompt_length"`
 	ResponseText string    `db:"response_text"`
 	LatencyMS    int       `db:"latency_ms"`
+	CacheHit     bool      `db:"cache_hit"`
+	Experiment   string    `db:"experiment"`
+	Variant      string    `db:"variant"`
 	CreatedAt    time.Time `db:"created_at"`
 }
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Document is an uploaded PDF, Markdown or text file. It has no table of its own: its
+// extracted text is stored as external_items chunks of the tenant's documents source,
+// and these fields are read back from the chunk metadata.
+type Document struct {
+	ID          uuid.UUID `db:"id"`
+	Title       string    `db:"title"`
+	Filename    string    `db:"filename"`
+	ContentType string    `db:"content_type"`
+	SizeBytes   int64     `db:"size_bytes"`
+	Key         string    `db:"key"`
+	URL         string    `db:"url"`
+	Chunks      int       `db:"chunks"`
+	CreatedAt   time.Time `db:"created_at"`
+}
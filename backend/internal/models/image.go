@@ -0,0 +1,69 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// ImageSet describes the processed renditions of an uploaded image, stored as JSONB next
+// to the image URL it was derived from.
+type ImageSet struct {
+	Width         int            `json:"width"`
+	Height        int            `json:"height"`
+	Blurhash      string         `json:"blurhash,omitempty"`
+	DominantColor string         `json:"dominant_color,omitempty"`
+	Variants      []ImageVariant `json:"variants"`
+}
+
+// ImageVariant is one resized rendition. WebPURL is set when a smaller WebP copy exists.
+type ImageVariant struct {
+	Name    string `json:"name"`
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+	URL     string `json:"url"`
+	WebPURL string `json:"webp_url,omitempty"`
+}
+
+// WithURLs returns a copy of the set with every variant URL passed through rewrite.
+func (s *ImageSet) WithURLs(rewrite func(string) string) *ImageSet {
+	if s == nil {
+		return nil
+	}
+	rewritten := *s
+	rewritten.Variants = make([]ImageVariant, len(s.Variants))
+	for i, variant := range s.Variants {
+		variant.URL = rewrite(variant.URL)
+		if variant.WebPURL != "" {
+			variant.WebPURL = rewrite(variant.WebPURL)
+		}
+		rewritten.Variants[i] = variant
+	}
+	return &rewritten
+}
+
+// Value implements driver.Valuer.
+func (s ImageSet) Value() (driver.Value, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("image set marshal: %w", err)
+	}
+	return data, nil
+}
+
+// Scan implements sql.Scanner.
+func (s *ImageSet) Scan(value any) error {
+	var data []byte
+	switch v := value.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("image set: unsupported type %T", value)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return fmt.Errorf("image set unmarshal: %w", err)
+	}
+	return nil
+}
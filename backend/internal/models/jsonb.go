@@ -50,3 +50,31 @@ func (j *JSONB) Scan(value any) error {
 	*j = JSONB(raw)
 	return nil
 }
+
+// NullJSONB is a JSONB column that stores nil as SQL NULL rather than an empty object.
+type NullJSONB map[string]any
+
+// Value implements driver.Valuer.
+func (j NullJSONB) Value() (driver.Value, error) {
+	if j == nil {
+		return nil, nil
+	}
+	return JSONB(j).Value()
+}
+
+// Scan implements sql.Scanner.
+func (j *NullJSONB) Scan(value any) error {
+	if j == nil {
+		return fmt.Errorf("jsonb: Scan on nil pointer")
+	}
+	if value == nil {
+		*j = nil
+		return nil
+	}
+	var raw JSONB
+	if err := raw.Scan(value); err != nil {
+		return err
+	}
+	*j = NullJSONB(raw)
+	return nil
+}
@@ -14,6 +14,7 @@ type Project struct {
 	Description   sql.NullString `db:"description"`
 	TechStack     pq.StringArray `db:"tech_stack"`
 	ImageURL      sql.NullString `db:"image_url"`
+	ImageVariants *ImageSet      `db:"image_variants"`
 	ProjectURL    sql.NullString `db:"project_url"`
 	Category      sql.NullString `db:"category"`
 	DurationLabel sql.NullString `db:"duration_label"`
@@ -21,4 +22,6 @@ type Project struct {
 	BudgetLabel   sql.NullString `db:"budget_label"`
 	Order         int            `db:"order"`
 	IsFeatured    bool           `db:"is_featured"`
+
+	Publication
 }
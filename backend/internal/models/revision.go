@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Publication states of draft-enabled content.
+const (
+	PublicationDraft     = "draft"
+	PublicationPublished = "published"
+	PublicationChanged   = "changed"
+)
+
+// Publication tracks which revision of an editable row is live. The row itself is always
+// the draft; the knowledge base only reads the published revision.
+type Publication struct {
+	PublishedRevisionID   *uuid.UUID `db:"published_revision_id"`
+	HasUnpublishedChanges bool       `db:"has_unpublished_changes"`
+	PublishAt             *time.Time `db:"publish_at"`
+}
+
+// Status reports whether the content was never published, is live as-is, or has draft
+// changes on top of its published revision.
+func (p Publication) Status() string {
+	switch {
+	case p.PublishedRevisionID == nil:
+		return PublicationDraft
+	case p.HasUnpublishedChanges:
+		return PublicationChanged
+	default:
+		return PublicationPublished
+	}
+}
+
+// ContentRevision is an immutable snapshot of published content.
+type ContentRevision struct {
+	ID          uuid.UUID  `db:"id"`
+	TenantID    uuid.UUID  `db:"tenant_id"`
+	EntityType  string     `db:"entity_type"`
+	EntityID    uuid.UUID  `db:"entity_id"`
+	Version     int        `db:"version"`
+	Data        JSONB      `db:"data"`
+	PublishedBy *uuid.UUID `db:"published_by"`
+	CreatedAt   time.Time  `db:"created_at"`
+}
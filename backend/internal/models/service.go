@@ -17,4 +17,6 @@ type Service struct {
 	DurationLabel sql.NullString  `db:"duration_label"`
 	IsActive      bool            `db:"is_active"`
 	Order         int             `db:"order"`
+
+	Publication
 }
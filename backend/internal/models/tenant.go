@@ -0,0 +1,21 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Tenant represents a portfolio owner hosted on the deployment.
+type Tenant struct {
+	ID         uuid.UUID      `db:"id"`
+	Slug       string         `db:"slug"`
+	Name       string         `db:"name"`
+	Hosts      pq.StringArray `db:"hosts"`
+	AIProvider sql.NullString `db:"ai_provider"`
+	ChatModel  sql.NullString `db:"chat_model"`
+	CreatedAt  time.Time      `db:"created_at"`
+	UpdatedAt  time.Time      `db:"updated_at"`
+}
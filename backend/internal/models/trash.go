@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TrashItem is a soft-deleted skill, service or project awaiting restore or purge.
+type TrashItem struct {
+	EntityType string    `db:"entity_type"`
+	ID         uuid.UUID `db:"id"`
+	Name       string    `db:"name"`
+	DeletedAt  time.Time `db:"deleted_at"`
+}
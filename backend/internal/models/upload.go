@@ -0,0 +1,82 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Upload records an object written by the upload endpoint. Image variants point at their
+// original through ParentID.
+type Upload struct {
+	ID          uuid.UUID  `db:"id"`
+	TenantID    uuid.UUID  `db:"tenant_id"`
+	ParentID    *uuid.UUID `db:"parent_id"`
+	Key         string     `db:"key"`
+	URL         string     `db:"url"`
+	ContentType string     `db:"content_type"`
+	SizeBytes   int64      `db:"size_bytes"`
+	UploadedBy  *uuid.UUID `db:"uploaded_by"`
+	CreatedAt   time.Time  `db:"created_at"`
+}
+
+// MediaItem is an original upload listed in the media library together with its variants
+// and the content that references it.
+type MediaItem struct {
+	Upload
+	UploaderEmail string          `db:"uploader_email"`
+	Variants      MediaVariants   `db:"variants"`
+	References    MediaReferences `db:"refs"`
+}
+
+// MediaVariant is a rendition stored next to an original upload.
+type MediaVariant struct {
+	Key         string `json:"key"`
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	SizeBytes   int64  `json:"size_bytes"`
+}
+
+// MediaReference names content that uses an upload, either in its draft or in its
+// published revision.
+type MediaReference struct {
+	Entity    string    `json:"entity"`
+	ID        uuid.UUID `json:"id"`
+	Published bool      `json:"published"`
+}
+
+// MediaVariants is scanned from a JSONB array.
+type MediaVariants []MediaVariant
+
+// Scan implements sql.Scanner.
+func (v *MediaVariants) Scan(value any) error {
+	return scanJSONArray(value, v)
+}
+
+// MediaReferences is scanned from a JSONB array.
+type MediaReferences []MediaReference
+
+// Scan implements sql.Scanner.
+func (r *MediaReferences) Scan(value any) error {
+	return scanJSONArray(value, r)
+}
+
+func scanJSONArray(value any, dest any) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("jsonb: unsupported type %T", value)
+	}
+	if err := json.Unmarshal(data, dest); err != nil {
+		return fmt.Errorf("jsonb unmarshal: %w", err)
+	}
+	return nil
+}
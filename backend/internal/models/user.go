@@ -10,18 +10,44 @@ import (
 type User struct {
 	ID           uuid.UUID `db:"id"`
 	Email        string    `db:"email"`
-	PasswordHash string    `db:"password_hash"`
+	PasswordHash string    `db:"password_hash" audit:"-"`
 	Name         *string   `db:"name"`
+	TenantID     uuid.UUID `db:"tenant_id"`
+	IsActive     bool      `db:"is_active"`
+	TOTPSecret   *string   `db:"totp_secret" audit:"-"`
+	TOTPEnabled  bool      `db:"totp_enabled"`
 	CreatedAt    time.Time `db:"created_at"`
 	UpdatedAt    time.Time `db:"updated_at"`
 }
 
-// RefreshToken represents a persisted refresh token record.
+// RefreshToken represents a persisted refresh token record. Tokens rotated from the same
+// login share a FamilyID, which also identifies the session.
 type RefreshToken struct {
-	ID        uuid.UUID `db:"id"`
-	UserID    uuid.UUID `db:"user_id"`
-	TokenHash string    `db:"token_hash"`
-	ExpiresAt time.Time `db:"expires_at"`
-	Revoked   bool      `db:"revoked"`
-	CreatedAt time.Time `db:"created_at"`
+	ID               uuid.UUID `db:"id"`
+	UserID           uuid.UUID `db:"user_id"`
+	FamilyID         uuid.UUID `db:"family_id"`
+	TokenHash        string    `db:"token_hash"`
+	ExpiresAt        time.Time `db:"expires_at"`
+	Revoked          bool      `db:"revoked"`
+	SessionStartedAt time.Time `db:"session_started_at"`
+	UserAgent        string    `db:"user_agent"`
+	IPAddress        string    `db:"ip_address"`
+	CreatedAt        time.Time `db:"created_at"`
+}
+
+// Password token purposes.
+const (
+	PasswordTokenInvite = "invite"
+	PasswordTokenReset  = "reset"
+)
+
+// PasswordToken is a one-time token allowing a user to set a new password.
+type PasswordToken struct {
+	ID        uuid.UUID  `db:"id"`
+	UserID    uuid.UUID  `db:"user_id"`
+	TokenHash string     `db:"token_hash"`
+	Purpose   string     `db:"purpose"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	CreatedAt time.Time  `db:"created_at"`
 }
@@ -0,0 +1,113 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/tanydotai/tanyai/backend/internal/audit"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
+)
+
+const apiKeyColumns = `id, tenant_id, name, prefix, key_hash, scopes, rate_limit_per_min, expires_at, last_used_at, revoked_at, created_by, created_at`
+
+// APIKeyRepository manages API keys.
+type APIKeyRepository interface {
+	List(ctx context.Context, params ListParams) ([]models.APIKey, int64, error)
+	Create(ctx context.Context, key models.APIKey) (models.APIKey, error)
+	Revoke(ctx context.Context, id uuid.UUID, at time.Time) error
+	// FindByHash looks a key up across tenants; callers derive the tenant from the result.
+	FindByHash(ctx context.Context, hash string) (models.APIKey, error)
+	TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error
+}
+
+// NewAPIKeyRepository constructs a SQL-backed API key repository.
+func NewAPIKeyRepository(db *sqlx.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+type apiKeyRepository struct {
+	db *sqlx.DB
+}
+
+func (r *apiKeyRepository) List(ctx context.Context, params ListParams) ([]models.APIKey, int64, error) {
+	tenantID := tenant.ID(ctx)
+	orderBy, err := params.ValidateSort(map[string]string{
+		"name":         "name",
+		"created_at":   "created_at",
+		"last_used_at": "last_used_at",
+	}, "created_at")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE tenant_id = $1 ORDER BY ` + orderBy + ` LIMIT $2 OFFSET $3`
+	var keys []models.APIKey
+	if err := r.db.SelectContext(ctx, &keys, query, tenantID, params.Limit, params.Offset()); err != nil {
+		return nil, 0, err
+	}
+
+	var total int64
+	if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM api_keys WHERE tenant_id = $1`, tenantID); err != nil {
+		return nil, 0, err
+	}
+	return keys, total, nil
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key models.APIKey) (models.APIKey, error) {
+	const query = `INSERT INTO api_keys (tenant_id, name, prefix, key_hash, scopes, rate_limit_per_min, expires_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING ` + apiKeyColumns
+	var created models.APIKey
+	err := withAudit(ctx, r.db, func(tx *sqlx.Tx) (audit.Change, error) {
+		if err := tx.GetContext(ctx, &created, query, tenant.ID(ctx), key.Name, key.Prefix, key.KeyHash, key.Scopes, key.RateLimitPerMin, key.ExpiresAt, key.CreatedBy); err != nil {
+			if isUniqueViolation(err) {
+				return audit.Change{}, ErrConflict
+			}
+			return audit.Change{}, err
+		}
+		return audit.Change{Action: audit.ActionCreate, EntityType: audit.EntityAPIKey, EntityID: created.ID.String(), After: created}, nil
+	})
+	if err != nil {
+		return models.APIKey{}, err
+	}
+	return created, nil
+}
+
+func (r *apiKeyRepository) Revoke(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return withAudit(ctx, r.db, func(tx *sqlx.Tx) (audit.Change, error) {
+		var before models.APIKey
+		lockQuery := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE id = $1 AND tenant_id = $2 AND revoked_at IS NULL FOR UPDATE`
+		if err := tx.GetContext(ctx, &before, lockQuery, id, tenant.ID(ctx)); err != nil {
+			if err == sql.ErrNoRows {
+				return audit.Change{}, ErrNotFound
+			}
+			return audit.Change{}, err
+		}
+		var after models.APIKey
+		query := `UPDATE api_keys SET revoked_at = $2 WHERE id = $1 RETURNING ` + apiKeyColumns
+		if err := tx.GetContext(ctx, &after, query, id, at); err != nil {
+			return audit.Change{}, err
+		}
+		return audit.Change{Action: audit.ActionRevoke, EntityType: audit.EntityAPIKey, EntityID: id.String(), Before: before, After: after}, nil
+	})
+}
+
+func (r *apiKeyRepository) FindByHash(ctx context.Context, hash string) (models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = $1`
+	var key models.APIKey
+	if err := r.db.GetContext(ctx, &key, query, hash); err != nil {
+		if err == sql.ErrNoRows {
+			return models.APIKey{}, ErrNotFound
+		}
+		return models.APIKey{}, err
+	}
+	return key, nil
+}
+
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE api_keys SET last_used_at = $2 WHERE id = $1`, id, at)
+	return err
+}
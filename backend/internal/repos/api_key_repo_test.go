@@ -0,0 +1,58 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
+)
+
+func TestAPIKeyRepositoryFindByHashMapsMissingKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewAPIKeyRepository(sqlx.NewDb(db, "sqlmock"))
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM api_keys WHERE key_hash = $1`)).
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	if _, err := repo.FindByHash(context.Background(), "hash"); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestAPIKeyRepositoryRevokeIsTenantScoped(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewAPIKeyRepository(sqlx.NewDb(db, "sqlmock"))
+	id := uuid.New()
+	at := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM api_keys WHERE id = $1 AND tenant_id = $2 AND revoked_at IS NULL FOR UPDATE`)).
+		WithArgs(id, tenant.DefaultID).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	if err := repo.Revoke(context.Background(), id, at); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,193 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/tanydotai/tanyai/backend/internal/audit"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
+)
+
+const auditLogColumns = `id, tenant_id, actor_user_id, actor_api_key_id, actor_email, action, entity_type, entity_id, before, after, changes, ip_address, created_at`
+
+// AuditListParams filters audit log listings.
+type AuditListParams struct {
+	ListParams
+	EntityType string
+	EntityID   string
+	Action     string
+	ActorID    *uuid.UUID
+	From       *time.Time
+	To         *time.Time
+}
+
+// AuditRepository reads the audit log and records changes that have no transaction of
+// their own, such as object storage uploads.
+type AuditRepository interface {
+	List(ctx context.Context, params AuditListParams) ([]models.AuditLog, int64, error)
+	Record(ctx context.Context, change audit.Change) error
+}
+
+// NewAuditRepository constructs a SQL-backed audit repository.
+func NewAuditRepository(db *sqlx.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+type auditRepository struct {
+	db *sqlx.DB
+}
+
+func (r *auditRepository) List(ctx context.Context, params AuditListParams) ([]models.AuditLog, int64, error) {
+	where := []string{"tenant_id = $1"}
+	args := []any{tenant.ID(ctx)}
+
+	if params.EntityType != "" {
+		where = append(where, fmt.Sprintf("entity_type = $%d", len(args)+1))
+		args = append(args, params.EntityType)
+	}
+	if params.EntityID != "" {
+		where = append(where, fmt.Sprintf("entity_id = $%d", len(args)+1))
+		args = append(args, params.EntityID)
+	}
+	if params.Action != "" {
+		where = append(where, fmt.Sprintf("action = $%d", len(args)+1))
+		args = append(args, params.Action)
+	}
+	if params.ActorID != nil {
+		where = append(where, fmt.Sprintf("(actor_user_id = $%d OR actor_api_key_id = $%d)", len(args)+1, len(args)+1))
+		args = append(args, *params.ActorID)
+	}
+	if params.From != nil {
+		where = append(where, fmt.Sprintf("created_at >= $%d", len(args)+1))
+		args = append(args, *params.From)
+	}
+	if params.To != nil {
+		where = append(where, fmt.Sprintf("created_at < $%d", len(args)+1))
+		args = append(args, *params.To)
+	}
+
+	sortParams := params.ListParams
+	if sortParams.SortField == "" && sortParams.SortDir == "" {
+		sortParams.SortDir = "desc"
+	}
+	orderBy, err := sortParams.ValidateSort(map[string]string{
+		"created_at": "created_at",
+	}, "created_at")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	whereClause := strings.Join(where, " AND ")
+	filterArgs := append([]any(nil), args...)
+	query := fmt.Sprintf(`SELECT %s FROM audit_log WHERE %s ORDER BY %s LIMIT $%d OFFSET $%d`, auditLogColumns, whereClause, orderBy, len(args)+1, len(args)+2)
+	args = append(args, params.Limit, params.Offset())
+
+	entries := make([]models.AuditLog, 0, params.Limit)
+	if err := r.db.SelectContext(ctx, &entries, query, args...); err != nil {
+		return nil, 0, err
+	}
+
+	var total int64
+	if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM audit_log WHERE `+whereClause, filterArgs...); err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+func (r *auditRepository) Record(ctx context.Context, change audit.Change) error {
+	return recordAudit(ctx, r.db, change)
+}
+
+// withAudit runs fn in a transaction and records the change it returns in the same
+// transaction, so a mutation is never committed without its audit entry.
+func withAudit(ctx context.Context, db *sqlx.DB, fn func(tx *sqlx.Tx) (audit.Change, error)) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	change, err := fn(tx)
+	if err != nil {
+		return err
+	}
+	if err := recordAudit(ctx, tx, change); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// recordAudit inserts an audit entry attributed to the actor carried by ctx.
+func recordAudit(ctx context.Context, exec sqlx.ExecerContext, change audit.Change) error {
+	actor, _ := audit.ActorFrom(ctx)
+	before := audit.Snapshot(change.Before)
+	after := audit.Snapshot(change.After)
+
+	const query = `INSERT INTO audit_log (tenant_id, actor_user_id, actor_api_key_id, actor_email, action, entity_type, entity_id, before, after, changes, ip_address)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+	_, err := exec.ExecContext(ctx, query,
+		tenant.ID(ctx),
+		nullableUUID(actor.UserID),
+		nullableUUID(actor.APIKeyID),
+		actor.Email,
+		change.Action,
+		change.EntityType,
+		change.EntityID,
+		models.NullJSONB(before),
+		models.NullJSONB(after),
+		audit.Diff(before, after),
+		actor.IP,
+	)
+	return err
+}
+
+func nullableUUID(id uuid.UUID) *uuid.UUID {
+	if id == uuid.Nil {
+		return nil
+	}
+	return &id
+}
+
+// reorderRows applies new positions to rows of table inside tx and describes the change as
+// {id: order} snapshots. It returns ErrNotFound when any id is missing from the tenant or
+// in the trash.
+func reorderRows(ctx context.Context, tx *sqlx.Tx, table, entityType string, ids []uuid.UUID, orders map[uuid.UUID]int) (audit.Change, error) {
+	tenantID := tenant.ID(ctx)
+	var current []struct {
+		ID    uuid.UUID `db:"id"`
+		Order int       `db:"order"`
+	}
+	query := `SELECT id, "order" FROM ` + table + ` WHERE tenant_id = $1 AND id = ANY($2) AND deleted_at IS NULL FOR UPDATE`
+	if err := tx.SelectContext(ctx, &current, query, tenantID, pq.Array(ids)); err != nil {
+		return audit.Change{}, err
+	}
+	if len(current) != len(orders) {
+		return audit.Change{}, ErrNotFound
+	}
+
+	before := models.JSONB{}
+	after := models.JSONB{}
+	for _, row := range current {
+		before[row.ID.String()] = row.Order
+		after[row.ID.String()] = orders[row.ID]
+	}
+
+	update := `UPDATE ` + table + ` SET "order" = $1 WHERE id = $2 AND tenant_id = $3`
+	if _, drafts := revisionTargets[entityType]; drafts {
+		update = `UPDATE ` + table + ` SET "order" = $1, has_unpublished_changes = TRUE WHERE id = $2 AND tenant_id = $3`
+	}
+	for _, id := range ids {
+		if _, err := tx.ExecContext(ctx, update, orders[id], id, tenantID); err != nil {
+			return audit.Change{}, err
+		}
+	}
+	return audit.Change{Action: audit.ActionReorder, EntityType: entityType, Before: before, After: after}, nil
+}
@@ -0,0 +1,108 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/tanydotai/tanyai/backend/internal/audit"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
+)
+
+// jsonArg matches a JSONB argument whose decoded value satisfies check.
+type jsonArg func(map[string]any) bool
+
+func (m jsonArg) Match(v driver.Value) bool {
+	raw, ok := v.([]byte)
+	if !ok {
+		return false
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return false
+	}
+	return m(decoded)
+}
+
+func TestServiceRepositoryUpdateRecordsAuditInTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewServiceRepository(sqlx.NewDb(db, "sqlmock"))
+	actorID := uuid.New()
+	ctx := audit.WithActor(context.Background(), audit.Actor{UserID: actorID, Email: "admin@example.com", IP: "10.0.0.1"})
+	id := uuid.New()
+	columns := []string{"id", "name", "description", "price_min", "price_max", "currency", "duration_label", "is_active", "order"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM services WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL FOR UPDATE`)).
+		WithArgs(id, tenant.DefaultID).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(id, "Audit", nil, 100.0, nil, "IDR", nil, true, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`UPDATE services SET`)).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(id, "Audit", nil, 150.0, nil, "IDR", nil, true, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO audit_log`)).
+		WithArgs(
+			tenant.DefaultID,
+			actorID,
+			nil,
+			"admin@example.com",
+			audit.ActionUpdate,
+			audit.EntityService,
+			id.String(),
+			jsonArg(func(before map[string]any) bool { return before["price_min"] == 100.0 }),
+			jsonArg(func(after map[string]any) bool { return after["price_min"] == 150.0 }),
+			jsonArg(func(changes map[string]any) bool {
+				_, priceChanged := changes["price_min"]
+				return priceChanged && len(changes) == 1
+			}),
+			"10.0.0.1",
+		).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	service := models.Service{ID: id, Name: "Audit", PriceMin: sql.NullFloat64{Float64: 150, Valid: true}, Currency: sql.NullString{String: "IDR", Valid: true}, IsActive: true, Order: 1}
+	if _, err := repo.Update(ctx, service); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestServiceRepositoryUpdateRollsBackWhenAuditFails(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewServiceRepository(sqlx.NewDb(db, "sqlmock"))
+	id := uuid.New()
+	columns := []string{"id", "name", "description", "price_min", "price_max", "currency", "duration_label", "is_active", "order"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`FOR UPDATE`)).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(id, "Audit", nil, nil, nil, nil, nil, true, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`UPDATE services SET`)).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(id, "Renamed", nil, nil, nil, nil, nil, true, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO audit_log`)).
+		WillReturnError(sql.ErrConnDone)
+	mock.ExpectRollback()
+
+	if _, err := repo.Update(context.Background(), models.Service{ID: id, Name: "Renamed", IsActive: true, Order: 1}); err != sql.ErrConnDone {
+		t.Fatalf("expected audit error, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
 )
 
 // ChatHistoryRepository persists chat interactions for auditing and analytics.
@@ -24,9 +25,9 @@ type chatHistoryRepository struct {
 }
 
 func (r *chatHistoryRepository) Create(ctx context.Context, history models.ChatHistory) (models.ChatHistory, error) {
-	const query = `INSERT INTO chat_history (chat_id, user_input, model, prompt, prompt_hash, prompt_length, response_text, latency_ms)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-RETURNING id, chat_id, user_input, model, prompt, prompt_hash, prompt_length, response_text, latency_ms, created_at`
+	const query = `INSERT INTO chat_history (chat_id, user_input, model, prompt, prompt_hash, prompt_length, response_text, latency_ms, cache_hit, experiment, variant, tenant_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+RETURNING id, chat_id, user_input, model, prompt, prompt_hash, prompt_length, response_text, latency_ms, cache_hit, experiment, variant, created_at`
 
 	var created models.ChatHistory
 	if err := r.db.GetContext(ctx, &created, query,
@@ -38,6 +39,10 @@ RETURNING id, chat_id, user_input, model, prompt, prompt_hash, prompt_length, re
 		history.PromptLength,
 		history.ResponseText,
 		history.LatencyMS,
+		history.CacheHit,
+		history.Experiment,
+		history.Variant,
+		tenant.ID(ctx),
 	); err != nil {
 		return models.ChatHistory{}, err
 	}
@@ -48,10 +53,10 @@ func (r *chatHistoryRepository) ListRecentByChat(ctx context.Context, chatID uui
 	if limit <= 0 {
 		limit = 5
 	}
-	const query = `SELECT id, chat_id, user_input, model, prompt, prompt_hash, prompt_length, response_text, latency_ms, created_at
-FROM chat_history WHERE chat_id = $1 ORDER BY created_at DESC LIMIT $2`
+	const query = `SELECT id, chat_id, user_input, model, prompt, prompt_hash, prompt_length, response_text, latency_ms, cache_hit, experiment, variant, created_at
+FROM chat_history WHERE chat_id = $1 AND tenant_id = $2 ORDER BY created_at DESC LIMIT $3`
 	var rows []models.ChatHistory
-	if err := r.db.SelectContext(ctx, &rows, query, chatID, limit); err != nil {
+	if err := r.db.SelectContext(ctx, &rows, query, chatID, tenant.ID(ctx), limit); err != nil {
 		return nil, err
 	}
 	return rows, nil
@@ -10,6 +10,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
 )
 
 func TestChatHistoryRepositoryCreate(t *testing.T) {
@@ -30,13 +31,15 @@ func TestChatHistoryRepositoryCreate(t *testing.T) {
 		PromptLength: 10,
 		ResponseText: "Jawaban",
 		LatencyMS:    123,
+		Experiment:   "pro-vs-flash",
+		Variant:      "flash",
 	}
 
-	rows := sqlmock.NewRows([]string{"id", "chat_id", "user_input", "model", "prompt", "prompt_hash", "prompt_length", "response_text", "latency_ms", "created_at"}).
-		AddRow(uuid.New(), history.ChatID, history.UserInput, history.Model, history.Prompt, history.PromptHash, history.PromptLength, history.ResponseText, history.LatencyMS, time.Now())
+	rows := sqlmock.NewRows([]string{"id", "chat_id", "user_input", "model", "prompt", "prompt_hash", "prompt_length", "response_text", "latency_ms", "cache_hit", "experiment", "variant", "created_at"}).
+		AddRow(uuid.New(), history.ChatID, history.UserInput, history.Model, history.Prompt, history.PromptHash, history.PromptLength, history.ResponseText, history.LatencyMS, history.CacheHit, history.Experiment, history.Variant, time.Now())
 
-	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO chat_history (chat_id, user_input, model, prompt, prompt_hash, prompt_length, response_text, latency_ms)`)).
-		WithArgs(history.ChatID, history.UserInput, history.Model, history.Prompt, history.PromptHash, history.PromptLength, history.ResponseText, history.LatencyMS).
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO chat_history (chat_id, user_input, model, prompt, prompt_hash, prompt_length, response_text, latency_ms, cache_hit, experiment, variant, tenant_id)`)).
+		WithArgs(history.ChatID, history.UserInput, history.Model, history.Prompt, history.PromptHash, history.PromptLength, history.ResponseText, history.LatencyMS, history.CacheHit, history.Experiment, history.Variant, tenant.DefaultID).
 		WillReturnRows(rows)
 
 	if _, err := repo.Create(context.Background(), history); err != nil {
@@ -0,0 +1,219 @@
+package repos
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/tanydotai/tanyai/backend/internal/audit"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
+)
+
+// DocumentSourceType marks the synthetic external source that holds a tenant's uploaded
+// documents. It is created on the first upload and is never synced over HTTP.
+const DocumentSourceType = "documents"
+
+// DocumentKind is the external_items kind of a document chunk.
+const DocumentKind = "document"
+
+const (
+	documentSourceName    = "Uploaded documents"
+	documentSourceBaseURL = "documents://uploads"
+)
+
+// documentSelect folds the chunks of every document back into one row. The tenant is
+// bound as $1; extra narrows the chunks before grouping.
+func documentSelect(extra string) string {
+	return `SELECT (i.metadata->>'documentId')::uuid AS id,
+    MIN(i.title) AS title,
+    COALESCE(MIN(i.metadata->>'filename'), '') AS filename,
+    COALESCE(MIN(i.metadata->>'contentType'), '') AS content_type,
+    COALESCE(MAX((i.metadata->>'sizeBytes')::bigint), 0) AS size_bytes,
+    COALESCE(MIN(i.metadata->>'key'), '') AS key,
+    COALESCE(MIN(i.metadata->>'url'), '') AS url,
+    COUNT(*) AS chunks,
+    MIN(i.created_at) AS created_at
+FROM external_items i
+JOIN external_sources s ON s.id = i.source_id
+WHERE s.tenant_id = $1 AND s.source_type = '` + DocumentSourceType + `' AND i.kind = '` + DocumentKind + `'` + extra + `
+GROUP BY i.metadata->>'documentId'`
+}
+
+// DocumentRepository stores uploaded documents as chunked external items.
+type DocumentRepository interface {
+	// Create stores one external item per chunk under the tenant's documents source,
+	// creating the source on first use.
+	Create(ctx context.Context, doc models.Document, chunks []string) (models.Document, error)
+	List(ctx context.Context, params ListParams) ([]models.Document, int64, error)
+	Get(ctx context.Context, id uuid.UUID) (models.Document, error)
+	// Delete removes every chunk of the document and returns it so its stored original
+	// can be deleted.
+	Delete(ctx context.Context, id uuid.UUID) (models.Document, error)
+}
+
+// NewDocumentRepository constructs a SQL-backed document repository.
+func NewDocumentRepository(db *sqlx.DB) DocumentRepository {
+	return &documentRepository{db: db}
+}
+
+type documentRepository struct {
+	db *sqlx.DB
+}
+
+func (r *documentRepository) Create(ctx context.Context, doc models.Document, chunks []string) (models.Document, error) {
+	const query = `INSERT INTO external_items (source_id, kind, title, url, content, metadata, hash, visible)
+VALUES ($1, $2, $3, $4, $5, $6, $7, TRUE)`
+
+	if doc.ID == uuid.Nil {
+		doc.ID = uuid.New()
+	}
+	base := doc.URL
+	if base == "" {
+		base = documentSourceBaseURL + "/" + doc.ID.String()
+	}
+
+	var created models.Document
+	err := withAudit(ctx, r.db, func(tx *sqlx.Tx) (audit.Change, error) {
+		sourceID, err := r.ensureSource(ctx, tx)
+		if err != nil {
+			return audit.Change{}, err
+		}
+		for i, chunk := range chunks {
+			metadata := models.JSONB{
+				"documentId":  doc.ID.String(),
+				"filename":    doc.Filename,
+				"contentType": doc.ContentType,
+				"sizeBytes":   doc.SizeBytes,
+				"chunk":       i + 1,
+				"chunks":      len(chunks),
+			}
+			if doc.Key != "" {
+				metadata["key"] = doc.Key
+				metadata["url"] = doc.URL
+			}
+			sum := sha256.Sum256([]byte(doc.ID.String() + ":" + strconv.Itoa(i) + ":" + chunk))
+			url := fmt.Sprintf("%s#chunk-%d", base, i+1)
+			if _, err := tx.ExecContext(ctx, query, sourceID, DocumentKind, doc.Title, url, chunk, metadata, hex.EncodeToString(sum[:])); err != nil {
+				if isUniqueViolation(err) {
+					return audit.Change{}, ErrConflict
+				}
+				return audit.Change{}, err
+			}
+		}
+		created, err = r.get(ctx, tx, doc.ID)
+		if err != nil {
+			return audit.Change{}, err
+		}
+		return audit.Change{
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntityDocument,
+			EntityID:   created.ID.String(),
+			After:      created,
+		}, nil
+	})
+	if err != nil {
+		return models.Document{}, err
+	}
+	return created, nil
+}
+
+// ensureSource returns the tenant's documents source, creating it when missing.
+func (r *documentRepository) ensureSource(ctx context.Context, tx *sqlx.Tx) (uuid.UUID, error) {
+	const selectQuery = `SELECT id FROM external_sources WHERE tenant_id = $1 AND source_type = $2 ORDER BY created_at LIMIT 1`
+	const insertQuery = `INSERT INTO external_sources (name, base_url, source_type, enabled, tenant_id) VALUES ($1, $2, $3, TRUE, $4) RETURNING id`
+
+	tenantID := tenant.ID(ctx)
+	var id uuid.UUID
+	err := tx.GetContext(ctx, &id, selectQuery, tenantID, DocumentSourceType)
+	if err == nil {
+		return id, nil
+	}
+	if err != sql.ErrNoRows {
+		return uuid.Nil, err
+	}
+	if err := tx.GetContext(ctx, &id, insertQuery, documentSourceName, documentSourceBaseURL, DocumentSourceType, tenantID); err != nil {
+		if isUniqueViolation(err) {
+			return uuid.Nil, ErrConflict
+		}
+		return uuid.Nil, err
+	}
+	return id, nil
+}
+
+func (r *documentRepository) List(ctx context.Context, params ListParams) ([]models.Document, int64, error) {
+	sortParams := params
+	if sortParams.SortField == "" && sortParams.SortDir == "" {
+		sortParams.SortDir = "desc"
+	}
+	orderBy, err := sortParams.ValidateSort(map[string]string{
+		"created_at": "d.created_at",
+		"title":      "LOWER(d.title)",
+		"size":       "d.size_bytes",
+	}, "created_at")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	from := `FROM (` + documentSelect("") + `) d`
+	query := `SELECT d.* ` + from + ` ORDER BY ` + orderBy + `, d.id LIMIT $2 OFFSET $3`
+
+	docs := make([]models.Document, 0, params.Limit)
+	if err := r.db.SelectContext(ctx, &docs, query, tenant.ID(ctx), params.Limit, params.Offset()); err != nil {
+		return nil, 0, err
+	}
+
+	var total int64
+	if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) `+from, tenant.ID(ctx)); err != nil {
+		return nil, 0, err
+	}
+	return docs, total, nil
+}
+
+func (r *documentRepository) Get(ctx context.Context, id uuid.UUID) (models.Document, error) {
+	return r.get(ctx, r.db, id)
+}
+
+func (r *documentRepository) get(ctx context.Context, q sqlx.QueryerContext, id uuid.UUID) (models.Document, error) {
+	var doc models.Document
+	query := documentSelect(` AND i.metadata->>'documentId' = $2`)
+	if err := sqlx.GetContext(ctx, q, &doc, query, tenant.ID(ctx), id.String()); err != nil {
+		if err == sql.ErrNoRows {
+			return models.Document{}, ErrNotFound
+		}
+		return models.Document{}, err
+	}
+	return doc, nil
+}
+
+func (r *documentRepository) Delete(ctx context.Context, id uuid.UUID) (models.Document, error) {
+	const query = `DELETE FROM external_items WHERE kind = $1 AND metadata->>'documentId' = $2
+    AND source_id IN (SELECT id FROM external_sources WHERE tenant_id = $3 AND source_type = $4)`
+
+	var deleted models.Document
+	err := withAudit(ctx, r.db, func(tx *sqlx.Tx) (audit.Change, error) {
+		doc, err := r.get(ctx, tx, id)
+		if err != nil {
+			return audit.Change{}, err
+		}
+		if _, err := tx.ExecContext(ctx, query, DocumentKind, id.String(), tenant.ID(ctx), DocumentSourceType); err != nil {
+			return audit.Change{}, err
+		}
+		deleted = doc
+		return audit.Change{
+			Action:     audit.ActionDelete,
+			EntityType: audit.EntityDocument,
+			EntityID:   id.String(),
+			Before:     doc,
+		}, nil
+	})
+	if err != nil {
+		return models.Document{}, err
+	}
+	return deleted, nil
+}
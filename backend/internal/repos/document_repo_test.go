@@ -0,0 +1,148 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/tanydotai/tanyai/backend/internal/audit"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
+)
+
+var documentColumnNames = []string{"id", "title", "filename", "content_type", "size_bytes", "key", "url", "chunks", "created_at"}
+
+func TestDocumentRepositoryCreateStoresChunksUnderDocumentSource(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewDocumentRepository(sqlx.NewDb(db, "sqlmock"))
+	docID, sourceID := uuid.New(), uuid.New()
+	now := time.Date(2025, 2, 12, 8, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id FROM external_sources WHERE tenant_id = $1 AND source_type = $2`)).
+		WithArgs(tenant.DefaultID, DocumentSourceType).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO external_sources`)).
+		WithArgs("Uploaded documents", "documents://uploads", DocumentSourceType, tenant.DefaultID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(sourceID))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO external_items`)).
+		WithArgs(sourceID, DocumentKind, "Daftar harga", "https://cdn/documents/a.pdf#chunk-1", "Paket dasar.", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO external_items`)).
+		WithArgs(sourceID, DocumentKind, "Daftar harga", "https://cdn/documents/a.pdf#chunk-2", "Paket lengkap.", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`AND i.metadata->>'documentId' = $2`)).
+		WithArgs(tenant.DefaultID, docID.String()).
+		WillReturnRows(sqlmock.NewRows(documentColumnNames).
+			AddRow(docID, "Daftar harga", "harga.pdf", "application/pdf", 2048, "documents/a.pdf", "https://cdn/documents/a.pdf", 2, now))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO audit_log`)).
+		WithArgs(tenant.DefaultID, nil, nil, "", audit.ActionCreate, audit.EntityDocument, docID.String(), nil, sqlmock.AnyArg(), sqlmock.AnyArg(), "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	created, err := repo.Create(context.Background(), models.Document{
+		ID:          docID,
+		Title:       "Daftar harga",
+		Filename:    "harga.pdf",
+		ContentType: "application/pdf",
+		SizeBytes:   2048,
+		Key:         "documents/a.pdf",
+		URL:         "https://cdn/documents/a.pdf",
+	}, []string{"Paket dasar.", "Paket lengkap."})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if created.ID != docID || created.Chunks != 2 {
+		t.Fatalf("unexpected document %+v", created)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestDocumentRepositoryListGroupsChunks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewDocumentRepository(sqlx.NewDb(db, "sqlmock"))
+	docID := uuid.New()
+	now := time.Date(2025, 2, 12, 8, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`GROUP BY i.metadata->>'documentId'\) d ORDER BY d.created_at DESC, d.id LIMIT \$2 OFFSET \$3`).
+		WithArgs(tenant.DefaultID, 20, 0).
+		WillReturnRows(sqlmock.NewRows(documentColumnNames).
+			AddRow(docID, "Catatan", "catatan.md", "text/markdown", 120, "", "", 1, now))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM (`)).
+		WithArgs(tenant.DefaultID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	docs, total, err := repo.List(context.Background(), ListParams{Page: 1, Limit: 20})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if total != 1 || len(docs) != 1 || docs[0].Filename != "catatan.md" || docs[0].Chunks != 1 {
+		t.Fatalf("unexpected documents %+v (total %d)", docs, total)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestDocumentRepositoryDeleteRemovesEveryChunk(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewDocumentRepository(sqlx.NewDb(db, "sqlmock"))
+	docID := uuid.New()
+	now := time.Date(2025, 2, 12, 8, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`AND i.metadata->>'documentId' = $2`)).
+		WithArgs(tenant.DefaultID, docID.String()).
+		WillReturnRows(sqlmock.NewRows(documentColumnNames))
+	mock.ExpectRollback()
+
+	if _, err := repo.Delete(context.Background(), docID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`AND i.metadata->>'documentId' = $2`)).
+		WithArgs(tenant.DefaultID, docID.String()).
+		WillReturnRows(sqlmock.NewRows(documentColumnNames).
+			AddRow(docID, "Daftar harga", "harga.pdf", "application/pdf", 2048, "documents/a.pdf", "https://cdn/documents/a.pdf", 3, now))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM external_items WHERE kind = $1 AND metadata->>'documentId' = $2`)).
+		WithArgs(DocumentKind, docID.String(), tenant.DefaultID, DocumentSourceType).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO audit_log`)).
+		WithArgs(tenant.DefaultID, nil, nil, "", audit.ActionDelete, audit.EntityDocument, docID.String(), sqlmock.AnyArg(), nil, sqlmock.AnyArg(), "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	deleted, err := repo.Delete(context.Background(), docID)
+	if err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if deleted.Key != "documents/a.pdf" {
+		t.Fatalf("expected stored key to be returned, got %+v", deleted)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
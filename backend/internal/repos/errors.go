@@ -9,4 +9,8 @@ var (
 	ErrInvalidSortField = errors.New("invalid sort field")
 	// ErrInvalidSortDirection indicates the sort direction is invalid.
 	ErrInvalidSortDirection = errors.New("invalid sort direction")
+	// ErrConflict indicates the record collides with an existing unique value.
+	ErrConflict = errors.New("record already exists")
+	// ErrUnsupportedEntity indicates the entity type has no draft/publish workflow.
+	ErrUnsupportedEntity = errors.New("entity does not support revisions")
 )
@@ -8,7 +8,9 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/tanydotai/tanyai/backend/internal/audit"
 	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
 )
 
 // ExternalItemWithSource couples an external item with its source metadata.
@@ -89,8 +91,9 @@ func (r *externalItemRepository) List(ctx context.Context, params ExternalItemLi
 FROM external_items i
 JOIN external_sources s ON s.id = i.source_id`)
 
-	var where []string
-	args := make([]any, 0, 5)
+	where := []string{"s.tenant_id = $1"}
+	args := make([]any, 0, 6)
+	args = append(args, tenant.ID(ctx))
 
 	if params.SourceID != nil {
 		where = append(where, fmt.Sprintf("i.source_id = $%d", len(args)+1))
@@ -110,10 +113,8 @@ JOIN external_sources s ON s.id = i.source_id`)
 		args = append(args, search, search)
 	}
 
-	if len(where) > 0 {
-		builder.WriteString(" WHERE ")
-		builder.WriteString(strings.Join(where, " AND "))
-	}
+	builder.WriteString(" WHERE ")
+	builder.WriteString(strings.Join(where, " AND "))
 
 	sortParams := params
 	if sortParams.SortField == "" && sortParams.SortDir == "" {
@@ -142,11 +143,9 @@ JOIN external_sources s ON s.id = i.source_id`)
 	}
 
 	countBuilder := strings.Builder{}
-	countBuilder.WriteString("SELECT COUNT(*) FROM external_items i")
-	if len(where) > 0 {
-		countBuilder.WriteString(" WHERE ")
-		countBuilder.WriteString(strings.Join(where, " AND "))
-	}
+	countBuilder.WriteString("SELECT COUNT(*) FROM external_items i JOIN external_sources s ON s.id = i.source_id")
+	countBuilder.WriteString(" WHERE ")
+	countBuilder.WriteString(strings.Join(where, " AND "))
 	countQuery := countBuilder.String()
 	var total int64
 	if err := r.db.GetContext(ctx, &total, countQuery, filterArgs...); err != nil {
@@ -157,12 +156,29 @@ JOIN external_sources s ON s.id = i.source_id`)
 }
 
 func (r *externalItemRepository) SetVisibility(ctx context.Context, id uuid.UUID, visible bool) (ExternalItemWithSource, error) {
-	const query = `UPDATE external_items SET visible = $2, updated_at = NOW() WHERE id = $1 RETURNING id, source_id, kind, title, url, summary, content, metadata, published_at, hash, visible, created_at, updated_at, (SELECT name FROM external_sources WHERE id = external_items.source_id) AS source_name, (SELECT base_url FROM external_sources WHERE id = external_items.source_id) AS source_base_url`
+	const lockQuery = `SELECT i.id, i.source_id, i.kind, i.title, i.url, i.summary, i.content, i.metadata, i.published_at, i.hash, i.visible, i.created_at, i.updated_at FROM external_items i WHERE i.id = $1 AND i.source_id IN (SELECT id FROM external_sources WHERE tenant_id = $2) FOR UPDATE`
+	const query = `UPDATE external_items SET visible = $2, updated_at = NOW() WHERE id = $1 AND source_id IN (SELECT id FROM external_sources WHERE tenant_id = $3) RETURNING id, source_id, kind, title, url, summary, content, metadata, published_at, hash, visible, created_at, updated_at, (SELECT name FROM external_sources WHERE id = external_items.source_id) AS source_name, (SELECT base_url FROM external_sources WHERE id = external_items.source_id) AS source_base_url`
 	var item ExternalItemWithSource
-	if err := r.db.GetContext(ctx, &item, query, id, visible); err != nil {
-		if err == sql.ErrNoRows {
-			return ExternalItemWithSource{}, ErrNotFound
+	err := withAudit(ctx, r.db, func(tx *sqlx.Tx) (audit.Change, error) {
+		var before models.ExternalItem
+		if err := tx.GetContext(ctx, &before, lockQuery, id, tenant.ID(ctx)); err != nil {
+			if err == sql.ErrNoRows {
+				return audit.Change{}, ErrNotFound
+			}
+			return audit.Change{}, err
 		}
+		if err := tx.GetContext(ctx, &item, query, id, visible, tenant.ID(ctx)); err != nil {
+			return audit.Change{}, err
+		}
+		return audit.Change{
+			Action:     audit.ActionVisibility,
+			EntityType: audit.EntityExternalItem,
+			EntityID:   id.String(),
+			Before:     map[string]any{"title": before.Title, "visible": before.Visible},
+			After:      map[string]any{"title": item.Title, "visible": item.Visible},
+		}, nil
+	})
+	if err != nil {
 		return ExternalItemWithSource{}, err
 	}
 	return item, nil
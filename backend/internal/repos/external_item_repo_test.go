@@ -12,6 +12,7 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/require"
 	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
 )
 
 func TestExternalItemRepositoryUpsert(t *testing.T) {
@@ -50,6 +51,7 @@ func TestExternalItemRepositoryListWithFilters(t *testing.T) {
 	repo := NewExternalItemRepository(sqlx.NewDb(db, "sqlmock"))
 
 	sourceID := uuid.New()
+	tenantID := uuid.New()
 	now := time.Now()
 	rows := sqlmock.NewRows([]string{"id", "source_id", "kind", "title", "url", "summary", "content", "metadata", "published_at", "hash", "visible", "created_at", "updated_at", "source_name", "source_base_url"}).
 		AddRow(uuid.New(), sourceID, "post", "Hello", "https://noahis.me/post", "Summary", nil, []byte(`{"sourceName":"noahis.me"}`), now, "hash", true, now, now, "noahis.me", "https://noahis.me")
@@ -58,12 +60,12 @@ func TestExternalItemRepositoryListWithFilters(t *testing.T) {
 
 	mock.ExpectQuery(regexp.QuoteMeta(`SELECT i.id, i.source_id, i.kind, i.title, i.url, i.summary, i.content, i.metadata, i.published_at, i.hash, i.visible, i.created_at, i.updated_at, s.name AS source_name, s.base_url AS source_base_url
 FROM external_items i
-JOIN external_sources s ON s.id = i.source_id WHERE i.source_id = $1 AND LOWER(i.kind) = LOWER($2) AND i.visible = $3 AND (LOWER(i.title) LIKE $4 OR LOWER(i.summary) LIKE $5) ORDER BY i.published_at DESC LIMIT $6 OFFSET $7`)).
-		WithArgs(sourceID, "post", true, search, search, 20, 0).
+JOIN external_sources s ON s.id = i.source_id WHERE s.tenant_id = $1 AND i.source_id = $2 AND LOWER(i.kind) = LOWER($3) AND i.visible = $4 AND (LOWER(i.title) LIKE $5 OR LOWER(i.summary) LIKE $6) ORDER BY i.published_at DESC LIMIT $7 OFFSET $8`)).
+		WithArgs(tenantID, sourceID, "post", true, search, search, 20, 0).
 		WillReturnRows(rows)
 
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM external_items i WHERE i.source_id = $1 AND LOWER(i.kind) = LOWER($2) AND i.visible = $3 AND (LOWER(i.title) LIKE $4 OR LOWER(i.summary) LIKE $5)`)).
-		WithArgs(sourceID, "post", true, search, search).
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM external_items i JOIN external_sources s ON s.id = i.source_id WHERE s.tenant_id = $1 AND i.source_id = $2 AND LOWER(i.kind) = LOWER($3) AND i.visible = $4 AND (LOWER(i.title) LIKE $5 OR LOWER(i.summary) LIKE $6)`)).
+		WithArgs(tenantID, sourceID, "post", true, search, search).
 		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
 
 	visible := true
@@ -75,7 +77,7 @@ JOIN external_sources s ON s.id = i.source_id WHERE i.source_id = $1 AND LOWER(i
 		Search:     "golang",
 	}
 
-	items, total, err := repo.List(context.Background(), params)
+	items, total, err := repo.List(tenant.WithID(context.Background(), tenantID), params)
 	require.NoError(t, err)
 	require.Equal(t, int64(1), total)
 	require.Len(t, items, 1)
@@ -94,9 +96,11 @@ func TestExternalItemRepositorySetVisibilityNotFound(t *testing.T) {
 
 	id := uuid.New()
 
-	mock.ExpectQuery(regexp.QuoteMeta(`UPDATE external_items SET visible = $2, updated_at = NOW() WHERE id = $1 RETURNING id, source_id, kind, title, url, summary, content, metadata, published_at, hash, visible, created_at, updated_at, (SELECT name FROM external_sources WHERE id = external_items.source_id) AS source_name, (SELECT base_url FROM external_sources WHERE id = external_items.source_id) AS source_base_url`)).
-		WithArgs(id, false).
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM external_items i WHERE i.id = $1 AND i.source_id IN (SELECT id FROM external_sources WHERE tenant_id = $2) FOR UPDATE`)).
+		WithArgs(id, tenant.DefaultID).
 		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
 
 	_, err = repo.SetVisibility(context.Background(), id, false)
 	require.ErrorIs(t, err, ErrNotFound)
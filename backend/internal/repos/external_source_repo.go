@@ -9,6 +9,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
 )
 
 // ExternalSourceRepository exposes DB operations for external_sources.
@@ -33,8 +34,9 @@ type externalSourceRepository struct {
 }
 
 func (r *externalSourceRepository) List(ctx context.Context, params ListParams) ([]models.ExternalSource, int64, error) {
-	const baseQuery = `SELECT id, name, base_url, source_type, enabled, etag, last_modified, last_synced_at, created_at, updated_at FROM external_sources`
-	const countQuery = `SELECT COUNT(*) FROM external_sources`
+	tenantID := tenant.ID(ctx)
+	const baseQuery = `SELECT id, name, base_url, source_type, enabled, etag, last_modified, last_synced_at, created_at, updated_at FROM external_sources WHERE tenant_id = $1`
+	const countQuery = `SELECT COUNT(*) FROM external_sources WHERE tenant_id = $1`
 
 	orderBy, err := params.ValidateSort(map[string]string{
 		"name":       "LOWER(name)",
@@ -45,15 +47,15 @@ func (r *externalSourceRepository) List(ctx context.Context, params ListParams)
 		return nil, 0, err
 	}
 
-	query := baseQuery + " ORDER BY " + orderBy + " LIMIT $1 OFFSET $2"
+	query := baseQuery + " ORDER BY " + orderBy + " LIMIT $2 OFFSET $3"
 
 	var sources []models.ExternalSource
-	if err := r.db.SelectContext(ctx, &sources, query, params.Limit, params.Offset()); err != nil {
+	if err := r.db.SelectContext(ctx, &sources, query, tenantID, params.Limit, params.Offset()); err != nil {
 		return nil, 0, err
 	}
 
 	var total int64
-	if err := r.db.GetContext(ctx, &total, countQuery); err != nil {
+	if err := r.db.GetContext(ctx, &total, countQuery, tenantID); err != nil {
 		return nil, 0, err
 	}
 
@@ -61,10 +63,10 @@ func (r *externalSourceRepository) List(ctx context.Context, params ListParams)
 }
 
 func (r *externalSourceRepository) Get(ctx context.Context, id uuid.UUID) (models.ExternalSource, error) {
-	const query = `SELECT id, name, base_url, source_type, enabled, etag, last_modified, last_synced_at, created_at, updated_at FROM external_sources WHERE id = $1`
+	const query = `SELECT id, name, base_url, source_type, enabled, etag, last_modified, last_synced_at, created_at, updated_at FROM external_sources WHERE id = $1 AND tenant_id = $2`
 
 	var source models.ExternalSource
-	if err := r.db.GetContext(ctx, &source, query, id); err != nil {
+	if err := r.db.GetContext(ctx, &source, query, id, tenant.ID(ctx)); err != nil {
 		if err == sql.ErrNoRows {
 			return models.ExternalSource{}, ErrNotFound
 		}
@@ -74,10 +76,10 @@ func (r *externalSourceRepository) Get(ctx context.Context, id uuid.UUID) (model
 }
 
 func (r *externalSourceRepository) FindByBaseURL(ctx context.Context, baseURL string) (models.ExternalSource, error) {
-	const query = `SELECT id, name, base_url, source_type, enabled, etag, last_modified, last_synced_at, created_at, updated_at FROM external_sources WHERE LOWER(base_url) = LOWER($1)`
+	const query = `SELECT id, name, base_url, source_type, enabled, etag, last_modified, last_synced_at, created_at, updated_at FROM external_sources WHERE LOWER(base_url) = LOWER($1) AND tenant_id = $2`
 
 	var source models.ExternalSource
-	if err := r.db.GetContext(ctx, &source, query, strings.TrimSpace(baseURL)); err != nil {
+	if err := r.db.GetContext(ctx, &source, query, strings.TrimSpace(baseURL), tenant.ID(ctx)); err != nil {
 		if err == sql.ErrNoRows {
 			return models.ExternalSource{}, ErrNotFound
 		}
@@ -87,8 +89,8 @@ func (r *externalSourceRepository) FindByBaseURL(ctx context.Context, baseURL st
 }
 
 func (r *externalSourceRepository) Create(ctx context.Context, source models.ExternalSource) (models.ExternalSource, error) {
-	const query = `INSERT INTO external_sources (name, base_url, source_type, enabled, etag, last_modified, last_synced_at)
-VALUES ($1, $2, $3, $4, $5, $6, $7)
+	const query = `INSERT INTO external_sources (name, base_url, source_type, enabled, etag, last_modified, last_synced_at, tenant_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 RETURNING id, name, base_url, source_type, enabled, etag, last_modified, last_synced_at, created_at, updated_at`
 
 	var created models.ExternalSource
@@ -100,6 +102,7 @@ RETURNING id, name, base_url, source_type, enabled, etag, last_modified, last_sy
 		source.ETag,
 		source.LastModified,
 		source.LastSyncedAt,
+		tenant.ID(ctx),
 	); err != nil {
 		return models.ExternalSource{}, err
 	}
@@ -116,7 +119,7 @@ func (r *externalSourceRepository) Update(ctx context.Context, source models.Ext
     last_modified = $7,
     last_synced_at = $8,
     updated_at = NOW()
-WHERE id = $1
+WHERE id = $1 AND tenant_id = $9
 RETURNING id, name, base_url, source_type, enabled, etag, last_modified, last_synced_at, created_at, updated_at`
 
 	var updated models.ExternalSource
@@ -129,6 +132,7 @@ RETURNING id, name, base_url, source_type, enabled, etag, last_modified, last_sy
 		source.ETag,
 		source.LastModified,
 		source.LastSyncedAt,
+		tenant.ID(ctx),
 	); err != nil {
 		if err == sql.ErrNoRows {
 			return models.ExternalSource{}, ErrNotFound
@@ -155,9 +159,9 @@ func (r *externalSourceRepository) UpdateSyncState(ctx context.Context, id uuid.
 }
 
 func (r *externalSourceRepository) SetEnabled(ctx context.Context, id uuid.UUID, enabled bool) (models.ExternalSource, error) {
-	const query = `UPDATE external_sources SET enabled = $2, updated_at = NOW() WHERE id = $1 RETURNING id, name, base_url, source_type, enabled, etag, last_modified, last_synced_at, created_at, updated_at`
+	const query = `UPDATE external_sources SET enabled = $2, updated_at = NOW() WHERE id = $1 AND tenant_id = $3 RETURNING id, name, base_url, source_type, enabled, etag, last_modified, last_synced_at, created_at, updated_at`
 	var source models.ExternalSource
-	if err := r.db.GetContext(ctx, &source, query, id, enabled); err != nil {
+	if err := r.db.GetContext(ctx, &source, query, id, enabled, tenant.ID(ctx)); err != nil {
 		if err == sql.ErrNoRows {
 			return models.ExternalSource{}, ErrNotFound
 		}
@@ -170,6 +174,7 @@ func (r *externalSourceRepository) EnsureDefaults(ctx context.Context, defaults
 	if len(defaults) == 0 {
 		return nil
 	}
+	tenantID := tenant.ID(ctx)
 
 	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
@@ -184,10 +189,10 @@ func (r *externalSourceRepository) EnsureDefaults(ctx context.Context, defaults
 			continue
 		}
 		var existing models.ExternalSource
-		err := tx.GetContext(ctx, &existing, `SELECT id, name, base_url, source_type, enabled, etag, last_modified, last_synced_at, created_at, updated_at FROM external_sources WHERE LOWER(base_url) = LOWER($1) LIMIT 1`, def.BaseURL)
+		err := tx.GetContext(ctx, &existing, `SELECT id, name, base_url, source_type, enabled, etag, last_modified, last_synced_at, created_at, updated_at FROM external_sources WHERE LOWER(base_url) = LOWER($1) AND tenant_id = $2 LIMIT 1`, def.BaseURL, tenantID)
 		if err != nil {
 			if err == sql.ErrNoRows {
-				if _, err := tx.ExecContext(ctx, `INSERT INTO external_sources (name, base_url, source_type, enabled) VALUES ($1, $2, $3, $4)`, def.Name, def.BaseURL, def.SourceType, def.Enabled); err != nil {
+				if _, err := tx.ExecContext(ctx, `INSERT INTO external_sources (name, base_url, source_type, enabled, tenant_id) VALUES ($1, $2, $3, $4, $5)`, def.Name, def.BaseURL, def.SourceType, def.Enabled, tenantID); err != nil {
 					return err
 				}
 				continue
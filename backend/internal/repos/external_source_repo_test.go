@@ -12,6 +12,7 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/require"
 	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
 )
 
 func TestExternalSourceRepositoryList(t *testing.T) {
@@ -25,11 +26,12 @@ func TestExternalSourceRepositoryList(t *testing.T) {
 	rows := sqlmock.NewRows([]string{"id", "name", "base_url", "source_type", "enabled", "etag", "last_modified", "last_synced_at", "created_at", "updated_at"}).
 		AddRow(uuid.New(), "noahis.me", "https://noahis.me", "auto", true, nil, now, now, now, now)
 
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, base_url, source_type, enabled, etag, last_modified, last_synced_at, created_at, updated_at FROM external_sources ORDER BY LOWER(name) ASC LIMIT $1 OFFSET $2`)).
-		WithArgs(10, 0).
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, base_url, source_type, enabled, etag, last_modified, last_synced_at, created_at, updated_at FROM external_sources WHERE tenant_id = $1 ORDER BY LOWER(name) ASC LIMIT $2 OFFSET $3`)).
+		WithArgs(tenant.DefaultID, 10, 0).
 		WillReturnRows(rows)
 
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM external_sources`)).
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM external_sources WHERE tenant_id = $1`)).
+		WithArgs(tenant.DefaultID).
 		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
 
 	sources, total, err := repo.List(context.Background(), ListParams{Page: 1, Limit: 10, SortField: "name", SortDir: "asc"})
@@ -68,11 +70,11 @@ func TestExternalSourceRepositoryEnsureDefaultsInsert(t *testing.T) {
 	repo := NewExternalSourceRepository(sqlx.NewDb(db, "sqlmock"))
 
 	mock.ExpectBegin()
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, base_url, source_type, enabled, etag, last_modified, last_synced_at, created_at, updated_at FROM external_sources WHERE LOWER(base_url) = LOWER($1) LIMIT 1`)).
-		WithArgs("https://noahis.me").
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, base_url, source_type, enabled, etag, last_modified, last_synced_at, created_at, updated_at FROM external_sources WHERE LOWER(base_url) = LOWER($1) AND tenant_id = $2 LIMIT 1`)).
+		WithArgs("https://noahis.me", tenant.DefaultID).
 		WillReturnError(sql.ErrNoRows)
-	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO external_sources (name, base_url, source_type, enabled) VALUES ($1, $2, $3, $4)`)).
-		WithArgs("noahis.me", "https://noahis.me", "auto", true).
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO external_sources (name, base_url, source_type, enabled, tenant_id) VALUES ($1, $2, $3, $4, $5)`)).
+		WithArgs("noahis.me", "https://noahis.me", "auto", true, tenant.DefaultID).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit()
 
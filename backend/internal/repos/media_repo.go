@@ -0,0 +1,282 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/tanydotai/tanyai/backend/internal/audit"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
+)
+
+const uploadColumns = `id, tenant_id, parent_id, key, url, content_type, size_bytes, uploaded_by, created_at`
+
+// mediaStem strips the extension from an upload key. Variants are stored as
+// "<stem>_<variant>.<ext>", so content mentioning the stem uses the original or one of its
+// variants. Matching on the key rather than the URL keeps working across CDN base changes
+// and signed URLs.
+const mediaStem = `regexp_replace(u.key, '\.[^/.]*$', '')`
+
+// publishedRevisionIDs selects the revisions currently served to visitors.
+const publishedRevisionIDs = `SELECT published_revision_id FROM profile WHERE published_revision_id IS NOT NULL
+        UNION ALL SELECT published_revision_id FROM projects WHERE published_revision_id IS NOT NULL`
+
+// mediaRefs lists the tenant's drafts and published revisions mentioning the upload.
+// Trashed projects count as references because they can still be restored.
+const mediaRefs = `COALESCE((SELECT jsonb_agg(ref) FROM (
+    SELECT jsonb_build_object('entity', 'profile', 'id', p.id, 'published', FALSE) AS ref FROM profile p
+    WHERE p.tenant_id = u.tenant_id AND (strpos(p.avatar_url, ` + mediaStem + `) > 0 OR strpos(COALESCE(p.avatar_variants::text, ''), ` + mediaStem + `) > 0)
+    UNION ALL
+    SELECT jsonb_build_object('entity', 'project', 'id', p.id, 'published', FALSE) FROM projects p
+    WHERE p.tenant_id = u.tenant_id AND (strpos(COALESCE(p.image_url, ''), ` + mediaStem + `) > 0 OR strpos(COALESCE(p.image_variants::text, ''), ` + mediaStem + `) > 0)
+    UNION ALL
+    SELECT jsonb_build_object('entity', r.entity_type, 'id', r.entity_id, 'published', TRUE) FROM content_revisions r
+    WHERE r.tenant_id = u.tenant_id AND r.id IN (` + publishedRevisionIDs + `) AND strpos(r.data::text, ` + mediaStem + `) > 0
+) refs), '[]'::jsonb) AS refs`
+
+const mediaSelect = `SELECT u.id, u.tenant_id, u.parent_id, u.key, u.url, u.content_type, u.size_bytes, u.uploaded_by, u.created_at,
+    COALESCE(us.email, '') AS uploader_email,
+    COALESCE((SELECT jsonb_agg(jsonb_build_object('key', v.key, 'url', v.url, 'content_type', v.content_type, 'size_bytes', v.size_bytes) ORDER BY v.key)
+        FROM uploads v WHERE v.parent_id = u.id), '[]'::jsonb) AS variants,
+    ` + mediaRefs + `
+FROM uploads u
+LEFT JOIN users us ON us.id = u.uploaded_by`
+
+// unreferenced matches stems that no tenant's draft or published content mentions. The
+// cleanup job looks across tenants because an imported bundle may reuse another
+// tenant's URLs.
+func unreferenced(stem string) string {
+	return `NOT EXISTS (SELECT 1 FROM profile p WHERE strpos(p.avatar_url, ` + stem + `) > 0 OR strpos(COALESCE(p.avatar_variants::text, ''), ` + stem + `) > 0)
+    AND NOT EXISTS (SELECT 1 FROM projects p WHERE strpos(COALESCE(p.image_url, ''), ` + stem + `) > 0 OR strpos(COALESCE(p.image_variants::text, ''), ` + stem + `) > 0)
+    AND NOT EXISTS (SELECT 1 FROM content_revisions r WHERE r.id IN (` + publishedRevisionIDs + `) AND strpos(r.data::text, ` + stem + `) > 0)`
+}
+
+// MediaListParams filters the media library.
+type MediaListParams struct {
+	ListParams
+	// ContentType matches a full MIME type or, when ending in "/", a family such as "image/".
+	ContentType string
+	// Orphaned limits results to unreferenced (true) or referenced (false) uploads.
+	Orphaned *bool
+}
+
+// MediaRepository records uploaded objects.
+type MediaRepository interface {
+	// Create records an original upload together with its variants.
+	Create(ctx context.Context, upload models.Upload, variants []models.Upload) (models.Upload, error)
+	List(ctx context.Context, params MediaListParams) ([]models.MediaItem, int64, error)
+	Get(ctx context.Context, id uuid.UUID) (models.MediaItem, error)
+	// Delete removes an upload and its variants and returns the removed rows so their
+	// objects can be deleted. Referenced uploads return ErrConflict unless force is set.
+	Delete(ctx context.Context, id uuid.UUID, force bool) ([]models.Upload, error)
+	// PurgeOrphans removes uploads of every tenant created before cutoff that no content
+	// references, returning the removed originals and variants.
+	PurgeOrphans(ctx context.Context, cutoff time.Time) ([]models.Upload, error)
+	// Registered reports whether an upload with key exists in any tenant.
+	Registered(ctx context.Context, key string) (bool, error)
+	// FilterUntracked returns the keys that have no upload row and are not referenced by
+	// any content, such as objects stored before uploads were recorded.
+	FilterUntracked(ctx context.Context, keys []string) ([]string, error)
+}
+
+// NewMediaRepository constructs a SQL-backed media repository.
+func NewMediaRepository(db *sqlx.DB) MediaRepository {
+	return &mediaRepository{db: db}
+}
+
+type mediaRepository struct {
+	db *sqlx.DB
+}
+
+func (r *mediaRepository) Create(ctx context.Context, upload models.Upload, variants []models.Upload) (models.Upload, error) {
+	const query = `INSERT INTO uploads (tenant_id, parent_id, key, url, content_type, size_bytes, uploaded_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING ` + uploadColumns
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return models.Upload{}, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	tenantID := tenant.ID(ctx)
+	var created models.Upload
+	if err := tx.GetContext(ctx, &created, query, tenantID, nil, upload.Key, upload.URL, upload.ContentType, upload.SizeBytes, upload.UploadedBy); err != nil {
+		if isUniqueViolation(err) {
+			return models.Upload{}, ErrConflict
+		}
+		return models.Upload{}, err
+	}
+	for _, variant := range variants {
+		if _, err := tx.ExecContext(ctx, query, tenantID, created.ID, variant.Key, variant.URL, variant.ContentType, variant.SizeBytes, upload.UploadedBy); err != nil {
+			if isUniqueViolation(err) {
+				return models.Upload{}, ErrConflict
+			}
+			return models.Upload{}, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return models.Upload{}, err
+	}
+	return created, nil
+}
+
+func (r *mediaRepository) List(ctx context.Context, params MediaListParams) ([]models.MediaItem, int64, error) {
+	where := []string{"TRUE"}
+	args := []any{tenant.ID(ctx)}
+	if params.ContentType != "" {
+		if strings.HasSuffix(params.ContentType, "/") {
+			where = append(where, fmt.Sprintf("m.content_type LIKE $%d", len(args)+1))
+			args = append(args, params.ContentType+"%")
+		} else {
+			where = append(where, fmt.Sprintf("m.content_type = $%d", len(args)+1))
+			args = append(args, params.ContentType)
+		}
+	}
+	if params.Orphaned != nil {
+		if *params.Orphaned {
+			where = append(where, "m.refs = '[]'::jsonb")
+		} else {
+			where = append(where, "m.refs <> '[]'::jsonb")
+		}
+	}
+
+	sortParams := params.ListParams
+	if sortParams.SortField == "" && sortParams.SortDir == "" {
+		sortParams.SortDir = "desc"
+	}
+	orderBy, err := sortParams.ValidateSort(map[string]string{
+		"created_at": "m.created_at",
+		"size":       "m.size_bytes",
+		"key":        "m.key",
+	}, "created_at")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	from := `FROM (` + mediaSelect + `
+WHERE u.tenant_id = $1 AND u.parent_id IS NULL) m WHERE ` + strings.Join(where, " AND ")
+	filterArgs := append([]any(nil), args...)
+	query := fmt.Sprintf(`SELECT m.* %s ORDER BY %s, m.id LIMIT $%d OFFSET $%d`, from, orderBy, len(args)+1, len(args)+2)
+	args = append(args, params.Limit, params.Offset())
+
+	items := make([]models.MediaItem, 0, params.Limit)
+	if err := r.db.SelectContext(ctx, &items, query, args...); err != nil {
+		return nil, 0, err
+	}
+
+	var total int64
+	if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) `+from, filterArgs...); err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+func (r *mediaRepository) Get(ctx context.Context, id uuid.UUID) (models.MediaItem, error) {
+	return r.get(ctx, r.db, id)
+}
+
+func (r *mediaRepository) get(ctx context.Context, q sqlx.QueryerContext, id uuid.UUID) (models.MediaItem, error) {
+	var item models.MediaItem
+	query := mediaSelect + `
+WHERE u.id = $1 AND u.tenant_id = $2 AND u.parent_id IS NULL`
+	if err := sqlx.GetContext(ctx, q, &item, query, id, tenant.ID(ctx)); err != nil {
+		if err == sql.ErrNoRows {
+			return models.MediaItem{}, ErrNotFound
+		}
+		return models.MediaItem{}, err
+	}
+	return item, nil
+}
+
+func (r *mediaRepository) Delete(ctx context.Context, id uuid.UUID, force bool) ([]models.Upload, error) {
+	const query = `DELETE FROM uploads WHERE id = $1 OR parent_id = $1 RETURNING ` + uploadColumns
+
+	var removed []models.Upload
+	err := withAudit(ctx, r.db, func(tx *sqlx.Tx) (audit.Change, error) {
+		item, err := r.get(ctx, tx, id)
+		if err != nil {
+			return audit.Change{}, err
+		}
+		if len(item.References) > 0 && !force {
+			return audit.Change{}, ErrConflict
+		}
+		if err := tx.SelectContext(ctx, &removed, query, id); err != nil {
+			return audit.Change{}, err
+		}
+		return audit.Change{Action: audit.ActionDelete, EntityType: audit.EntityUpload, EntityID: item.Key, Before: item.Upload}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
+func (r *mediaRepository) PurgeOrphans(ctx context.Context, cutoff time.Time) ([]models.Upload, error) {
+	query := `WITH orphans AS (
+    SELECT u.id FROM uploads u
+    WHERE u.parent_id IS NULL AND u.created_at < $1 AND ` + unreferenced(mediaStem) + `
+)
+DELETE FROM uploads WHERE id IN (SELECT id FROM orphans) OR parent_id IN (SELECT id FROM orphans)
+RETURNING ` + uploadColumns
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var removed []models.Upload
+	if err := tx.SelectContext(ctx, &removed, query, cutoff); err != nil {
+		return nil, err
+	}
+	for _, upload := range removed {
+		if upload.ParentID != nil {
+			continue
+		}
+		change := audit.Change{Action: audit.ActionPurge, EntityType: audit.EntityUpload, EntityID: upload.Key, Before: upload}
+		if err := recordAudit(tenant.WithID(ctx, upload.TenantID), tx, change); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
+func (r *mediaRepository) Registered(ctx context.Context, key string) (bool, error) {
+	var registered bool
+	if err := r.db.GetContext(ctx, &registered, `SELECT EXISTS (SELECT 1 FROM uploads WHERE key = $1)`, key); err != nil {
+		return false, err
+	}
+	return registered, nil
+}
+
+func (r *mediaRepository) FilterUntracked(ctx context.Context, keys []string) ([]string, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	// Untracked variants are matched through their original's stem.
+	const stem = `regexp_replace(regexp_replace(o.key, '\.[^/.]*$', ''), '_[^/]*$', '')`
+	query := `SELECT o.key FROM unnest($1::text[]) AS o(key)
+WHERE NOT EXISTS (SELECT 1 FROM uploads t WHERE t.key = o.key)
+    AND ` + unreferenced(stem) + `
+ORDER BY o.key`
+
+	var untracked []string
+	if err := r.db.SelectContext(ctx, &untracked, query, pq.StringArray(keys)); err != nil {
+		return nil, err
+	}
+	return untracked, nil
+}
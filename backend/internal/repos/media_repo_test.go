@@ -0,0 +1,201 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/tanydotai/tanyai/backend/internal/audit"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
+)
+
+var uploadColumnNames = []string{"id", "tenant_id", "parent_id", "key", "url", "content_type", "size_bytes", "uploaded_by", "created_at"}
+
+var mediaColumnNames = append(append([]string(nil), uploadColumnNames...), "uploader_email", "variants", "refs")
+
+func TestMediaRepositoryCreateLinksVariants(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewMediaRepository(sqlx.NewDb(db, "sqlmock"))
+	id, userID := uuid.New(), uuid.New()
+	now := time.Date(2025, 2, 11, 8, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO uploads`)).
+		WithArgs(tenant.DefaultID, nil, "uploads/a.jpg", "https://cdn/a.jpg", "image/jpeg", int64(100), &userID).
+		WillReturnRows(sqlmock.NewRows(uploadColumnNames).AddRow(id, tenant.DefaultID, nil, "uploads/a.jpg", "https://cdn/a.jpg", "image/jpeg", 100, userID, now))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO uploads`)).
+		WithArgs(tenant.DefaultID, id, "uploads/a_thumb.jpg", "https://cdn/a_thumb.jpg", "image/jpeg", int64(10), &userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	created, err := repo.Create(context.Background(),
+		models.Upload{Key: "uploads/a.jpg", URL: "https://cdn/a.jpg", ContentType: "image/jpeg", SizeBytes: 100, UploadedBy: &userID},
+		[]models.Upload{{Key: "uploads/a_thumb.jpg", URL: "https://cdn/a_thumb.jpg", ContentType: "image/jpeg", SizeBytes: 10}})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if created.ID != id {
+		t.Fatalf("unexpected upload %+v", created)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestMediaRepositoryListFiltersOrphanedImages(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewMediaRepository(sqlx.NewDb(db, "sqlmock"))
+	id := uuid.New()
+	now := time.Date(2025, 2, 11, 8, 0, 0, 0, time.UTC)
+	orphaned := true
+
+	mock.ExpectQuery(`WHERE TRUE AND m.content_type LIKE \$2 AND m.refs = '\[\]'::jsonb ORDER BY m.created_at DESC, m.id LIMIT \$3 OFFSET \$4`).
+		WithArgs(tenant.DefaultID, "image/%", 20, 0).
+		WillReturnRows(sqlmock.NewRows(mediaColumnNames).AddRow(id, tenant.DefaultID, nil, "uploads/a.jpg", "https://cdn/a.jpg", "image/jpeg", 100, nil, now,
+			"", []byte(`[{"key":"uploads/a_thumb.jpg","url":"https://cdn/a_thumb.jpg","content_type":"image/jpeg","size_bytes":10}]`), []byte(`[]`)))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM (`)).
+		WithArgs(tenant.DefaultID, "image/%").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	items, total, err := repo.List(context.Background(), MediaListParams{ListParams: ListParams{Page: 1, Limit: 20}, ContentType: "image/", Orphaned: &orphaned})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if total != 1 || len(items) != 1 || len(items[0].Variants) != 1 || items[0].Variants[0].Key != "uploads/a_thumb.jpg" || len(items[0].References) != 0 {
+		t.Fatalf("unexpected items %+v total %d", items, total)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestMediaRepositoryDeleteRefusesReferencedUpload(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewMediaRepository(sqlx.NewDb(db, "sqlmock"))
+	id, projectID := uuid.New(), uuid.New()
+	now := time.Date(2025, 2, 11, 8, 0, 0, 0, time.UTC)
+	refs := []byte(`[{"entity":"project","id":"` + projectID.String() + `","published":true}]`)
+	itemRow := func() *sqlmock.Rows {
+		return sqlmock.NewRows(mediaColumnNames).AddRow(id, tenant.DefaultID, nil, "uploads/a.jpg", "https://cdn/a.jpg", "image/jpeg", 100, nil, now, "", []byte(`[]`), refs)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`WHERE u.id = $1 AND u.tenant_id = $2 AND u.parent_id IS NULL`)).
+		WithArgs(id, tenant.DefaultID).
+		WillReturnRows(itemRow())
+	mock.ExpectRollback()
+
+	if _, err := repo.Delete(context.Background(), id, false); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`WHERE u.id = $1 AND u.tenant_id = $2 AND u.parent_id IS NULL`)).
+		WithArgs(id, tenant.DefaultID).
+		WillReturnRows(itemRow())
+	mock.ExpectQuery(regexp.QuoteMeta(`DELETE FROM uploads WHERE id = $1 OR parent_id = $1 RETURNING`)).
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows(uploadColumnNames).
+			AddRow(id, tenant.DefaultID, nil, "uploads/a.jpg", "https://cdn/a.jpg", "image/jpeg", 100, nil, now).
+			AddRow(uuid.New(), tenant.DefaultID, id, "uploads/a_thumb.jpg", "https://cdn/a_thumb.jpg", "image/jpeg", 10, nil, now))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO audit_log`)).
+		WithArgs(tenant.DefaultID, nil, nil, "", audit.ActionDelete, audit.EntityUpload, "uploads/a.jpg", sqlmock.AnyArg(), nil, sqlmock.AnyArg(), "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	removed, err := repo.Delete(context.Background(), id, true)
+	if err != nil {
+		t.Fatalf("forced delete: %v", err)
+	}
+	if len(removed) != 2 {
+		t.Fatalf("expected original and variant removed, got %+v", removed)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestMediaRepositoryPurgeOrphansAuditsOriginalsPerTenant(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewMediaRepository(sqlx.NewDb(db, "sqlmock"))
+	cutoff := time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC)
+	id, otherTenant := uuid.New(), uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`WITH orphans AS (`)).
+		WithArgs(cutoff).
+		WillReturnRows(sqlmock.NewRows(uploadColumnNames).
+			AddRow(id, otherTenant, nil, "uploads/a.jpg", "https://cdn/a.jpg", "image/jpeg", 100, nil, cutoff).
+			AddRow(uuid.New(), otherTenant, id, "uploads/a_thumb.jpg", "https://cdn/a_thumb.jpg", "image/jpeg", 10, nil, cutoff))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO audit_log`)).
+		WithArgs(otherTenant, nil, nil, "", audit.ActionPurge, audit.EntityUpload, "uploads/a.jpg", sqlmock.AnyArg(), nil, sqlmock.AnyArg(), "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	removed, err := repo.PurgeOrphans(context.Background(), cutoff)
+	if err != nil {
+		t.Fatalf("purge orphans: %v", err)
+	}
+	if len(removed) != 2 {
+		t.Fatalf("expected 2 removed rows, got %d", len(removed))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestMediaRepositoryFilterUntracked(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewMediaRepository(sqlx.NewDb(db, "sqlmock"))
+	keys := []string{"uploads/old.png", "uploads/used.png"}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT o.key FROM unnest($1::text[]) AS o(key)`)).
+		WithArgs(pq.StringArray(keys)).
+		WillReturnRows(sqlmock.NewRows([]string{"key"}).AddRow("uploads/old.png"))
+
+	untracked, err := repo.FilterUntracked(context.Background(), keys)
+	if err != nil {
+		t.Fatalf("filter untracked: %v", err)
+	}
+	if len(untracked) != 1 || untracked[0] != "uploads/old.png" {
+		t.Fatalf("unexpected keys %v", untracked)
+	}
+	if untracked, err := repo.FilterUntracked(context.Background(), nil); err != nil || untracked != nil {
+		t.Fatalf("expected no query for empty keys, got %v %v", untracked, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,247 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/tanydotai/tanyai/backend/internal/audit"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
+)
+
+const externalSourceColumns = `id, name, base_url, source_type, enabled, etag, last_modified, last_synced_at, created_at, updated_at`
+
+// PortfolioContent is the complete editable content of a tenant. Profile is nil when the
+// tenant has none.
+type PortfolioContent struct {
+	Profile         *models.Profile
+	Skills          []models.Skill
+	Services        []models.Service
+	Projects        []models.Project
+	ExternalSources []models.ExternalSource
+}
+
+// PortfolioRepository reads and writes a tenant's content as a whole.
+type PortfolioRepository interface {
+	Export(ctx context.Context) (PortfolioContent, error)
+	// Import upserts content by ID in a single transaction, restoring trashed rows it
+	// names. With replace set, skills, services and projects missing from content are
+	// moved to the trash and missing external sources are deleted. Rows whose ID belongs
+	// to another tenant yield ErrConflict.
+	Import(ctx context.Context, content PortfolioContent, replace bool) error
+}
+
+// NewPortfolioRepository constructs a SQL-backed portfolio repository.
+func NewPortfolioRepository(db *sqlx.DB) PortfolioRepository {
+	return &portfolioRepository{db: db}
+}
+
+type portfolioRepository struct {
+	db *sqlx.DB
+}
+
+func (r *portfolioRepository) Export(ctx context.Context) (PortfolioContent, error) {
+	tenantID := tenant.ID(ctx)
+	var content PortfolioContent
+
+	var profile models.Profile
+	if err := r.db.GetContext(ctx, &profile, profileQuery, tenantID); err == nil {
+		content.Profile = &profile
+	} else if err != sql.ErrNoRows {
+		return PortfolioContent{}, err
+	}
+
+	queries := []struct {
+		dest  any
+		query string
+	}{
+		{&content.Skills, `SELECT id, name, "order" FROM skills WHERE tenant_id = $1 AND deleted_at IS NULL ORDER BY "order", name`},
+		{&content.Services, `SELECT ` + serviceColumns + ` FROM services WHERE tenant_id = $1 AND deleted_at IS NULL ORDER BY "order", name`},
+		{&content.Projects, `SELECT ` + projectColumns + ` FROM projects WHERE tenant_id = $1 AND deleted_at IS NULL ORDER BY "order", title`},
+		{&content.ExternalSources, `SELECT ` + externalSourceColumns + ` FROM external_sources WHERE tenant_id = $1 AND source_type <> '` + DocumentSourceType + `' ORDER BY LOWER(name)`},
+	}
+	for _, q := range queries {
+		if err := r.db.SelectContext(ctx, q.dest, q.query, tenantID); err != nil {
+			return PortfolioContent{}, err
+		}
+	}
+	return content, nil
+}
+
+func (r *portfolioRepository) Import(ctx context.Context, content PortfolioContent, replace bool) error {
+	return withAudit(ctx, r.db, func(tx *sqlx.Tx) (audit.Change, error) {
+		tenantID := tenant.ID(ctx)
+		if replace {
+			if err := deleteMissing(ctx, tx, tenantID, content); err != nil {
+				return audit.Change{}, err
+			}
+		}
+
+		if content.Profile != nil {
+			if err := importProfile(ctx, tx, tenantID, *content.Profile); err != nil {
+				return audit.Change{}, err
+			}
+		}
+		for _, skill := range content.Skills {
+			const query = `INSERT INTO skills (id, name, "order", tenant_id) VALUES ($1, $2, $3, $4)
+ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, "order" = EXCLUDED."order", deleted_at = NULL
+WHERE skills.tenant_id = EXCLUDED.tenant_id`
+			if err := execUpsert(ctx, tx, query, skill.ID, skill.Name, skill.Order, tenantID); err != nil {
+				return audit.Change{}, err
+			}
+		}
+		for _, service := range content.Services {
+			const query = `INSERT INTO services (id, name, description, price_min, price_max, currency, duration_label, is_active, "order", tenant_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+ON CONFLICT (id) DO UPDATE SET
+    name = EXCLUDED.name,
+    description = EXCLUDED.description,
+    price_min = EXCLUDED.price_min,
+    price_max = EXCLUDED.price_max,
+    currency = EXCLUDED.currency,
+    duration_label = EXCLUDED.duration_label,
+    is_active = EXCLUDED.is_active,
+    "order" = EXCLUDED."order",
+    has_unpublished_changes = TRUE,
+    deleted_at = NULL
+WHERE services.tenant_id = EXCLUDED.tenant_id`
+			if err := execUpsert(ctx, tx, query, service.ID, service.Name, service.Description, service.PriceMin, service.PriceMax,
+				service.Currency, service.DurationLabel, service.IsActive, service.Order, tenantID); err != nil {
+				return audit.Change{}, err
+			}
+		}
+		for _, project := range content.Projects {
+			const query = `INSERT INTO projects (id, title, description, tech_stack, image_url, image_variants, project_url, category, duration_label, price_label, budget_label, "order", is_featured, tenant_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+ON CONFLICT (id) DO UPDATE SET
+    title = EXCLUDED.title,
+    description = EXCLUDED.description,
+    tech_stack = EXCLUDED.tech_stack,
+    image_url = EXCLUDED.image_url,
+    image_variants = EXCLUDED.image_variants,
+    project_url = EXCLUDED.project_url,
+    category = EXCLUDED.category,
+    duration_label = EXCLUDED.duration_label,
+    price_label = EXCLUDED.price_label,
+    budget_label = EXCLUDED.budget_label,
+    "order" = EXCLUDED."order",
+    is_featured = EXCLUDED.is_featured,
+    has_unpublished_changes = TRUE,
+    deleted_at = NULL
+WHERE projects.tenant_id = EXCLUDED.tenant_id`
+			if err := execUpsert(ctx, tx, query, project.ID, project.Title, project.Description, project.TechStack, project.ImageURL,
+				project.ImageVariants, project.ProjectURL, project.Category, project.DurationLabel, project.PriceLabel, project.BudgetLabel,
+				project.Order, project.IsFeatured, tenantID); err != nil {
+				return audit.Change{}, err
+			}
+		}
+		for _, source := range content.ExternalSources {
+			const query = `INSERT INTO external_sources (id, name, base_url, source_type, enabled, tenant_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (id) DO UPDATE SET
+    name = EXCLUDED.name,
+    base_url = EXCLUDED.base_url,
+    source_type = EXCLUDED.source_type,
+    enabled = EXCLUDED.enabled,
+    updated_at = NOW()
+WHERE external_sources.tenant_id = EXCLUDED.tenant_id`
+			if err := execUpsert(ctx, tx, query, source.ID, source.Name, source.BaseURL, source.SourceType, source.Enabled, tenantID); err != nil {
+				return audit.Change{}, err
+			}
+		}
+
+		return audit.Change{
+			Action:     audit.ActionImport,
+			EntityType: audit.EntityPortfolio,
+			After: map[string]any{
+				"replace":          replace,
+				"profile":          content.Profile != nil,
+				"skills":           len(content.Skills),
+				"services":         len(content.Services),
+				"projects":         len(content.Projects),
+				"external_sources": len(content.ExternalSources),
+			},
+		}, nil
+	})
+}
+
+// importProfile updates the tenant's profile in place, keeping its ID, or inserts the
+// imported one when the tenant has none.
+func importProfile(ctx context.Context, tx *sqlx.Tx, tenantID uuid.UUID, profile models.Profile) error {
+	var existing uuid.UUID
+	err := tx.GetContext(ctx, &existing, `SELECT id FROM profile WHERE tenant_id = $1 FOR UPDATE`, tenantID)
+	switch {
+	case err == sql.ErrNoRows:
+		const insert = `INSERT INTO profile (id, name, title, bio, email, phone, location, avatar_url, avatar_variants, tenant_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+		return execUpsert(ctx, tx, insert, profile.ID, profile.Name, profile.Title, profile.Bio, profile.Email,
+			profile.Phone, profile.Location, profile.AvatarURL, profile.AvatarVariants, tenantID)
+	case err != nil:
+		return err
+	}
+	const update = `UPDATE profile SET name = $2, title = $3, bio = $4, email = $5, phone = $6, location = $7, avatar_url = $8,
+    avatar_variants = $9, updated_at = NOW(), has_unpublished_changes = TRUE
+WHERE id = $1`
+	_, err = tx.ExecContext(ctx, update, existing, profile.Name, profile.Title, profile.Bio, profile.Email,
+		profile.Phone, profile.Location, profile.AvatarURL, profile.AvatarVariants)
+	return err
+}
+
+func deleteMissing(ctx context.Context, tx *sqlx.Tx, tenantID uuid.UUID, content PortfolioContent) error {
+	keep := map[string][]uuid.UUID{
+		"skills":           make([]uuid.UUID, 0, len(content.Skills)),
+		"services":         make([]uuid.UUID, 0, len(content.Services)),
+		"projects":         make([]uuid.UUID, 0, len(content.Projects)),
+		"external_sources": make([]uuid.UUID, 0, len(content.ExternalSources)),
+	}
+	for _, skill := range content.Skills {
+		keep["skills"] = append(keep["skills"], skill.ID)
+	}
+	for _, service := range content.Services {
+		keep["services"] = append(keep["services"], service.ID)
+	}
+	for _, project := range content.Projects {
+		keep["projects"] = append(keep["projects"], project.ID)
+	}
+	for _, source := range content.ExternalSources {
+		keep["external_sources"] = append(keep["external_sources"], source.ID)
+	}
+	for _, table := range []string{"skills", "services", "projects", "external_sources"} {
+		query := `UPDATE ` + table + ` SET deleted_at = NOW() WHERE tenant_id = $1 AND deleted_at IS NULL AND NOT (id = ANY($2))`
+		switch table {
+		case "services", "projects":
+			// Trashed rows must not stay scheduled, or the publish job would keep picking them up.
+			query = `UPDATE ` + table + ` SET deleted_at = NOW(), publish_at = NULL WHERE tenant_id = $1 AND deleted_at IS NULL AND NOT (id = ANY($2))`
+		case "external_sources":
+			// Uploaded documents are not part of bundles, so their source survives a replace.
+			query = `DELETE FROM external_sources WHERE tenant_id = $1 AND NOT (id = ANY($2)) AND source_type <> '` + DocumentSourceType + `'`
+		}
+		if _, err := tx.ExecContext(ctx, query, tenantID, pq.Array(keep[table])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// execUpsert runs an insert whose conflict clause only updates rows of the same tenant;
+// a row owned by another tenant is left untouched and reported as ErrConflict.
+func execUpsert(ctx context.Context, tx *sqlx.Tx, query string, args ...any) error {
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrConflict
+		}
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrConflict
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
+)
+
+func TestPortfolioRepositoryImportReplaceUpsertsAndTrashesMissing(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPortfolioRepository(sqlx.NewDb(db, "sqlmock"))
+	skillID := uuid.New()
+	existingProfileID := uuid.New()
+
+	mock.ExpectBegin()
+	for _, table := range []string{"skills", "services", "projects", "external_sources"} {
+		keep := pq.Array([]uuid.UUID{})
+		if table == "skills" {
+			keep = pq.Array([]uuid.UUID{skillID})
+		}
+		query := `UPDATE ` + table + ` SET deleted_at = NOW() WHERE tenant_id = $1 AND deleted_at IS NULL AND NOT (id = ANY($2))`
+		switch table {
+		case "services", "projects":
+			query = `UPDATE ` + table + ` SET deleted_at = NOW(), publish_at = NULL WHERE tenant_id = $1 AND deleted_at IS NULL AND NOT (id = ANY($2))`
+		case "external_sources":
+			query = `DELETE FROM external_sources WHERE tenant_id = $1 AND NOT (id = ANY($2)) AND source_type <> 'documents'`
+		}
+		mock.ExpectExec(regexp.QuoteMeta(query)).
+			WithArgs(tenant.DefaultID, keep).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+	}
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id FROM profile WHERE tenant_id = $1 FOR UPDATE`)).
+		WithArgs(tenant.DefaultID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(existingProfileID))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE profile SET name = $2`)).
+		WithArgs(existingProfileID, "Jane", "Engineer", "", "", "", "", "", nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO skills (id, name, "order", tenant_id) VALUES ($1, $2, $3, $4)`)).
+		WithArgs(skillID, "Go", 1, tenant.DefaultID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO audit_log`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = repo.Import(context.Background(), PortfolioContent{
+		Profile: &models.Profile{ID: uuid.New(), Name: "Jane", Title: "Engineer"},
+		Skills:  []models.Skill{{ID: skillID, Name: "Go", Order: 1}},
+	}, true)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestPortfolioRepositoryImportRejectsForeignTenantID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPortfolioRepository(sqlx.NewDb(db, "sqlmock"))
+	skillID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO skills`)).
+		WithArgs(skillID, "Go", 0, tenant.DefaultID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err = repo.Import(context.Background(), PortfolioContent{Skills: []models.Skill{{ID: skillID, Name: "Go"}}}, false)
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
@@ -6,9 +6,15 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/tanydotai/tanyai/backend/internal/audit"
 	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
 )
 
+const profileColumns = `id, name, title, bio, email, phone, location, avatar_url, avatar_variants, updated_at, ` + publicationColumns
+
+const profileQuery = `SELECT ` + profileColumns + ` FROM profile WHERE tenant_id = $1 LIMIT 1`
+
 // ProfileRepository defines data access behaviour for profile entity.
 type ProfileRepository interface {
 	Get(ctx context.Context) (models.Profile, error)
@@ -25,10 +31,8 @@ type profileRepository struct {
 }
 
 func (r *profileRepository) Get(ctx context.Context) (models.Profile, error) {
-	const query = `SELECT id, name, title, bio, email, phone, location, avatar_url, updated_at FROM profile LIMIT 1`
-
 	var profile models.Profile
-	if err := r.db.GetContext(ctx, &profile, query); err != nil {
+	if err := r.db.GetContext(ctx, &profile, profileQuery, tenant.ID(ctx)); err != nil {
 		if err == sql.ErrNoRows {
 			return models.Profile{}, ErrNotFound
 		}
@@ -38,36 +42,41 @@ func (r *profileRepository) Get(ctx context.Context) (models.Profile, error) {
 }
 
 func (r *profileRepository) Upsert(ctx context.Context, profile models.Profile) (models.Profile, error) {
-	if profile.ID == uuid.Nil {
-		existing, err := r.Get(ctx)
-		if err == nil {
-			profile.ID = existing.ID
-		} else if err != ErrNotFound {
-			return models.Profile{}, err
+	var saved models.Profile
+	err := withAudit(ctx, r.db, func(tx *sqlx.Tx) (audit.Change, error) {
+		var before *models.Profile
+		var existing models.Profile
+		if err := tx.GetContext(ctx, &existing, profileQuery+" FOR UPDATE", tenant.ID(ctx)); err == nil {
+			before = &existing
+			if profile.ID == uuid.Nil {
+				profile.ID = existing.ID
+			}
+		} else if err != sql.ErrNoRows {
+			return audit.Change{}, err
 		}
-	}
 
-	if profile.ID == uuid.Nil {
-		const insertQuery = `INSERT INTO profile (name, title, bio, email, phone, location, avatar_url)
-VALUES ($1, $2, $3, $4, $5, $6, $7)
-RETURNING id, name, title, bio, email, phone, location, avatar_url, updated_at`
-		var created models.Profile
-		if err := r.db.GetContext(ctx, &created, insertQuery,
-			profile.Name,
-			profile.Title,
-			profile.Bio,
-			profile.Email,
-			profile.Phone,
-			profile.Location,
-			profile.AvatarURL,
-		); err != nil {
-			return models.Profile{}, err
+		if profile.ID == uuid.Nil {
+			const insertQuery = `INSERT INTO profile (name, title, bio, email, phone, location, avatar_url, avatar_variants, tenant_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING ` + profileColumns
+			if err := tx.GetContext(ctx, &saved, insertQuery,
+				profile.Name,
+				profile.Title,
+				profile.Bio,
+				profile.Email,
+				profile.Phone,
+				profile.Location,
+				profile.AvatarURL,
+				profile.AvatarVariants,
+				tenant.ID(ctx),
+			); err != nil {
+				return audit.Change{}, err
+			}
+			return audit.Change{Action: audit.ActionCreate, EntityType: audit.EntityProfile, EntityID: saved.ID.String(), After: saved}, nil
 		}
-		return created, nil
-	}
 
-	const upsertQuery = `INSERT INTO profile (id, name, title, bio, email, phone, location, avatar_url)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		const upsertQuery = `INSERT INTO profile (id, name, title, bio, email, phone, location, avatar_url, avatar_variants, tenant_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 ON CONFLICT (id) DO UPDATE SET
     name = EXCLUDED.name,
     title = EXCLUDED.title,
@@ -76,21 +85,33 @@ ON CONFLICT (id) DO UPDATE SET
     phone = EXCLUDED.phone,
     location = EXCLUDED.location,
     avatar_url = EXCLUDED.avatar_url,
-    updated_at = NOW()
-RETURNING id, name, title, bio, email, phone, location, avatar_url, updated_at`
+    avatar_variants = EXCLUDED.avatar_variants,
+    updated_at = NOW(),
+    has_unpublished_changes = TRUE
+WHERE profile.tenant_id = EXCLUDED.tenant_id
+RETURNING ` + profileColumns
 
-	var updated models.Profile
-	if err := r.db.GetContext(ctx, &updated, upsertQuery,
-		profile.ID,
-		profile.Name,
-		profile.Title,
-		profile.Bio,
-		profile.Email,
-		profile.Phone,
-		profile.Location,
-		profile.AvatarURL,
-	); err != nil {
+		if err := tx.GetContext(ctx, &saved, upsertQuery,
+			profile.ID,
+			profile.Name,
+			profile.Title,
+			profile.Bio,
+			profile.Email,
+			profile.Phone,
+			profile.Location,
+			profile.AvatarURL,
+			profile.AvatarVariants,
+			tenant.ID(ctx),
+		); err != nil {
+			if err == sql.ErrNoRows {
+				return audit.Change{}, ErrNotFound
+			}
+			return audit.Change{}, err
+		}
+		return audit.Change{Action: audit.ActionUpdate, EntityType: audit.EntityProfile, EntityID: saved.ID.String(), Before: before, After: saved}, nil
+	})
+	if err != nil {
 		return models.Profile{}, err
 	}
-	return updated, nil
+	return saved, nil
 }
@@ -6,14 +6,19 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/tanydotai/tanyai/backend/internal/audit"
 	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
 )
 
+const projectColumns = `id, title, description, tech_stack, image_url, image_variants, project_url, category, duration_label, price_label, budget_label, "order", is_featured, ` + publicationColumns
+
 // ProjectRepository defines DB operations for projects.
 type ProjectRepository interface {
 	List(ctx context.Context, params ListParams) ([]models.Project, int64, error)
 	Create(ctx context.Context, project models.Project) (models.Project, error)
 	Update(ctx context.Context, project models.Project) (models.Project, error)
+	// Delete moves the row to the trash; see TrashRepository.
 	Delete(ctx context.Context, id uuid.UUID) error
 	Reorder(ctx context.Context, pairs []models.Project) error
 	SetFeatured(ctx context.Context, id uuid.UUID, featured bool) (models.Project, error)
@@ -29,8 +34,9 @@ type projectRepository struct {
 }
 
 func (r *projectRepository) List(ctx context.Context, params ListParams) ([]models.Project, int64, error) {
-	const baseQuery = `SELECT id, title, description, tech_stack, image_url, project_url, category, duration_label, price_label, budget_label, "order", is_featured FROM projects`
-	const countQuery = `SELECT COUNT(*) FROM projects`
+	tenantID := tenant.ID(ctx)
+	const baseQuery = `SELECT ` + projectColumns + ` FROM projects WHERE tenant_id = $1 AND deleted_at IS NULL`
+	const countQuery = `SELECT COUNT(*) FROM projects WHERE tenant_id = $1 AND deleted_at IS NULL`
 
 	orderBy, err := params.ValidateSort(map[string]string{
 		"order":       "\"order\"",
@@ -41,15 +47,15 @@ func (r *projectRepository) List(ctx context.Context, params ListParams) ([]mode
 		return nil, 0, err
 	}
 
-	query := baseQuery + " ORDER BY " + orderBy + " LIMIT $1 OFFSET $2"
+	query := baseQuery + " ORDER BY " + orderBy + " LIMIT $2 OFFSET $3"
 
 	var projects []models.Project
-	if err := r.db.SelectContext(ctx, &projects, query, params.Limit, params.Offset()); err != nil {
+	if err := r.db.SelectContext(ctx, &projects, query, tenantID, params.Limit, params.Offset()); err != nil {
 		return nil, 0, err
 	}
 
 	var total int64
-	if err := r.db.GetContext(ctx, &total, countQuery); err != nil {
+	if err := r.db.GetContext(ctx, &total, countQuery, tenantID); err != nil {
 		return nil, 0, err
 	}
 
@@ -57,24 +63,32 @@ func (r *projectRepository) List(ctx context.Context, params ListParams) ([]mode
 }
 
 func (r *projectRepository) Create(ctx context.Context, project models.Project) (models.Project, error) {
-	const query = `INSERT INTO projects (title, description, tech_stack, image_url, project_url, category, duration_label, price_label, budget_label, "order", is_featured)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-RETURNING id, title, description, tech_stack, image_url, project_url, category, duration_label, price_label, budget_label, "order", is_featured`
+	const query = `INSERT INTO projects (title, description, tech_stack, image_url, image_variants, project_url, category, duration_label, price_label, budget_label, "order", is_featured, tenant_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+RETURNING ` + projectColumns
 
 	var created models.Project
-	if err := r.db.GetContext(ctx, &created, query,
-		project.Title,
-		project.Description,
-		project.TechStack,
-		project.ImageURL,
-		project.ProjectURL,
-		project.Category,
-		project.DurationLabel,
-		project.PriceLabel,
-		project.BudgetLabel,
-		project.Order,
-		project.IsFeatured,
-	); err != nil {
+	err := withAudit(ctx, r.db, func(tx *sqlx.Tx) (audit.Change, error) {
+		if err := tx.GetContext(ctx, &created, query,
+			project.Title,
+			project.Description,
+			project.TechStack,
+			project.ImageURL,
+			project.ImageVariants,
+			project.ProjectURL,
+			project.Category,
+			project.DurationLabel,
+			project.PriceLabel,
+			project.BudgetLabel,
+			project.Order,
+			project.IsFeatured,
+			tenant.ID(ctx),
+		); err != nil {
+			return audit.Change{}, err
+		}
+		return audit.Change{Action: audit.ActionCreate, EntityType: audit.EntityProject, EntityID: created.ID.String(), After: created}, nil
+	})
+	if err != nil {
 		return models.Project{}, err
 	}
 	return created, nil
@@ -86,89 +100,100 @@ func (r *projectRepository) Update(ctx context.Context, project models.Project)
     description = $3,
     tech_stack = $4,
     image_url = $5,
-    project_url = $6,
-    category = $7,
-    duration_label = $8,
-    price_label = $9,
-    budget_label = $10,
-    "order" = $11,
-    is_featured = $12
-WHERE id = $1
-RETURNING id, title, description, tech_stack, image_url, project_url, category, duration_label, price_label, budget_label, "order", is_featured`
+    image_variants = $6,
+    project_url = $7,
+    category = $8,
+    duration_label = $9,
+    price_label = $10,
+    budget_label = $11,
+    "order" = $12,
+    is_featured = $13,
+    has_unpublished_changes = TRUE
+WHERE id = $1 AND tenant_id = $14
+RETURNING ` + projectColumns
 
 	var updated models.Project
-	if err := r.db.GetContext(ctx, &updated, query,
-		project.ID,
-		project.Title,
-		project.Description,
-		project.TechStack,
-		project.ImageURL,
-		project.ProjectURL,
-		project.Category,
-		project.DurationLabel,
-		project.PriceLabel,
-		project.BudgetLabel,
-		project.Order,
-		project.IsFeatured,
-	); err != nil {
-		if err == sql.ErrNoRows {
-			return models.Project{}, ErrNotFound
+	err := withAudit(ctx, r.db, func(tx *sqlx.Tx) (audit.Change, error) {
+		before, err := r.lock(ctx, tx, project.ID)
+		if err != nil {
+			return audit.Change{}, err
 		}
+		if err := tx.GetContext(ctx, &updated, query,
+			project.ID,
+			project.Title,
+			project.Description,
+			project.TechStack,
+			project.ImageURL,
+			project.ImageVariants,
+			project.ProjectURL,
+			project.Category,
+			project.DurationLabel,
+			project.PriceLabel,
+			project.BudgetLabel,
+			project.Order,
+			project.IsFeatured,
+			tenant.ID(ctx),
+		); err != nil {
+			return audit.Change{}, err
+		}
+		return audit.Change{Action: audit.ActionUpdate, EntityType: audit.EntityProject, EntityID: project.ID.String(), Before: before, After: updated}, nil
+	})
+	if err != nil {
 		return models.Project{}, err
 	}
 	return updated, nil
 }
 
 func (r *projectRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	const query = `DELETE FROM projects WHERE id = $1`
-	res, err := r.db.ExecContext(ctx, query, id)
-	if err != nil {
-		return err
-	}
-	rows, err := res.RowsAffected()
-	if err != nil {
-		return err
-	}
-	if rows == 0 {
-		return ErrNotFound
-	}
-	return nil
+	return withAudit(ctx, r.db, func(tx *sqlx.Tx) (audit.Change, error) {
+		before, err := r.lock(ctx, tx, id)
+		if err != nil {
+			return audit.Change{}, err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE projects SET deleted_at = NOW(), publish_at = NULL WHERE id = $1 AND tenant_id = $2`, id, tenant.ID(ctx)); err != nil {
+			return audit.Change{}, err
+		}
+		return audit.Change{Action: audit.ActionDelete, EntityType: audit.EntityProject, EntityID: id.String(), Before: before}, nil
+	})
 }
 
 func (r *projectRepository) Reorder(ctx context.Context, pairs []models.Project) error {
-	tx, err := r.db.BeginTxx(ctx, nil)
-	if err != nil {
-		return err
+	ids := make([]uuid.UUID, len(pairs))
+	orders := make(map[uuid.UUID]int, len(pairs))
+	for i, pair := range pairs {
+		ids[i] = pair.ID
+		orders[pair.ID] = pair.Order
 	}
-	defer func() {
-		_ = tx.Rollback()
-	}()
+	return withAudit(ctx, r.db, func(tx *sqlx.Tx) (audit.Change, error) {
+		return reorderRows(ctx, tx, "projects", audit.EntityProject, ids, orders)
+	})
+}
 
-	for _, pair := range pairs {
-		res, err := tx.ExecContext(ctx, `UPDATE projects SET "order" = $1 WHERE id = $2`, pair.Order, pair.ID)
-		if err != nil {
-			return err
-		}
-		rows, err := res.RowsAffected()
+func (r *projectRepository) SetFeatured(ctx context.Context, id uuid.UUID, featured bool) (models.Project, error) {
+	const query = `UPDATE projects SET is_featured = $2, has_unpublished_changes = TRUE WHERE id = $1 AND tenant_id = $3 RETURNING ` + projectColumns
+
+	var project models.Project
+	err := withAudit(ctx, r.db, func(tx *sqlx.Tx) (audit.Change, error) {
+		before, err := r.lock(ctx, tx, id)
 		if err != nil {
-			return err
+			return audit.Change{}, err
 		}
-		if rows == 0 {
-			return ErrNotFound
+		if err := tx.GetContext(ctx, &project, query, id, featured, tenant.ID(ctx)); err != nil {
+			return audit.Change{}, err
 		}
+		return audit.Change{Action: audit.ActionFeature, EntityType: audit.EntityProject, EntityID: id.String(), Before: before, After: project}, nil
+	})
+	if err != nil {
+		return models.Project{}, err
 	}
-
-	if err := tx.Commit(); err != nil {
-		return err
-	}
-	return nil
+	return project, nil
 }
 
-func (r *projectRepository) SetFeatured(ctx context.Context, id uuid.UUID, featured bool) (models.Project, error) {
-	const query = `UPDATE projects SET is_featured = $2 WHERE id = $1 RETURNING id, title, description, tech_stack, image_url, project_url, category, duration_label, price_label, budget_label, "order", is_featured`
-
+// lock loads a project for update within tx, returning ErrNotFound when it is missing or
+// in the trash.
+func (r *projectRepository) lock(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (models.Project, error) {
 	var project models.Project
-	if err := r.db.GetContext(ctx, &project, query, id, featured); err != nil {
+	if err := tx.GetContext(ctx, &project, `SELECT `+projectColumns+` FROM projects WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL FOR UPDATE`, id, tenant.ID(ctx)); err != nil {
 		if err == sql.ErrNoRows {
 			return models.Project{}, ErrNotFound
 		}
@@ -0,0 +1,327 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/tanydotai/tanyai/backend/internal/audit"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
+)
+
+const (
+	publicationColumns = `published_revision_id, has_unpublished_changes, publish_at`
+	revisionColumns    = `id, tenant_id, entity_type, entity_id, version, data, published_by, created_at`
+	// revisionData renders the draft row t as the JSON stored in content_revisions.
+	revisionData = `to_jsonb(t) - 'published_revision_id' - 'has_unpublished_changes' - 'publish_at' - 'deleted_at'`
+)
+
+// revisionTarget describes a table whose rows are drafts published as revisions. Columns
+// lists the content copied back into the draft on restore; trashable tables hide
+// soft-deleted rows.
+type revisionTarget struct {
+	table     string
+	columns   string
+	trashable bool
+}
+
+var revisionTargets = map[string]revisionTarget{
+	audit.EntityProfile: {table: "profile", columns: `name, title, bio, email, phone, location, avatar_url, avatar_variants`},
+	audit.EntityService: {table: "services", columns: `name, description, price_min, price_max, currency, duration_label, is_active, "order"`, trashable: true},
+	audit.EntityProject: {table: "projects", columns: `title, description, tech_stack, image_url, image_variants, project_url, category, duration_label, price_label, budget_label, "order", is_featured`, trashable: true},
+}
+
+// live filters out rows in the trash.
+func (t revisionTarget) live() string {
+	if t.trashable {
+		return ` AND deleted_at IS NULL`
+	}
+	return ``
+}
+
+// RevisionRef identifies a draft-enabled entity.
+type RevisionRef struct {
+	EntityType string
+	EntityID   uuid.UUID
+}
+
+// RevisionRepository publishes draft content and manages its revision history.
+type RevisionRepository interface {
+	// Publish snapshots every referenced draft as a new revision in a single transaction.
+	// Drafts without unpublished changes keep their current revision.
+	Publish(ctx context.Context, refs []RevisionRef) ([]models.ContentRevision, error)
+	// Schedule sets or, with a nil time, clears the scheduled publish time of the drafts.
+	Schedule(ctx context.Context, refs []RevisionRef, at *time.Time) error
+	// PublishDue publishes drafts of every tenant whose scheduled time has passed. A draft
+	// that fails to publish is logged and skipped so it does not hold up the others.
+	PublishDue(ctx context.Context, now time.Time) (int, error)
+	List(ctx context.Context, ref RevisionRef, params ListParams) ([]models.ContentRevision, int64, error)
+	Get(ctx context.Context, ref RevisionRef, version int) (models.ContentRevision, error)
+	// Draft returns the current draft in the shape of revision data.
+	Draft(ctx context.Context, ref RevisionRef) (models.JSONB, error)
+	// Restore copies a revision back into the draft without publishing it.
+	Restore(ctx context.Context, ref RevisionRef, version int) error
+}
+
+// NewRevisionRepository constructs a SQL-backed revision repository.
+func NewRevisionRepository(db *sqlx.DB) RevisionRepository {
+	return &revisionRepository{db: db}
+}
+
+type revisionRepository struct {
+	db *sqlx.DB
+}
+
+func lookupRevisionTarget(entityType string) (revisionTarget, error) {
+	target, ok := revisionTargets[entityType]
+	if !ok {
+		return revisionTarget{}, fmt.Errorf("%w: %s", ErrUnsupportedEntity, entityType)
+	}
+	return target, nil
+}
+
+func validateRevisionRefs(refs []RevisionRef) error {
+	for _, ref := range refs {
+		if _, err := lookupRevisionTarget(ref.EntityType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *revisionRepository) Publish(ctx context.Context, refs []RevisionRef) ([]models.ContentRevision, error) {
+	if err := validateRevisionRefs(refs); err != nil {
+		return nil, err
+	}
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	revisions := make([]models.ContentRevision, 0, len(refs))
+	for _, ref := range refs {
+		revision, err := r.publish(ctx, tx, ref)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (r *revisionRepository) publish(ctx context.Context, tx *sqlx.Tx, ref RevisionRef) (models.ContentRevision, error) {
+	target, err := lookupRevisionTarget(ref.EntityType)
+	if err != nil {
+		return models.ContentRevision{}, err
+	}
+
+	var draft struct {
+		models.Publication
+		Data models.JSONB `db:"data"`
+	}
+	lockQuery := `SELECT ` + publicationColumns + `, ` + revisionData + ` AS data FROM ` + target.table + ` t WHERE id = $1 AND tenant_id = $2` + target.live() + ` FOR UPDATE`
+	if err := tx.GetContext(ctx, &draft, lockQuery, ref.EntityID, tenant.ID(ctx)); err != nil {
+		if err == sql.ErrNoRows {
+			return models.ContentRevision{}, ErrNotFound
+		}
+		return models.ContentRevision{}, err
+	}
+
+	var revision models.ContentRevision
+	if draft.Status() == models.PublicationPublished {
+		if err := tx.GetContext(ctx, &revision, `SELECT `+revisionColumns+` FROM content_revisions WHERE id = $1`, *draft.PublishedRevisionID); err != nil {
+			return models.ContentRevision{}, err
+		}
+		if draft.PublishAt != nil {
+			if _, err := tx.ExecContext(ctx, `UPDATE `+target.table+` SET publish_at = NULL WHERE id = $1`, ref.EntityID); err != nil {
+				return models.ContentRevision{}, err
+			}
+		}
+		return revision, nil
+	}
+
+	actor, _ := audit.ActorFrom(ctx)
+	const insertQuery = `INSERT INTO content_revisions (tenant_id, entity_type, entity_id, version, data, published_by)
+SELECT $1::uuid, $2::text, $3::uuid, COALESCE(MAX(version), 0) + 1, $4::jsonb, $5::uuid FROM content_revisions WHERE entity_type = $2 AND entity_id = $3
+RETURNING ` + revisionColumns
+	if err := tx.GetContext(ctx, &revision, insertQuery, tenant.ID(ctx), ref.EntityType, ref.EntityID, draft.Data, nullableUUID(actor.UserID)); err != nil {
+		return models.ContentRevision{}, err
+	}
+	updateQuery := `UPDATE ` + target.table + ` SET published_revision_id = $2, has_unpublished_changes = FALSE, publish_at = NULL WHERE id = $1`
+	if _, err := tx.ExecContext(ctx, updateQuery, ref.EntityID, revision.ID); err != nil {
+		return models.ContentRevision{}, err
+	}
+
+	change := audit.Change{
+		Action:     audit.ActionPublish,
+		EntityType: ref.EntityType,
+		EntityID:   ref.EntityID.String(),
+		After:      map[string]any{"version": revision.Version, "revision_id": revision.ID.String()},
+	}
+	if err := recordAudit(ctx, tx, change); err != nil {
+		return models.ContentRevision{}, err
+	}
+	return revision, nil
+}
+
+func (r *revisionRepository) Schedule(ctx context.Context, refs []RevisionRef, at *time.Time) error {
+	if err := validateRevisionRefs(refs); err != nil {
+		return err
+	}
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	for _, ref := range refs {
+		target, err := lookupRevisionTarget(ref.EntityType)
+		if err != nil {
+			return err
+		}
+		var previous *time.Time
+		lockQuery := `SELECT publish_at FROM ` + target.table + ` WHERE id = $1 AND tenant_id = $2` + target.live() + ` FOR UPDATE`
+		if err := tx.GetContext(ctx, &previous, lockQuery, ref.EntityID, tenant.ID(ctx)); err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNotFound
+			}
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE `+target.table+` SET publish_at = $2 WHERE id = $1`, ref.EntityID, at); err != nil {
+			return err
+		}
+		change := audit.Change{
+			Action:     audit.ActionSchedule,
+			EntityType: ref.EntityType,
+			EntityID:   ref.EntityID.String(),
+			Before:     map[string]any{"publish_at": previous},
+			After:      map[string]any{"publish_at": at},
+		}
+		if err := recordAudit(ctx, tx, change); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *revisionRepository) PublishDue(ctx context.Context, now time.Time) (int, error) {
+	published := 0
+	for _, entityType := range []string{audit.EntityProfile, audit.EntityService, audit.EntityProject} {
+		target := revisionTargets[entityType]
+		var due []struct {
+			ID       uuid.UUID `db:"id"`
+			TenantID uuid.UUID `db:"tenant_id"`
+		}
+		query := `SELECT id, tenant_id FROM ` + target.table + ` WHERE publish_at IS NOT NULL AND publish_at <= $1` + target.live() + ` ORDER BY publish_at`
+		if err := r.db.SelectContext(ctx, &due, query, now); err != nil {
+			return published, err
+		}
+		for _, row := range due {
+			tenantCtx := tenant.WithID(ctx, row.TenantID)
+			if _, err := r.Publish(tenantCtx, []RevisionRef{{EntityType: entityType, EntityID: row.ID}}); err != nil {
+				slog.Error("scheduled_publish_failed", "entity_type", entityType, "entity_id", row.ID, "tenant_id", row.TenantID, "error", err)
+				continue
+			}
+			published++
+		}
+	}
+	return published, nil
+}
+
+func (r *revisionRepository) List(ctx context.Context, ref RevisionRef, params ListParams) ([]models.ContentRevision, int64, error) {
+	if _, err := lookupRevisionTarget(ref.EntityType); err != nil {
+		return nil, 0, err
+	}
+	tenantID := tenant.ID(ctx)
+	query := `SELECT ` + revisionColumns + ` FROM content_revisions WHERE tenant_id = $1 AND entity_type = $2 AND entity_id = $3 ORDER BY version DESC LIMIT $4 OFFSET $5`
+	var revisions []models.ContentRevision
+	if err := r.db.SelectContext(ctx, &revisions, query, tenantID, ref.EntityType, ref.EntityID, params.Limit, params.Offset()); err != nil {
+		return nil, 0, err
+	}
+
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM content_revisions WHERE tenant_id = $1 AND entity_type = $2 AND entity_id = $3`
+	if err := r.db.GetContext(ctx, &total, countQuery, tenantID, ref.EntityType, ref.EntityID); err != nil {
+		return nil, 0, err
+	}
+	return revisions, total, nil
+}
+
+func (r *revisionRepository) Get(ctx context.Context, ref RevisionRef, version int) (models.ContentRevision, error) {
+	return getRevision(ctx, r.db, ref, version)
+}
+
+func getRevision(ctx context.Context, q sqlx.QueryerContext, ref RevisionRef, version int) (models.ContentRevision, error) {
+	if _, err := lookupRevisionTarget(ref.EntityType); err != nil {
+		return models.ContentRevision{}, err
+	}
+	query := `SELECT ` + revisionColumns + ` FROM content_revisions WHERE tenant_id = $1 AND entity_type = $2 AND entity_id = $3 AND version = $4`
+	var revision models.ContentRevision
+	if err := sqlx.GetContext(ctx, q, &revision, query, tenant.ID(ctx), ref.EntityType, ref.EntityID, version); err != nil {
+		if err == sql.ErrNoRows {
+			return models.ContentRevision{}, ErrNotFound
+		}
+		return models.ContentRevision{}, err
+	}
+	return revision, nil
+}
+
+func (r *revisionRepository) Draft(ctx context.Context, ref RevisionRef) (models.JSONB, error) {
+	return draftData(ctx, r.db, ref, "")
+}
+
+func draftData(ctx context.Context, q sqlx.QueryerContext, ref RevisionRef, suffix string) (models.JSONB, error) {
+	target, err := lookupRevisionTarget(ref.EntityType)
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT ` + revisionData + ` FROM ` + target.table + ` t WHERE id = $1 AND tenant_id = $2` + target.live() + suffix
+	var data models.JSONB
+	if err := sqlx.GetContext(ctx, q, &data, query, ref.EntityID, tenant.ID(ctx)); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+func (r *revisionRepository) Restore(ctx context.Context, ref RevisionRef, version int) error {
+	target, err := lookupRevisionTarget(ref.EntityType)
+	if err != nil {
+		return err
+	}
+	return withAudit(ctx, r.db, func(tx *sqlx.Tx) (audit.Change, error) {
+		before, err := draftData(ctx, tx, ref, " FOR UPDATE")
+		if err != nil {
+			return audit.Change{}, err
+		}
+		revision, err := getRevision(ctx, tx, ref, version)
+		if err != nil {
+			return audit.Change{}, err
+		}
+
+		query := `UPDATE ` + target.table + ` SET (` + target.columns + `) = (SELECT ` + target.columns + ` FROM jsonb_populate_record(NULL::` + target.table + `, $2::jsonb)), has_unpublished_changes = TRUE WHERE id = $1`
+		if _, err := tx.ExecContext(ctx, query, ref.EntityID, revision.Data); err != nil {
+			return audit.Change{}, err
+		}
+		after, err := draftData(ctx, tx, ref, "")
+		if err != nil {
+			return audit.Change{}, err
+		}
+		return audit.Change{Action: audit.ActionRestore, EntityType: ref.EntityType, EntityID: ref.EntityID.String(), Before: before, After: after}, nil
+	})
+}
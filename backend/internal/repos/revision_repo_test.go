@@ -0,0 +1,199 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/tanydotai/tanyai/backend/internal/audit"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
+)
+
+var revisionRowColumns = []string{"id", "tenant_id", "entity_type", "entity_id", "version", "data", "published_by", "created_at"}
+
+func TestRevisionRepositoryPublishSnapshotsDraft(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewRevisionRepository(sqlx.NewDb(db, "sqlmock"))
+	serviceID := uuid.New()
+	revisionID := uuid.New()
+	data := `{"id":"` + serviceID.String() + `","name":"Audit","price_min":150}`
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT published_revision_id, has_unpublished_changes, publish_at, to_jsonb(t) - 'published_revision_id' - 'has_unpublished_changes' - 'publish_at' - 'deleted_at' AS data FROM services t WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL FOR UPDATE`)).
+		WithArgs(serviceID, tenant.DefaultID).
+		WillReturnRows(sqlmock.NewRows([]string{"published_revision_id", "has_unpublished_changes", "publish_at", "data"}).AddRow(uuid.New(), true, nil, []byte(data)))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO content_revisions`)).
+		WithArgs(tenant.DefaultID, audit.EntityService, serviceID, sqlmock.AnyArg(), nil).
+		WillReturnRows(sqlmock.NewRows(revisionRowColumns).AddRow(revisionID, tenant.DefaultID, audit.EntityService, serviceID, 2, []byte(data), nil, time.Now()))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE services SET published_revision_id = $2, has_unpublished_changes = FALSE, publish_at = NULL WHERE id = $1`)).
+		WithArgs(serviceID, revisionID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO audit_log`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	revisions, err := repo.Publish(context.Background(), []RevisionRef{{EntityType: audit.EntityService, EntityID: serviceID}})
+	if err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if len(revisions) != 1 || revisions[0].Version != 2 || revisions[0].Data["price_min"] != 150.0 {
+		t.Fatalf("unexpected revisions %+v", revisions)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestRevisionRepositoryPublishKeepsUnchangedRevision(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewRevisionRepository(sqlx.NewDb(db, "sqlmock"))
+	projectID := uuid.New()
+	revisionID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM projects t WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL FOR UPDATE`)).
+		WithArgs(projectID, tenant.DefaultID).
+		WillReturnRows(sqlmock.NewRows([]string{"published_revision_id", "has_unpublished_changes", "publish_at", "data"}).AddRow(revisionID, false, nil, []byte(`{}`)))
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM content_revisions WHERE id = $1`)).
+		WithArgs(revisionID).
+		WillReturnRows(sqlmock.NewRows(revisionRowColumns).AddRow(revisionID, tenant.DefaultID, audit.EntityProject, projectID, 3, []byte(`{}`), nil, time.Now()))
+	mock.ExpectCommit()
+
+	revisions, err := repo.Publish(context.Background(), []RevisionRef{{EntityType: audit.EntityProject, EntityID: projectID}})
+	if err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if len(revisions) != 1 || revisions[0].Version != 3 {
+		t.Fatalf("expected existing revision, got %+v", revisions)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestRevisionRepositoryPublishRollsBackBatchOnMissingDraft(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewRevisionRepository(sqlx.NewDb(db, "sqlmock"))
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM profile t WHERE id = $1`)).
+		WillReturnRows(sqlmock.NewRows([]string{"published_revision_id"}))
+	mock.ExpectRollback()
+
+	refs := []RevisionRef{{EntityType: audit.EntityProfile, EntityID: uuid.New()}, {EntityType: audit.EntityService, EntityID: uuid.New()}}
+	if _, err := repo.Publish(context.Background(), refs); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := repo.Publish(context.Background(), []RevisionRef{{EntityType: audit.EntitySkill, EntityID: uuid.New()}}); !errors.Is(err, ErrUnsupportedEntity) {
+		t.Fatalf("expected skills to be rejected, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestRevisionRepositoryPublishDueSkipsFailedRows(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewRevisionRepository(sqlx.NewDb(db, "sqlmock"))
+	now := time.Date(2025, 2, 9, 8, 0, 0, 0, time.UTC)
+	brokenID, serviceID := uuid.New(), uuid.New()
+	revisionID := uuid.New()
+	data := `{"id":"` + serviceID.String() + `","name":"Audit"}`
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, tenant_id FROM profile WHERE publish_at IS NOT NULL AND publish_at <= $1 ORDER BY publish_at`)).
+		WithArgs(now).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id"}))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, tenant_id FROM services WHERE publish_at IS NOT NULL AND publish_at <= $1 AND deleted_at IS NULL ORDER BY publish_at`)).
+		WithArgs(now).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id"}).AddRow(brokenID, tenant.DefaultID).AddRow(serviceID, tenant.DefaultID))
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM services t WHERE id = $1`)).
+		WithArgs(brokenID, tenant.DefaultID).
+		WillReturnError(errors.New("boom"))
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM services t WHERE id = $1`)).
+		WithArgs(serviceID, tenant.DefaultID).
+		WillReturnRows(sqlmock.NewRows([]string{"published_revision_id", "has_unpublished_changes", "publish_at", "data"}).AddRow(nil, true, now, []byte(data)))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO content_revisions`)).
+		WillReturnRows(sqlmock.NewRows(revisionRowColumns).AddRow(revisionID, tenant.DefaultID, audit.EntityService, serviceID, 1, []byte(data), nil, now))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE services SET published_revision_id = $2`)).
+		WithArgs(serviceID, revisionID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO audit_log`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, tenant_id FROM projects WHERE publish_at IS NOT NULL AND publish_at <= $1 AND deleted_at IS NULL ORDER BY publish_at`)).
+		WithArgs(now).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id"}))
+
+	published, err := repo.PublishDue(context.Background(), now)
+	if err != nil {
+		t.Fatalf("publish due: %v", err)
+	}
+	if published != 1 {
+		t.Fatalf("expected the healthy row to be published, got %d", published)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestRevisionRepositoryRestoreCopiesRevisionIntoDraft(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewRevisionRepository(sqlx.NewDb(db, "sqlmock"))
+	serviceID := uuid.New()
+	old := []byte(`{"name":"Old"}`)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM services t WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL FOR UPDATE`)).
+		WithArgs(serviceID, tenant.DefaultID).
+		WillReturnRows(sqlmock.NewRows([]string{"data"}).AddRow([]byte(`{"name":"New"}`)))
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM content_revisions WHERE tenant_id = $1 AND entity_type = $2 AND entity_id = $3 AND version = $4`)).
+		WithArgs(tenant.DefaultID, audit.EntityService, serviceID, 1).
+		WillReturnRows(sqlmock.NewRows(revisionRowColumns).AddRow(uuid.New(), tenant.DefaultID, audit.EntityService, serviceID, 1, old, nil, time.Now()))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE services SET (name, description, price_min, price_max, currency, duration_label, is_active, "order") = (SELECT name, description, price_min, price_max, currency, duration_label, is_active, "order" FROM jsonb_populate_record(NULL::services, $2::jsonb)), has_unpublished_changes = TRUE WHERE id = $1`)).
+		WithArgs(serviceID, old).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM services t WHERE id = $1 AND tenant_id = $2`)).
+		WillReturnRows(sqlmock.NewRows([]string{"data"}).AddRow(old))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO audit_log`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.Restore(context.Background(), RevisionRef{EntityType: audit.EntityService, EntityID: serviceID}, 1); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
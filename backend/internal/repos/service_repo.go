@@ -6,14 +6,19 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/tanydotai/tanyai/backend/internal/audit"
 	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
 )
 
+const serviceColumns = `id, name, description, price_min, price_max, currency, duration_label, is_active, "order", ` + publicationColumns
+
 // ServiceRepository defines CRUD behaviour for services.
 type ServiceRepository interface {
 	List(ctx context.Context, params ListParams) ([]models.Service, int64, error)
 	Create(ctx context.Context, service models.Service) (models.Service, error)
 	Update(ctx context.Context, service models.Service) (models.Service, error)
+	// Delete moves the row to the trash; see TrashRepository.
 	Delete(ctx context.Context, id uuid.UUID) error
 	Reorder(ctx context.Context, pairs []models.Service) error
 	Toggle(ctx context.Context, id uuid.UUID, desired *bool) (models.Service, error)
@@ -29,8 +34,9 @@ type serviceRepository struct {
 }
 
 func (r *serviceRepository) List(ctx context.Context, params ListParams) ([]models.Service, int64, error) {
-	const baseQuery = `SELECT id, name, description, price_min, price_max, currency, duration_label, is_active, "order" FROM services`
-	const countQuery = `SELECT COUNT(*) FROM services`
+	tenantID := tenant.ID(ctx)
+	const baseQuery = `SELECT ` + serviceColumns + ` FROM services WHERE tenant_id = $1 AND deleted_at IS NULL`
+	const countQuery = `SELECT COUNT(*) FROM services WHERE tenant_id = $1 AND deleted_at IS NULL`
 
 	orderBy, err := params.ValidateSort(map[string]string{
 		"order": "\"order\"",
@@ -40,15 +46,15 @@ func (r *serviceRepository) List(ctx context.Context, params ListParams) ([]mode
 		return nil, 0, err
 	}
 
-	query := baseQuery + " ORDER BY " + orderBy + " LIMIT $1 OFFSET $2"
+	query := baseQuery + " ORDER BY " + orderBy + " LIMIT $2 OFFSET $3"
 
 	var services []models.Service
-	if err := r.db.SelectContext(ctx, &services, query, params.Limit, params.Offset()); err != nil {
+	if err := r.db.SelectContext(ctx, &services, query, tenantID, params.Limit, params.Offset()); err != nil {
 		return nil, 0, err
 	}
 
 	var total int64
-	if err := r.db.GetContext(ctx, &total, countQuery); err != nil {
+	if err := r.db.GetContext(ctx, &total, countQuery, tenantID); err != nil {
 		return nil, 0, err
 	}
 
@@ -56,21 +62,28 @@ func (r *serviceRepository) List(ctx context.Context, params ListParams) ([]mode
 }
 
 func (r *serviceRepository) Create(ctx context.Context, service models.Service) (models.Service, error) {
-	const query = `INSERT INTO services (name, description, price_min, price_max, currency, duration_label, is_active, "order")
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-RETURNING id, name, description, price_min, price_max, currency, duration_label, is_active, "order"`
+	const query = `INSERT INTO services (name, description, price_min, price_max, currency, duration_label, is_active, "order", tenant_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING ` + serviceColumns
 
 	var created models.Service
-	if err := r.db.GetContext(ctx, &created, query,
-		service.Name,
-		service.Description,
-		service.PriceMin,
-		service.PriceMax,
-		service.Currency,
-		service.DurationLabel,
-		service.IsActive,
-		service.Order,
-	); err != nil {
+	err := withAudit(ctx, r.db, func(tx *sqlx.Tx) (audit.Change, error) {
+		if err := tx.GetContext(ctx, &created, query,
+			service.Name,
+			service.Description,
+			service.PriceMin,
+			service.PriceMax,
+			service.Currency,
+			service.DurationLabel,
+			service.IsActive,
+			service.Order,
+			tenant.ID(ctx),
+		); err != nil {
+			return audit.Change{}, err
+		}
+		return audit.Change{Action: audit.ActionCreate, EntityType: audit.EntityService, EntityID: created.ID.String(), After: created}, nil
+	})
+	if err != nil {
 		return models.Service{}, err
 	}
 	return created, nil
@@ -85,85 +98,94 @@ func (r *serviceRepository) Update(ctx context.Context, service models.Service)
     currency = $6,
     duration_label = $7,
     is_active = $8,
-    "order" = $9
-WHERE id = $1
-RETURNING id, name, description, price_min, price_max, currency, duration_label, is_active, "order"`
+    "order" = $9,
+    has_unpublished_changes = TRUE
+WHERE id = $1 AND tenant_id = $10
+RETURNING ` + serviceColumns
 
 	var updated models.Service
-	if err := r.db.GetContext(ctx, &updated, query,
-		service.ID,
-		service.Name,
-		service.Description,
-		service.PriceMin,
-		service.PriceMax,
-		service.Currency,
-		service.DurationLabel,
-		service.IsActive,
-		service.Order,
-	); err != nil {
-		if err == sql.ErrNoRows {
-			return models.Service{}, ErrNotFound
+	err := withAudit(ctx, r.db, func(tx *sqlx.Tx) (audit.Change, error) {
+		before, err := r.lock(ctx, tx, service.ID)
+		if err != nil {
+			return audit.Change{}, err
+		}
+		if err := tx.GetContext(ctx, &updated, query,
+			service.ID,
+			service.Name,
+			service.Description,
+			service.PriceMin,
+			service.PriceMax,
+			service.Currency,
+			service.DurationLabel,
+			service.IsActive,
+			service.Order,
+			tenant.ID(ctx),
+		); err != nil {
+			return audit.Change{}, err
 		}
+		return audit.Change{Action: audit.ActionUpdate, EntityType: audit.EntityService, EntityID: service.ID.String(), Before: before, After: updated}, nil
+	})
+	if err != nil {
 		return models.Service{}, err
 	}
 	return updated, nil
 }
 
 func (r *serviceRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	const query = `DELETE FROM services WHERE id = $1`
-	res, err := r.db.ExecContext(ctx, query, id)
-	if err != nil {
-		return err
-	}
-	rows, err := res.RowsAffected()
-	if err != nil {
-		return err
-	}
-	if rows == 0 {
-		return ErrNotFound
-	}
-	return nil
-}
-
-func (r *serviceRepository) Reorder(ctx context.Context, pairs []models.Service) error {
-	tx, err := r.db.BeginTxx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		_ = tx.Rollback()
-	}()
-
-	for _, pair := range pairs {
-		res, err := tx.ExecContext(ctx, `UPDATE services SET "order" = $1 WHERE id = $2`, pair.Order, pair.ID)
+	return withAudit(ctx, r.db, func(tx *sqlx.Tx) (audit.Change, error) {
+		before, err := r.lock(ctx, tx, id)
 		if err != nil {
-			return err
+			return audit.Change{}, err
 		}
-		rows, err := res.RowsAffected()
-		if err != nil {
-			return err
+		if _, err := tx.ExecContext(ctx, `UPDATE services SET deleted_at = NOW(), publish_at = NULL WHERE id = $1 AND tenant_id = $2`, id, tenant.ID(ctx)); err != nil {
+			return audit.Change{}, err
 		}
-		if rows == 0 {
-			return ErrNotFound
-		}
-	}
+		return audit.Change{Action: audit.ActionDelete, EntityType: audit.EntityService, EntityID: id.String(), Before: before}, nil
+	})
+}
 
-	if err := tx.Commit(); err != nil {
-		return err
+func (r *serviceRepository) Reorder(ctx context.Context, pairs []models.Service) error {
+	ids := make([]uuid.UUID, len(pairs))
+	orders := make(map[uuid.UUID]int, len(pairs))
+	for i, pair := range pairs {
+		ids[i] = pair.ID
+		orders[pair.ID] = pair.Order
 	}
-	return nil
+	return withAudit(ctx, r.db, func(tx *sqlx.Tx) (audit.Change, error) {
+		return reorderRows(ctx, tx, "services", audit.EntityService, ids, orders)
+	})
 }
 
 func (r *serviceRepository) Toggle(ctx context.Context, id uuid.UUID, desired *bool) (models.Service, error) {
-	query := `UPDATE services SET is_active = NOT is_active WHERE id = $1 RETURNING id, name, description, price_min, price_max, currency, duration_label, is_active, "order"`
-	args := []any{id}
+	query := `UPDATE services SET is_active = NOT is_active, has_unpublished_changes = TRUE WHERE id = $1 AND tenant_id = $2 RETURNING ` + serviceColumns
+	args := []any{id, tenant.ID(ctx)}
 	if desired != nil {
-		query = `UPDATE services SET is_active = $2 WHERE id = $1 RETURNING id, name, description, price_min, price_max, currency, duration_label, is_active, "order"`
+		query = `UPDATE services SET is_active = $3, has_unpublished_changes = TRUE WHERE id = $1 AND tenant_id = $2 RETURNING ` + serviceColumns
 		args = append(args, *desired)
 	}
 
 	var service models.Service
-	if err := r.db.GetContext(ctx, &service, query, args...); err != nil {
+	err := withAudit(ctx, r.db, func(tx *sqlx.Tx) (audit.Change, error) {
+		before, err := r.lock(ctx, tx, id)
+		if err != nil {
+			return audit.Change{}, err
+		}
+		if err := tx.GetContext(ctx, &service, query, args...); err != nil {
+			return audit.Change{}, err
+		}
+		return audit.Change{Action: audit.ActionToggle, EntityType: audit.EntityService, EntityID: id.String(), Before: before, After: service}, nil
+	})
+	if err != nil {
+		return models.Service{}, err
+	}
+	return service, nil
+}
+
+// lock loads a service for update within tx, returning ErrNotFound when it is missing or
+// in the trash.
+func (r *serviceRepository) lock(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (models.Service, error) {
+	var service models.Service
+	if err := tx.GetContext(ctx, &service, `SELECT `+serviceColumns+` FROM services WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL FOR UPDATE`, id, tenant.ID(ctx)); err != nil {
 		if err == sql.ErrNoRows {
 			return models.Service{}, ErrNotFound
 		}
@@ -6,7 +6,9 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/tanydotai/tanyai/backend/internal/audit"
 	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
 )
 
 // SkillRepository exposes persistence operations for skills.
@@ -14,6 +16,7 @@ type SkillRepository interface {
 	List(ctx context.Context, params ListParams) ([]models.Skill, int64, error)
 	Create(ctx context.Context, skill models.Skill) (models.Skill, error)
 	Update(ctx context.Context, skill models.Skill) (models.Skill, error)
+	// Delete moves the row to the trash; see TrashRepository.
 	Delete(ctx context.Context, id uuid.UUID) error
 	Reorder(ctx context.Context, pairs []models.Skill) error
 }
@@ -28,8 +31,9 @@ type skillRepository struct {
 }
 
 func (r *skillRepository) List(ctx context.Context, params ListParams) ([]models.Skill, int64, error) {
-	const baseQuery = `SELECT id, name, "order" FROM skills`
-	const countQuery = `SELECT COUNT(*) FROM skills`
+	tenantID := tenant.ID(ctx)
+	const baseQuery = `SELECT id, name, "order" FROM skills WHERE tenant_id = $1 AND deleted_at IS NULL`
+	const countQuery = `SELECT COUNT(*) FROM skills WHERE tenant_id = $1 AND deleted_at IS NULL`
 
 	orderBy, err := params.ValidateSort(map[string]string{
 		"order": "\"order\"",
@@ -39,15 +43,15 @@ func (r *skillRepository) List(ctx context.Context, params ListParams) ([]models
 		return nil, 0, err
 	}
 
-	query := baseQuery + " ORDER BY " + orderBy + " LIMIT $1 OFFSET $2"
+	query := baseQuery + " ORDER BY " + orderBy + " LIMIT $2 OFFSET $3"
 
 	var skills []models.Skill
-	if err := r.db.SelectContext(ctx, &skills, query, params.Limit, params.Offset()); err != nil {
+	if err := r.db.SelectContext(ctx, &skills, query, tenantID, params.Limit, params.Offset()); err != nil {
 		return nil, 0, err
 	}
 
 	var total int64
-	if err := r.db.GetContext(ctx, &total, countQuery); err != nil {
+	if err := r.db.GetContext(ctx, &total, countQuery, tenantID); err != nil {
 		return nil, 0, err
 	}
 
@@ -55,69 +59,75 @@ func (r *skillRepository) List(ctx context.Context, params ListParams) ([]models
 }
 
 func (r *skillRepository) Create(ctx context.Context, skill models.Skill) (models.Skill, error) {
-	const query = `INSERT INTO skills (name, "order") VALUES ($1, $2) RETURNING id, name, "order"`
+	const query = `INSERT INTO skills (name, "order", tenant_id) VALUES ($1, $2, $3) RETURNING id, name, "order"`
 
 	var created models.Skill
-	if err := r.db.GetContext(ctx, &created, query, skill.Name, skill.Order); err != nil {
+	err := withAudit(ctx, r.db, func(tx *sqlx.Tx) (audit.Change, error) {
+		if err := tx.GetContext(ctx, &created, query, skill.Name, skill.Order, tenant.ID(ctx)); err != nil {
+			return audit.Change{}, err
+		}
+		return audit.Change{Action: audit.ActionCreate, EntityType: audit.EntitySkill, EntityID: created.ID.String(), After: created}, nil
+	})
+	if err != nil {
 		return models.Skill{}, err
 	}
 	return created, nil
 }
 
 func (r *skillRepository) Update(ctx context.Context, skill models.Skill) (models.Skill, error) {
-	const query = `UPDATE skills SET name = $2, "order" = $3 WHERE id = $1 RETURNING id, name, "order"`
+	const query = `UPDATE skills SET name = $2, "order" = $3 WHERE id = $1 AND tenant_id = $4 RETURNING id, name, "order"`
 
 	var updated models.Skill
-	if err := r.db.GetContext(ctx, &updated, query, skill.ID, skill.Name, skill.Order); err != nil {
-		if err == sql.ErrNoRows {
-			return models.Skill{}, ErrNotFound
+	err := withAudit(ctx, r.db, func(tx *sqlx.Tx) (audit.Change, error) {
+		before, err := r.lock(ctx, tx, skill.ID)
+		if err != nil {
+			return audit.Change{}, err
+		}
+		if err := tx.GetContext(ctx, &updated, query, skill.ID, skill.Name, skill.Order, tenant.ID(ctx)); err != nil {
+			return audit.Change{}, err
 		}
+		return audit.Change{Action: audit.ActionUpdate, EntityType: audit.EntitySkill, EntityID: skill.ID.String(), Before: before, After: updated}, nil
+	})
+	if err != nil {
 		return models.Skill{}, err
 	}
 	return updated, nil
 }
 
 func (r *skillRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	const query = `DELETE FROM skills WHERE id = $1`
-	res, err := r.db.ExecContext(ctx, query, id)
-	if err != nil {
-		return err
-	}
-	rows, err := res.RowsAffected()
-	if err != nil {
-		return err
-	}
-	if rows == 0 {
-		return ErrNotFound
-	}
-	return nil
+	return withAudit(ctx, r.db, func(tx *sqlx.Tx) (audit.Change, error) {
+		before, err := r.lock(ctx, tx, id)
+		if err != nil {
+			return audit.Change{}, err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE skills SET deleted_at = NOW() WHERE id = $1 AND tenant_id = $2`, id, tenant.ID(ctx)); err != nil {
+			return audit.Change{}, err
+		}
+		return audit.Change{Action: audit.ActionDelete, EntityType: audit.EntitySkill, EntityID: id.String(), Before: before}, nil
+	})
 }
 
 func (r *skillRepository) Reorder(ctx context.Context, pairs []models.Skill) error {
-	tx, err := r.db.BeginTxx(ctx, nil)
-	if err != nil {
-		return err
+	ids := make([]uuid.UUID, len(pairs))
+	orders := make(map[uuid.UUID]int, len(pairs))
+	for i, pair := range pairs {
+		ids[i] = pair.ID
+		orders[pair.ID] = pair.Order
 	}
-	defer func() {
-		_ = tx.Rollback()
-	}()
+	return withAudit(ctx, r.db, func(tx *sqlx.Tx) (audit.Change, error) {
+		return reorderRows(ctx, tx, "skills", audit.EntitySkill, ids, orders)
+	})
+}
 
-	for _, pair := range pairs {
-		res, err := tx.ExecContext(ctx, `UPDATE skills SET "order" = $1 WHERE id = $2`, pair.Order, pair.ID)
-		if err != nil {
-			return err
-		}
-		rows, err := res.RowsAffected()
-		if err != nil {
-			return err
-		}
-		if rows == 0 {
-			return ErrNotFound
+// lock loads a skill for update within tx, returning ErrNotFound when it is missing or
+// in the trash.
+func (r *skillRepository) lock(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (models.Skill, error) {
+	var skill models.Skill
+	if err := tx.GetContext(ctx, &skill, `SELECT id, name, "order" FROM skills WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL FOR UPDATE`, id, tenant.ID(ctx)); err != nil {
+		if err == sql.ErrNoRows {
+			return models.Skill{}, ErrNotFound
 		}
+		return models.Skill{}, err
 	}
-
-	if err := tx.Commit(); err != nil {
-		return err
-	}
-	return nil
+	return skill, nil
 }
@@ -0,0 +1,32 @@
+package repos
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+)
+
+// TenantRepository exposes read access to tenants.
+type TenantRepository interface {
+	List(ctx context.Context) ([]models.Tenant, error)
+}
+
+// NewTenantRepository constructs a SQL-backed tenant repository.
+func NewTenantRepository(db *sqlx.DB) TenantRepository {
+	return &tenantRepository{db: db}
+}
+
+type tenantRepository struct {
+	db *sqlx.DB
+}
+
+func (r *tenantRepository) List(ctx context.Context) ([]models.Tenant, error) {
+	const query = `SELECT id, slug, name, hosts, ai_provider, chat_model, created_at, updated_at FROM tenants ORDER BY slug ASC`
+
+	var tenants []models.Tenant
+	if err := r.db.SelectContext(ctx, &tenants, query); err != nil {
+		return nil, err
+	}
+	return tenants, nil
+}
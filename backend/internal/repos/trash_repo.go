@@ -0,0 +1,203 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/tanydotai/tanyai/backend/internal/audit"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
+)
+
+// trashTarget describes a table with soft deletion; label is the column shown in the trash.
+type trashTarget struct {
+	table string
+	label string
+}
+
+var trashTargets = map[string]trashTarget{
+	audit.EntitySkill:   {table: "skills", label: "name"},
+	audit.EntityService: {table: "services", label: "name"},
+	audit.EntityProject: {table: "projects", label: "title"},
+}
+
+// trashOrder fixes the iteration order over trashTargets.
+var trashOrder = []string{audit.EntitySkill, audit.EntityService, audit.EntityProject}
+
+// TrashRepository manages soft-deleted content.
+type TrashRepository interface {
+	// List returns trashed items, most recently deleted first, optionally limited to one
+	// entity type.
+	List(ctx context.Context, entityType string, params ListParams) ([]models.TrashItem, int64, error)
+	// Restore takes an item out of the trash. Published services and projects become
+	// visible again with their published revision.
+	Restore(ctx context.Context, entityType string, id uuid.UUID) error
+	// Purge permanently deletes a trashed item together with its revisions.
+	Purge(ctx context.Context, entityType string, id uuid.UUID) error
+	// PurgeExpired permanently deletes items of every tenant trashed before cutoff.
+	PurgeExpired(ctx context.Context, cutoff time.Time) (int, error)
+}
+
+// NewTrashRepository constructs a SQL-backed trash repository.
+func NewTrashRepository(db *sqlx.DB) TrashRepository {
+	return &trashRepository{db: db}
+}
+
+type trashRepository struct {
+	db *sqlx.DB
+}
+
+func lookupTrashTarget(entityType string) (trashTarget, error) {
+	target, ok := trashTargets[entityType]
+	if !ok {
+		return trashTarget{}, fmt.Errorf("%w: %s", ErrUnsupportedEntity, entityType)
+	}
+	return target, nil
+}
+
+func (r *trashRepository) List(ctx context.Context, entityType string, params ListParams) ([]models.TrashItem, int64, error) {
+	entityTypes := trashOrder
+	if entityType != "" {
+		if _, err := lookupTrashTarget(entityType); err != nil {
+			return nil, 0, err
+		}
+		entityTypes = []string{entityType}
+	}
+
+	selects := make([]string, len(entityTypes))
+	for i, entity := range entityTypes {
+		target := trashTargets[entity]
+		selects[i] = `SELECT '` + entity + `' AS entity_type, id, ` + target.label + ` AS name, deleted_at FROM ` + target.table + ` WHERE tenant_id = $1 AND deleted_at IS NOT NULL`
+	}
+	union := strings.Join(selects, " UNION ALL ")
+
+	tenantID := tenant.ID(ctx)
+	var items []models.TrashItem
+	query := `SELECT entity_type, id, name, deleted_at FROM (` + union + `) trash ORDER BY deleted_at DESC, id LIMIT $2 OFFSET $3`
+	if err := r.db.SelectContext(ctx, &items, query, tenantID, params.Limit, params.Offset()); err != nil {
+		return nil, 0, err
+	}
+
+	var total int64
+	if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM (`+union+`) trash`, tenantID); err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+func (r *trashRepository) Restore(ctx context.Context, entityType string, id uuid.UUID) error {
+	target, err := lookupTrashTarget(entityType)
+	if err != nil {
+		return err
+	}
+	return withAudit(ctx, r.db, func(tx *sqlx.Tx) (audit.Change, error) {
+		var deletedAt time.Time
+		lockQuery := `SELECT deleted_at FROM ` + target.table + ` WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NOT NULL FOR UPDATE`
+		if err := tx.GetContext(ctx, &deletedAt, lockQuery, id, tenant.ID(ctx)); err != nil {
+			if err == sql.ErrNoRows {
+				return audit.Change{}, ErrNotFound
+			}
+			return audit.Change{}, err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE `+target.table+` SET deleted_at = NULL WHERE id = $1`, id); err != nil {
+			return audit.Change{}, err
+		}
+		return audit.Change{
+			Action:     audit.ActionRestore,
+			EntityType: entityType,
+			EntityID:   id.String(),
+			Before:     map[string]any{"deleted_at": deletedAt},
+			After:      map[string]any{"deleted_at": nil},
+		}, nil
+	})
+}
+
+func (r *trashRepository) Purge(ctx context.Context, entityType string, id uuid.UUID) error {
+	target, err := lookupTrashTarget(entityType)
+	if err != nil {
+		return err
+	}
+	return withAudit(ctx, r.db, func(tx *sqlx.Tx) (audit.Change, error) {
+		var before models.JSONB
+		query := `DELETE FROM ` + target.table + ` t WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NOT NULL RETURNING to_jsonb(t)`
+		if err := tx.GetContext(ctx, &before, query, id, tenant.ID(ctx)); err != nil {
+			if err == sql.ErrNoRows {
+				return audit.Change{}, ErrNotFound
+			}
+			return audit.Change{}, err
+		}
+		if err := deleteRevisions(ctx, tx, entityType, []uuid.UUID{id}); err != nil {
+			return audit.Change{}, err
+		}
+		return audit.Change{Action: audit.ActionPurge, EntityType: entityType, EntityID: id.String(), Before: before}, nil
+	})
+}
+
+func (r *trashRepository) PurgeExpired(ctx context.Context, cutoff time.Time) (int, error) {
+	purged := 0
+	for _, entityType := range trashOrder {
+		count, err := r.purgeExpired(ctx, entityType, cutoff)
+		purged += count
+		if err != nil {
+			return purged, err
+		}
+	}
+	return purged, nil
+}
+
+// purgeExpired deletes the expired rows of one table in a single transaction, auditing each
+// row under its own tenant.
+func (r *trashRepository) purgeExpired(ctx context.Context, entityType string, cutoff time.Time) (int, error) {
+	target := trashTargets[entityType]
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var rows []struct {
+		ID       uuid.UUID    `db:"id"`
+		TenantID uuid.UUID    `db:"tenant_id"`
+		Data     models.JSONB `db:"data"`
+	}
+	query := `DELETE FROM ` + target.table + ` t WHERE deleted_at IS NOT NULL AND deleted_at < $1 RETURNING id, tenant_id, to_jsonb(t) AS data`
+	if err := tx.SelectContext(ctx, &rows, query, cutoff); err != nil {
+		return 0, err
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]uuid.UUID, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+		change := audit.Change{Action: audit.ActionPurge, EntityType: entityType, EntityID: row.ID.String(), Before: row.Data}
+		if err := recordAudit(tenant.WithID(ctx, row.TenantID), tx, change); err != nil {
+			return 0, err
+		}
+	}
+	if err := deleteRevisions(ctx, tx, entityType, ids); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(rows), nil
+}
+
+// deleteRevisions removes the revision history of purged draft-enabled rows.
+func deleteRevisions(ctx context.Context, tx *sqlx.Tx, entityType string, ids []uuid.UUID) error {
+	if _, drafts := revisionTargets[entityType]; !drafts {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, `DELETE FROM content_revisions WHERE entity_type = $1 AND entity_id = ANY($2)`, entityType, pq.Array(ids))
+	return err
+}
@@ -0,0 +1,172 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/tanydotai/tanyai/backend/internal/audit"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
+)
+
+func TestSkillRepositoryDeleteMovesToTrash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewSkillRepository(sqlx.NewDb(db, "sqlmock"))
+	id := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, "order" FROM skills WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL FOR UPDATE`)).
+		WithArgs(id, tenant.DefaultID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "order"}).AddRow(id, "Go", 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE skills SET deleted_at = NOW() WHERE id = $1 AND tenant_id = $2`)).
+		WithArgs(id, tenant.DefaultID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO audit_log`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.Delete(context.Background(), id); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestTrashRepositoryListFiltersEntity(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewTrashRepository(sqlx.NewDb(db, "sqlmock"))
+	id := uuid.New()
+	deletedAt := time.Date(2025, 2, 9, 8, 0, 0, 0, time.UTC)
+	union := `SELECT 'project' AS entity_type, id, title AS name, deleted_at FROM projects WHERE tenant_id = $1 AND deleted_at IS NOT NULL`
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT entity_type, id, name, deleted_at FROM (`+union+`) trash ORDER BY deleted_at DESC, id LIMIT $2 OFFSET $3`)).
+		WithArgs(tenant.DefaultID, 20, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"entity_type", "id", "name", "deleted_at"}).AddRow(audit.EntityProject, id, "Atlas", deletedAt))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM (` + union + `) trash`)).
+		WithArgs(tenant.DefaultID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	items, total, err := repo.List(context.Background(), audit.EntityProject, ListParams{Page: 1, Limit: 20})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if total != 1 || len(items) != 1 || items[0].Name != "Atlas" || !items[0].DeletedAt.Equal(deletedAt) {
+		t.Fatalf("unexpected items %+v total %d", items, total)
+	}
+	if _, _, err := repo.List(context.Background(), audit.EntityProfile, ListParams{Page: 1, Limit: 20}); !errors.Is(err, ErrUnsupportedEntity) {
+		t.Fatalf("expected ErrUnsupportedEntity, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestTrashRepositoryRestoreRequiresTrashedRow(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewTrashRepository(sqlx.NewDb(db, "sqlmock"))
+	id := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT deleted_at FROM services WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NOT NULL FOR UPDATE`)).
+		WithArgs(id, tenant.DefaultID).
+		WillReturnRows(sqlmock.NewRows([]string{"deleted_at"}))
+	mock.ExpectRollback()
+
+	if err := repo.Restore(context.Background(), audit.EntityService, id); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestTrashRepositoryPurgeDeletesRevisions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewTrashRepository(sqlx.NewDb(db, "sqlmock"))
+	id := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`DELETE FROM projects t WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NOT NULL RETURNING to_jsonb(t)`)).
+		WithArgs(id, tenant.DefaultID).
+		WillReturnRows(sqlmock.NewRows([]string{"to_jsonb"}).AddRow([]byte(`{"title":"Atlas"}`)))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM content_revisions WHERE entity_type = $1 AND entity_id = ANY($2)`)).
+		WithArgs(audit.EntityProject, pq.Array([]uuid.UUID{id})).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO audit_log`)).
+		WithArgs(tenant.DefaultID, nil, nil, "", audit.ActionPurge, audit.EntityProject, id.String(), sqlmock.AnyArg(), nil, sqlmock.AnyArg(), "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.Purge(context.Background(), audit.EntityProject, id); err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestTrashRepositoryPurgeExpiredAuditsPerTenant(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewTrashRepository(sqlx.NewDb(db, "sqlmock"))
+	cutoff := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+	skillID, otherTenant := uuid.New(), uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`DELETE FROM skills t WHERE deleted_at IS NOT NULL AND deleted_at < $1 RETURNING id, tenant_id, to_jsonb(t) AS data`)).
+		WithArgs(cutoff).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id", "data"}).AddRow(skillID, otherTenant, []byte(`{"name":"PHP"}`)))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO audit_log`)).
+		WithArgs(otherTenant, nil, nil, "", audit.ActionPurge, audit.EntitySkill, skillID.String(), sqlmock.AnyArg(), nil, sqlmock.AnyArg(), "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	for _, table := range []string{"services", "projects"} {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`DELETE FROM ` + table + ` t WHERE deleted_at IS NOT NULL`)).
+			WithArgs(cutoff).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id", "data"}))
+		mock.ExpectRollback()
+	}
+
+	purged, err := repo.PurgeExpired(context.Background(), cutoff)
+	if err != nil {
+		t.Fatalf("purge expired: %v", err)
+	}
+	if purged != 1 {
+		t.Fatalf("expected 1 purged row, got %d", purged)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
@@ -3,23 +3,53 @@ package repos
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/tanydotai/tanyai/backend/internal/audit"
 	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
 )
 
+const refreshTokenColumns = `id, user_id, family_id, token_hash, expires_at, revoked, session_started_at, user_agent, ip_address, created_at`
+
+const userColumns = `id, email, password_hash, name, tenant_id, is_active, totp_secret, totp_enabled, created_at, updated_at`
+
 // UserRepository exposes persistence operations for users and refresh tokens.
 type UserRepository interface {
 	GetByEmail(ctx context.Context, email string) (models.User, []string, error)
 	GetByID(ctx context.Context, id uuid.UUID) (models.User, []string, error)
+	List(ctx context.Context, params ListParams) ([]UserWithRoles, int64, error)
+	Create(ctx context.Context, user models.User, roles []string) (models.User, error)
+	SetRoles(ctx context.Context, id uuid.UUID, roles []string) error
+	SetActive(ctx context.Context, id uuid.UUID, active bool) error
+	SetPassword(ctx context.Context, id uuid.UUID, hash string) error
+	CreatePasswordToken(ctx context.Context, token models.PasswordToken) error
+	ConsumePasswordToken(ctx context.Context, hash string, now time.Time) (models.PasswordToken, error)
+	RevokeAllRefreshTokens(ctx context.Context, userID uuid.UUID) (int64, error)
+	SetPendingTOTP(ctx context.Context, id uuid.UUID, secret string) error
+	EnableTOTP(ctx context.Context, id uuid.UUID, counter int64, recoveryHashes []string) error
+	DisableTOTP(ctx context.Context, id uuid.UUID) error
+	AcceptTOTPCounter(ctx context.Context, id uuid.UUID, counter int64) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, id uuid.UUID, recoveryHashes []string) error
+	ConsumeRecoveryCode(ctx context.Context, id uuid.UUID, hash string, now time.Time) (bool, error)
 	CreateRefreshToken(ctx context.Context, token models.RefreshToken) error
 	FindRefreshTokenByHash(ctx context.Context, hash string) (models.RefreshToken, error)
 	RevokeRefreshToken(ctx context.Context, id uuid.UUID) (bool, error)
 	RevokeRefreshTokenByHash(ctx context.Context, hash string) error
 	DeleteExpiredRefreshTokens(ctx context.Context, before time.Time) (int64, error)
+	RevokeRefreshFamily(ctx context.Context, userID, familyID uuid.UUID) (int64, error)
+	ListActiveRefreshTokens(ctx context.Context, userID uuid.UUID, now time.Time) ([]models.RefreshToken, error)
+}
+
+// UserWithRoles is a user row joined with its assigned roles.
+type UserWithRoles struct {
+	models.User
+	Roles pq.StringArray `db:"roles"`
 }
 
 // NewUserRepository constructs a SQL-backed user repository.
@@ -32,7 +62,7 @@ type userRepository struct {
 }
 
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (models.User, []string, error) {
-	const query = `SELECT id, email, password_hash, name, created_at, updated_at FROM users WHERE LOWER(email) = LOWER($1)`
+	const query = `SELECT ` + userColumns + ` FROM users WHERE LOWER(email) = LOWER($1)`
 
 	var user models.User
 	if err := r.db.GetContext(ctx, &user, query, strings.TrimSpace(email)); err != nil {
@@ -50,7 +80,7 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (models.U
 }
 
 func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (models.User, []string, error) {
-	const query = `SELECT id, email, password_hash, name, created_at, updated_at FROM users WHERE id = $1`
+	const query = `SELECT ` + userColumns + ` FROM users WHERE id = $1`
 	var user models.User
 	if err := r.db.GetContext(ctx, &user, query, id); err != nil {
 		if err == sql.ErrNoRows {
@@ -75,20 +105,387 @@ func (r *userRepository) fetchRoles(ctx context.Context, userID uuid.UUID) ([]st
 	return roles, nil
 }
 
+func (r *userRepository) List(ctx context.Context, params ListParams) ([]UserWithRoles, int64, error) {
+	tenantID := tenant.ID(ctx)
+	const baseQuery = `SELECT u.id, u.email, u.password_hash, u.name, u.tenant_id, u.is_active, u.totp_secret, u.totp_enabled, u.created_at, u.updated_at,
+		COALESCE(array_agg(ur.role ORDER BY ur.role) FILTER (WHERE ur.role IS NOT NULL), '{}') AS roles
+		FROM users u LEFT JOIN user_roles ur ON ur.user_id = u.id
+		WHERE u.tenant_id = $1 GROUP BY u.id`
+	const countQuery = `SELECT COUNT(*) FROM users WHERE tenant_id = $1`
+
+	orderBy, err := params.ValidateSort(map[string]string{
+		"email":      "u.email",
+		"created_at": "u.created_at",
+	}, "email")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := baseQuery + " ORDER BY " + orderBy + " LIMIT $2 OFFSET $3"
+
+	var users []UserWithRoles
+	if err := r.db.SelectContext(ctx, &users, query, tenantID, params.Limit, params.Offset()); err != nil {
+		return nil, 0, err
+	}
+
+	var total int64
+	if err := r.db.GetContext(ctx, &total, countQuery, tenantID); err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+func (r *userRepository) Create(ctx context.Context, user models.User, roles []string) (models.User, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return models.User{}, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	const query = `INSERT INTO users (email, password_hash, name, tenant_id, is_active) VALUES ($1, $2, $3, $4, $5) RETURNING ` + userColumns
+	var created models.User
+	if err := tx.GetContext(ctx, &created, query, strings.ToLower(strings.TrimSpace(user.Email)), user.PasswordHash, user.Name, tenant.ID(ctx), user.IsActive); err != nil {
+		if isUniqueViolation(err) {
+			return models.User{}, ErrConflict
+		}
+		return models.User{}, err
+	}
+
+	if err := insertRoles(ctx, tx, created.ID, roles); err != nil {
+		return models.User{}, err
+	}
+	change := audit.Change{
+		Action:     audit.ActionCreate,
+		EntityType: audit.EntityUser,
+		EntityID:   created.ID.String(),
+		After:      UserWithRoles{User: created, Roles: roles},
+	}
+	if err := recordAudit(ctx, tx, change); err != nil {
+		return models.User{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.User{}, err
+	}
+	return created, nil
+}
+
+func (r *userRepository) SetRoles(ctx context.Context, id uuid.UUID, roles []string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if err := ensureTenantUser(ctx, tx, id); err != nil {
+		return err
+	}
+	var previous []string
+	if err := tx.SelectContext(ctx, &previous, `SELECT role FROM user_roles WHERE user_id = $1 ORDER BY role`, id); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_roles WHERE user_id = $1`, id); err != nil {
+		return err
+	}
+	if err := insertRoles(ctx, tx, id, roles); err != nil {
+		return err
+	}
+	change := audit.Change{
+		Action:     audit.ActionRoles,
+		EntityType: audit.EntityUser,
+		EntityID:   id.String(),
+		Before:     map[string]any{"roles": previous},
+		After:      map[string]any{"roles": roles},
+	}
+	if err := recordAudit(ctx, tx, change); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetActive toggles the account; deactivating also revokes every outstanding refresh token.
+func (r *userRepository) SetActive(ctx context.Context, id uuid.UUID, active bool) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var wasActive bool
+	if err := tx.GetContext(ctx, &wasActive, `SELECT is_active FROM users WHERE id = $1 AND tenant_id = $2 FOR UPDATE`, id, tenant.ID(ctx)); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET is_active = $2, updated_at = NOW() WHERE id = $1 AND tenant_id = $3`, id, active, tenant.ID(ctx)); err != nil {
+		return err
+	}
+	if !active {
+		if _, err := tx.ExecContext(ctx, `UPDATE refresh_tokens SET revoked = TRUE WHERE user_id = $1 AND revoked = FALSE`, id); err != nil {
+			return err
+		}
+	}
+	action := audit.ActionActivate
+	if !active {
+		action = audit.ActionDeactivate
+	}
+	change := audit.Change{
+		Action:     action,
+		EntityType: audit.EntityUser,
+		EntityID:   id.String(),
+		Before:     map[string]any{"is_active": wasActive},
+		After:      map[string]any{"is_active": active},
+	}
+	if err := recordAudit(ctx, tx, change); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetPassword stores a new password hash and revokes every outstanding refresh token.
+func (r *userRepository) SetPassword(ctx context.Context, id uuid.UUID, hash string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	res, err := tx.ExecContext(ctx, `UPDATE users SET password_hash = $2, updated_at = NOW() WHERE id = $1`, id, hash)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE refresh_tokens SET revoked = TRUE WHERE user_id = $1 AND revoked = FALSE`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *userRepository) CreatePasswordToken(ctx context.Context, token models.PasswordToken) error {
+	const query = `INSERT INTO password_tokens (id, user_id, token_hash, purpose, expires_at) VALUES ($1, $2, $3, $4, $5)`
+	_, err := r.db.ExecContext(ctx, query, token.ID, token.UserID, token.TokenHash, token.Purpose, token.ExpiresAt)
+	return err
+}
+
+// ConsumePasswordToken marks an unused, unexpired token as used and returns it.
+func (r *userRepository) ConsumePasswordToken(ctx context.Context, hash string, now time.Time) (models.PasswordToken, error) {
+	const query = `UPDATE password_tokens SET used_at = $2
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2
+		RETURNING id, user_id, token_hash, purpose, expires_at, used_at, created_at`
+	var token models.PasswordToken
+	if err := r.db.GetContext(ctx, &token, query, hash, now); err != nil {
+		if err == sql.ErrNoRows {
+			return models.PasswordToken{}, ErrNotFound
+		}
+		return models.PasswordToken{}, err
+	}
+	return token, nil
+}
+
+func (r *userRepository) RevokeAllRefreshTokens(ctx context.Context, userID uuid.UUID) (int64, error) {
+	const query = `UPDATE refresh_tokens SET revoked = TRUE WHERE user_id = $1 AND revoked = FALSE`
+	res, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// SetPendingTOTP stores a secret awaiting verification; it has no effect on login until EnableTOTP.
+func (r *userRepository) SetPendingTOTP(ctx context.Context, id uuid.UUID, secret string) error {
+	const query = `UPDATE users SET totp_secret = $2, totp_last_counter = NULL, updated_at = NOW() WHERE id = $1 AND totp_enabled = FALSE`
+	res, err := r.db.ExecContext(ctx, query, id, secret)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+// EnableTOTP activates the pending secret and stores fresh recovery codes.
+func (r *userRepository) EnableTOTP(ctx context.Context, id uuid.UUID, counter int64, recoveryHashes []string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	const query = `UPDATE users SET totp_enabled = TRUE, totp_last_counter = $2, updated_at = NOW()
+		WHERE id = $1 AND totp_secret IS NOT NULL AND totp_enabled = FALSE`
+	res, err := tx.ExecContext(ctx, query, id, counter)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrConflict
+	}
+	if err := replaceRecoveryCodes(ctx, tx, id, recoveryHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *userRepository) DisableTOTP(ctx context.Context, id uuid.UUID) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	res, err := tx.ExecContext(ctx, `UPDATE users SET totp_secret = NULL, totp_enabled = FALSE, totp_last_counter = NULL, updated_at = NOW() WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// AcceptTOTPCounter records counter as used, returning false when it is not newer than the
+// last accepted one so a code cannot be replayed within its validity window.
+func (r *userRepository) AcceptTOTPCounter(ctx context.Context, id uuid.UUID, counter int64) (bool, error) {
+	const query = `UPDATE users SET totp_last_counter = $2
+		WHERE id = $1 AND totp_enabled = TRUE AND (totp_last_counter IS NULL OR totp_last_counter < $2)`
+	res, err := r.db.ExecContext(ctx, query, id, counter)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func (r *userRepository) ReplaceRecoveryCodes(ctx context.Context, id uuid.UUID, recoveryHashes []string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if err := replaceRecoveryCodes(ctx, tx, id, recoveryHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *userRepository) ConsumeRecoveryCode(ctx context.Context, id uuid.UUID, hash string, now time.Time) (bool, error) {
+	const query = `UPDATE user_recovery_codes SET used_at = $3 WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+	res, err := r.db.ExecContext(ctx, query, id, hash, now)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, hashes []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	for _, hash := range hashes {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO user_recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ensureTenantUser(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) error {
+	var exists bool
+	if err := tx.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM users WHERE id = $1 AND tenant_id = $2)`, id, tenant.ID(ctx)); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func insertRoles(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, roles []string) error {
+	for _, role := range roles {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO user_roles (user_id, role) VALUES ($1, $2) ON CONFLICT DO NOTHING`, userID, role); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
 func (r *userRepository) CreateRefreshToken(ctx context.Context, token models.RefreshToken) error {
-	const query = `INSERT INTO refresh_tokens (id, user_id, token_hash, expires_at, revoked) VALUES ($1, $2, $3, $4, $5)`
+	const query = `INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, expires_at, revoked, session_started_at, user_agent, ip_address)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+	familyID := token.FamilyID
+	if familyID == uuid.Nil {
+		familyID = token.ID
+	}
+	startedAt := token.SessionStartedAt
+	if startedAt.IsZero() {
+		startedAt = time.Now().UTC()
+	}
 	_, err := r.db.ExecContext(ctx, query,
 		token.ID,
 		token.UserID,
+		familyID,
 		token.TokenHash,
 		token.ExpiresAt,
 		token.Revoked,
+		startedAt,
+		token.UserAgent,
+		token.IPAddress,
 	)
 	return err
 }
 
 func (r *userRepository) FindRefreshTokenByHash(ctx context.Context, hash string) (models.RefreshToken, error) {
-	const query = `SELECT id, user_id, token_hash, expires_at, revoked, created_at FROM refresh_tokens WHERE token_hash = $1`
+	const query = `SELECT ` + refreshTokenColumns + ` FROM refresh_tokens WHERE token_hash = $1`
 	var token models.RefreshToken
 	if err := r.db.GetContext(ctx, &token, query, hash); err != nil {
 		if err == sql.ErrNoRows {
@@ -130,3 +527,25 @@ func (r *userRepository) DeleteExpiredRefreshTokens(ctx context.Context, before
 	}
 	return affected, nil
 }
+
+// RevokeRefreshFamily revokes every token of a session belonging to userID.
+func (r *userRepository) RevokeRefreshFamily(ctx context.Context, userID, familyID uuid.UUID) (int64, error) {
+	const query = `UPDATE refresh_tokens SET revoked = TRUE WHERE user_id = $1 AND family_id = $2 AND revoked = FALSE`
+	res, err := r.db.ExecContext(ctx, query, userID, familyID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ListActiveRefreshTokens returns the current token of every live session, newest first.
+func (r *userRepository) ListActiveRefreshTokens(ctx context.Context, userID uuid.UUID, now time.Time) ([]models.RefreshToken, error) {
+	const query = `SELECT ` + refreshTokenColumns + ` FROM refresh_tokens
+		WHERE user_id = $1 AND revoked = FALSE AND expires_at > $2
+		ORDER BY created_at DESC`
+	var tokens []models.RefreshToken
+	if err := r.db.SelectContext(ctx, &tokens, query, userID, now); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
@@ -0,0 +1,123 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	defaultClamdTimeout   = 10 * time.Second
+	defaultClamdChunkSize = 64 * 1024
+)
+
+// Clamd scans files with a ClamAV daemon using the INSTREAM command.
+type Clamd struct {
+	network   string
+	address   string
+	timeout   time.Duration
+	maxStream int64
+	chunkSize int
+}
+
+// NewClamd constructs a clamd client. addr is "host:port" for TCP or "unix:/path" for a
+// Unix socket; a non-positive timeout uses a 10 second default. maxStream caps how many
+// leading bytes of a file are sent and should not exceed clamd's StreamMaxLength, which
+// otherwise rejects the whole stream; zero sends files in full.
+func NewClamd(addr string, timeout time.Duration, maxStream int64) *Clamd {
+	network := "tcp"
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		network, addr = "unix", path
+	}
+	if timeout <= 0 {
+		timeout = defaultClamdTimeout
+	}
+	return &Clamd{network: network, address: addr, timeout: timeout, maxStream: maxStream, chunkSize: defaultClamdChunkSize}
+}
+
+// Name identifies the scanner in reports.
+func (c *Clamd) Name() string {
+	return "clamd"
+}
+
+// Scan streams r to clamd in length-prefixed chunks and parses the verdict, e.g.
+// "stream: OK" or "stream: Eicar-Signature FOUND".
+func (c *Clamd) Scan(ctx context.Context, r io.Reader, _ string) (Finding, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, c.network, c.address)
+	if err != nil {
+		return Finding{}, fmt.Errorf("clamd: connect: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	writer := bufio.NewWriter(conn)
+	if _, err := writer.WriteString("zINSTREAM\x00"); err != nil {
+		return Finding{}, fmt.Errorf("clamd: write command: %w", err)
+	}
+	if c.maxStream > 0 {
+		r = io.LimitReader(r, c.maxStream)
+	}
+	var size [4]byte
+	chunk := make([]byte, c.chunkSize)
+	for {
+		n, err := io.ReadFull(r, chunk)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size[:], uint32(n))
+			if _, err := writer.Write(size[:]); err != nil {
+				return Finding{}, fmt.Errorf("clamd: write chunk: %w", err)
+			}
+			if _, err := writer.Write(chunk[:n]); err != nil {
+				return Finding{}, fmt.Errorf("clamd: write chunk: %w", err)
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return Finding{}, fmt.Errorf("clamd: read file: %w", err)
+		}
+	}
+	binary.BigEndian.PutUint32(size[:], 0)
+	if _, err := writer.Write(size[:]); err != nil {
+		return Finding{}, fmt.Errorf("clamd: write terminator: %w", err)
+	}
+	if err := writer.Flush(); err != nil {
+		return Finding{}, fmt.Errorf("clamd: write: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadBytes(0)
+	if err != nil && len(reply) == 0 {
+		return Finding{}, fmt.Errorf("clamd: read reply: %w", err)
+	}
+	return parseClamdReply(string(bytes.TrimRight(reply, "\x00\n")))
+}
+
+func parseClamdReply(reply string) (Finding, error) {
+	// Replies are prefixed with the stream name, e.g. "stream: OK".
+	_, verdict, found := strings.Cut(reply, ": ")
+	if !found {
+		verdict = reply
+	}
+	switch {
+	case verdict == "OK":
+		return Finding{}, nil
+	case strings.HasSuffix(verdict, " FOUND"):
+		return Finding{Threat: strings.TrimSuffix(verdict, " FOUND")}, nil
+	default:
+		// Includes "INSTREAM size limit exceeded. ERROR" when StreamMaxLength is too low.
+		return Finding{}, fmt.Errorf("clamd: unexpected reply %q", reply)
+	}
+}
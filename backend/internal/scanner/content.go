@@ -0,0 +1,226 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"mime"
+	"strings"
+)
+
+const (
+	// contentWindow bounds the leading and trailing bytes kept for the polyglot checks.
+	contentWindow = 1 << 20
+	// contentChunk is how much of the stream is searched for markers at a time.
+	contentChunk = 64 * 1024
+	// markerOverlap carries the end of one chunk into the next so markers split across
+	// chunks are still found. It covers the longest marker plus one delimiter byte.
+	markerOverlap = 16
+)
+
+// scriptMarkers are markup and server-side code that never belongs inside binary media.
+// A browser or misconfigured server sniffing such a file may execute it.
+var scriptMarkers = [][]byte{
+	[]byte("<script"),
+	[]byte("<?php"),
+	[]byte("<html"),
+	[]byte("<iframe"),
+	[]byte("javascript:"),
+}
+
+// embeddedSignatures identify a second file format appended to media, the classic
+// polyglot construction.
+var embeddedSignatures = []struct {
+	threat    string
+	signature []byte
+}{
+	{"Polyglot.ZIP", []byte("PK\x03\x04")},
+	{"Polyglot.ZIP", []byte("PK\x05\x06")},
+	{"Polyglot.ZIP", []byte("PK\x01\x02")},
+	{"Polyglot.PDF", []byte("%PDF-")},
+	{"Polyglot.ELF", []byte("\x7fELF")},
+	{"Polyglot.PE", []byte("MZ\x90\x00")},
+	{"Polyglot.RAR", []byte("Rar!\x1a\x07")},
+}
+
+// pdfActions are PDF names that run code or carry files.
+var pdfActions = []struct {
+	threat string
+	name   []byte
+}{
+	{"PDF.JavaScript", []byte("/JavaScript")},
+	{"PDF.JavaScript", []byte("/JS")},
+	{"PDF.Launch", []byte("/Launch")},
+	{"PDF.EmbeddedFile", []byte("/EmbeddedFile")},
+}
+
+// Content is a dependency-free checker for polyglot files and embedded scripts.
+type Content struct{}
+
+// NewContent constructs the built-in content checker.
+func NewContent() *Content {
+	return &Content{}
+}
+
+// Name identifies the scanner in reports.
+func (c *Content) Name() string {
+	return "content"
+}
+
+// Scan inspects r according to its declared type. SVG and text are left to the SVG
+// sanitizer and are not checked for markup. Scripts and PDF actions are searched for in
+// the whole stream, a chunk at a time; file signatures are checked at the start and, for
+// data appended after an image, within the last contentWindow bytes. Memory use stays
+// bounded whatever the file size.
+func (c *Content) Scan(_ context.Context, r io.Reader, contentType string) (Finding, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+	if mediaType == "image/svg+xml" || strings.HasPrefix(mediaType, "text/") {
+		return Finding{}, nil
+	}
+	isPDF := mediaType == "application/pdf"
+
+	var (
+		head, tail, carry []byte
+		total             int64
+		prefixChecked     bool
+	)
+	chunk := make([]byte, contentChunk)
+	for {
+		n, readErr := io.ReadFull(r, chunk)
+		final := errors.Is(readErr, io.EOF) || errors.Is(readErr, io.ErrUnexpectedEOF)
+		if readErr != nil && !final {
+			return Finding{}, readErr
+		}
+		data := chunk[:n]
+		total += int64(n)
+		if room := contentWindow - len(head); room > 0 {
+			head = append(head, data[:min(room, n)]...)
+		}
+		tail = appendWindow(tail, data)
+
+		if !prefixChecked && (len(head) >= markerOverlap || final) {
+			prefixChecked = true
+			if finding := scanPrefix(head, mediaType); finding.Threat != "" {
+				return finding, nil
+			}
+		}
+
+		window := append(carry, data...)
+		var finding Finding
+		if isPDF {
+			finding = scanPDF(window, final)
+		} else {
+			finding = scanMarkers(window)
+		}
+		if finding.Threat != "" {
+			return finding, nil
+		}
+		if final {
+			break
+		}
+		carry = append(carry[:0], window[max(0, len(window)-markerOverlap):]...)
+	}
+
+	if isPDF {
+		return Finding{}, nil
+	}
+	// Signatures are short enough to occur by chance inside compressed image data, so
+	// they only count after the image's own end marker.
+	if trailing := trailingData(head, tail, total, mediaType); len(trailing) > 0 {
+		for _, embedded := range embeddedSignatures {
+			if bytes.Contains(trailing, embedded.signature) {
+				return Finding{Threat: embedded.threat}, nil
+			}
+		}
+	}
+	return Finding{}, nil
+}
+
+// scanPrefix rejects media that starts with the signature of another format.
+func scanPrefix(head []byte, mediaType string) Finding {
+	if mediaType == "application/pdf" || mediaType == "application/zip" {
+		return Finding{}
+	}
+	for _, embedded := range embeddedSignatures {
+		if bytes.HasPrefix(head, embedded.signature) {
+			return Finding{Threat: embedded.threat}
+		}
+	}
+	return Finding{}
+}
+
+func scanMarkers(window []byte) Finding {
+	lower := bytes.ToLower(window)
+	for _, marker := range scriptMarkers {
+		if bytes.Contains(lower, marker) {
+			return Finding{Threat: "Embedded.Script"}
+		}
+	}
+	return Finding{}
+}
+
+// appendWindow appends data to tail and keeps only the last contentWindow bytes.
+func appendWindow(tail, data []byte) []byte {
+	tail = append(tail, data...)
+	if len(tail) > 2*contentWindow {
+		n := copy(tail, tail[len(tail)-contentWindow:])
+		tail = tail[:n]
+	}
+	return tail
+}
+
+// scanPDF looks for action names in data. A name ending exactly at the end of data only
+// counts in the final chunk, since the next chunk may show it continues.
+func scanPDF(data []byte, final bool) Finding {
+	for _, action := range pdfActions {
+		for offset := 0; offset < len(data); {
+			idx := bytes.Index(data[offset:], action.name)
+			if idx < 0 {
+				break
+			}
+			end := offset + idx + len(action.name)
+			// Names are delimited; "/JS" must not match "/JSONData".
+			if end == len(data) && final || end < len(data) && !isPDFNameChar(data[end]) {
+				return Finding{Threat: action.threat}
+			}
+			offset = end
+		}
+	}
+	return Finding{}
+}
+
+func isPDFNameChar(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9'
+}
+
+// trailingData returns the bytes after the end of a JPEG, PNG or WebP image that fall
+// within tail, the last bytes of a stream of total bytes starting with head.
+func trailingData(head, tail []byte, total int64, mediaType string) []byte {
+	if len(tail) > contentWindow {
+		tail = tail[len(tail)-contentWindow:]
+	}
+	switch mediaType {
+	case "image/jpeg":
+		if idx := bytes.LastIndex(tail, []byte{0xFF, 0xD9}); idx >= 0 {
+			return tail[idx+2:]
+		}
+	case "image/png":
+		if idx := bytes.LastIndex(tail, []byte("IEND")); idx >= 0 && idx+8 <= len(tail) {
+			return tail[idx+8:]
+		}
+	case "image/webp":
+		if len(head) >= 12 && bytes.HasPrefix(head, []byte("RIFF")) {
+			end := int64(binary.LittleEndian.Uint32(head[4:8])) + 8
+			if end < total {
+				start := int64(len(tail)) - (total - end)
+				return tail[max(0, start):]
+			}
+		}
+	}
+	return nil
+}
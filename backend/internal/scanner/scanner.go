@@ -0,0 +1,164 @@
+// Package scanner checks uploaded files for malware and smuggled content before they are
+// stored.
+package scanner
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// Status is the outcome of scanning a file.
+type Status string
+
+const (
+	// StatusClean means every scanner accepted the file.
+	StatusClean Status = "clean"
+	// StatusInfected means a scanner detected a threat; the file must be rejected.
+	StatusInfected Status = "infected"
+	// StatusFailed means a scanner could not run and the chain fails closed.
+	StatusFailed Status = "failed"
+	// StatusUnscanned means a scanner could not run and the chain fails open.
+	StatusUnscanned Status = "unscanned"
+)
+
+// Finding is what a single scanner reports. An empty Threat means the file is clean.
+type Finding struct {
+	Threat string
+}
+
+// Scanner inspects file contents. Scan reads r as a stream and may stop before its end
+// once it has a verdict.
+type Scanner interface {
+	Name() string
+	Scan(ctx context.Context, r io.Reader, contentType string) (Finding, error)
+}
+
+// Result records one scanner's verdict.
+type Result struct {
+	Scanner string `json:"scanner"`
+	Status  Status `json:"status"`
+	Threat  string `json:"threat,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Report summarises a chain run.
+type Report struct {
+	Status  Status   `json:"status"`
+	Threat  string   `json:"threat,omitempty"`
+	Results []Result `json:"results"`
+}
+
+// Chain runs scanners in order, stopping at the first detection.
+type Chain struct {
+	scanners []Scanner
+	failOpen bool
+}
+
+// NewChain constructs a Chain. With failOpen, scanner errors are recorded and the file is
+// accepted as unscanned; otherwise they fail the whole scan.
+func NewChain(failOpen bool, scanners ...Scanner) *Chain {
+	return &Chain{scanners: scanners, failOpen: failOpen}
+}
+
+// Len returns the number of configured scanners.
+func (c *Chain) Len() int {
+	return len(c.scanners)
+}
+
+// errScanDone tells the chain a scanner stopped reading its copy of the stream.
+var errScanDone = errors.New("scanner: done")
+
+// Scan feeds r to every scanner at once, so a file is read a single time and never held
+// in memory whatever its size. Results are reported in chain order: the first detection
+// wins and, failing closed, a scanner error before it fails the scan. An error reading r
+// is returned instead of a report, since it says nothing about the file.
+func (c *Chain) Scan(ctx context.Context, r io.Reader, contentType string) (Report, error) {
+	findings := make([]Finding, len(c.scanners))
+	errs := make([]error, len(c.scanners))
+	fanout := &fanout{writers: make([]*io.PipeWriter, len(c.scanners))}
+	var wg sync.WaitGroup
+	for i, s := range c.scanners {
+		pr, pw := io.Pipe()
+		fanout.writers[i] = pw
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			findings[i], errs[i] = s.Scan(ctx, pr, contentType)
+			// Unblock the writer if the scanner stopped early.
+			pr.CloseWithError(errScanDone)
+		}()
+	}
+	_, readErr := io.Copy(fanout, r)
+	if errors.Is(readErr, errScanDone) {
+		// Every scanner has its verdict; the rest of the file is not needed.
+		readErr = nil
+	}
+	fanout.close(readErr)
+	wg.Wait()
+	if readErr != nil {
+		return Report{}, readErr
+	}
+
+	report := Report{Status: StatusClean, Results: make([]Result, 0, len(c.scanners))}
+	for i, s := range c.scanners {
+		finding, err := findings[i], errs[i]
+		switch {
+		case err != nil:
+			status := StatusFailed
+			if c.failOpen {
+				status = StatusUnscanned
+			}
+			report.Results = append(report.Results, Result{Scanner: s.Name(), Status: status, Error: err.Error()})
+			if !c.failOpen {
+				report.Status = StatusFailed
+				return report, nil
+			}
+			report.Status = StatusUnscanned
+		case finding.Threat != "":
+			report.Results = append(report.Results, Result{Scanner: s.Name(), Status: StatusInfected, Threat: finding.Threat})
+			report.Status = StatusInfected
+			report.Threat = finding.Threat
+			return report, nil
+		default:
+			report.Results = append(report.Results, Result{Scanner: s.Name(), Status: StatusClean})
+		}
+	}
+	return report, nil
+}
+
+// fanout copies writes to every scanner still reading. A scanner that has stopped is
+// dropped rather than failing the copy for the others; once all have stopped, writes
+// fail with errScanDone.
+type fanout struct {
+	writers []*io.PipeWriter
+}
+
+func (f *fanout) Write(p []byte) (int, error) {
+	live := 0
+	for i, w := range f.writers {
+		if w == nil {
+			continue
+		}
+		if _, err := w.Write(p); err != nil {
+			f.writers[i] = nil
+			continue
+		}
+		live++
+	}
+	if live == 0 {
+		return 0, errScanDone
+	}
+	return len(p), nil
+}
+
+// close ends every stream, passing err on so scanners do not mistake a failed read for
+// the end of the file.
+func (f *fanout) close(err error) {
+	for _, w := range f.writers {
+		if w != nil {
+			w.CloseWithError(err)
+		}
+	}
+}
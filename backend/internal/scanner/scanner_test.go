@@ -0,0 +1,245 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClamd accepts INSTREAM sessions, reassembles the stream and replies with reply(stream).
+func fakeClamd(t *testing.T, reply func(stream []byte) string) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				command, err := reader.ReadString(0)
+				if err != nil || command != "zINSTREAM\x00" {
+					_, _ = conn.Write([]byte("UNKNOWN COMMAND\x00"))
+					return
+				}
+				var stream bytes.Buffer
+				for {
+					var size uint32
+					if err := binary.Read(reader, binary.BigEndian, &size); err != nil {
+						return
+					}
+					if size == 0 {
+						break
+					}
+					if _, err := io.CopyN(&stream, reader, int64(size)); err != nil {
+						return
+					}
+				}
+				_, _ = conn.Write([]byte(reply(stream.Bytes()) + "\x00"))
+			}(conn)
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestClamdInstream(t *testing.T) {
+	addr := fakeClamd(t, func(stream []byte) string {
+		if bytes.Contains(stream, []byte("EICAR")) {
+			return "stream: Eicar-Signature FOUND"
+		}
+		if len(stream) > 100_000 {
+			return "INSTREAM size limit exceeded. ERROR"
+		}
+		return "stream: OK"
+	})
+	clamd := NewClamd(addr, time.Second, 0)
+	clamd.chunkSize = 7
+	ctx := context.Background()
+
+	finding, err := clamd.Scan(ctx, strings.NewReader("hello, chunked world"), "text/plain")
+	if err != nil || finding.Threat != "" {
+		t.Fatalf("expected clean, got %+v %v", finding, err)
+	}
+	finding, err = clamd.Scan(ctx, strings.NewReader("X5O!P%@AP EICAR test"), "text/plain")
+	if err != nil || finding.Threat != "Eicar-Signature" {
+		t.Fatalf("expected detection, got %+v %v", finding, err)
+	}
+	clamd.chunkSize = defaultClamdChunkSize
+	if _, err := clamd.Scan(ctx, bytes.NewReader(make([]byte, 200_000)), "application/octet-stream"); err == nil || !strings.Contains(err.Error(), "size limit") {
+		t.Fatalf("expected size limit error, got %v", err)
+	}
+
+	// Capped at clamd's stream limit, large files are scanned by their leading bytes
+	// instead of failing.
+	capped := NewClamd(addr, time.Second, 100_000)
+	finding, err = capped.Scan(ctx, io.MultiReader(bytes.NewReader(make([]byte, 150_000)), strings.NewReader("EICAR")), "video/mp4")
+	if err != nil || finding.Threat != "" {
+		t.Fatalf("expected the capped stream to be scanned, got %+v %v", finding, err)
+	}
+	finding, err = capped.Scan(ctx, io.MultiReader(strings.NewReader("EICAR"), bytes.NewReader(make([]byte, 150_000))), "video/mp4")
+	if err != nil || finding.Threat != "Eicar-Signature" {
+		t.Fatalf("expected detection within the cap, got %+v %v", finding, err)
+	}
+}
+
+func TestClamdUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	_ = listener.Close()
+
+	if _, err := NewClamd(addr, time.Second, 0).Scan(context.Background(), strings.NewReader("data"), ""); err == nil {
+		t.Fatalf("expected connection error")
+	}
+}
+
+func TestContentDetectsPolyglotsAndScripts(t *testing.T) {
+	png := append([]byte("\x89PNG\r\n\x1a\n"), []byte("\x00\x00\x00\x00IEND\xaeB`\x82")...)
+	jpeg := []byte{0xFF, 0xD8, 0xFF, 0xE0, 'P', 'K', 0x03, 0x04, 0xFF, 0xD9}
+	cases := []struct {
+		name        string
+		data        []byte
+		contentType string
+		threat      string
+	}{
+		{"clean png", png, "image/png", ""},
+		{"zip appended to png", append(append([]byte{}, png...), "PK\x03\x04rest"...), "image/png", "Polyglot.ZIP"},
+		{"signature inside jpeg scan data", jpeg, "image/jpeg", ""},
+		{"pdf appended to jpeg", append(append([]byte{}, jpeg...), "%PDF-1.4"...), "image/jpeg", "Polyglot.PDF"},
+		{"script in png", append(append([]byte{}, png...), "<SCRIPT>alert(1)"...), "image/png", "Embedded.Script"},
+		{"php in jpeg comment", []byte("\xff\xd8\xff\xfe<?php system($_GET[0]); ?>\xff\xd9"), "image/jpeg", "Embedded.Script"},
+		{"executable declared as image", []byte("\x7fELF\x02\x01"), "image/png", "Polyglot.ELF"},
+		{"pdf javascript", []byte("%PDF-1.7\n<< /S /JavaScript /JS (app.alert(1)) >>"), "application/pdf", "PDF.JavaScript"},
+		{"pdf json name is not js", []byte("%PDF-1.7\n<< /JSONData 1 >>"), "application/pdf", ""},
+		{"pdf launch", []byte("%PDF-1.7\n<< /S /Launch /F (cmd.exe) >>"), "application/pdf", "PDF.Launch"},
+		{"svg is left to the sanitizer", []byte("<svg><script>alert(1)</script></svg>"), "image/svg+xml", ""},
+	}
+	content := NewContent()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			finding, err := content.Scan(context.Background(), bytes.NewReader(tc.data), tc.contentType)
+			if err != nil {
+				t.Fatalf("scan: %v", err)
+			}
+			if finding.Threat != tc.threat {
+				t.Fatalf("expected threat %q, got %q", tc.threat, finding.Threat)
+			}
+		})
+	}
+}
+
+type stubScanner struct {
+	name    string
+	finding Finding
+	err     error
+	calls   int
+	read    int64
+}
+
+func (s *stubScanner) Name() string { return s.name }
+
+func (s *stubScanner) Scan(_ context.Context, r io.Reader, _ string) (Finding, error) {
+	s.calls++
+	if s.err == nil {
+		n, _ := io.Copy(io.Discard, r)
+		s.read = n
+	}
+	return s.finding, s.err
+}
+
+func TestChainFailModes(t *testing.T) {
+	down := &stubScanner{name: "clamd", err: errors.New("connection refused")}
+	content := &stubScanner{name: "content"}
+	scan := func(chain *Chain) Report {
+		t.Helper()
+		report, err := chain.Scan(context.Background(), strings.NewReader("data"), "")
+		if err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		return report
+	}
+
+	report := scan(NewChain(false, down, content))
+	if report.Status != StatusFailed || len(report.Results) != 1 {
+		t.Fatalf("expected fail-closed report, got %+v", report)
+	}
+
+	report = scan(NewChain(true, down, content))
+	if report.Status != StatusUnscanned || len(report.Results) != 2 || report.Results[1].Status != StatusClean {
+		t.Fatalf("expected fail-open report, got %+v", report)
+	}
+
+	content.finding = Finding{Threat: "Embedded.Script"}
+	report = scan(NewChain(true, down, content))
+	if report.Status != StatusInfected || report.Threat != "Embedded.Script" {
+		t.Fatalf("expected detection to win over fail-open, got %+v", report)
+	}
+}
+
+func TestChainStreamsFileOnceToEveryScanner(t *testing.T) {
+	const size = 3 << 20
+	early := &stubScanner{name: "early", err: errors.New("gave up")}
+	full := &stubScanner{name: "full"}
+	report, err := NewChain(true, early, full).Scan(context.Background(), io.LimitReader(zeroReader{}, size), "video/mp4")
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if report.Status != StatusUnscanned || full.read != size {
+		t.Fatalf("expected the remaining scanner to read the whole stream, got %+v after %d bytes", report, full.read)
+	}
+
+	failing := io.MultiReader(strings.NewReader("data"), readerFunc(func([]byte) (int, error) { return 0, errors.New("bucket gone") }))
+	if _, err := NewChain(false, &stubScanner{name: "content"}).Scan(context.Background(), failing, ""); err == nil || err.Error() != "bucket gone" {
+		t.Fatalf("expected the read error, got %v", err)
+	}
+}
+
+func TestContentFindsMarkersAcrossChunksOfLargeFiles(t *testing.T) {
+	content := NewContent()
+	padding := bytes.Repeat([]byte{0x11}, contentChunk-3)
+
+	split := append([]byte{0xFF, 0xD8}, padding...)
+	split = append(split, "<scRIPT>"...)
+	if finding, err := content.Scan(context.Background(), bytes.NewReader(split), "image/jpeg"); err != nil || finding.Threat != "Embedded.Script" {
+		t.Fatalf("expected a marker split across chunks to be found, got %+v %v", finding, err)
+	}
+
+	pdf := append([]byte("%PDF-1.7\n"), padding...)
+	pdf = append(pdf, "/JSONData 1 /JS (x)"...)
+	if finding, err := content.Scan(context.Background(), bytes.NewReader(pdf), "application/pdf"); err != nil || finding.Threat != "PDF.JavaScript" {
+		t.Fatalf("expected a pdf action after the first chunk, got %+v %v", finding, err)
+	}
+
+	large := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0x22}, 3*contentWindow)...)
+	large = append(large, "\x00\x00\x00\x00IEND\xaeB`\x82PK\x03\x04"...)
+	if finding, err := content.Scan(context.Background(), bytes.NewReader(large), "image/png"); err != nil || finding.Threat != "Polyglot.ZIP" {
+		t.Fatalf("expected data appended to a large image to be found, got %+v %v", finding, err)
+	}
+}
+
+type readerFunc func([]byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) { return f(p) }
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
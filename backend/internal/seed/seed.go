@@ -209,6 +209,8 @@ func seedAll(ctx context.Context, db *sqlx.DB, payload seedPayload) error {
 		_ = tx.Rollback()
 	}()
 
+	// Profile, services and projects are scheduled for immediate publishing; the server's
+	// scheduled_publish job then makes them visible to the knowledge base.
 	if err := seedProfile(ctx, tx, payload.Profile); err != nil {
 		return err
 	}
@@ -237,8 +239,8 @@ func seedAll(ctx context.Context, db *sqlx.DB, payload seedPayload) error {
 func seedProfile(ctx context.Context, tx *sqlx.Tx, data profileSeed) error {
 	data.UpdatedAt = time.Now().UTC()
 	query := `
-INSERT INTO profile (id, name, title, bio, email, phone, location, avatar_url, updated_at)
-VALUES (:id, :name, :title, :bio, :email, :phone, :location, :avatar_url, :updated_at)
+INSERT INTO profile (id, name, title, bio, email, phone, location, avatar_url, updated_at, publish_at)
+VALUES (:id, :name, :title, :bio, :email, :phone, :location, :avatar_url, :updated_at, NOW())
 ON CONFLICT (id) DO UPDATE SET
         name = EXCLUDED.name,
         title = EXCLUDED.title,
@@ -247,7 +249,9 @@ ON CONFLICT (id) DO UPDATE SET
         phone = EXCLUDED.phone,
         location = EXCLUDED.location,
         avatar_url = EXCLUDED.avatar_url,
-        updated_at = EXCLUDED.updated_at;
+        updated_at = EXCLUDED.updated_at,
+        has_unpublished_changes = TRUE,
+        publish_at = EXCLUDED.publish_at;
 `
 	_, err := tx.NamedExecContext(ctx, query, data)
 	return err
@@ -271,8 +275,8 @@ ON CONFLICT (id) DO UPDATE SET
 
 func seedServices(ctx context.Context, tx *sqlx.Tx, data []serviceSeed) error {
 	query := `
-INSERT INTO services (id, name, description, price_min, price_max, currency, duration_label, is_active, "order")
-VALUES (:id, :name, :description, :price_min, :price_max, :currency, :duration_label, :is_active, :order)
+INSERT INTO services (id, name, description, price_min, price_max, currency, duration_label, is_active, "order", publish_at)
+VALUES (:id, :name, :description, :price_min, :price_max, :currency, :duration_label, :is_active, :order, NOW())
 ON CONFLICT (id) DO UPDATE SET
         name = EXCLUDED.name,
         description = EXCLUDED.description,
@@ -281,7 +285,9 @@ ON CONFLICT (id) DO UPDATE SET
         currency = EXCLUDED.currency,
         duration_label = EXCLUDED.duration_label,
         is_active = EXCLUDED.is_active,
-        "order" = EXCLUDED."order";
+        "order" = EXCLUDED."order",
+        has_unpublished_changes = TRUE,
+        publish_at = EXCLUDED.publish_at;
 `
 	for _, item := range data {
 		if _, err := tx.NamedExecContext(ctx, query, item); err != nil {
@@ -293,8 +299,8 @@ ON CONFLICT (id) DO UPDATE SET
 
 func seedProjects(ctx context.Context, tx *sqlx.Tx, data []projectSeed) error {
 	query := `
-INSERT INTO projects (id, title, description, tech_stack, image_url, project_url, category, duration_label, price_label, budget_label, "order", is_featured)
-VALUES (:id, :title, :description, CAST(:tech_stack AS TEXT[]), :image_url, :project_url, :category, :duration_label, :price_label, :budget_label, :order, :is_featured)
+INSERT INTO projects (id, title, description, tech_stack, image_url, project_url, category, duration_label, price_label, budget_label, "order", is_featured, publish_at)
+VALUES (:id, :title, :description, CAST(:tech_stack AS TEXT[]), :image_url, :project_url, :category, :duration_label, :price_label, :budget_label, :order, :is_featured, NOW())
 ON CONFLICT (id) DO UPDATE SET
         title = EXCLUDED.title,
         description = EXCLUDED.description,
@@ -306,7 +312,9 @@ ON CONFLICT (id) DO UPDATE SET
         price_label = EXCLUDED.price_label,
         budget_label = EXCLUDED.budget_label,
         "order" = EXCLUDED."order",
-        is_featured = EXCLUDED.is_featured;
+        is_featured = EXCLUDED.is_featured,
+        has_unpublished_changes = TRUE,
+        publish_at = EXCLUDED.publish_at;
 `
 	for _, item := range data {
 		if _, err := tx.NamedExecContext(ctx, query, item); err != nil {
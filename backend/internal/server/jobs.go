@@ -0,0 +1,48 @@
+package server
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// trashPurgeInterval is how often content trashed longer than the retention period is
+// purged.
+const trashPurgeInterval = time.Hour
+
+// mediaCleanupInterval is how often uploads unreferenced for longer than the grace period
+// are deleted.
+const mediaCleanupInterval = time.Hour
+
+// job is background maintenance work repeated for the lifetime of the server.
+type job struct {
+	name     string
+	interval time.Duration
+	run      func(ctx context.Context) error
+}
+
+// runJobs starts every job and returns immediately; jobs stop when ctx is cancelled.
+func runJobs(ctx context.Context, jobs []job) {
+	for _, j := range jobs {
+		if j.interval <= 0 || j.run == nil {
+			continue
+		}
+		go runJob(ctx, j)
+	}
+}
+
+func runJob(ctx context.Context, j job) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := j.run(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("[warn] job %s failed: %v", j.name, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunJobsRunsImmediatelyAndStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var runs atomic.Int32
+	done := make(chan struct{})
+
+	go func() {
+		runJob(ctx, job{name: "test", interval: time.Hour, run: func(context.Context) error {
+			runs.Add(1)
+			return nil
+		}})
+		close(done)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for runs.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if runs.Load() != 1 {
+		t.Fatalf("expected job to run once on start, got %d", runs.Load())
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("expected job to stop after cancel")
+	}
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -21,9 +22,15 @@ import (
 	"github.com/tanydotai/tanyai/backend/internal/middleware"
 	"github.com/tanydotai/tanyai/backend/internal/models"
 	"github.com/tanydotai/tanyai/backend/internal/repos"
+	"github.com/tanydotai/tanyai/backend/internal/services/answercache"
+	"github.com/tanydotai/tanyai/backend/internal/services/apikeys"
+	"github.com/tanydotai/tanyai/backend/internal/services/experiments"
 	"github.com/tanydotai/tanyai/backend/internal/services/ingest"
 	"github.com/tanydotai/tanyai/backend/internal/services/kb"
+	"github.com/tanydotai/tanyai/backend/internal/services/medialibrary"
+	"github.com/tanydotai/tanyai/backend/internal/services/portfolio"
 	"github.com/tanydotai/tanyai/backend/internal/storage"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
 )
 
 const defaultPort = "8080"
@@ -32,6 +39,7 @@ const defaultPort = "8080"
 type Server struct {
 	engine     *gin.Engine
 	httpServer *http.Server
+	jobs       []job
 }
 
 // New constructs an HTTP server with all routes and middleware registered.
@@ -45,8 +53,37 @@ func New(database *sqlx.DB, cfg config.Config) (*Server, error) {
 	analyticsHandler := analytics.NewHandler(analyticsService)
 
 	chatHistoryRepo := repos.NewChatHistoryRepository(database)
-	provider := resolveProvider(cfg)
+	provider, err := resolveProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
 	chatHandler := handlers.NewChatHandler(aggregator, chatHistoryRepo, cfg.ChatModel, provider, cfg.AIProvider, analyticsService)
+	if cfg.AnswerCache.Enabled {
+		cacheOpts := answercache.Options{
+			TTL:        cfg.AnswerCache.TTL,
+			MaxEntries: cfg.AnswerCache.MaxEntries,
+			Similarity: cfg.AnswerCache.Similarity,
+		}
+		if embedder, ok := provider.(ai.Embedder); ok && cfg.AnswerCache.Similarity > 0 {
+			cacheOpts.Embedder = embedder
+		}
+		answers := answercache.New(cacheOpts)
+		aggregator.OnInvalidate(answers.Purge)
+		chatHandler.SetAnswerCache(answers)
+	}
+	tenantProviderSet := newTenantProviders(cfg)
+	chatHandler.SetProviderSelector(tenantProviderSet)
+	chatHandler.SetProviderResolver(tenantProviderSet)
+	chatHandler.SetExposePrompt(cfg.ChatExposePrompt)
+	if cfg.ChatExperiment != "" {
+		experiment, err := newExperiment(cfg, tenantProviderSet)
+		if err != nil {
+			return nil, err
+		}
+		chatHandler.SetExperiment(experiment)
+	}
+	contentHandler := handlers.NewContentHandler(aggregator)
+	tenantResolver := tenant.NewResolver(repos.NewTenantRepository(database), cfg.KnowledgeCacheTTL)
 	healthHandler := handlers.NewHealthHandler(database)
 
 	externalSourceRepo := repos.NewExternalSourceRepository(database)
@@ -78,21 +115,31 @@ func New(database *sqlx.DB, cfg config.Config) (*Server, error) {
 	}
 
 	userRepo := repos.NewUserRepository(database)
-	tokenService, err := auth.NewTokenService(cfg.JWTSecret, cfg.AccessTokenTTL, cfg.RefreshTokenTTL)
+	tokenService, err := newTokenService(cfg)
 	if err != nil {
 		return nil, err
 	}
+	apiKeyRepo := repos.NewAPIKeyRepository(database)
+	apiKeyService := apikeys.NewService(apiKeyRepo)
+	auditRepo := repos.NewAuditRepository(database)
 	rateLimiter := auth.NewRateLimiter(cfg.LoginRateLimitPerMin, cfg.LoginRateLimitBurst, 10*time.Minute)
 
 	profileRepo := repos.NewProfileRepository(database)
 	skillsRepo := repos.NewSkillRepository(database)
 	servicesRepo := repos.NewServiceRepository(database)
 	projectsRepo := repos.NewProjectRepository(database)
+	revisionRepo := repos.NewRevisionRepository(database)
+	trashRepo := repos.NewTrashRepository(database)
+	trashRetention := time.Duration(cfg.TrashRetentionDays) * 24 * time.Hour
+	portfolioService := portfolio.NewService(repos.NewPortfolioRepository(database), revisionRepo)
 
-	profileHandler := adminhandlers.NewProfileHandler(profileRepo, aggregator.Invalidate)
+	profileHandler := adminhandlers.NewProfileHandler(profileRepo)
 	skillHandler := adminhandlers.NewSkillHandler(skillsRepo, aggregator.Invalidate)
 	serviceHandler := adminhandlers.NewServiceHandler(servicesRepo, aggregator.Invalidate)
 	projectHandler := adminhandlers.NewProjectHandler(projectsRepo, aggregator.Invalidate)
+	revisionHandler := adminhandlers.NewRevisionHandler(revisionRepo, aggregator.Invalidate)
+	portfolioHandler := adminhandlers.NewPortfolioHandler(portfolioService, aggregator.Invalidate)
+	trashHandler := adminhandlers.NewTrashHandler(trashRepo, trashRetention, aggregator.Invalidate)
 	externalSourceHandler := adminhandlers.NewExternalSourceHandler(externalSourceRepo, externalItemRepo, ingestService, aggregator.Invalidate)
 	externalItemHandler := adminhandlers.NewExternalItemHandler(externalItemRepo, aggregator.Invalidate)
 
@@ -102,40 +149,98 @@ func New(database *sqlx.DB, cfg config.Config) (*Server, error) {
 	}
 	uploadsLogger := log.New(os.Stdout, "", 0)
 	uploadsHandler := adminhandlers.NewUploadsHandler(objectStore, cfg.Upload, uploadsLogger)
+	uploadsHandler.SetAuditRecorder(auditRepo)
+	mediaRepo := repos.NewMediaRepository(database)
+	uploadsHandler.SetMediaRecorder(mediaRepo)
+	mediaService := medialibrary.NewService(mediaRepo, objectStore)
+	mediaHandler := adminhandlers.NewMediaHandler(mediaService)
+	documentsHandler := adminhandlers.NewDocumentsHandler(repos.NewDocumentRepository(database), objectStore, cfg.Upload, aggregator.Invalidate, uploadsLogger)
+	// Stored file URLs are stable; local storage with a signing key signs them on the way out.
+	fileURLs := storage.SignerFor(objectStore)
+	aggregator.SetURLSigner(fileURLs)
+	profileHandler.SetURLSigner(fileURLs)
+	projectHandler.SetURLSigner(fileURLs)
+	mediaHandler.SetURLSigner(fileURLs)
+	mediaOrphanGrace := time.Duration(cfg.MediaOrphanGraceHours) * time.Hour
 	uploadLimiter := auth.NewRateLimiter(cfg.UploadRateLimitPerMin, cfg.UploadRateLimitBurst, 10*time.Minute)
 	authHandler := authhandlers.NewHandler(userRepo, tokenService, rateLimiter, cfg.RefreshCookieName)
+	authHandler.SetMFAIssuer(cfg.MFAIssuer)
+	usersHandler := adminhandlers.NewUsersHandler(userRepo)
+	apiKeysHandler := adminhandlers.NewAPIKeysHandler(apiKeyRepo)
+	auditHandler := adminhandlers.NewAuditHandler(auditRepo)
 
 	engine.GET("/healthz", healthHandler.HandleHealth)
+	engine.GET("/.well-known/jwks.json", authHandler.JWKS)
 
 	knowledgeLimiter := auth.NewRateLimiter(cfg.KnowledgeRateLimitPerMin, cfg.KnowledgeRateLimitBurst, 10*time.Minute)
 	chatLimiter := auth.NewRateLimiter(cfg.ChatRateLimitPerMin, cfg.ChatRateLimitBurst, 10*time.Minute)
 
-	api := engine.Group("/api/v1")
-	{
-		api.POST("/chat", middleware.RateLimitByIP(chatLimiter), middleware.JSONLogger("chat"), chatHandler.HandleChat)
+	// Public routes resolve the tenant from the request host, or from the slug on /api/v1/t/:tenant.
+	registerPublic := func(api *gin.RouterGroup) {
+		api.POST("/chat", middleware.OptionalAPIKey(apiKeyService, auth.ScopeChat), middleware.OptionalBearer(tokenService), middleware.RateLimitByIP(chatLimiter), middleware.JSONLogger("chat"), chatHandler.HandleChat)
+		api.POST("/chat/feedback", middleware.RateLimitByIP(chatLimiter), middleware.JSONLogger("chat_feedback"), chatHandler.HandleFeedback)
+		api.POST("/chat/lead", middleware.RateLimitByIP(chatLimiter), middleware.JSONLogger("chat_lead"), chatHandler.HandleLead)
 		api.GET("/knowledge-base", middleware.RateLimitByIP(knowledgeLimiter), middleware.JSONLogger("knowledge_base"), chatHandler.HandleKnowledgeBase)
+
+		content := api.Group("", middleware.RateLimitByIP(knowledgeLimiter), middleware.JSONLogger("content"))
+		content.GET("/projects", contentHandler.HandleProjects)
+		content.GET("/projects/:id", contentHandler.HandleProject)
+		content.GET("/services", contentHandler.HandleServices)
+		content.GET("/skills", contentHandler.HandleSkills)
+		content.GET("/posts", contentHandler.HandlePosts)
+	}
+
+	api := engine.Group("/api/v1")
+	if localStore, ok := objectStore.(*storage.LocalStorage); ok {
+		filesHandler := handlers.NewFilesHandler(localStore)
+		api.GET("/files/*key", filesHandler.Serve)
+		api.HEAD("/files/*key", filesHandler.Serve)
 	}
+	registerPublic(api.Group("", middleware.ResolveTenant(tenantResolver)))
+	registerPublic(api.Group("/t/:"+middleware.TenantParam, middleware.ResolveTenant(tenantResolver)))
 
 	authGroup := engine.Group("/api/auth")
 	{
 		authGroup.POST("/login", authHandler.Login)
 		authGroup.POST("/refresh", authHandler.Refresh)
 		authGroup.POST("/logout", authHandler.Logout)
+		authGroup.POST("/password/set", middleware.RateLimitByIP(rateLimiter), authHandler.SetPassword)
+		authGroup.POST("/password/change", middleware.Authn(tokenService, nil), authHandler.ChangePassword)
+		authGroup.POST("/mfa/verify", authHandler.VerifyMFA)
+
+		sessions := authGroup.Group("/sessions", middleware.Authn(tokenService, nil))
+		{
+			sessions.GET("", authHandler.ListSessions)
+			sessions.DELETE("/:id", authHandler.RevokeSession)
+		}
+
+		mfa := authGroup.Group("/mfa", middleware.Authn(tokenService, nil))
+		{
+			mfa.POST("/enroll", authHandler.EnrollMFA)
+			mfa.POST("/enroll/verify", authHandler.ConfirmMFA)
+			mfa.POST("/disable", authHandler.DisableMFA)
+			mfa.POST("/recovery-codes", authHandler.RegenerateRecoveryCodes)
+		}
 	}
 
-	adminGroup := engine.Group("/api/admin", middleware.Authn(tokenService), middleware.AuthzAdmin())
+	adminGroup := engine.Group("/api/admin", middleware.Authn(tokenService, apiKeyService), middleware.RequireAnyPermission())
 	{
-		adminGroup.GET("/profile", profileHandler.Get)
-		adminGroup.PUT("/profile", profileHandler.Put)
+		content := adminGroup.Group("", middleware.RequirePermission(auth.PermissionContentWrite))
+		{
+			content.GET("/profile", profileHandler.Get)
+			content.PUT("/profile", profileHandler.Put)
+			content.POST("/chat/playground", middleware.JSONLogger("chat_playground"), chatHandler.HandlePlayground)
+		}
 
-		analyticsGroup := adminGroup.Group("/analytics")
+		analyticsGroup := adminGroup.Group("/analytics", middleware.RequirePermission(auth.PermissionAnalyticsRead))
 		{
 			analyticsGroup.GET("/summary", analyticsHandler.Summary)
 			analyticsGroup.GET("/events", analyticsHandler.Events)
 			analyticsGroup.GET("/leads", analyticsHandler.Leads)
+			analyticsGroup.GET("/experiments/:name", analyticsHandler.Experiment)
 		}
 
-		skills := adminGroup.Group("/skills")
+		skills := content.Group("/skills")
 		{
 			skills.GET("", skillHandler.List)
 			skills.POST("", skillHandler.Create)
@@ -144,7 +249,7 @@ func New(database *sqlx.DB, cfg config.Config) (*Server, error) {
 			skills.PATCH("/reorder", skillHandler.Reorder)
 		}
 
-		services := adminGroup.Group("/services")
+		services := content.Group("/services")
 		{
 			services.GET("", serviceHandler.List)
 			services.POST("", serviceHandler.Create)
@@ -154,7 +259,7 @@ func New(database *sqlx.DB, cfg config.Config) (*Server, error) {
 			services.PATCH(":id/toggle", serviceHandler.Toggle)
 		}
 
-		projects := adminGroup.Group("/projects")
+		projects := content.Group("/projects")
 		{
 			projects.GET("", projectHandler.List)
 			projects.POST("", projectHandler.Create)
@@ -164,15 +269,68 @@ func New(database *sqlx.DB, cfg config.Config) (*Server, error) {
 			projects.PATCH(":id/feature", projectHandler.Feature)
 		}
 
-		external := adminGroup.Group("/external")
+		content.POST("/publish", revisionHandler.PublishBatch)
+		revisions := content.Group("/content/:entity/:id")
+		{
+			revisions.POST("/publish", revisionHandler.Publish)
+			revisions.DELETE("/schedule", revisionHandler.CancelSchedule)
+			revisions.GET("/revisions", revisionHandler.List)
+			revisions.GET("/revisions/:version", revisionHandler.Get)
+			revisions.GET("/revisions/:version/diff", revisionHandler.Diff)
+			revisions.POST("/revisions/:version/restore", revisionHandler.Restore)
+		}
+
+		content.GET("/trash", trashHandler.List)
+		trash := content.Group("/trash/:entity/:id")
+		{
+			trash.POST("/restore", trashHandler.Restore)
+			trash.DELETE("", trashHandler.Purge)
+		}
+
+		content.GET("/export", portfolioHandler.Export)
+		content.POST("/import", portfolioHandler.Import)
+
+		sources := adminGroup.Group("/external/sources", middleware.RequirePermission(auth.PermissionSourcesSync))
+		{
+			sources.GET("", externalSourceHandler.List)
+			sources.POST("/:id/sync", externalSourceHandler.Sync)
+		}
+
+		items := content.Group("/external/items")
+		{
+			items.GET("", externalItemHandler.List)
+			items.PATCH("/:id/visibility", externalItemHandler.ToggleVisibility)
+		}
+
+		content.POST("/uploads", middleware.RateLimitByIP(uploadLimiter), uploadsHandler.Create)
+		content.POST("/uploads/presign", middleware.RateLimitByIP(uploadLimiter), uploadsHandler.Presign)
+		content.POST("/uploads/complete", middleware.RateLimitByIP(uploadLimiter), uploadsHandler.Complete)
+		content.GET("/media", mediaHandler.List)
+		content.DELETE("/media/:id", mediaHandler.Delete)
+		content.GET("/documents", documentsHandler.List)
+		content.POST("/documents", middleware.RateLimitByIP(uploadLimiter), documentsHandler.Create)
+		content.DELETE("/documents/:id", documentsHandler.Delete)
+
+		users := adminGroup.Group("/users", middleware.RequirePermission(auth.PermissionUsersManage))
+		{
+			users.GET("", usersHandler.List)
+			users.POST("/invite", usersHandler.Invite)
+			users.PUT("/:id/roles", usersHandler.UpdateRoles)
+			users.POST("/:id/deactivate", usersHandler.Deactivate)
+			users.POST("/:id/activate", usersHandler.Activate)
+			users.POST("/:id/logout", usersHandler.Logout)
+			users.POST("/:id/reset-password", usersHandler.ResetPassword)
+			users.POST("/:id/mfa/reset", usersHandler.ResetMFA)
+		}
+
+		keys := adminGroup.Group("/api-keys", middleware.RequirePermission(auth.PermissionUsersManage))
 		{
-			external.GET("/sources", externalSourceHandler.List)
-			external.POST("/sources/:id/sync", externalSourceHandler.Sync)
-			external.GET("/items", externalItemHandler.List)
-			external.PATCH("/items/:id/visibility", externalItemHandler.ToggleVisibility)
+			keys.GET("", apiKeysHandler.List)
+			keys.POST("", apiKeysHandler.Create)
+			keys.DELETE("/:id", apiKeysHandler.Revoke)
 		}
 
-		adminGroup.POST("/uploads", middleware.RateLimitByIP(uploadLimiter), uploadsHandler.Create)
+		adminGroup.GET("/audit", middleware.RequirePermission(auth.PermissionUsersManage), auditHandler.List)
 	}
 
 	httpSrv := &http.Server{
@@ -183,14 +341,59 @@ func New(database *sqlx.DB, cfg config.Config) (*Server, error) {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	jobs := []job{
+		{
+			name:     "refresh_token_cleanup",
+			interval: cfg.TokenCleanupInterval,
+			run: func(ctx context.Context) error {
+				_, err := userRepo.DeleteExpiredRefreshTokens(ctx, time.Now().UTC())
+				return err
+			},
+		},
+		{
+			name:     "scheduled_publish",
+			interval: cfg.ScheduledPublishInterval,
+			run: func(ctx context.Context) error {
+				published, err := revisionRepo.PublishDue(ctx, time.Now().UTC())
+				if published > 0 {
+					aggregator.Invalidate()
+				}
+				return err
+			},
+		},
+	}
+	if trashRetention > 0 {
+		jobs = append(jobs, job{
+			name:     "trash_purge",
+			interval: trashPurgeInterval,
+			run: func(ctx context.Context) error {
+				_, err := trashRepo.PurgeExpired(ctx, time.Now().UTC().Add(-trashRetention))
+				return err
+			},
+		})
+	}
+	if mediaOrphanGrace > 0 {
+		jobs = append(jobs, job{
+			name:     "media_cleanup",
+			interval: mediaCleanupInterval,
+			run: func(ctx context.Context) error {
+				_, err := mediaService.CleanupOrphans(ctx, time.Now().UTC().Add(-mediaOrphanGrace))
+				return err
+			},
+		})
+	}
+
 	return &Server{
 		engine:     engine,
 		httpServer: httpSrv,
+		jobs:       jobs,
 	}, nil
 }
 
 // Run starts the HTTP server and blocks until shutdown is requested via context.
 func (s *Server) Run(ctx context.Context) error {
+	runJobs(ctx, s.jobs)
+
 	errCh := make(chan error, 1)
 	go func() {
 		if err := s.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -218,26 +421,88 @@ func (s *Server) Engine() *gin.Engine {
 	return s.engine
 }
 
-func resolveProvider(cfg config.Config) ai.Provider {
+// resolveProvider builds the configured AI provider. Missing credentials fall back to the
+// mock, but AI_PROVIDER=replay without loadable cassettes is an error: a replay run that
+// silently answers with the mock would pass against the wrong responses.
+func resolveProvider(cfg config.Config) (ai.Provider, error) {
+	provider, err := resolveBaseProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if _, mock := provider.(*ai.Mock); cfg.AIRecord && !mock {
+		log.Printf("[info] recording %s responses to %s", cfg.AIProvider, cfg.AICassetteDir)
+		return ai.NewRecorder(provider, cfg.AICassetteDir), nil
+	}
+	return provider, nil
+}
+
+func resolveBaseProvider(cfg config.Config) (ai.Provider, error) {
 	switch strings.ToLower(cfg.AIProvider) {
 	case "gemini":
 		if strings.TrimSpace(cfg.GoogleGenAIKey) == "" {
 			log.Println("[warn] GOOGLE_GENAI_API_KEY is empty, using mock provider")
-			return ai.NewMock()
+			return ai.NewMock(), nil
 		}
-		return ai.NewGemini(cfg.GoogleGenAIKey, cfg.ChatModel)
+		return ai.NewGemini(cfg.GoogleGenAIKey, cfg.ChatModel), nil
 	case "leapcell":
 		if strings.TrimSpace(cfg.LeapcellAPIKey) == "" {
 			log.Println("[warn] LEAPCELL_API_KEY is empty, using mock provider")
-			return ai.NewMock()
+			return ai.NewMock(), nil
+		}
+		return ai.NewLeapcell(cfg.LeapcellAPIKey, cfg.LeapcellProjectID, cfg.LeapcellTableID), nil
+	case "replay":
+		replay, err := ai.NewReplay(cfg.AICassetteDir)
+		if err != nil {
+			return nil, fmt.Errorf("load AI cassettes from %s: %w", cfg.AICassetteDir, err)
 		}
-		return ai.NewLeapcell(cfg.LeapcellAPIKey, cfg.LeapcellProjectID, cfg.LeapcellTableID)
+		return replay, nil
 	case "mock", "":
-		return ai.NewMock()
+		return ai.NewMock(), nil
 	default:
 		log.Printf("[warn] unsupported AI_PROVIDER=%s, using mock provider", cfg.AIProvider)
-		return ai.NewMock()
+		return ai.NewMock(), nil
+	}
+}
+
+// newExperiment parses CHAT_EXPERIMENT and checks that every variant's provider can be
+// built, so a misconfigured experiment fails at startup rather than per chat.
+func newExperiment(cfg config.Config, providers *tenantProviders) (*experiments.Experiment, error) {
+	experiment, err := experiments.Parse(cfg.ChatExperiment)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CHAT_EXPERIMENT: %w", err)
+	}
+	for _, variant := range experiment.Variants {
+		if variant.Provider == "" && variant.Model == "" {
+			continue
+		}
+		name := variant.Provider
+		if name == "" {
+			name = cfg.AIProvider
+		}
+		if _, err := providers.Resolve(name, variant.Model); err != nil {
+			return nil, fmt.Errorf("invalid CHAT_EXPERIMENT variant %s: %w", variant.Name, err)
+		}
+	}
+	return experiment, nil
+}
+
+func newTokenService(cfg config.Config) (*auth.TokenService, error) {
+	if cfg.JWTKeys.ActiveKeyID == "" {
+		return auth.NewTokenService(cfg.JWTSecret, cfg.AccessTokenTTL, cfg.RefreshTokenTTL)
+	}
+	keys := make([]auth.SigningKey, 0, len(cfg.JWTKeys.Keys))
+	for _, source := range cfg.JWTKeys.Keys {
+		key, err := auth.ParseKeyPEM(source.ID, source.PEM)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	keySet, err := auth.NewKeySet(cfg.JWTKeys.ActiveKeyID, keys...)
+	if err != nil {
+		return nil, err
 	}
+	return auth.NewTokenServiceWithKeys(keySet, cfg.JWTSecret, cfg.AccessTokenTTL, cfg.RefreshTokenTTL)
 }
 
 func resolvePort() string {
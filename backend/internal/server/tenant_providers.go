@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/tanydotai/tanyai/backend/internal/ai"
+	"github.com/tanydotai/tanyai/backend/internal/config"
+	"github.com/tanydotai/tanyai/backend/internal/handlers"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
+)
+
+// tenantProviders builds and memoises AI providers for tenants overriding the
+// deployment provider or chat model.
+type tenantProviders struct {
+	cfg config.Config
+
+	mu        sync.Mutex
+	providers map[string]handlers.ProviderSelection
+}
+
+func newTenantProviders(cfg config.Config) *tenantProviders {
+	return &tenantProviders{cfg: cfg, providers: make(map[string]handlers.ProviderSelection)}
+}
+
+// Select implements handlers.ProviderSelector.
+func (p *tenantProviders) Select(ctx context.Context) (handlers.ProviderSelection, bool) {
+	t, ok := tenant.FromContext(ctx)
+	if !ok {
+		return handlers.ProviderSelection{}, false
+	}
+	name := strings.TrimSpace(t.AIProvider.String)
+	model := strings.TrimSpace(t.ChatModel.String)
+	if name == "" && model == "" {
+		return handlers.ProviderSelection{}, false
+	}
+
+	cfg := p.cfg
+	if name != "" {
+		cfg.AIProvider = name
+	}
+	if model != "" {
+		cfg.ChatModel = model
+	}
+
+	selection, err := p.build(cfg)
+	if err != nil {
+		log.Printf("[warn] tenant %s provider unavailable (%v), using the default", t.Slug, err)
+		return handlers.ProviderSelection{}, false
+	}
+	return selection, true
+}
+
+// Resolve implements handlers.ProviderResolver for the chat playground. Unlike Select it
+// refuses unknown providers and providers whose credentials are missing instead of
+// silently answering with the mock.
+func (p *tenantProviders) Resolve(name, model string) (handlers.ProviderSelection, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	switch name {
+	case "gemini", "leapcell", "replay", "mock":
+	case "":
+		name = "mock"
+	default:
+		return handlers.ProviderSelection{}, fmt.Errorf("unsupported provider %q", name)
+	}
+
+	cfg := p.cfg
+	cfg.AIProvider = name
+	if model = strings.TrimSpace(model); model != "" {
+		cfg.ChatModel = model
+	}
+	selection, err := p.build(cfg)
+	if err != nil {
+		return handlers.ProviderSelection{}, err
+	}
+	if _, mock := selection.Provider.(*ai.Mock); mock && name != "mock" {
+		return handlers.ProviderSelection{}, fmt.Errorf("provider %q is not configured", name)
+	}
+	return selection, nil
+}
+
+// build returns the memoised provider for cfg's provider and model. Failures are not
+// memoised.
+func (p *tenantProviders) build(cfg config.Config) (handlers.ProviderSelection, error) {
+	key := strings.ToLower(cfg.AIProvider) + "|" + cfg.ChatModel
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if selection, ok := p.providers[key]; ok {
+		return selection, nil
+	}
+	provider, err := resolveProvider(cfg)
+	if err != nil {
+		return handlers.ProviderSelection{}, err
+	}
+	selection := handlers.ProviderSelection{Provider: provider, Name: cfg.AIProvider, Model: cfg.ChatModel}
+	p.providers[key] = selection
+	return selection, nil
+}
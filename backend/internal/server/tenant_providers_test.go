@@ -0,0 +1,95 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tanydotai/tanyai/backend/internal/ai"
+	"github.com/tanydotai/tanyai/backend/internal/config"
+)
+
+func TestTenantProvidersResolveRejectsUnavailableProviders(t *testing.T) {
+	providers := newTenantProviders(config.Config{AIProvider: "mock", ChatModel: "default"})
+
+	selection, err := providers.Resolve("", "")
+	if err != nil {
+		t.Fatalf("resolve mock: %v", err)
+	}
+	if _, ok := selection.Provider.(*ai.Mock); !ok || selection.Name != "mock" || selection.Model != "default" {
+		t.Fatalf("expected default mock selection, got %+v", selection)
+	}
+
+	if _, err := providers.Resolve("gemini", "gemini-2.5-flash"); err == nil {
+		t.Fatalf("expected an error for gemini without an API key")
+	}
+	if _, err := providers.Resolve("openai", ""); err == nil {
+		t.Fatalf("expected an error for an unsupported provider")
+	}
+
+	providers = newTenantProviders(config.Config{AIProvider: "mock", GoogleGenAIKey: "secret"})
+	selection, err = providers.Resolve("Gemini", "gemini-2.5-flash")
+	if err != nil {
+		t.Fatalf("resolve gemini: %v", err)
+	}
+	gemini, ok := selection.Provider.(*ai.Gemini)
+	if !ok || gemini.Model != "gemini-2.5-flash" {
+		t.Fatalf("expected gemini provider with chosen model, got %+v", selection)
+	}
+}
+
+func TestResolveProviderReplayAndRecord(t *testing.T) {
+	dir := t.TempDir()
+	if provider, err := resolveProvider(config.Config{AIProvider: "replay", AICassetteDir: dir}); err != nil {
+		t.Fatalf("expected AI_PROVIDER=replay to load cassettes: %v", err)
+	} else if _, ok := provider.(*ai.Replay); !ok {
+		t.Fatalf("expected AI_PROVIDER=replay to load cassettes, got %T", provider)
+	}
+	missing := config.Config{AIProvider: "replay", AICassetteDir: dir + "/missing"}
+	if provider, err := resolveProvider(missing); err == nil {
+		t.Fatalf("expected a missing cassette directory to fail instead of answering with %T", provider)
+	}
+	if _, err := New(nil, missing); err == nil || !strings.Contains(err.Error(), "cassettes") {
+		t.Fatalf("expected startup to fail without cassettes, got %v", err)
+	}
+
+	recorded, err := resolveProvider(config.Config{AIProvider: "gemini", GoogleGenAIKey: "secret", AIRecord: true, AICassetteDir: dir})
+	if err != nil {
+		t.Fatalf("resolve recorded gemini: %v", err)
+	}
+	recorder, ok := recorded.(*ai.Recorder)
+	if !ok {
+		t.Fatalf("expected AI_RECORD to wrap the live provider, got %T", recorded)
+	}
+	if _, ok := recorder.Provider.(*ai.Gemini); !ok || recorder.Dir != dir {
+		t.Fatalf("unexpected recorder: %+v", recorder)
+	}
+	if provider, _ := resolveProvider(config.Config{AIProvider: "mock", AIRecord: true}); provider == nil {
+		t.Fatalf("expected the mock provider")
+	} else if _, ok := provider.(*ai.Mock); !ok {
+		t.Fatalf("the mock should never be recorded")
+	}
+}
+
+func TestNewExperimentRejectsUnavailableVariants(t *testing.T) {
+	providers := newTenantProviders(config.Config{AIProvider: "mock", ChatModel: "default"})
+	cfg := config.Config{AIProvider: "mock", ChatExperiment: `{"name": "x", "variants": [
+		{"name": "control", "weight": 1},
+		{"name": "short", "template": "Singkat saja.", "weight": 1}
+	]}`}
+	experiment, err := newExperiment(cfg, providers)
+	if err != nil || experiment.Name != "x" {
+		t.Fatalf("expected a valid experiment, got %+v (%v)", experiment, err)
+	}
+
+	cfg.ChatExperiment = `{"name": "x", "variants": [
+		{"name": "control", "weight": 1},
+		{"name": "flash", "provider": "gemini", "model": "gemini-2.5-flash", "weight": 1}
+	]}`
+	if _, err := newExperiment(cfg, providers); err == nil {
+		t.Fatalf("expected a variant without credentials to be rejected")
+	}
+	cfg.ChatExperiment = `{"name": "x"}`
+	if _, err := newExperiment(cfg, providers); err == nil {
+		t.Fatalf("expected an invalid definition to be rejected")
+	}
+}
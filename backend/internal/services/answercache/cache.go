@@ -0,0 +1,285 @@
+package answercache
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
+)
+
+const (
+	defaultTTL        = 6 * time.Hour
+	defaultMaxEntries = 1000
+)
+
+// Embedder produces vector representations used for near-duplicate matching.
+type Embedder interface {
+	Embed(ctx context.Context, text string) ([]float32, error)
+}
+
+// Options configures the answer cache.
+type Options struct {
+	TTL        time.Duration
+	MaxEntries int
+	// Embedder enables near-duplicate lookups when non-nil.
+	Embedder Embedder
+	// Similarity is the minimum cosine similarity for a near-duplicate hit.
+	Similarity float64
+}
+
+// Entry is a cached answer for a normalized question.
+type Entry struct {
+	Question  string
+	Answer    string
+	Model     string
+	ETag      string
+	CreatedAt time.Time
+	Hits      int
+}
+
+// Match describes a successful cache lookup.
+type Match struct {
+	Entry
+	// Exact reports whether the normalized question matched verbatim.
+	Exact bool
+	// Score is the cosine similarity for near-duplicate matches (1 for exact hits).
+	Score float64
+}
+
+type item struct {
+	entry     Entry
+	key       string
+	vector    []float32
+	expiresAt time.Time
+}
+
+// scope holds the answers of a single tenant, all grounded on the same knowledge base ETag.
+type scope struct {
+	etag  string
+	items map[string]*item
+}
+
+// Cache stores provider answers keyed by tenant, normalized question and knowledge base ETag.
+type Cache struct {
+	ttl        time.Duration
+	maxEntries int
+	embedder   Embedder
+	similarity float64
+	clock      func() time.Time
+
+	mu     sync.Mutex
+	scopes map[uuid.UUID]*scope
+}
+
+// New constructs an answer cache.
+func New(opts Options) *Cache {
+	ttl := opts.TTL
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+	maxEntries := opts.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = defaultMaxEntries
+	}
+	similarity := opts.Similarity
+	if similarity <= 0 || similarity > 1 {
+		similarity = 0.95
+	}
+	return &Cache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		embedder:   opts.Embedder,
+		similarity: similarity,
+		clock:      time.Now,
+		scopes:     make(map[uuid.UUID]*scope),
+	}
+}
+
+// SetClock overrides the default clock. Intended for testing.
+func (c *Cache) SetClock(clock func() time.Time) {
+	if clock == nil {
+		clock = time.Now
+	}
+	c.mu.Lock()
+	c.clock = clock
+	c.mu.Unlock()
+}
+
+// Lookup returns a cached answer for the question under the given knowledge base ETag.
+// Answers are only shared within the tenant carried by ctx.
+func (c *Cache) Lookup(ctx context.Context, question, etag string) (Match, bool) {
+	key := Normalize(question)
+	if key == "" || etag == "" {
+		return Match{}, false
+	}
+	tenantID := tenant.ID(ctx)
+
+	c.mu.Lock()
+	sc := c.scopeLocked(tenantID, etag)
+	now := c.clock()
+	if it, ok := sc.items[key]; ok {
+		if now.Before(it.expiresAt) {
+			it.entry.Hits++
+			entry := it.entry
+			c.mu.Unlock()
+			return Match{Entry: entry, Exact: true, Score: 1}, true
+		}
+		delete(sc.items, key)
+	}
+	hasVectors := c.embedder != nil && len(sc.items) > 0
+	c.mu.Unlock()
+
+	if !hasVectors {
+		return Match{}, false
+	}
+
+	vector, err := c.embedder.Embed(ctx, key)
+	if err != nil || len(vector) == 0 {
+		return Match{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sc, ok := c.scopes[tenantID]
+	if !ok || sc.etag != etag {
+		return Match{}, false
+	}
+	var best *item
+	bestScore := 0.0
+	for _, it := range sc.items {
+		if len(it.vector) == 0 || !now.Before(it.expiresAt) {
+			continue
+		}
+		score := cosine(vector, it.vector)
+		if score >= c.similarity && score > bestScore {
+			best = it
+			bestScore = score
+		}
+	}
+	if best == nil {
+		return Match{}, false
+	}
+	best.entry.Hits++
+	return Match{Entry: best.entry, Score: bestScore}, true
+}
+
+// Store records an answer for the question under the given knowledge base ETag.
+func (c *Cache) Store(ctx context.Context, question, etag, answer, model string) {
+	key := Normalize(question)
+	answer = strings.TrimSpace(answer)
+	if key == "" || etag == "" || answer == "" {
+		return
+	}
+
+	var vector []float32
+	if c.embedder != nil {
+		if v, err := c.embedder.Embed(ctx, key); err == nil {
+			vector = v
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sc := c.scopeLocked(tenant.ID(ctx), etag)
+	now := c.clock()
+	if _, exists := sc.items[key]; !exists && len(sc.items) >= c.maxEntries {
+		c.evictLocked(sc, now)
+	}
+	sc.items[key] = &item{
+		key:    key,
+		vector: vector,
+		entry: Entry{
+			Question:  question,
+			Answer:    answer,
+			Model:     model,
+			ETag:      etag,
+			CreatedAt: now,
+		},
+		expiresAt: now.Add(c.ttl),
+	}
+}
+
+// Purge drops every cached answer.
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	c.scopes = make(map[uuid.UUID]*scope)
+	c.mu.Unlock()
+}
+
+// Len reports the number of cached answers across all tenants.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	total := 0
+	for _, sc := range c.scopes {
+		total += len(sc.items)
+	}
+	return total
+}
+
+// scopeLocked returns the tenant's scope, discarding answers grounded on an outdated knowledge base.
+func (c *Cache) scopeLocked(tenantID uuid.UUID, etag string) *scope {
+	sc, ok := c.scopes[tenantID]
+	if !ok || sc.etag != etag {
+		sc = &scope{etag: etag, items: make(map[string]*item)}
+		c.scopes[tenantID] = sc
+	}
+	return sc
+}
+
+func (c *Cache) evictLocked(sc *scope, now time.Time) {
+	var oldest *item
+	for key, it := range sc.items {
+		if !now.Before(it.expiresAt) {
+			delete(sc.items, key)
+			continue
+		}
+		if oldest == nil || it.entry.CreatedAt.Before(oldest.entry.CreatedAt) {
+			oldest = it
+		}
+	}
+	if len(sc.items) >= c.maxEntries && oldest != nil {
+		delete(sc.items, oldest.key)
+	}
+}
+
+// Normalize lowercases the question, strips punctuation and collapses whitespace
+// so trivially different phrasings share a cache key.
+func Normalize(question string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(strings.TrimSpace(question)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			b.WriteRune(r)
+		default:
+			space = true
+		}
+	}
+	return b.String()
+}
+
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,122 @@
+package answercache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
+)
+
+type fakeEmbedder struct {
+	vectors map[string][]float32
+}
+
+func (f *fakeEmbedder) Embed(_ context.Context, text string) ([]float32, error) {
+	return f.vectors[text], nil
+}
+
+func TestLookupMatchesNormalizedQuestion(t *testing.T) {
+	cache := New(Options{})
+	cache.Store(context.Background(), "Berapa harga layanan Build?", "v1", "Mulai 10 juta.", "mock")
+
+	match, ok := cache.Lookup(context.Background(), "  berapa HARGA layanan build ", "v1")
+	if !ok {
+		t.Fatalf("expected cache hit")
+	}
+	if !match.Exact || match.Answer != "Mulai 10 juta." {
+		t.Fatalf("unexpected match: %+v", match)
+	}
+	if match.Hits != 1 {
+		t.Fatalf("expected hit counter to increase, got %d", match.Hits)
+	}
+}
+
+func TestLookupMissesAfterETagChange(t *testing.T) {
+	cache := New(Options{})
+	cache.Store(context.Background(), "Halo", "v1", "Hai!", "mock")
+
+	if _, ok := cache.Lookup(context.Background(), "Halo", "v2"); ok {
+		t.Fatalf("expected miss for a newer knowledge base")
+	}
+	if cache.Len() != 0 {
+		t.Fatalf("expected stale answers to be discarded, got %d", cache.Len())
+	}
+}
+
+func TestLookupHonoursTTL(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := New(Options{TTL: time.Minute})
+	cache.SetClock(func() time.Time { return now })
+	cache.Store(context.Background(), "Halo", "v1", "Hai!", "mock")
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := cache.Lookup(context.Background(), "Halo", "v1"); ok {
+		t.Fatalf("expected expired answer to miss")
+	}
+}
+
+func TestLookupFindsNearDuplicates(t *testing.T) {
+	embedder := &fakeEmbedder{vectors: map[string][]float32{
+		"berapa harga build":         {1, 0, 0.1},
+		"harga layanan build berapa": {1, 0, 0.12},
+		"siapa kamu":                 {0, 1, 0},
+	}}
+	cache := New(Options{Embedder: embedder, Similarity: 0.9})
+	cache.Store(context.Background(), "Berapa harga Build?", "v1", "Mulai 10 juta.", "mock")
+
+	match, ok := cache.Lookup(context.Background(), "Harga layanan Build berapa?", "v1")
+	if !ok {
+		t.Fatalf("expected near-duplicate hit")
+	}
+	if match.Exact || match.Score < 0.9 {
+		t.Fatalf("unexpected match: %+v", match)
+	}
+	if _, ok := cache.Lookup(context.Background(), "Siapa kamu?", "v1"); ok {
+		t.Fatalf("expected unrelated question to miss")
+	}
+}
+
+func TestStoreEvictsOldestEntry(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := New(Options{MaxEntries: 2})
+	cache.SetClock(func() time.Time { return now })
+
+	for _, q := range []string{"satu", "dua", "tiga"} {
+		cache.Store(context.Background(), q, "v1", "jawaban "+q, "mock")
+		now = now.Add(time.Second)
+	}
+
+	if cache.Len() != 2 {
+		t.Fatalf("expected 2 entries, got %d", cache.Len())
+	}
+	if _, ok := cache.Lookup(context.Background(), "satu", "v1"); ok {
+		t.Fatalf("expected oldest entry to be evicted")
+	}
+}
+
+func TestPurgeDropsEverything(t *testing.T) {
+	cache := New(Options{})
+	cache.Store(context.Background(), "Halo", "v1", "Hai!", "mock")
+	cache.Purge()
+	if _, ok := cache.Lookup(context.Background(), "Halo", "v1"); ok {
+		t.Fatalf("expected purge to drop cached answers")
+	}
+}
+
+func TestLookupIsScopedPerTenant(t *testing.T) {
+	cache := New(Options{})
+	other := tenant.WithID(context.Background(), uuid.New())
+	cache.Store(context.Background(), "Halo", "v1", "Hai!", "mock")
+	cache.Store(other, "Halo", "v2", "Halo juga!", "mock")
+
+	match, ok := cache.Lookup(context.Background(), "Halo", "v1")
+	if !ok || match.Answer != "Hai!" {
+		t.Fatalf("expected default tenant answer to survive, got %+v", match)
+	}
+	match, ok = cache.Lookup(other, "Halo", "v2")
+	if !ok || match.Answer != "Halo juga!" {
+		t.Fatalf("expected tenant specific answer, got %+v", match)
+	}
+}
@@ -0,0 +1,87 @@
+package apikeys
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/tanydotai/tanyai/backend/internal/auth"
+	"github.com/tanydotai/tanyai/backend/internal/repos"
+)
+
+const (
+	// DefaultRateLimitPerMin applies to keys created without an explicit limit.
+	DefaultRateLimitPerMin = 60
+	// lastUsedResolution throttles last_used_at writes to one per key per minute.
+	lastUsedResolution = time.Minute
+	limiterTTL         = 10 * time.Minute
+)
+
+// Service authenticates API keys, enforcing expiry, revocation and per-key rate limits.
+type Service struct {
+	repo    repos.APIKeyRepository
+	limiter *auth.RateLimiter
+	now     func() time.Time
+}
+
+// NewService constructs a Service.
+func NewService(repo repos.APIKeyRepository) *Service {
+	return &Service{
+		repo:    repo,
+		limiter: auth.NewRateLimiter(DefaultRateLimitPerMin, DefaultRateLimitPerMin, limiterTTL),
+		now:     time.Now,
+	}
+}
+
+// AuthenticateAPIKey resolves a raw key to claims scoped to the key's tenant. It returns
+// auth.ErrInvalidToken for unknown or revoked keys, auth.ErrTokenExpired for expired keys
+// and auth.ErrRateLimited when the key exceeded its limit.
+func (s *Service) AuthenticateAPIKey(ctx context.Context, raw string) (*auth.Claims, error) {
+	raw = strings.TrimSpace(raw)
+	if !strings.HasPrefix(raw, auth.APIKeyPrefix) {
+		return nil, auth.ErrInvalidToken
+	}
+
+	key, err := s.repo.FindByHash(ctx, auth.HashAPIKey(raw))
+	if err != nil {
+		if errors.Is(err, repos.ErrNotFound) {
+			return nil, auth.ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	now := s.now().UTC()
+	if key.RevokedAt != nil {
+		return nil, auth.ErrInvalidToken
+	}
+	if key.ExpiresAt != nil && !now.Before(*key.ExpiresAt) {
+		return nil, auth.ErrTokenExpired
+	}
+	if !s.limiter.AllowLimit(key.ID.String(), key.RateLimitPerMin, key.RateLimitPerMin) {
+		return nil, auth.ErrRateLimited
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedResolution {
+		if err := s.repo.TouchLastUsed(ctx, key.ID, now); err != nil {
+			slog.Warn("api_key_touch_failed", "error", err, "api_key", key.Prefix)
+		}
+	}
+
+	scopes := make([]auth.Permission, len(key.Scopes))
+	for i, scope := range key.Scopes {
+		scopes[i] = auth.Permission(scope)
+	}
+	claims := &auth.Claims{
+		TenantID:     key.TenantID,
+		IssuedAt:     key.CreatedAt,
+		APIKeyID:     key.ID,
+		APIKeyPrefix: key.Prefix,
+		Scopes:       scopes,
+	}
+	if key.ExpiresAt != nil {
+		claims.ExpiresAt = *key.ExpiresAt
+	}
+	return claims, nil
+}
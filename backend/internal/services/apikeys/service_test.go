@@ -0,0 +1,108 @@
+package apikeys
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/tanydotai/tanyai/backend/internal/auth"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/repos"
+)
+
+type stubRepo struct {
+	repos.APIKeyRepository
+	keys    map[string]models.APIKey
+	touched int
+}
+
+func (s *stubRepo) FindByHash(_ context.Context, hash string) (models.APIKey, error) {
+	key, ok := s.keys[hash]
+	if !ok {
+		return models.APIKey{}, repos.ErrNotFound
+	}
+	return key, nil
+}
+
+func (s *stubRepo) TouchLastUsed(_ context.Context, id uuid.UUID, at time.Time) error {
+	s.touched++
+	for hash, key := range s.keys {
+		if key.ID == id {
+			key.LastUsedAt = &at
+			s.keys[hash] = key
+		}
+	}
+	return nil
+}
+
+func newKey(t *testing.T, repo *stubRepo, mutate func(*models.APIKey)) string {
+	t.Helper()
+	raw, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	key := models.APIKey{ID: uuid.New(), TenantID: uuid.New(), Prefix: prefix, KeyHash: hash, Scopes: pq.StringArray{"chat"}, RateLimitPerMin: 2}
+	if mutate != nil {
+		mutate(&key)
+	}
+	repo.keys[hash] = key
+	return raw
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	repo := &stubRepo{keys: map[string]models.APIKey{}}
+	svc := NewService(repo)
+	now := time.Date(2025, 2, 6, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	raw := newKey(t, repo, nil)
+	claims, err := svc.AuthenticateAPIKey(context.Background(), raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !claims.IsAPIKey() || !claims.HasPermission(auth.ScopeChat) || claims.HasPermission(auth.PermissionAnalyticsRead) {
+		t.Fatalf("unexpected claims %+v", claims)
+	}
+	if claims.TenantID == uuid.Nil || claims.APIKeyPrefix == "" {
+		t.Fatalf("expected tenant and prefix on claims, got %+v", claims)
+	}
+
+	// Second request within the same minute does not write last_used_at again.
+	if _, err := svc.AuthenticateAPIKey(context.Background(), raw); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.touched != 1 {
+		t.Fatalf("expected one last-used update, got %d", repo.touched)
+	}
+	if _, err := svc.AuthenticateAPIKey(context.Background(), raw); !errors.Is(err, auth.ErrRateLimited) {
+		t.Fatalf("expected rate limit, got %v", err)
+	}
+}
+
+func TestAuthenticateAPIKeyRejectsUnusableKeys(t *testing.T) {
+	repo := &stubRepo{keys: map[string]models.APIKey{}}
+	svc := NewService(repo)
+	now := time.Now().UTC()
+	past := now.Add(-time.Hour)
+
+	revoked := newKey(t, repo, func(k *models.APIKey) { k.RevokedAt = &past })
+	expired := newKey(t, repo, func(k *models.APIKey) { k.ExpiresAt = &past })
+
+	cases := map[string]struct {
+		raw  string
+		want error
+	}{
+		"unknown":    {raw: auth.APIKeyPrefix + "abcdefgh_unknown", want: auth.ErrInvalidToken},
+		"bad prefix": {raw: "sk_live_123", want: auth.ErrInvalidToken},
+		"revoked":    {raw: revoked, want: auth.ErrInvalidToken},
+		"expired":    {raw: expired, want: auth.ErrTokenExpired},
+	}
+	for name, tc := range cases {
+		if _, err := svc.AuthenticateAPIKey(context.Background(), tc.raw); !errors.Is(err, tc.want) {
+			t.Fatalf("%s: expected %v, got %v", name, tc.want, err)
+		}
+	}
+}
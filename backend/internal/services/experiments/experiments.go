@@ -0,0 +1,109 @@
+// Package experiments splits chat traffic between variants of the provider, model and
+// prompt settings so they can be compared on real conversations.
+package experiments
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxTemperature    = 2
+	maxMaxTokens      = 8192
+	maxTemplateRunes  = 2000
+	minVariantsPerRun = 2
+)
+
+// Variant is one arm of an experiment. Empty settings keep the deployment defaults.
+type Variant struct {
+	Name     string `json:"name"`
+	Provider string `json:"provider,omitempty"`
+	Model    string `json:"model,omitempty"`
+	// Template replaces the default answering instructions of the prompt.
+	Template    string   `json:"template,omitempty"`
+	Temperature *float32 `json:"temperature,omitempty"`
+	MaxTokens   int      `json:"maxTokens,omitempty"`
+	// Weight is the variant's relative share of traffic.
+	Weight int `json:"weight"`
+}
+
+// Experiment is a named set of weighted variants.
+type Experiment struct {
+	Name     string    `json:"name"`
+	Variants []Variant `json:"variants"`
+
+	totalWeight int
+}
+
+// Parse decodes and validates an experiment definition in JSON.
+func Parse(raw string) (*Experiment, error) {
+	var experiment Experiment
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&experiment); err != nil {
+		return nil, fmt.Errorf("parse experiment: %w", err)
+	}
+	if err := experiment.validate(); err != nil {
+		return nil, err
+	}
+	return &experiment, nil
+}
+
+func (e *Experiment) validate() error {
+	e.Name = strings.TrimSpace(e.Name)
+	if e.Name == "" {
+		return errors.New("experiment name is required")
+	}
+	if len(e.Variants) < minVariantsPerRun {
+		return fmt.Errorf("experiment %s needs at least %d variants", e.Name, minVariantsPerRun)
+	}
+	seen := make(map[string]bool, len(e.Variants))
+	e.totalWeight = 0
+	for i := range e.Variants {
+		v := &e.Variants[i]
+		v.Name = strings.TrimSpace(v.Name)
+		v.Provider = strings.ToLower(strings.TrimSpace(v.Provider))
+		v.Model = strings.TrimSpace(v.Model)
+		switch {
+		case v.Name == "":
+			return fmt.Errorf("variant %d: name is required", i+1)
+		case seen[v.Name]:
+			return fmt.Errorf("variant %s: duplicate name", v.Name)
+		case v.Weight < 0:
+			return fmt.Errorf("variant %s: weight must not be negative", v.Name)
+		case v.Temperature != nil && (*v.Temperature < 0 || *v.Temperature > maxTemperature):
+			return fmt.Errorf("variant %s: temperature must be between 0 and %d", v.Name, maxTemperature)
+		case v.MaxTokens < 0 || v.MaxTokens > maxMaxTokens:
+			return fmt.Errorf("variant %s: maxTokens must be between 0 and %d", v.Name, maxMaxTokens)
+		case len([]rune(v.Template)) > maxTemplateRunes:
+			return fmt.Errorf("variant %s: template must be at most %d characters", v.Name, maxTemplateRunes)
+		}
+		seen[v.Name] = true
+		e.totalWeight += v.Weight
+	}
+	if e.totalWeight == 0 {
+		return fmt.Errorf("experiment %s needs at least one variant with a positive weight", e.Name)
+	}
+	return nil
+}
+
+// Assign returns the variant for chatID. Assignment is a hash of the experiment name and
+// chat ID, so every message of a chat gets the same variant without storing anything.
+// Changing the weights or the variant order reshuffles chats.
+func (e *Experiment) Assign(chatID uuid.UUID) Variant {
+	sum := sha256.Sum256([]byte(e.Name + ":" + chatID.String()))
+	bucket := int(binary.BigEndian.Uint64(sum[:8]) % uint64(e.totalWeight))
+	for _, variant := range e.Variants {
+		if bucket < variant.Weight {
+			return variant
+		}
+		bucket -= variant.Weight
+	}
+	return e.Variants[len(e.Variants)-1]
+}
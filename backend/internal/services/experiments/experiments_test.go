@@ -0,0 +1,68 @@
+package experiments
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestParseValidatesVariants(t *testing.T) {
+	invalid := []string{
+		`{"variants": [{"name": "a", "weight": 1}, {"name": "b", "weight": 1}]}`,
+		`{"name": "x", "variants": [{"name": "a", "weight": 1}]}`,
+		`{"name": "x", "variants": [{"name": "a", "weight": 1}, {"name": "a", "weight": 1}]}`,
+		`{"name": "x", "variants": [{"name": "a", "weight": 0}, {"name": "b", "weight": 0}]}`,
+		`{"name": "x", "variants": [{"name": "a", "weight": -1}, {"name": "b", "weight": 2}]}`,
+		`{"name": "x", "variants": [{"name": "a", "weight": 1, "temperature": 3}, {"name": "b", "weight": 1}]}`,
+		`{"name": "x", "variants": [{"name": "a", "weight": 1, "maxTokens": 9000}, {"name": "b", "weight": 1}]}`,
+		`{"name": "x", "variants": [{"name": "a", "weight": 1, "temprature": 1}, {"name": "b", "weight": 1}]}`,
+	}
+	for _, raw := range invalid {
+		if _, err := Parse(raw); err == nil {
+			t.Fatalf("expected %s to be rejected", raw)
+		}
+	}
+
+	experiment, err := Parse(`{"name": " pro-vs-flash ", "variants": [
+		{"name": "control", "weight": 1},
+		{"name": "flash", "provider": "Gemini", "model": "gemini-2.5-flash", "temperature": 0.2, "weight": 1}
+	]}`)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if experiment.Name != "pro-vs-flash" || experiment.Variants[1].Provider != "gemini" {
+		t.Fatalf("expected trimmed name and lowercase provider, got %+v", experiment)
+	}
+	if *experiment.Variants[1].Temperature != 0.2 {
+		t.Fatalf("expected temperature to be kept")
+	}
+}
+
+func TestAssignIsStickyAndFollowsWeights(t *testing.T) {
+	experiment, err := Parse(`{"name": "split", "variants": [
+		{"name": "a", "weight": 3},
+		{"name": "b", "weight": 1},
+		{"name": "off", "weight": 0}
+	]}`)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	counts := map[string]int{}
+	const chats = 4000
+	for i := 0; i < chats; i++ {
+		chatID := uuid.New()
+		variant := experiment.Assign(chatID)
+		if again := experiment.Assign(chatID); again.Name != variant.Name {
+			t.Fatalf("assignment must be sticky, got %s then %s", variant.Name, again.Name)
+		}
+		counts[variant.Name]++
+	}
+	if counts["off"] != 0 {
+		t.Fatalf("zero weight variants must not receive traffic")
+	}
+	if share := float64(counts["a"]) / chats; math.Abs(share-0.75) > 0.04 {
+		t.Fatalf("expected about 75%% of chats on a, got %.2f", share)
+	}
+}
@@ -17,6 +17,8 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
 	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/storage"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
 )
 
 type cacheEntry struct {
@@ -26,12 +28,18 @@ type cacheEntry struct {
 }
 
 // Aggregator loads and caches knowledge base data from the database.
+// Each tenant gets its own cache entry; the tenant is taken from the request context.
 type Aggregator struct {
 	db  *sqlx.DB
 	ttl time.Duration
 
 	mu    sync.RWMutex
-	cache *cacheEntry
+	cache map[uuid.UUID]*cacheEntry
+
+	hooksMu sync.Mutex
+	hooks   []func()
+
+	urls storage.URLSigner
 }
 
 // NewAggregator constructs a new Aggregator with the provided cache TTL.
@@ -39,16 +47,23 @@ func NewAggregator(db *sqlx.DB, ttl time.Duration) *Aggregator {
 	if ttl <= 0 {
 		ttl = time.Minute
 	}
-	return &Aggregator{db: db, ttl: ttl}
+	return &Aggregator{db: db, ttl: ttl, cache: make(map[uuid.UUID]*cacheEntry), urls: storage.SignerFor(nil)}
+}
+
+// SetURLSigner signs the avatar and document URLs of loaded knowledge bases. Signed links
+// must outlive the cache TTL, which holds for any TTL below half the link lifetime.
+func (a *Aggregator) SetURLSigner(signer storage.URLSigner) {
+	a.urls = signer
 }
 
 // Get retrieves the knowledge base, optionally serving it from cache.
 // It returns the data, the computed ETag and a boolean indicating a cache hit.
 func (a *Aggregator) Get(ctx context.Context) (KnowledgeBase, string, bool, error) {
 	now := time.Now()
+	tenantID := tenant.ID(ctx)
 
 	a.mu.RLock()
-	entry := a.cache
+	entry := a.cache[tenantID]
 	if entry != nil && now.Before(entry.expires) {
 		data := entry.data
 		etag := entry.etag
@@ -57,7 +72,7 @@ func (a *Aggregator) Get(ctx context.Context) (KnowledgeBase, string, bool, erro
 	}
 	a.mu.RUnlock()
 
-	data, err := a.load(ctx)
+	data, err := a.load(ctx, tenantID)
 	if err != nil {
 		return KnowledgeBase{}, "", false, err
 	}
@@ -67,17 +82,36 @@ func (a *Aggregator) Get(ctx context.Context) (KnowledgeBase, string, bool, erro
 	}
 
 	a.mu.Lock()
-	a.cache = &cacheEntry{data: data, etag: etag, expires: now.Add(a.ttl)}
+	a.cache[tenantID] = &cacheEntry{data: data, etag: etag, expires: now.Add(a.ttl)}
 	a.mu.Unlock()
 
 	return data, etag, false, nil
 }
 
-// Invalidate clears the in-memory cache so subsequent Get calls refetch data.
+// Invalidate clears the in-memory cache of every tenant so subsequent Get calls refetch data.
+// Registered OnInvalidate hooks run after the cache is cleared.
 func (a *Aggregator) Invalidate() {
 	a.mu.Lock()
-	a.cache = nil
+	a.cache = make(map[uuid.UUID]*cacheEntry)
 	a.mu.Unlock()
+
+	a.hooksMu.Lock()
+	hooks := append([]func(){}, a.hooks...)
+	a.hooksMu.Unlock()
+	for _, hook := range hooks {
+		hook()
+	}
+}
+
+// OnInvalidate registers a callback that runs whenever the knowledge base is invalidated,
+// allowing derived caches (e.g. cached answers) to be discarded together with it.
+func (a *Aggregator) OnInvalidate(hook func()) {
+	if hook == nil {
+		return
+	}
+	a.hooksMu.Lock()
+	a.hooks = append(a.hooks, hook)
+	a.hooksMu.Unlock()
 }
 
 // CacheTTL returns the configured cache duration.
@@ -85,28 +119,28 @@ func (a *Aggregator) CacheTTL() time.Duration {
 	return a.ttl
 }
 
-func (a *Aggregator) load(ctx context.Context) (KnowledgeBase, error) {
-	profile, err := a.fetchProfile(ctx)
+func (a *Aggregator) load(ctx context.Context, tenantID uuid.UUID) (KnowledgeBase, error) {
+	profile, err := a.fetchProfile(ctx, tenantID)
 	if err != nil {
 		return KnowledgeBase{}, err
 	}
 
-	skills, err := a.fetchSkills(ctx)
+	skills, err := a.fetchSkills(ctx, tenantID)
 	if err != nil {
 		return KnowledgeBase{}, err
 	}
 
-	services, err := a.fetchServices(ctx)
+	services, err := a.fetchServices(ctx, tenantID)
 	if err != nil {
 		return KnowledgeBase{}, err
 	}
 
-	projects, err := a.fetchProjects(ctx)
+	projects, err := a.fetchProjects(ctx, tenantID)
 	if err != nil {
 		return KnowledgeBase{}, err
 	}
 
-	extServices, extProjects, posts, err := a.fetchExternalItems(ctx)
+	extServices, extProjects, posts, documents, err := a.fetchExternalItems(ctx, tenantID)
 	if err != nil {
 		return KnowledgeBase{}, err
 	}
@@ -114,11 +148,26 @@ func (a *Aggregator) load(ctx context.Context) (KnowledgeBase, error) {
 	services = append(services, extServices...)
 	projects = append(projects, extProjects...)
 
-	return KnowledgeBase{Profile: profile, Skills: skills, Services: services, Projects: projects, Posts: posts}, nil
+	profile.AvatarURL = a.urls.SignURL(profile.AvatarURL)
+	for i := range documents {
+		documents[i].URL = a.urls.SignURL(documents[i].URL)
+	}
+
+	return KnowledgeBase{Profile: profile, Skills: skills, Services: services, Projects: projects, Posts: posts, Documents: documents}, nil
+}
+
+// publishedFrom selects the published revision of every row in table as p, decoded back
+// into the table's row type, so unpublished drafts never reach the knowledge base. The
+// caller binds the tenant as $1 and filters trashed rows on t.
+func publishedFrom(table string) string {
+	return `FROM ` + table + ` t
+JOIN content_revisions r ON r.id = t.published_revision_id
+CROSS JOIN LATERAL jsonb_populate_record(NULL::` + table + `, r.data) p
+WHERE t.tenant_id = $1`
 }
 
-func (a *Aggregator) fetchProfile(ctx context.Context) (Profile, error) {
-	const query = `SELECT id, name, title, bio, email, phone, location, avatar_url, updated_at FROM profile ORDER BY updated_at DESC LIMIT 1`
+func (a *Aggregator) fetchProfile(ctx context.Context, tenantID uuid.UUID) (Profile, error) {
+	query := `SELECT p.id, p.name, p.title, p.bio, p.email, p.phone, p.location, p.avatar_url, p.updated_at ` + publishedFrom("profile") + ` ORDER BY p.updated_at DESC LIMIT 1`
 
 	var row struct {
 		ID        uuid.UUID `db:"id"`
@@ -132,7 +181,7 @@ func (a *Aggregator) fetchProfile(ctx context.Context) (Profile, error) {
 		UpdatedAt time.Time `db:"updated_at"`
 	}
 
-	if err := a.db.GetContext(ctx, &row, query); err != nil {
+	if err := a.db.GetContext(ctx, &row, query, tenantID); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return Profile{}, nil
 		}
@@ -151,12 +200,12 @@ func (a *Aggregator) fetchProfile(ctx context.Context) (Profile, error) {
 	}, nil
 }
 
-func (a *Aggregator) fetchSkills(ctx context.Context) ([]Skill, error) {
-	const query = `SELECT name FROM skills ORDER BY "order" ASC, name ASC`
+func (a *Aggregator) fetchSkills(ctx context.Context, tenantID uuid.UUID) ([]Skill, error) {
+	const query = `SELECT name FROM skills WHERE tenant_id = $1 AND deleted_at IS NULL ORDER BY "order" ASC, name ASC`
 	var rows []struct {
 		Name string `db:"name"`
 	}
-	if err := a.db.SelectContext(ctx, &rows, query); err != nil {
+	if err := a.db.SelectContext(ctx, &rows, query, tenantID); err != nil {
 		return nil, err
 	}
 
@@ -167,8 +216,8 @@ func (a *Aggregator) fetchSkills(ctx context.Context) ([]Skill, error) {
 	return skills, nil
 }
 
-func (a *Aggregator) fetchServices(ctx context.Context) ([]Service, error) {
-	const query = `SELECT id, name, description, price_min, price_max, currency, duration_label, "order" FROM services WHERE is_active = TRUE ORDER BY "order" ASC, name ASC`
+func (a *Aggregator) fetchServices(ctx context.Context, tenantID uuid.UUID) ([]Service, error) {
+	query := `SELECT p.id, p.name, p.description, p.price_min, p.price_max, p.currency, p.duration_label, p."order" ` + publishedFrom("services") + ` AND t.deleted_at IS NULL AND p.is_active = TRUE ORDER BY p."order" ASC, p.name ASC`
 	var rows []struct {
 		ID            uuid.UUID `db:"id"`
 		Name          string    `db:"name"`
@@ -179,7 +228,7 @@ func (a *Aggregator) fetchServices(ctx context.Context) ([]Service, error) {
 		DurationLabel *string   `db:"duration_label"`
 		Order         int       `db:"order"`
 	}
-	if err := a.db.SelectContext(ctx, &rows, query); err != nil {
+	if err := a.db.SelectContext(ctx, &rows, query, tenantID); err != nil {
 		return nil, err
 	}
 
@@ -198,8 +247,8 @@ func (a *Aggregator) fetchServices(ctx context.Context) ([]Service, error) {
 	return services, nil
 }
 
-func (a *Aggregator) fetchProjects(ctx context.Context) ([]Project, error) {
-	const query = `SELECT id, title, description, tech_stack, project_url, category, duration_label, price_label, budget_label, "order", is_featured FROM projects ORDER BY is_featured DESC, "order" ASC, title ASC`
+func (a *Aggregator) fetchProjects(ctx context.Context, tenantID uuid.UUID) ([]Project, error) {
+	query := `SELECT p.id, p.title, p.description, p.tech_stack, p.project_url, p.category, p.duration_label, p.price_label, p.budget_label, p."order", p.is_featured ` + publishedFrom("projects") + ` AND t.deleted_at IS NULL ORDER BY p.is_featured DESC, p."order" ASC, p.title ASC`
 	var rows []struct {
 		ID            uuid.UUID      `db:"id"`
 		Title         string         `db:"title"`
@@ -213,7 +262,7 @@ func (a *Aggregator) fetchProjects(ctx context.Context) ([]Project, error) {
 		Order         int            `db:"order"`
 		IsFeatured    bool           `db:"is_featured"`
 	}
-	if err := a.db.SelectContext(ctx, &rows, query); err != nil {
+	if err := a.db.SelectContext(ctx, &rows, query, tenantID); err != nil {
 		return nil, err
 	}
 
@@ -236,11 +285,11 @@ func (a *Aggregator) fetchProjects(ctx context.Context) ([]Project, error) {
 	return projects, nil
 }
 
-func (a *Aggregator) fetchExternalItems(ctx context.Context) ([]Service, []Project, []Post, error) {
+func (a *Aggregator) fetchExternalItems(ctx context.Context, tenantID uuid.UUID) ([]Service, []Project, []Post, []Document, error) {
 	const query = `SELECT i.id, i.kind, i.title, i.url, i.summary, i.content, i.metadata, i.published_at, i.updated_at, s.name AS source_name
 FROM external_items i
 JOIN external_sources s ON s.id = i.source_id
-WHERE i.visible = TRUE AND s.enabled = TRUE
+WHERE s.tenant_id = $1 AND i.visible = TRUE AND s.enabled = TRUE
 ORDER BY COALESCE(i.published_at, i.updated_at) DESC`
 
 	var rows []struct {
@@ -256,16 +305,17 @@ ORDER BY COALESCE(i.published_at, i.updated_at) DESC`
 		SourceName  string         `db:"source_name"`
 	}
 
-	if err := a.db.SelectContext(ctx, &rows, query); err != nil {
+	if err := a.db.SelectContext(ctx, &rows, query, tenantID); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, nil, nil, nil
+			return nil, nil, nil, nil, nil
 		}
-		return nil, nil, nil, err
+		return nil, nil, nil, nil, err
 	}
 
 	services := make([]Service, 0)
 	projects := make([]Project, 0)
 	posts := make([]Post, 0)
+	var documents []Document
 
 	serviceOrder := 1000
 	projectOrder := 1000
@@ -277,7 +327,8 @@ ORDER BY COALESCE(i.published_at, i.updated_at) DESC`
 		if description == "" {
 			description = content
 		}
-		switch strings.ToLower(strings.TrimSpace(row.Kind)) {
+		kind := strings.ToLower(strings.TrimSpace(row.Kind))
+		switch kind {
 		case "service":
 			services = append(services, Service{
 				ID:          row.ID.String(),
@@ -297,6 +348,17 @@ ORDER BY COALESCE(i.published_at, i.updated_at) DESC`
 				Order:       projectOrder,
 			})
 			projectOrder++
+		case "document":
+			// Chunks keep their full text; the prompt builder picks and trims the relevant ones.
+			documents = append(documents, Document{
+				ID:         row.ID.String(),
+				DocumentID: stringFromAny(row.Metadata["documentId"]),
+				Title:      row.Title,
+				Content:    content,
+				URL:        row.URL,
+				Chunk:      intFromAny(row.Metadata["chunk"]),
+				Chunks:     intFromAny(row.Metadata["chunks"]),
+			})
 		default:
 			published := time.Time{}
 			if row.PublishedAt.Valid {
@@ -308,12 +370,13 @@ ORDER BY COALESCE(i.published_at, i.updated_at) DESC`
 				Summary:     description,
 				URL:         row.URL,
 				Source:      row.SourceName,
+				Kind:        kind,
 				PublishedAt: published,
 			})
 		}
 	}
 
-	return services, projects, posts, nil
+	return services, projects, posts, documents, nil
 }
 
 func extractStringSlice(meta models.JSONB, key string) []string {
@@ -360,6 +423,18 @@ func stringFromAny(value any) string {
 	}
 }
 
+// intFromAny reads a JSON number, which metadata decodes as float64.
+func intFromAny(value any) int {
+	switch v := value.(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	default:
+		return 0
+	}
+}
+
 func computeETag(data KnowledgeBase) (string, error) {
 	payload, err := json.Marshal(data)
 	if err != nil {
@@ -7,7 +7,9 @@ import (
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/tanydotai/tanyai/backend/internal/tenant"
 )
 
 func TestAggregatorLoadsDataAndCaches(t *testing.T) {
@@ -18,25 +20,33 @@ func TestAggregatorLoadsDataAndCaches(t *testing.T) {
 	defer db.Close()
 
 	columnsProfile := []string{"id", "name", "title", "bio", "email", "phone", "location", "avatar_url", "updated_at"}
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, title, bio, email, phone, location, avatar_url, updated_at FROM profile ORDER BY updated_at DESC LIMIT 1`)).
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT p.id, p.name, p.title, p.bio, p.email, p.phone, p.location, p.avatar_url, p.updated_at FROM profile t
+JOIN content_revisions r ON r.id = t.published_revision_id`)).
+		WithArgs(tenant.DefaultID).
 		WillReturnRows(sqlmock.NewRows(columnsProfile).AddRow("00000000-0000-0000-0000-000000000001", "Tanya", "Lead", "Bio", "hello@tany.ai", "", "Jakarta", "", time.Now()))
 
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT name FROM skills ORDER BY "order" ASC, name ASC`)).
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT name FROM skills WHERE tenant_id = $1 AND deleted_at IS NULL ORDER BY "order" ASC, name ASC`)).
+		WithArgs(tenant.DefaultID).
 		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("Go"))
 
 	columnsServices := []string{"id", "name", "description", "price_min", "price_max", "currency", "duration_label", "order"}
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, description, price_min, price_max, currency, duration_label, "order" FROM services WHERE is_active = TRUE ORDER BY "order" ASC, name ASC`)).
+	mock.ExpectQuery(regexp.QuoteMeta(`jsonb_populate_record(NULL::services, r.data) p
+WHERE t.tenant_id = $1 AND t.deleted_at IS NULL AND p.is_active = TRUE ORDER BY p."order" ASC, p.name ASC`)).
+		WithArgs(tenant.DefaultID).
 		WillReturnRows(sqlmock.NewRows(columnsServices).AddRow("00000000-0000-0000-0000-000000000010", "Dev", "Desc", 1000.0, 2000.0, "IDR", "2 minggu", 1))
 
 	columnsProjects := []string{"id", "title", "description", "tech_stack", "project_url", "category", "duration_label", "price_label", "budget_label", "order", "is_featured"}
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, title, description, tech_stack, project_url, category, duration_label, price_label, budget_label, "order", is_featured FROM projects ORDER BY is_featured DESC, "order" ASC, title ASC`)).
+	mock.ExpectQuery(regexp.QuoteMeta(`jsonb_populate_record(NULL::projects, r.data) p
+WHERE t.tenant_id = $1 AND t.deleted_at IS NULL ORDER BY p.is_featured DESC, p."order" ASC, p.title ASC`)).
+		WithArgs(tenant.DefaultID).
 		WillReturnRows(sqlmock.NewRows(columnsProjects).AddRow("00000000-0000-0000-0000-000000000020", "Proj", "Impact", `{"Go"}`, "https://example.com", "Web", "2 bulan", "IDR 50Jt", "Series A", 1, true))
 
 	mock.ExpectQuery(regexp.QuoteMeta(`SELECT i.id, i.kind, i.title, i.url, i.summary, i.content, i.metadata, i.published_at, i.updated_at, s.name AS source_name
 FROM external_items i
 JOIN external_sources s ON s.id = i.source_id
-WHERE i.visible = TRUE AND s.enabled = TRUE
+WHERE s.tenant_id = $1 AND i.visible = TRUE AND s.enabled = TRUE
 ORDER BY COALESCE(i.published_at, i.updated_at) DESC`)).
+		WithArgs(tenant.DefaultID).
 		WillReturnRows(sqlmock.NewRows([]string{"id", "kind", "title", "url", "summary", "content", "metadata", "published_at", "updated_at", "source_name"}))
 
 	aggregator := NewAggregator(sqlx.NewDb(db, "sqlmock"), time.Second)
@@ -71,3 +81,97 @@ ORDER BY COALESCE(i.published_at, i.updated_at) DESC`)).
 		t.Fatalf("unmet expectations: %v", err)
 	}
 }
+
+var externalItemColumns = []string{"id", "kind", "title", "url", "summary", "content", "metadata", "published_at", "updated_at", "source_name"}
+
+func expectEmptyKnowledgeBase(mock sqlmock.Sqlmock, tenantID uuid.UUID, name string) {
+	expectKnowledgeBase(mock, tenantID, name, sqlmock.NewRows(externalItemColumns))
+}
+
+func expectKnowledgeBase(mock sqlmock.Sqlmock, tenantID uuid.UUID, name string, externalItems *sqlmock.Rows) {
+	mock.ExpectQuery(`FROM profile t JOIN content_revisions`).WithArgs(tenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "title", "bio", "email", "phone", "location", "avatar_url", "updated_at"}).
+			AddRow(uuid.New().String(), name, nil, nil, nil, nil, nil, nil, time.Now()))
+	mock.ExpectQuery(`FROM skills WHERE tenant_id`).WithArgs(tenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"name"}))
+	mock.ExpectQuery(`FROM services t JOIN content_revisions`).WithArgs(tenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "price_min", "price_max", "currency", "duration_label", "order"}))
+	mock.ExpectQuery(`FROM projects t JOIN content_revisions`).WithArgs(tenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "description", "tech_stack", "project_url", "category", "duration_label", "price_label", "budget_label", "order", "is_featured"}))
+	mock.ExpectQuery(`FROM external_items i`).WithArgs(tenantID).
+		WillReturnRows(externalItems)
+}
+
+func TestAggregatorMapsDocumentChunks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	chunkID, docID := uuid.New(), uuid.New()
+	metadata := []byte(`{"documentId":"` + docID.String() + `","filename":"harga.pdf","chunk":2,"chunks":3}`)
+	expectKnowledgeBase(mock, tenant.DefaultID, "Tanya", sqlmock.NewRows(externalItemColumns).
+		AddRow(chunkID, "document", "Daftar harga", "https://cdn/documents/a.pdf#chunk-2", nil, "Paket lengkap mulai 10 juta.", metadata, nil, time.Now(), "Uploaded documents"))
+
+	aggregator := NewAggregator(sqlx.NewDb(db, "sqlmock"), time.Minute)
+	data, _, _, err := aggregator.Get(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(data.Posts) != 0 {
+		t.Fatalf("documents must not be listed as posts: %+v", data.Posts)
+	}
+	want := Document{
+		ID:         chunkID.String(),
+		DocumentID: docID.String(),
+		Title:      "Daftar harga",
+		Content:    "Paket lengkap mulai 10 juta.",
+		URL:        "https://cdn/documents/a.pdf#chunk-2",
+		Chunk:      2,
+		Chunks:     3,
+	}
+	if len(data.Documents) != 1 || data.Documents[0] != want {
+		t.Fatalf("unexpected documents %+v", data.Documents)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestAggregatorCachesPerTenant(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	other := uuid.New()
+	expectEmptyKnowledgeBase(mock, tenant.DefaultID, "Default")
+	expectEmptyKnowledgeBase(mock, other, "Other")
+
+	aggregator := NewAggregator(sqlx.NewDb(db, "sqlmock"), time.Minute)
+
+	first, firstETag, _, err := aggregator.Get(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, secondETag, hit, err := aggregator.Get(tenant.WithID(context.Background(), other))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hit {
+		t.Fatalf("expected another tenant to miss the cache")
+	}
+	if first.Profile.Name != "Default" || second.Profile.Name != "Other" || firstETag == secondETag {
+		t.Fatalf("expected tenant specific knowledge bases, got %q and %q", first.Profile.Name, second.Profile.Name)
+	}
+
+	if _, _, hit, _ := aggregator.Get(context.Background()); !hit {
+		t.Fatalf("expected default tenant to stay cached")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
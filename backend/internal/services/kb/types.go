@@ -52,14 +52,28 @@ type Post struct {
 	Summary     string    `json:"summary,omitempty"`
 	URL         string    `json:"url"`
 	Source      string    `json:"source"`
+	Kind        string    `json:"kind,omitempty"`
 	PublishedAt time.Time `json:"publishedAt,omitempty"`
 }
 
+// Document is one chunk of an uploaded document. Chunks of the same upload share
+// DocumentID; Chunk is 1-based.
+type Document struct {
+	ID         string `json:"id"`
+	DocumentID string `json:"documentId"`
+	Title      string `json:"title"`
+	Content    string `json:"content"`
+	URL        string `json:"url,omitempty"`
+	Chunk      int    `json:"chunk"`
+	Chunks     int    `json:"chunks"`
+}
+
 // KnowledgeBase aggregates all public knowledge powering the assistant.
 type KnowledgeBase struct {
-	Profile  Profile   `json:"profile"`
-	Skills   []Skill   `json:"skills"`
-	Services []Service `json:"services"`
-	Projects []Project `json:"projects"`
-	Posts    []Post    `json:"posts,omitempty"`
+	Profile   Profile    `json:"profile"`
+	Skills    []Skill    `json:"skills"`
+	Services  []Service  `json:"services"`
+	Projects  []Project  `json:"projects"`
+	Posts     []Post     `json:"posts,omitempty"`
+	Documents []Document `json:"documents,omitempty"`
 }
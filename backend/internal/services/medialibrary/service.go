@@ -0,0 +1,128 @@
+package medialibrary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/repos"
+	"github.com/tanydotai/tanyai/backend/internal/storage"
+)
+
+// uploadPrefix is where the upload endpoint writes objects.
+const uploadPrefix = "uploads/"
+
+// ErrStorage wraps failures to remove objects whose rows are already gone. The next
+// cleanup run retries them through the untracked-object sweep.
+var ErrStorage = errors.New("medialibrary: storage delete failed")
+
+// CleanupResult counts the work done by one cleanup run.
+type CleanupResult struct {
+	// Uploads is the number of orphaned upload rows removed, variants included.
+	Uploads int
+	// Objects is the number of storage objects deleted.
+	Objects int
+}
+
+// Service manages uploaded media, keeping the uploads table and object storage in step.
+type Service struct {
+	repo  repos.MediaRepository
+	store storage.ObjectStorage
+}
+
+// NewService constructs a Service.
+func NewService(repo repos.MediaRepository, store storage.ObjectStorage) *Service {
+	return &Service{repo: repo, store: store}
+}
+
+// List returns a page of the media library.
+func (s *Service) List(ctx context.Context, params repos.MediaListParams) ([]models.MediaItem, int64, error) {
+	return s.repo.List(ctx, params)
+}
+
+// Delete removes an upload, its variants and their objects. Referenced uploads return
+// repos.ErrConflict unless force is set.
+func (s *Service) Delete(ctx context.Context, id uuid.UUID, force bool) error {
+	removed, err := s.repo.Delete(ctx, id, force)
+	if err != nil {
+		return err
+	}
+	if _, err := s.deleteObjects(ctx, keysOf(removed)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// CleanupOrphans removes uploads older than cutoff that no content references, then
+// deletes untracked objects under the upload prefix, such as files stored before uploads
+// were recorded or whose deletion previously failed.
+func (s *Service) CleanupOrphans(ctx context.Context, cutoff time.Time) (CleanupResult, error) {
+	var result CleanupResult
+
+	removed, err := s.repo.PurgeOrphans(ctx, cutoff)
+	if err != nil {
+		return result, err
+	}
+	result.Uploads = len(removed)
+	deleted, err := s.deleteObjects(ctx, keysOf(removed))
+	result.Objects += deleted
+	if err != nil {
+		return result, err
+	}
+
+	objects, err := s.store.List(ctx, uploadPrefix)
+	if err != nil {
+		return result, err
+	}
+	var candidates []string
+	for _, object := range objects {
+		if object.LastModified.Before(cutoff) {
+			candidates = append(candidates, object.Key)
+		}
+	}
+	untracked, err := s.repo.FilterUntracked(ctx, candidates)
+	if err != nil {
+		return result, err
+	}
+	deleted, err = s.deleteObjects(ctx, untracked)
+	result.Objects += deleted
+	if err != nil {
+		return result, err
+	}
+
+	if result.Uploads > 0 || result.Objects > 0 {
+		slog.Info("media_cleanup", "uploads", result.Uploads, "objects", result.Objects)
+	}
+	return result, nil
+}
+
+// deleteObjects attempts every key and reports the first failure.
+func (s *Service) deleteObjects(ctx context.Context, keys []string) (int, error) {
+	var (
+		deleted  int
+		firstErr error
+	)
+	for _, key := range keys {
+		if err := s.store.Delete(ctx, key); err != nil {
+			slog.Warn("media_object_delete_failed", "error", err, "key", key)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%w: %s: %v", ErrStorage, key, err)
+			}
+			continue
+		}
+		deleted++
+	}
+	return deleted, firstErr
+}
+
+func keysOf(uploads []models.Upload) []string {
+	keys := make([]string, len(uploads))
+	for i, upload := range uploads {
+		keys[i] = upload.Key
+	}
+	return keys
+}
@@ -0,0 +1,113 @@
+package medialibrary
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/repos"
+	"github.com/tanydotai/tanyai/backend/internal/storage"
+)
+
+type stubRepo struct {
+	repos.MediaRepository
+	removed   []models.Upload
+	deleteErr error
+	orphans   []models.Upload
+	untracked map[string]bool
+	filtered  []string
+}
+
+func (s *stubRepo) Delete(_ context.Context, _ uuid.UUID, _ bool) ([]models.Upload, error) {
+	return s.removed, s.deleteErr
+}
+
+func (s *stubRepo) PurgeOrphans(_ context.Context, _ time.Time) ([]models.Upload, error) {
+	return s.orphans, nil
+}
+
+func (s *stubRepo) FilterUntracked(_ context.Context, keys []string) ([]string, error) {
+	s.filtered = keys
+	var out []string
+	for _, key := range keys {
+		if s.untracked[key] {
+			out = append(out, key)
+		}
+	}
+	return out, nil
+}
+
+type stubStore struct {
+	storage.ObjectStorage
+	objects []storage.ObjectInfo
+	deleted []string
+	failKey string
+}
+
+func (s *stubStore) Delete(_ context.Context, key string) error {
+	if key == s.failKey {
+		return errors.New("boom")
+	}
+	s.deleted = append(s.deleted, key)
+	return nil
+}
+
+func (s *stubStore) List(_ context.Context, _ string) ([]storage.ObjectInfo, error) {
+	return s.objects, nil
+}
+
+func TestDeleteRemovesObjects(t *testing.T) {
+	repo := &stubRepo{removed: []models.Upload{{Key: "uploads/a.jpg"}, {Key: "uploads/a_thumb.jpg"}}}
+	store := &stubStore{failKey: "uploads/a.jpg"}
+	svc := NewService(repo, store)
+
+	err := svc.Delete(context.Background(), uuid.New(), false)
+	if !errors.Is(err, ErrStorage) {
+		t.Fatalf("expected ErrStorage, got %v", err)
+	}
+	if len(store.deleted) != 1 || store.deleted[0] != "uploads/a_thumb.jpg" {
+		t.Fatalf("expected remaining objects to be deleted, got %v", store.deleted)
+	}
+
+	repo.deleteErr = repos.ErrConflict
+	store.deleted = nil
+	if err := svc.Delete(context.Background(), uuid.New(), false); !errors.Is(err, repos.ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+	if len(store.deleted) != 0 {
+		t.Fatalf("expected no objects deleted, got %v", store.deleted)
+	}
+}
+
+func TestCleanupOrphansSweepsUntrackedObjects(t *testing.T) {
+	cutoff := time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC)
+	repo := &stubRepo{
+		orphans:   []models.Upload{{Key: "uploads/orphan.png"}},
+		untracked: map[string]bool{"uploads/legacy.png": true},
+	}
+	store := &stubStore{objects: []storage.ObjectInfo{
+		{Key: "uploads/legacy.png", LastModified: cutoff.Add(-time.Hour)},
+		{Key: "uploads/used.png", LastModified: cutoff.Add(-time.Hour)},
+		{Key: "uploads/fresh.png", LastModified: cutoff.Add(time.Hour)},
+	}}
+	svc := NewService(repo, store)
+
+	result, err := svc.CleanupOrphans(context.Background(), cutoff)
+	if err != nil {
+		t.Fatalf("cleanup: %v", err)
+	}
+	if result.Uploads != 1 || result.Objects != 2 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if len(repo.filtered) != 2 {
+		t.Fatalf("expected only objects older than the cutoff to be checked, got %v", repo.filtered)
+	}
+	sort.Strings(store.deleted)
+	if len(store.deleted) != 2 || store.deleted[0] != "uploads/legacy.png" || store.deleted[1] != "uploads/orphan.png" {
+		t.Fatalf("unexpected deleted objects %v", store.deleted)
+	}
+}
@@ -0,0 +1,206 @@
+// Package portfolio plans and applies imports of a tenant's complete content.
+package portfolio
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/tanydotai/tanyai/backend/internal/audit"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/repos"
+)
+
+// ignoredFields are maintained by the server and never carried by a bundle, so they are
+// left out of import diffs.
+var ignoredFields = []string{
+	"id",
+	"created_at",
+	"updated_at",
+	"published_revision_id",
+	"has_unpublished_changes",
+	"publish_at",
+	"etag",
+	"last_modified",
+	"last_synced_at",
+}
+
+// Options controls how content is imported.
+type Options struct {
+	// Replace trashes skills, services and projects and deletes external sources missing
+	// from the imported content instead of keeping them.
+	Replace bool
+	// DryRun only computes the report.
+	DryRun bool
+	// Publish publishes the imported profile, services and projects afterwards.
+	Publish bool
+}
+
+// Report describes the effect of an import per kind of content.
+type Report struct {
+	Profile         EntityReport
+	Skills          EntityReport
+	Services        EntityReport
+	Projects        EntityReport
+	ExternalSources EntityReport
+	// Published counts the revisions created when Options.Publish is set.
+	Published int
+}
+
+// EntityReport lists the entries an import creates, updates or deletes.
+type EntityReport struct {
+	Created   []uuid.UUID
+	Updated   []Update
+	Deleted   []uuid.UUID
+	Unchanged int
+}
+
+// Update lists the changed fields of an entry as produced by audit.Diff.
+type Update struct {
+	ID      uuid.UUID
+	Changes models.JSONB
+}
+
+// Service exports and imports portfolio content.
+type Service struct {
+	repo      repos.PortfolioRepository
+	revisions repos.RevisionRepository
+}
+
+// NewService constructs a Service. revisions may be nil when imports are never published.
+func NewService(repo repos.PortfolioRepository, revisions repos.RevisionRepository) *Service {
+	return &Service{repo: repo, revisions: revisions}
+}
+
+// Export returns the tenant's current content.
+func (s *Service) Export(ctx context.Context) (repos.PortfolioContent, error) {
+	return s.repo.Export(ctx)
+}
+
+// Import compares content with the tenant's current content and, unless opts.DryRun is
+// set, writes it. The report is computed before writing; IDs owned by another tenant
+// only surface as repos.ErrConflict when the import is applied.
+func (s *Service) Import(ctx context.Context, content repos.PortfolioContent, opts Options) (Report, error) {
+	current, err := s.repo.Export(ctx)
+	if err != nil {
+		return Report{}, err
+	}
+
+	if content.Profile != nil && content.Profile.ID == uuid.Nil {
+		profile := *content.Profile
+		profile.ID = uuid.New()
+		content.Profile = &profile
+	}
+
+	report := Plan(current, content, opts.Replace)
+	if opts.DryRun {
+		return report, nil
+	}
+	if err := s.repo.Import(ctx, content, opts.Replace); err != nil {
+		return Report{}, err
+	}
+	if opts.Publish && s.revisions != nil {
+		revisions, err := s.revisions.Publish(ctx, publishRefs(current, content))
+		if err != nil {
+			return Report{}, err
+		}
+		report.Published = len(revisions)
+	}
+	return report, nil
+}
+
+// publishRefs references the imported drafts; an existing profile keeps its ID.
+func publishRefs(current, content repos.PortfolioContent) []repos.RevisionRef {
+	refs := make([]repos.RevisionRef, 0, len(content.Services)+len(content.Projects)+1)
+	if content.Profile != nil {
+		profileID := content.Profile.ID
+		if current.Profile != nil {
+			profileID = current.Profile.ID
+		}
+		refs = append(refs, repos.RevisionRef{EntityType: audit.EntityProfile, EntityID: profileID})
+	}
+	for _, service := range content.Services {
+		refs = append(refs, repos.RevisionRef{EntityType: audit.EntityService, EntityID: service.ID})
+	}
+	for _, project := range content.Projects {
+		refs = append(refs, repos.RevisionRef{EntityType: audit.EntityProject, EntityID: project.ID})
+	}
+	return refs
+}
+
+// Plan computes the report of importing incoming over current.
+func Plan(current, incoming repos.PortfolioContent, replace bool) Report {
+	report := Report{
+		Skills:          diffEntries(current.Skills, incoming.Skills, func(s models.Skill) uuid.UUID { return s.ID }, replace),
+		Services:        diffEntries(current.Services, incoming.Services, func(s models.Service) uuid.UUID { return s.ID }, replace),
+		Projects:        diffEntries(normalizeProjects(current.Projects), normalizeProjects(incoming.Projects), func(p models.Project) uuid.UUID { return p.ID }, replace),
+		ExternalSources: diffEntries(current.ExternalSources, incoming.ExternalSources, func(s models.ExternalSource) uuid.UUID { return s.ID }, replace),
+	}
+	switch {
+	case incoming.Profile == nil:
+	case current.Profile == nil:
+		report.Profile.Created = append(report.Profile.Created, incoming.Profile.ID)
+	default:
+		// The tenant keeps its profile ID; only the fields are replaced.
+		recordUpdate(&report.Profile, current.Profile.ID, *current.Profile, *incoming.Profile)
+	}
+	return report
+}
+
+func diffEntries[T any](current, incoming []T, id func(T) uuid.UUID, replace bool) EntityReport {
+	var report EntityReport
+	existing := make(map[uuid.UUID]T, len(current))
+	for _, entry := range current {
+		existing[id(entry)] = entry
+	}
+
+	seen := make(map[uuid.UUID]struct{}, len(incoming))
+	for _, entry := range incoming {
+		entryID := id(entry)
+		seen[entryID] = struct{}{}
+		before, ok := existing[entryID]
+		if !ok {
+			report.Created = append(report.Created, entryID)
+			continue
+		}
+		recordUpdate(&report, entryID, before, entry)
+	}
+
+	if replace {
+		for _, entry := range current {
+			if _, ok := seen[id(entry)]; !ok {
+				report.Deleted = append(report.Deleted, id(entry))
+			}
+		}
+	}
+	return report
+}
+
+func recordUpdate(report *EntityReport, id uuid.UUID, before, after any) {
+	changes := audit.Diff(snapshot(before), snapshot(after))
+	if len(changes) == 0 {
+		report.Unchanged++
+		return
+	}
+	report.Updated = append(report.Updated, Update{ID: id, Changes: changes})
+}
+
+func snapshot(v any) models.JSONB {
+	values := audit.Snapshot(v)
+	for _, field := range ignoredFields {
+		delete(values, field)
+	}
+	return values
+}
+
+// normalizeProjects treats a NULL tech stack like an empty one.
+func normalizeProjects(projects []models.Project) []models.Project {
+	out := make([]models.Project, len(projects))
+	for i, project := range projects {
+		if project.TechStack == nil {
+			project.TechStack = pq.StringArray{}
+		}
+		out[i] = project
+	}
+	return out
+}
@@ -0,0 +1,119 @@
+package portfolio
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/repos"
+)
+
+type stubRepo struct {
+	current  repos.PortfolioContent
+	imported *repos.PortfolioContent
+	replace  bool
+}
+
+func (s *stubRepo) Export(context.Context) (repos.PortfolioContent, error) {
+	return s.current, nil
+}
+
+func (s *stubRepo) Import(_ context.Context, content repos.PortfolioContent, replace bool) error {
+	s.imported = &content
+	s.replace = replace
+	return nil
+}
+
+type stubRevisions struct {
+	repos.RevisionRepository
+	refs []repos.RevisionRef
+}
+
+func (s *stubRevisions) Publish(_ context.Context, refs []repos.RevisionRef) ([]models.ContentRevision, error) {
+	s.refs = refs
+	return make([]models.ContentRevision, len(refs)), nil
+}
+
+func TestPlanReportsChangesAndDeletions(t *testing.T) {
+	kept, changed, removed, added := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	current := repos.PortfolioContent{
+		Skills: []models.Skill{{ID: kept, Name: "Go"}, {ID: changed, Name: "Rust", Order: 1}, {ID: removed, Name: "PHP"}},
+		Services: []models.Service{{
+			ID:          changed,
+			Name:        "Audit",
+			Description: sql.NullString{String: "old", Valid: true},
+			Publication: models.Publication{HasUnpublishedChanges: true},
+		}},
+	}
+	incoming := repos.PortfolioContent{
+		Skills:   []models.Skill{{ID: kept, Name: "Go"}, {ID: changed, Name: "Rust", Order: 2}, {ID: added, Name: "Zig"}},
+		Services: []models.Service{{ID: changed, Name: "Audit", Description: sql.NullString{String: "new", Valid: true}}},
+	}
+
+	report := Plan(current, incoming, true)
+
+	if len(report.Skills.Created) != 1 || report.Skills.Created[0] != added {
+		t.Fatalf("unexpected created %v", report.Skills.Created)
+	}
+	if len(report.Skills.Deleted) != 1 || report.Skills.Deleted[0] != removed {
+		t.Fatalf("unexpected deleted %v", report.Skills.Deleted)
+	}
+	if report.Skills.Unchanged != 1 || len(report.Skills.Updated) != 1 {
+		t.Fatalf("unexpected skills report %+v", report.Skills)
+	}
+	if _, ok := report.Skills.Updated[0].Changes["order"]; !ok || len(report.Skills.Updated[0].Changes) != 1 {
+		t.Fatalf("unexpected skill changes %v", report.Skills.Updated[0].Changes)
+	}
+	// Publication state is server-maintained and must not show up as a change.
+	if len(report.Services.Updated) != 1 || len(report.Services.Updated[0].Changes) != 1 {
+		t.Fatalf("unexpected service changes %+v", report.Services.Updated)
+	}
+
+	if upsert := Plan(current, incoming, false); len(upsert.Skills.Deleted) != 0 {
+		t.Fatalf("upsert mode must not delete, got %v", upsert.Skills.Deleted)
+	}
+}
+
+func TestImportDryRunDoesNotWrite(t *testing.T) {
+	repo := &stubRepo{}
+	svc := NewService(repo, nil)
+
+	report, err := svc.Import(context.Background(), repos.PortfolioContent{
+		Profile: &models.Profile{Name: "Jane", Title: "Engineer"},
+	}, Options{DryRun: true})
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if repo.imported != nil {
+		t.Fatal("dry run wrote content")
+	}
+	if len(report.Profile.Created) != 1 || report.Profile.Created[0] == uuid.Nil {
+		t.Fatalf("expected created profile with generated ID, got %+v", report.Profile)
+	}
+}
+
+func TestImportPublishesWithExistingProfileID(t *testing.T) {
+	profileID, serviceID := uuid.New(), uuid.New()
+	repo := &stubRepo{current: repos.PortfolioContent{Profile: &models.Profile{ID: profileID, Name: "Jane", Title: "Engineer"}}}
+	revisions := &stubRevisions{}
+	svc := NewService(repo, revisions)
+
+	report, err := svc.Import(context.Background(), repos.PortfolioContent{
+		Profile:  &models.Profile{ID: uuid.New(), Name: "Jane", Title: "Staff Engineer"},
+		Services: []models.Service{{ID: serviceID, Name: "Audit"}},
+	}, Options{Replace: true, Publish: true})
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if repo.imported == nil || !repo.replace {
+		t.Fatal("expected replace import")
+	}
+	if len(revisions.refs) != 2 || revisions.refs[0].EntityID != profileID || revisions.refs[1].EntityID != serviceID {
+		t.Fatalf("unexpected publish refs %+v", revisions.refs)
+	}
+	if report.Published != 2 || len(report.Profile.Updated) != 1 || report.Profile.Updated[0].ID != profileID {
+		t.Fatalf("unexpected report %+v", report)
+	}
+}
@@ -0,0 +1,247 @@
+package prompt
+
+import (
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	defaultTokenBudget  = 900
+	runesPerToken       = 4
+	minTrimmedEntryToks = 8
+	ellipsis            = "…"
+)
+
+// Section identifies a block of knowledge that can be placed in the prompt.
+type Section string
+
+const (
+	SectionProfile   Section = "profile"
+	SectionServices  Section = "services"
+	SectionProjects  Section = "projects"
+	SectionDocuments Section = "documents"
+	SectionPosts     Section = "posts"
+	SectionHistory   Section = "history"
+)
+
+// DefaultPriority lists sections from most to least important when the budget is tight.
+var DefaultPriority = []Section{SectionProfile, SectionServices, SectionProjects, SectionDocuments, SectionPosts, SectionHistory}
+
+// renderOrder keeps the prompt layout stable regardless of allocation priority.
+var renderOrder = []Section{SectionProfile, SectionServices, SectionProjects, SectionDocuments, SectionPosts, SectionHistory}
+
+// SectionReport describes how the budgeter treated a single section.
+type SectionReport struct {
+	Section  Section `json:"section"`
+	Entries  int     `json:"entries"`
+	Included int     `json:"included"`
+	Tokens   int     `json:"tokens"`
+	Trimmed  bool    `json:"trimmed"`
+	Dropped  bool    `json:"dropped"`
+}
+
+// EstimateTokens approximates the number of model tokens needed for text.
+// Each whitespace separated word costs one token per four runes (rounded up),
+// which tracks SentencePiece style tokenizers closely enough for budgeting.
+func EstimateTokens(text string) int {
+	total := 0
+	for _, word := range strings.Fields(text) {
+		n := utf8.RuneCountInString(word)
+		total += (n + runesPerToken - 1) / runesPerToken
+	}
+	return total
+}
+
+// TruncateRunes shortens text to at most max runes without splitting a rune,
+// preferring to cut at a word boundary and appending an ellipsis when trimmed.
+func TruncateRunes(text string, max int) string {
+	text = strings.TrimSpace(text)
+	if max <= 0 {
+		return ""
+	}
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	limit := max - utf8.RuneCountInString(ellipsis)
+	if limit <= 0 {
+		return string(runes[:max])
+	}
+	cut := runes[:limit]
+	if idx := lastSpace(cut); idx > limit/2 {
+		cut = cut[:idx]
+	}
+	return strings.TrimRightFunc(string(cut), isTrailingPunct) + ellipsis
+}
+
+// TrimToTokens shortens text so that EstimateTokens reports at most maxTokens,
+// cutting on the last complete sentence when that keeps most of the content.
+func TrimToTokens(text string, maxTokens int) string {
+	text = strings.TrimSpace(text)
+	if maxTokens <= 0 {
+		return ""
+	}
+	if EstimateTokens(text) <= maxTokens {
+		return text
+	}
+
+	words := strings.Fields(text)
+	used := 0
+	kept := 0
+	// Reserve one token for the ellipsis marker.
+	for kept < len(words) {
+		cost := EstimateTokens(words[kept])
+		if used+cost > maxTokens-1 {
+			break
+		}
+		used += cost
+		kept++
+	}
+	if kept == 0 {
+		return TruncateRunes(words[0], maxTokens*runesPerToken)
+	}
+
+	candidate := strings.Join(words[:kept], " ")
+	if idx := lastSentenceEnd(candidate); idx > 0 && utf8.RuneCountInString(candidate[:idx]) >= utf8.RuneCountInString(candidate)/2 {
+		return strings.TrimSpace(candidate[:idx])
+	}
+	return strings.TrimRightFunc(candidate, isTrailingPunct) + ellipsis
+}
+
+func lastSpace(runes []rune) int {
+	for i := len(runes) - 1; i >= 0; i-- {
+		if unicode.IsSpace(runes[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// lastSentenceEnd returns the byte offset just after the last sentence terminator.
+func lastSentenceEnd(text string) int {
+	end := -1
+	for i, r := range text {
+		if r == '.' || r == '!' || r == '?' {
+			next := i + utf8.RuneLen(r)
+			if next == len(text) || text[next] == ' ' {
+				end = next
+			}
+		}
+	}
+	return end
+}
+
+func isTrailingPunct(r rune) bool {
+	return unicode.IsSpace(r) || r == ',' || r == ';' || r == ':' || r == '-' || r == '–' || r == '—'
+}
+
+func priorityFromEnv(key string, fallback []Section) []Section {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return fallback
+	}
+	return normalizePriority(parseSections(raw))
+}
+
+func parseSections(raw string) []Section {
+	parts := strings.Split(raw, ",")
+	sections := make([]Section, 0, len(parts))
+	for _, part := range parts {
+		if name := strings.ToLower(strings.TrimSpace(part)); name != "" {
+			sections = append(sections, Section(name))
+		}
+	}
+	return sections
+}
+
+// normalizePriority drops unknown or duplicated sections and appends any missing
+// ones in their default order so every section still gets a chance at the budget.
+func normalizePriority(priority []Section) []Section {
+	seen := make(map[Section]bool, len(DefaultPriority))
+	known := make(map[Section]bool, len(DefaultPriority))
+	for _, section := range DefaultPriority {
+		known[section] = true
+	}
+
+	result := make([]Section, 0, len(DefaultPriority))
+	for _, section := range priority {
+		if !known[section] || seen[section] {
+			continue
+		}
+		seen[section] = true
+		result = append(result, section)
+	}
+	for _, section := range DefaultPriority {
+		if !seen[section] {
+			result = append(result, section)
+		}
+	}
+	return result
+}
+
+type sectionBlock struct {
+	section Section
+	heading string
+	entries []string
+	// refs, when set, holds the citable reference of each entry.
+	refs []Reference
+}
+
+type allocation struct {
+	report  SectionReport
+	entries []string
+}
+
+// allocate distributes the remaining token budget across blocks following priority.
+func allocate(blocks map[Section]sectionBlock, priority []Section, budget int) (map[Section]allocation, int) {
+	remaining := budget
+	result := make(map[Section]allocation, len(blocks))
+
+	for _, section := range priority {
+		block, ok := blocks[section]
+		if !ok || len(block.entries) == 0 {
+			continue
+		}
+
+		alloc := allocation{report: SectionReport{Section: section, Entries: len(block.entries)}}
+		overhead := EstimateTokens(block.heading)
+		if remaining-overhead < minTrimmedEntryToks {
+			alloc.report.Dropped = true
+			result[section] = alloc
+			continue
+		}
+
+		spent := overhead
+		for _, entry := range block.entries {
+			cost := EstimateTokens(entry)
+			if spent+cost <= remaining {
+				alloc.entries = append(alloc.entries, entry)
+				spent += cost
+				continue
+			}
+			alloc.report.Trimmed = true
+			if left := remaining - spent; left >= minTrimmedEntryToks {
+				trimmed := TrimToTokens(entry, left)
+				alloc.entries = append(alloc.entries, trimmed)
+				spent += EstimateTokens(trimmed)
+			}
+			break
+		}
+
+		if len(alloc.entries) == 0 {
+			alloc.report.Dropped = true
+			alloc.report.Trimmed = false
+			result[section] = alloc
+			continue
+		}
+
+		alloc.report.Included = len(alloc.entries)
+		alloc.report.Tokens = spent
+		remaining -= spent
+		result[section] = alloc
+	}
+
+	return result, remaining
+}
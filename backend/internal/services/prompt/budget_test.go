@@ -0,0 +1,107 @@
+package prompt
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/tanydotai/tanyai/backend/internal/services/kb"
+)
+
+func TestTruncateRunesKeepsMultiByteRunesIntact(t *testing.T) {
+	text := strings.Repeat("héllo wörld ", 20)
+	trimmed := TruncateRunes(text, 50)
+	if !utf8.ValidString(trimmed) {
+		t.Fatalf("expected valid UTF-8, got %q", trimmed)
+	}
+	if n := utf8.RuneCountInString(trimmed); n > 50 {
+		t.Fatalf("expected at most 50 runes, got %d", n)
+	}
+	if !strings.HasSuffix(trimmed, "…") {
+		t.Fatalf("expected ellipsis suffix, got %q", trimmed)
+	}
+}
+
+func TestTrimToTokensPrefersSentenceBoundary(t *testing.T) {
+	text := "Kami membangun aplikasi web modern. Fokus pada performa dan aksesibilitas. Kalimat ketiga yang panjang sekali dan tidak akan muat."
+	trimmed := TrimToTokens(text, 20)
+	if EstimateTokens(trimmed) > 20 {
+		t.Fatalf("expected trimmed text within budget, got %d tokens", EstimateTokens(trimmed))
+	}
+	if !strings.HasSuffix(trimmed, "aksesibilitas.") {
+		t.Fatalf("expected cut at sentence boundary, got %q", trimmed)
+	}
+}
+
+func TestAssembleReportsDroppedSectionsUnderTightBudget(t *testing.T) {
+	base := sampleBase()
+	base.Profile.Bio = strings.Repeat("Pengalaman panjang membangun produk digital. ", 10)
+
+	result := Assemble(base, "Apa layananmu?", Options{
+		TokenBudget: 105,
+		History:     []Turn{{Question: "Halo", Answer: "Hai, ada yang bisa dibantu?"}},
+	})
+
+	if result.EstimatedTokens > result.Budget {
+		t.Fatalf("expected prompt within budget, got %d > %d", result.EstimatedTokens, result.Budget)
+	}
+	if !strings.Contains(result.Text, "Berikan jawaban untuk: Apa layananmu?") {
+		t.Fatalf("question must always be kept")
+	}
+	if len(result.Dropped) == 0 {
+		t.Fatalf("expected some sections to be dropped")
+	}
+	for _, section := range result.Dropped {
+		if section == SectionProfile {
+			t.Fatalf("profile has highest priority and should not be dropped")
+		}
+	}
+	if strings.Contains(result.Text, "Percakapan sebelumnya") {
+		t.Fatalf("history has lowest priority and should be dropped first")
+	}
+}
+
+func TestAssembleHonoursCustomPriority(t *testing.T) {
+	base := sampleBase()
+	result := Assemble(base, "Apa layananmu?", Options{
+		TokenBudget: 110,
+		Priority:    []Section{SectionPosts, SectionServices},
+	})
+
+	if !strings.Contains(result.Text, "Update terbaru") {
+		t.Fatalf("posts were prioritised and should be included:\n%s", result.Text)
+	}
+	if len(result.Dropped) == 0 {
+		t.Fatalf("expected lower priority sections to be dropped")
+	}
+}
+
+func TestAssembleIncludesHistoryWhenBudgetAllows(t *testing.T) {
+	result := Assemble(sampleBase(), "Berapa harganya?", Options{
+		TokenBudget: 2000,
+		History:     []Turn{{Question: "Apa layanan Build?", Answer: "Build adalah layanan pembuatan aplikasi."}},
+	})
+	if !strings.Contains(result.Text, "Pengunjung: Apa layanan Build?") {
+		t.Fatalf("expected history to be included")
+	}
+	if len(result.Dropped) != 0 {
+		t.Fatalf("expected nothing dropped, got %v", result.Dropped)
+	}
+}
+
+func TestAssembleRanksMatchedServicesFirst(t *testing.T) {
+	base := kb.KnowledgeBase{
+		Profile: kb.Profile{Name: "Tanya"},
+		Services: []kb.Service{
+			{Name: "Build", Description: "Build apps", Order: 1},
+			{Name: "Optimize", Description: "Optimize database", Order: 2},
+		},
+	}
+	result := Assemble(base, "Bisa bantu optimize database?", Options{TokenBudget: 2000})
+	if !strings.Contains(result.Text, "] Optimize") {
+		t.Fatalf("expected matched service in prompt:\n%s", result.Text)
+	}
+	if strings.Contains(result.Text, "] Build") {
+		t.Fatalf("expected unmatched service to be left out for non-service question")
+	}
+}
@@ -6,16 +6,22 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/tanydotai/tanyai/backend/internal/services/kb"
 )
 
 const (
-	defaultMaxServicesInPrompt = 3
-	defaultMaxProjectsInPrompt = 3
-	defaultMaxPostsInPrompt    = 2
+	defaultMaxServicesInPrompt  = 3
+	defaultMaxProjectsInPrompt  = 3
+	defaultMaxPostsInPrompt     = 2
+	defaultMaxDocumentsInPrompt = 2
+	documentExcerptRunes        = 600
 )
 
+// DefaultInstructions tells the model how to answer when Options.Instructions is empty.
+const DefaultInstructions = "Jawab dengan ringkas dan ramah dalam bahasa Indonesia. Gunakan hanya informasi yang tersedia di atas."
+
 func maxFromEnv(key string, fallback int) int {
 	if fallback <= 0 {
 		fallback = 1
@@ -28,13 +34,52 @@ func maxFromEnv(key string, fallback int) int {
 	return fallback
 }
 
+// Turn captures a previous exchange within the same chat session.
+type Turn struct {
+	Question string
+	Answer   string
+}
+
+// Options tunes how Assemble spends the context budget.
+type Options struct {
+	// TokenBudget caps the estimated prompt size. Zero uses PROMPT_TOKEN_BUDGET or the default.
+	TokenBudget int
+	// Priority orders sections for allocation. Empty uses PROMPT_SECTION_PRIORITY or DefaultPriority.
+	Priority []Section
+	// History holds earlier turns of the conversation, oldest first.
+	History []Turn
+	// Instructions replaces the default answering instructions in the footer. The
+	// citation instruction is still appended when entries can be cited.
+	Instructions string
+}
+
+// Result is an assembled prompt together with its budgeting report.
+type Result struct {
+	Text            string
+	EstimatedTokens int
+	Budget          int
+	Sections        []SectionReport
+	Dropped         []Section
+	// References lists the knowledge base entries that made it into the prompt, in
+	// prompt order. Answers may only cite these.
+	References []Reference
+}
+
 // BuildPrompt creates a grounded single-message prompt suitable for Gemini style inputs.
 // Empty or whitespace questions will return an error about invalid input.
 func BuildPrompt(base kb.KnowledgeBase, question string) string {
+	return Assemble(base, question, Options{}).Text
+}
+
+// Assemble builds the grounded prompt while keeping it within the token budget.
+// Sections are filled in priority order; entries that do not fit are trimmed on
+// sentence or word boundaries, and sections that cannot fit at all are reported as dropped.
+func Assemble(base kb.KnowledgeBase, question string, opts Options) Result {
 	// Validate and sanitize input
 	question = strings.TrimSpace(question)
 	if question == "" {
-		return "Mohon maaf, saya tidak dapat memproses pertanyaan kosong. Silakan ajukan pertanyaan Anda."
+		text := "Mohon maaf, saya tidak dapat memproses pertanyaan kosong. Silakan ajukan pertanyaan Anda."
+		return Result{Text: text, EstimatedTokens: EstimateTokens(text)}
 	}
 
 	questionLower := strings.ToLower(question)
@@ -44,104 +89,145 @@ func BuildPrompt(base kb.KnowledgeBase, question string) string {
 		question = "Perkenalkan diri Anda dan layanan yang tersedia"
 		questionLower = strings.ToLower(question)
 	}
-	var builder strings.Builder
-	profile := base.Profile
 
-	var maxLen int
-	if strings.Contains(questionLower, "layanan") {
-		maxLen = 800 // Lebih banyak ruang untuk informasi layanan
-	} else if strings.Contains(questionLower, "proyek") || strings.Contains(questionLower, "portfolio") {
-		maxLen = 600 // Lebih banyak ruang untuk informasi proyek
-	} else {
-		maxLen = 400 // Default untuk pertanyaan umum
+	budget := opts.TokenBudget
+	if budget <= 0 {
+		budget = maxFromEnv("PROMPT_TOKEN_BUDGET", defaultTokenBudget)
 	}
-
-	// Start with the question
-	builder.WriteString("Pertanyaan: ")
-	if len(question) > 100 {
-		builder.WriteString(question[:97] + "...")
-	} else {
-		builder.WriteString(question)
+	priority := normalizePriority(opts.Priority)
+	if len(opts.Priority) == 0 {
+		priority = priorityFromEnv("PROMPT_SECTION_PRIORITY", DefaultPriority)
 	}
-	builder.WriteString("\n\n")
 
-	// Add context header
-	builder.WriteString("Anda adalah asisten virtual untuk ")
+	profile := base.Profile
+
+	var header strings.Builder
+	header.WriteString("Pertanyaan: ")
+	header.WriteString(TruncateRunes(question, 100))
+	header.WriteString("\n\n")
+	header.WriteString("Anda adalah asisten virtual untuk ")
 	if profile.Name != "" {
-		builder.WriteString(profile.Name)
+		header.WriteString(profile.Name)
 	} else {
-		builder.WriteString("tany.ai")
+		header.WriteString("tany.ai")
+	}
+	header.WriteString(". Jawab menggunakan informasi berikut.\n\n")
+
+	blocks := map[Section]sectionBlock{
+		SectionProfile:   profileBlock(profile),
+		SectionServices:  servicesBlock(base.Services, questionLower),
+		SectionProjects:  projectsBlock(base.Projects, questionLower),
+		SectionDocuments: documentsBlock(base.Documents, questionLower),
+		SectionPosts:     postsBlock(base.Posts),
+		SectionHistory:   historyBlock(opts.History),
+	}
+
+	instruction := DefaultInstructions
+	if custom := strings.TrimSpace(opts.Instructions); custom != "" {
+		instruction = custom
 	}
-	builder.WriteString(". Jawab menggunakan informasi berikut.\n\n")
+	if hasReferences(blocks) {
+		instruction += " Kutip sumber dengan ID-nya, mis. [prj:1a2b3c]; jangan mengarang ID."
+	}
+	footer := "\nInstruksi: " + instruction + "\n\n" +
+		"Berikan jawaban untuk: " + question
+
+	remaining := budget - EstimateTokens(header.String()) - EstimateTokens(footer)
+	allocations, _ := allocate(blocks, priority, remaining)
 
-	if profile.Title != "" || profile.Location != "" || profile.Bio != "" {
-		builder.WriteString("Profil singkat:\n")
-		if profile.Title != "" {
-			builder.WriteString(fmt.Sprintf("- Peran: %s\n", profile.Title))
+	var builder strings.Builder
+	builder.WriteString(header.String())
+	result := Result{Budget: budget}
+	for _, section := range renderOrder {
+		alloc, ok := allocations[section]
+		if !ok {
+			continue
 		}
-		if profile.Location != "" {
-			builder.WriteString(fmt.Sprintf("- Lokasi: %s\n", profile.Location))
+		result.Sections = append(result.Sections, alloc.report)
+		if alloc.report.Dropped {
+			result.Dropped = append(result.Dropped, section)
+			continue
 		}
-		if profile.Bio != "" {
-			bio := profile.Bio
-			if len(bio) > 200 {
-				bio = bio[:197] + "..."
+		block := blocks[section]
+		builder.WriteString(block.heading)
+		builder.WriteString("\n")
+		for i, entry := range alloc.entries {
+			builder.WriteString(entry)
+			builder.WriteString("\n")
+			if i < len(block.refs) {
+				result.References = append(result.References, block.refs[i])
 			}
-			builder.WriteString(fmt.Sprintf("- Bio: %s\n", bio))
 		}
 		builder.WriteString("\n")
 	}
+	builder.WriteString(footer)
+
+	result.Text = builder.String()
+	result.EstimatedTokens = EstimateTokens(result.Text)
+	return result
+}
+
+func profileBlock(profile kb.Profile) sectionBlock {
+	block := sectionBlock{section: SectionProfile, heading: "Profil singkat:"}
+	if profile.Title != "" {
+		block.entries = append(block.entries, fmt.Sprintf("- Peran: %s", profile.Title))
+	}
+	if profile.Location != "" {
+		block.entries = append(block.entries, fmt.Sprintf("- Lokasi: %s", profile.Location))
+	}
+	if profile.Bio != "" {
+		block.entries = append(block.entries, fmt.Sprintf("- Bio: %s", TruncateRunes(profile.Bio, 200)))
+	}
+	return block
+}
 
+func servicesBlock(all []kb.Service, questionLower string) sectionBlock {
 	maxServicesAllowed := maxFromEnv("PROMPT_MAX_SERVICES", defaultMaxServicesInPrompt)
 	serviceLimit := maxServicesAllowed
-	if serviceLimit <= 0 {
-		serviceLimit = defaultMaxServicesInPrompt
-	}
 	serviceFocused := strings.Contains(questionLower, "layanan") ||
 		strings.Contains(questionLower, "jasa") ||
 		strings.Contains(questionLower, "service")
-	if !serviceFocused && serviceLimit > 1 {
-		serviceLimit = 1
-	}
-	services := topServices(base.Services, serviceLimit)
-	if len(services) > 0 {
-		builder.WriteString("Layanan prioritas:\n")
-		for _, service := range services {
-			line := fmt.Sprintf("- %s", service.Name)
-			details := make([]string, 0, 3)
-			if service.Description != "" {
-				desc := service.Description
-				if len(desc) > 100 {
-					desc = desc[:97] + "..."
-				}
-				details = append(details, desc)
-			}
-			if len(service.PriceRange) > 0 {
-				currency := service.Currency
-				if currency == "" {
-					currency = "IDR"
-				}
-				details = append(details, fmt.Sprintf("Harga %s %s", currency, strings.Join(service.PriceRange, " – ")))
-			}
-			if service.DurationLabel != "" {
-				details = append(details, fmt.Sprintf("Durasi %s", service.DurationLabel))
-			}
-			if len(details) > 0 {
-				line += " — " + strings.Join(details, "; ")
-			}
-			if len(line) > 200 {
-				line = line[:197] + "..."
+
+	matched := matchServices(all, questionLower)
+	if !serviceFocused {
+		serviceLimit = len(matched)
+		if serviceLimit < 1 {
+			serviceLimit = 1
+		}
+		if serviceLimit > maxServicesAllowed {
+			serviceLimit = maxServicesAllowed
+		}
+	}
+
+	block := sectionBlock{section: SectionServices, heading: "Layanan prioritas:"}
+	for _, service := range rankServices(all, matched, serviceLimit) {
+		ref := newReference(KindService, service.ID, service.Name, "")
+		line := fmt.Sprintf("- %s %s", ref.tag(), service.Name)
+		details := make([]string, 0, 3)
+		if service.Description != "" {
+			details = append(details, TruncateRunes(service.Description, 100))
+		}
+		if len(service.PriceRange) > 0 {
+			currency := service.Currency
+			if currency == "" {
+				currency = "IDR"
 			}
-			builder.WriteString(line)
-			builder.WriteString("\n")
+			details = append(details, fmt.Sprintf("Harga %s %s", currency, strings.Join(service.PriceRange, " – ")))
 		}
-		builder.WriteString("\n")
+		if service.DurationLabel != "" {
+			details = append(details, fmt.Sprintf("Durasi %s", service.DurationLabel))
+		}
+		if len(details) > 0 {
+			line += " — " + strings.Join(details, "; ")
+		}
+		block.entries = append(block.entries, TruncateRunes(line, 200))
+		block.refs = append(block.refs, ref)
 	}
+	return block
+}
 
+func projectsBlock(all []kb.Project, questionLower string) sectionBlock {
 	maxProjectsAllowed := maxFromEnv("PROMPT_MAX_PROJECTS", defaultMaxProjectsInPrompt)
-	if maxProjectsAllowed <= 0 {
-		maxProjectsAllowed = defaultMaxProjectsInPrompt
-	}
 	projectFocused := strings.Contains(questionLower, "proyek") || strings.Contains(questionLower, "portfolio")
 	projectLimit := 1
 	if projectFocused {
@@ -149,146 +235,214 @@ func BuildPrompt(base kb.KnowledgeBase, question string) string {
 	} else if maxProjectsAllowed < projectLimit {
 		projectLimit = maxProjectsAllowed
 	}
-	projects := topProjects(base.Projects, projectLimit)
-	if len(projects) > 0 {
-		builder.WriteString("Portofolio unggulan:\n")
-		for _, project := range projects {
-			line := fmt.Sprintf("- %s", project.Title)
-			details := make([]string, 0, 3)
-			if project.Description != "" {
-				desc := project.Description
-				if len(desc) > 100 {
-					desc = desc[:97] + "..."
-				}
-				details = append(details, desc)
-			}
-			if len(project.TechStack) > 0 {
-				stack := project.TechStack
-				if len(stack) > 5 {
-					stack = stack[:5]
-				}
-				details = append(details, fmt.Sprintf("Tech: %s", strings.Join(stack, ", ")))
-			}
-			if project.ProjectURL != "" {
-				details = append(details, fmt.Sprintf("URL: %s", project.ProjectURL))
-			}
-			if len(details) > 0 {
-				line += " — " + strings.Join(details, "; ")
-			}
-			if len(line) > 200 {
-				line = line[:197] + "..."
+
+	block := sectionBlock{section: SectionProjects, heading: "Portofolio unggulan:"}
+	for _, project := range topProjects(all, projectLimit) {
+		ref := newReference(KindProject, project.ID, project.Title, project.ProjectURL)
+		line := fmt.Sprintf("- %s %s", ref.tag(), project.Title)
+		details := make([]string, 0, 3)
+		if project.Description != "" {
+			details = append(details, TruncateRunes(project.Description, 100))
+		}
+		if len(project.TechStack) > 0 {
+			stack := project.TechStack
+			if len(stack) > 5 {
+				stack = stack[:5]
 			}
-			builder.WriteString(line)
-			builder.WriteString("\n")
+			details = append(details, fmt.Sprintf("Tech: %s", strings.Join(stack, ", ")))
 		}
-		builder.WriteString("\n")
+		if project.ProjectURL != "" {
+			details = append(details, fmt.Sprintf("URL: %s", project.ProjectURL))
+		}
+		if len(details) > 0 {
+			line += " — " + strings.Join(details, "; ")
+		}
+		block.entries = append(block.entries, TruncateRunes(line, 200))
+		block.refs = append(block.refs, ref)
 	}
+	return block
+}
 
-	posts := topPosts(base.Posts, defaultMaxPostsInPrompt)
-	if len(posts) > 0 {
-		builder.WriteString("Update terbaru:\n")
-		for _, post := range posts {
-			line := fmt.Sprintf("- %s", post.Title)
-			details := make([]string, 0, 4)
-			if post.Source != "" {
-				details = append(details, fmt.Sprintf("Sumber %s", post.Source))
-			}
-			if !post.PublishedAt.IsZero() {
-				details = append(details, post.PublishedAt.Format("2006-01-02"))
-			}
-			if post.Summary != "" {
-				summary := post.Summary
-				if len(summary) > 120 {
-					summary = summary[:117] + "..."
-				}
-				details = append(details, summary)
-			}
-			if post.URL != "" {
-				details = append(details, fmt.Sprintf("URL: %s", post.URL))
-			}
-			if len(details) > 0 {
-				line += " — " + strings.Join(details, "; ")
-			}
-			if len(line) > 200 {
-				line = line[:197] + "..."
-			}
-			builder.WriteString(line)
-			builder.WriteString("\n")
+func postsBlock(all []kb.Post) sectionBlock {
+	block := sectionBlock{section: SectionPosts, heading: "Update terbaru:"}
+	for _, post := range topPosts(all, defaultMaxPostsInPrompt) {
+		ref := newReference(KindPost, post.ID, post.Title, post.URL)
+		line := fmt.Sprintf("- %s %s", ref.tag(), post.Title)
+		details := make([]string, 0, 4)
+		if post.Source != "" {
+			details = append(details, fmt.Sprintf("Sumber %s", post.Source))
 		}
-		builder.WriteString("\n")
+		if !post.PublishedAt.IsZero() {
+			details = append(details, post.PublishedAt.Format("2006-01-02"))
+		}
+		if post.Summary != "" {
+			details = append(details, TruncateRunes(post.Summary, 120))
+		}
+		if post.URL != "" {
+			details = append(details, fmt.Sprintf("URL: %s", post.URL))
+		}
+		if len(details) > 0 {
+			line += " — " + strings.Join(details, "; ")
+		}
+		block.entries = append(block.entries, TruncateRunes(line, 200))
+		block.refs = append(block.refs, ref)
 	}
+	return block
+}
 
-	// Add final instructions
-	builder.WriteString("\nInstruksi: Jawab dengan ringkas dan ramah dalam bahasa Indonesia. Gunakan hanya informasi yang tersedia di atas.\n\n")
-	builder.WriteString("Berikan jawaban untuk: ")
-	builder.WriteString(strings.TrimSpace(question))
-
-	// Get final text and ensure it's complete
-	result := builder.String()
-
-	// If we need to trim, do it intelligently to keep important parts
-	if len(result) > maxLen {
-		const summaryFormat = `Anda adalah %s, %s yang berlokasi di %s.
-
-%s
-
-%s
-
-Berikan jawaban untuk: %s`
-
-		// Get core information
-		role := "Full Stack Developer & AI Consultant"
-		if profile.Title != "" {
-			role = profile.Title
+// documentsBlock quotes the uploaded document chunks that share keywords with the
+// question. The title and URL come first so trimming under a tight budget only shortens
+// the excerpt, never the reference.
+func documentsBlock(all []kb.Document, questionLower string) sectionBlock {
+	block := sectionBlock{section: SectionDocuments, heading: "Kutipan dokumen:"}
+	limit := maxFromEnv("PROMPT_MAX_DOCUMENTS", defaultMaxDocumentsInPrompt)
+	for _, doc := range matchDocuments(all, questionLower, limit) {
+		ref := newReference(KindDocument, doc.ID, doc.Title, doc.URL)
+		line := fmt.Sprintf("- %s %s", ref.tag(), doc.Title)
+		if doc.Chunks > 1 {
+			line += fmt.Sprintf(", bagian %d/%d", doc.Chunk, doc.Chunks)
+		}
+		if doc.URL != "" {
+			line += fmt.Sprintf(" (URL: %s)", doc.URL)
 		}
+		line += ": " + TruncateRunes(doc.Content, documentExcerptRunes)
+		block.entries = append(block.entries, line)
+		block.refs = append(block.refs, ref)
+	}
+	return block
+}
 
-		location := "Indonesia"
-		if profile.Location != "" {
-			location = profile.Location
+// hasReferences reports whether any block carries citable entries.
+func hasReferences(blocks map[Section]sectionBlock) bool {
+	for _, block := range blocks {
+		if len(block.refs) > 0 {
+			return true
 		}
+	}
+	return false
+}
 
-		// Get top service
-		serviceDesc := "Tidak ada informasi layanan"
-		if len(services) > 0 {
-			details := make([]string, 0, 2)
-
-			if len(services[0].PriceRange) > 0 {
-				currency := services[0].Currency
-				if currency == "" {
-					currency = "IDR"
-				}
-				priceRange := strings.Join(services[0].PriceRange, " - ")
-				priceRange = strings.ReplaceAll(priceRange, "IDR ", "") // Remove duplicate IDR
-				details = append(details, fmt.Sprintf("Harga %s %s", currency, priceRange))
-			}
+func historyBlock(history []Turn) sectionBlock {
+	block := sectionBlock{section: SectionHistory, heading: "Percakapan sebelumnya:"}
+	// Newest turns are the most relevant, so they are offered to the budget first.
+	for i := len(history) - 1; i >= 0; i-- {
+		turn := history[i]
+		question := strings.TrimSpace(turn.Question)
+		if question == "" {
+			continue
+		}
+		entry := fmt.Sprintf("- Pengunjung: %s", TruncateRunes(question, 160))
+		if answer := strings.TrimSpace(turn.Answer); answer != "" {
+			entry += fmt.Sprintf(" | Asisten: %s", TruncateRunes(answer, 240))
+		}
+		block.entries = append(block.entries, entry)
+	}
+	return block
+}
 
-			if services[0].DurationLabel != "" {
-				details = append(details, fmt.Sprintf("Durasi %s", services[0].DurationLabel))
-			}
+// matchServices returns services whose name or description shares a keyword with the question.
+func matchServices(services []kb.Service, questionLower string) map[string]int {
+	keywords := questionKeywords(questionLower)
+	matched := make(map[string]int)
+	for i, service := range services {
+		if score := keywordScore(service.Name+" "+service.Description, keywords); score > 0 {
+			matched[serviceKey(service, i)] = score
+		}
+	}
+	return matched
+}
 
-			detailStr := ""
-			if len(details) > 0 {
-				detailStr = fmt.Sprintf(" (%s)", strings.Join(details, ", "))
-			}
+// matchDocuments returns up to limit document chunks sharing keywords with the question,
+// best matches first. Unrelated chunks are never included.
+func matchDocuments(docs []kb.Document, questionLower string, limit int) []kb.Document {
+	keywords := questionKeywords(questionLower)
+	if len(keywords) == 0 || limit <= 0 {
+		return nil
+	}
+	type ranked struct {
+		doc   kb.Document
+		score int
+	}
+	items := make([]ranked, 0)
+	for _, doc := range docs {
+		if score := keywordScore(doc.Title+" "+doc.Content, keywords); score > 0 {
+			items = append(items, ranked{doc: doc, score: score})
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].score > items[j].score
+	})
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	result := make([]kb.Document, len(items))
+	for i, item := range items {
+		result[i] = item.doc
+	}
+	return result
+}
 
-			serviceDesc = fmt.Sprintf("Layanan utama:\n- %s%s",
-				services[0].Name,
-				detailStr)
+// questionKeywords splits the question into words of at least four runes.
+func questionKeywords(questionLower string) []string {
+	keywords := make([]string, 0)
+	for _, word := range strings.FieldsFunc(questionLower, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(word)) >= 4 {
+			keywords = append(keywords, word)
 		}
+	}
+	return keywords
+}
 
-		// Format the shortened version
-		return fmt.Sprintf(summaryFormat,
-			profile.Name,
-			role,
-			location,
-			serviceDesc,
-			"Instruksi: Jawab dengan ringkas dan ramah dalam bahasa Indonesia. Gunakan hanya informasi yang tersedia di atas.",
-			strings.TrimSpace(question))
+// keywordScore counts the keywords found in text.
+func keywordScore(text string, keywords []string) int {
+	haystack := strings.ToLower(text)
+	score := 0
+	for _, keyword := range keywords {
+		if strings.Contains(haystack, keyword) {
+			score++
+		}
 	}
+	return score
+}
 
+// rankServices orders matched services first (by score) and then by configured order.
+func rankServices(services []kb.Service, matched map[string]int, limit int) []kb.Service {
+	if len(services) == 0 || limit <= 0 {
+		return nil
+	}
+	type ranked struct {
+		service kb.Service
+		score   int
+	}
+	items := make([]ranked, len(services))
+	for i, service := range services {
+		items[i] = ranked{service: service, score: matched[serviceKey(service, i)]}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].score != items[j].score {
+			return items[i].score > items[j].score
+		}
+		return items[i].service.Order < items[j].service.Order
+	})
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	result := make([]kb.Service, len(items))
+	for i, item := range items {
+		result[i] = item.service
+	}
 	return result
 }
 
+func serviceKey(service kb.Service, index int) string {
+	if service.ID != "" {
+		return service.ID
+	}
+	return strconv.Itoa(index) + ":" + service.Name
+}
+
 func topServices(services []kb.Service, limit int) []kb.Service {
 	if len(services) == 0 {
 		return nil
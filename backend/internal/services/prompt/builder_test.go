@@ -58,3 +58,39 @@ func TestSummarizeForHumanReferencesFeaturedProject(t *testing.T) {
 		t.Fatalf("expected latest post mention in summary")
 	}
 }
+
+func TestBuildPromptQuotesMatchingDocuments(t *testing.T) {
+	base := sampleBase()
+	base.Documents = []kb.Document{
+		{ID: "1", DocumentID: "d1", Title: "Daftar harga", Content: "Paket maintenance bulanan mulai 3 juta per bulan.", URL: "https://cdn/documents/harga.pdf#chunk-2", Chunk: 2, Chunks: 3},
+		{ID: "2", DocumentID: "d2", Title: "CV", Content: "Pengalaman sepuluh tahun di fintech.", URL: "https://cdn/documents/cv.pdf#chunk-1", Chunk: 1, Chunks: 1},
+	}
+
+	prompt := BuildPrompt(base, "Berapa biaya maintenance bulanan?")
+	if !strings.Contains(prompt, "Kutipan dokumen:") {
+		t.Fatalf("prompt should include a documents section:\n%s", prompt)
+	}
+	if !strings.Contains(prompt, "] Daftar harga, bagian 2/3 (URL: https://cdn/documents/harga.pdf#chunk-2): Paket maintenance bulanan") {
+		t.Fatalf("prompt should quote the matching chunk with its reference:\n%s", prompt)
+	}
+	if strings.Contains(prompt, "fintech") {
+		t.Fatalf("prompt should skip unrelated documents:\n%s", prompt)
+	}
+
+	if prompt := BuildPrompt(base, "Halo apa kabar?"); strings.Contains(prompt, "Kutipan dokumen:") {
+		t.Fatalf("documents should only be quoted when they match the question:\n%s", prompt)
+	}
+}
+
+func TestAssembleUsesCustomInstructions(t *testing.T) {
+	result := Assemble(sampleBase(), "Apa layananmu?", Options{TokenBudget: 2000, Instructions: "Answer in English, in one sentence."})
+	if !strings.Contains(result.Text, "Instruksi: Answer in English, in one sentence.") {
+		t.Fatalf("custom instructions should replace the default:\n%s", result.Text)
+	}
+	if strings.Contains(result.Text, DefaultInstructions) {
+		t.Fatalf("default instructions should not remain")
+	}
+	if !strings.Contains(result.Text, "jangan mengarang ID") {
+		t.Fatalf("citation instruction should still be appended")
+	}
+}
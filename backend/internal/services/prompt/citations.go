@@ -0,0 +1,138 @@
+package prompt
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+// Kinds of knowledge base entries that can be cited.
+const (
+	KindService  = "service"
+	KindProject  = "project"
+	KindPost     = "post"
+	KindDocument = "document"
+)
+
+var refPrefixes = map[string]string{
+	KindService:  "svc",
+	KindProject:  "prj",
+	KindPost:     "post",
+	KindDocument: "doc",
+}
+
+// Reference is a knowledge base entry included in a prompt under a citable ID.
+type Reference struct {
+	ID    string `json:"id"`
+	Kind  string `json:"kind"`
+	Title string `json:"title"`
+	URL   string `json:"url,omitempty"`
+}
+
+// newReference derives the reference ID from the entry's own ID, falling back to its
+// title, so an entry keeps the same reference across prompts while the knowledge base
+// is unchanged.
+func newReference(kind, id, title, url string) Reference {
+	key := id
+	if key == "" {
+		key = title
+	}
+	sum := sha256.Sum256([]byte(kind + ":" + key))
+	return Reference{
+		ID:    refPrefixes[kind] + ":" + hex.EncodeToString(sum[:3]),
+		Kind:  kind,
+		Title: title,
+		URL:   url,
+	}
+}
+
+// tag renders the reference marker placed at the start of an entry and in answers.
+func (r Reference) tag() string {
+	return "[" + r.ID + "]"
+}
+
+var (
+	citationGroup = regexp.MustCompile(`\[([^\[\]\n]{1,120})\]`)
+	citationToken = regexp.MustCompile(`(?i)^(?:svc|prj|post|doc):[0-9a-z]+$`)
+)
+
+// Cite extracts the reference markers from an answer. Markers naming a reference of the
+// prompt are kept in the text and returned once each, in order of first appearance.
+// Markers that look like references but were not in the prompt are removed from the
+// text and reported as rejected, so a model cannot invent sources.
+func Cite(answer string, refs []Reference) (string, []Reference, []string) {
+	known := make(map[string]Reference, len(refs))
+	for _, ref := range refs {
+		known[ref.ID] = ref
+	}
+
+	citations := make([]Reference, 0)
+	var rejected []string
+	seen := make(map[string]bool)
+	text := citationGroup.ReplaceAllStringFunc(answer, func(group string) string {
+		tokens := strings.FieldsFunc(group[1:len(group)-1], func(r rune) bool {
+			return r == ',' || r == ';' || r == ' '
+		})
+		kept := make([]string, 0, len(tokens))
+		for _, token := range tokens {
+			if !citationToken.MatchString(token) {
+				// Ordinary bracketed prose is left untouched.
+				return group
+			}
+			ref, ok := known[strings.ToLower(token)]
+			if !ok {
+				rejected = append(rejected, token)
+				continue
+			}
+			kept = append(kept, ref.tag())
+			if !seen[ref.ID] {
+				seen[ref.ID] = true
+				citations = append(citations, ref)
+			}
+		}
+		if len(kept) == 0 {
+			return removedMarker
+		}
+		return strings.Join(kept, "")
+	})
+	if len(rejected) > 0 {
+		text = cleanupSpacing(text)
+	}
+	return text, citations, rejected
+}
+
+// removedMarker stands in for a marker that was dropped entirely until cleanupSpacing
+// closes the gap it leaves.
+const removedMarker = "\x00"
+
+var removedGap = regexp.MustCompile(`([ \t]*)\x00+([ \t]*)([.,;:!?]?)`)
+
+// cleanupSpacing tidies the gaps left behind by removed markers. Only the whitespace
+// touching a removal point is changed, so list markers, indentation and code blocks
+// elsewhere in the answer survive as written.
+func cleanupSpacing(text string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range removedGap.FindAllStringSubmatchIndex(text, -1) {
+		b.WriteString(text[last:m[0]])
+		last = m[1]
+		before, after, punct := text[m[2]:m[3]], text[m[4]:m[5]], text[m[6]:m[7]]
+		lineStart := m[0] == 0 || text[m[0]-1] == '\n'
+		switch {
+		case punct != "":
+			// "see [x]." becomes "see."
+			if lineStart {
+				b.WriteString(before)
+			}
+			b.WriteString(punct)
+		case lineStart:
+			// Keep the indentation, drop the gap after the marker.
+			b.WriteString(before)
+		case before+after != "" && m[1] < len(text) && text[m[1]] != '\n':
+			b.WriteString(" ")
+		}
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
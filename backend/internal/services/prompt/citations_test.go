@@ -0,0 +1,112 @@
+package prompt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tanydotai/tanyai/backend/internal/services/kb"
+)
+
+func TestAssembleTagsEntriesWithStableReferences(t *testing.T) {
+	base := sampleBase()
+	first := Assemble(base, "Apa proyek terbaru?", Options{TokenBudget: 2000})
+	second := Assemble(base, "Apa proyek terbaru?", Options{TokenBudget: 2000})
+
+	if len(first.References) == 0 {
+		t.Fatalf("expected references for included entries")
+	}
+	if len(first.References) != len(second.References) {
+		t.Fatalf("expected the same references across prompts")
+	}
+	for i, ref := range first.References {
+		if ref.ID != second.References[i].ID {
+			t.Fatalf("reference IDs must be stable, got %q and %q", ref.ID, second.References[i].ID)
+		}
+		if !strings.Contains(first.Text, "- ["+ref.ID+"] "+ref.Title) {
+			t.Fatalf("entry %q should be tagged in the prompt:\n%s", ref.Title, first.Text)
+		}
+	}
+
+	var project Reference
+	for _, ref := range first.References {
+		if ref.Kind == KindProject && project.ID == "" {
+			project = ref
+		}
+	}
+	if project.Title != "Project A" || !strings.HasPrefix(project.ID, "prj:") {
+		t.Fatalf("expected project reference, got %+v", project)
+	}
+	if !strings.Contains(first.Text, "jangan mengarang ID") {
+		t.Fatalf("prompt should instruct the model to cite references")
+	}
+}
+
+func TestAssembleOmitsCitationInstructionWithoutEntries(t *testing.T) {
+	result := Assemble(kb.KnowledgeBase{Profile: kb.Profile{Name: "Tanya", Title: "Engineer"}}, "Siapa kamu?", Options{TokenBudget: 2000})
+	if len(result.References) != 0 {
+		t.Fatalf("expected no references, got %+v", result.References)
+	}
+	if strings.Contains(result.Text, "jangan mengarang ID") {
+		t.Fatalf("citation instruction should only appear when entries can be cited")
+	}
+}
+
+func TestCiteKeepsKnownAndRejectsUnknownReferences(t *testing.T) {
+	refs := []Reference{
+		{ID: "prj:1a2b3c", Kind: KindProject, Title: "Project A", URL: "https://example.com/a"},
+		{ID: "svc:abcdef", Kind: KindService, Title: "Build"},
+	}
+	answer := "Saya membangun Project A [prj:1a2b3c] dan layanan Build [svc:abcdef, post:999999]. " +
+		"Lihat juga [doc:ffffff]. Catatan [lihat di atas] tetap utuh [PRJ:1A2B3C]."
+
+	text, citations, rejected := Cite(answer, refs)
+
+	if len(citations) != 2 || citations[0].ID != "prj:1a2b3c" || citations[1].ID != "svc:abcdef" {
+		t.Fatalf("unexpected citations %+v", citations)
+	}
+	if citations[0].URL != "https://example.com/a" {
+		t.Fatalf("citation should carry the entry URL, got %+v", citations[0])
+	}
+	if len(rejected) != 2 || rejected[0] != "post:999999" || rejected[1] != "doc:ffffff" {
+		t.Fatalf("unexpected rejected IDs %v", rejected)
+	}
+	if strings.Contains(text, "post:999999") || strings.Contains(text, "doc:ffffff") {
+		t.Fatalf("unknown references should be removed from the answer: %q", text)
+	}
+	if !strings.Contains(text, "[svc:abcdef].") || !strings.Contains(text, "Lihat juga.") {
+		t.Fatalf("answer should be tidied after removing references: %q", text)
+	}
+	if !strings.Contains(text, "[lihat di atas]") {
+		t.Fatalf("ordinary brackets should be kept: %q", text)
+	}
+	if !strings.HasSuffix(text, "[prj:1a2b3c].") {
+		t.Fatalf("references should be normalised to their canonical form: %q", text)
+	}
+}
+
+func TestCiteKeepsFormattingAwayFromRemovedReferences(t *testing.T) {
+	refs := []Reference{{ID: "svc:abcdef", Kind: KindService, Title: "Build"}}
+	answer := "Langkah:\n\n- Audit  awal [svc:abcdef]\n- Desain [doc:ffffff]\n  - Wireframe [post:999999] dan prototipe\n\n" +
+		"```go\nfunc main() {\n\tfmt.Println(\"a  b\")\n}\n```\n[prj:000000] Selesai [doc:ffffff]."
+
+	text, _, rejected := Cite(answer, refs)
+
+	if len(rejected) != 4 {
+		t.Fatalf("unexpected rejected IDs %v", rejected)
+	}
+	want := "Langkah:\n\n- Audit  awal [svc:abcdef]\n- Desain\n  - Wireframe dan prototipe\n\n" +
+		"```go\nfunc main() {\n\tfmt.Println(\"a  b\")\n}\n```\nSelesai."
+	if text != want {
+		t.Fatalf("only the gaps left by removed references should change:\n got %q\nwant %q", text, want)
+	}
+}
+
+func TestCiteWithoutReferences(t *testing.T) {
+	text, citations, rejected := Cite("Halo! Ada yang bisa dibantu?", nil)
+	if text != "Halo! Ada yang bisa dibantu?" || len(citations) != 0 || len(rejected) != 0 {
+		t.Fatalf("unexpected result %q %+v %v", text, citations, rejected)
+	}
+	if citations == nil {
+		t.Fatalf("citations should be an empty slice so it encodes as []")
+	}
+}
@@ -13,6 +13,8 @@ func New(cfg config.StorageConfig) (ObjectStorage, error) {
 		return NewSupabaseStorage(cfg.Supabase)
 	case config.StorageDriverS3:
 		return NewS3Storage(cfg.S3)
+	case config.StorageDriverLocal:
+		return NewLocalStorage(cfg.Local)
 	default:
 		return nil, fmt.Errorf("storage: unsupported driver %q", cfg.Driver)
 	}
@@ -0,0 +1,315 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tanydotai/tanyai/backend/internal/config"
+)
+
+const maxKeyLength = 512
+
+var (
+	// ErrInvalidSignature is returned when a signed URL does not match its key.
+	ErrInvalidSignature = errors.New("storage: invalid signature")
+	// ErrURLExpired is returned when a signed URL is used after its expiry.
+	ErrURLExpired = errors.New("storage: signed url expired")
+)
+
+// LocalStorage stores files below a directory on the local filesystem. Files are served
+// back by the API; with a signing key, the serving route only accepts URLs carrying an
+// expiring HMAC. Put always returns the stable, unsigned URL so it can be stored, and
+// SignURL signs it whenever it is handed to a client.
+type LocalStorage struct {
+	dir         string
+	publicBase  string
+	signingKey  []byte
+	urlTTL      time.Duration
+	cacheMaxAge time.Duration
+	now         func() time.Time
+}
+
+// NewLocalStorage creates a LocalStorage, creating its directory when missing.
+func NewLocalStorage(cfg config.LocalStorageConfig) (*LocalStorage, error) {
+	if cfg.Dir == "" || cfg.PublicURL == "" {
+		return nil, fmt.Errorf("storage: incomplete local configuration")
+	}
+	dir, err := filepath.Abs(cfg.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("storage: resolve local dir: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("storage: create local dir: %w", err)
+	}
+	return &LocalStorage{
+		dir:         dir,
+		publicBase:  strings.TrimSuffix(cfg.PublicURL, "/"),
+		signingKey:  []byte(cfg.SigningKey),
+		urlTTL:      cfg.URLTTL,
+		cacheMaxAge: cfg.CacheMaxAge,
+		now:         time.Now,
+	}, nil
+}
+
+// Put writes content to disk and returns its stable URL. The file is written to a
+// temporary name first so readers never see partial content.
+func (s *LocalStorage) Put(_ context.Context, key string, content []byte, _ string) (string, error) {
+	if len(content) == 0 {
+		return "", fmt.Errorf("storage: empty content")
+	}
+	target, err := s.path(key)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return "", fmt.Errorf("storage: create local dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return "", fmt.Errorf("storage: local write failed: %w", err)
+	}
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("storage: local write failed: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("storage: local write failed: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("storage: local write failed: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("storage: local write failed: %w", err)
+	}
+	return s.publicBase + "/" + escapeKey(key), nil
+}
+
+// Open returns the file stored under key. Callers must close it.
+func (s *LocalStorage) Open(key string) (*os.File, os.FileInfo, error) {
+	target, err := s.path(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := os.Open(target)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	if !info.Mode().IsRegular() {
+		file.Close()
+		return nil, nil, ErrNotFound
+	}
+	return file, info, nil
+}
+
+// SigningRequired reports whether files may only be served through signed URLs.
+func (s *LocalStorage) SigningRequired() bool {
+	return len(s.signingKey) > 0
+}
+
+// CacheMaxAge is how long clients may cache unsigned files.
+func (s *LocalStorage) CacheMaxAge() time.Duration {
+	return s.cacheMaxAge
+}
+
+// SignedURL returns a URL for key that stays valid for ttl.
+func (s *LocalStorage) SignedURL(key string, ttl time.Duration) (string, error) {
+	if !s.SigningRequired() {
+		return "", errors.New("storage: local signing key not configured")
+	}
+	if err := ValidateKey(key); err != nil {
+		return "", err
+	}
+	return s.signedURL(key, s.now().Add(ttl)), nil
+}
+
+func (s *LocalStorage) signedURL(key string, expiry time.Time) string {
+	expires := strconv.FormatInt(expiry.Unix(), 10)
+	query := url.Values{"expires": {expires}, "signature": {s.sign(key, expires)}}
+	return s.publicBase + "/" + escapeKey(key) + "?" + query.Encode()
+}
+
+// SignURL signs a stable URL returned by Put. URLs of other origins, and every URL when
+// signing is off, are returned unchanged. The expiry is counted from the start of the
+// current half TTL step, so a link stays valid for at least half the TTL and repeated
+// reads produce the same URL, which keeps response ETags stable.
+func (s *LocalStorage) SignURL(raw string) string {
+	key, fragment, ok := s.keyFromURL(raw)
+	if !ok || !s.SigningRequired() {
+		return raw
+	}
+	start := s.now()
+	if step := s.urlTTL / 2; step > 0 {
+		start = start.Truncate(step)
+	}
+	return s.signedURL(key, start.Add(s.urlTTL)) + fragment
+}
+
+// StableURL removes the signature SignURL added, so URLs sent back by clients are stored
+// in their stable form.
+func (s *LocalStorage) StableURL(raw string) string {
+	key, fragment, ok := s.keyFromURL(raw)
+	if !ok {
+		return raw
+	}
+	return s.publicBase + "/" + escapeKey(key) + fragment
+}
+
+// keyFromURL returns the key addressed by a URL below the public base and its fragment,
+// including the "#", such as the "#chunk-N" anchors of document citations. The fragment
+// is not part of the key and has to follow any query string added when signing.
+func (s *LocalStorage) keyFromURL(raw string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(raw, s.publicBase+"/")
+	if !ok {
+		return "", "", false
+	}
+	fragment := ""
+	if i := strings.IndexByte(rest, '#'); i >= 0 {
+		rest, fragment = rest[:i], rest[i:]
+	}
+	rest, _, _ = strings.Cut(rest, "?")
+	key, err := url.PathUnescape(rest)
+	if err != nil || ValidateKey(key) != nil {
+		return "", "", false
+	}
+	return key, fragment, true
+}
+
+// Verify checks the expiry and signature query parameters of a signed URL and returns
+// the time at which it expires.
+func (s *LocalStorage) Verify(key, expires, signature string) (time.Time, error) {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || signature == "" {
+		return time.Time{}, ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.sign(key, expires))) {
+		return time.Time{}, ErrInvalidSignature
+	}
+	expiry := time.Unix(unix, 0)
+	if !s.now().Before(expiry) {
+		return time.Time{}, ErrURLExpired
+	}
+	return expiry, nil
+}
+
+func (s *LocalStorage) sign(key, expires string) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(key))
+	mac.Write([]byte{'\n'})
+	mac.Write([]byte(expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s *LocalStorage) path(key string) (string, error) {
+	if err := ValidateKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// ValidateKey rejects keys that are not clean, relative, slash separated paths made of
+// printable characters, so a key can never address a file outside the storage root.
+func ValidateKey(key string) error {
+	if key == "" || len(key) > maxKeyLength || strings.HasPrefix(key, "/") || path.Clean(key) != key {
+		return ErrInvalidKey
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "." || segment == ".." || strings.HasPrefix(segment, ".") {
+			return ErrInvalidKey
+		}
+	}
+	for _, r := range key {
+		if r < 0x20 || r == 0x7f || r == '\\' || r == ':' {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
+
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// Delete removes the file stored under key.
+func (s *LocalStorage) Delete(_ context.Context, key string) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("storage: local delete failed: %w", err)
+	}
+	return nil
+}
+
+// List walks the storage directory and returns the files whose key starts with prefix.
+func (s *LocalStorage) List(_ context.Context, prefix string) ([]ObjectInfo, error) {
+	objects := []ObjectInfo{}
+	err := filepath.WalkDir(s.dir, func(current string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(s.dir, current)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if entry.IsDir() {
+			// Skip directories that cannot contain matching keys.
+			if key != "." && !strings.HasPrefix(key+"/", prefix) && !strings.HasPrefix(prefix, key+"/") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() || !strings.HasPrefix(key, prefix) || ValidateKey(key) != nil {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, ObjectInfo{Key: key, Size: info.Size(), ContentType: contentTypeFor(key), LastModified: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("storage: local list failed: %w", err)
+	}
+	return objects, nil
+}
+
+// Stat describes the file stored under key.
+func (s *LocalStorage) Stat(_ context.Context, key string) (ObjectInfo, error) {
+	file, info, err := s.Open(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	file.Close()
+	return ObjectInfo{Key: key, Size: info.Size(), ContentType: contentTypeFor(key), LastModified: info.ModTime()}, nil
+}
@@ -0,0 +1,165 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tanydotai/tanyai/backend/internal/config"
+)
+
+func newLocal(t *testing.T, signingKey string) *LocalStorage {
+	t.Helper()
+	store, err := NewLocalStorage(config.LocalStorageConfig{
+		Dir:        t.TempDir(),
+		PublicURL:  "http://localhost:8080/api/v1/files/",
+		SigningKey: signingKey,
+		URLTTL:     time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("new local storage: %v", err)
+	}
+	return store
+}
+
+func TestLocalStoragePutAndOpen(t *testing.T) {
+	store := newLocal(t, "")
+
+	publicURL, err := store.Put(context.Background(), "uploads/2025/02/10/a b.png", []byte("png"), "image/png")
+	if err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if publicURL != "http://localhost:8080/api/v1/files/uploads/2025/02/10/a%20b.png" {
+		t.Fatalf("unexpected url %s", publicURL)
+	}
+
+	file, info, err := store.Open("uploads/2025/02/10/a b.png")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer file.Close()
+	content, _ := io.ReadAll(file)
+	if string(content) != "png" || info.Size() != 3 {
+		t.Fatalf("unexpected content %q", content)
+	}
+
+	entries, _ := os.ReadDir(filepath.Join(store.dir, "uploads/2025/02/10"))
+	if len(entries) != 1 {
+		t.Fatalf("expected temporary files to be cleaned up, got %d entries", len(entries))
+	}
+	if _, _, err := store.Open("uploads/missing.png"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if _, _, err := store.Open("uploads"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected directories to be hidden, got %v", err)
+	}
+}
+
+func TestValidateKeyRejectsEscapes(t *testing.T) {
+	for _, key := range []string{
+		"", "/etc/passwd", "../secret", "uploads/../../secret", "uploads//a.png", "uploads/./a.png",
+		"uploads/a.png/", `uploads\..\a.png`, "uploads/.upload-123", "c:/windows", "uploads/a\x00.png",
+		strings.Repeat("a", maxKeyLength+1),
+	} {
+		if err := ValidateKey(key); !errors.Is(err, ErrInvalidKey) {
+			t.Fatalf("expected %q to be rejected, got %v", key, err)
+		}
+	}
+	if err := ValidateKey("uploads/2025/02/10/photo_thumb.webp"); err != nil {
+		t.Fatalf("expected valid key, got %v", err)
+	}
+
+	store := newLocal(t, "")
+	if _, err := store.Put(context.Background(), "../escape.png", []byte("x"), "image/png"); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("expected put to reject escaping key, got %v", err)
+	}
+}
+
+func TestLocalStorageSignedURLs(t *testing.T) {
+	store := newLocal(t, "a-signing-key-that-is-long-enough-1234")
+	now := time.Date(2025, 2, 10, 8, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	stable, err := store.Put(context.Background(), "uploads/a.png", []byte("png"), "image/png")
+	if err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if stable != "http://localhost:8080/api/v1/files/uploads/a.png" {
+		t.Fatalf("expected put to return the stable url, got %s", stable)
+	}
+	signed := store.SignURL(stable)
+	if store.StableURL(signed) != stable {
+		t.Fatalf("expected the signature to be removable, got %s", store.StableURL(signed))
+	}
+	if other := "https://cdn.example.com/a.png"; store.SignURL(other) != other {
+		t.Fatalf("expected foreign urls to be left alone")
+	}
+	later := now
+	now = now.Add(10 * time.Minute)
+	if again := store.SignURL(stable); again != signed {
+		t.Fatalf("expected reads within a step to share a url, got %s and %s", signed, again)
+	}
+	now = later
+	parsed, err := url.Parse(signed)
+	if err != nil {
+		t.Fatalf("parse url: %v", err)
+	}
+	query := parsed.Query()
+	expiry, err := store.Verify("uploads/a.png", query.Get("expires"), query.Get("signature"))
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if !expiry.Equal(now.Add(time.Hour)) {
+		t.Fatalf("unexpected expiry %s", expiry)
+	}
+
+	if _, err := store.Verify("uploads/b.png", query.Get("expires"), query.Get("signature")); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected signature bound to key, got %v", err)
+	}
+	if _, err := store.Verify("uploads/a.png", "9999999999", query.Get("signature")); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected signature bound to expiry, got %v", err)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, err := store.Verify("uploads/a.png", query.Get("expires"), query.Get("signature")); !errors.Is(err, ErrURLExpired) {
+		t.Fatalf("expected ErrURLExpired, got %v", err)
+	}
+}
+
+func TestLocalStorageListStatDelete(t *testing.T) {
+	store := newLocal(t, "")
+	ctx := context.Background()
+	for _, key := range []string{"uploads/2025/a.png", "uploads/2025/b.webp", "other/c.png"} {
+		if _, err := store.Put(ctx, key, []byte("data"), ""); err != nil {
+			t.Fatalf("put %s: %v", key, err)
+		}
+	}
+
+	objects, err := store.List(ctx, "uploads/")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(objects) != 2 || objects[0].Key != "uploads/2025/a.png" || objects[1].ContentType != "image/webp" {
+		t.Fatalf("unexpected objects %+v", objects)
+	}
+
+	info, err := store.Stat(ctx, "uploads/2025/a.png")
+	if err != nil || info.Size != 4 || info.ContentType != "image/png" {
+		t.Fatalf("unexpected stat %+v (%v)", info, err)
+	}
+	if err := store.Delete(ctx, "uploads/2025/a.png"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := store.Delete(ctx, "uploads/2025/a.png"); err != nil {
+		t.Fatalf("expected repeated delete to succeed, got %v", err)
+	}
+	if _, err := store.Stat(ctx, "uploads/2025/a.png"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// PresignedPut is a time-limited URL the client uploads one object to without passing
+// the bytes through the API.
+type PresignedPut struct {
+	URL    string
+	Method string
+	// Headers must be sent verbatim with the upload request.
+	Headers   map[string]string
+	ExpiresAt time.Time
+}
+
+// DirectUploader is implemented by drivers that accept uploads sent straight from the
+// client to the bucket.
+type DirectUploader interface {
+	// PresignPut returns an upload URL for key valid for roughly ttl. Drivers that cannot
+	// bind the size into the signature leave it to the caller to verify afterwards.
+	PresignPut(ctx context.Context, key, contentType string, size int64, ttl time.Duration) (PresignedPut, error)
+	// ReadPrefix returns up to n leading bytes of an object for content sniffing.
+	ReadPrefix(ctx context.Context, key string, n int64) ([]byte, error)
+	// Open streams an object so it can be scanned without holding it in memory. The
+	// caller closes the reader.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// PublicURL returns the URL an uploaded object is served from.
+	PublicURL(key string) string
+}
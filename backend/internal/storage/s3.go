@@ -3,13 +3,17 @@ package storage
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
 	"github.com/tanydotai/tanyai/backend/internal/config"
 )
 
@@ -74,6 +78,146 @@ func (s *S3Storage) Put(ctx context.Context, key string, content []byte, content
 	return s.publicURL(key), nil
 }
 
+// Delete removes an object. S3 treats deleting a missing key as success.
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("storage: s3 delete failed: %w", err)
+	}
+	return nil
+}
+
+// List returns every object whose key starts with prefix.
+func (s *S3Storage) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	objects := []ObjectInfo{}
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("storage: s3 list failed: %w", err)
+		}
+		for _, object := range page.Contents {
+			key := aws.ToString(object.Key)
+			objects = append(objects, ObjectInfo{
+				Key:          key,
+				Size:         object.Size,
+				ContentType:  contentTypeFor(key),
+				LastModified: aws.ToTime(object.LastModified),
+			})
+		}
+	}
+	return objects, nil
+}
+
+// Stat describes one object using HeadObject.
+func (s *S3Storage) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	out, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		if isS3NotFound(err) {
+			return ObjectInfo{}, ErrNotFound
+		}
+		return ObjectInfo{}, fmt.Errorf("storage: s3 stat failed: %w", err)
+	}
+	info := ObjectInfo{
+		Key:          key,
+		Size:         out.ContentLength,
+		ContentType:  aws.ToString(out.ContentType),
+		LastModified: aws.ToTime(out.LastModified),
+	}
+	if info.ContentType == "" {
+		info.ContentType = contentTypeFor(key)
+	}
+	return info, nil
+}
+
+// PresignPut signs a PutObject request. The content type and length are part of the
+// signature, so S3 rejects uploads that differ from what was presigned.
+func (s *S3Storage) PresignPut(ctx context.Context, key, contentType string, size int64, ttl time.Duration) (PresignedPut, error) {
+	presigned, err := s3.NewPresignClient(s.client).PresignPutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(key),
+		ContentType:   aws.String(contentType),
+		ContentLength: size,
+		ACL:           types.ObjectCannedACLPublicRead,
+	}, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return PresignedPut{}, fmt.Errorf("storage: s3 presign failed: %w", err)
+	}
+	headers := make(map[string]string, len(presigned.SignedHeader))
+	for name, values := range presigned.SignedHeader {
+		// Browsers set Host and Content-Length themselves and refuse to override them.
+		if strings.EqualFold(name, "Host") || strings.EqualFold(name, "Content-Length") || len(values) == 0 {
+			continue
+		}
+		headers[name] = values[0]
+	}
+	return PresignedPut{
+		URL:       presigned.URL,
+		Method:    presigned.Method,
+		Headers:   headers,
+		ExpiresAt: time.Now().Add(ttl).UTC(),
+	}, nil
+}
+
+// ReadPrefix fetches the first n bytes of an object with a ranged GetObject.
+func (s *S3Storage) ReadPrefix(ctx context.Context, key string, n int64) ([]byte, error) {
+	body, err := s.get(ctx, key, fmt.Sprintf("bytes=0-%d", n-1))
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	data, err := io.ReadAll(io.LimitReader(body, n))
+	if err != nil {
+		return nil, fmt.Errorf("storage: s3 read failed: %w", err)
+	}
+	return data, nil
+}
+
+// Open streams the whole object.
+func (s *S3Storage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	return s.get(ctx, key, "")
+}
+
+func (s *S3Storage) get(ctx context.Context, key, byteRange string) (io.ReadCloser, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	}
+	if byteRange != "" {
+		input.Range = aws.String(byteRange)
+	}
+	out, err := s.client.GetObject(ctx, input)
+	if err != nil {
+		if isS3NotFound(err) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("storage: s3 read failed: %w", err)
+	}
+	return out.Body, nil
+}
+
+// PublicURL returns the URL objects are served from.
+func (s *S3Storage) PublicURL(key string) string {
+	return s.publicURL(key)
+}
+
+func isS3NotFound(err error) bool {
+	var notFound *types.NotFound
+	var noSuchKey *types.NoSuchKey
+	var apiErr smithy.APIError
+	return errors.As(err, &notFound) || errors.As(err, &noSuchKey) ||
+		(errors.As(err, &apiErr) && (apiErr.ErrorCode() == "NotFound" || apiErr.ErrorCode() == "NoSuchKey"))
+}
+
 func (s *S3Storage) publicURL(key string) string {
 	cleaned := strings.TrimPrefix(key, "/")
 	if s.publicBase != "" {
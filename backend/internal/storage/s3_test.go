@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tanydotai/tanyai/backend/internal/config"
+)
+
+func newS3(t *testing.T, handler http.HandlerFunc) *S3Storage {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	store, err := NewS3Storage(config.S3Config{
+		Region:          "ap-southeast-1",
+		Bucket:          "media",
+		Endpoint:        server.URL,
+		AccessKeyID:     "key",
+		SecretAccessKey: "secret",
+	})
+	if err != nil {
+		t.Fatalf("new s3 storage: %v", err)
+	}
+	return store
+}
+
+func TestS3ListPaginates(t *testing.T) {
+	store := newS3(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/media" || r.URL.Query().Get("list-type") != "2" || r.URL.Query().Get("prefix") != "uploads/" {
+			t.Errorf("unexpected request %s", r.URL.String())
+		}
+		w.Header().Set("Content-Type", "application/xml")
+		if r.URL.Query().Get("continuation-token") == "" {
+			_, _ = w.Write([]byte(`<ListBucketResult><Name>media</Name><IsTruncated>true</IsTruncated><NextContinuationToken>next</NextContinuationToken>` +
+				`<Contents><Key>uploads/a.png</Key><LastModified>2025-02-10T08:00:00.000Z</LastModified><Size>3</Size></Contents></ListBucketResult>`))
+			return
+		}
+		_, _ = w.Write([]byte(`<ListBucketResult><Name>media</Name><IsTruncated>false</IsTruncated>` +
+			`<Contents><Key>uploads/b.webp</Key><LastModified>2025-02-11T08:00:00.000Z</LastModified><Size>7</Size></Contents></ListBucketResult>`))
+	})
+
+	objects, err := store.List(context.Background(), "uploads/")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(objects) != 2 {
+		t.Fatalf("expected 2 objects, got %+v", objects)
+	}
+	if objects[0].Key != "uploads/a.png" || objects[0].Size != 3 || objects[0].ContentType != "image/png" || objects[0].LastModified.IsZero() {
+		t.Fatalf("unexpected object %+v", objects[0])
+	}
+	if objects[1].Key != "uploads/b.webp" || objects[1].ContentType != "image/webp" {
+		t.Fatalf("unexpected object %+v", objects[1])
+	}
+}
+
+func TestS3DeleteAndStat(t *testing.T) {
+	var deleted string
+	store := newS3(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodDelete:
+			deleted = r.URL.Path
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodHead && r.URL.Path == "/media/uploads/a.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Header().Set("Content-Length", "42")
+			w.Header().Set("Last-Modified", "Mon, 10 Feb 2025 08:00:00 GMT")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	ctx := context.Background()
+
+	if err := store.Delete(ctx, "uploads/a.png"); err != nil || deleted != "/media/uploads/a.png" {
+		t.Fatalf("delete: %v (path %q)", err, deleted)
+	}
+	info, err := store.Stat(ctx, "uploads/a.png")
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if info.Size != 42 || info.ContentType != "image/png" || info.LastModified.IsZero() {
+		t.Fatalf("unexpected info %+v", info)
+	}
+	if _, err := store.Stat(ctx, "uploads/missing.png"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestS3PresignPutAndReadPrefix(t *testing.T) {
+	store := newS3(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/media/uploads/demo.mp4" || r.Header.Get("Range") != "bytes=0-3" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`<Error><Code>NoSuchKey</Code></Error>`))
+			return
+		}
+		w.WriteHeader(http.StatusPartialContent)
+		_, _ = w.Write([]byte("abcd"))
+	})
+	ctx := context.Background()
+
+	presigned, err := store.PresignPut(ctx, "uploads/demo.mp4", "video/mp4", 1024, 15*time.Minute)
+	if err != nil {
+		t.Fatalf("presign: %v", err)
+	}
+	if presigned.Method != http.MethodPut || !strings.Contains(presigned.URL, "/media/uploads/demo.mp4?") || !strings.Contains(presigned.URL, "X-Amz-Expires=900") {
+		t.Fatalf("unexpected presigned request %+v", presigned)
+	}
+	if presigned.Headers["Content-Type"] != "video/mp4" {
+		t.Fatalf("expected signed content type header, got %v", presigned.Headers)
+	}
+	if _, ok := presigned.Headers["Host"]; ok {
+		t.Fatalf("host header must not be returned: %v", presigned.Headers)
+	}
+
+	data, err := store.ReadPrefix(ctx, "uploads/demo.mp4", 4)
+	if err != nil || string(data) != "abcd" {
+		t.Fatalf("read prefix: %q %v", data, err)
+	}
+	if _, err := store.ReadPrefix(ctx, "uploads/missing.mp4", 4); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestS3OpenStreamsWholeObject(t *testing.T) {
+	store := newS3(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/media/uploads/demo.mp4" || r.Header.Get("Range") != "" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`<Error><Code>NoSuchKey</Code></Error>`))
+			return
+		}
+		_, _ = w.Write([]byte("abcdefgh"))
+	})
+	ctx := context.Background()
+
+	body, err := store.Open(ctx, "uploads/demo.mp4")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer body.Close()
+	if data, err := io.ReadAll(body); err != nil || string(data) != "abcdefgh" {
+		t.Fatalf("read: %q %v", data, err)
+	}
+	if _, err := store.Open(ctx, "uploads/missing.mp4"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
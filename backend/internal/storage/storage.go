@@ -3,12 +3,64 @@ package storage
 import (
 	"context"
 	"errors"
+	"mime"
+	"path"
+	"time"
 )
 
 // ObjectStorage defines the behaviour for storing binary objects and returning public URLs.
 type ObjectStorage interface {
 	Put(ctx context.Context, key string, content []byte, contentType string) (string, error)
+	// Delete removes an object. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+	// List returns every object whose key starts with prefix.
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+	// Stat describes one object, returning ErrNotFound when it does not exist.
+	Stat(ctx context.Context, key string) (ObjectInfo, error)
 }
 
-// ErrUnsupportedDriver is returned when the configured storage driver is unknown.
-var ErrUnsupportedDriver = errors.New("storage: unsupported driver")
+// URLSigner converts between the stable URLs stored for objects and the URLs handed to
+// clients. Only local storage with a signing key changes them.
+type URLSigner interface {
+	SignURL(raw string) string
+	StableURL(raw string) string
+}
+
+// SignerFor returns the URL signer of store, or one that leaves URLs unchanged.
+func SignerFor(store ObjectStorage) URLSigner {
+	if signer, ok := store.(URLSigner); ok {
+		return signer
+	}
+	return unsignedURLs{}
+}
+
+type unsignedURLs struct{}
+
+func (unsignedURLs) SignURL(raw string) string   { return raw }
+func (unsignedURLs) StableURL(raw string) string { return raw }
+
+// ObjectInfo describes a stored object.
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	LastModified time.Time
+}
+
+var (
+	// ErrUnsupportedDriver is returned when the configured storage driver is unknown.
+	ErrUnsupportedDriver = errors.New("storage: unsupported driver")
+	// ErrInvalidKey is returned for object keys that are empty or could escape the storage root.
+	ErrInvalidKey = errors.New("storage: invalid object key")
+	// ErrNotFound is returned when an object does not exist.
+	ErrNotFound = errors.New("storage: object not found")
+)
+
+// contentTypeFor guesses a content type from the key's extension for providers whose
+// listings do not carry one.
+func contentTypeFor(key string) string {
+	if contentType := mime.TypeByExtension(path.Ext(key)); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
@@ -3,10 +3,12 @@ package storage
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
@@ -66,6 +68,250 @@ func (s *SupabaseStorage) Put(ctx context.Context, key string, content []byte, c
 	return s.publicURL(key), nil
 }
 
+const (
+	// supabaseListPageSize is the number of entries requested per list call.
+	supabaseListPageSize = 1000
+	// supabaseSignedUploadTTL is fixed by Supabase; signed upload URLs cannot be shortened.
+	supabaseSignedUploadTTL = 2 * time.Hour
+)
+
+// Delete removes an object from the bucket.
+func (s *SupabaseStorage) Delete(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.buildObjectURL(key), nil)
+	if err != nil {
+		return fmt.Errorf("storage: build supabase request: %w", err)
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return fmt.Errorf("storage: supabase delete failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if isSupabaseNotFound(resp.StatusCode, body) {
+			return nil
+		}
+		return fmt.Errorf("storage: supabase delete error %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+type supabaseListEntry struct {
+	Name      string  `json:"name"`
+	ID        *string `json:"id"`
+	UpdatedAt string  `json:"updated_at"`
+	Metadata  *struct {
+		Size     int64  `json:"size"`
+		Mimetype string `json:"mimetype"`
+	} `json:"metadata"`
+}
+
+// List returns the objects below prefix. Supabase lists one folder at a time, so
+// sub-folders are walked recursively.
+func (s *SupabaseStorage) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	folder, namePrefix := path.Split(strings.TrimPrefix(prefix, "/"))
+	objects := []ObjectInfo{}
+	if err := s.listFolder(ctx, strings.TrimSuffix(folder, "/"), namePrefix, &objects); err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+func (s *SupabaseStorage) listFolder(ctx context.Context, folder, namePrefix string, into *[]ObjectInfo) error {
+	for offset := 0; ; offset += supabaseListPageSize {
+		payload, _ := json.Marshal(map[string]any{
+			"prefix": folder,
+			"search": namePrefix,
+			"limit":  supabaseListPageSize,
+			"offset": offset,
+			"sortBy": map[string]string{"column": "name", "order": "asc"},
+		})
+		endpoint := s.baseURL + "/storage/v1/object/list/" + s.bucket
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+		if err != nil {
+			return fmt.Errorf("storage: build supabase request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := s.do(req)
+		if err != nil {
+			return fmt.Errorf("storage: supabase list failed: %w", err)
+		}
+		var entries []supabaseListEntry
+		if resp.StatusCode >= 300 {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+			return fmt.Errorf("storage: supabase list error %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		}
+		err = json.NewDecoder(resp.Body).Decode(&entries)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("storage: decode supabase list: %w", err)
+		}
+
+		for _, entry := range entries {
+			// The search filter is a substring match; keep prefix semantics.
+			if !strings.HasPrefix(entry.Name, namePrefix) {
+				continue
+			}
+			key := entry.Name
+			if folder != "" {
+				key = folder + "/" + entry.Name
+			}
+			if entry.ID == nil {
+				if err := s.listFolder(ctx, key, "", into); err != nil {
+					return err
+				}
+				continue
+			}
+			info := ObjectInfo{Key: key, ContentType: contentTypeFor(key)}
+			if entry.Metadata != nil {
+				info.Size = entry.Metadata.Size
+				if entry.Metadata.Mimetype != "" {
+					info.ContentType = entry.Metadata.Mimetype
+				}
+			}
+			info.LastModified, _ = time.Parse(time.RFC3339Nano, entry.UpdatedAt)
+			*into = append(*into, info)
+		}
+		if len(entries) < supabaseListPageSize {
+			return nil
+		}
+	}
+}
+
+// Stat describes one object using a HEAD request against the authenticated endpoint.
+func (s *SupabaseStorage) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	endpoint := s.baseURL + "/" + path.Join("storage/v1/object/authenticated", s.bucket, strings.TrimPrefix(key, "/"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, endpoint, nil)
+	if err != nil {
+		return ObjectInfo{}, fmt.Errorf("storage: build supabase request: %w", err)
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return ObjectInfo{}, fmt.Errorf("storage: supabase stat failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		// HEAD responses carry no body, and Supabase reports missing objects as 400 or 404.
+		if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusBadRequest {
+			return ObjectInfo{}, ErrNotFound
+		}
+		return ObjectInfo{}, fmt.Errorf("storage: supabase stat error %d", resp.StatusCode)
+	}
+
+	info := ObjectInfo{Key: key, ContentType: resp.Header.Get("Content-Type")}
+	info.Size, _ = strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	info.LastModified, _ = http.ParseTime(resp.Header.Get("Last-Modified"))
+	if info.ContentType == "" {
+		info.ContentType = contentTypeFor(key)
+	}
+	return info, nil
+}
+
+// PresignPut creates a signed upload URL. Supabase does not sign the size or content type
+// and always issues URLs valid for two hours, so ttl only caps the reported expiry and the
+// caller must verify the object once it is uploaded.
+func (s *SupabaseStorage) PresignPut(ctx context.Context, key, contentType string, _ int64, ttl time.Duration) (PresignedPut, error) {
+	endpoint := s.baseURL + "/" + path.Join("storage/v1/object/upload/sign", s.bucket, strings.TrimPrefix(key, "/"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, nil)
+	if err != nil {
+		return PresignedPut{}, fmt.Errorf("storage: build supabase request: %w", err)
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return PresignedPut{}, fmt.Errorf("storage: supabase presign failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return PresignedPut{}, fmt.Errorf("storage: supabase presign error %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	var signed struct {
+		URL string `json:"url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&signed); err != nil {
+		return PresignedPut{}, fmt.Errorf("storage: decode supabase presign response: %w", err)
+	}
+	if signed.URL == "" {
+		return PresignedPut{}, fmt.Errorf("storage: supabase presign response without url")
+	}
+	if ttl <= 0 || ttl > supabaseSignedUploadTTL {
+		ttl = supabaseSignedUploadTTL
+	}
+	return PresignedPut{
+		URL:       s.baseURL + "/storage/v1/" + strings.TrimPrefix(signed.URL, "/"),
+		Method:    http.MethodPut,
+		Headers:   map[string]string{"Content-Type": contentType, "x-upsert": "false"},
+		ExpiresAt: time.Now().Add(ttl).UTC(),
+	}, nil
+}
+
+// ReadPrefix fetches the first n bytes of an object with a ranged request.
+func (s *SupabaseStorage) ReadPrefix(ctx context.Context, key string, n int64) ([]byte, error) {
+	body, err := s.get(ctx, key, fmt.Sprintf("bytes=0-%d", n-1))
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	data, err := io.ReadAll(io.LimitReader(body, n))
+	if err != nil {
+		return nil, fmt.Errorf("storage: supabase read failed: %w", err)
+	}
+	return data, nil
+}
+
+// Open streams the whole object.
+func (s *SupabaseStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	return s.get(ctx, key, "")
+}
+
+func (s *SupabaseStorage) get(ctx context.Context, key, byteRange string) (io.ReadCloser, error) {
+	endpoint := s.baseURL + "/" + path.Join("storage/v1/object/authenticated", s.bucket, strings.TrimPrefix(key, "/"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("storage: build supabase request: %w", err)
+	}
+	if byteRange != "" {
+		req.Header.Set("Range", byteRange)
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("storage: supabase read failed: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if isSupabaseNotFound(resp.StatusCode, body) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("storage: supabase read error %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return resp.Body, nil
+}
+
+// PublicURL returns the URL objects are served from.
+func (s *SupabaseStorage) PublicURL(key string) string {
+	return s.publicURL(key)
+}
+
+func (s *SupabaseStorage) do(req *http.Request) (*http.Response, error) {
+	req.Header.Set("Authorization", "Bearer "+s.serviceKey)
+	return s.client.Do(req)
+}
+
+// isSupabaseNotFound recognises missing objects, which Supabase reports either as a 404
+// or as a 400 whose body carries a "not_found" error.
+func isSupabaseNotFound(status int, body []byte) bool {
+	if status == http.StatusNotFound {
+		return true
+	}
+	lower := strings.ToLower(string(body))
+	return status == http.StatusBadRequest && (strings.Contains(lower, "not_found") || strings.Contains(lower, "not found"))
+}
+
 func (s *SupabaseStorage) buildObjectURL(key string) string {
 	cleanedKey := strings.TrimPrefix(key, "/")
 	joined := path.Join("storage/v1/object", s.bucket, cleanedKey)
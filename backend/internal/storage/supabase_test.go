@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tanydotai/tanyai/backend/internal/config"
+)
+
+func newSupabase(t *testing.T, handler http.HandlerFunc) *SupabaseStorage {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	store, err := NewSupabaseStorage(config.SupabaseConfig{URL: server.URL, Bucket: "media", ServiceRole: "service"})
+	if err != nil {
+		t.Fatalf("new supabase storage: %v", err)
+	}
+	return store
+}
+
+func TestSupabaseListWalksFolders(t *testing.T) {
+	var prefixes []string
+	store := newSupabase(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/storage/v1/object/list/media" || r.Header.Get("Authorization") != "Bearer service" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var body struct {
+			Prefix string `json:"prefix"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		prefixes = append(prefixes, body.Prefix)
+		switch body.Prefix {
+		case "uploads":
+			_, _ = w.Write([]byte(`[{"name":"2025","id":null},{"name":"a.png","id":"1","updated_at":"2025-02-10T08:00:00.000Z","metadata":{"size":3,"mimetype":"image/png"}}]`))
+		case "uploads/2025":
+			_, _ = w.Write([]byte(`[{"name":"b.jpg","id":"2","updated_at":"2025-02-11T08:00:00Z","metadata":{"size":5,"mimetype":""}}]`))
+		default:
+			_, _ = w.Write([]byte(`[]`))
+		}
+	})
+
+	objects, err := store.List(context.Background(), "uploads/")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(objects) != 2 || len(prefixes) != 2 {
+		t.Fatalf("unexpected objects %+v (prefixes %v)", objects, prefixes)
+	}
+	if objects[0].Key != "uploads/2025/b.jpg" || objects[0].ContentType != "image/jpeg" || objects[0].Size != 5 {
+		t.Fatalf("unexpected nested object %+v", objects[0])
+	}
+	if objects[1].Key != "uploads/a.png" || objects[1].ContentType != "image/png" || objects[1].LastModified.IsZero() {
+		t.Fatalf("unexpected object %+v", objects[1])
+	}
+}
+
+func TestSupabaseDeleteAndStat(t *testing.T) {
+	store := newSupabase(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodDelete && r.URL.Path == "/storage/v1/object/media/uploads/a.png":
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodDelete && r.URL.Path == "/storage/v1/object/media/uploads/gone.png":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"statusCode":"404","error":"not_found","message":"Object not found"}`))
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusForbidden)
+		case r.Method == http.MethodHead && r.URL.Path == "/storage/v1/object/authenticated/media/uploads/a.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Header().Set("Content-Length", "42")
+			w.Header().Set("Last-Modified", "Mon, 10 Feb 2025 08:00:00 GMT")
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+	ctx := context.Background()
+
+	if err := store.Delete(ctx, "uploads/a.png"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := store.Delete(ctx, "uploads/gone.png"); err != nil {
+		t.Fatalf("expected missing object delete to succeed, got %v", err)
+	}
+	if err := store.Delete(ctx, "uploads/denied.png"); err == nil {
+		t.Fatalf("expected delete error")
+	}
+
+	info, err := store.Stat(ctx, "uploads/a.png")
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if info.Size != 42 || info.ContentType != "image/png" || info.LastModified.IsZero() {
+		t.Fatalf("unexpected info %+v", info)
+	}
+	if _, err := store.Stat(ctx, "uploads/missing.png"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestSupabasePresignPutAndReadPrefix(t *testing.T) {
+	store := newSupabase(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/storage/v1/object/upload/sign/media/uploads/doc.pdf":
+			_, _ = w.Write([]byte(`{"url":"/object/upload/sign/media/uploads/doc.pdf?token=abc"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/storage/v1/object/authenticated/media/uploads/doc.pdf" && r.Header.Get("Range") == "bytes=0-4":
+			w.WriteHeader(http.StatusPartialContent)
+			_, _ = w.Write([]byte("%PDF-"))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"not_found"}`))
+		}
+	})
+	ctx := context.Background()
+
+	presigned, err := store.PresignPut(ctx, "uploads/doc.pdf", "application/pdf", 2048, 15*time.Minute)
+	if err != nil {
+		t.Fatalf("presign: %v", err)
+	}
+	if presigned.Method != http.MethodPut || !strings.HasSuffix(presigned.URL, "/storage/v1/object/upload/sign/media/uploads/doc.pdf?token=abc") {
+		t.Fatalf("unexpected presigned request %+v", presigned)
+	}
+	if presigned.Headers["Content-Type"] != "application/pdf" || time.Until(presigned.ExpiresAt) > 15*time.Minute {
+		t.Fatalf("unexpected headers or expiry %+v", presigned)
+	}
+
+	data, err := store.ReadPrefix(ctx, "uploads/doc.pdf", 5)
+	if err != nil || string(data) != "%PDF-" {
+		t.Fatalf("read prefix: %q %v", data, err)
+	}
+	if _, err := store.ReadPrefix(ctx, "uploads/missing.pdf", 5); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestSupabaseOpenStreamsWholeObject(t *testing.T) {
+	store := newSupabase(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/storage/v1/object/authenticated/media/uploads/doc.pdf" || r.Header.Get("Range") != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"not_found"}`))
+			return
+		}
+		_, _ = w.Write([]byte("%PDF-1.7"))
+	})
+	ctx := context.Background()
+
+	body, err := store.Open(ctx, "uploads/doc.pdf")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer body.Close()
+	if data, err := io.ReadAll(body); err != nil || string(data) != "%PDF-1.7" {
+		t.Fatalf("read: %q %v", data, err)
+	}
+	if _, err := store.Open(ctx, "uploads/missing.pdf"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
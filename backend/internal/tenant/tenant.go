@@ -0,0 +1,151 @@
+// Package tenant carries the active portfolio owner through request contexts and
+// resolves tenants from path slugs or request hosts.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+)
+
+// DefaultID identifies the tenant owning data created before multi-tenancy was introduced.
+var DefaultID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+// DefaultSlug is the slug of the default tenant.
+const DefaultSlug = "default"
+
+// ErrUnknownTenant indicates no tenant matches the requested slug.
+var ErrUnknownTenant = errors.New("unknown tenant")
+
+type contextKey struct{}
+
+// WithTenant returns a copy of ctx carrying the tenant.
+func WithTenant(ctx context.Context, t models.Tenant) context.Context {
+	return context.WithValue(ctx, contextKey{}, t)
+}
+
+// WithID returns a copy of ctx carrying a tenant known only by ID.
+func WithID(ctx context.Context, id uuid.UUID) context.Context {
+	return WithTenant(ctx, models.Tenant{ID: id})
+}
+
+// FromContext returns the tenant stored in ctx.
+func FromContext(ctx context.Context) (models.Tenant, bool) {
+	t, ok := ctx.Value(contextKey{}).(models.Tenant)
+	if !ok || t.ID == uuid.Nil {
+		return models.Tenant{}, false
+	}
+	return t, true
+}
+
+// ID returns the tenant ID stored in ctx, falling back to DefaultID.
+func ID(ctx context.Context) uuid.UUID {
+	if t, ok := FromContext(ctx); ok {
+		return t.ID
+	}
+	return DefaultID
+}
+
+// Store lists the configured tenants.
+type Store interface {
+	List(ctx context.Context) ([]models.Tenant, error)
+}
+
+// Resolver maps slugs and hosts to tenants using a periodically refreshed snapshot.
+type Resolver struct {
+	store Store
+	ttl   time.Duration
+
+	mu      sync.RWMutex
+	bySlug  map[string]models.Tenant
+	byHost  map[string]models.Tenant
+	expires time.Time
+}
+
+// NewResolver constructs a Resolver that reloads tenants from store after ttl.
+func NewResolver(store Store, ttl time.Duration) *Resolver {
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+	return &Resolver{store: store, ttl: ttl}
+}
+
+// Resolve returns the tenant for slug when provided, otherwise the tenant mapped to host.
+// Requests for unknown hosts fall back to the default tenant; unknown slugs return ErrUnknownTenant.
+func (r *Resolver) Resolve(ctx context.Context, slug, host string) (models.Tenant, error) {
+	if err := r.refresh(ctx); err != nil {
+		return models.Tenant{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if slug = strings.ToLower(strings.TrimSpace(slug)); slug != "" {
+		t, ok := r.bySlug[slug]
+		if !ok {
+			return models.Tenant{}, ErrUnknownTenant
+		}
+		return t, nil
+	}
+	if t, ok := r.byHost[normalizeHost(host)]; ok {
+		return t, nil
+	}
+	if t, ok := r.bySlug[DefaultSlug]; ok {
+		return t, nil
+	}
+	return models.Tenant{ID: DefaultID, Slug: DefaultSlug}, nil
+}
+
+// Invalidate forces the next Resolve call to reload tenants.
+func (r *Resolver) Invalidate() {
+	r.mu.Lock()
+	r.expires = time.Time{}
+	r.mu.Unlock()
+}
+
+func (r *Resolver) refresh(ctx context.Context) error {
+	now := time.Now()
+	r.mu.RLock()
+	fresh := r.bySlug != nil && now.Before(r.expires)
+	r.mu.RUnlock()
+	if fresh {
+		return nil
+	}
+
+	tenants, err := r.store.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	bySlug := make(map[string]models.Tenant, len(tenants))
+	byHost := make(map[string]models.Tenant)
+	for _, t := range tenants {
+		bySlug[strings.ToLower(t.Slug)] = t
+		for _, host := range t.Hosts {
+			if h := normalizeHost(host); h != "" {
+				byHost[h] = t
+			}
+		}
+	}
+
+	r.mu.Lock()
+	r.bySlug = bySlug
+	r.byHost = byHost
+	r.expires = now.Add(r.ttl)
+	r.mu.Unlock()
+	return nil
+}
+
+func normalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(host, ".")
+}
@@ -0,0 +1,57 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+)
+
+type stubStore struct {
+	tenants []models.Tenant
+	calls   int
+}
+
+func (s *stubStore) List(context.Context) ([]models.Tenant, error) {
+	s.calls++
+	return s.tenants, nil
+}
+
+func TestResolverMatchesSlugHostAndDefault(t *testing.T) {
+	acme := models.Tenant{ID: uuid.New(), Slug: "acme", Hosts: []string{"chat.acme.dev"}}
+	store := &stubStore{tenants: []models.Tenant{{ID: DefaultID, Slug: DefaultSlug}, acme}}
+	resolver := NewResolver(store, time.Minute)
+	ctx := context.Background()
+
+	got, err := resolver.Resolve(ctx, "ACME", "")
+	if err != nil || got.ID != acme.ID {
+		t.Fatalf("expected slug match, got %+v (%v)", got, err)
+	}
+	got, err = resolver.Resolve(ctx, "", "Chat.Acme.dev:443")
+	if err != nil || got.ID != acme.ID {
+		t.Fatalf("expected host match, got %+v (%v)", got, err)
+	}
+	got, err = resolver.Resolve(ctx, "", "unknown.example.com")
+	if err != nil || got.ID != DefaultID {
+		t.Fatalf("expected default tenant, got %+v (%v)", got, err)
+	}
+	if _, err := resolver.Resolve(ctx, "missing", ""); !errors.Is(err, ErrUnknownTenant) {
+		t.Fatalf("expected ErrUnknownTenant, got %v", err)
+	}
+	if store.calls != 1 {
+		t.Fatalf("expected tenants to be loaded once, got %d", store.calls)
+	}
+}
+
+func TestIDFallsBackToDefault(t *testing.T) {
+	if ID(context.Background()) != DefaultID {
+		t.Fatalf("expected default tenant without context value")
+	}
+	id := uuid.New()
+	if ID(WithID(context.Background(), id)) != id {
+		t.Fatalf("expected tenant from context")
+	}
+}
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_chat_history_prompt_hash;
+
+ALTER TABLE chat_history
+    DROP COLUMN IF EXISTS cache_hit;
@@ -0,0 +1,4 @@
+ALTER TABLE chat_history
+    ADD COLUMN IF NOT EXISTS cache_hit BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_chat_history_prompt_hash ON chat_history (prompt_hash);
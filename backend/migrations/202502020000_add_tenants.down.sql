@@ -0,0 +1,22 @@
+DROP INDEX IF EXISTS idx_external_sources_name;
+DROP INDEX IF EXISTS idx_external_sources_base_url;
+DELETE FROM external_sources WHERE tenant_id <> '00000000-0000-0000-0000-000000000001';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_external_sources_name ON external_sources (LOWER(name));
+CREATE UNIQUE INDEX IF NOT EXISTS idx_external_sources_base_url ON external_sources (LOWER(base_url));
+
+DROP INDEX IF EXISTS idx_chat_history_tenant;
+DROP INDEX IF EXISTS idx_users_tenant;
+DROP INDEX IF EXISTS idx_projects_tenant;
+DROP INDEX IF EXISTS idx_services_tenant;
+DROP INDEX IF EXISTS idx_skills_tenant;
+DROP INDEX IF EXISTS idx_profile_tenant;
+
+ALTER TABLE chat_history DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE users DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE external_sources DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE projects DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE services DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE skills DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE profile DROP COLUMN IF EXISTS tenant_id;
+
+DROP TABLE IF EXISTS tenants;
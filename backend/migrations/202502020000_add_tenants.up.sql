@@ -0,0 +1,45 @@
+CREATE TABLE IF NOT EXISTS tenants (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    slug TEXT NOT NULL,
+    name TEXT NOT NULL,
+    hosts TEXT[] NOT NULL DEFAULT '{}',
+    ai_provider TEXT,
+    chat_model TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (slug ~ '^[a-z0-9][a-z0-9-]*$')
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tenants_slug ON tenants (slug);
+
+-- Existing single-owner data is assigned to the default tenant.
+INSERT INTO tenants (id, slug, name)
+VALUES ('00000000-0000-0000-0000-000000000001', 'default', 'Default')
+ON CONFLICT (id) DO NOTHING;
+
+ALTER TABLE profile ADD COLUMN IF NOT EXISTS tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES tenants(id) ON DELETE CASCADE;
+ALTER TABLE skills ADD COLUMN IF NOT EXISTS tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES tenants(id) ON DELETE CASCADE;
+ALTER TABLE services ADD COLUMN IF NOT EXISTS tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES tenants(id) ON DELETE CASCADE;
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES tenants(id) ON DELETE CASCADE;
+ALTER TABLE external_sources ADD COLUMN IF NOT EXISTS tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES tenants(id) ON DELETE CASCADE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES tenants(id) ON DELETE CASCADE;
+ALTER TABLE chat_history ADD COLUMN IF NOT EXISTS tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES tenants(id) ON DELETE CASCADE;
+
+-- Each tenant has a single profile; keep the most recently updated one if several exist.
+DELETE FROM profile p
+USING profile newer
+WHERE p.tenant_id = newer.tenant_id
+  AND (p.updated_at, p.id) < (newer.updated_at, newer.id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_profile_tenant ON profile (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_skills_tenant ON skills (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_services_tenant ON services (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_projects_tenant ON projects (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_users_tenant ON users (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_chat_history_tenant ON chat_history (tenant_id);
+
+-- Source names and URLs only need to be unique within a tenant.
+DROP INDEX IF EXISTS idx_external_sources_name;
+DROP INDEX IF EXISTS idx_external_sources_base_url;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_external_sources_name ON external_sources (tenant_id, LOWER(name));
+CREATE UNIQUE INDEX IF NOT EXISTS idx_external_sources_base_url ON external_sources (tenant_id, LOWER(base_url));
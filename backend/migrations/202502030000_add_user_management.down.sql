@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS password_tokens;
+ALTER TABLE users DROP COLUMN IF EXISTS is_active;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_refresh_tokens_family_id;
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS ip_address;
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS user_agent;
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS session_started_at;
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS family_id;
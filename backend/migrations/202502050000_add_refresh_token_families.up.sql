@@ -0,0 +1,13 @@
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS family_id UUID;
+UPDATE refresh_tokens SET family_id = id WHERE family_id IS NULL;
+ALTER TABLE refresh_tokens ALTER COLUMN family_id SET NOT NULL;
+
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS session_started_at TIMESTAMPTZ;
+UPDATE refresh_tokens SET session_started_at = created_at WHERE session_started_at IS NULL;
+ALTER TABLE refresh_tokens ALTER COLUMN session_started_at SET NOT NULL;
+ALTER TABLE refresh_tokens ALTER COLUMN session_started_at SET DEFAULT NOW();
+
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS user_agent TEXT NOT NULL DEFAULT '';
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS ip_address TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens (family_id);
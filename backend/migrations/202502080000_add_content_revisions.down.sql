@@ -0,0 +1,14 @@
+ALTER TABLE projects
+    DROP COLUMN IF EXISTS publish_at,
+    DROP COLUMN IF EXISTS has_unpublished_changes,
+    DROP COLUMN IF EXISTS published_revision_id;
+ALTER TABLE services
+    DROP COLUMN IF EXISTS publish_at,
+    DROP COLUMN IF EXISTS has_unpublished_changes,
+    DROP COLUMN IF EXISTS published_revision_id;
+ALTER TABLE profile
+    DROP COLUMN IF EXISTS publish_at,
+    DROP COLUMN IF EXISTS has_unpublished_changes,
+    DROP COLUMN IF EXISTS published_revision_id;
+
+DROP TABLE IF EXISTS content_revisions;
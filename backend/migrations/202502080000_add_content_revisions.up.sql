@@ -0,0 +1,47 @@
+CREATE TABLE IF NOT EXISTS content_revisions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    entity_type TEXT NOT NULL,
+    entity_id UUID NOT NULL,
+    version INTEGER NOT NULL,
+    data JSONB NOT NULL,
+    published_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (entity_type, entity_id, version),
+    CHECK (entity_type IN ('profile', 'service', 'project'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_content_revisions_tenant ON content_revisions (tenant_id, entity_type, entity_id);
+
+-- The editable row is the draft; the knowledge base only reads the revision it points to.
+ALTER TABLE profile
+    ADD COLUMN IF NOT EXISTS published_revision_id UUID REFERENCES content_revisions(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS has_unpublished_changes BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN IF NOT EXISTS publish_at TIMESTAMPTZ;
+ALTER TABLE services
+    ADD COLUMN IF NOT EXISTS published_revision_id UUID REFERENCES content_revisions(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS has_unpublished_changes BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN IF NOT EXISTS publish_at TIMESTAMPTZ;
+ALTER TABLE projects
+    ADD COLUMN IF NOT EXISTS published_revision_id UUID REFERENCES content_revisions(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS has_unpublished_changes BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN IF NOT EXISTS publish_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_profile_publish_at ON profile (publish_at) WHERE publish_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_services_publish_at ON services (publish_at) WHERE publish_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_projects_publish_at ON projects (publish_at) WHERE publish_at IS NOT NULL;
+
+-- Content that was live before drafts existed is published as version 1.
+INSERT INTO content_revisions (tenant_id, entity_type, entity_id, version, data)
+SELECT tenant_id, 'profile', id, 1, to_jsonb(t) - 'published_revision_id' - 'has_unpublished_changes' - 'publish_at' FROM profile t;
+INSERT INTO content_revisions (tenant_id, entity_type, entity_id, version, data)
+SELECT tenant_id, 'service', id, 1, to_jsonb(t) - 'published_revision_id' - 'has_unpublished_changes' - 'publish_at' FROM services t;
+INSERT INTO content_revisions (tenant_id, entity_type, entity_id, version, data)
+SELECT tenant_id, 'project', id, 1, to_jsonb(t) - 'published_revision_id' - 'has_unpublished_changes' - 'publish_at' FROM projects t;
+
+UPDATE profile t SET published_revision_id = r.id, has_unpublished_changes = FALSE
+FROM content_revisions r WHERE r.entity_type = 'profile' AND r.entity_id = t.id;
+UPDATE services t SET published_revision_id = r.id, has_unpublished_changes = FALSE
+FROM content_revisions r WHERE r.entity_type = 'service' AND r.entity_id = t.id;
+UPDATE projects t SET published_revision_id = r.id, has_unpublished_changes = FALSE
+FROM content_revisions r WHERE r.entity_type = 'project' AND r.entity_id = t.id;
@@ -0,0 +1,11 @@
+DELETE FROM projects WHERE deleted_at IS NOT NULL;
+DELETE FROM services WHERE deleted_at IS NOT NULL;
+DELETE FROM skills WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_projects_deleted_at;
+DROP INDEX IF EXISTS idx_services_deleted_at;
+DROP INDEX IF EXISTS idx_skills_deleted_at;
+
+ALTER TABLE projects DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE services DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE skills DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,2 @@
+ALTER TABLE projects DROP COLUMN IF EXISTS image_variants;
+ALTER TABLE profile DROP COLUMN IF EXISTS avatar_variants;
@@ -0,0 +1,2 @@
+ALTER TABLE profile ADD COLUMN IF NOT EXISTS avatar_variants JSONB;
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS image_variants JSONB;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS uploads (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    -- Image variants point at the original they were rendered from.
+    parent_id UUID REFERENCES uploads(id) ON DELETE CASCADE,
+    key TEXT NOT NULL UNIQUE,
+    url TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    uploaded_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_uploads_tenant_created ON uploads (tenant_id, created_at DESC) WHERE parent_id IS NULL;
+CREATE INDEX IF NOT EXISTS idx_uploads_parent ON uploads (parent_id) WHERE parent_id IS NOT NULL;
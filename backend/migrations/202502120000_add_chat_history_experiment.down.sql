@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_chat_history_experiment;
+
+ALTER TABLE chat_history
+    DROP COLUMN IF EXISTS variant,
+    DROP COLUMN IF EXISTS experiment;
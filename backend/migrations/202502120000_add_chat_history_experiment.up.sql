@@ -0,0 +1,5 @@
+ALTER TABLE chat_history
+    ADD COLUMN IF NOT EXISTS experiment TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS variant TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_chat_history_experiment ON chat_history (experiment, variant) WHERE experiment <> '';
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/tanydotai/tanyai/backend/internal/config"
+	admin "github.com/tanydotai/tanyai/backend/internal/handlers/admin"
+	"github.com/tanydotai/tanyai/backend/internal/middleware"
+	"github.com/tanydotai/tanyai/backend/internal/models"
+	"github.com/tanydotai/tanyai/backend/internal/repos"
+)
+
+type documentRepoStub struct {
+	docs   map[uuid.UUID]models.Document
+	chunks map[uuid.UUID][]string
+}
+
+func (s *documentRepoStub) Create(_ context.Context, doc models.Document, chunks []string) (models.Document, error) {
+	if s.docs == nil {
+		s.docs = map[uuid.UUID]models.Document{}
+		s.chunks = map[uuid.UUID][]string{}
+	}
+	doc.Chunks = len(chunks)
+	doc.CreatedAt = time.Date(2025, 2, 12, 8, 0, 0, 0, time.UTC)
+	s.docs[doc.ID] = doc
+	s.chunks[doc.ID] = chunks
+	return doc, nil
+}
+
+func (s *documentRepoStub) List(_ context.Context, _ repos.ListParams) ([]models.Document, int64, error) {
+	docs := make([]models.Document, 0, len(s.docs))
+	for _, doc := range s.docs {
+		docs = append(docs, doc)
+	}
+	return docs, int64(len(docs)), nil
+}
+
+func (s *documentRepoStub) Get(_ context.Context, id uuid.UUID) (models.Document, error) {
+	doc, ok := s.docs[id]
+	if !ok {
+		return models.Document{}, repos.ErrNotFound
+	}
+	return doc, nil
+}
+
+func (s *documentRepoStub) Delete(_ context.Context, id uuid.UUID) (models.Document, error) {
+	doc, ok := s.docs[id]
+	if !ok {
+		return models.Document{}, repos.ErrNotFound
+	}
+	delete(s.docs, id)
+	delete(s.chunks, id)
+	return doc, nil
+}
+
+func setupDocumentsRouter(t *testing.T, repo repos.DocumentRepository, store *storageStub, policy config.UploadConfig, invalidated *int) (*gin.Engine, string) {
+	t.Helper()
+	router, tokens := setupUploadRouter(t, store, policy, nil)
+	handler := admin.NewDocumentsHandler(repo, store, policy, func() { *invalidated++ }, log.New(io.Discard, "", 0))
+
+	group := router.Group("/api/admin", middleware.Authn(tokens, nil), middleware.AuthzAdmin())
+	group.GET("/documents", handler.List)
+	group.POST("/documents", handler.Create)
+	group.DELETE("/documents/:id", handler.Delete)
+	return router, mustAdminToken(t, tokens)
+}
+
+func defaultDocumentPolicy() config.UploadConfig {
+	policy := defaultUploadPolicy()
+	policy.Document = config.DocumentConfig{MaxBytes: 1024 * 1024, ChunkRunes: 200, MaxChunks: 10}
+	policy.Scan = config.ScanConfig{Builtin: true}
+	return policy
+}
+
+func postDocument(t *testing.T, router *gin.Engine, token, filename string, content []byte, contentType string) *httptest.ResponseRecorder {
+	t.Helper()
+	body, boundary := multipartBody(t, "file", filename, content, contentType)
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/documents", body)
+	req.Header.Set("Content-Type", "multipart/form-data; boundary="+boundary)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAdminDocumentsUploadMarkdown(t *testing.T) {
+	repo := &documentRepoStub{}
+	store := &storageStub{}
+	invalidated := 0
+	router, token := setupDocumentsRouter(t, repo, store, defaultDocumentPolicy(), &invalidated)
+
+	markdown := "# Daftar Harga\n\n" + strings.Repeat("Paket **maintenance** bulanan mulai 3 juta. ", 8)
+	rec := postDocument(t, router, token, "daftar-harga.md", []byte(markdown), "text/plain")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	require.Contains(t, rec.Body.String(), `"title":"daftar-harga"`)
+	require.Contains(t, rec.Body.String(), `"contentType":"text/markdown"`)
+	require.Equal(t, 1, invalidated)
+
+	require.Len(t, repo.docs, 1)
+	for id, chunks := range repo.chunks {
+		require.Greater(t, len(chunks), 1)
+		require.True(t, strings.HasPrefix(chunks[0], "Daftar Harga\n\nPaket maintenance"), chunks[0])
+		require.NotContains(t, strings.Join(chunks, " "), "**")
+		doc := repo.docs[id]
+		require.True(t, strings.HasPrefix(doc.Key, "documents/"), doc.Key)
+		require.Equal(t, "text/markdown", store.objects[doc.Key])
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/admin/documents/"+id.String(), nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		del := httptest.NewRecorder()
+		router.ServeHTTP(del, req)
+		require.Equal(t, http.StatusNoContent, del.Code, del.Body.String())
+	}
+	require.Empty(t, repo.docs)
+	require.Empty(t, store.objects)
+	require.Equal(t, 2, invalidated)
+}
+
+func TestAdminDocumentsRejectsInvalidUploads(t *testing.T) {
+	repo := &documentRepoStub{}
+	store := &storageStub{}
+	invalidated := 0
+	router, token := setupDocumentsRouter(t, repo, store, defaultDocumentPolicy(), &invalidated)
+
+	rec := postDocument(t, router, token, "logo.png", pngBytes(), "image/png")
+	require.Equal(t, http.StatusUnsupportedMediaType, rec.Code, rec.Body.String())
+
+	rec = postDocument(t, router, token, "kosong.txt", []byte("  \n\n\t "), "text/plain")
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code, rec.Body.String())
+
+	malicious := []byte("%PDF-1.4\n1 0 obj << /OpenAction << /S /JavaScript /JS (app.alert(1)) >> >> endobj\n%%EOF")
+	rec = postDocument(t, router, token, "brosur.pdf", malicious, "application/pdf")
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code, rec.Body.String())
+	require.Contains(t, rec.Body.String(), "threat")
+
+	rec = postDocument(t, router, token, "rusak.pdf", []byte("%PDF-1.4\nbroken"), "application/pdf")
+	require.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+
+	long := strings.Repeat("Kalimat yang cukup panjang untuk memenuhi satu potongan teks. ", 60)
+	rec = postDocument(t, router, token, "panjang.txt", []byte(long), "text/plain")
+	require.Equal(t, http.StatusRequestEntityTooLarge, rec.Code, rec.Body.String())
+
+	require.Empty(t, repo.docs)
+	require.Empty(t, store.objects)
+	require.Zero(t, invalidated)
+}
@@ -12,9 +12,12 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
+	"github.com/tanydotai/tanyai/backend/internal/config"
+	"github.com/tanydotai/tanyai/backend/internal/dto"
 	admin "github.com/tanydotai/tanyai/backend/internal/handlers/admin"
 	"github.com/tanydotai/tanyai/backend/internal/models"
 	"github.com/tanydotai/tanyai/backend/internal/repos"
+	"github.com/tanydotai/tanyai/backend/internal/storage"
 )
 
 func init() {
@@ -33,7 +36,7 @@ func TestAdminProfileGetSuccess(t *testing.T) {
 		},
 	}
 
-	router := setupProfileRouter(repo, nil)
+	router := setupProfileRouter(repo)
 
 	req := httptest.NewRequest(http.MethodGet, "/api/admin/profile", nil)
 	rec := httptest.NewRecorder()
@@ -50,7 +53,7 @@ func TestAdminProfileGetSuccess(t *testing.T) {
 func TestAdminProfilePutValidationError(t *testing.T) {
 	t.Setenv("ENABLE_ADMIN_GUARD", "false")
 	repo := &profileRepoStub{}
-	router := setupProfileRouter(repo, nil)
+	router := setupProfileRouter(repo)
 
 	payload := map[string]string{"name": "", "title": ""}
 	body, _ := json.Marshal(payload)
@@ -74,8 +77,7 @@ func TestAdminProfilePutSuccess(t *testing.T) {
 			UpdatedAt: time.Now(),
 		},
 	}
-	var invalidated bool
-	router := setupProfileRouter(repo, func() { invalidated = true })
+	router := setupProfileRouter(repo)
 
 	payload := map[string]string{
 		"name":       "Jane Doe",
@@ -92,13 +94,51 @@ func TestAdminProfilePutSuccess(t *testing.T) {
 
 	require.Equal(t, http.StatusOK, rec.Code)
 	require.Equal(t, "Jane Doe", repo.lastUpsert.Name)
-	require.True(t, invalidated)
+
+	var response struct {
+		Data dto.ProfileResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	require.Equal(t, models.PublicationDraft, response.Data.Status)
+}
+
+func TestAdminProfileStoresStableAvatarURL(t *testing.T) {
+	t.Setenv("ENABLE_ADMIN_GUARD", "false")
+	store, err := storage.NewLocalStorage(config.LocalStorageConfig{
+		Dir:        t.TempDir(),
+		PublicURL:  "http://localhost:8080/api/v1/files",
+		SigningKey: "a-signing-key-that-is-long-enough-1234",
+		URLTTL:     time.Hour,
+	})
+	require.NoError(t, err)
+	stable := "http://localhost:8080/api/v1/files/uploads/avatar.png"
+
+	repo := &profileRepoStub{}
+	handler := admin.NewProfileHandler(repo)
+	handler.SetURLSigner(store)
+	router := gin.New()
+	router.PUT("/api/admin/profile", handler.Put)
+
+	body, _ := json.Marshal(map[string]string{"name": "Jane Doe", "title": "Designer", "avatar_url": store.SignURL(stable)})
+	req := httptest.NewRequest(http.MethodPut, "/api/admin/profile", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, stable, repo.lastUpsert.AvatarURL)
+
+	var response struct {
+		Data dto.ProfileResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	require.Contains(t, response.Data.AvatarURL, stable+"?expires=")
 }
 
 func TestAdminProfileGetNotFound(t *testing.T) {
 	t.Setenv("ENABLE_ADMIN_GUARD", "false")
 	repo := &profileRepoStub{getErr: repos.ErrNotFound}
-	router := setupProfileRouter(repo, nil)
+	router := setupProfileRouter(repo)
 
 	req := httptest.NewRequest(http.MethodGet, "/api/admin/profile", nil)
 	rec := httptest.NewRecorder()
@@ -107,11 +147,8 @@ func TestAdminProfileGetNotFound(t *testing.T) {
 	require.Equal(t, http.StatusNotFound, rec.Code)
 }
 
-func setupProfileRouter(repo repos.ProfileRepository, invalidate func()) *gin.Engine {
-	if invalidate == nil {
-		invalidate = func() {}
-	}
-	handler := admin.NewProfileHandler(repo, invalidate)
+func setupProfileRouter(repo repos.ProfileRepository) *gin.Engine {
+	handler := admin.NewProfileHandler(repo)
 	router := gin.New()
 	group := router.Group("/api/admin")
 	group.GET("/profile", handler.Get)
@@ -0,0 +1,330 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	appauth "github.com/tanydotai/tanyai/backend/internal/auth"
+	"github.com/tanydotai/tanyai/backend/internal/config"
+	admin "github.com/tanydotai/tanyai/backend/internal/handlers/admin"
+	"github.com/tanydotai/tanyai/backend/internal/middleware"
+	"github.com/tanydotai/tanyai/backend/internal/storage"
+)
+
+type directStorageStub struct {
+	storageStub
+	data      map[string][]byte
+	presigned []string
+	deleted   []string
+}
+
+func (s *directStorageStub) PresignPut(_ context.Context, key, contentType string, _ int64, ttl time.Duration) (storage.PresignedPut, error) {
+	s.presigned = append(s.presigned, key)
+	return storage.PresignedPut{
+		URL:       "https://bucket.example.com/" + key + "?signature=abc",
+		Method:    http.MethodPut,
+		Headers:   map[string]string{"Content-Type": contentType},
+		ExpiresAt: time.Now().Add(ttl),
+	}, nil
+}
+
+func (s *directStorageStub) Stat(_ context.Context, key string) (storage.ObjectInfo, error) {
+	data, ok := s.data[key]
+	if !ok {
+		return storage.ObjectInfo{}, storage.ErrNotFound
+	}
+	return storage.ObjectInfo{Key: key, Size: int64(len(data))}, nil
+}
+
+func (s *directStorageStub) ReadPrefix(_ context.Context, key string, n int64) ([]byte, error) {
+	data := s.data[key]
+	if int64(len(data)) > n {
+		data = data[:n]
+	}
+	return data, nil
+}
+
+func (s *directStorageStub) Open(_ context.Context, key string) (io.ReadCloser, error) {
+	data, ok := s.data[key]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *directStorageStub) Delete(_ context.Context, key string) error {
+	s.deleted = append(s.deleted, key)
+	delete(s.data, key)
+	return nil
+}
+
+func (s *directStorageStub) PublicURL(key string) string {
+	return "https://cdn.example.com/" + key
+}
+
+func setupDirectUploadRouter(t *testing.T, store storage.ObjectStorage, recorder admin.MediaRecorder) (*gin.Engine, string) {
+	t.Helper()
+	return setupScannedDirectUploadRouter(t, store, recorder, config.ScanConfig{})
+}
+
+func setupScannedDirectUploadRouter(t *testing.T, store storage.ObjectStorage, recorder admin.MediaRecorder, scan config.ScanConfig) (*gin.Engine, string) {
+	t.Helper()
+
+	tokenService, err := appauth.NewTokenService("this_is_a_super_secret_for_tests_1234567890", time.Hour, time.Hour)
+	require.NoError(t, err)
+
+	policy := defaultUploadPolicy()
+	policy.Direct = config.DirectUploadConfig{
+		MaxBytes:    1024,
+		AllowedMIME: []string{"image/png", "application/pdf", "video/mp4"},
+		URLTTL:      15 * time.Minute,
+	}
+	policy.Scan = scan
+	handler := admin.NewUploadsHandler(store, policy, log.New(io.Discard, "", 0))
+	if recorder != nil {
+		handler.SetMediaRecorder(recorder)
+	}
+
+	router := gin.New()
+	group := router.Group("/api/admin", middleware.Authn(tokenService, nil), middleware.AuthzAdmin())
+	group.POST("/uploads/presign", handler.Presign)
+	group.POST("/uploads/complete", handler.Complete)
+	return router, mustAdminToken(t, tokenService)
+}
+
+func postJSON(router *gin.Engine, token, path string, payload any) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(payload)
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAdminUploadsPresignValidatesPolicy(t *testing.T) {
+	store := &directStorageStub{}
+	router, token := setupDirectUploadRouter(t, store, nil)
+
+	rec := postJSON(router, token, "/api/admin/uploads/presign", map[string]any{"filename": "deck.pdf", "contentType": "application/pdf", "size": 512})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp struct {
+		Data struct {
+			Key       string            `json:"key"`
+			UploadURL string            `json:"uploadUrl"`
+			Method    string            `json:"method"`
+			Headers   map[string]string `json:"headers"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Regexp(t, `^uploads/\d{4}/\d{2}/\d{2}/[0-9a-f-]{36}\.pdf$`, resp.Data.Key)
+	require.Equal(t, http.MethodPut, resp.Data.Method)
+	require.Equal(t, "application/pdf", resp.Data.Headers["Content-Type"])
+	require.Equal(t, []string{resp.Data.Key}, store.presigned)
+
+	rec = postJSON(router, token, "/api/admin/uploads/presign", map[string]any{"contentType": "application/pdf", "size": 4096})
+	require.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	rec = postJSON(router, token, "/api/admin/uploads/presign", map[string]any{"contentType": "image/svg+xml", "size": 10})
+	require.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+
+	rec = postJSON(router, token, "/api/admin/uploads/presign", map[string]any{"contentType": "application/pdf"})
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Len(t, store.presigned, 1)
+}
+
+func TestAdminUploadsPresignRequiresDirectDriver(t *testing.T) {
+	router, token := setupDirectUploadRouter(t, &storageStub{}, nil)
+
+	rec := postJSON(router, token, "/api/admin/uploads/presign", map[string]any{"contentType": "application/pdf", "size": 10})
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+// presignUpload declares an upload and returns the issued key and completion token.
+func presignUpload(t *testing.T, router *gin.Engine, token, contentType string, size int) (string, string) {
+	t.Helper()
+	rec := postJSON(router, token, "/api/admin/uploads/presign", map[string]any{"contentType": contentType, "size": size})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var resp struct {
+		Data struct {
+			Key   string `json:"key"`
+			Token string `json:"token"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.NotEmpty(t, resp.Data.Token)
+	return resp.Data.Key, resp.Data.Token
+}
+
+func TestAdminUploadsCompleteRegistersVerifiedObject(t *testing.T) {
+	pdf := []byte("%PDF-1.7\n1 0 obj\n<<>>\nendobj\n")
+	store := &directStorageStub{data: map[string][]byte{}}
+	recorder := &mediaRecorderStub{}
+	router, token := setupDirectUploadRouter(t, store, recorder)
+	key, uploadToken := presignUpload(t, router, token, "application/pdf", len(pdf))
+	store.data[key] = pdf
+
+	rec := postJSON(router, token, "/api/admin/uploads/complete", map[string]any{"key": key, "contentType": "application/pdf", "size": len(pdf), "token": uploadToken})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var resp struct {
+		Data struct {
+			ID  string `json:"id"`
+			URL string `json:"url"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, "33333333-3333-3333-3333-333333333333", resp.Data.ID)
+	require.Equal(t, "https://cdn.example.com/"+key, resp.Data.URL)
+	require.Equal(t, key, recorder.upload.Key)
+	require.Equal(t, int64(len(pdf)), recorder.upload.SizeBytes)
+	require.NotNil(t, recorder.upload.UploadedBy)
+	require.Empty(t, store.deleted)
+}
+
+func TestAdminUploadsCompleteRejectsMismatchedObjects(t *testing.T) {
+	html := []byte("<html><script>alert(1)</script></html>")
+	store := &directStorageStub{data: map[string][]byte{}}
+	router, token := setupDirectUploadRouter(t, store, &mediaRecorderStub{})
+
+	key, uploadToken := presignUpload(t, router, token, "image/png", 10)
+	store.data[key] = html
+	rec := postJSON(router, token, "/api/admin/uploads/complete", map[string]any{"key": key, "contentType": "image/png", "size": 10, "token": uploadToken})
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Equal(t, []string{key}, store.deleted)
+
+	key, uploadToken = presignUpload(t, router, token, "image/png", len(html))
+	store.data[key] = html
+	store.deleted = nil
+	rec = postJSON(router, token, "/api/admin/uploads/complete", map[string]any{"key": key, "contentType": "image/png", "size": len(html), "token": uploadToken})
+	require.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+	require.Equal(t, []string{key}, store.deleted)
+
+	rec = postJSON(router, token, "/api/admin/uploads/complete", map[string]any{"key": key, "contentType": "image/png", "size": len(html), "token": uploadToken})
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = postJSON(router, token, "/api/admin/uploads/complete", map[string]any{"key": "profile/secret.png", "contentType": "image/png", "size": 10, "token": uploadToken})
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestAdminUploadsCompleteRequiresIssuedToken(t *testing.T) {
+	live := "uploads/2025/02/12/0f8fad5b-d9cb-469f-a165-70867728950e.png"
+	store := &directStorageStub{data: map[string][]byte{live: []byte("live media of another tenant")}}
+	recorder := &mediaRecorderStub{registered: map[string]bool{}}
+	router, token := setupDirectUploadRouter(t, store, recorder)
+	key, uploadToken := presignUpload(t, router, token, "image/png", 10)
+
+	// A token only covers the key, type and size it was issued for.
+	for _, payload := range []map[string]any{
+		{"key": live, "contentType": "image/png", "size": 10},
+		{"key": live, "contentType": "image/png", "size": 10, "token": uploadToken},
+		{"key": key, "contentType": "image/png", "size": 11, "token": uploadToken},
+		{"key": key, "contentType": "image/png", "size": 10, "token": "9999999999.forged"},
+	} {
+		rec := postJSON(router, token, "/api/admin/uploads/complete", payload)
+		require.Contains(t, []int{http.StatusBadRequest, http.StatusForbidden}, rec.Code, rec.Body.String())
+	}
+
+	recorder.registered[key] = true
+	store.data[key] = []byte("not a png")
+	rec := postJSON(router, token, "/api/admin/uploads/complete", map[string]any{"key": key, "contentType": "image/png", "size": 1, "token": uploadToken})
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	rec = postJSON(router, token, "/api/admin/uploads/complete", map[string]any{"key": key, "contentType": "image/png", "size": 10, "token": uploadToken})
+	require.Equal(t, http.StatusConflict, rec.Code)
+
+	require.Empty(t, store.deleted)
+	require.Contains(t, store.data, live)
+}
+
+// eicarClamd serves the clamd INSTREAM protocol and flags streams holding the EICAR test
+// signature.
+func eicarClamd(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				if _, err := reader.ReadString(0); err != nil {
+					return
+				}
+				var stream bytes.Buffer
+				for {
+					var size uint32
+					if err := binary.Read(reader, binary.BigEndian, &size); err != nil || size == 0 {
+						break
+					}
+					if _, err := io.CopyN(&stream, reader, int64(size)); err != nil {
+						return
+					}
+				}
+				reply := "stream: OK"
+				if bytes.Contains(stream.Bytes(), []byte("EICAR-STANDARD-ANTIVIRUS-TEST-FILE")) {
+					reply = "stream: Eicar-Signature FOUND"
+				}
+				_, _ = conn.Write([]byte(reply + "\x00"))
+			}(conn)
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestAdminUploadsCompleteScansObject(t *testing.T) {
+	eicar := []byte("%PDF-1.7\n" + `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`)
+	store := &directStorageStub{data: map[string][]byte{}}
+	recorder := &mediaRecorderStub{}
+	router, token := setupScannedDirectUploadRouter(t, store, recorder, config.ScanConfig{ClamdAddr: eicarClamd(t), ClamdTimeout: time.Second})
+
+	key, uploadToken := presignUpload(t, router, token, "application/pdf", len(eicar))
+	store.data[key] = eicar
+	rec := postJSON(router, token, "/api/admin/uploads/complete", map[string]any{"key": key, "contentType": "application/pdf", "size": len(eicar), "token": uploadToken})
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code, rec.Body.String())
+	require.Contains(t, rec.Body.String(), "Eicar-Signature")
+	require.Equal(t, []string{key}, store.deleted)
+	require.Empty(t, recorder.upload.Key)
+
+	pdf := []byte("%PDF-1.7\n1 0 obj\n<<>>\nendobj\n")
+	key, uploadToken = presignUpload(t, router, token, "application/pdf", len(pdf))
+	store.data[key] = pdf
+	rec = postJSON(router, token, "/api/admin/uploads/complete", map[string]any{"key": key, "contentType": "application/pdf", "size": len(pdf), "token": uploadToken})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	require.Contains(t, rec.Body.String(), `"status":"clean"`)
+	require.Equal(t, key, recorder.upload.Key)
+}
+
+func TestAdminUploadsCompleteFailsClosedWhenScannerIsDown(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	unreachable := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	pdf := []byte("%PDF-1.7\n1 0 obj\n<<>>\nendobj\n")
+	store := &directStorageStub{data: map[string][]byte{}}
+	router, token := setupScannedDirectUploadRouter(t, store, &mediaRecorderStub{}, config.ScanConfig{ClamdAddr: unreachable, ClamdTimeout: time.Second})
+
+	key, uploadToken := presignUpload(t, router, token, "application/pdf", len(pdf))
+	store.data[key] = pdf
+	rec := postJSON(router, token, "/api/admin/uploads/complete", map[string]any{"key": key, "contentType": "application/pdf", "size": len(pdf), "token": uploadToken})
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code, rec.Body.String())
+	require.Equal(t, []string{key}, store.deleted)
+}
@@ -3,12 +3,18 @@ package handlers
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/jpeg"
 	"io"
 	"log"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/textproto"
+	"strings"
 	"testing"
 	"time"
 
@@ -19,6 +25,7 @@ import (
 	"github.com/tanydotai/tanyai/backend/internal/config"
 	admin "github.com/tanydotai/tanyai/backend/internal/handlers/admin"
 	"github.com/tanydotai/tanyai/backend/internal/middleware"
+	"github.com/tanydotai/tanyai/backend/internal/models"
 	"github.com/tanydotai/tanyai/backend/internal/storage"
 )
 
@@ -32,12 +39,17 @@ type storageStub struct {
 	lastKey    string
 	lastType   string
 	lastObject []byte
+	objects    map[string]string
 }
 
 func (s *storageStub) Put(_ context.Context, key string, content []byte, contentType string) (string, error) {
 	s.lastKey = key
 	s.lastType = contentType
 	s.lastObject = append([]byte(nil), content...)
+	if s.objects == nil {
+		s.objects = map[string]string{}
+	}
+	s.objects[key] = contentType
 	if s.err != nil {
 		return "", s.err
 	}
@@ -47,6 +59,29 @@ func (s *storageStub) Put(_ context.Context, key string, content []byte, content
 	return "https://cdn.example.com/" + key, nil
 }
 
+func (s *storageStub) Delete(_ context.Context, key string) error {
+	delete(s.objects, key)
+	return nil
+}
+
+func (s *storageStub) List(_ context.Context, prefix string) ([]storage.ObjectInfo, error) {
+	var objects []storage.ObjectInfo
+	for key, contentType := range s.objects {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, storage.ObjectInfo{Key: key, ContentType: contentType})
+		}
+	}
+	return objects, nil
+}
+
+func (s *storageStub) Stat(_ context.Context, key string) (storage.ObjectInfo, error) {
+	contentType, ok := s.objects[key]
+	if !ok {
+		return storage.ObjectInfo{}, storage.ErrNotFound
+	}
+	return storage.ObjectInfo{Key: key, ContentType: contentType}, nil
+}
+
 func TestAdminUploadsUnauthorized(t *testing.T) {
 	router, _ := setupUploadRouter(t, &storageStub{}, defaultUploadPolicy(), nil)
 
@@ -151,6 +186,190 @@ func TestAdminUploadsSuccess(t *testing.T) {
 	require.NotEmpty(t, store.lastObject)
 }
 
+func TestAdminUploadsProcessesImages(t *testing.T) {
+	policy := defaultUploadPolicy()
+	policy.Image = config.ImageConfig{
+		Enabled:  true,
+		Variants: []config.ImageVariantConfig{{Name: "thumb", Width: 16}, {Name: "large", Width: 200}},
+	}
+	store := &storageStub{}
+	router, tokens := setupUploadRouter(t, store, policy, nil)
+
+	token := mustAdminToken(t, tokens)
+	body, boundary := multipartBody(t, "file", "cover.jpg", jpegBytes(t, 64, 32), "image/jpeg")
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/uploads", body)
+	req.Header.Set("Content-Type", "multipart/form-data; boundary="+boundary)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var resp struct {
+		Data struct {
+			Key    string          `json:"key"`
+			Width  int             `json:"width"`
+			Height int             `json:"height"`
+			Image  models.ImageSet `json:"image"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, 64, resp.Data.Width)
+	require.Equal(t, 32, resp.Data.Height)
+	require.Len(t, resp.Data.Image.Blurhash, 28)
+	require.Regexp(t, `^#[0-9a-f]{6}$`, resp.Data.Image.DominantColor)
+	require.Len(t, resp.Data.Image.Variants, 2)
+
+	base := strings.TrimSuffix(resp.Data.Key, ".jpg")
+	thumb := resp.Data.Image.Variants[0]
+	require.Equal(t, "thumb", thumb.Name)
+	require.Equal(t, 16, thumb.Width)
+	require.Equal(t, 8, thumb.Height)
+	require.Equal(t, "https://cdn.example.com/"+base+"_thumb.jpg", thumb.URL)
+	require.Equal(t, "image/jpeg", store.objects[base+"_thumb.jpg"])
+	require.Equal(t, 64, resp.Data.Image.Variants[1].Width)
+	require.Equal(t, "image/jpeg", store.objects[resp.Data.Key])
+}
+
+type mediaRecorderStub struct {
+	upload     models.Upload
+	variants   []models.Upload
+	registered map[string]bool
+}
+
+func (m *mediaRecorderStub) Registered(_ context.Context, key string) (bool, error) {
+	return m.registered[key], nil
+}
+
+func (m *mediaRecorderStub) Create(_ context.Context, upload models.Upload, variants []models.Upload) (models.Upload, error) {
+	m.upload = upload
+	m.variants = variants
+	upload.ID = uuid.MustParse("33333333-3333-3333-3333-333333333333")
+	return upload, nil
+}
+
+func TestAdminUploadsRecordsMedia(t *testing.T) {
+	policy := defaultUploadPolicy()
+	policy.Image = config.ImageConfig{Enabled: true, Variants: []config.ImageVariantConfig{{Name: "thumb", Width: 16}}}
+	tokenService, err := appauth.NewTokenService("this_is_a_super_secret_for_tests_1234567890", time.Hour, time.Hour)
+	require.NoError(t, err)
+
+	recorder := &mediaRecorderStub{}
+	handler := admin.NewUploadsHandler(&storageStub{}, policy, log.New(io.Discard, "", 0))
+	handler.SetMediaRecorder(recorder)
+	router := gin.New()
+	router.POST("/api/admin/uploads", middleware.Authn(tokenService, nil), middleware.AuthzAdmin(), handler.Create)
+
+	body, boundary := multipartBody(t, "file", "cover.jpg", jpegBytes(t, 64, 32), "image/jpeg")
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/uploads", body)
+	req.Header.Set("Content-Type", "multipart/form-data; boundary="+boundary)
+	req.Header.Set("Authorization", "Bearer "+mustAdminToken(t, tokenService))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var resp struct {
+		Data struct {
+			ID  string `json:"id"`
+			Key string `json:"key"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, "33333333-3333-3333-3333-333333333333", resp.Data.ID)
+	require.Equal(t, resp.Data.Key, recorder.upload.Key)
+	require.Equal(t, "image/jpeg", recorder.upload.ContentType)
+	require.NotNil(t, recorder.upload.UploadedBy)
+	require.Len(t, recorder.variants, 1)
+	require.Equal(t, strings.TrimSuffix(resp.Data.Key, ".jpg")+"_thumb.jpg", recorder.variants[0].Key)
+}
+
+func TestAdminUploadsRejectsCorruptImage(t *testing.T) {
+	policy := defaultUploadPolicy()
+	policy.Image = config.ImageConfig{Enabled: true}
+	store := &storageStub{}
+	router, tokens := setupUploadRouter(t, store, policy, nil)
+
+	token := mustAdminToken(t, tokens)
+	body, boundary := multipartBody(t, "file", "broken.png", pngBytes()[:40], "image/png")
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/uploads", body)
+	req.Header.Set("Content-Type", "multipart/form-data; boundary="+boundary)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Empty(t, store.objects)
+}
+
+func postUpload(t *testing.T, router *gin.Engine, token, filename string, content []byte, contentType string) *httptest.ResponseRecorder {
+	t.Helper()
+	body, boundary := multipartBody(t, "file", filename, content, contentType)
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/uploads", body)
+	req.Header.Set("Content-Type", "multipart/form-data; boundary="+boundary)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAdminUploadsScannerRejectsPolyglot(t *testing.T) {
+	policy := defaultUploadPolicy()
+	policy.Scan = config.ScanConfig{Builtin: true}
+	store := &storageStub{}
+	router, tokens := setupUploadRouter(t, store, policy, nil)
+	token := mustAdminToken(t, tokens)
+
+	polyglot := append(pngBytes(), []byte("PK\x03\x04payload.jar")...)
+	rec := postUpload(t, router, token, "cat.png", polyglot, "image/png")
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code, rec.Body.String())
+	require.Contains(t, rec.Body.String(), "Polyglot.ZIP")
+	require.Empty(t, store.objects)
+
+	rec = postUpload(t, router, token, "cat.png", pngBytes(), "image/png")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var resp struct {
+		Data struct {
+			Scan struct {
+				Status  string `json:"status"`
+				Results []struct {
+					Scanner string `json:"scanner"`
+				} `json:"results"`
+			} `json:"scan"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, "clean", resp.Data.Scan.Status)
+	require.Equal(t, "content", resp.Data.Scan.Results[0].Scanner)
+}
+
+func TestAdminUploadsScannerFailureModes(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	unreachable := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	policy := defaultUploadPolicy()
+	policy.Scan = config.ScanConfig{ClamdAddr: unreachable, ClamdTimeout: time.Second}
+	store := &storageStub{}
+	router, tokens := setupUploadRouter(t, store, policy, nil)
+	token := mustAdminToken(t, tokens)
+
+	rec := postUpload(t, router, token, "cat.png", pngBytes(), "image/png")
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code, rec.Body.String())
+	require.Empty(t, store.objects)
+
+	policy.Scan.FailOpen = true
+	router, tokens = setupUploadRouter(t, store, policy, nil)
+	rec = postUpload(t, router, mustAdminToken(t, tokens), "cat.png", pngBytes(), "image/png")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	require.Contains(t, rec.Body.String(), `"status":"unscanned"`)
+	require.Len(t, store.objects, 1)
+}
+
 func TestAdminUploadsSvgSanitized(t *testing.T) {
 	policy := defaultUploadPolicy()
 	policy.AllowSVG = true
@@ -227,7 +446,7 @@ func setupUploadRouter(t *testing.T, store storage.ObjectStorage, policy config.
 	handler := admin.NewUploadsHandler(store, policy, log.New(io.Discard, "", 0))
 
 	router := gin.New()
-	group := router.Group("/api/admin", middleware.Authn(tokenService), middleware.AuthzAdmin())
+	group := router.Group("/api/admin", middleware.Authn(tokenService, nil), middleware.AuthzAdmin())
 	group.POST("/uploads", middleware.RateLimitByIP(limiter), handler.Create)
 	return router, tokenService
 }
@@ -275,6 +494,19 @@ func pngBytes() []byte {
 	}, []byte{}...)
 }
 
+func jpegBytes(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 4), G: uint8(y * 8), B: 90, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, nil))
+	return buf.Bytes()
+}
+
 func mustAdminToken(t *testing.T, tokens *appauth.TokenService) string {
 	t.Helper()
 	token, err := tokens.GenerateAccessToken(appauth.Subject{ID: uuidFromString(t, "11111111-1111-1111-1111-111111111111"), Email: "admin@example.com", Roles: []string{"admin"}})
@@ -13,12 +13,12 @@ export type ChatApiResponse = {
   chatId: string;
   answer: string;
   model: string;
-  prompt: string;
+  prompt?: string;
 };
 
 export type AssistantReply = {
   chatId: string;
-  prompt: string;
+  prompt?: string;
   model: string;
   message: ChatMessage;
 };